@@ -31,6 +31,10 @@ import (
 // startTime records when the app started (initialized when package loads)
 var startTime = time.Now()
 
+// migrationDrainTimeout bounds how long shutdown waits for running
+// migrations to finish their current item.
+const migrationDrainTimeout = 30 * time.Second
+
 var serveCmd = &cobra.Command{
 	Use:   "serve",
 	Short: "Start the Supabase Lite server",
@@ -149,7 +153,7 @@ var serveCmd = &cobra.Command{
 		migrationsDir, _ := cmd.Flags().GetString("migrations-dir")
 
 		// Build storage configuration (pass db for dashboard settings)
-		storageConfig := buildStorageConfig(cmd, database.DB)
+		storageConfig := buildStorageConfig(cmd, database.DB, jwtSecret)
 
 		// Static file serving configuration
 		staticDir, _ := cmd.Flags().GetString("static-dir")
@@ -157,12 +161,46 @@ var serveCmd = &cobra.Command{
 			staticDir = envStaticDir
 		}
 
+		// Request body size limits (MB; 0 = default, negative = unlimited)
+		maxBodySize := megabytesSetting(cmd, "max-body-size", "SBLITE_MAX_BODY_SIZE")
+		maxUploadSize := megabytesSetting(cmd, "max-upload-size", "SBLITE_MAX_UPLOAD_SIZE")
+
+		// Dashboard upload spooling: files above the memory threshold go to a temp dir
+		uploadMemory := megabytesSetting(cmd, "upload-memory-threshold", "SBLITE_UPLOAD_MEMORY_THRESHOLD")
+		uploadTempDir, _ := cmd.Flags().GetString("upload-temp-dir")
+		if envTempDir := os.Getenv("SBLITE_UPLOAD_TEMP_DIR"); envTempDir != "" && !cmd.Flags().Changed("upload-temp-dir") {
+			uploadTempDir = envTempDir
+		}
+
+		// Dashboard mount path, e.g. /admin when a proxy serves the dashboard there
+		dashboardPath, _ := cmd.Flags().GetString("dashboard-path")
+		if envDashboardPath := os.Getenv("SBLITE_DASHBOARD_PATH"); envDashboardPath != "" && !cmd.Flags().Changed("dashboard-path") {
+			dashboardPath = envDashboardPath
+		}
+		dashboardPath, err = server.NormalizeDashboardPath(dashboardPath)
+		if err != nil {
+			return err
+		}
+		disableImpersonation, _ := cmd.Flags().GetBool("disable-impersonation")
+		if env := os.Getenv("SBLITE_DISABLE_IMPERSONATION"); env != "" && !cmd.Flags().Changed("disable-impersonation") {
+			disableImpersonation = env == "true" || env == "1"
+		}
+		dashboardTimeout := secondsSetting(cmd, "dashboard-timeout", "SBLITE_DASHBOARD_TIMEOUT")
+
 		srv := server.NewWithConfig(database, server.ServerConfig{
 			JWTSecret:     jwtSecret,
 			MailConfig:    mailConfig,
 			MigrationsDir: migrationsDir,
 			StorageConfig: storageConfig,
 			StaticDir:     staticDir,
+			MaxBodySize:   maxBodySize,
+			MaxUploadSize: maxUploadSize,
+			UploadTempDir: uploadTempDir,
+			UploadMemory:  uploadMemory,
+			DashboardPath: dashboardPath,
+
+			DisableImpersonation: disableImpersonation,
+			DashboardTimeout:     dashboardTimeout,
 		})
 
 		// Set telemetry on server BEFORE setting up routes
@@ -192,6 +230,11 @@ var serveCmd = &cobra.Command{
 			JWTSecret:    jwtSecret,
 		})
 		srv.SetMigrationService(migrationSvc)
+		if n, err := migrationSvc.RecoverInterrupted(); err != nil {
+			log.Warn("failed to recover interrupted migrations", "error", err)
+		} else if n > 0 {
+			log.Warn("marked migrations interrupted by the last shutdown", "count", n)
+		}
 
 		addr := fmt.Sprintf("%s:%d", host, port)
 		log.Info("starting server",
@@ -267,6 +310,9 @@ var serveCmd = &cobra.Command{
 		// Start TUS cleanup routine for expired resumable uploads
 		srv.StartTUSCleanup(ctx)
 
+		// Deliver queued storage events to registered webhooks
+		srv.StartStorageWebhooks(ctx)
+
 		// Start PostgreSQL wire protocol server if requested
 		pgPort, _ := cmd.Flags().GetInt("pg-port")
 		var pgServer *pgwire.Server
@@ -323,21 +369,26 @@ var serveCmd = &cobra.Command{
 		}
 
 		// Handle graceful shutdown for all modes
+		shutdownDone := make(chan struct{})
 		go func() {
+			defer close(shutdownDone)
 			sigCh := make(chan os.Signal, 1)
 			signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 			<-sigCh
 			log.Info("shutting down...")
 
+			// Stop accepting migration runs and let active ones reach the end
+			// of their current item; unfinished ones are marked interrupted
+			drainCtx, drainCancel := context.WithTimeout(context.Background(), migrationDrainTimeout)
+			if err := migrationSvc.Shutdown(drainCtx); err != nil {
+				log.Warn("migration shutdown error", "error", err)
+			}
+			drainCancel()
+
 			// Create a timeout context for shutdown
 			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer shutdownCancel()
 
-			// Stop functions first if enabled
-			if functionsEnabled {
-				srv.StopFunctions()
-			}
-
 			// Shutdown pgwire server if enabled
 			if pgServer != nil {
 				if err := pgServer.Shutdown(shutdownCtx); err != nil {
@@ -350,6 +401,13 @@ var serveCmd = &cobra.Command{
 				log.Warn("HTTP server shutdown error", "error", err)
 			}
 
+			// Stop the edge runtime once in-flight function requests are done
+			if functionsEnabled {
+				if err := srv.StopFunctions(); err != nil {
+					log.Warn("edge runtime shutdown error", "error", err)
+				}
+			}
+
 			cancel()
 		}()
 
@@ -398,10 +456,48 @@ var serveCmd = &cobra.Command{
 				return err
 			}
 		}
+		// ListenAndServe returns as soon as shutdown starts; wait for the
+		// rest of it before exiting
+		<-shutdownDone
 		return nil
 	},
 }
 
+// megabytesSetting reads a size in megabytes from a flag or environment variable
+// and returns it in bytes. Priority: CLI flag > environment variable > 0 (default).
+func megabytesSetting(cmd *cobra.Command, flag, env string) int64 {
+	mb, _ := cmd.Flags().GetInt(flag)
+	if !cmd.Flags().Changed(flag) {
+		if v := os.Getenv(env); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				mb = n
+			}
+		}
+	}
+	if mb < 0 {
+		return -1
+	}
+	return int64(mb) << 20
+}
+
+// secondsSetting reads a duration in seconds from a flag, falling back to
+// an environment variable when the flag isn't set. Negative values map to
+// -1 ("none"), 0 keeps the default.
+func secondsSetting(cmd *cobra.Command, flag, env string) time.Duration {
+	secs, _ := cmd.Flags().GetInt(flag)
+	if !cmd.Flags().Changed(flag) {
+		if v := os.Getenv(env); v != "" {
+			if n, err := strconv.Atoi(v); err == nil {
+				secs = n
+			}
+		}
+	}
+	if secs < 0 {
+		return -1
+	}
+	return time.Duration(secs) * time.Second
+}
+
 // buildMailConfig creates a mail.Config from environment variables and CLI flags.
 // Priority: CLI flags > environment variables > defaults
 func buildMailConfig(cmd *cobra.Command) *mail.Config {
@@ -431,6 +527,11 @@ func buildMailConfig(cmd *cobra.Command) *mail.Config {
 	if smtpPass := os.Getenv("SBLITE_SMTP_PASS"); smtpPass != "" {
 		cfg.SMTPPass = smtpPass
 	}
+	if rate := os.Getenv("SBLITE_MAIL_RATE"); rate != "" {
+		if n, err := strconv.Atoi(rate); err == nil {
+			cfg.RatePerMinute = n
+		}
+	}
 
 	// CLI flags override environment variables
 	if mailMode, _ := cmd.Flags().GetString("mail-mode"); mailMode != "" {
@@ -442,6 +543,9 @@ func buildMailConfig(cmd *cobra.Command) *mail.Config {
 	if siteURL, _ := cmd.Flags().GetString("site-url"); siteURL != "" {
 		cfg.SiteURL = siteURL
 	}
+	if rate, _ := cmd.Flags().GetInt("mail-rate"); rate > 0 {
+		cfg.RatePerMinute = rate
+	}
 
 	return cfg
 }
@@ -490,6 +594,15 @@ func buildLogConfig(cmd *cobra.Command) *log.Config {
 			cfg.BufferLines = v
 		}
 	}
+	if level := os.Getenv("SBLITE_ACCESS_LOG_LEVEL"); level != "" {
+		cfg.AccessLogLevel = level
+	}
+	if skip := os.Getenv("SBLITE_ACCESS_LOG_SKIP_STATIC"); skip != "" {
+		cfg.AccessLogSkipStatic = skip == "true" || skip == "1"
+	}
+	if exclude := os.Getenv("SBLITE_ACCESS_LOG_EXCLUDE"); exclude != "" {
+		cfg.AccessLogExclude = strings.Split(exclude, ",")
+	}
 
 	// CLI flags override environment variables
 	if mode, _ := cmd.Flags().GetString("log-mode"); mode != "" {
@@ -522,6 +635,15 @@ func buildLogConfig(cmd *cobra.Command) *log.Config {
 	if bufferLines, _ := cmd.Flags().GetInt("log-buffer-lines"); cmd.Flags().Changed("log-buffer-lines") {
 		cfg.BufferLines = bufferLines
 	}
+	if level, _ := cmd.Flags().GetString("access-log-level"); level != "" {
+		cfg.AccessLogLevel = level
+	}
+	if skip, _ := cmd.Flags().GetBool("access-log-skip-static"); cmd.Flags().Changed("access-log-skip-static") {
+		cfg.AccessLogSkipStatic = skip
+	}
+	if exclude, _ := cmd.Flags().GetStringSlice("access-log-exclude"); len(exclude) > 0 {
+		cfg.AccessLogExclude = exclude
+	}
 
 	return cfg
 }
@@ -546,6 +668,7 @@ func buildOTelConfig(cmd *cobra.Command) *observability.Config {
 			cfg.SampleRate = rate
 		}
 	}
+	routeSampling := os.Getenv("SBLITE_OTEL_ROUTE_SAMPLING")
 
 	// CLI flags override environment variables
 	if exporter, _ := cmd.Flags().GetString("otel-exporter"); exporter != "" {
@@ -562,6 +685,17 @@ func buildOTelConfig(cmd *cobra.Command) *observability.Config {
 			cfg.SampleRate = sampleRate
 		}
 	}
+	if cmd.Flags().Changed("otel-route-sampling") {
+		routeSampling, _ = cmd.Flags().GetString("otel-route-sampling")
+	}
+	if routeSampling != "" {
+		routes, err := observability.ParseRouteSampling(routeSampling)
+		if err != nil {
+			log.Warn("ignoring invalid route sampling", "error", err)
+		} else {
+			cfg.RouteSampling = routes
+		}
+	}
 
 	// Enable metrics/traces if exporter is set (unless explicitly disabled)
 	if cfg.ShouldEnable() {
@@ -586,7 +720,8 @@ func buildOTelConfig(cmd *cobra.Command) *observability.Config {
 
 // buildStorageConfig creates a storage.Config from dashboard settings, environment variables, and CLI flags.
 // Priority: Dashboard settings > CLI flags > environment variables > defaults
-func buildStorageConfig(cmd *cobra.Command, db *sql.DB) *storage.Config {
+// Stored credentials are decrypted with jwtSecret.
+func buildStorageConfig(cmd *cobra.Command, db *sql.DB, jwtSecret string) *storage.Config {
 	cfg := &storage.Config{
 		Backend:   "local",
 		LocalPath: "./storage",
@@ -665,8 +800,12 @@ func buildStorageConfig(cmd *cobra.Command, db *sql.DB) *storage.Config {
 		if s3AccessKey, _ := store.Get("storage_s3_access_key"); s3AccessKey != "" {
 			cfg.S3AccessKey = s3AccessKey
 		}
-		if s3SecretKey, _ := store.Get("storage_s3_secret_key"); s3SecretKey != "" {
-			cfg.S3SecretKey = s3SecretKey
+		if stored, _ := store.Get("storage_s3_secret_key"); stored != "" {
+			if s3SecretKey, err := dashboard.DecryptSetting(jwtSecret, stored); err == nil {
+				cfg.S3SecretKey = s3SecretKey
+			} else {
+				log.Warn("failed to decrypt stored S3 secret key", "error", err)
+			}
 		}
 		if s3PathStyle, _ := store.Get("storage_s3_path_style"); s3PathStyle == "true" {
 			cfg.S3ForcePathStyle = true
@@ -698,6 +837,7 @@ func init() {
 	serveCmd.Flags().String("mail-mode", "", "Email mode: log, catch, or smtp (default: log)")
 	serveCmd.Flags().String("mail-from", "", "Default sender email address")
 	serveCmd.Flags().String("site-url", "", "Base URL for email links")
+	serveCmd.Flags().Int("mail-rate", 0, "Max SMTP emails sent per minute from the outbound queue (default: 60)")
 
 	// Logging flags
 	serveCmd.Flags().String("log-mode", "", "Logging output: console, file, database (default: console)")
@@ -710,6 +850,9 @@ func init() {
 	serveCmd.Flags().Int("log-max-backups", 0, "Max backup files to keep (default: 3)")
 	serveCmd.Flags().StringSlice("log-fields", nil, "DB log fields: source,request_id,user_id,extra")
 	serveCmd.Flags().Int("log-buffer-lines", 500, "Number of log lines to keep in memory buffer (0 to disable)")
+	serveCmd.Flags().String("access-log-level", "", "Level for successful request log lines: debug, info, or off (default: info)")
+	serveCmd.Flags().Bool("access-log-skip-static", false, "Don't log successful static asset requests")
+	serveCmd.Flags().StringSlice("access-log-exclude", nil, "Path prefixes whose successful requests aren't logged")
 
 	// Edge functions flags
 	serveCmd.Flags().Bool("functions", false, "Enable edge functions support")
@@ -732,11 +875,23 @@ func init() {
 	// Static file serving flags
 	serveCmd.Flags().String("static-dir", "./public", "Directory for static file hosting")
 
+	// Dashboard flags
+	serveCmd.Flags().String("dashboard-path", server.DefaultDashboardPath, "Path the dashboard is served under")
+	serveCmd.Flags().Bool("disable-impersonation", false, "Disable minting user access tokens from the dashboard")
+	serveCmd.Flags().Int("dashboard-timeout", 0, "Dashboard API request timeout in seconds (default: 30, -1 = none)")
+
+	// Request body limit flags
+	serveCmd.Flags().Int("max-body-size", 0, "Max request body size in MB (default: 10, -1 = unlimited)")
+	serveCmd.Flags().Int("max-upload-size", 0, "Max body size in MB for storage uploads and functions (default: 50, -1 = unlimited)")
+	serveCmd.Flags().String("upload-temp-dir", "", "Directory for spooling large dashboard uploads (default: system temp dir)")
+	serveCmd.Flags().Int("upload-memory-threshold", 0, "Upload size in MB kept in memory before spooling to disk (default: 8, -1 = always spool)")
+
 	// OpenTelemetry flags
 	serveCmd.Flags().String("otel-exporter", "", "OpenTelemetry exporter: none (default), stdout, otlp")
 	serveCmd.Flags().String("otel-endpoint", "", "OpenTelemetry OTLP endpoint (default: localhost:4317)")
 	serveCmd.Flags().String("otel-service-name", "", "OpenTelemetry service name (default: sblite)")
 	serveCmd.Flags().Float64("otel-sample-rate", 0.1, "OpenTelemetry trace sampling rate 0.0-1.0 (default: 0.1)")
+	serveCmd.Flags().String("otel-route-sampling", "", "Per-route sampling overrides as prefix=rate pairs, e.g. /_/static=0,/rest/v1=1")
 	serveCmd.Flags().Bool("otel-metrics-enabled", true, "Enable OpenTelemetry metrics")
 	serveCmd.Flags().Bool("otel-traces-enabled", true, "Enable OpenTelemetry traces")
 }
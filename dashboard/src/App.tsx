@@ -1,6 +1,7 @@
 import { BrowserRouter, Routes, Route, Navigate, Outlet } from "react-router-dom"
 import { AuthProvider, useAuth } from "@/contexts/AuthContext"
 import { ThemeProvider } from "@/contexts/ThemeContext"
+import { DASHBOARD_BASE } from "@/lib/base"
 import { Toaster } from "sonner"
 
 // Layout components
@@ -41,7 +42,7 @@ function App() {
   return (
     <ThemeProvider>
       <AuthProvider>
-        <BrowserRouter basename="/_/">
+        <BrowserRouter basename={`${DASHBOARD_BASE}/`}>
           <Routes>
           {/* Auth routes */}
           <Route element={<AuthLayout />}>
@@ -4,6 +4,7 @@
  */
 
 import { createContext, useContext, useState, useEffect, ReactNode } from 'react'
+import { API_BASE } from '@/lib/base'
 
 interface AuthState {
   authenticated: boolean
@@ -30,7 +31,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   const checkStatus = async () => {
     setState((prev) => ({ ...prev, loading: true }))
     try {
-      const res = await fetch('/_/api/auth/status')
+      const res = await fetch(`${API_BASE}/auth/status`)
       if (res.ok) {
         const data = await res.json()
         setState({
@@ -49,7 +50,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   const login = async (password: string) => {
     setState((prev) => ({ ...prev, loading: true }))
     try {
-      const res = await fetch('/_/api/auth/login', {
+      const res = await fetch(`${API_BASE}/auth/login`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ password }),
@@ -68,7 +69,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   const setup = async (password: string) => {
     setState((prev) => ({ ...prev, loading: true }))
     try {
-      const res = await fetch('/_/api/auth/setup', {
+      const res = await fetch(`${API_BASE}/auth/setup`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ password }),
@@ -87,7 +88,7 @@ export function AuthProvider({ children }: { children: ReactNode }) {
   const logout = async () => {
     setState((prev) => ({ ...prev, loading: true }))
     try {
-      await fetch('/_/api/auth/logout', { method: 'POST' })
+      await fetch(`${API_BASE}/auth/logout`, { method: 'POST' })
       setState({ authenticated: false, needsSetup: false, loading: false })
     } finally {
       setState((prev) => ({ ...prev, loading: false }))
@@ -3,7 +3,8 @@
  * Type-safe API client for all dashboard endpoints
  */
 
-const API_BASE = '/_/api'
+import { API_BASE } from './base'
+import { getCSRFToken } from './csrf'
 
 /**
  * Helper to make API requests with proper error handling
@@ -87,6 +88,11 @@ export const authApi = {
 export const tablesApi = {
   list: () => request<string[]>('/tables'),
 
+  pkAudit: () => request<{
+    tables: Array<{ table: string; has_primary_key: boolean; primary_key: string[]; candidates?: string[]; suggestion?: string }>
+    missing: number
+  }>('/tables/pk-audit'),
+
   get: (name: string) => request<{ name: string; description: string; columns: unknown }>(`/tables/${name}`),
 
   create: (data: {
@@ -100,16 +106,31 @@ export const tablesApi = {
   setDescription: (name: string, description: string) =>
     patch<void>(`/tables/${name}/description`, { description }),
 
+  getDDL: (name: string, dialect: 'sqlite' | 'postgres' = 'sqlite') =>
+    request<{ table: string; dialect: string; sql: string }>(`/tables/${name}/ddl?dialect=${dialect}`),
+
   // Columns
   addColumn: (tableName: string, data: { name: string; type: string; nullable?: boolean }) =>
     post<void>(`/tables/${tableName}/columns`, data),
 
+  addColumns: (tableName: string, columns: Array<{ name: string; type: string; nullable?: boolean; default?: string }>) =>
+    post<void>(`/tables/${tableName}/columns/batch`, { columns }),
+
   renameColumn: (tableName: string, columnName: string, newName: string) =>
     patch<void>(`/tables/${tableName}/columns/${columnName}`, { name: newName }),
 
   deleteColumn: (tableName: string, columnName: string) =>
     del<void>(`/tables/${tableName}/columns/${columnName}`),
 
+  moveColumn: (tableName: string, columnName: string, position: number) =>
+    patch<{ columns: string[] }>(`/tables/${tableName}/columns/${columnName}/position`, { position }),
+
+  getColumnEnum: (tableName: string, columnName: string) =>
+    request<{ table: string; column: string; enum_values: string[] | null }>(`/tables/${tableName}/columns/${columnName}/enum`),
+
+  setColumnEnum: (tableName: string, columnName: string, enumValues: string[] | null) =>
+    put<{ table: string; column: string; enum_values: string[] | null }>(`/tables/${tableName}/columns/${columnName}/enum`, { enum_values: enumValues }),
+
   // FTS
   listFts: (tableName: string) => request<Array<{ name: string; columns: string[] }>>(`/tables/${tableName}/fts`),
 
@@ -161,6 +182,24 @@ export const dataApi = {
     }),
 }
 
+// ============================================================================
+// Views API
+// ============================================================================
+
+export interface ViewInfo {
+  name: string
+  type: 'view'
+  sql: string
+  columns?: Array<{ name: string; type: string }>
+}
+
+// Views are read-only; rows are read through dataApi like a table's
+export const viewsApi = {
+  list: () => request<ViewInfo[]>('/views'),
+
+  get: (name: string) => request<ViewInfo>(`/views/${encodeURIComponent(name)}`),
+}
+
 // ============================================================================
 // Users API
 // ============================================================================
@@ -177,6 +216,16 @@ export interface User {
   app_metadata: Record<string, unknown>
 }
 
+export interface UserSession {
+  id: string
+  created_at: string | null
+  last_used_at: string | null
+  aal: string | null
+  not_after: string | null
+  revoked: boolean
+  refresh_count: number
+}
+
 export interface UsersListResponse {
   users: User[]
   total: number
@@ -213,12 +262,41 @@ export const usersApi = {
   }) => patch<void>(`/users/${id}`, data),
 
   delete: (id: string) => del<void>(`/users/${id}`),
+
+  sessions: (id: string) => request<UserSession[]>(`/users/${id}/sessions`),
+
+  revokeSessions: (id: string, sessionId?: string) =>
+    del<{ revoked: number }>(`/users/${id}/sessions${sessionId ? `/${sessionId}` : ''}`),
+
+  impersonate: (id: string, data: { password: string; expires_in?: number }) =>
+    post<{ access_token: string; token_type: string; expires_in: number; expires_at: number }>(
+      `/users/${id}/impersonate`,
+      data
+    ),
 }
 
 // ============================================================================
 // Policies API
 // ============================================================================
 
+export interface ExportedPolicy {
+  table_name: string
+  policy_name: string
+  command: 'SELECT' | 'INSERT' | 'UPDATE' | 'DELETE' | 'ALL'
+  using_expr: string
+  check_expr: string
+  enabled?: boolean
+  permissive?: boolean
+}
+
+export interface PolicyImportResult {
+  index: number
+  table_name?: string
+  policy_name?: string
+  status: 'created' | 'updated' | 'skipped' | 'conflict' | 'error'
+  error?: string
+}
+
 export const policiesApi = {
   list: () => request<unknown[]>('/policies'),
 
@@ -242,15 +320,49 @@ export const policiesApi = {
 
   test: (data: { policy: string; user_id?: string }) =>
     post<{ affected_rows: number; explanation?: string }>('/policies/test', data),
+
+  exportJson: () => request<{ exported_at: string; count: number; policies: ExportedPolicy[] }>('/export/policies.json'),
+
+  importJson: (policies: ExportedPolicy[], onConflict: 'skip' | 'update' | 'error' = 'skip') =>
+    post<{
+      created: number
+      updated: number
+      skipped: number
+      conflicts: PolicyImportResult[]
+      results: PolicyImportResult[]
+    }>(`/import/policies?on_conflict=${onConflict}`, { policies }),
 }
 
 // ============================================================================
 // Storage API
 // ============================================================================
 
+export interface BucketsListResponse {
+  buckets: unknown[]
+  total: number
+  limit: number
+  offset: number
+}
+
 export const storageApi = {
   // Buckets
-  listBuckets: () => request<unknown[]>('/storage/buckets'),
+  listBuckets: (params?: {
+    limit?: number
+    offset?: number
+    search?: string
+    sort?: 'name' | 'created_at'
+    order?: 'asc' | 'desc'
+  }) => {
+    const searchParams = new URLSearchParams()
+    if (params?.limit) searchParams.set('limit', params.limit.toString())
+    if (params?.offset) searchParams.set('offset', params.offset.toString())
+    if (params?.search) searchParams.set('search', params.search)
+    if (params?.sort) searchParams.set('sort', params.sort)
+    if (params?.order) searchParams.set('order', params.order)
+
+    const query = searchParams.toString()
+    return request<BucketsListResponse>(`/storage/buckets${query ? `?${query}` : ''}`)
+  },
 
   createBucket: (data: {
     id: string
@@ -258,6 +370,7 @@ export const storageApi = {
     public?: boolean
     file_size_limit?: number
     allowed_mime_types?: string[]
+    cache_max_age?: number
   }) => post<void>('/storage/buckets', data),
 
   getBucket: (id: string) => request<unknown>(`/storage/buckets/${id}`),
@@ -267,6 +380,7 @@ export const storageApi = {
     public?: boolean
     file_size_limit?: number
     allowed_mime_types?: string[]
+    cache_max_age?: number
   }) => put<void>(`/storage/buckets/${id}`, data),
 
   deleteBucket: (id: string) => del<void>(`/storage/buckets/${id}`),
@@ -304,8 +418,14 @@ export const storageApi = {
       xhr.addEventListener('error', () => reject(new Error('Upload failed')))
       xhr.addEventListener('abort', () => reject(new Error('Upload cancelled')))
 
-      xhr.open('POST', `${API_BASE}/storage/objects/upload/${bucket}/${path}`)
-      xhr.send(formData)
+      // XHR bypasses the fetch wrapper, so attach the CSRF token here
+      getCSRFToken().then((token) => {
+        xhr.open('POST', `${API_BASE}/storage/objects/upload/${bucket}/${path}`)
+        if (token) {
+          xhr.setRequestHeader('X-CSRF-Token', token)
+        }
+        xhr.send(formData)
+      }, reject)
     })
   },
 
@@ -363,8 +483,17 @@ export const functionsApi = {
   // Config
   getConfig: (name: string) => request<unknown>(`/functions/${name}/config`),
 
-  updateConfig: (name: string, data: { verify_jwt?: boolean }) =>
-    patch<void>(`/functions/${name}/config`, data),
+  updateConfig: (name: string, data: {
+    verify_jwt?: boolean
+    import_map?: string
+    generate_import_map?: boolean
+  }) => patch<void>(`/functions/${name}/config`, data),
+
+  getImports: (name: string) => request<{
+    import_map: string
+    specifiers: Array<{ specifier: string; kind: string; files: string[]; resolved: boolean; mapped_to?: string }>
+    unresolved: string[]
+  }>(`/functions/${name}/imports`),
 
   // Secrets
   listSecrets: () => request<string[]>('/secrets'),
@@ -647,10 +776,11 @@ export const migrationApi = {
 export const mailApi = {
   getStatus: () => request<{ enabled: boolean }>('/mail/status'),
 
-  list: (params?: { limit?: number; offset?: number }) => {
+  list: (params?: { limit?: number; offset?: number; q?: string }) => {
     const searchParams = new URLSearchParams()
     if (params?.limit) searchParams.set('limit', params.limit.toString())
     if (params?.offset) searchParams.set('offset', params.offset.toString())
+    if (params?.q) searchParams.set('q', params.q)
 
     const query = searchParams.toString()
     return request<{ emails: unknown[]; total: number }>(`/mail/emails${query ? `?${query}` : ''}`)
@@ -683,6 +813,47 @@ export const exportApi = {
   },
 }
 
+// ============================================================================
+// Sequences API
+// ============================================================================
+
+export interface Sequence {
+  name: string
+  table_name: string
+  column_name: string
+  current_value: number
+  created_at: string
+}
+
+export const sequencesApi = {
+  list: (table?: string) =>
+    request<Sequence[]>(`/sequences${table ? `?table=${encodeURIComponent(table)}` : ''}`),
+
+  get: (name: string) => request<Sequence>(`/sequences/${encodeURIComponent(name)}`),
+
+  reset: (name: string, data: { value?: number; force?: boolean } = {}) =>
+    post<Sequence>(`/sequences/${encodeURIComponent(name)}/reset`, data),
+}
+
+// ============================================================================
+// Maintenance API
+// ============================================================================
+
+export interface ResetSummary {
+  tables: string[]
+  views: string[]
+  policies: number
+  rpc_functions: number
+  buckets: string[]
+  objects: number
+  functions: string[]
+}
+
+export const maintenanceApi = {
+  reset: (data: { confirmation: string; remove_functions?: boolean }) =>
+    post<ResetSummary>('/maintenance/reset', data),
+}
+
 // ============================================================================
 // Convenience exports
 // ============================================================================
@@ -692,6 +863,7 @@ export const api = {
   tables: tablesApi,
   data: dataApi,
   users: usersApi,
+  views: viewsApi,
   policies: policiesApi,
   storage: storageApi,
   functions: functionsApi,
@@ -704,4 +876,6 @@ export const api = {
   migration: migrationApi,
   mail: mailApi,
   export: exportApi,
+  maintenance: maintenanceApi,
+  sequences: sequencesApi,
 }
@@ -4,7 +4,7 @@
  * In production, they go directly to the Go backend
  */
 
-const API_BASE = "/_/api"
+import { API_BASE } from './base'
 
 export interface Table {
   name: string
@@ -0,0 +1,15 @@
+/**
+ * Dashboard base path
+ * The server mounts the dashboard at a configurable path (default /_) and
+ * passes it to the page as window.__SBLITE_BASE__.
+ */
+
+declare global {
+  interface Window {
+    __SBLITE_BASE__?: string
+  }
+}
+
+export const DASHBOARD_BASE = window.__SBLITE_BASE__ || '/_'
+
+export const API_BASE = `${DASHBOARD_BASE}/api`
@@ -0,0 +1,45 @@
+/**
+ * CSRF protection
+ * State-changing dashboard API requests must echo the CSRF token in the
+ * X-CSRF-Token header. This wraps fetch so every caller gets it for free.
+ */
+
+import { API_BASE } from './base'
+
+const API_PREFIX = `${API_BASE}/`
+const SAFE_METHODS = ['GET', 'HEAD', 'OPTIONS']
+const SESSION_ENDPOINTS = /\/auth\/(login|setup|logout)$/
+
+let csrfToken: string | null = null
+const originalFetch = window.fetch.bind(window)
+
+export async function getCSRFToken(): Promise<string | null> {
+  if (!csrfToken) {
+    const res = await originalFetch(`${API_PREFIX}auth/status`)
+    const data = await res.json().catch(() => ({}))
+    csrfToken = data.csrf_token ?? null
+  }
+  return csrfToken
+}
+
+window.fetch = async (input: RequestInfo | URL, init: RequestInit = {}) => {
+  const url = typeof input === 'string' ? input : input instanceof URL ? input.pathname : input.url
+  const method = (init.method ?? (input instanceof Request ? input.method : 'GET')).toUpperCase()
+  const path = url.startsWith('http') ? new URL(url).pathname : url
+
+  if (path.startsWith(API_PREFIX) && !SAFE_METHODS.includes(method) && !/\/auth\/(login|setup)$/.test(path)) {
+    const token = await getCSRFToken()
+    if (token) {
+      const headers = new Headers(init.headers)
+      headers.set('X-CSRF-Token', token)
+      init = { ...init, headers }
+    }
+  }
+
+  const response = await originalFetch(input, init)
+  // A new or ended session comes with a new token
+  if (SESSION_ENDPOINTS.test(path)) {
+    csrfToken = null
+  }
+  return response
+}
@@ -1,5 +1,6 @@
 import { createRoot } from 'react-dom/client'
 import './index.css'
+import './lib/csrf'
 import App from './App.tsx'
 
 createRoot(document.getElementById('root')!).render(
@@ -4,6 +4,7 @@
 
 import { useState } from 'react'
 import { useToast } from '@/hooks'
+import { API_BASE } from '@/lib/base'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
@@ -38,7 +39,7 @@ export function CreateUserModal({ onClose, onCreated }: CreateUserModalProps) {
 
     setLoading(true)
     try {
-      const res = await fetch('/_/api/users', {
+      const res = await fetch(`${API_BASE}/users`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ email, password, email_confirm: autoConfirm }),
@@ -4,6 +4,7 @@
 
 import { useState } from 'react'
 import { useToast } from '@/hooks'
+import { API_BASE } from '@/lib/base'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
@@ -30,7 +31,7 @@ export function InviteUserModal({ onClose, onInvited }: InviteUserModalProps) {
 
     setLoading(true)
     try {
-      const res = await fetch('/_/api/users/invite', {
+      const res = await fetch(`${API_BASE}/users/invite`, {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({ email }),
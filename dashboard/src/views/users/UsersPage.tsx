@@ -6,6 +6,7 @@
 
 import { useState, useEffect } from 'react'
 import { useToast } from '@/hooks'
+import { API_BASE } from '@/lib/base'
 import { formatDateTime } from '@/lib/utils'
 import type { User } from '@/lib/api-client'
 import { CreateUserModal } from './CreateUserModal'
@@ -53,7 +54,7 @@ export function UsersPage() {
     setLoading(true)
     try {
       const offset = (page - 1) * pageSize
-      const res = await fetch(`/_/api/users?limit=${pageSize}&offset=${offset}&filter=${filter}`)
+      const res = await fetch(`${API_BASE}/users?limit=${pageSize}&offset=${offset}&filter=${filter}`)
       if (!res.ok) throw new Error('Failed to load users')
       const data = await res.json()
       setUsers(data.users)
@@ -78,7 +79,7 @@ export function UsersPage() {
     }
 
     try {
-      const res = await fetch(`/_/api/users/${user.id}`, { method: 'DELETE' })
+      const res = await fetch(`${API_BASE}/users/${user.id}`, { method: 'DELETE' })
       if (!res.ok) throw new Error('Failed to delete user')
       success('User deleted', `User "${user.email}" has been deleted.`)
       loadUsers()
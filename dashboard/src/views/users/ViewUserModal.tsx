@@ -4,6 +4,7 @@
 
 import { useState } from 'react'
 import { useToast } from '@/hooks'
+import { API_BASE } from '@/lib/base'
 import { Button } from '@/components/ui/button'
 import { Input } from '@/components/ui/input'
 import { Label } from '@/components/ui/label'
@@ -46,7 +47,7 @@ export function ViewUserModal({ user, onClose, onUpdated }: ViewUserModalProps)
 
     setLoading(true)
     try {
-      const res = await fetch(`/_/api/users/${user.id}`, {
+      const res = await fetch(`${API_BASE}/users/${user.id}`, {
         method: 'PATCH',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify({
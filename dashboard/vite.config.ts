@@ -9,8 +9,20 @@ export default defineConfig({
   define: {
     'process.env.NODE_ENV': JSON.stringify('development'),
   },
-  // Base path for the dashboard - assets will be served at /_/assets/*
+  // Base path for the dashboard - assets will be served at /_/assets/*.
+  // The server rewrites it in index.html when mounted elsewhere (--dashboard-path)
   base: '/_/',
+  experimental: {
+    // Resolve asset URLs against the runtime base path instead of /_/
+    renderBuiltUrl(filename, { hostType }) {
+      if (hostType === 'js') {
+        return { runtime: `(window.__SBLITE_BASE__ || '/_') + '/' + ${JSON.stringify(filename)}` }
+      }
+      if (hostType === 'css') {
+        return { relative: true }
+      }
+    },
+  },
   plugins: [react(), tailwindcss()],
   resolve: {
     alias: {
@@ -9,6 +9,7 @@ import { TEST_CONFIG } from '../../setup/global-setup'
 
 const TEST_PASSWORD = 'testpassword123'
 let sessionCookie = ''
+let csrfToken = ''
 
 /**
  * Setup dashboard auth - ensures password is set and logs in
@@ -35,10 +36,9 @@ async function setupDashboardAuth(): Promise<void> {
   })
 
   // Extract session cookie from response
-  const setCookie = loginRes.headers.get('set-cookie')
-  if (setCookie) {
-    sessionCookie = setCookie.split(';')[0]
-  }
+  // Send back the session and CSRF cookies; the CSRF token also goes in a header
+  sessionCookie = loginRes.headers.getSetCookie().map((c) => c.split(';')[0]).join('; ')
+  csrfToken = (await loginRes.json()).csrf_token ?? ''
 }
 
 /**
@@ -50,7 +50,8 @@ async function executeSql(query: string, postgresMode = true) {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
-      'Cookie': sessionCookie
+      'Cookie': sessionCookie,
+      'X-CSRF-Token': csrfToken
     },
     body: JSON.stringify({ query, postgres_mode: postgresMode })
   })
@@ -10,6 +10,7 @@ import { TEST_CONFIG } from '../../setup/global-setup'
 
 const TEST_PASSWORD = 'testpassword123'
 let sessionCookie = ''
+let csrfToken = ''
 let serviceClient: SupabaseClient
 
 /**
@@ -33,10 +34,9 @@ async function setupDashboardAuth(): Promise<void> {
     body: JSON.stringify({ password: TEST_PASSWORD })
   })
 
-  const setCookie = loginRes.headers.get('set-cookie')
-  if (setCookie) {
-    sessionCookie = setCookie.split(';')[0]
-  }
+  // Send back the session and CSRF cookies; the CSRF token also goes in a header
+  sessionCookie = loginRes.headers.getSetCookie().map((c) => c.split(';')[0]).join('; ')
+  csrfToken = (await loginRes.json()).csrf_token ?? ''
 }
 
 /**
@@ -47,7 +47,8 @@ async function executeSQL(query: string): Promise<void> {
     method: 'POST',
     headers: {
       'Content-Type': 'application/json',
-      'Cookie': sessionCookie
+      'Cookie': sessionCookie,
+      'X-CSRF-Token': csrfToken
     },
     body: JSON.stringify({ query, postgres_mode: false })
   })
@@ -1,5 +1,48 @@
 // sblite Dashboard Application
 
+// The server mounts the dashboard at a configurable path and passes it in
+// window.__SBLITE_BASE__; all API URLs are built from it.
+const DASHBOARD_BASE = window.__SBLITE_BASE__ || '/_';
+const API_BASE = DASHBOARD_BASE + '/api';
+
+// State-changing dashboard API requests must echo the CSRF token in the
+// X-CSRF-Token header; wrap fetch so every call site gets it.
+(function () {
+    let csrfToken = null;
+    const originalFetch = window.fetch.bind(window);
+
+    async function getCSRFToken() {
+        if (!csrfToken) {
+            const res = await originalFetch(API_BASE + '/auth/status');
+            const data = await res.json().catch(() => ({}));
+            csrfToken = data.csrf_token || null;
+        }
+        return csrfToken;
+    }
+
+    window.fetch = async function (input, init = {}) {
+        const url = typeof input === 'string' ? input : input.url;
+        const method = (init.method || (input instanceof Request ? input.method : 'GET')).toUpperCase();
+        const path = url.startsWith('http') ? new URL(url).pathname : url;
+
+        if (path.startsWith(API_BASE + '/') && !['GET', 'HEAD', 'OPTIONS'].includes(method) && !/\/auth\/(login|setup)$/.test(path)) {
+            const token = await getCSRFToken();
+            if (token) {
+                const headers = new Headers(init.headers);
+                headers.set('X-CSRF-Token', token);
+                init = { ...init, headers };
+            }
+        }
+
+        const response = await originalFetch(input, init);
+        // A new or ended session comes with a new token
+        if (/\/auth\/(login|setup|logout)$/.test(path)) {
+            csrfToken = null;
+        }
+        return response;
+    };
+})();
+
 const App = {
     state: {
         authenticated: false,
@@ -44,6 +87,7 @@ const App = {
             enabled: false,     // Whether mail catcher is enabled
             list: [],           // Caught emails
             filter: '',         // Filter by type
+            search: '',         // Full-text search query
             autoRefresh: true,  // Auto-refresh enabled
             autoRefreshInterval: null,
             selectedEmail: null, // Currently viewed email
@@ -185,6 +229,7 @@ const App = {
                 currentFile: null,      // Currently open file path
                 content: '',            // File content in editor
                 originalContent: '',    // For dirty detection
+                baseHash: null,         // Hash of the file as last read/saved
                 isDirty: false,         // Has unsaved changes
                 tree: null,             // File tree structure
                 expandedFolders: {},    // Which folders are expanded
@@ -313,7 +358,7 @@ const App = {
 
     async checkAuth() {
         try {
-            const res = await fetch('/_/api/auth/status');
+            const res = await fetch(API_BASE + '/auth/status');
             const data = await res.json();
             this.state.needsSetup = data.needs_setup;
             this.state.authenticated = data.authenticated;
@@ -325,7 +370,7 @@ const App = {
 
     async setup(password) {
         try {
-            const res = await fetch('/_/api/auth/setup', {
+            const res = await fetch(API_BASE + '/auth/setup', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ password })
@@ -348,7 +393,7 @@ const App = {
 
     async login(password) {
         try {
-            const res = await fetch('/_/api/auth/login', {
+            const res = await fetch(API_BASE + '/auth/login', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ password })
@@ -370,7 +415,7 @@ const App = {
 
     async logout() {
         try {
-            await fetch('/_/api/auth/logout', { method: 'POST' });
+            await fetch(API_BASE + '/auth/logout', { method: 'POST' });
             this.state.authenticated = false;
             this.render();
         } catch (e) {
@@ -382,7 +427,7 @@ const App = {
     // Table management methods
     async loadTables() {
         try {
-            const res = await fetch('/_/api/tables');
+            const res = await fetch(API_BASE + '/tables');
             if (res.ok) {
                 this.state.tables.list = await res.json();
             }
@@ -405,7 +450,7 @@ const App = {
 
     async loadTableSchema(name) {
         try {
-            const res = await fetch(`/_/api/tables/${name}`);
+            const res = await fetch(`${API_BASE}/tables/${name}`);
             if (res.ok) {
                 this.state.tables.schema = await res.json();
             }
@@ -441,7 +486,7 @@ const App = {
                 params.set('order', `${sort.column}.${sort.direction}`);
             }
 
-            const res = await fetch(`/_/api/data/${selected}?${params.toString()}`);
+            const res = await fetch(`${API_BASE}/data/${selected}?${params.toString()}`);
             if (res.ok) {
                 const data = await res.json();
                 this.state.tables.data = data.rows;
@@ -1045,7 +1090,7 @@ const App = {
         const primaryKey = schema.columns.find(c => c.primary)?.name || schema.columns[0]?.name;
 
         try {
-            const res = await fetch(`/_/api/data/${selected}?${primaryKey}=eq.${rowId}`, {
+            const res = await fetch(`${API_BASE}/data/${selected}?${primaryKey}=eq.${rowId}`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ [column]: value || null })
@@ -1132,7 +1177,7 @@ const App = {
         });
 
         try {
-            const res = await fetch('/_/api/tables', {
+            const res = await fetch(API_BASE + '/tables', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ name, columns: formattedColumns })
@@ -1316,7 +1361,7 @@ const App = {
         try {
             let res;
             if (isNew) {
-                res = await fetch(`/_/api/data/${selected}`, {
+                res = await fetch(`${API_BASE}/data/${selected}`, {
                     method: 'POST',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify(rowData)
@@ -1324,7 +1369,7 @@ const App = {
             } else {
                 const { schema } = this.state.tables;
                 const primaryKey = schema.columns.find(c => c.primary)?.name || schema.columns[0]?.name;
-                res = await fetch(`/_/api/data/${selected}?${primaryKey}=eq.${data._rowId}`, {
+                res = await fetch(`${API_BASE}/data/${selected}?${primaryKey}=eq.${data._rowId}`, {
                     method: 'PATCH',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify(rowData)
@@ -1363,9 +1408,18 @@ const App = {
                 ${schema.columns.map(col => `
                     <div class="form-group">
                         <label class="form-label">${col.name} <span class="col-type">${col.type}</span></label>
-                        <input type="text" class="form-input" value="${data[col.name] ?? ''}"
-                            onchange="App.updateRowField('${col.name}', this.value)"
-                            ${col.primary && !isNew ? 'disabled' : ''}>
+                        ${col.enum_values ? `
+                            <select class="form-input"
+                                onchange="App.updateRowField('${col.name}', this.value === '' ? null : this.value)"
+                                ${col.primary && !isNew ? 'disabled' : ''}>
+                                <option value="">${col.nullable ? 'NULL' : 'Select...'}</option>
+                                ${col.enum_values.map(v => `<option value="${v}" ${String(data[col.name] ?? '') === v ? 'selected' : ''}>${v}</option>`).join('')}
+                            </select>
+                        ` : `
+                            <input type="text" class="form-input" value="${data[col.name] ?? ''}"
+                                onchange="App.updateRowField('${col.name}', this.value)"
+                                ${col.primary && !isNew ? 'disabled' : ''}>
+                        `}
                     </div>
                 `).join('')}
             </div>
@@ -1384,7 +1438,7 @@ const App = {
         const primaryKey = schema.columns.find(c => c.primary)?.name || schema.columns[0]?.name;
 
         try {
-            const res = await fetch(`/_/api/data/${selected}?${primaryKey}=eq.${rowId}`, {
+            const res = await fetch(`${API_BASE}/data/${selected}?${primaryKey}=eq.${rowId}`, {
                 method: 'DELETE'
             });
 
@@ -1410,7 +1464,7 @@ const App = {
 
         for (const rowId of selectedRows) {
             try {
-                await fetch(`/_/api/data/${selected}?${primaryKey}=eq.${rowId}`, {
+                await fetch(`${API_BASE}/data/${selected}?${primaryKey}=eq.${rowId}`, {
                     method: 'DELETE'
                 });
             } catch (e) {
@@ -1427,7 +1481,7 @@ const App = {
         if (!confirm(`Delete table "${selected}"? This cannot be undone.`)) return;
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}`, {
                 method: 'DELETE'
             });
 
@@ -1476,7 +1530,7 @@ const App = {
         delete formattedData.defaultValue;
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}/columns`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}/columns`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(formattedData)
@@ -1504,7 +1558,7 @@ const App = {
         const { selected } = this.state.tables;
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}/columns/${oldName}`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}/columns/${oldName}`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ new_name: newName })
@@ -1529,7 +1583,7 @@ const App = {
         const { selected } = this.state.tables;
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}/columns/${colName}`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}/columns/${colName}`, {
                 method: 'DELETE'
             });
 
@@ -1549,7 +1603,7 @@ const App = {
     // FTS Index Management
     async loadFTSIndexes(tableName) {
         try {
-            const res = await fetch(`/_/api/tables/${tableName}/fts`);
+            const res = await fetch(`${API_BASE}/tables/${tableName}/fts`);
             if (res.ok) {
                 const data = await res.json();
                 this.state.tables.ftsIndexes = data.indexes || [];
@@ -1594,7 +1648,7 @@ const App = {
         }
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}/fts`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}/fts`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -1625,7 +1679,7 @@ const App = {
         const { selected } = this.state.tables;
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}/fts/${indexName}`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}/fts/${indexName}`, {
                 method: 'DELETE'
             });
 
@@ -1647,7 +1701,7 @@ const App = {
         const { selected } = this.state.tables;
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}/fts/${indexName}/rebuild`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}/fts/${indexName}/rebuild`, {
                 method: 'POST'
             });
 
@@ -1696,7 +1750,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch(`/_/api/tables/${selected}/fts/test`, {
+            const res = await fetch(`${API_BASE}/tables/${selected}/fts/test`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -1838,7 +1892,7 @@ const App = {
                 <div class="form-group">
                     <label class="form-label">Default Value</label>
                     <input type="text" class="form-input" value="${this.escapeHtml(data.defaultValue || '')}"
-                        placeholder="e.g., now(), gen_random_uuid(), 0, 'text'"
+                        placeholder="e.g., now(), gen_random_uuid(), nextval(), 0, 'text'"
                         oninput="App.updateModalData('defaultValue', this.value)">
                 </div>
                 <div class="form-group">
@@ -1986,7 +2040,7 @@ const App = {
         try {
             const { page, pageSize, filter } = this.state.users;
             const offset = (page - 1) * pageSize;
-            const res = await fetch(`/_/api/users?limit=${pageSize}&offset=${offset}&filter=${filter || 'all'}`);
+            const res = await fetch(`${API_BASE}/users?limit=${pageSize}&offset=${offset}&filter=${filter || 'all'}`);
             if (res.ok) {
                 const data = await res.json();
                 this.state.users.list = data.users;
@@ -2131,7 +2185,7 @@ const App = {
 
     async showUserModal(userId) {
         try {
-            const res = await fetch(`/_/api/users/${userId}`);
+            const res = await fetch(`${API_BASE}/users/${userId}`);
             if (res.ok) {
                 const user = await res.json();
                 this.state.modal = { type: 'userDetail', data: user };
@@ -2170,7 +2224,7 @@ const App = {
         if (!confirm(confirmMessage)) return;
 
         try {
-            const res = await fetch(`/_/api/users/${userId}`, { method: 'DELETE' });
+            const res = await fetch(`${API_BASE}/users/${userId}`, { method: 'DELETE' });
             if (res.ok) {
                 await this.loadUsers();
             } else {
@@ -2193,7 +2247,7 @@ const App = {
         };
 
         try {
-            const res = await fetch(`/_/api/users/${userId}`, {
+            const res = await fetch(`${API_BASE}/users/${userId}`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(updateData)
@@ -2307,7 +2361,7 @@ const App = {
         }
 
         try {
-            const res = await fetch('/_/api/users', {
+            const res = await fetch(API_BASE + '/users', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ email, password, auto_confirm: autoConfirm })
@@ -2389,7 +2443,7 @@ const App = {
         }
 
         try {
-            const res = await fetch('/_/api/users/invite', {
+            const res = await fetch(API_BASE + '/users/invite', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ email })
@@ -2495,13 +2549,13 @@ const App = {
 
         try {
             // Load tables list
-            const tablesRes = await fetch('/_/api/tables');
+            const tablesRes = await fetch(API_BASE + '/tables');
             if (tablesRes.ok) {
                 const tables = await tablesRes.json();
 
                 // For each table, get RLS status
                 const tablesWithRLS = await Promise.all(tables.map(async (t) => {
-                    const rlsRes = await fetch(`/_/api/tables/${t.name}/rls`);
+                    const rlsRes = await fetch(`${API_BASE}/tables/${t.name}/rls`);
                     if (rlsRes.ok) {
                         const rlsData = await rlsRes.json();
                         return { ...t, rls_enabled: rlsData.rls_enabled, policy_count: rlsData.policy_count };
@@ -2524,7 +2578,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch(`/_/api/policies?table=${tableName}`);
+            const res = await fetch(`${API_BASE}/policies?table=${tableName}`);
             if (res.ok) {
                 const data = await res.json();
                 this.state.policies.list = data.policies || [];
@@ -2538,7 +2592,7 @@ const App = {
 
     async toggleTableRLS(tableName, enabled) {
         try {
-            const res = await fetch(`/_/api/tables/${tableName}/rls`, {
+            const res = await fetch(`${API_BASE}/tables/${tableName}/rls`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ enabled })
@@ -2559,7 +2613,7 @@ const App = {
 
     async loadMailStatus() {
         try {
-            const response = await fetch('/_/api/mail/status');
+            const response = await fetch(API_BASE + '/mail/status');
             if (response.ok) {
                 const data = await response.json();
                 this.state.mail.enabled = data.enabled;
@@ -2576,7 +2630,9 @@ const App = {
         this.render();
 
         try {
-            const response = await fetch('/_/api/mail/emails');
+            const { search } = this.state.mail;
+            const query = search ? `?q=${encodeURIComponent(search)}` : '';
+            const response = await fetch(`${API_BASE}/mail/emails${query}`);
             if (response.ok) {
                 this.state.mail.list = await response.json() || [];
             }
@@ -2590,7 +2646,7 @@ const App = {
 
     async viewEmail(id) {
         try {
-            const response = await fetch(`/_/api/mail/emails/${id}`);
+            const response = await fetch(`${API_BASE}/mail/emails/${id}`);
             if (response.ok) {
                 this.state.mail.selectedEmail = await response.json();
                 this.state.mail.showModal = true;
@@ -2606,7 +2662,7 @@ const App = {
 
     async deleteEmail(id) {
         try {
-            const response = await fetch(`/_/api/mail/emails/${id}`, { method: 'DELETE' });
+            const response = await fetch(`${API_BASE}/mail/emails/${id}`, { method: 'DELETE' });
             if (response.ok) {
                 await this.loadEmails();
             }
@@ -2621,7 +2677,7 @@ const App = {
         if (!confirm('Are you sure you want to delete all emails?')) return;
 
         try {
-            const response = await fetch('/_/api/mail/emails', { method: 'DELETE' });
+            const response = await fetch(API_BASE + '/mail/emails', { method: 'DELETE' });
             if (response.ok) {
                 await this.loadEmails();
             }
@@ -2637,6 +2693,18 @@ const App = {
         this.render();
     },
 
+    searchEmails(query) {
+        this.state.mail.search = query.trim();
+        this.loadEmails();
+    },
+
+    // Search snippets mark matches with <mark>; everything else is escaped
+    renderMailSnippet(snippet) {
+        return this.escapeHtml(snippet)
+            .replace(/&lt;mark&gt;/g, '<mark>')
+            .replace(/&lt;\/mark&gt;/g, '</mark>');
+    },
+
     toggleMailAutoRefresh() {
         this.state.mail.autoRefresh = !this.state.mail.autoRefresh;
 
@@ -2684,7 +2752,7 @@ const App = {
     },
 
     renderMailView() {
-        const { enabled, list, filter, autoRefresh, loading } = this.state.mail;
+        const { enabled, list, filter, search, autoRefresh, loading } = this.state.mail;
 
         // First load - check status and load emails
         if (!this.state.mail._initialized) {
@@ -2739,6 +2807,8 @@ const App = {
                             <option value="invite" ${filter === 'invite' ? 'selected' : ''}>Invite</option>
                         </select>
                     </div>
+                    <input type="search" class="form-input" style="width: 16rem;" placeholder="Search subjects and bodies"
+                        value="${this.escapeHtml(search)}" onchange="App.searchEmails(this.value)">
                     <label style="display: flex; align-items: center; gap: 0.5rem; cursor: pointer;">
                         <input type="checkbox" ${autoRefresh ? 'checked' : ''} onchange="App.toggleMailAutoRefresh()">
                         Auto-refresh (5s)
@@ -2755,7 +2825,7 @@ const App = {
 
                 ${filteredEmails.length === 0 ? `
                     <div class="empty-state">
-                        <p>${filter ? 'No emails match the current filter.' : 'No emails caught yet.'}</p>
+                        <p>${filter || search ? 'No emails match the current filter.' : 'No emails caught yet.'}</p>
                         <p style="margin-top: 0.5rem; color: var(--text-muted);">
                             Emails sent by the application will appear here.
                         </p>
@@ -2783,6 +2853,7 @@ const App = {
                                             <a href="#" onclick="event.preventDefault(); App.viewEmail('${email.id}')" style="color: var(--link); font-weight: 500;">
                                                 ${this.escapeHtml(email.subject)}
                                             </a>
+                                            ${email.snippet ? `<div class="mail-snippet">${this.renderMailSnippet(email.snippet)}</div>` : ''}
                                         </td>
                                         <td style="color: var(--text-muted); font-size: 0.85em;">
                                             ${this.formatMailTime(email.created_at)}
@@ -3041,7 +3112,7 @@ const App = {
 
     async togglePolicyEnabled(policyId, enabled) {
         try {
-            const res = await fetch(`/_/api/policies/${policyId}`, {
+            const res = await fetch(`${API_BASE}/policies/${policyId}`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ enabled })
@@ -3069,7 +3140,7 @@ const App = {
         if (!confirm(message)) return;
 
         try {
-            const res = await fetch(`/_/api/policies/${policyId}`, { method: 'DELETE' });
+            const res = await fetch(`${API_BASE}/policies/${policyId}`, { method: 'DELETE' });
             if (res.ok) {
                 this.state.policies.list = list.filter(p => p.id !== policyId);
                 // Update policy count
@@ -3107,7 +3178,7 @@ const App = {
 
     async showEditPolicyModal(policyId) {
         try {
-            const res = await fetch(`/_/api/policies/${policyId}`);
+            const res = await fetch(`${API_BASE}/policies/${policyId}`);
             if (res.ok) {
                 const policy = await res.json();
                 this.state.modal = {
@@ -3168,7 +3239,7 @@ const App = {
         const { table_name, using_expr, check_expr, testUserId } = this.state.modal.data;
 
         try {
-            const res = await fetch('/_/api/policies/test', {
+            const res = await fetch(API_BASE + '/policies/test', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -3206,7 +3277,7 @@ const App = {
         try {
             let res;
             if (isNew) {
-                res = await fetch('/_/api/policies', {
+                res = await fetch(API_BASE + '/policies', {
                     method: 'POST',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify({
@@ -3219,7 +3290,7 @@ const App = {
                     })
                 });
             } else {
-                res = await fetch(`/_/api/policies/${data.id}`, {
+                res = await fetch(`${API_BASE}/policies/${data.id}`, {
                     method: 'PATCH',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify({
@@ -3399,7 +3470,7 @@ const App = {
     async loadUsersForTest() {
         if (this.state.users.list.length === 0) {
             try {
-                const res = await fetch('/_/api/users?limit=100');
+                const res = await fetch(API_BASE + '/users?limit=100');
                 if (res.ok) {
                     const data = await res.json();
                     this.state.users.list = data.users || [];
@@ -3420,13 +3491,13 @@ const App = {
 
         try {
             const [serverRes, authRes, templatesRes, oauthRes, redirectUrlsRes, apiKeysRes, authConfigRes] = await Promise.all([
-                fetch('/_/api/settings/server'),
-                fetch('/_/api/settings/auth'),
-                fetch('/_/api/settings/templates'),
-                fetch('/_/api/settings/oauth'),
-                fetch('/_/api/settings/oauth/redirect-urls'),
-                fetch('/_/api/apikeys'),
-                fetch('/_/api/settings/auth-config')
+                fetch(API_BASE + '/settings/server'),
+                fetch(API_BASE + '/settings/auth'),
+                fetch(API_BASE + '/settings/templates'),
+                fetch(API_BASE + '/settings/oauth'),
+                fetch(API_BASE + '/settings/oauth/redirect-urls'),
+                fetch(API_BASE + '/apikeys'),
+                fetch(API_BASE + '/settings/auth-config')
             ]);
 
             if (serverRes.ok) {
@@ -3477,7 +3548,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch('/_/api/settings/storage');
+            const res = await fetch(API_BASE + '/settings/storage');
             if (res.ok) {
                 const data = await res.json();
                 this.state.settings.storageSettings.backend = data.backend || 'local';
@@ -3509,13 +3580,13 @@ const App = {
 
         try {
             // Load buckets
-            const bucketsRes = await fetch('/_/api/storage/buckets');
+            const bucketsRes = await fetch(API_BASE + '/storage/buckets');
             if (bucketsRes.ok) {
-                sp.buckets = await bucketsRes.json();
+                sp.buckets = (await bucketsRes.json()).buckets;
             }
 
             // Load all storage_objects policies
-            const policiesRes = await fetch('/_/api/policies?table=storage_objects');
+            const policiesRes = await fetch(API_BASE + '/policies?table=storage_objects');
             if (policiesRes.ok) {
                 const data = await policiesRes.json();
                 sp.list = data.policies || [];
@@ -3575,7 +3646,7 @@ const App = {
 
     async showEditStoragePolicyModal(policyId) {
         try {
-            const res = await fetch(`/_/api/policies/${policyId}`);
+            const res = await fetch(`${API_BASE}/policies/${policyId}`);
             if (res.ok) {
                 const policy = await res.json();
                 this.showStoragePolicyModal(policy);
@@ -3774,7 +3845,7 @@ const App = {
         try {
             let res;
             if (isEdit) {
-                res = await fetch(`/_/api/policies/${p.id}`, {
+                res = await fetch(`${API_BASE}/policies/${p.id}`, {
                     method: 'PATCH',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify({
@@ -3786,7 +3857,7 @@ const App = {
                     })
                 });
             } else {
-                res = await fetch('/_/api/policies', {
+                res = await fetch(API_BASE + '/policies', {
                     method: 'POST',
                     headers: { 'Content-Type': 'application/json' },
                     body: JSON.stringify({
@@ -3816,7 +3887,7 @@ const App = {
 
     async toggleStoragePolicyEnabled(policyId, enabled) {
         try {
-            const res = await fetch(`/_/api/policies/${policyId}`, {
+            const res = await fetch(`${API_BASE}/policies/${policyId}`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ enabled })
@@ -3838,7 +3909,7 @@ const App = {
         if (!confirm(`Delete policy "${policyName}"? This cannot be undone.`)) return;
 
         try {
-            const res = await fetch(`/_/api/policies/${policyId}`, { method: 'DELETE' });
+            const res = await fetch(`${API_BASE}/policies/${policyId}`, { method: 'DELETE' });
             if (res.ok) {
                 await this.loadStoragePolicies();
             }
@@ -3852,7 +3923,7 @@ const App = {
         this.render();
 
         try {
-            const resp = await fetch('/_/api/settings/mail');
+            const resp = await fetch(API_BASE + '/settings/mail');
             if (resp.ok) {
                 const data = await resp.json();
                 this.state.settings.mailSettings.mode = data.mode || 'log';
@@ -3909,7 +3980,7 @@ const App = {
                 }
             }
 
-            const resp = await fetch('/_/api/settings/mail', {
+            const resp = await fetch(API_BASE + '/settings/mail', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(body)
@@ -3973,7 +4044,7 @@ const App = {
                 payload.s3.secret_key = ss.s3.secretKey;
             }
 
-            const res = await fetch('/_/api/settings/storage', {
+            const res = await fetch(API_BASE + '/settings/storage', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(payload)
@@ -4014,7 +4085,7 @@ const App = {
                 payload.secret_key = ss.s3.secretKey;
             }
 
-            const res = await fetch('/_/api/settings/storage/test', {
+            const res = await fetch(API_BASE + '/settings/storage/test', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(payload)
@@ -4063,7 +4134,7 @@ const App = {
         if (!template) return;
 
         try {
-            const res = await fetch(`/_/api/settings/templates/${template.type}`, {
+            const res = await fetch(`${API_BASE}/settings/templates/${template.type}`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -4094,7 +4165,7 @@ const App = {
         if (!confirm(`Reset ${type} template to default? Your changes will be lost.`)) return;
 
         try {
-            const res = await fetch(`/_/api/settings/templates/${type}/reset`, { method: 'POST' });
+            const res = await fetch(`${API_BASE}/settings/templates/${type}/reset`, { method: 'POST' });
             if (res.ok) {
                 const data = await res.json();
                 const idx = this.state.settings.templates.findIndex(t => t.type === type);
@@ -4129,7 +4200,7 @@ const App = {
         const { confirmation } = this.state.modal.data;
 
         try {
-            const res = await fetch('/_/api/settings/auth/regenerate-secret', {
+            const res = await fetch(API_BASE + '/settings/auth/regenerate-secret', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ confirmation })
@@ -4178,7 +4249,7 @@ const App = {
     },
 
     async exportSchema() {
-        window.location.href = '/_/api/export/schema';
+        window.location.href = API_BASE + '/export/schema';
     },
 
     async exportData(format) {
@@ -4187,11 +4258,11 @@ const App = {
             alert('No tables to export');
             return;
         }
-        window.location.href = `/_/api/export/data?tables=${encodeURIComponent(tables)}&format=${format}`;
+        window.location.href = `${API_BASE}/export/data?tables=${encodeURIComponent(tables)}&format=${format}`;
     },
 
     async exportBackup() {
-        window.location.href = '/_/api/export/backup';
+        window.location.href = API_BASE + '/export/backup';
     },
 
     renderSettingsView() {
@@ -4497,7 +4568,7 @@ const App = {
 
     async toggleEmailConfirmation(required) {
         try {
-            const res = await fetch('/_/api/settings/auth-config', {
+            const res = await fetch(API_BASE + '/settings/auth-config', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ require_email_confirmation: required })
@@ -4519,7 +4590,7 @@ const App = {
 
     async toggleAnonymousSignin(enabled) {
         try {
-            const res = await fetch('/_/api/settings/auth-config', {
+            const res = await fetch(API_BASE + '/settings/auth-config', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ allow_anonymous: enabled })
@@ -4546,7 +4617,7 @@ const App = {
         const siteURL = input?.value?.trim() || '';
 
         try {
-            const res = await fetch('/_/api/settings/auth-config', {
+            const res = await fetch(API_BASE + '/settings/auth-config', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ site_url: siteURL })
@@ -4563,7 +4634,7 @@ const App = {
 
     async toggleOAuthProvider(provider, enabled) {
         try {
-            const res = await fetch('/_/api/settings/oauth', {
+            const res = await fetch(API_BASE + '/settings/oauth', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ [provider]: { enabled } })
@@ -4591,7 +4662,7 @@ const App = {
         if (!value.trim()) return;
 
         try {
-            const res = await fetch('/_/api/settings/oauth', {
+            const res = await fetch(API_BASE + '/settings/oauth', {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ [provider]: { [field]: value } })
@@ -4638,7 +4709,7 @@ const App = {
         }
 
         try {
-            const res = await fetch('/_/api/settings/oauth/redirect-urls', {
+            const res = await fetch(API_BASE + '/settings/oauth/redirect-urls', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ url })
@@ -4662,7 +4733,7 @@ const App = {
 
     async removeRedirectUrl(url) {
         try {
-            const res = await fetch('/_/api/settings/oauth/redirect-urls', {
+            const res = await fetch(API_BASE + '/settings/oauth/redirect-urls', {
                 method: 'DELETE',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ url })
@@ -4910,7 +4981,7 @@ const App = {
                                 </div>
                                 <small class="text-muted">
                                     ${ms.mode === 'log' ? 'Emails are printed to server console. Good for quick debugging.' :
-                                      ms.mode === 'catch' ? 'Emails are stored in database. View at /_/mail. Good for development.' :
+                                      ms.mode === 'catch' ? `Emails are stored in database. View at ${DASHBOARD_BASE}/mail. Good for development.` :
                                       'Emails are sent via SMTP server. Use for staging/production.'}
                                 </small>
                             </div>
@@ -4918,7 +4989,7 @@ const App = {
                             ${modeChanged ? `
                                 <div class="warning-banner">
                                     <strong>Note:</strong> Changing email mode takes effect immediately after saving.
-                                    ${ms.mode === 'catch' ? 'The mail viewer will be available at <code>/_/mail</code>.' : ''}
+                                    ${ms.mode === 'catch' ? `The mail viewer will be available at <code>${DASHBOARD_BASE}/mail</code>.` : ''}
                                 </div>
                             ` : ''}
 
@@ -5074,6 +5145,9 @@ const App = {
                                 <button class="btn btn-secondary" onclick="App.exportData('csv')">
                                     Export Data (CSV)
                                 </button>
+                                <button class="btn btn-secondary" onclick="App.exportData('ndjson')">
+                                    Export Data (NDJSON)
+                                </button>
                                 <small>Export all table data</small>
                             </div>
                             <div class="export-item">
@@ -5099,7 +5173,7 @@ const App = {
 
         try {
             // Load log config
-            const configRes = await fetch('/_/api/logs/config');
+            const configRes = await fetch(API_BASE + '/logs/config');
             if (configRes.ok) {
                 this.state.logs.config = await configRes.json();
             }
@@ -5123,7 +5197,7 @@ const App = {
 
     async loadConsoleBuffer() {
         try {
-            const res = await fetch('/_/api/logs/buffer?lines=500');
+            const res = await fetch(API_BASE + '/logs/buffer?lines=500');
             if (res.ok) {
                 const data = await res.json();
                 this.state.logs.consoleLines = data.lines || [];
@@ -5151,9 +5225,9 @@ const App = {
 
         try {
             const [statusRes, metricsRes, tracesRes] = await Promise.all([
-                fetch('/_/api/observability/status'),
-                fetch('/_/api/observability/metrics?minutes=' + this.state.observability.timeRange),
-                fetch('/_/api/observability/traces?limit=100&method=' + encodeURIComponent(this.state.observability.filters.method) +
+                fetch(API_BASE + '/observability/status'),
+                fetch(API_BASE + '/observability/metrics?minutes=' + this.state.observability.timeRange),
+                fetch(API_BASE + '/observability/traces?limit=100&method=' + encodeURIComponent(this.state.observability.filters.method) +
                       '&path=' + encodeURIComponent(this.state.observability.filters.path) +
                       '&status=' + encodeURIComponent(this.state.observability.filters.status))
             ]);
@@ -5252,7 +5326,7 @@ const App = {
         params.set('offset', ((page - 1) * pageSize).toString());
 
         try {
-            const res = await fetch(`/_/api/logs?${params}`);
+            const res = await fetch(`${API_BASE}/logs?${params}`);
             if (res.ok) {
                 const data = await res.json();
                 this.state.logs.list = data.logs || [];
@@ -5265,10 +5339,14 @@ const App = {
 
     async tailLogs() {
         try {
-            const res = await fetch('/_/api/logs/tail?lines=100');
+            const res = await fetch(API_BASE + '/logs/tail?lines=100');
             if (res.ok) {
                 const data = await res.json();
-                this.state.logs.tailLines = data.lines || [];
+                // JSON-format logs come back parsed; show them as readable lines
+                this.state.logs.tailLines = data.entries
+                    ? data.entries.map(e => [e.timestamp, e.level, e.message, e.fields ? JSON.stringify(e.fields) : '']
+                        .filter(Boolean).join(' '))
+                    : (data.lines || []);
             }
         } catch (e) {
             this.state.logs.tailLines = [];
@@ -5639,7 +5717,7 @@ const App = {
 
     async loadApiKeys() {
         try {
-            const res = await fetch('/_/api/apikeys');
+            const res = await fetch(API_BASE + '/apikeys');
             if (res.ok) {
                 this.state.apiConsole.apiKeys = await res.json();
             }
@@ -6068,7 +6146,7 @@ const App = {
 
         // Load table list for autocomplete
         try {
-            const res = await fetch('/_/api/tables');
+            const res = await fetch(API_BASE + '/tables');
             if (res.ok) {
                 const tables = await res.json();
                 this.state.sqlBrowser.tables = tables;
@@ -6121,7 +6199,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch('/_/api/sql', {
+            const res = await fetch(API_BASE + '/sql', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -6626,14 +6704,14 @@ const App = {
         try {
             // Load functions list, status, runtime info, and API keys in parallel
             const requests = [
-                fetch('/_/api/functions'),
-                fetch('/_/api/functions/status'),
-                fetch('/_/api/functions/runtime-info')
+                fetch(API_BASE + '/functions'),
+                fetch(API_BASE + '/functions/status'),
+                fetch(API_BASE + '/functions/runtime-info')
             ];
 
             // Also load API keys if not already loaded (for test console)
             if (!this.state.apiConsole.apiKeys) {
-                requests.push(fetch('/_/api/apikeys'));
+                requests.push(fetch(API_BASE + '/apikeys'));
             }
 
             const responses = await Promise.all(requests);
@@ -6681,7 +6759,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch('/_/api/secrets');
+            const res = await fetch(API_BASE + '/secrets');
             if (res.ok) {
                 const data = await res.json();
                 // API returns { secrets: [...], enabled: bool }
@@ -6704,6 +6782,7 @@ const App = {
             currentFile: null,
             content: '',
             originalContent: '',
+            baseHash: null,
             isDirty: false,
             tree: null,
             expandedFolders: {},
@@ -6716,7 +6795,7 @@ const App = {
 
         // Load function config
         try {
-            const res = await fetch(`/_/api/functions/${name}/config`);
+            const res = await fetch(`${API_BASE}/functions/${name}/config`);
             if (res.ok) {
                 this.state.functions.config = await res.json();
             }
@@ -6739,6 +6818,7 @@ const App = {
             currentFile: null,
             content: '',
             originalContent: '',
+            baseHash: null,
             isDirty: false,
             tree: null,
             expandedFolders: {},
@@ -6767,7 +6847,7 @@ const App = {
         }
 
         try {
-            const res = await fetch(`/_/api/functions/${name}`, {
+            const res = await fetch(`${API_BASE}/functions/${name}`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ template })
@@ -6792,7 +6872,7 @@ const App = {
         if (!confirm(`Delete function "${name}"? This cannot be undone.`)) return;
 
         try {
-            const res = await fetch(`/_/api/functions/${name}`, { method: 'DELETE' });
+            const res = await fetch(`${API_BASE}/functions/${name}`, { method: 'DELETE' });
             if (res.ok) {
                 if (this.state.functions.selected === name) {
                     this.state.functions.selected = null;
@@ -6811,7 +6891,7 @@ const App = {
 
     async toggleFunctionJWT(name, enabled) {
         try {
-            const res = await fetch(`/_/api/functions/${name}/config`, {
+            const res = await fetch(`${API_BASE}/functions/${name}/config`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ verify_jwt: enabled })
@@ -6857,7 +6937,7 @@ const App = {
         }
 
         try {
-            const res = await fetch('/_/api/secrets', {
+            const res = await fetch(API_BASE + '/secrets', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ name, value })
@@ -6881,7 +6961,7 @@ const App = {
         if (!confirm(`Delete secret "${name}"? This cannot be undone.`)) return;
 
         try {
-            const res = await fetch(`/_/api/secrets/${name}`, { method: 'DELETE' });
+            const res = await fetch(`${API_BASE}/secrets/${name}`, { method: 'DELETE' });
             if (res.ok) {
                 await this.loadFunctionsSecrets();
             } else {
@@ -7003,7 +7083,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch(`/_/api/functions/${name}/files`);
+            const res = await fetch(`${API_BASE}/functions/${name}/files`);
             if (res.ok) {
                 const tree = await res.json();
                 this.state.functions.editor.tree = tree;
@@ -7043,12 +7123,13 @@ const App = {
         }
 
         try {
-            const res = await fetch(`/_/api/functions/${name}/files/${path}`);
+            const res = await fetch(`${API_BASE}/functions/${name}/files/${path}`);
             if (res.ok) {
                 const data = await res.json();
                 this.state.functions.editor.currentFile = path;
                 this.state.functions.editor.content = data.content;
                 this.state.functions.editor.originalContent = data.content;
+                this.state.functions.editor.baseHash = data.hash;
                 this.state.functions.editor.isDirty = false;
 
                 // Update Monaco editor if it exists
@@ -7064,21 +7145,32 @@ const App = {
         }
     },
 
-    async saveFunctionFile() {
+    async saveFunctionFile(force = false) {
         const { selected } = this.state.functions;
-        const { currentFile, monacoEditor } = this.state.functions.editor;
+        const { currentFile, monacoEditor, baseHash } = this.state.functions.editor;
         if (!selected || !currentFile) return;
 
         const content = monacoEditor ? monacoEditor.getValue() : this.state.functions.editor.content;
+        const body = { content };
+        if (!force && baseHash) body.base_hash = baseHash;
 
         try {
-            const res = await fetch(`/_/api/functions/${selected}/files/${currentFile}`, {
+            const res = await fetch(`${API_BASE}/functions/${selected}/files/${currentFile}`, {
                 method: 'PUT',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ content })
+                body: JSON.stringify(body)
             });
 
+            if (res.status === 409) {
+                if (confirm('This file was changed outside the editor since you opened it. Overwrite those changes?')) {
+                    await this.saveFunctionFile(true);
+                }
+                return;
+            }
+
             if (res.ok) {
+                const data = await res.json();
+                this.state.functions.editor.baseHash = data.hash;
                 this.state.functions.editor.originalContent = content;
                 this.state.functions.editor.content = content;
                 this.state.functions.editor.isDirty = false;
@@ -7097,7 +7189,7 @@ const App = {
     async restartFunctionsRuntime() {
         const { selected } = this.state.functions;
         try {
-            const res = await fetch(`/_/api/functions/${selected}/restart`, { method: 'POST' });
+            const res = await fetch(`${API_BASE}/functions/${selected}/restart`, { method: 'POST' });
             if (res.ok) {
                 await this.loadFunctionsStatus();
                 // Update status indicator without full render
@@ -7124,7 +7216,7 @@ const App = {
 
     async loadFunctionsStatus() {
         try {
-            const res = await fetch('/_/api/functions/status');
+            const res = await fetch(API_BASE + '/functions/status');
             if (res.ok) {
                 const statusData = await res.json();
                 this.state.functions.status = { ...this.state.functions.status, ...statusData };
@@ -7397,7 +7489,7 @@ const App = {
         this.render();
 
         try {
-            const response = await fetch('/_/api/functions/runtime-install', {
+            const response = await fetch(API_BASE + '/functions/runtime-install', {
                 method: 'POST',
             });
 
@@ -7811,11 +7903,11 @@ const App = {
         try {
             // Fetch buckets and API key in parallel
             const [bucketsRes, apiKeysRes] = await Promise.all([
-                fetch('/_/api/storage/buckets'),
-                fetch('/_/api/apikeys')
+                fetch(API_BASE + '/storage/buckets'),
+                fetch(API_BASE + '/apikeys')
             ]);
             if (!bucketsRes.ok) throw new Error('Failed to load buckets');
-            this.state.storage.buckets = await bucketsRes.json();
+            this.state.storage.buckets = (await bucketsRes.json()).buckets;
             if (apiKeysRes.ok) {
                 const keys = await apiKeysRes.json();
                 this.state.storage.apiKey = keys.service_role_key;
@@ -7898,7 +7990,7 @@ const App = {
             if (sizeLimit) body.file_size_limit = parseInt(sizeLimit) * 1024 * 1024;
             if (mimeTypes) body.allowed_mime_types = mimeTypes.split(',').map(t => t.trim());
 
-            const res = await fetch('/_/api/storage/buckets', {
+            const res = await fetch(API_BASE + '/storage/buckets', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(body)
@@ -8026,7 +8118,7 @@ const App = {
             if (sizeLimit) body.file_size_limit = parseInt(sizeLimit) * 1024 * 1024;
             if (mimeTypes) body.allowed_mime_types = mimeTypes.split(',').map(t => t.trim());
 
-            const res = await fetch(`/_/api/storage/buckets/${bucketId}`, {
+            const res = await fetch(`${API_BASE}/storage/buckets/${bucketId}`, {
                 method: 'PUT',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify(body)
@@ -8046,7 +8138,7 @@ const App = {
         if (!confirmed) return;
 
         try {
-            const res = await fetch(`/_/api/storage/buckets/${bucketId}/empty`, { method: 'POST' });
+            const res = await fetch(`${API_BASE}/storage/buckets/${bucketId}/empty`, { method: 'POST' });
             if (!res.ok) throw new Error('Failed to empty bucket');
             this.showToast('Bucket emptied', 'success');
             await this.loadObjects();
@@ -8061,7 +8153,7 @@ const App = {
         if (!confirmed) return;
 
         try {
-            const res = await fetch(`/_/api/storage/buckets/${bucketId}`, { method: 'DELETE' });
+            const res = await fetch(`${API_BASE}/storage/buckets/${bucketId}`, { method: 'DELETE' });
             if (!res.ok) {
                 const err = await res.json();
                 throw new Error(err.message || 'Failed to delete bucket');
@@ -8191,7 +8283,7 @@ const App = {
                         const thumbUrl = isImage
                             ? (selectedBucket.public
                                 ? `/storage/v1/object/public/${selectedBucket.name}/${item.name}`
-                                : `/_/api/storage/objects/download?bucket=${encodeURIComponent(selectedBucket.name)}&path=${encodeURIComponent(item.name)}`)
+                                : `${API_BASE}/storage/objects/download?bucket=${encodeURIComponent(selectedBucket.name)}&path=${encodeURIComponent(item.name)}`)
                             : null;
 
                         return `
@@ -8293,7 +8385,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch('/_/api/storage/objects/list', {
+            const res = await fetch(API_BASE + '/storage/objects/list', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -8372,7 +8464,7 @@ const App = {
         const path = currentPath + filename;
         const url = selectedBucket.public
             ? `/storage/v1/object/public/${selectedBucket.name}/${path}`
-            : `/_/api/storage/objects/download?bucket=${encodeURIComponent(selectedBucket.name)}&path=${encodeURIComponent(path)}`;
+            : `${API_BASE}/storage/objects/download?bucket=${encodeURIComponent(selectedBucket.name)}&path=${encodeURIComponent(path)}`;
 
         this.state.modal = {
             type: 'filePreview',
@@ -8499,7 +8591,7 @@ const App = {
         const fullPath = path && this._contextMenuType === 'dir' ? `${path}/${filename}` : filename;
 
         try {
-            const res = await fetch(`/_/api/functions/${name}/files/${fullPath}`, {
+            const res = await fetch(`${API_BASE}/functions/${name}/files/${fullPath}`, {
                 method: 'PUT',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ content: '' })
@@ -8531,7 +8623,7 @@ const App = {
 
         // Create folder by creating a placeholder file
         try {
-            const res = await fetch(`/_/api/functions/${name}/files/${fullPath}/.gitkeep`, {
+            const res = await fetch(`${API_BASE}/functions/${name}/files/${fullPath}/.gitkeep`, {
                 method: 'PUT',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ content: '' })
@@ -8561,7 +8653,7 @@ const App = {
         const newPath = oldPath.replace(/[^/]+$/, newName);
 
         try {
-            const res = await fetch(`/_/api/functions/${name}/files/rename`, {
+            const res = await fetch(`${API_BASE}/functions/${name}/files/rename`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ oldPath, newPath })
@@ -8591,7 +8683,7 @@ const App = {
         if (!confirm(`Delete ${path}?`)) return;
 
         try {
-            const res = await fetch(`/_/api/functions/${name}/files/${path}`, {
+            const res = await fetch(`${API_BASE}/functions/${name}/files/${path}`, {
                 method: 'DELETE'
             });
 
@@ -8601,6 +8693,7 @@ const App = {
                     this.state.functions.editor.currentFile = null;
                     this.state.functions.editor.content = '';
                     this.state.functions.editor.originalContent = '';
+                    this.state.functions.editor.baseHash = null;
                     this.state.functions.editor.isDirty = false;
                     if (this.state.functions.editor.monacoEditor) {
                         this.state.functions.editor.monacoEditor.setValue('// Select a file to edit');
@@ -8947,7 +9040,7 @@ const App = {
     downloadFile(filename) {
         const { selectedBucket, currentPath } = this.state.storage;
         const path = currentPath + filename;
-        const url = `/_/api/storage/objects/download?bucket=${encodeURIComponent(selectedBucket.name)}&path=${encodeURIComponent(path)}`;
+        const url = `${API_BASE}/storage/objects/download?bucket=${encodeURIComponent(selectedBucket.name)}&path=${encodeURIComponent(path)}`;
 
         // Create temporary link and click
         const a = document.createElement('a');
@@ -8978,7 +9071,7 @@ const App = {
 
         try {
             const paths = selectedFiles.map(f => currentPath + f);
-            const res = await fetch('/_/api/storage/objects', {
+            const res = await fetch(API_BASE + '/storage/objects', {
                 method: 'DELETE',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -9059,8 +9152,8 @@ const App = {
         try {
             // Load tables and functions in parallel
             const [tablesRes, functionsRes] = await Promise.all([
-                fetch('/_/api/apidocs/tables'),
-                fetch('/_/api/apidocs/functions')
+                fetch(API_BASE + '/apidocs/tables'),
+                fetch(API_BASE + '/apidocs/functions')
             ]);
 
             if (tablesRes.ok) {
@@ -9098,7 +9191,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch(`/_/api/apidocs/tables/${encodeURIComponent(tableName)}`);
+            const res = await fetch(`${API_BASE}/apidocs/tables/${encodeURIComponent(tableName)}`);
             if (res.ok) {
                 this.state.apiDocs.selectedTable = await res.json();
             }
@@ -9115,7 +9208,7 @@ const App = {
         this.render();
 
         try {
-            const res = await fetch(`/_/api/apidocs/functions/${encodeURIComponent(funcName)}`);
+            const res = await fetch(`${API_BASE}/apidocs/functions/${encodeURIComponent(funcName)}`);
             if (res.ok) {
                 this.state.apiDocs.selectedFunction = await res.json();
             }
@@ -9134,7 +9227,7 @@ const App = {
 
     async updateTableDescription(tableName, description) {
         try {
-            const res = await fetch(`/_/api/apidocs/tables/${encodeURIComponent(tableName)}/description`, {
+            const res = await fetch(`${API_BASE}/apidocs/tables/${encodeURIComponent(tableName)}/description`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ description })
@@ -9150,7 +9243,7 @@ const App = {
 
     async updateColumnDescription(tableName, columnName, description) {
         try {
-            const res = await fetch(`/_/api/apidocs/tables/${encodeURIComponent(tableName)}/columns/${encodeURIComponent(columnName)}/description`, {
+            const res = await fetch(`${API_BASE}/apidocs/tables/${encodeURIComponent(tableName)}/columns/${encodeURIComponent(columnName)}/description`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ description })
@@ -9169,7 +9262,7 @@ const App = {
 
     async updateFunctionDescription(funcName, description) {
         try {
-            const res = await fetch(`/_/api/apidocs/functions/${encodeURIComponent(funcName)}/description`, {
+            const res = await fetch(`${API_BASE}/apidocs/functions/${encodeURIComponent(funcName)}/description`, {
                 method: 'PATCH',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ description })
@@ -9501,8 +9594,9 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
                 <h2>Available Tables</h2>
                 ${tables.length === 0
                     ? '<p>No user tables found. Create a table in the <a onclick="App.navigate(\'tables\')" style="cursor:pointer;color:var(--accent)">Tables</a> view to get started.</p>'
-                    : `<ul>${tables.map(t => `<li><a onclick="App.navigateApiDocs('table', '${this.escapeJsString(t.name)}')" style="cursor:pointer;color:var(--accent)">${this.escapeHtml(t.name)}</a></li>`).join('')}</ul>`
+                    : `<ul>${tables.map(t => `<li><a onclick="App.navigateApiDocs('table', '${this.escapeJsString(t.name)}')" style="cursor:pointer;color:var(--accent)">${this.escapeHtml(t.name)}</a>${t.is_view ? ' <span class="api-docs-badge type">VIEW</span>' : ''}</li>`).join('')}</ul>`
                 }
+                <p>Views are read-only: they support reads but not inserts, updates or deletes.</p>
 
                 <h2>REST Conventions</h2>
                 <p>The API follows PostgREST conventions:</p>
@@ -9557,13 +9651,13 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         const { language, selectedTable } = this.state.apiDocs;
         if (!selectedTable) return '<div class="loading">Loading...</div>';
 
-        const { name, description, columns } = selectedTable;
+        const { name, description, columns, is_view } = selectedTable;
         const baseUrl = this.getApiBaseUrl();
 
         return `
             <div class="api-docs-page">
                 ${this.renderApiDocsLanguageTabs()}
-                <h1>${this.escapeHtml(name)}</h1>
+                <h1>${this.escapeHtml(name)}${is_view ? ' <span class="api-docs-badge type">VIEW</span>' : ''}</h1>
 
                 <div class="api-docs-section">
                     <h2>Description</h2>
@@ -9576,7 +9670,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
 
                 ${columns.map(col => this.renderApiDocsColumn(name, col)).join('')}
 
-                ${this.renderApiDocsTableOperations(name, columns)}
+                ${this.renderApiDocsTableOperations(name, columns, is_view)}
             </div>
         `;
     },
@@ -9617,7 +9711,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         `;
     },
 
-    renderApiDocsTableOperations(tableName, columns) {
+    renderApiDocsTableOperations(tableName, columns, isView) {
         const { language } = this.state.apiDocs;
         const baseUrl = this.getApiBaseUrl();
         const allColumns = columns.map(c => c.name).join(', ');
@@ -9744,7 +9838,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
             </div>
         `;
 
-        return `
+        const readSection = `
             <div class="api-docs-section">
                 <h2>Read Rows</h2>
                 ${renderOp('readAll')}
@@ -9752,6 +9846,12 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
                 ${renderOp('pagination')}
                 ${renderOp('filter')}
             </div>
+        `;
+        // Views only support reads
+        if (isView) return readSection;
+
+        return `
+            ${readSection}
 
             <div class="api-docs-section">
                 <h2>Insert Rows</h2>
@@ -9860,7 +9960,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         this.render();
 
         try {
-            const res = await fetch('/_/api/realtime/stats');
+            const res = await fetch(API_BASE + '/realtime/stats');
             if (res.status === 503) {
                 // Realtime not enabled
                 this.state.realtime.enabled = false;
@@ -9989,6 +10089,11 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
                     <div class="stat-card">
                         <div class="stat-label">Sample Rate</div>
                         <div class="stat-value">${(config?.sampleRate * 100).toFixed(0)}%</div>
+                        ${(config?.routeSampling || []).length ? `
+                            <div style="font-size: 0.8rem; color: var(--text-muted);">
+                                ${config.routeSampling.map(r => `${this.escapeHtml(r.prefix)}: ${(r.rate * 100).toFixed(0)}%`).join('<br>')}
+                            </div>
+                        ` : ''}
                     </div>
                     <div class="stat-card">
                         <div class="stat-label">Metrics</div>
@@ -10168,6 +10273,14 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
                                             <td style="${isSlow ? 'color: var(--warning); font-weight: bold;' : ''}">${duration.toFixed(1)}ms</td>
                                             <td style="font-size: 0.8rem; color: var(--text-muted); max-width: 300px; overflow: hidden; text-overflow: ellipsis;" title="${t.tags}">${t.tags}</td>
                                         </tr>
+                                        ${(t.spans || []).map(s => `
+                                            <tr class="trace-span-row" style="font-size: 0.85rem; color: var(--text-muted);">
+                                                <td></td>
+                                                <td colspan="2">&#8627; <code>function.invoke</code></td>
+                                                <td>${(s.duration_ms || 0).toFixed(1)}ms</td>
+                                                <td>${this.escapeHtml(s['function.name'] || '')} (${s['http.status_code'] || 'N/A'})</td>
+                                            </tr>
+                                        `).join('')}
                                     `;
                                 }).join('')}
                             </tbody>
@@ -10370,7 +10483,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         this.render();
 
         try {
-            const res = await fetch('/_/api/migrations');
+            const res = await fetch(API_BASE + '/migrations');
             if (!res.ok) {
                 throw new Error('Failed to load migrations');
             }
@@ -10563,9 +10676,9 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
                 alert('No tables to export');
                 return;
             }
-            window.location.href = `/_/api/export/data?tables=${encodeURIComponent(tables)}&format=json`;
+            window.location.href = `${API_BASE}/export/data?tables=${encodeURIComponent(tables)}&format=json`;
         } else {
-            window.location.href = `/_/api/export/${type}`;
+            window.location.href = `${API_BASE}/export/${type}`;
         }
     },
 
@@ -10673,7 +10786,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
 
         try {
             // Start a new migration session
-            const startRes = await fetch('/_/api/migration/start', {
+            const startRes = await fetch(API_BASE + '/migration/start', {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' }
             });
@@ -10684,7 +10797,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
             this.state.migration.currentMigration = { id };
 
             // Connect with the token
-            const connectRes = await fetch(`/_/api/migration/${id}/connect`, {
+            const connectRes = await fetch(`${API_BASE}/migration/${id}/connect`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ token })
@@ -10695,7 +10808,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
             }
 
             // Fetch projects
-            const projectsRes = await fetch(`/_/api/migration/${id}/projects`);
+            const projectsRes = await fetch(`${API_BASE}/migration/${id}/projects`);
             if (!projectsRes.ok) {
                 throw new Error('Failed to fetch projects');
             }
@@ -10771,7 +10884,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
 
         try {
             // Select the project
-            const res = await fetch(`/_/api/migration/${currentMigration.id}/select`, {
+            const res = await fetch(`${API_BASE}/migration/${currentMigration.id}/select`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({
@@ -10923,7 +11036,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         }
 
         try {
-            const res = await fetch(`/_/api/migration/${currentMigration.id}/run`, {
+            const res = await fetch(`${API_BASE}/migration/${currentMigration.id}/run`, {
                 method: 'POST',
                 headers: { 'Content-Type': 'application/json' },
                 body: JSON.stringify({ items })
@@ -10961,7 +11074,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         if (!currentMigration?.id) return;
 
         try {
-            const res = await fetch(`/_/api/migration/${currentMigration.id}`);
+            const res = await fetch(`${API_BASE}/migration/${currentMigration.id}`);
             if (!res.ok) return;
 
             const data = await res.json();
@@ -11080,7 +11193,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         if (!currentMigration?.id) return;
 
         try {
-            const res = await fetch(`/_/api/migration/${currentMigration.id}/retry`, {
+            const res = await fetch(`${API_BASE}/migration/${currentMigration.id}/retry`, {
                 method: 'POST'
             });
             if (!res.ok) {
@@ -11107,7 +11220,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         this.render();
 
         try {
-            const res = await fetch(`/_/api/migration/${currentMigration.id}/rollback`, {
+            const res = await fetch(`${API_BASE}/migration/${currentMigration.id}/rollback`, {
                 method: 'POST'
             });
             if (!res.ok) {
@@ -11129,7 +11242,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         const { currentMigration } = this.state.migration;
         if (currentMigration?.id) {
             try {
-                await fetch(`/_/api/migration/${currentMigration.id}`, {
+                await fetch(`${API_BASE}/migration/${currentMigration.id}`, {
                     method: 'DELETE'
                 });
             } catch (e) {
@@ -11280,7 +11393,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
         this.render();
 
         try {
-            const res = await fetch(`/_/api/migration/${currentMigration.id}/verify/${layer}`, {
+            const res = await fetch(`${API_BASE}/migration/${currentMigration.id}/verify/${layer}`, {
                 method: 'POST'
             });
             if (!res.ok) {
@@ -11373,7 +11486,7 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
 
     async viewMigration(id) {
         try {
-            const res = await fetch(`/_/api/migration/${id}`);
+            const res = await fetch(`${API_BASE}/migration/${id}`);
             if (!res.ok) throw new Error('Failed to load migration');
 
             const data = await res.json();
@@ -48,7 +48,7 @@ type authConfigUpdate struct {
 func (h *Handler) handlePatchAuthConfig(w http.ResponseWriter, r *http.Request) {
 	var updates authConfigUpdate
 	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
 		return
 	}
 
@@ -0,0 +1,38 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// defaultBasePath is where the dashboard is mounted unless SetBasePath
+// configures another path.
+const defaultBasePath = "/_"
+
+// SetBasePath sets the path the dashboard routes are mounted under, without a
+// trailing slash, e.g. "/admin".
+func (h *Handler) SetBasePath(path string) {
+	h.basePath = path
+}
+
+// BasePath returns the path the dashboard is mounted under.
+func (h *Handler) BasePath() string {
+	if h.basePath == "" {
+		return defaultBasePath
+	}
+	return h.basePath
+}
+
+// renderIndex adapts index.html to the base path. The root-relative asset
+// URLs are written against the default path and are rewritten, and the base
+// path is exposed to the frontend as window.__SBLITE_BASE__ so it can build
+// API URLs and router paths.
+func (h *Handler) renderIndex(content []byte) []byte {
+	base := h.BasePath()
+	if base != defaultBasePath {
+		content = bytes.ReplaceAll(content, []byte(`"`+defaultBasePath+`/`), []byte(`"`+base+`/`))
+	}
+	encoded, _ := json.Marshal(base)
+	script := []byte("<head>\n    <script>window.__SBLITE_BASE__=" + string(encoded) + "</script>")
+	return bytes.Replace(content, []byte("<head>"), script, 1)
+}
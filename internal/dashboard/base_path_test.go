@@ -0,0 +1,39 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerBasePath(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	handler.SetBasePath("/admin")
+	r := chi.NewRouter()
+	r.Route("/admin", handler.RegisterRoutes)
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/admin/", w.Header().Get("Location"))
+
+	req = httptest.NewRequest("GET", "/admin/", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, `window.__SBLITE_BASE__="/admin"`)
+	assert.Contains(t, body, `"/admin/static/app.js"`)
+	assert.NotContains(t, body, `"/_/`)
+
+	// Session cookies are scoped to the base path by default
+	assert.Equal(t, "/admin/", handler.sessionCookieSettings().Path)
+}
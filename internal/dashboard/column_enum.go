@@ -0,0 +1,191 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/types"
+)
+
+// columnEnumError reports a value outside a column's allowed values.
+type columnEnumError struct {
+	Column  string
+	Value   interface{}
+	Allowed []string
+}
+
+func (e *columnEnumError) Error() string {
+	return fmt.Sprintf("column %q must be one of %s, got %v", e.Column, strings.Join(e.Allowed, ", "), e.Value)
+}
+
+// parseEnumValues decodes the enum_values JSON stored in _columns.
+func parseEnumValues(raw sql.NullString) []string {
+	if !raw.Valid || raw.String == "" {
+		return nil
+	}
+	var values []string
+	if json.Unmarshal([]byte(raw.String), &values) != nil || len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// getColumnEnums returns the allowed values declared for a table's columns.
+func (h *Handler) getColumnEnums(tableName string) map[string][]string {
+	enums := make(map[string][]string)
+	rows, err := h.db.Query(`SELECT column_name, enum_values FROM _columns
+		WHERE table_name = ? AND enum_values IS NOT NULL AND enum_values != ''`, tableName)
+	if err != nil {
+		return enums
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var raw sql.NullString
+		if rows.Scan(&name, &raw) != nil {
+			continue
+		}
+		if values := parseEnumValues(raw); values != nil {
+			enums[name] = values
+		}
+	}
+	return enums
+}
+
+// validateColumnEnums checks incoming values against their column's allowed
+// values. Null values are not validated; nullability is enforced by the
+// database.
+func validateColumnEnums(enums map[string][]string, data map[string]interface{}) error {
+	cols := make([]string, 0, len(data))
+	for col := range data {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	for _, col := range cols {
+		allowed, ok := enums[col]
+		if !ok || data[col] == nil {
+			continue
+		}
+		if !types.EnumAllows(allowed, data[col]) {
+			return &columnEnumError{Column: col, Value: data[col], Allowed: allowed}
+		}
+	}
+	return nil
+}
+
+// writeColumnEnumError writes a 400 response naming the column and its
+// allowed values.
+func writeColumnEnumError(w http.ResponseWriter, err error) {
+	var details map[string]interface{}
+	if ee, ok := err.(*columnEnumError); ok {
+		details = map[string]interface{}{
+			"column":  ee.Column,
+			"allowed": ee.Allowed,
+		}
+	}
+	writeErrorDetails(w, http.StatusBadRequest, "invalid_enum_value", err.Error(), details)
+}
+
+// handleGetColumnEnum returns the allowed values declared for a column.
+// GET /_/api/tables/{name}/columns/{column}/enum
+func (h *Handler) handleGetColumnEnum(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	var raw sql.NullString
+	err := h.db.QueryRow(`SELECT enum_values FROM _columns WHERE table_name = ? AND column_name = ?`,
+		tableName, columnName).Scan(&raw)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get column enum values")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":       tableName,
+		"column":      columnName,
+		"enum_values": parseEnumValues(raw),
+	})
+}
+
+// handleSetColumnEnum declares the allowed values for a column, or removes
+// them when enum_values is null or empty. Existing rows must already use
+// allowed values, otherwise it answers 409 with how many don't.
+// PUT /_/api/tables/{name}/columns/{column}/enum
+func (h *Handler) handleSetColumnEnum(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	var req struct {
+		EnumValues []string `json:"enum_values"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	seen := make(map[string]bool, len(req.EnumValues))
+	for _, v := range req.EnumValues {
+		if v == "" {
+			writeError(w, http.StatusBadRequest, "invalid_enum_values", "enum_values must not contain empty strings")
+			return
+		}
+		if seen[v] {
+			writeError(w, http.StatusBadRequest, "invalid_enum_values", fmt.Sprintf("duplicate enum value %q", v))
+			return
+		}
+		seen[v] = true
+	}
+
+	// Make sure columns of tables created outside the dashboard are registered
+	h.ensureTableRegistered(tableName)
+
+	var exists int
+	if err := h.db.QueryRow(`SELECT 1 FROM _columns WHERE table_name = ? AND column_name = ?`,
+		tableName, columnName).Scan(&exists); err != nil {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+
+	var stored interface{}
+	if len(req.EnumValues) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(req.EnumValues)), ", ")
+		args := make([]interface{}, len(req.EnumValues))
+		for i, v := range req.EnumValues {
+			args[i] = v
+		}
+		var invalid int
+		query := fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE "%s" IS NOT NULL AND CAST("%s" AS TEXT) NOT IN (%s)`,
+			tableName, columnName, columnName, placeholders)
+		if err := h.db.QueryRow(query, args...).Scan(&invalid); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to check existing values")
+			return
+		}
+		if invalid > 0 {
+			writeErrorDetails(w, http.StatusConflict, "enum_values_conflict",
+				fmt.Sprintf("%d existing rows have values outside enum_values", invalid),
+				map[string]interface{}{"invalid_rows": invalid})
+			return
+		}
+		data, _ := json.Marshal(req.EnumValues)
+		stored = string(data)
+	}
+
+	if _, err := h.db.Exec(`UPDATE _columns SET enum_values = ? WHERE table_name = ? AND column_name = ?`,
+		stored, tableName, columnName); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to save column enum values")
+		return
+	}
+
+	h.handleGetColumnEnum(w, r)
+}
@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnEnumValues(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE accounts (id INTEGER PRIMARY KEY, status TEXT);
+		INSERT INTO accounts (id, status) VALUES (1, 'active'), (2, NULL)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, is_primary) VALUES
+		('accounts', 'id', 'integer', false, true), ('accounts', 'status', 'text', true, false)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}", handler.handleGetTableSchema)
+	r.Get("/tables/{name}/ddl", handler.handleTableDDL)
+	r.Get("/tables/{name}/columns/{column}/enum", handler.handleGetColumnEnum)
+	r.Put("/tables/{name}/columns/{column}/enum", handler.handleSetColumnEnum)
+	r.Post("/data/{table}", handler.handleInsertData)
+	r.Patch("/data/{table}", handler.handleUpdateData)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Existing rows must already fit
+	w := do("PUT", "/tables/accounts/columns/status/enum", `{"enum_values": ["inactive"]}`)
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "enum_values_conflict")
+
+	w = do("PUT", "/tables/accounts/columns/status/enum", `{"enum_values": ["a", "a"]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do("PUT", "/tables/accounts/columns/status/enum", `{"enum_values": ["active", "inactive"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `{"table": "accounts", "column": "status", "enum_values": ["active", "inactive"]}`, w.Body.String())
+
+	w = do("PUT", "/tables/accounts/columns/missing/enum", `{"enum_values": ["x"]}`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	// Surfaced in the table schema
+	w = do("GET", "/tables/accounts", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var schema struct {
+		Columns []map[string]interface{} `json:"columns"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+	for _, col := range schema.Columns {
+		if col["name"] == "status" {
+			assert.Equal(t, []interface{}{"active", "inactive"}, col["enum_values"])
+		} else {
+			assert.NotContains(t, col, "enum_values")
+		}
+	}
+
+	// Enforced on insert and update; null passes
+	w = do("POST", "/data/accounts", `{"id": 3, "status": "banned"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_enum_value")
+	w = do("POST", "/data/accounts", `{"id": 3, "status": "inactive"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do("POST", "/data/accounts", `{"id": 4, "status": null}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = do("PATCH", "/data/accounts?id=eq.1", `{"status": "banned"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Exported as a CHECK constraint
+	w = do("GET", "/tables/accounts/ddl?dialect=postgres", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `ALTER TABLE accounts ADD CHECK (status IN ('active', 'inactive'));`)
+
+	// An empty list removes the constraint
+	w = do("PUT", "/tables/accounts/columns/status/enum", `{"enum_values": []}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"table": "accounts", "column": "status", "enum_values": null}`, w.Body.String())
+	w = do("POST", "/data/accounts", `{"id": 5, "status": "banned"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+}
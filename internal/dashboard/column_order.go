@@ -0,0 +1,116 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// columnOrder returns a table's column names in display order. Columns with
+// an explicit ordinal in _columns sort by it; the rest fall back to their
+// physical position, which SQLite can't change after creation.
+func (h *Handler) columnOrder(tableName string) ([]string, error) {
+	rows, err := h.db.Query(`SELECT p.name FROM pragma_table_info(?) p
+		LEFT JOIN _columns c ON c.table_name = ? AND c.column_name = p.name
+		ORDER BY COALESCE(c.ordinal, p.cid), p.cid`, tableName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// handleSetColumnPosition moves a column to a new zero-based position in the
+// table's display order. Only the ordinal metadata in _columns changes; the
+// physical column order stays as it was created.
+// PATCH /_/api/tables/{name}/columns/{column}/position
+func (h *Handler) handleSetColumnPosition(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	var req struct {
+		Position *int `json:"position"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.Position == nil {
+		writeError(w, http.StatusBadRequest, "missing_field", "position is required")
+		return
+	}
+
+	// Make sure columns of tables created outside the dashboard are registered
+	h.ensureTableRegistered(tableName)
+
+	order, err := h.columnOrder(tableName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get table info")
+		return
+	}
+	if len(order) == 0 {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+
+	current := -1
+	for i, name := range order {
+		if name == columnName {
+			current = i
+			break
+		}
+	}
+	if current < 0 {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+	if *req.Position < 0 || *req.Position >= len(order) {
+		writeError(w, http.StatusBadRequest, "invalid_position",
+			fmt.Sprintf("position must be between 0 and %d", len(order)-1))
+		return
+	}
+
+	reordered := make([]string, 0, len(order))
+	reordered = append(reordered, order[:current]...)
+	reordered = append(reordered, order[current+1:]...)
+	reordered = append(reordered[:*req.Position], append([]string{columnName}, reordered[*req.Position:]...)...)
+
+	err = h.runWrite(r, func() error {
+		tx, err := h.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		for i, name := range reordered {
+			if _, err := tx.Exec(`UPDATE _columns SET ordinal = ? WHERE table_name = ? AND column_name = ?`,
+				i, tableName, name); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update column position")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":    tableName,
+		"column":   columnName,
+		"position": *req.Position,
+		"columns":  reordered,
+	})
+}
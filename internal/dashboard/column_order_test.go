@@ -0,0 +1,67 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetColumnPosition(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE people (id INTEGER PRIMARY KEY, first TEXT, last TEXT, email TEXT)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}", handler.handleGetTableSchema)
+	r.Patch("/tables/{name}/columns/{column}/position", handler.handleSetColumnPosition)
+
+	move := func(column, body string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("PATCH", "/tables/people/columns/"+column+"/position", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := move("email", `{"position": 1}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"id", "email", "first", "last"}, resp["columns"])
+
+	code, resp = move("id", `{"position": 3}`)
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []interface{}{"email", "first", "last", "id"}, resp["columns"])
+
+	// The schema renders columns in the stored order
+	req := httptest.NewRequest("GET", "/tables/people", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var schema struct {
+		Columns []struct {
+			Name string `json:"name"`
+		} `json:"columns"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+	var names []string
+	for _, col := range schema.Columns {
+		names = append(names, col.Name)
+	}
+	assert.Equal(t, []string{"email", "first", "last", "id"}, names)
+
+	code, _ = move("email", `{"position": 4}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = move("email", `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = move("missing", `{"position": 0}`)
+	assert.Equal(t, http.StatusNotFound, code)
+}
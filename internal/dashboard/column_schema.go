@@ -0,0 +1,150 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/types"
+)
+
+// columnSchemaError reports JSON Schema violations for a column.
+type columnSchemaError struct {
+	Column string
+	Errors []string
+}
+
+func (e *columnSchemaError) Error() string {
+	return fmt.Sprintf("column %q does not match its JSON schema: %s", e.Column, strings.Join(e.Errors, "; "))
+}
+
+// getColumnJSONSchemas returns the parsed JSON Schemas attached to a table's columns.
+func (h *Handler) getColumnJSONSchemas(tableName string) map[string]map[string]any {
+	schemas := make(map[string]map[string]any)
+	rows, err := h.db.Query(`SELECT column_name, json_schema FROM _columns
+		WHERE table_name = ? AND json_schema IS NOT NULL AND json_schema != ''`, tableName)
+	if err != nil {
+		return schemas
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, raw string
+		if rows.Scan(&name, &raw) != nil {
+			continue
+		}
+		if schema, err := types.ParseJSONSchema(raw); err == nil {
+			schemas[name] = schema
+		}
+	}
+	return schemas
+}
+
+// validateColumnSchemas checks incoming values against their column's JSON Schema.
+// columnTypes gives each column's pg_type, so that only jsonb text is decoded
+// as JSON. Null values are not validated; nullability is enforced by the database.
+func validateColumnSchemas(schemas map[string]map[string]any, columnTypes map[string]string, data map[string]interface{}) error {
+	cols := make([]string, 0, len(data))
+	for col := range data {
+		cols = append(cols, col)
+	}
+	sort.Strings(cols)
+
+	for _, col := range cols {
+		schema, ok := schemas[col]
+		if !ok || data[col] == nil {
+			continue
+		}
+		if errs := types.ValidateJSONSchema(schema, types.PgType(columnTypes[col]), data[col]); len(errs) > 0 {
+			return &columnSchemaError{Column: col, Errors: errs}
+		}
+	}
+	return nil
+}
+
+// writeColumnSchemaError writes a 400 response listing the schema violations.
+func writeColumnSchemaError(w http.ResponseWriter, err error) {
+	var details map[string]interface{}
+	if se, ok := err.(*columnSchemaError); ok {
+		details = map[string]interface{}{
+			"column":            se.Column,
+			"validation_errors": se.Errors,
+		}
+	}
+	writeErrorDetails(w, http.StatusBadRequest, "schema_validation_failed", err.Error(), details)
+}
+
+// handleGetColumnSchema returns the JSON Schema attached to a column.
+// GET /_/api/tables/{name}/columns/{column}/schema
+func (h *Handler) handleGetColumnSchema(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	var raw sql.NullString
+	err := h.db.QueryRow(`SELECT json_schema FROM _columns WHERE table_name = ? AND column_name = ?`,
+		tableName, columnName).Scan(&raw)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get column schema")
+		return
+	}
+
+	var schema interface{}
+	if raw.Valid && raw.String != "" {
+		json.Unmarshal([]byte(raw.String), &schema)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":  tableName,
+		"column": columnName,
+		"schema": schema,
+	})
+}
+
+// handleSetColumnSchema attaches a JSON Schema to a column, or removes it when
+// the schema is null.
+// PUT /_/api/tables/{name}/columns/{column}/schema
+func (h *Handler) handleSetColumnSchema(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	var req struct {
+		Schema json.RawMessage `json:"schema"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	var stored interface{}
+	if len(req.Schema) > 0 && string(req.Schema) != "null" {
+		if _, err := types.ParseJSONSchema(string(req.Schema)); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		stored = string(req.Schema)
+	}
+
+	// Make sure columns of tables created outside the dashboard are registered
+	h.ensureTableRegistered(tableName)
+
+	result, err := h.db.Exec(`UPDATE _columns SET json_schema = ? WHERE table_name = ? AND column_name = ?`,
+		stored, tableName, columnName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to save column schema")
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+
+	h.handleGetColumnSchema(w, r)
+}
@@ -0,0 +1,77 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnSchemaValidatesInsert(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE profiles (id INTEGER PRIMARY KEY, settings TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, is_primary) VALUES
+		('profiles', 'id', 'integer', false, true), ('profiles', 'settings', 'jsonb', true, false)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Put("/tables/{name}/columns/{column}/schema", handler.handleSetColumnSchema)
+	r.Post("/data/{table}", handler.handleInsertData)
+
+	schema := `{"schema": {"type": "object", "required": ["theme"], "properties": {"theme": {"enum": ["light", "dark"]}}}}`
+	req := httptest.NewRequest("PUT", "/tables/profiles/columns/settings/schema", bytes.NewBufferString(schema))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("POST", "/data/profiles", bytes.NewBufferString(`{"id": 1, "settings": {"theme": "blue"}}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "schema_validation_failed", resp["code"])
+	details := resp["details"].(map[string]interface{})
+	assert.Equal(t, "settings", details["column"])
+	assert.NotEmpty(t, details["validation_errors"])
+
+	req = httptest.NewRequest("POST", "/data/profiles", bytes.NewBufferString(`{"id": 2, "settings": {"theme": "dark"}}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var stored string
+	require.NoError(t, database.QueryRow(`SELECT settings FROM profiles WHERE id = 2`).Scan(&stored))
+	assert.JSONEq(t, `{"theme":"dark"}`, stored)
+}
+
+func TestColumnSchemaTextColumnKeepsStrings(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE accounts (id INTEGER PRIMARY KEY, zip TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, is_primary, json_schema) VALUES
+		('accounts', 'id', 'integer', false, true, NULL), ('accounts', 'zip', 'text', true, false, '{"type": "string"}')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/data/{table}", handler.handleInsertData)
+
+	// A numeric-looking string is not decoded as a JSON number for text columns
+	req := httptest.NewRequest("POST", "/data/accounts", bytes.NewBufferString(`{"id": 1, "zip": "12345"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
@@ -0,0 +1,268 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// columnStatsTTL is how long computed column statistics are reused.
+	columnStatsTTL = 30 * time.Second
+	// defaultTopValues is how many frequent values are returned for text columns.
+	defaultTopValues = 10
+	maxTopValues     = 100
+	// defaultDistinctValues and maxDistinctValues bound the distinct endpoint.
+	defaultDistinctValues = 100
+	maxDistinctValues     = 1000
+)
+
+// ColumnStats profiles the values of a single column.
+type ColumnStats struct {
+	Table         string            `json:"table"`
+	Column        string            `json:"column"`
+	Type          string            `json:"type"`
+	Count         int64             `json:"count"`
+	NullCount     int64             `json:"null_count"`
+	DistinctCount int64             `json:"distinct_count"`
+	Min           interface{}       `json:"min"`
+	Max           interface{}       `json:"max"`
+	Avg           *float64          `json:"avg,omitempty"`        // numeric columns only
+	TopValues     []ColumnValueFreq `json:"top_values,omitempty"` // text columns only
+	ComputedAt    time.Time         `json:"computed_at"`
+}
+
+// ColumnValueFreq is a value and how many rows hold it.
+type ColumnValueFreq struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+type columnStatsEntry struct {
+	stats   *ColumnStats
+	expires time.Time
+}
+
+// columnStatsCache keeps recently computed column statistics, since they
+// require a full table scan.
+type columnStatsCache struct {
+	mu      sync.Mutex
+	entries map[string]columnStatsEntry
+}
+
+func newColumnStatsCache() *columnStatsCache {
+	return &columnStatsCache{entries: make(map[string]columnStatsEntry)}
+}
+
+func (c *columnStatsCache) get(key string) *ColumnStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil
+	}
+	return entry.stats
+}
+
+func (c *columnStatsCache) put(key string, stats *ColumnStats) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = columnStatsEntry{stats: stats, expires: time.Now().Add(columnStatsTTL)}
+}
+
+// handleColumnStats returns count, null count, distinct count, min and max for
+// a column, plus the average for numeric columns and the most frequent values
+// for text columns. Results are cached briefly; pass refresh=true to recompute.
+// GET /_/api/tables/{name}/columns/{column}/stats?top=10
+func (h *Handler) handleColumnStats(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	top := defaultTopValues
+	if t := r.URL.Query().Get("top"); t != "" {
+		parsed, err := strconv.Atoi(t)
+		if err != nil || parsed < 0 || parsed > maxTopValues {
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("top must be between 0 and %d", maxTopValues))
+			return
+		}
+		top = parsed
+	}
+
+	var declaredType string
+	err := h.db.QueryRow(`SELECT type FROM pragma_table_info(?) WHERE name = ?`, tableName, columnName).Scan(&declaredType)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	key := fmt.Sprintf("%s\x00%s\x00%d", tableName, columnName, top)
+	if r.URL.Query().Get("refresh") != "true" {
+		if stats := h.columnStats.get(key); stats != nil {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(stats)
+			return
+		}
+	}
+
+	colType := h.columnType(tableName, columnName, declaredType)
+	stats, err := h.computeColumnStats(tableName, columnName, colType, top)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	h.columnStats.put(key, stats)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// columnType returns the column's registered type from _columns, falling back
+// to the SQLite declared type.
+func (h *Handler) columnType(table, column, declaredType string) string {
+	var pgType string
+	if err := h.db.QueryRow(`SELECT pg_type FROM _columns WHERE table_name = ? AND column_name = ?`,
+		table, column).Scan(&pgType); err == nil && pgType != "" {
+		return pgType
+	}
+	return strings.ToLower(declaredType)
+}
+
+// isNumericColumnType reports whether a registered or declared type holds numbers.
+func isNumericColumnType(colType string) bool {
+	t := strings.ToUpper(colType)
+	for _, s := range []string{"INT", "REAL", "FLOA", "DOUB", "NUMERIC", "DECIMAL"} {
+		if strings.Contains(t, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTextColumnType reports whether a registered or declared type holds text.
+func isTextColumnType(colType string) bool {
+	t := strings.ToUpper(colType)
+	return t == "" || strings.Contains(t, "TEXT") || strings.Contains(t, "CHAR") ||
+		strings.Contains(t, "CLOB") || t == "UUID"
+}
+
+// computeColumnStats gathers the aggregates in one scan, then the most
+// frequent values for text columns.
+func (h *Handler) computeColumnStats(table, column, colType string, top int) (*ColumnStats, error) {
+	stats := &ColumnStats{Table: table, Column: column, Type: colType, ComputedAt: time.Now().UTC()}
+	numeric := isNumericColumnType(colType)
+
+	aggregates := fmt.Sprintf(`COUNT(*), COUNT("%s"), COUNT(DISTINCT "%s"), MIN("%s"), MAX("%s")`,
+		column, column, column, column)
+	if numeric {
+		aggregates += fmt.Sprintf(`, AVG("%s")`, column)
+	}
+
+	var nonNull int64
+	var avg sql.NullFloat64
+	dest := []interface{}{&stats.Count, &nonNull, &stats.DistinctCount, &stats.Min, &stats.Max}
+	if numeric {
+		dest = append(dest, &avg)
+	}
+	if err := h.db.QueryRow(fmt.Sprintf(`SELECT %s FROM "%s"`, aggregates, table)).Scan(dest...); err != nil {
+		return nil, err
+	}
+	stats.NullCount = stats.Count - nonNull
+	if avg.Valid {
+		stats.Avg = &avg.Float64
+	}
+
+	if top > 0 && isTextColumnType(colType) {
+		rows, err := h.db.Query(fmt.Sprintf(`SELECT "%s", COUNT(*) AS n FROM "%s" WHERE "%s" IS NOT NULL
+			GROUP BY "%s" ORDER BY n DESC, "%s" LIMIT ?`, column, table, column, column, column), top)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		stats.TopValues = []ColumnValueFreq{}
+		for rows.Next() {
+			var freq ColumnValueFreq
+			if err := rows.Scan(&freq.Value, &freq.Count); err != nil {
+				return nil, err
+			}
+			stats.TopValues = append(stats.TopValues, freq)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// handleColumnDistinct returns the distinct non-null values of a column with
+// how many rows hold each, most frequent first, for building filter
+// dropdowns. truncated reports whether more values exist beyond the limit.
+// GET /_/api/tables/{name}/columns/{column}/distinct?limit=100
+func (h *Handler) handleColumnDistinct(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	limit := defaultDistinctValues
+	if l := r.URL.Query().Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > maxDistinctValues {
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("limit must be between 1 and %d", maxDistinctValues))
+			return
+		}
+		limit = parsed
+	}
+
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, tableName, columnName).Scan(&exists)
+	if exists == 0 {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+
+	// Fetch one extra value to tell whether the list was cut off
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT "%s", COUNT(*) AS n FROM "%s" WHERE "%s" IS NOT NULL
+		GROUP BY "%s" ORDER BY n DESC, "%s" LIMIT ?`, columnName, tableName, columnName, columnName, columnName), limit+1)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	values := []ColumnValueFreq{}
+	for rows.Next() {
+		var freq ColumnValueFreq
+		if err := rows.Scan(&freq.Value, &freq.Count); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		values = append(values, freq)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	truncated := len(values) > limit
+	if truncated {
+		values = values[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":     tableName,
+		"column":    columnName,
+		"values":    values,
+		"limit":     limit,
+		"truncated": truncated,
+	})
+}
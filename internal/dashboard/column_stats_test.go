@@ -0,0 +1,115 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColumnStats(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT, amount REAL)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO orders (status, amount) VALUES
+		('paid', 10), ('paid', 20), ('refunded', 30), ('paid', NULL), (NULL, 40)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}/columns/{column}/stats", handler.handleColumnStats)
+
+	get := func(path string) (int, ColumnStats) {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var stats ColumnStats
+		json.Unmarshal(w.Body.Bytes(), &stats)
+		return w.Code, stats
+	}
+
+	code, stats := get("/tables/orders/columns/amount/stats")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(5), stats.Count)
+	assert.Equal(t, int64(1), stats.NullCount)
+	assert.Equal(t, int64(4), stats.DistinctCount)
+	assert.Equal(t, float64(10), stats.Min)
+	assert.Equal(t, float64(40), stats.Max)
+	require.NotNil(t, stats.Avg)
+	assert.Equal(t, float64(25), *stats.Avg)
+	assert.Empty(t, stats.TopValues)
+
+	code, stats = get("/tables/orders/columns/status/stats?top=1")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, int64(1), stats.NullCount)
+	assert.Equal(t, int64(2), stats.DistinctCount)
+	assert.Nil(t, stats.Avg)
+	require.Len(t, stats.TopValues, 1)
+	assert.Equal(t, "paid", stats.TopValues[0].Value)
+	assert.Equal(t, int64(3), stats.TopValues[0].Count)
+
+	// Cached until refreshed
+	_, err = database.Exec(`INSERT INTO orders (status, amount) VALUES ('paid', 50)`)
+	require.NoError(t, err)
+	_, stats = get("/tables/orders/columns/amount/stats")
+	assert.Equal(t, int64(5), stats.Count)
+	_, stats = get("/tables/orders/columns/amount/stats?refresh=true")
+	assert.Equal(t, int64(6), stats.Count)
+
+	code, _ = get("/tables/orders/columns/missing/stats")
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestColumnDistinct(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE orders (id INTEGER PRIMARY KEY, status TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO orders (status) VALUES
+		('paid'), ('paid'), ('paid'), ('refunded'), ('pending'), ('pending'), (NULL)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}/columns/{column}/distinct", handler.handleColumnDistinct)
+
+	type distinctResponse struct {
+		Values    []ColumnValueFreq `json:"values"`
+		Truncated bool              `json:"truncated"`
+	}
+	get := func(path string) (int, distinctResponse) {
+		req := httptest.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp distinctResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+
+	code, resp := get("/tables/orders/columns/status/distinct")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, resp.Values, 3)
+	assert.Equal(t, "paid", resp.Values[0].Value)
+	assert.Equal(t, int64(3), resp.Values[0].Count)
+	assert.Equal(t, "pending", resp.Values[1].Value)
+	assert.Equal(t, "refunded", resp.Values[2].Value)
+	assert.False(t, resp.Truncated)
+
+	code, resp = get("/tables/orders/columns/status/distinct?limit=2")
+	require.Equal(t, http.StatusOK, code)
+	assert.Len(t, resp.Values, 2)
+	assert.True(t, resp.Truncated)
+
+	code, _ = get("/tables/orders/columns/missing/distinct")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = get("/tables/orders/columns/status/distinct?limit=0")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,128 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// hiddenColumns returns the set of a table's columns marked hidden in
+// _columns. Hidden columns are left out of data responses unless requested
+// explicitly with select=.
+func (h *Handler) hiddenColumns(tableName string) map[string]bool {
+	rows, err := h.db.Query(`SELECT column_name FROM _columns WHERE table_name = ? AND is_hidden = 1`, tableName)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	hidden := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			hidden[name] = true
+		}
+	}
+	return hidden
+}
+
+// selectColumnList builds the column list for a data query. An explicit
+// select (comma-separated column names) is validated against the table and
+// may include hidden columns; otherwise every column except the hidden ones
+// is returned. It returns "*" when nothing needs to be excluded.
+func (h *Handler) selectColumnList(tableName, selectParam string) (string, error) {
+	var tableCols []string
+	rows, err := h.db.Query(`SELECT name FROM pragma_table_info(?) ORDER BY cid`, tableName)
+	if err != nil {
+		return "", err
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			tableCols = append(tableCols, name)
+		}
+	}
+	rows.Close()
+
+	var selected []string
+	if selectParam = strings.TrimSpace(selectParam); selectParam != "" && selectParam != "*" {
+		known := make(map[string]bool, len(tableCols))
+		for _, col := range tableCols {
+			known[col] = true
+		}
+		for _, col := range strings.Split(selectParam, ",") {
+			col = strings.TrimSpace(col)
+			if col == "" {
+				continue
+			}
+			if !known[col] {
+				return "", fmt.Errorf("column %s does not exist", col)
+			}
+			selected = append(selected, col)
+		}
+	} else {
+		hidden := h.hiddenColumns(tableName)
+		if len(hidden) == 0 {
+			return "*", nil
+		}
+		for _, col := range tableCols {
+			if !hidden[col] {
+				selected = append(selected, col)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return "", fmt.Errorf("no columns selected")
+	}
+
+	quoted := make([]string, len(selected))
+	for i, col := range selected {
+		quoted[i] = fmt.Sprintf(`"%s"`, col)
+	}
+	return strings.Join(quoted, ", "), nil
+}
+
+// handleSetColumnHidden marks a column hidden or visible. Hidden columns,
+// such as password hashes or internal tokens, are excluded from data
+// responses unless named in select=.
+// PUT /_/api/tables/{name}/columns/{column}/hidden
+func (h *Handler) handleSetColumnHidden(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	columnName := chi.URLParam(r, "column")
+
+	var req struct {
+		Hidden *bool `json:"hidden"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.Hidden == nil {
+		writeError(w, http.StatusBadRequest, "missing_field", "hidden is required")
+		return
+	}
+
+	// Make sure columns of tables created outside the dashboard are registered
+	h.ensureTableRegistered(tableName)
+
+	result, err := h.db.Exec(`UPDATE _columns SET is_hidden = ? WHERE table_name = ? AND column_name = ?`,
+		*req.Hidden, tableName, columnName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update column")
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":  tableName,
+		"column": columnName,
+		"hidden": *req.Hidden,
+	})
+}
@@ -0,0 +1,101 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHiddenColumnsExcludedFromData(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE accounts (id INTEGER PRIMARY KEY, name TEXT, password_hash TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO accounts VALUES (1, 'alice', 'secret-hash')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}", handler.handleGetTableSchema)
+	r.Put("/tables/{name}/columns/{column}/hidden", handler.handleSetColumnHidden)
+	r.Get("/data/{table}", handler.handleSelectData)
+
+	req := httptest.NewRequest("PUT", "/tables/accounts/columns/password_hash/hidden", bytes.NewBufferString(`{"hidden": true}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	fetchRow := func(url string) map[string]interface{} {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp struct {
+			Rows []map[string]interface{} `json:"rows"`
+		}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		require.Len(t, resp.Rows, 1)
+		return resp.Rows[0]
+	}
+
+	row := fetchRow("/data/accounts")
+	assert.Equal(t, "alice", row["name"])
+	assert.NotContains(t, row, "password_hash")
+
+	row = fetchRow("/data/accounts?select=id,password_hash")
+	assert.Equal(t, "secret-hash", row["password_hash"])
+	assert.NotContains(t, row, "name")
+
+	// The schema flags the hidden column
+	req = httptest.NewRequest("GET", "/tables/accounts", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var schema struct {
+		Columns []map[string]interface{} `json:"columns"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&schema))
+	hidden := map[string]interface{}{}
+	for _, col := range schema.Columns {
+		hidden[col["name"].(string)] = col["hidden"]
+	}
+	assert.Equal(t, true, hidden["password_hash"])
+	assert.Equal(t, false, hidden["name"])
+
+	// Unhiding restores the column to default responses
+	req = httptest.NewRequest("PUT", "/tables/accounts/columns/password_hash/hidden", bytes.NewBufferString(`{"hidden": false}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, fetchRow("/data/accounts"), "password_hash")
+}
+
+func TestSelectDataRejectsUnknownColumn(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/data/{table}", handler.handleSelectData)
+	r.Put("/tables/{name}/columns/{column}/hidden", handler.handleSetColumnHidden)
+
+	req := httptest.NewRequest("GET", "/data/notes?select=id,missing", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest("PUT", "/tables/notes/columns/missing/hidden", bytes.NewBufferString(`{"hidden": true}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,75 @@
+package dashboard
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CSRFHeader carries the double-submit CSRF token on state-changing requests.
+const CSRFHeader = "X-CSRF-Token"
+
+// csrfCookieName returns the name of the cookie holding the CSRF token. It is
+// readable by scripts, unlike the session cookie, and scoped to the same port.
+func (h *Handler) csrfCookieName() string {
+	return h.sessionCookieName() + "_csrf"
+}
+
+// issueCSRFToken sets a fresh CSRF cookie alongside a new session and returns
+// the token.
+func (h *Handler) issueCSRFToken(w http.ResponseWriter, r *http.Request) string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+
+	cookie := h.dashboardCookie(r, h.csrfCookieName(), token, sessionMaxAge)
+	cookie.HttpOnly = false
+	http.SetCookie(w, cookie)
+	return token
+}
+
+// clearCSRFToken deletes the CSRF cookie on logout.
+func (h *Handler) clearCSRFToken(w http.ResponseWriter, r *http.Request) {
+	cookie := h.dashboardCookie(r, h.csrfCookieName(), "", -1)
+	cookie.HttpOnly = false
+	http.SetCookie(w, cookie)
+}
+
+// csrfExempt lists the endpoints that create a session; they cannot carry a
+// token yet. Paths are relative to the /api router requireCSRF is mounted on.
+var csrfExempt = map[string]bool{"/auth/login": true, "/auth/setup": true}
+
+// requireCSRF rejects state-changing API requests made with a session cookie
+// unless the CSRF header matches the CSRF cookie. A cross-site page can make
+// the browser send the cookies but cannot read the token to echo it back.
+// Requests without a session carry no ambient authority and are left to
+// requireAuth.
+func (h *Handler) requireCSRF(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && csrfExempt[rctx.RoutePath] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if session, err := r.Cookie(h.sessionCookieName()); err != nil || session.Value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cookie, err := r.Cookie(h.csrfCookieName())
+		header := r.Header.Get(CSRFHeader)
+		if err != nil || cookie.Value == "" || header == "" ||
+			subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+			writeError(w, http.StatusForbidden, "csrf_token_invalid", "Missing or invalid "+CSRFHeader+" header")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,86 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir()+"/migrations")
+	require.NoError(t, handler.auth.SetupPassword("testpassword123"))
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	// Login is exempt and issues the token as a cookie and in the body
+	req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"password":"testpassword123"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var body map[string]string
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	require.NotEmpty(t, body["csrf_token"])
+
+	var session, csrf *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		switch c.Name {
+		case "_sblite_session":
+			session = c
+		case "_sblite_session_csrf":
+			csrf = c
+		}
+	}
+	require.NotNil(t, session)
+	require.NotNil(t, csrf)
+	assert.False(t, csrf.HttpOnly, "scripts must be able to read the token")
+	assert.Equal(t, body["csrf_token"], csrf.Value)
+
+	createTable := func(header string) int {
+		req := httptest.NewRequest("POST", "/api/tables", strings.NewReader(`{"name":"notes","columns":[{"name":"id","type":"integer","primary":true}]}`))
+		req.AddCookie(session)
+		req.AddCookie(csrf)
+		if header != "" {
+			req.Header.Set(CSRFHeader, header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusForbidden, createTable(""))
+	assert.Equal(t, http.StatusForbidden, createTable("forged"))
+	assert.Equal(t, http.StatusCreated, createTable(csrf.Value))
+
+	// Only the exact login and setup paths are exempt
+	req = httptest.NewRequest("PUT", "/api/functions/hello/files/auth/login", strings.NewReader(`{"content":"x"}`))
+	req.AddCookie(session)
+	req.AddCookie(csrf)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Reads need no token
+	req = httptest.NewRequest("GET", "/api/tables", nil)
+	req.AddCookie(session)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Status hands the token back to an authenticated page
+	req = httptest.NewRequest("GET", "/api/auth/status", nil)
+	req.AddCookie(session)
+	req.AddCookie(csrf)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var status map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	assert.Equal(t, csrf.Value, status["csrf_token"])
+}
@@ -0,0 +1,105 @@
+package dashboard
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/markb/sblite/internal/types"
+)
+
+// columnTypes returns the pg_type registered in _columns for each of a
+// table's columns. Columns without metadata are missing from the map.
+func (h *Handler) columnTypes(tableName string) map[string]string {
+	rows, err := h.db.Query(`SELECT column_name, pg_type FROM _columns WHERE table_name = ?`, tableName)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	colTypes := make(map[string]string)
+	for rows.Next() {
+		var name, pgType string
+		if err := rows.Scan(&name, &pgType); err == nil {
+			colTypes[name] = pgType
+		}
+	}
+	return colTypes
+}
+
+// jsonValue converts a scanned column value for a JSON response according
+// to the column's declared type, since SQLite returns whatever was stored.
+//
+// SQLite hands back BLOB values as []byte, which encoding/json would
+// base64-encode, garbling text stored with BLOB affinity. Like the SQL
+// browser, they are returned as text, except in bytea columns, where real
+// binary data is expected and base64 is kept. Booleans stored as 0/1 become
+// true/false, and integer and numeric values stored as text become numbers.
+// Values that don't fit the declared type, and columns of other or unknown
+// types, are returned as they are.
+func jsonValue(v interface{}, pgType string) interface{} {
+	if b, ok := v.([]byte); ok {
+		if types.PgType(pgType) == types.TypeBytea {
+			return b
+		}
+		v = string(b)
+	}
+
+	switch types.PgType(pgType) {
+	case types.TypeBoolean:
+		switch val := v.(type) {
+		case int64:
+			if val == 0 || val == 1 {
+				return val == 1
+			}
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(val)); err == nil {
+				return b
+			}
+		}
+	case types.TypeInteger:
+		switch val := v.(type) {
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(val), 10, 64); err == nil {
+				return n
+			}
+		case float64:
+			if val == float64(int64(val)) {
+				return int64(val)
+			}
+		}
+	case types.TypeNumeric:
+		// json.Number keeps the stored digits, which a float64 could round
+		if val, ok := v.(string); ok {
+			val = strings.TrimSpace(val)
+			if isJSONNumber(val) {
+				return json.Number(val)
+			}
+		}
+	}
+	return v
+}
+
+// isJSONNumber reports whether s is valid as a JSON number literal, which
+// excludes forms strconv accepts such as "Inf", "0x1p-2" and "+1".
+func isJSONNumber(s string) bool {
+	return json.Valid([]byte(s)) && s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9'))
+}
+
+// csvValue formats a scanned column value for a CSV export, with bytea
+// values base64-encoded as in JSON.
+func csvValue(v interface{}, pgType string) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		if types.PgType(pgType) == types.TypeBytea {
+			return base64.StdEncoding.EncodeToString(val)
+		}
+		return string(val)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
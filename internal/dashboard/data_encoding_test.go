@@ -0,0 +1,115 @@
+package dashboard
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataBlobEncoding(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE files (id INTEGER PRIMARY KEY, note BLOB, data BLOB)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO _columns (table_name, column_name, pg_type) VALUES
+		('files', 'id', 'integer'), ('files', 'note', 'text'), ('files', 'data', 'bytea')`)
+	require.NoError(t, err)
+	binary := []byte{0x00, 0xff, 0x10, 0x80}
+	_, err = database.Exec(`INSERT INTO files (id, note, data) VALUES (1, ?, ?)`, []byte("hello blob"), binary)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/data/{table}", handler.handleSelectData)
+	r.Get("/data/{table}/{id}", handler.handleGetRow)
+	r.Get("/export/data", handler.handleExportData)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return w
+	}
+	encoded := base64.StdEncoding.EncodeToString(binary)
+
+	// Text stored as a BLOB comes back as text; bytea stays base64
+	var list struct {
+		Rows []map[string]interface{} `json:"rows"`
+	}
+	require.NoError(t, json.Unmarshal(get("/data/files").Body.Bytes(), &list))
+	require.Len(t, list.Rows, 1)
+	assert.Equal(t, "hello blob", list.Rows[0]["note"])
+	assert.Equal(t, encoded, list.Rows[0]["data"])
+
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal(get("/data/files/1").Body.Bytes(), &row))
+	assert.Equal(t, "hello blob", row["note"])
+	assert.Equal(t, encoded, row["data"])
+
+	var export map[string][]map[string]interface{}
+	require.NoError(t, json.Unmarshal(get("/export/data?tables=files").Body.Bytes(), &export))
+	assert.Equal(t, "hello blob", export["files"][0]["note"])
+	assert.Equal(t, encoded, export["files"][0]["data"])
+
+	csv := get("/export/data?tables=files&format=csv").Body.String()
+	assert.Contains(t, csv, "1,hello blob,"+encoded)
+}
+
+func TestDataTypeFidelity(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE products (id INTEGER PRIMARY KEY, active INTEGER, qty TEXT, price TEXT, label TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO _columns (table_name, column_name, pg_type) VALUES
+		('products', 'id', 'integer'), ('products', 'active', 'boolean'), ('products', 'qty', 'integer'),
+		('products', 'price', 'numeric'), ('products', 'label', 'text')`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO products VALUES (1, 1, '42', '19.990', '7'), (2, 0, 'lots', 'n/a', 'x')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/data/{table}", handler.handleSelectData)
+
+	req := httptest.NewRequest("GET", "/data/products?order=id", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Rows []map[string]json.RawMessage `json:"rows"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Rows, 2)
+
+	first, second := resp.Rows[0], resp.Rows[1]
+	assert.Equal(t, "true", string(first["active"]))
+	assert.Equal(t, "42", string(first["qty"]))
+	assert.Equal(t, "19.990", string(first["price"]))
+	assert.Equal(t, `"7"`, string(first["label"]))
+
+	// Values that don't fit the declared type are left alone
+	assert.Equal(t, "false", string(second["active"]))
+	assert.Equal(t, `"lots"`, string(second["qty"]))
+	assert.Equal(t, `"n/a"`, string(second["price"]))
+}
+
+func TestJSONValue(t *testing.T) {
+	assert.Equal(t, true, jsonValue(int64(1), "boolean"))
+	assert.Equal(t, true, jsonValue("true", "boolean"))
+	assert.Equal(t, int64(2), jsonValue(int64(2), "boolean"))
+	assert.Equal(t, int64(5), jsonValue(float64(5), "integer"))
+	assert.Equal(t, json.Number("-1.5e3"), jsonValue("-1.5e3", "numeric"))
+	assert.Equal(t, "Inf", jsonValue("Inf", "numeric"))
+	assert.Equal(t, "1", jsonValue("1", "text"))
+	assert.Equal(t, "1", jsonValue([]byte("1"), ""))
+}
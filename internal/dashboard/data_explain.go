@@ -0,0 +1,27 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// wantsSQLExplain reports whether a data request asked for its generated SQL
+// instead of results, via ?explain=sql or an "X-Debug: sql" header.
+func wantsSQLExplain(r *http.Request) bool {
+	return r.URL.Query().Get("explain") == "sql" || strings.EqualFold(r.Header.Get("X-Debug"), "sql")
+}
+
+// writeSQLExplain returns the statements a select would run and their bound
+// parameters, so users can check how filters and ordering were translated.
+func writeSQLExplain(w http.ResponseWriter, query, countQuery string, params []interface{}) {
+	if params == nil {
+		params = []interface{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"query":       query,
+		"count_query": countQuery,
+		"params":      params,
+	})
+}
@@ -0,0 +1,45 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerSelectDataExplainSQL(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE tasks (id INTEGER PRIMARY KEY, title TEXT, done INTEGER)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/data/{table}", handler.handleSelectData)
+
+	explain := func(req *http.Request) map[string]interface{} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp
+	}
+
+	resp := explain(httptest.NewRequest("GET", "/data/tasks?done=eq.1&order=title.desc&limit=5&explain=sql", nil))
+	assert.Equal(t, `SELECT * FROM "tasks" WHERE "done" = ? ORDER BY "title" DESC LIMIT 5 OFFSET 0`, resp["query"])
+	assert.Equal(t, `SELECT COUNT(*) FROM "tasks" WHERE "done" = ?`, resp["count_query"])
+	assert.Equal(t, []interface{}{"1"}, resp["params"])
+	assert.NotContains(t, resp, "rows")
+
+	req := httptest.NewRequest("GET", "/data/tasks", nil)
+	req.Header.Set("X-Debug", "sql")
+	resp = explain(req)
+	assert.Equal(t, []interface{}{}, resp["params"])
+	assert.Contains(t, resp["query"], `FROM "tasks"`)
+}
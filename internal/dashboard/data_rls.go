@@ -0,0 +1,146 @@
+package dashboard
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/markb/sblite/internal/auth"
+	"github.com/markb/sblite/internal/db"
+	"github.com/markb/sblite/internal/rls"
+)
+
+// errRLSCheckViolation is returned by writes whose new row fails the table's
+// RLS check expression.
+var errRLSCheckViolation = errors.New("new row violates row-level security policy")
+
+// errInvalidDataToken is returned for a bearer token that does not verify.
+var errInvalidDataToken = errors.New("invalid or expired token")
+
+// newRLSService returns the policy service the REST API also enforces
+// policies through, over the dashboard's database.
+func newRLSService(conn *sql.DB) *rls.Service {
+	return rls.NewService(&db.DB{DB: conn})
+}
+
+// dataAuthContext returns the auth context RLS is enforced for on the data
+// API. A dashboard session alone is unrestricted; a request that also carries
+// a user JWT in Authorization: Bearer sees the data as that user. As in the
+// REST API, service_role tokens bypass RLS.
+func (h *Handler) dataAuthContext(r *http.Request) (*rls.AuthContext, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") || h.jwtSecret == "" {
+		return nil, nil
+	}
+
+	claims, err := auth.NewService(&db.DB{DB: h.db}, h.jwtSecret).ValidateAccessToken(strings.TrimPrefix(header, "Bearer "))
+	if err != nil {
+		return nil, errInvalidDataToken
+	}
+
+	authCtx := &rls.AuthContext{Claims: *claims}
+	authCtx.UserID, _ = (*claims)["sub"].(string)
+	authCtx.Email, _ = (*claims)["email"].(string)
+	authCtx.Role, _ = (*claims)["role"].(string)
+	authCtx.BypassRLS = authCtx.Role == "service_role"
+	return authCtx, nil
+}
+
+// dataRLSConditions returns the USING and CHECK conditions the REST API's
+// enforcer applies for command on a table, for the request's auth context.
+// Both are empty when the request is unrestricted or the table does not have
+// RLS enabled.
+func (h *Handler) dataRLSConditions(r *http.Request, tableName, command string) (using, check string, err error) {
+	authCtx, err := h.dataAuthContext(r)
+	if err != nil || authCtx == nil {
+		return "", "", err
+	}
+
+	enabled, err := h.rlsService.IsRLSEnabled(tableName)
+	if err != nil || !enabled {
+		return "", "", err
+	}
+
+	switch command {
+	case "SELECT":
+		using, err = h.rlsEnforcer.GetSelectConditions(tableName, authCtx)
+	case "INSERT":
+		check, err = h.rlsEnforcer.GetInsertConditions(tableName, authCtx)
+	case "UPDATE":
+		if using, err = h.rlsEnforcer.GetUpdateConditions(tableName, authCtx); err == nil {
+			check, err = h.rlsEnforcer.GetUpdateCheckConditions(tableName, authCtx)
+		}
+	case "DELETE":
+		using, err = h.rlsEnforcer.GetDeleteConditions(tableName, authCtx)
+	}
+	return using, check, err
+}
+
+// appendWhere adds a condition to a WHERE clause, which may be empty.
+func appendWhere(whereClause, cond string) string {
+	if cond == "" {
+		return whereClause
+	}
+	if whereClause == "" {
+		return "WHERE " + cond
+	}
+	return whereClause + " AND " + cond
+}
+
+// verifyRLSCheck fails with errRLSCheckViolation unless every written row,
+// identified by rowid, passes the check expression. A NULL result fails.
+func verifyRLSCheck(tx *sql.Tx, tableName, check string, rowIDs []int64) error {
+	if check == "" || len(rowIDs) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(rowIDs))
+	args := make([]interface{}, len(rowIDs))
+	for i, id := range rowIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	var failed int
+	err := tx.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE rowid IN (%s) AND NOT COALESCE((%s), 0)`,
+		tableName, strings.Join(placeholders, ", "), check), args...).Scan(&failed)
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errRLSCheckViolation
+	}
+	return nil
+}
+
+// writeDataRLSError writes the response for errors from dataRLSConditions and
+// verifyRLSCheck, reporting whether err was one of them.
+func writeDataRLSError(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, errInvalidDataToken):
+		writeError(w, http.StatusUnauthorized, "invalid_token", "Invalid or expired token")
+	case errors.Is(err, errRLSCheckViolation):
+		writeError(w, http.StatusForbidden, "rls_violation", err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// queryRowIDs runs a statement ending in RETURNING rowid and collects the ids.
+func queryRowIDs(tx *sql.Tx, query string, args []interface{}) ([]int64, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataAPIEnforcesRLSForUserTokens(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	handler.SetJWTSecret("test-secret")
+	_, err := database.Exec(`
+		CREATE TABLE notes (id INTEGER PRIMARY KEY, owner TEXT, body TEXT);
+		INSERT INTO notes VALUES (1, 'alice', 'a1'), (2, 'bob', 'b1'), (3, 'alice', 'a2');
+		INSERT INTO _rls_tables (table_name, enabled) VALUES ('notes', 1);
+		INSERT INTO _rls_policies (table_name, policy_name, command, using_expr, check_expr)
+			VALUES ('notes', 'own notes', 'ALL', 'owner = auth.uid()', 'owner = auth.uid()');
+	`)
+	require.NoError(t, err)
+
+	tokenFor := func(sub, role string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": sub, "role": role}).SignedString([]byte("test-secret"))
+		require.NoError(t, err)
+		return token
+	}
+	alice := tokenFor("alice", "authenticated")
+
+	r := chi.NewRouter()
+	r.Get("/data/{table}", handler.handleSelectData)
+	r.Get("/data/{table}/{id}", handler.handleGetRow)
+	r.Post("/data/{table}", handler.handleInsertData)
+	r.Patch("/data/{table}", handler.handleUpdateData)
+	r.Delete("/data/{table}", handler.handleDeleteData)
+	do := func(method, url, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	total := func(token string) float64 {
+		w := do("GET", "/data/notes", token, "")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		return resp["total"].(float64)
+	}
+
+	// The dashboard session alone and service_role tokens are unrestricted
+	assert.Equal(t, float64(3), total(""))
+	assert.Equal(t, float64(3), total(tokenFor("", "service_role")))
+	assert.Equal(t, float64(2), total(alice))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/data/notes/2", alice, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, do("GET", "/data/notes", "not-a-token", "").Code)
+
+	// Writes must pass the check expression and only touch visible rows
+	assert.Equal(t, http.StatusForbidden, do("POST", "/data/notes", alice, `{"id": 4, "owner": "bob"}`).Code)
+	assert.Equal(t, http.StatusCreated, do("POST", "/data/notes", alice, `{"id": 4, "owner": "alice"}`).Code)
+	assert.Equal(t, http.StatusForbidden, do("PATCH", "/data/notes?id=eq.1", alice, `{"owner": "bob"}`).Code)
+	w := do("PATCH", "/data/notes?id=eq.2", alice, `{"body": "mine now"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"updated": 0}`, w.Body.String())
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/data/notes?id=eq.2", alice, "").Code)
+
+	var count int
+	database.QueryRow(`SELECT COUNT(*) FROM notes WHERE owner = 'bob'`).Scan(&count)
+	assert.Equal(t, 1, count)
+	database.QueryRow(`SELECT COUNT(*) FROM notes`).Scan(&count)
+	assert.Equal(t, 4, count)
+}
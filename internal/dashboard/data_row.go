@@ -0,0 +1,131 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// rowIDDelimiter separates the values of a composite key in a row id.
+const rowIDDelimiter = ","
+
+// rowKeyFilter builds the WHERE condition matching one row. Key values come
+// from query parameters named after the key columns when all are present,
+// otherwise from id, split on rowIDDelimiter for composite keys.
+func rowKeyFilter(keyCols []string, id string, query url.Values) (string, []interface{}, error) {
+	values := make([]string, 0, len(keyCols))
+	for _, col := range keyCols {
+		if v, ok := query[col]; ok && len(v) > 0 {
+			values = append(values, v[0])
+		}
+	}
+	if len(values) != len(keyCols) {
+		values = strings.Split(id, rowIDDelimiter)
+		if len(keyCols) == 1 {
+			values = []string{id}
+		}
+	}
+	if len(values) != len(keyCols) {
+		return "", nil, fmt.Errorf("id must contain %d %q-separated values for key columns: %s",
+			len(keyCols), rowIDDelimiter, strings.Join(keyCols, ", "))
+	}
+
+	conds := make([]string, len(keyCols))
+	args := make([]interface{}, len(keyCols))
+	for i, col := range keyCols {
+		conds[i] = fmt.Sprintf(`"%s" = ?`, col)
+		args[i] = values[i]
+	}
+	return strings.Join(conds, " AND "), args, nil
+}
+
+// handleGetRow returns a single row by primary key as a bare object. Tables
+// without a primary key are addressed by rowid.
+// GET /_/api/data/{table}/{id}?select=col1,col2
+func (h *Handler) handleGetRow(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "table")
+	id := chi.URLParam(r, "id")
+	// chi matches on the escaped path when it has one
+	if r.URL.RawPath != "" {
+		if unescaped, err := url.PathUnescape(id); err == nil {
+			id = unescaped
+		}
+	}
+
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists)
+	if exists == 0 {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+	// Registration only matters for hidden column metadata
+	h.ensureTableRegistered(tableName)
+
+	keyCols := h.primaryKeyColumns(tableName)
+	if len(keyCols) == 0 {
+		keyCols = []string{"rowid"}
+	}
+	rowFilter, args, err := rowKeyFilter(keyCols, id, r.URL.Query())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_pk", err.Error())
+		return
+	}
+	// A row hidden by RLS from the request's user token is reported as missing
+	rlsUsing, _, err := h.dataRLSConditions(r, tableName, "SELECT")
+	if err != nil {
+		if !writeDataRLSError(w, err) {
+			writeError(w, http.StatusInternalServerError, "rls_error", "Failed to apply RLS")
+		}
+		return
+	}
+	if rlsUsing != "" {
+		rowFilter += " AND " + rlsUsing
+	}
+
+	// Hidden columns are only returned when named in select=
+	columnList, err := h.selectColumnList(tableName, r.URL.Query().Get("select"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_select", err.Error())
+		return
+	}
+
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT %s FROM "%s" WHERE %s LIMIT 1`, columnList, tableName, rowFilter), args...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		writeError(w, http.StatusNotFound, "row_not_found", "No row matches the given id")
+		return
+	}
+
+	columns, _ := rows.Columns()
+	values := make([]interface{}, len(columns))
+	valuePtrs := make([]interface{}, len(columns))
+	for i := range values {
+		valuePtrs[i] = &values[i]
+	}
+	if err := rows.Scan(valuePtrs...); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	colTypes := h.columnTypes(tableName)
+	row := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		row[col] = jsonValue(values[i], colTypes[col])
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(row)
+}
@@ -0,0 +1,65 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerGetRow(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`
+		CREATE TABLE notes (id INTEGER PRIMARY KEY, title TEXT);
+		INSERT INTO notes VALUES (1, 'first'), (2, 'second');
+		CREATE TABLE memberships (org TEXT, member TEXT, role TEXT, PRIMARY KEY (org, member));
+		INSERT INTO memberships VALUES ('acme', 'bob', 'admin'), ('a,b', 'carol', 'viewer');
+		CREATE TABLE events (name TEXT);
+		INSERT INTO events VALUES ('boot');
+	`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/data/{table}/{id}", handler.handleGetRow)
+
+	get := func(url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) map[string]interface{} {
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var row map[string]interface{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&row))
+		return row
+	}
+
+	row := decode(get("/data/notes/2"))
+	assert.Equal(t, "second", row["title"])
+	assert.EqualValues(t, 2, row["id"])
+
+	row = decode(get("/data/notes/2?select=title"))
+	assert.NotContains(t, row, "id")
+
+	// Composite keys: delimited id, or query params for values containing the delimiter
+	row = decode(get("/data/memberships/acme,bob"))
+	assert.Equal(t, "admin", row["role"])
+	row = decode(get("/data/memberships/_?org=a%2Cb&member=carol"))
+	assert.Equal(t, "viewer", row["role"])
+
+	// Tables without a primary key are addressed by rowid
+	row = decode(get("/data/events/1"))
+	assert.Equal(t, "boot", row["name"])
+
+	assert.Equal(t, http.StatusNotFound, get("/data/notes/99").Code)
+	assert.Equal(t, http.StatusNotFound, get("/data/missing/1").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/data/memberships/acme").Code)
+}
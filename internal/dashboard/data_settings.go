@@ -0,0 +1,252 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultMaxPageSize is the maximum number of rows returned per page by the data API
+// when neither a global nor a per-table limit has been configured.
+const defaultMaxPageSize = 100
+
+// defaultExportSoftLimit is the row count above which a buffered data export
+// must be confirmed with confirm_large=true.
+const defaultExportSoftLimit = 100000
+
+// DataSettings holds global data API settings.
+type DataSettings struct {
+	MaxPageSize int `json:"max_page_size"`
+	// IdempotencyWindowMinutes is how long Idempotency-Key results are kept.
+	IdempotencyWindowMinutes int `json:"idempotency_window_minutes"`
+	// BusyRetries is how many times a write hitting a locked database is retried.
+	BusyRetries int `json:"busy_retries"`
+	// ExportSoftLimit is the row count above which JSON and CSV exports need
+	// confirm_large=true. 0 disables the check.
+	ExportSoftLimit int `json:"export_soft_limit"`
+}
+
+// TableSettings holds per-table overrides for the data API.
+// Nil fields fall back to the global setting.
+type TableSettings struct {
+	Table       string `json:"table"`
+	MaxPageSize *int   `json:"max_page_size"`
+	// DefaultOrder is the order ("column" or "column.desc") used when a
+	// request has none. Nil means primary key order.
+	DefaultOrder *string `json:"default_order"`
+}
+
+// tableSettingKey returns the _dashboard key for a per-table setting.
+func tableSettingKey(table, setting string) string {
+	return "table_settings:" + table + ":" + setting
+}
+
+// getIntSetting reads a positive integer from the store, returning 0 if unset or invalid.
+func (h *Handler) getIntSetting(key string) int {
+	val, _ := h.store.Get(key)
+	if n, err := strconv.Atoi(val); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+// maxPageSize returns the effective page size cap for a table:
+// the table override if set, otherwise the global setting, otherwise the default.
+func (h *Handler) maxPageSize(table string) int {
+	if n := h.getIntSetting(tableSettingKey(table, "max_page_size")); n > 0 {
+		return n
+	}
+	if n := h.getIntSetting("data_max_page_size"); n > 0 {
+		return n
+	}
+	return defaultMaxPageSize
+}
+
+// defaultOrder returns the table's configured default order, or "".
+func (h *Handler) defaultOrder(table string) string {
+	order, _ := h.store.Get(tableSettingKey(table, "default_order"))
+	return order
+}
+
+// primaryKeyOrderClause returns an ORDER BY clause on the table's primary key,
+// or on rowid if it has none. Views have neither and get no clause.
+func (h *Handler) primaryKeyOrderClause(table string) string {
+	var objType string
+	if err := h.db.QueryRow(`SELECT type FROM sqlite_master WHERE name = ?`, table).Scan(&objType); err != nil || objType != "table" {
+		return ""
+	}
+
+	var cols []string
+	for _, name := range h.primaryKeyColumns(table) {
+		cols = append(cols, fmt.Sprintf(`"%s"`, name))
+	}
+	if len(cols) == 0 {
+		return " ORDER BY rowid"
+	}
+	return " ORDER BY " + strings.Join(cols, ", ")
+}
+
+// exportSoftLimit returns the configured export row threshold, 0 when disabled.
+func (h *Handler) exportSoftLimit() int {
+	val, _ := h.store.Get("data_export_soft_limit")
+	if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+		return n
+	}
+	return defaultExportSoftLimit
+}
+
+// handleGetDataSettings returns global data API settings.
+// GET /_/api/settings/data
+func (h *Handler) handleGetDataSettings(w http.ResponseWriter, r *http.Request) {
+	settings := DataSettings{
+		MaxPageSize:              defaultMaxPageSize,
+		IdempotencyWindowMinutes: int(h.idempotencyWindow() / time.Minute),
+		BusyRetries:              h.busyRetries(),
+		ExportSoftLimit:          h.exportSoftLimit(),
+	}
+	if n := h.getIntSetting("data_max_page_size"); n > 0 {
+		settings.MaxPageSize = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// handleUpdateDataSettings updates global data API settings.
+// PATCH /_/api/settings/data
+func (h *Handler) handleUpdateDataSettings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MaxPageSize              *int `json:"max_page_size"`
+		IdempotencyWindowMinutes *int `json:"idempotency_window_minutes"`
+		BusyRetries              *int `json:"busy_retries"`
+		ExportSoftLimit          *int `json:"export_soft_limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+
+	if req.IdempotencyWindowMinutes != nil {
+		if *req.IdempotencyWindowMinutes <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "idempotency_window_minutes must be positive")
+			return
+		}
+		if err := h.store.Set("data_idempotency_window_minutes", strconv.Itoa(*req.IdempotencyWindowMinutes)); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	if req.BusyRetries != nil {
+		if *req.BusyRetries < 0 || *req.BusyRetries > 10 {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "busy_retries must be between 0 and 10")
+			return
+		}
+		if err := h.store.Set("data_busy_retries", strconv.Itoa(*req.BusyRetries)); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	if req.ExportSoftLimit != nil {
+		if *req.ExportSoftLimit < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "export_soft_limit cannot be negative")
+			return
+		}
+		if err := h.store.Set("data_export_soft_limit", strconv.Itoa(*req.ExportSoftLimit)); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	if req.MaxPageSize != nil {
+		if *req.MaxPageSize <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "max_page_size must be positive")
+			return
+		}
+		if err := h.store.Set("data_max_page_size", strconv.Itoa(*req.MaxPageSize)); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	h.handleGetDataSettings(w, r)
+}
+
+// loadTableSettings reads the per-table overrides for a table.
+func (h *Handler) loadTableSettings(table string) TableSettings {
+	settings := TableSettings{Table: table}
+	if n := h.getIntSetting(tableSettingKey(table, "max_page_size")); n > 0 {
+		settings.MaxPageSize = &n
+	}
+	if order := h.defaultOrder(table); order != "" {
+		settings.DefaultOrder = &order
+	}
+	return settings
+}
+
+// handleGetTableSettings returns the per-table data API overrides.
+// GET /_/api/tables/{name}/settings
+func (h *Handler) handleGetTableSettings(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.loadTableSettings(tableName))
+}
+
+// handleUpdateTableSettings updates per-table data API overrides.
+// A value of 0 clears the override so the global setting applies.
+// PATCH /_/api/tables/{name}/settings
+func (h *Handler) handleUpdateTableSettings(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var req struct {
+		MaxPageSize  *int    `json:"max_page_size"`
+		DefaultOrder *string `json:"default_order"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+
+	if req.MaxPageSize != nil {
+		if *req.MaxPageSize < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "max_page_size cannot be negative")
+			return
+		}
+		value := ""
+		if *req.MaxPageSize > 0 {
+			value = strconv.Itoa(*req.MaxPageSize)
+		}
+		if err := h.store.Set(tableSettingKey(tableName, "max_page_size"), value); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	if req.DefaultOrder != nil {
+		// An empty value clears the override so primary key order applies
+		order := *req.DefaultOrder
+		if order != "" {
+			col, dir, _ := strings.Cut(order, ".")
+			var exists int
+			h.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, tableName, col).Scan(&exists)
+			if exists == 0 || (dir != "" && dir != "asc" && dir != "desc") {
+				writeError(w, http.StatusBadRequest, "invalid_setting", "default_order must be an existing column, optionally followed by .asc or .desc")
+				return
+			}
+		}
+		if err := h.store.Set(tableSettingKey(tableName, "default_order"), order); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.loadTableSettings(tableName))
+}
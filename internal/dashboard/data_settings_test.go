@@ -0,0 +1,171 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxPageSizeDefaults(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	assert.Equal(t, defaultMaxPageSize, handler.maxPageSize("items"))
+
+	require.NoError(t, handler.store.Set("data_max_page_size", "500"))
+	assert.Equal(t, 500, handler.maxPageSize("items"))
+
+	require.NoError(t, handler.store.Set(tableSettingKey("items", "max_page_size"), "10"))
+	assert.Equal(t, 10, handler.maxPageSize("items"))
+	assert.Equal(t, 500, handler.maxPageSize("other"))
+}
+
+func TestSelectDataClampsToTableMaxPageSize(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		_, err = database.Exec(fmt.Sprintf(`INSERT INTO items (id) VALUES (%d)`, i))
+		require.NoError(t, err)
+	}
+
+	r := chi.NewRouter()
+	r.Patch("/tables/{name}/settings", handler.handleUpdateTableSettings)
+	r.Get("/data/{table}", handler.handleSelectData)
+
+	req := httptest.NewRequest("PATCH", "/tables/items/settings", bytes.NewBufferString(`{"max_page_size": 3}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	req = httptest.NewRequest("GET", "/data/items?limit=1000", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, float64(3), resp["limit"])
+	assert.Equal(t, float64(3), resp["max_limit"])
+	assert.Len(t, resp["rows"], 3)
+}
+
+func TestSelectDataDefaultOrder(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE fruits (code TEXT PRIMARY KEY, name TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO fruits VALUES ('c', 'cherry'), ('a', 'banana'), ('b', 'apple')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Patch("/tables/{name}/settings", handler.handleUpdateTableSettings)
+	r.Get("/data/{table}", handler.handleSelectData)
+
+	codes := func(query string) []string {
+		req := httptest.NewRequest("GET", "/data/fruits"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var resp map[string]interface{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		var out []string
+		for _, row := range resp["rows"].([]interface{}) {
+			out = append(out, row.(map[string]interface{})["code"].(string))
+		}
+		return out
+	}
+
+	// Primary key order, not insertion order
+	assert.Equal(t, []string{"a", "b", "c"}, codes(""))
+	assert.Equal(t, []string{"b", "c"}, codes("?limit=2&offset=1"))
+
+	// A configured default order applies, and an explicit order wins over it
+	req := httptest.NewRequest("PATCH", "/tables/fruits/settings", bytes.NewBufferString(`{"default_order": "name.desc"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"c", "a", "b"}, codes(""))
+	assert.Equal(t, []string{"b", "a", "c"}, codes("?order=name"))
+
+	req = httptest.NewRequest("PATCH", "/tables/fruits/settings", bytes.NewBufferString(`{"default_order": "missing"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPrimaryKeyOrderClause(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE pairs (a TEXT, b TEXT, PRIMARY KEY (b, a))`)
+	require.NoError(t, err)
+	_, err = database.Exec(`CREATE TABLE notes (body TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`CREATE VIEW note_view AS SELECT body FROM notes`)
+	require.NoError(t, err)
+
+	assert.Equal(t, ` ORDER BY "b", "a"`, handler.primaryKeyOrderClause("pairs"))
+	assert.Equal(t, ` ORDER BY rowid`, handler.primaryKeyOrderClause("notes"))
+	assert.Equal(t, "", handler.primaryKeyOrderClause("note_view"))
+}
+
+func TestExportDataSoftLimit(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+	for i := 1; i <= 5; i++ {
+		_, err = database.Exec(`INSERT INTO items (id) VALUES (?)`, i)
+		require.NoError(t, err)
+	}
+
+	r := chi.NewRouter()
+	r.Patch("/settings/data", handler.handleUpdateDataSettings)
+	r.Get("/export/data", handler.handleExportData)
+
+	req := httptest.NewRequest("PATCH", "/settings/data", bytes.NewBufferString(`{"export_soft_limit": 3}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	export := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/export/data?tables=items"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w = export("")
+	require.Equal(t, http.StatusConflict, w.Code)
+	var resp APIError
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "export_too_large", resp.Code)
+	assert.Equal(t, float64(5), resp.Details.(map[string]interface{})["row_count"])
+
+	assert.Equal(t, http.StatusOK, export("&confirm_large=true").Code)
+	assert.Equal(t, http.StatusOK, export("&format=ndjson").Code)
+	assert.Equal(t, http.StatusOK, export("&limit=3").Code)
+
+	req = httptest.NewRequest("PATCH", "/settings/data", bytes.NewBufferString(`{"export_soft_limit": 0}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusOK, export("").Code)
+}
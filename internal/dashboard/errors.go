@@ -0,0 +1,36 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// APIError is the error envelope returned by every dashboard API endpoint.
+// Code is a stable, machine-readable identifier (e.g. "table_not_found") that
+// clients can branch on; Message is for humans and may change.
+type APIError struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+	// Error repeats Message for clients written against the older
+	// {"error": "..."} responses.
+	Error string `json:"error"`
+}
+
+// writeError writes an APIError with the given status.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeErrorDetails(w, status, code, message, nil)
+}
+
+// writeErrorDetails writes an APIError carrying extra structured details,
+// such as the list of validation issues.
+func writeErrorDetails(w http.ResponseWriter, status int, code, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIError{
+		Code:    code,
+		Message: message,
+		Details: details,
+		Error:   message,
+	})
+}
@@ -0,0 +1,55 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteErrorEnvelope(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeErrorDetails(w, http.StatusBadRequest, "invalid_request", "Invalid request body", map[string]string{"field": "name"})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "invalid_request", resp["code"])
+	assert.Equal(t, "Invalid request body", resp["message"])
+	assert.Equal(t, "Invalid request body", resp["error"])
+	assert.Equal(t, map[string]interface{}{"field": "name"}, resp["details"])
+}
+
+func TestWriteErrorOmitsEmptyDetails(t *testing.T) {
+	w := httptest.NewRecorder()
+	writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.NotContains(t, resp, "details")
+}
+
+func TestTableNotFoundErrorCode(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	r := chi.NewRouter()
+	r.Get("/tables/{name}", handler.handleGetTableSchema)
+
+	req := httptest.NewRequest("GET", "/tables/missing", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	var resp APIError
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "table_not_found", resp.Code)
+	assert.Equal(t, "Table not found", resp.Message)
+}
@@ -0,0 +1,119 @@
+package dashboard
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// utf8BOM is prepended to CSV exports with bom=true so Excel detects UTF-8.
+const utf8BOM = "\xEF\xBB\xBF"
+
+// csvOptions controls the format of CSV exports.
+type csvOptions struct {
+	delimiter rune
+	quote     rune
+	bom       bool
+}
+
+// parseCSVOptions reads the delimiter, quote and bom query parameters of a
+// CSV export. Without them the output is comma-separated and double-quoted.
+func parseCSVOptions(r *http.Request) (csvOptions, error) {
+	opts := csvOptions{delimiter: ',', quote: '"'}
+	query := r.URL.Query()
+
+	single := func(name, value string) (rune, error) {
+		if utf8.RuneCountInString(value) != 1 {
+			return 0, fmt.Errorf("%s must be a single character", name)
+		}
+		c, _ := utf8.DecodeRuneInString(value)
+		if c == '\r' || c == '\n' || c == utf8.RuneError {
+			return 0, fmt.Errorf("%s must not be a line break", name)
+		}
+		return c, nil
+	}
+
+	var err error
+	if v := query.Get("delimiter"); v != "" {
+		if opts.delimiter, err = single("delimiter", v); err != nil {
+			return opts, err
+		}
+	}
+	if v := query.Get("quote"); v != "" {
+		if opts.quote, err = single("quote", v); err != nil {
+			return opts, err
+		}
+	}
+	if opts.delimiter == opts.quote {
+		return opts, fmt.Errorf("delimiter and quote must differ")
+	}
+	opts.bom = query.Get("bom") == "true"
+	return opts, nil
+}
+
+// csvExportWriter writes CSV records with the configured delimiter and quote.
+// encoding/csv always quotes with '"', so other quote characters are written
+// directly using the same quoting rules.
+type csvExportWriter struct {
+	opts csvOptions
+	csv  *csv.Writer
+	buf  *bufio.Writer
+}
+
+func newCSVExportWriter(w io.Writer, opts csvOptions) *csvExportWriter {
+	if opts.quote == '"' {
+		cw := csv.NewWriter(w)
+		cw.Comma = opts.delimiter
+		return &csvExportWriter{opts: opts, csv: cw}
+	}
+	return &csvExportWriter{opts: opts, buf: bufio.NewWriter(w)}
+}
+
+// Write writes one record.
+func (c *csvExportWriter) Write(record []string) error {
+	if c.csv != nil {
+		return c.csv.Write(record)
+	}
+	for i, field := range record {
+		if i > 0 {
+			c.buf.WriteRune(c.opts.delimiter)
+		}
+		if !c.needsQuotes(field) {
+			c.buf.WriteString(field)
+			continue
+		}
+		q := string(c.opts.quote)
+		c.buf.WriteString(q)
+		c.buf.WriteString(strings.ReplaceAll(field, q, q+q))
+		c.buf.WriteString(q)
+	}
+	_, err := c.buf.WriteString("\n")
+	return err
+}
+
+// needsQuotes mirrors encoding/csv: fields containing the delimiter, the
+// quote, a line break or a leading space are quoted.
+func (c *csvExportWriter) needsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if strings.ContainsRune(field, c.opts.delimiter) || strings.ContainsRune(field, c.opts.quote) ||
+		strings.ContainsAny(field, "\r\n") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return r == ' ' || r == '\t'
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (c *csvExportWriter) Flush() {
+	if c.csv != nil {
+		c.csv.Flush()
+		return
+	}
+	c.buf.Flush()
+}
@@ -1,6 +1,8 @@
 package dashboard
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"path/filepath"
 	"strings"
@@ -140,3 +142,10 @@ func SanitizePath(basePath, relativePath string) (string, error) {
 
 	return fullPath, nil
 }
+
+// FileContentHash returns the hex-encoded SHA-256 of file content. The function
+// editor sends it back as base_hash to detect changes made since the file was read.
+func FileContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
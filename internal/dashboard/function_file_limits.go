@@ -0,0 +1,91 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"strconv"
+)
+
+// defaultFunctionMaxFiles is the number of files a function directory may
+// hold when no limit has been configured.
+const defaultFunctionMaxFiles = 200
+
+// FunctionFileLimits caps what the dashboard file editor may write into a
+// function directory.
+type FunctionFileLimits struct {
+	// MaxFileSize is the largest file, in bytes, that can be written. It
+	// can't exceed MaxFileSize, the largest file the editor can read.
+	MaxFileSize int64 `json:"max_file_size"`
+	// MaxFiles is the most files a function directory may hold.
+	MaxFiles int `json:"max_files"`
+}
+
+// functionFileLimits returns the configured limits, defaults included.
+func (h *Handler) functionFileLimits() FunctionFileLimits {
+	limits := FunctionFileLimits{MaxFileSize: MaxFileSize, MaxFiles: defaultFunctionMaxFiles}
+	if n := h.getIntSetting("functions_max_file_size"); n > 0 && n <= MaxFileSize {
+		limits.MaxFileSize = int64(n)
+	}
+	if n := h.getIntSetting("functions_max_files"); n > 0 {
+		limits.MaxFiles = n
+	}
+	return limits
+}
+
+// countFunctionFiles returns the number of regular files under dir.
+func countFunctionFiles(dir string) int {
+	count := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			count++
+		}
+		return nil
+	})
+	return count
+}
+
+// handleGetFunctionSettings returns the function file limits.
+// GET /_/api/settings/functions
+func (h *Handler) handleGetFunctionSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.functionFileLimits())
+}
+
+// handleUpdateFunctionSettings updates the function file limits.
+// PATCH /_/api/settings/functions
+func (h *Handler) handleUpdateFunctionSettings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MaxFileSize *int `json:"max_file_size"`
+		MaxFiles    *int `json:"max_files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+
+	if req.MaxFileSize != nil {
+		if *req.MaxFileSize <= 0 || *req.MaxFileSize > MaxFileSize {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "max_file_size must be between 1 and "+strconv.Itoa(MaxFileSize))
+			return
+		}
+		if err := h.store.Set("functions_max_file_size", strconv.Itoa(*req.MaxFileSize)); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	if req.MaxFiles != nil {
+		if *req.MaxFiles <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "max_files must be positive")
+			return
+		}
+		if err := h.store.Set("functions_max_files", strconv.Itoa(*req.MaxFiles)); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	h.handleGetFunctionSettings(w, r)
+}
@@ -0,0 +1,217 @@
+package dashboard
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/markb/sblite/internal/functions"
+)
+
+// functionImportFile is a validated file from a functions zip.
+type functionImportFile struct {
+	function string
+	relPath  string
+	fullPath string
+	file     *zip.File
+}
+
+// functionImportError describes a rejected zip entry.
+type functionImportError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// handleImportFunctions deploys edge functions from a zip in the layout
+// produced by the functions export: functions/<name>/<files>. Entries without
+// the functions/ prefix are also accepted as <name>/<files>. Every entry is
+// validated before anything is written; the import is rejected if any file
+// has a disallowed extension or path, or breaks the function file limits.
+// The runtime is restarted if it is running.
+// POST /_/api/functions/import
+func (h *Handler) handleImportFunctions(w http.ResponseWriter, r *http.Request) {
+	if h.functionsService == nil {
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions not enabled. Start the server with --functions flag.")
+		return
+	}
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "missing_field", "file field is required")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	// The server's upload body limit bounds the zip size
+	data, err := io.ReadAll(body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Failed to read zip: "+err.Error())
+		return
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_zip", "Invalid zip archive: "+err.Error())
+		return
+	}
+
+	functionsDir := h.functionsService.FunctionsDir()
+	limits := h.functionFileLimits()
+	files, rejected := collectFunctionImportFiles(archive, functionsDir, limits)
+	if len(rejected) > 0 {
+		writeErrorDetails(w, http.StatusBadRequest, "invalid_function_files",
+			fmt.Sprintf("%d file(s) in the zip were rejected", len(rejected)), rejected)
+		return
+	}
+	if len(files) == 0 {
+		writeError(w, http.StatusBadRequest, "invalid_zip", "Zip contains no function files")
+		return
+	}
+
+	// Note which functions exist before writing
+	existed := make(map[string]bool)
+	for _, f := range files {
+		if _, ok := existed[f.function]; !ok {
+			_, err := os.Stat(filepath.Join(functionsDir, f.function))
+			existed[f.function] = err == nil
+		}
+	}
+
+	for _, f := range files {
+		if err := writeFunctionImportFile(f, limits.MaxFileSize); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to write %s: %v", f.relPath, err))
+			return
+		}
+	}
+
+	created := []string{}
+	overwritten := []string{}
+	for name, ok := range existed {
+		if ok {
+			overwritten = append(overwritten, name)
+		} else {
+			created = append(created, name)
+		}
+	}
+	sort.Strings(created)
+	sort.Strings(overwritten)
+
+	restarted := false
+	if h.functionsService.IsRunning() {
+		if err := h.functionsService.Restart(r.Context()); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Functions imported but failed to restart runtime: %v", err))
+			return
+		}
+		restarted = true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"created":     created,
+		"overwritten": overwritten,
+		"files":       len(files),
+		"restarted":   restarted,
+	})
+}
+
+// collectFunctionImportFiles maps zip entries to destination paths, returning
+// the files to write and any entries that fail validation. The export README
+// and the internal _main service are skipped. Files are held to the per-file
+// size limit, and each function to the file count limit once its existing
+// files are counted in.
+func collectFunctionImportFiles(archive *zip.Reader, functionsDir string, limits FunctionFileLimits) ([]functionImportFile, []functionImportError) {
+	var files []functionImportFile
+	var rejected []functionImportError
+
+	for _, entry := range archive.File {
+		name := strings.TrimPrefix(strings.ReplaceAll(entry.Name, "\\", "/"), "./")
+		if entry.FileInfo().IsDir() || name == "README.md" {
+			continue
+		}
+		name = strings.TrimPrefix(name, "functions/")
+
+		function, rel, ok := strings.Cut(name, "/")
+		if !ok || rel == "" {
+			rejected = append(rejected, functionImportError{Path: entry.Name, Message: "file is not inside a function directory"})
+			continue
+		}
+		if function == "_main" {
+			continue
+		}
+		if function != "_shared" {
+			if err := functions.ValidateFunctionName(function); err != nil {
+				rejected = append(rejected, functionImportError{Path: entry.Name, Message: err.Error()})
+				continue
+			}
+		}
+		if !IsAllowedExtension(path.Ext(rel)) {
+			rejected = append(rejected, functionImportError{Path: entry.Name, Message: ErrDisallowedExtension.Error()})
+			continue
+		}
+		if entry.UncompressedSize64 > uint64(limits.MaxFileSize) {
+			rejected = append(rejected, functionImportError{Path: entry.Name, Message: fmt.Sprintf("file exceeds the %d byte limit", limits.MaxFileSize)})
+			continue
+		}
+
+		fullPath, err := SanitizePath(filepath.Join(functionsDir, function), rel)
+		if err != nil {
+			rejected = append(rejected, functionImportError{Path: entry.Name, Message: err.Error()})
+			continue
+		}
+		files = append(files, functionImportFile{function: function, relPath: name, fullPath: fullPath, file: entry})
+	}
+
+	// Files the zip adds, rather than overwrites, count towards the limit
+	added := make(map[string]int)
+	var order []string
+	for _, f := range files {
+		if _, ok := added[f.function]; !ok {
+			order = append(order, f.function)
+			added[f.function] = 0
+		}
+		if _, err := os.Stat(f.fullPath); os.IsNotExist(err) {
+			added[f.function]++
+		}
+	}
+	for _, function := range order {
+		if total := countFunctionFiles(filepath.Join(functionsDir, function)) + added[function]; total > limits.MaxFiles {
+			rejected = append(rejected, functionImportError{Path: function + "/",
+				Message: fmt.Sprintf("function would have %d files, more than the limit of %d", total, limits.MaxFiles)})
+		}
+	}
+	return files, rejected
+}
+
+// writeFunctionImportFile extracts one zip entry to its destination.
+func writeFunctionImportFile(f functionImportFile, maxSize int64) error {
+	rc, err := f.file.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// The header size can lie; never read more than the per-file limit
+	content, err := io.ReadAll(io.LimitReader(rc, maxSize+1))
+	if err != nil {
+		return err
+	}
+	if int64(len(content)) > maxSize {
+		return fmt.Errorf("file exceeds the %d byte limit", maxSize)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.fullPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(f.fullPath, content, 0644)
+}
@@ -0,0 +1,108 @@
+package dashboard
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/functions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildZip(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = f.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return &buf
+}
+
+func TestImportFunctions(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	dir := t.TempDir()
+	svc, err := functions.NewService(database.DB, &functions.Config{FunctionsDir: dir})
+	require.NoError(t, err)
+	handler.SetFunctionsService(svc)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "existing"), 0755))
+
+	r := chi.NewRouter()
+	r.Post("/functions/import", handler.handleImportFunctions)
+
+	importZip := func(files map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/functions/import", buildZip(t, files))
+		req.Header.Set("Content-Type", "application/zip")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := importZip(map[string]string{
+		"README.md":                   "# Export",
+		"functions/hello/index.ts":    "export default {}",
+		"functions/hello/lib/util.ts": "export const x = 1",
+		"functions/existing/index.ts": "// updated",
+		"functions/_shared/cors.ts":   "export const cors = {}",
+		"functions/_main/index.ts":    "// internal, skipped",
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Created     []string `json:"created"`
+		Overwritten []string `json:"overwritten"`
+		Files       int      `json:"files"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, []string{"_shared", "hello"}, resp.Created)
+	assert.Equal(t, []string{"existing"}, resp.Overwritten)
+	assert.Equal(t, 4, resp.Files)
+
+	content, err := os.ReadFile(filepath.Join(dir, "hello", "lib", "util.ts"))
+	require.NoError(t, err)
+	assert.Equal(t, "export const x = 1", string(content))
+	_, err = os.Stat(filepath.Join(dir, "_main"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Any invalid entry rejects the whole import
+	w = importZip(map[string]string{
+		"functions/other/index.ts":   "export default {}",
+		"functions/other/../../x.ts": "escape",
+		"functions/other/run.sh":     "echo",
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_function_files")
+	_, err = os.Stat(filepath.Join(dir, "other"))
+	assert.True(t, os.IsNotExist(err))
+
+	// The configured file limits apply; overwriting a file doesn't add to the count
+	require.NoError(t, handler.store.Set("functions_max_file_size", "10"))
+	require.NoError(t, handler.store.Set("functions_max_files", "2"))
+
+	w = importZip(map[string]string{"functions/hello/index.ts": "// v2"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = importZip(map[string]string{"functions/hello/big.ts": "// more than ten bytes"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "10 byte limit")
+
+	w = importZip(map[string]string{"functions/hello/new.ts": "// new"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "limit of 2")
+	_, err = os.Stat(filepath.Join(dir, "hello", "new.ts"))
+	assert.True(t, os.IsNotExist(err))
+}
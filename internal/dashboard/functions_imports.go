@@ -0,0 +1,63 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/functions"
+)
+
+// handleFunctionImports lists the module specifiers a function imports and
+// which of them don't resolve without runtime flags, typically npm: and jsr:
+// specifiers pasted from examples. PATCH the config with
+// generate_import_map to map those through the function's import map.
+// GET /_/api/functions/{name}/imports
+func (h *Handler) handleFunctionImports(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	if h.functionsService == nil {
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
+		return
+	}
+
+	fnDir := filepath.Join(h.functionsService.FunctionsDir(), name)
+	if info, err := os.Stat(fnDir); err != nil || !info.IsDir() {
+		writeError(w, http.StatusNotFound, "function_not_found", fmt.Sprintf("Function %q not found", name))
+		return
+	}
+
+	meta, err := h.functionsService.GetMetadata(name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	importMapFile := functions.ImportMapFile(meta)
+	importMap, err := functions.LoadImportMap(fnDir, importMapFile)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_import_map", err.Error())
+		return
+	}
+
+	specs, err := functions.ScanImports(fnDir, importMap)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan function files: "+err.Error())
+		return
+	}
+	unresolved := []string{}
+	for _, spec := range specs {
+		if !spec.Resolved {
+			unresolved = append(unresolved, spec.Specifier)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"import_map": importMapFile,
+		"specifiers": specs,
+		"unresolved": unresolved,
+	})
+}
@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/functions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunctionImports(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	dir := t.TempDir()
+	svc, err := functions.NewService(database.DB, &functions.Config{FunctionsDir: dir, JWTSecret: "test-secret"})
+	require.NoError(t, err)
+	handler.SetFunctionsService(svc)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "hello"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "hello", "index.ts"), []byte(`import { z } from "npm:zod@3"
+import { cors } from "../_shared/cors.ts"
+`), 0644))
+
+	r := chi.NewRouter()
+	r.Get("/functions/{name}/imports", handler.handleFunctionImports)
+	r.Patch("/functions/{name}/config", handler.handleUpdateFunctionConfig)
+
+	imports := func() map[string]interface{} {
+		req := httptest.NewRequest("GET", "/functions/hello/imports", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	patchConfig := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/functions/hello/config", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	resp := imports()
+	assert.Equal(t, functions.DefaultImportMapFile, resp["import_map"])
+	assert.Equal(t, []interface{}{"npm:zod@3"}, resp["unresolved"])
+	assert.Len(t, resp["specifiers"], 2)
+
+	// Import map paths must stay inside the function directory
+	w := patchConfig(`{"import_map": "../secrets.json"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = patchConfig(`{"generate_import_map": true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var meta functions.FunctionMetadata
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &meta))
+	assert.Equal(t, functions.DefaultImportMapFile, meta.ImportMap)
+
+	resp = imports()
+	assert.Equal(t, []interface{}{}, resp["unresolved"])
+
+	req := httptest.NewRequest("GET", "/functions/missing/imports", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
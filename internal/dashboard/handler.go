@@ -5,8 +5,8 @@ import (
 	"bufio"
 	"bytes"
 	"database/sql"
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -26,14 +26,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/markb/sblite/internal/dashboard/assets"
 	"github.com/markb/sblite/internal/dashboard/migration"
+	"github.com/markb/sblite/internal/db"
 	"github.com/markb/sblite/internal/fts"
 	"github.com/markb/sblite/internal/functions"
 	"github.com/markb/sblite/internal/log"
 	"github.com/markb/sblite/internal/mail"
 	"github.com/markb/sblite/internal/observability"
 	"github.com/markb/sblite/internal/pgtranslate"
+	"github.com/markb/sblite/internal/rls"
 	"github.com/markb/sblite/internal/rpc"
 	"github.com/markb/sblite/internal/storage"
+	"github.com/markb/sblite/internal/types"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -60,6 +63,7 @@ type Handler struct {
 	rpcInterceptor   *rpc.Interceptor
 	rpcExecutor      *rpc.Executor
 	catchMailer      *mail.CatchMailer
+	mailQueue        *mail.QueueMailer
 	migrationService *migration.Service
 	migrationsDir    string
 	startTime        time.Time
@@ -69,8 +73,19 @@ type Handler struct {
 	onSiteURLChange   func(string)
 	onStorageReload   func(*StorageConfig) error
 	onMailReload      func(*MailConfig) error
+	onSigningKeyRotate func(string)
 	realtimeService   RealtimeStatsProvider
 	telemetry        *observability.Telemetry
+	writes           *db.WriteQueue
+	columnStats      *columnStatsCache
+	uploadConfig     UploadConfig
+	loginGuard       *loginGuard
+	rlsService       *rls.Service
+	rlsEnforcer      *rls.Enforcer
+	basePath         string
+
+	impersonationDisabled bool
+	requestTimeout        time.Duration
 }
 
 // ServerConfig holds server configuration for display in settings.
@@ -87,6 +102,7 @@ type ServerConfig struct {
 // NewHandler creates a new Handler.
 func NewHandler(db *sql.DB, migrationsDir string) *Handler {
 	store := NewStore(db)
+	rlsService := newRLSService(db)
 	return &Handler{
 		db:            db,
 		store:         store,
@@ -96,6 +112,11 @@ func NewHandler(db *sql.DB, migrationsDir string) *Handler {
 		migrationsDir: migrationsDir,
 		startTime:     time.Now(),
 		serverConfig:  &ServerConfig{Version: "0.1.1"},
+		writes:        newDefaultWriteQueue(),
+		columnStats:   newColumnStatsCache(),
+		loginGuard:    newLoginGuard(),
+		rlsService:    rlsService,
+		rlsEnforcer:   rls.NewEnforcer(rlsService),
 	}
 }
 
@@ -121,6 +142,7 @@ func (h *Handler) sessionCookieName() string {
 // SetJWTSecret sets the JWT secret for API key generation.
 func (h *Handler) SetJWTSecret(secret string) {
 	h.jwtSecret = secret
+	h.encryptStoredSecrets()
 }
 
 // SetOAuthReloadFunc sets the callback function to be called when OAuth settings change.
@@ -168,6 +190,11 @@ func (h *Handler) SetCatchMailer(cm *mail.CatchMailer) {
 	h.catchMailer = cm
 }
 
+// SetMailQueue sets the outbound mail queue (nil when SMTP mode is off).
+func (h *Handler) SetMailQueue(q *mail.QueueMailer) {
+	h.mailQueue = q
+}
+
 // SetRealtimeService sets the realtime service for stats
 func (h *Handler) SetRealtimeService(svc RealtimeStatsProvider) {
 	h.realtimeService = svc
@@ -178,6 +205,13 @@ func (h *Handler) SetMigrationService(svc *migration.Service) {
 	h.migrationService = svc
 }
 
+// SetWriteQueue sets the shared write queue used to serialize data mutations.
+func (h *Handler) SetWriteQueue(q *db.WriteQueue) {
+	if q != nil {
+		h.writes = q
+	}
+}
+
 // SetTelemetry sets the OpenTelemetry manager for metrics access.
 func (h *Handler) SetTelemetry(tel *observability.Telemetry) {
 	h.telemetry = tel
@@ -191,6 +225,8 @@ func (h *Handler) SetTelemetry(tel *observability.Telemetry) {
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	// API routes
 	r.Route("/api", func(r chi.Router) {
+		r.Use(h.requireCSRF)
+		r.Use(h.enforceRequestTimeout)
 		r.Get("/auth/status", h.handleAuthStatus)
 		r.Post("/auth/setup", h.handleSetup)
 		r.Post("/auth/login", h.handleLogin)
@@ -201,17 +237,46 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/", h.handleListTables)
 			r.Post("/", h.handleCreateTable)
+			r.Post("/validate", h.handleValidateTable)
+			r.Get("/pk-audit", h.handlePrimaryKeyAudit)
 			r.Get("/{name}", h.handleGetTableSchema)
 			r.Delete("/{name}", h.handleDeleteTable)
+			r.Post("/{name}/truncate", h.handleTruncateTable)
+			r.Post("/{name}/clone", h.handleCloneTable)
+			r.Put("/{name}/primary-key", h.handleSetPrimaryKey)
+			r.Get("/{name}/indexes", h.handleListIndexes)
+			r.Get("/{name}/indexes/usage", h.handleIndexUsage)
+			r.Post("/{name}/indexes", h.handleCreateIndex)
+			r.Delete("/{name}/indexes/{index}", h.handleDropIndex)
+			r.Get("/{name}/settings", h.handleGetTableSettings)
+			r.Patch("/{name}/settings", h.handleUpdateTableSettings)
+			r.Get("/{name}/ddl", h.handleTableDDL)
 			r.Post("/{name}/columns", h.handleAddColumn)
+			r.Post("/{name}/columns/batch", h.handleAddColumnsBatch)
 			r.Patch("/{name}/columns/{column}", h.handleRenameColumn)
 			r.Delete("/{name}/columns/{column}", h.handleDropColumn)
+			r.Get("/{name}/columns/{column}/schema", h.handleGetColumnSchema)
+			r.Put("/{name}/columns/{column}/schema", h.handleSetColumnSchema)
+			r.Get("/{name}/columns/{column}/enum", h.handleGetColumnEnum)
+			r.Put("/{name}/columns/{column}/enum", h.handleSetColumnEnum)
+			r.Put("/{name}/columns/{column}/hidden", h.handleSetColumnHidden)
+			r.Patch("/{name}/columns/{column}/position", h.handleSetColumnPosition)
+			r.Get("/{name}/columns/{column}/stats", h.handleColumnStats)
+			r.Get("/{name}/columns/{column}/distinct", h.handleColumnDistinct)
+		})
+
+		// Views are read-only; they are created and dropped through the SQL browser
+		r.Route("/views", func(r chi.Router) {
+			r.Use(h.requireAuth)
+			r.Get("/", h.handleListViews)
+			r.Get("/{name}", h.handleGetView)
 		})
 
 		// Data API routes (require auth)
 		r.Route("/data", func(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/{table}", h.handleSelectData)
+			r.Get("/{table}/{id}", h.handleGetRow)
 			r.Post("/{table}", h.handleInsertData)
 			r.Patch("/{table}", h.handleUpdateData)
 			r.Delete("/{table}", h.handleDeleteData)
@@ -223,9 +288,14 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Get("/", h.handleListUsers)
 			r.Post("/", h.handleCreateUser)
 			r.Post("/invite", h.handleInviteUser)
+			r.Post("/import", h.handleImportUsers)
 			r.Get("/{id}", h.handleGetUser)
 			r.Patch("/{id}", h.handleUpdateUser)
 			r.Delete("/{id}", h.handleDeleteUser)
+			r.Get("/{id}/sessions", h.handleListUserSessions)
+			r.Delete("/{id}/sessions", h.handleRevokeUserSessions)
+			r.Delete("/{id}/sessions/{sessionId}", h.handleRevokeUserSessions)
+			r.Post("/{id}/impersonate", h.handleImpersonateUser)
 		})
 
 		// RLS Policies API routes (require auth)
@@ -244,6 +314,14 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/", h.handleGetTableRLS)
 			r.Patch("/", h.handleSetTableRLS)
+			r.Post("/explain", h.handleExplainRLS)
+		})
+
+		// Bulk policy toggles (nested under tables)
+		r.Route("/tables/{name}/policies", func(r chi.Router) {
+			r.Use(h.requireAuth)
+			r.Post("/disable-all", h.handleDisableAllPolicies)
+			r.Post("/restore", h.handleRestorePolicies)
 		})
 
 		// FTS index management routes (nested under tables)
@@ -257,10 +335,19 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Post("/{index}/rebuild", h.handleRebuildFTSIndex)
 		})
 
+		// Sequences behind nextval() column defaults (require auth)
+		r.Route("/sequences", func(r chi.Router) {
+			r.Use(h.requireAuth)
+			r.Get("/", h.handleListSequences)
+			r.Get("/{name}", h.handleGetSequence)
+			r.Post("/{name}/reset", h.handleResetSequence)
+		})
+
 		// Settings API routes (require auth)
 		r.Route("/settings", func(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/server", h.handleGetServerInfo)
+			r.Post("/reload", h.handleReloadSettings)
 			r.Get("/auth", h.handleGetAuthSettings)
 			r.Post("/auth/regenerate-secret", h.handleRegenerateSecret)
 			r.Get("/templates", h.handleListTemplates)
@@ -269,9 +356,11 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			// OAuth settings routes
 			r.Get("/oauth", h.handleGetOAuthSettings)
 			r.Patch("/oauth", h.handleUpdateOAuthSettings)
+			r.Post("/oauth/{provider}/test", h.handleTestOAuthProvider)
 			r.Get("/oauth/redirect-urls", h.handleGetRedirectURLs)
 			r.Post("/oauth/redirect-urls", h.handleAddRedirectURL)
 			r.Delete("/oauth/redirect-urls", h.handleDeleteRedirectURL)
+			r.Post("/oauth/redirect-urls/test", h.handleTestRedirectURL)
 			// Auth configuration settings routes
 			r.Get("/auth-config", h.handleGetAuthConfig)
 			r.Patch("/auth-config", h.handlePatchAuthConfig)
@@ -282,6 +371,16 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			// Mail settings routes
 			r.Get("/mail", h.handleGetMailSettings)
 			r.Patch("/mail", h.handleUpdateMailSettings)
+			r.Post("/mail/test", h.handleTestMailConnection)
+			// Data API settings routes
+			r.Get("/data", h.handleGetDataSettings)
+			r.Patch("/data", h.handleUpdateDataSettings)
+			// Function file editor limits
+			r.Get("/functions", h.handleGetFunctionSettings)
+			r.Patch("/functions", h.handleUpdateFunctionSettings)
+			// Session cookie attributes for reverse-proxy deployments
+			r.Get("/session-cookie", h.handleGetSessionCookieSettings)
+			r.Patch("/session-cookie", h.handleUpdateSessionCookieSettings)
 		})
 
 		// Export API routes (require auth)
@@ -291,6 +390,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Get("/data", h.handleExportData)
 			r.Get("/backup", h.handleExportBackup)
 			r.Get("/rls", h.handleExportRLS)
+			r.Get("/policies.json", h.handleExportPolicies)
 			r.Get("/functions", h.handleExportFunctions)
 			r.Get("/secrets", h.handleExportSecrets)
 			r.Route("/auth", func(r chi.Router) {
@@ -303,11 +403,18 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			})
 		})
 
+		// Import API routes (require auth)
+		r.Route("/import", func(r chi.Router) {
+			r.Use(h.requireAuth)
+			r.Post("/policies", h.handleImportPolicies)
+		})
+
 		// Logs API routes (require auth)
 		r.Route("/logs", func(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/", h.handleQueryLogs)
 			r.Get("/config", h.handleGetLogConfig)
+			r.Patch("/config", h.handleUpdateLogConfig)
 			r.Get("/tail", h.handleTailLogs)
 			r.Get("/buffer", h.handleBufferLogs)
 		})
@@ -316,6 +423,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Route("/observability", func(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/status", h.handleObservabilityStatus)
+			r.Patch("/status", h.handleUpdateObservabilityStatus)
 			r.Get("/metrics", h.handleObservabilityMetrics)
 			r.Get("/traces", h.handleObservabilityTraces)
 		})
@@ -326,6 +434,19 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Post("/", h.handleExecuteSQL)
 		})
 
+		// Maintenance routes (require auth)
+		r.Route("/maintenance", func(r chi.Router) {
+			r.Use(h.requireAuth)
+			r.Get("/db-stats", h.handleDBStats)
+			r.Post("/analyze", h.handleAnalyze)
+			r.Post("/integrity-check", h.handleRunIntegrityCheck)
+			r.Get("/integrity-check/results", h.handleListIntegrityCheckResults)
+			r.Get("/integrity-checks", h.handleListIntegrityChecks)
+			r.Post("/integrity-checks", h.handleCreateIntegrityCheck)
+			r.Delete("/integrity-checks/{name}", h.handleDeleteIntegrityCheck)
+			r.Post("/reset", h.handleFactoryReset)
+		})
+
 		// API Keys route (require auth)
 		r.Route("/apikeys", func(r chi.Router) {
 			r.Use(h.requireAuth)
@@ -339,11 +460,13 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Get("/status", h.handleGetFunctionsStatus)
 			r.Get("/runtime-info", h.handleGetRuntimeInfo)
 			r.Post("/runtime-install", h.handleInstallRuntime)
+			r.Post("/import", h.handleImportFunctions)
 			r.Post("/{name}", h.handleCreateFunction)
 			r.Get("/{name}", h.handleGetFunction)
 			r.Delete("/{name}", h.handleDeleteFunction)
 			r.Get("/{name}/config", h.handleGetFunctionConfig)
 			r.Patch("/{name}/config", h.handleUpdateFunctionConfig)
+			r.Get("/{name}/imports", h.handleFunctionImports)
 
 			// File operations (rename must come before wildcard routes)
 			r.Get("/{name}/files", h.handleListFunctionFiles)
@@ -361,12 +484,15 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/", h.handleListSecrets)
 			r.Post("/", h.handleSetSecret)
+			r.Post("/import", h.handleImportSecrets)
+			r.Post("/{name}/reveal", h.handleRevealSecret)
 			r.Delete("/{name}", h.handleDeleteSecret)
 		})
 
 		// Storage management routes (require auth)
 		r.Route("/storage", func(r chi.Router) {
 			r.Use(h.requireAuth)
+			r.Get("/usage", h.handleStorageUsage)
 			r.Get("/buckets", h.handleListBuckets)
 			r.Post("/buckets", h.handleCreateBucket)
 			r.Get("/buckets/{id}", h.handleGetBucket)
@@ -374,10 +500,18 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Delete("/buckets/{id}", h.handleDeleteBucket)
 			r.Post("/buckets/{id}/empty", h.handleEmptyBucket)
 			// Object routes
+			r.Post("/rotate-signing-key", h.handleRotateStorageSigningKey)
 			r.Post("/objects/list", h.handleListObjects)
 			r.Post("/objects/upload", h.handleUploadObject)
 			r.Get("/objects/download", h.handleDownloadObject)
 			r.Delete("/objects", h.handleDeleteObjects)
+			// Storage event webhooks
+			r.Get("/webhooks", h.handleListStorageWebhooks)
+			r.Post("/webhooks", h.handleCreateStorageWebhook)
+			r.Get("/webhooks/{id}", h.handleGetStorageWebhook)
+			r.Put("/webhooks/{id}", h.handleUpdateStorageWebhook)
+			r.Delete("/webhooks/{id}", h.handleDeleteStorageWebhook)
+			r.Get("/webhooks/{id}/deliveries", h.handleListStorageWebhookDeliveries)
 		})
 
 		// API Docs routes (require auth)
@@ -408,17 +542,28 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Get("/emails/{id}", h.handleGetEmail)
 			r.Delete("/emails/{id}", h.handleDeleteEmail)
 			r.Delete("/emails", h.handleClearEmails)
+			r.Get("/queue", h.handleMailQueue)
+			r.Post("/queue/retry", h.handleRetryMailQueue)
 		})
 
 		// Migration management routes (require auth)
+		r.Route("/schema", func(r chi.Router) {
+			r.Use(h.requireAuth)
+			r.Get("/version", h.handleSchemaVersion)
+			r.Get("/snapshots", h.handleListSchemaSnapshots)
+			r.Get("/snapshots/diff", h.handleDiffSchemaSnapshots)
+			r.Get("/snapshots/{id}", h.handleGetSchemaSnapshot)
+		})
 		r.Route("/migrations", func(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Get("/", h.handleMigrationsList)
+			r.Post("/{version}/revert", h.handleRevertMigration)
 		})
 		r.Route("/migration", func(r chi.Router) {
 			r.Use(h.requireAuth)
 			r.Post("/start", h.handleMigrationStart)
 			r.Get("/{id}", h.handleMigrationGet)
+			r.Get("/{id}/items", h.handleMigrationItems)
 			r.Delete("/{id}", h.handleMigrationDelete)
 			r.Post("/{id}/connect", h.handleMigrationConnect)
 			r.Get("/{id}/projects", h.handleMigrationProjects)
@@ -431,6 +576,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 			r.Post("/{id}/verify/basic", h.handleVerifyBasic)
 			r.Post("/{id}/verify/integrity", h.handleVerifyIntegrity)
 			r.Post("/{id}/verify/functional", h.handleVerifyFunctional)
+			r.Post("/{id}/verify/rollback", h.handleVerifyRollback)
 			r.Get("/{id}/verify/results", h.handleVerifyResults)
 		})
 	})
@@ -439,20 +585,24 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 	// Handle both /_/static/* (legacy) and /_/assets/* (React build)
 	r.Route("/static", func(r chi.Router) {
 		r.Get("/*", h.handleStatic)
+		r.Head("/*", h.handleStatic)
 	})
 	r.Route("/assets", func(r chi.Router) {
 		r.Get("/*", h.handleAssets)
+		r.Head("/*", h.handleAssets)
 	})
 
 	// SPA - serve index.html for root and use NotFound for other routes
 	r.Get("/", h.handleIndex)
+	r.Head("/", h.handleIndex)
 	r.NotFound(h.handleIndex)
 }
 
 func (h *Handler) handleIndex(w http.ResponseWriter, r *http.Request) {
 	// Redirect /_ to /_/ for React Router basename to work correctly
-	if r.URL.Path == "/_" {
-		http.Redirect(w, r, "/_/", http.StatusMovedPermanently)
+	base := h.BasePath()
+	if r.URL.Path == base {
+		http.Redirect(w, r, base+"/", http.StatusMovedPermanently)
 		return
 	}
 
@@ -466,8 +616,8 @@ func (h *Handler) handleIndex(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	w.Write(content)
+	// The rendered page depends on the base path, so it is part of the ETag key
+	serveEmbedded(w, r, base+"/index.html", h.renderIndex(content), "text/html; charset=utf-8", cacheRevalidate)
 }
 
 func (h *Handler) handleStatic(w http.ResponseWriter, r *http.Request) {
@@ -509,8 +659,7 @@ func (h *Handler) handleStatic(w http.ResponseWriter, r *http.Request) {
 		contentType = "image/jpeg"
 	}
 
-	w.Header().Set("Content-Type", contentType)
-	w.Write(content)
+	serveEmbedded(w, r, "static/"+path, content, contentType, cacheRevalidate)
 }
 
 func (h *Handler) handleAssets(w http.ResponseWriter, r *http.Request) {
@@ -554,8 +703,7 @@ func (h *Handler) handleAssets(w http.ResponseWriter, r *http.Request) {
 		contentType = "font/woff2"
 	}
 
-	w.Header().Set("Content-Type", contentType)
-	w.Write(content)
+	serveEmbeddedCompressed(w, r, dashboardFS, "assets/"+path, content, contentType, cacheImmutable)
 }
 
 func (h *Handler) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
@@ -567,11 +715,22 @@ func (h *Handler) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
 		authenticated = h.sessions.Validate(cookie.Value)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"needs_setup":   h.auth.NeedsSetup(),
 		"authenticated": authenticated,
-	})
+	}
+	// Hand the CSRF token to the page, issuing one for sessions that
+	// predate CSRF protection
+	if authenticated {
+		if csrf, err := r.Cookie(h.csrfCookieName()); err == nil && csrf.Value != "" {
+			resp["csrf_token"] = csrf.Value
+		} else {
+			resp["csrf_token"] = h.issueCSRFToken(w, r)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (h *Handler) handleSetup(w http.ResponseWriter, r *http.Request) {
@@ -579,36 +738,27 @@ func (h *Handler) handleSetup(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
 	if err := h.auth.SetupPassword(req.Password); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
 	// Create session
 	token, err := h.sessions.Create()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create session"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create session")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     h.sessionCookieName(),
-		Value:    token,
-		Path:     "/_/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   86400, // 24 hours
-	})
+	h.setSessionCookie(w, r, token, sessionMaxAge)
+	csrfToken := h.issueCSRFToken(w, r)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok", "csrf_token": csrfToken})
 }
 
 func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
@@ -616,49 +766,39 @@ func (h *Handler) handleLogin(w http.ResponseWriter, r *http.Request) {
 		Password string `json:"password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
+	if !h.checkLoginAllowed(w, r) {
+		return
+	}
 	if !h.auth.VerifyPassword(req.Password) {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid password"})
+		h.recordLoginFailure(w, r)
 		return
 	}
+	h.loginGuard.recordSuccess(clientIP(r))
+	log.Info("dashboard login succeeded", "audit", true, "remote_addr", clientIP(r))
 
 	// Create session
 	token, err := h.sessions.Create()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create session"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create session")
 		return
 	}
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     h.sessionCookieName(),
-		Value:    token,
-		Path:     "/_/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   86400,
-	})
+	h.setSessionCookie(w, r, token, sessionMaxAge)
+	csrfToken := h.issueCSRFToken(w, r)
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok", "csrf_token": csrfToken})
 }
 
 func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
 	h.sessions.Destroy()
 
-	http.SetCookie(w, &http.Cookie{
-		Name:     h.sessionCookieName(),
-		Value:    "",
-		Path:     "/_/",
-		HttpOnly: true,
-		SameSite: http.SameSiteStrictMode,
-		MaxAge:   -1, // Delete cookie
-	})
+	h.setSessionCookie(w, r, "", -1)
+	h.clearCSRFToken(w, r)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
@@ -669,16 +809,15 @@ func (h *Handler) requireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		cookie, err := r.Cookie(h.sessionCookieName())
 		if err != nil || cookie.Value == "" || !h.sessions.Validate(cookie.Value) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusUnauthorized)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Unauthorized")
 			return
 		}
 		next.ServeHTTP(w, r)
 	})
 }
 
-func (h *Handler) handleListTables(w http.ResponseWriter, r *http.Request) {
+// userTables returns the names of user tables, leaving out internal tables.
+func (h *Handler) userTables() ([]string, error) {
 	// Query sqlite_master for all user tables, filtering out internal tables
 	rows, err := h.db.Query(`
 		SELECT name FROM sqlite_master
@@ -690,14 +829,11 @@ func (h *Handler) handleListTables(w http.ResponseWriter, r *http.Request) {
 		ORDER BY name
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to list tables"})
-		return
+		return nil, err
 	}
 	defer rows.Close()
 
-	var tables []string
+	tables := []string{}
 	for rows.Next() {
 		var name string
 		if err := rows.Scan(&name); err != nil {
@@ -705,9 +841,14 @@ func (h *Handler) handleListTables(w http.ResponseWriter, r *http.Request) {
 		}
 		tables = append(tables, name)
 	}
+	return tables, nil
+}
 
-	if tables == nil {
-		tables = []string{}
+func (h *Handler) handleListTables(w http.ResponseWriter, r *http.Request) {
+	tables, err := h.userTables()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list tables")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -717,9 +858,7 @@ func (h *Handler) handleListTables(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleGetTableSchema(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
@@ -731,9 +870,7 @@ func (h *Handler) handleGetTableSchema(w http.ResponseWriter, r *http.Request) {
 	// First, get actual columns from SQLite table schema using PRAGMA
 	pragmaRows, err := h.db.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, tableName))
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to get table info"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get table info")
 		return
 	}
 
@@ -761,19 +898,15 @@ func (h *Handler) handleGetTableSchema(w http.ResponseWriter, r *http.Request) {
 	pragmaRows.Close()
 
 	if len(pragmaCols) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table not found"})
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
 		return
 	}
 
 	// Get metadata from _columns table (may not have all columns)
-	metaRows, err := h.db.Query(`SELECT column_name, pg_type, is_nullable, default_value, is_primary
-		FROM _columns WHERE table_name = ?`, tableName)
+	metaRows, err := h.db.Query(`SELECT column_name, pg_type, is_nullable, default_value, is_primary, COALESCE(description, ''),
+		COALESCE(is_hidden, 0), enum_values FROM _columns WHERE table_name = ?`, tableName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to get schema metadata"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get schema metadata")
 		return
 	}
 	defer metaRows.Close()
@@ -781,20 +914,25 @@ func (h *Handler) handleGetTableSchema(w http.ResponseWriter, r *http.Request) {
 	// Build a map of _columns metadata by column name
 	metaMap := make(map[string]map[string]interface{})
 	for metaRows.Next() {
-		var name, pgType string
-		var nullable, primary bool
-		var defaultVal sql.NullString
-		if err := metaRows.Scan(&name, &pgType, &nullable, &defaultVal, &primary); err != nil {
+		var name, pgType, description string
+		var nullable, primary, hidden bool
+		var defaultVal, enumValues sql.NullString
+		if err := metaRows.Scan(&name, &pgType, &nullable, &defaultVal, &primary, &description, &hidden, &enumValues); err != nil {
 			continue
 		}
 		meta := map[string]interface{}{
-			"type":     pgType,
-			"nullable": nullable,
-			"primary":  primary,
+			"type":        pgType,
+			"nullable":    nullable,
+			"primary":     primary,
+			"description": description,
+			"hidden":      hidden,
 		}
 		if defaultVal.Valid {
 			meta["default"] = defaultVal.String
 		}
+		if values := parseEnumValues(enumValues); values != nil {
+			meta["enum_values"] = values
+		}
 		metaMap[name] = meta
 	}
 
@@ -812,12 +950,19 @@ func (h *Handler) handleGetTableSchema(w http.ResponseWriter, r *http.Request) {
 			col["type"] = meta["type"]
 			col["nullable"] = meta["nullable"]
 			col["primary"] = meta["primary"]
+			col["description"] = meta["description"]
+			col["hidden"] = meta["hidden"]
 			if dflt, ok := meta["default"]; ok {
 				col["default"] = dflt
 			}
+			if values, ok := meta["enum_values"]; ok {
+				col["enum_values"] = values
+			}
 		} else {
 			// Infer PostgreSQL type from SQLite type
 			col["type"] = sqliteTypeToPgType(pc.sqliteType)
+			col["description"] = ""
+			col["hidden"] = false
 		}
 
 		if pc.dfltValue.Valid && col["default"] == nil {
@@ -827,10 +972,31 @@ func (h *Handler) handleGetTableSchema(w http.ResponseWriter, r *http.Request) {
 		columns = append(columns, col)
 	}
 
+	// Render in display order, which may differ from the physical order
+	if order, err := h.columnOrder(tableName); err == nil {
+		position := make(map[string]int, len(order))
+		for i, name := range order {
+			position[name] = i
+		}
+		sort.SliceStable(columns, func(i, j int) bool {
+			return position[columns[i]["name"].(string)] < position[columns[j]["name"].(string)]
+		})
+	}
+
+	primaryKey := h.primaryKeyColumns(tableName)
+	if primaryKey == nil {
+		primaryKey = []string{}
+	}
+
+	var description string
+	h.db.QueryRow(`SELECT description FROM _table_descriptions WHERE table_name = ?`, tableName).Scan(&description)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"name":    tableName,
-		"columns": columns,
+		"name":        tableName,
+		"description": description,
+		"columns":     columns,
+		"primary_key": primaryKey,
 	})
 }
 
@@ -857,6 +1023,11 @@ func sqliteTypeToPgType(sqliteType string) string {
 // and if not, auto-registers columns by inferring types from SQLite schema.
 // This allows tables created via migrations or SQL browser to appear in the dashboard.
 func (h *Handler) ensureTableRegistered(tableName string) error {
+	// Views have no column metadata of their own
+	if h.isView(tableName) {
+		return nil
+	}
+
 	// Get existing columns for this table from _columns
 	existingCols := make(map[string]bool)
 	rows, err := h.db.Query(`SELECT column_name FROM _columns WHERE table_name = ?`, tableName)
@@ -933,88 +1104,142 @@ type CreateTableRequest struct {
 		Default  string `json:"default,omitempty"`
 		Primary  bool   `json:"primary"`
 	} `json:"columns"`
+	// PrimaryKey lists the primary key columns in key order. When empty, the
+	// columns marked primary are used in column order.
+	PrimaryKey []string `json:"primary_key,omitempty"`
 }
 
 func (h *Handler) handleCreateTable(w http.ResponseWriter, r *http.Request) {
 	var req CreateTableRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Name == "" || len(req.Columns) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Name and columns required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Name and columns required")
+		return
+	}
+
+	if isReservedTableName(req.Name) {
+		writeError(w, http.StatusBadRequest, "reserved_table_name", reservedTableNameMessage(req.Name))
 		return
 	}
 
+	// An explicit key order marks its columns primary
+	if len(req.PrimaryKey) > 0 {
+		inKey := make(map[string]bool, len(req.PrimaryKey))
+		for _, name := range req.PrimaryKey {
+			inKey[name] = true
+		}
+		for i := range req.Columns {
+			req.Columns[i].Primary = inKey[req.Columns[i].Name]
+		}
+	}
+
 	// Build CREATE TABLE SQL
-	var colDefs []string
+	// The migration declares sequence columns with a nextval() default
+	var colDefs, migrationColDefs []string
 	var primaryKeys []string
-	for _, col := range req.Columns {
+	var sequenceCols []string
+	for i, col := range req.Columns {
 		sqlType := pgTypeToSQLite(col.Type)
 		def := fmt.Sprintf(`"%s" %s`, col.Name, sqlType)
-		if !col.Nullable {
-			def += " NOT NULL"
-		}
-		if col.Default != "" {
-			def += " DEFAULT " + mapDefaultValueForSQLite(col.Default, col.Type)
+		migrationDef := def
+		if isSequenceDefault(col.Default) {
+			// Filled in by the sequence trigger after the insert
+			req.Columns[i].Nullable = true
+			sequenceCols = append(sequenceCols, col.Name)
+			migrationDef += " DEFAULT " + nextvalSQL(sequenceName(req.Name, col.Name))
+		} else {
+			if !col.Nullable {
+				def += " NOT NULL"
+			}
+			if col.Default != "" {
+				def += " DEFAULT " + mapDefaultValueForSQLite(col.Default, col.Type)
+			}
+			migrationDef = def
 		}
 		colDefs = append(colDefs, def)
+		migrationColDefs = append(migrationColDefs, migrationDef)
 		if col.Primary {
 			primaryKeys = append(primaryKeys, fmt.Sprintf(`"%s"`, col.Name))
 		}
 	}
+	if len(req.PrimaryKey) > 0 {
+		primaryKeys = primaryKeys[:0]
+		for _, name := range req.PrimaryKey {
+			primaryKeys = append(primaryKeys, fmt.Sprintf(`"%s"`, name))
+		}
+	}
 	if len(primaryKeys) > 0 {
 		colDefs = append(colDefs, "PRIMARY KEY ("+strings.Join(primaryKeys, ", ")+")")
+		migrationColDefs = append(migrationColDefs, "PRIMARY KEY ("+strings.Join(primaryKeys, ", ")+")")
 	}
 
 	createSQL := fmt.Sprintf(`CREATE TABLE "%s" (%s)`, req.Name, strings.Join(colDefs, ", "))
+	migrationName := fmt.Sprintf("create_%s_table", req.Name)
+	var upStatements []string
+	for _, col := range sequenceCols {
+		upStatements = append(upStatements, createSequenceSQL(sequenceName(req.Name, col), 0))
+	}
+	upStatements = append(upStatements, fmt.Sprintf(`CREATE TABLE "%s" (%s);`, req.Name, strings.Join(migrationColDefs, ", ")))
+	upSQL := strings.Join(upStatements, "\n")
+	downSQL := fmt.Sprintf(`DROP TABLE IF EXISTS "%s";`, req.Name)
+	for _, col := range sequenceCols {
+		downSQL += "\n" + dropSequenceSQL(sequenceName(req.Name, col))
+	}
+
+	// A dry run shows what would run and be written, without doing either
+	if r.URL.Query().Get("dry_run") == "true" {
+		version := migrationVersion(time.Now())
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"dry_run":             true,
+			"sql":                 upSQL,
+			"down_sql":            downSQL,
+			"migration_file":      migrationFilename(version, migrationName, false),
+			"down_migration_file": migrationFilename(version, migrationName, true),
+		})
+		return
+	}
 
 	tx, err := h.db.Begin()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to start transaction"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to start transaction")
 		return
 	}
 	defer tx.Rollback()
 
 	if _, err := tx.Exec(createSQL); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
 	// Register columns in metadata
 	for _, col := range req.Columns {
 		_, err := tx.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, default_value, is_primary) VALUES (?, ?, ?, ?, ?, ?)`,
-			req.Name, col.Name, col.Type, col.Nullable, col.Default, col.Primary)
+			req.Name, col.Name, col.Type, col.Nullable, canonicalDefaultValue(col.Default), col.Primary)
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to register column"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to register column")
+			return
+		}
+	}
+	for _, col := range sequenceCols {
+		if _, err := createSequence(tx, req.Name, col); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 			return
 		}
 	}
 
 	if err := tx.Commit(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to commit"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to commit")
 		return
 	}
 
 	// Write migration file
-	migrationName := fmt.Sprintf("create_%s_table", req.Name)
-	if err := h.writeMigration(migrationName, createSQL+";"); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table created but failed to write migration: " + err.Error()})
+	if err := h.writeReversibleMigration(migrationName, upSQL, downSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Table created but failed to write migration: "+err.Error())
 		return
 	}
 
@@ -1034,6 +1259,21 @@ func pgTypeToSQLite(pgType string) string {
 	}
 }
 
+// canonicalDefaultValue returns a column default the way it is recorded in
+// _columns. Function defaults are stored as gen_random_uuid() and now(),
+// which is what rewriteInsertWithUUIDs looks for.
+func canonicalDefaultValue(defaultVal string) string {
+	switch strings.ToLower(strings.TrimSpace(defaultVal)) {
+	case "gen_random_uuid()":
+		return "gen_random_uuid()"
+	case "now()":
+		return "now()"
+	case sequenceDefault:
+		return sequenceDefault
+	}
+	return defaultVal
+}
+
 // mapDefaultValueForSQLite maps PostgreSQL default values to SQLite equivalents.
 func mapDefaultValueForSQLite(defaultVal, pgType string) string {
 	lower := strings.ToLower(defaultVal)
@@ -1044,6 +1284,9 @@ func mapDefaultValueForSQLite(defaultVal, pgType string) string {
 	case "now()":
 		// PostgreSQL-compatible timestamptz format with milliseconds and UTC offset
 		return "(strftime('%Y-%m-%d %H:%M:%f+00', 'now'))"
+	case sequenceDefault:
+		// Sequence values are assigned by a trigger, see createSequence
+		return "NULL"
 	}
 
 	// Handle boolean literals
@@ -1059,16 +1302,37 @@ func mapDefaultValueForSQLite(defaultVal, pgType string) string {
 	return defaultVal
 }
 
+// migrationVersion returns the version of a migration written at t.
+func migrationVersion(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+// migrationFilename returns the file name of a migration, or of its paired
+// down migration.
+func migrationFilename(version, name string, down bool) string {
+	if down {
+		return fmt.Sprintf("%s_%s.down.sql", version, name)
+	}
+	return fmt.Sprintf("%s_%s.sql", version, name)
+}
+
 // writeMigration creates a migration file and records it in _schema_migrations.
 func (h *Handler) writeMigration(name string, sql string) error {
+	return h.writeReversibleMigration(name, sql, "")
+}
+
+// writeReversibleMigration is writeMigration with a paired down migration that
+// undoes it, written alongside as version_name.down.sql. An empty downSQL
+// writes no down file.
+func (h *Handler) writeReversibleMigration(name, sql, downSQL string) error {
 	// Ensure migrations directory exists (auto-create if needed)
 	if err := os.MkdirAll(h.migrationsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create migrations directory: %w", err)
 	}
 
 	// Generate version timestamp
-	version := time.Now().UTC().Format("20060102150405")
-	filename := fmt.Sprintf("%s_%s.sql", version, name)
+	version := migrationVersion(time.Now())
+	filename := migrationFilename(version, name, false)
 
 	// Write migration file
 	path := filepath.Join(h.migrationsDir, filename)
@@ -1076,77 +1340,124 @@ func (h *Handler) writeMigration(name string, sql string) error {
 		return fmt.Errorf("failed to write migration file: %w", err)
 	}
 
+	downPath := ""
+	if downSQL != "" {
+		downPath = filepath.Join(h.migrationsDir, migrationFilename(version, name, true))
+		if err := os.WriteFile(downPath, []byte(downSQL), 0644); err != nil {
+			os.Remove(path)
+			return fmt.Errorf("failed to write down migration file: %w", err)
+		}
+	}
+
 	// Record in _schema_migrations
 	_, err := h.db.Exec(`INSERT INTO _schema_migrations (version, name) VALUES (?, ?)`, version, name)
 	if err != nil {
-		// Clean up the file if we can't record the migration
+		// Clean up the files if we can't record the migration
 		os.Remove(path)
+		if downPath != "" {
+			os.Remove(downPath)
+		}
 		return fmt.Errorf("failed to record migration: %w", err)
 	}
 
+	h.recordSchemaSnapshot(name)
 	return nil
 }
 
 func (h *Handler) handleDeleteTable(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
+	// The down migration recreates the table and its indexes (without data).
+	// Sequence triggers aren't copied; the sequences are declared instead,
+	// continuing from their current values.
+	type tableSequence struct {
+		name, column string
+		current      int64
+	}
+	var sequences []tableSequence
+	sequenceTriggers := map[string]bool{}
+	if rows, err := h.db.Query(`SELECT name, column_name, current_value FROM _sequences WHERE table_name = ? ORDER BY name`, tableName); err == nil {
+		for rows.Next() {
+			var seq tableSequence
+			if rows.Scan(&seq.name, &seq.column, &seq.current) == nil {
+				sequences = append(sequences, seq)
+				sequenceTriggers[seq.name] = true
+			}
+		}
+		rows.Close()
+	}
+	var downStatements []string
+	for _, seq := range sequences {
+		downStatements = append(downStatements, createSequenceSQL(seq.name, seq.current))
+	}
+	if rows, err := h.db.Query(`SELECT name, sql FROM sqlite_master WHERE tbl_name = ? AND sql IS NOT NULL
+		ORDER BY CASE type WHEN 'table' THEN 0 ELSE 1 END`, tableName); err == nil {
+		for rows.Next() {
+			var name, stmt string
+			if rows.Scan(&name, &stmt) == nil && !sequenceTriggers[name] {
+				downStatements = append(downStatements, stmt+";")
+			}
+		}
+		rows.Close()
+	}
+	for _, seq := range sequences {
+		downStatements = append(downStatements, setSequenceDefaultSQL(tableName, seq.column, seq.name))
+	}
+
 	tx, err := h.db.Begin()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to start transaction"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to start transaction")
 		return
 	}
 	defer tx.Rollback()
 
 	// Drop the table
 	if _, err := tx.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS "%s"`, tableName)); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	// Remove metadata
 	if _, err := tx.Exec(`DELETE FROM _columns WHERE table_name = ?`, tableName); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to remove metadata"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to remove metadata")
+		return
+	}
+	if _, err := tx.Exec(`DELETE FROM _sequences WHERE table_name = ?`, tableName); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to remove metadata")
 		return
 	}
 
 	if err := tx.Commit(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to commit"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to commit")
 		return
 	}
 
 	// Write migration file
 	dropSQL := fmt.Sprintf(`DROP TABLE IF EXISTS "%s";`, tableName)
+	for _, seq := range sequences {
+		dropSQL += "\n" + dropSequenceSQL(seq.name)
+	}
 	migrationName := fmt.Sprintf("drop_%s_table", tableName)
-	if err := h.writeMigration(migrationName, dropSQL); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table dropped but failed to write migration: " + err.Error()})
+	if err := h.writeReversibleMigration(migrationName, dropSQL, strings.Join(downStatements, "\n")); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Table dropped but failed to write migration: "+err.Error())
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleSelectData returns a page of rows. An explicit order=column[.desc]
+// parameter always wins; otherwise the table's default_order setting is used,
+// then its primary key, then rowid, so pagination is stable.
+// GET /_/api/data/{table}
 func (h *Handler) handleSelectData(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "table")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
@@ -1155,13 +1466,18 @@ func (h *Handler) handleSelectData(w http.ResponseWriter, r *http.Request) {
 		// Log but don't fail - table might not exist yet
 	}
 
+	// Requests above the configured cap are clamped rather than rejected
+	maxLimit := h.maxPageSize(tableName)
 	limit := 25
 	offset := 0
 	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
 			limit = parsed
 		}
 	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
 	if o := r.URL.Query().Get("offset"); o != "" {
 		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
 			offset = parsed
@@ -1169,11 +1485,37 @@ func (h *Handler) handleSelectData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Parse filters
+	if err := h.checkArrayFilters(tableName, r.URL.Query()); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_filter", err.Error())
+		return
+	}
 	whereClause, whereValues := h.parseSelectFilter(r.URL.Query())
 
-	// Parse order
+	// Requests made with a user token only see rows their RLS policies allow
+	rlsUsing, _, err := h.dataRLSConditions(r, tableName, "SELECT")
+	if err != nil {
+		if !writeDataRLSError(w, err) {
+			writeError(w, http.StatusInternalServerError, "rls_error", "Failed to apply RLS")
+		}
+		return
+	}
+	whereClause = appendWhere(whereClause, rlsUsing)
+
+	// Hidden columns are only returned when named in select=
+	columnList, err := h.selectColumnList(tableName, r.URL.Query().Get("select"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_select", err.Error())
+		return
+	}
+
+	// Parse order. Without one, rows come back in a stable default order so
+	// pages neither skip nor repeat rows.
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = h.defaultOrder(tableName)
+	}
 	orderClause := ""
-	if order := r.URL.Query().Get("order"); order != "" {
+	if order != "" {
 		parts := strings.Split(order, ".")
 		if len(parts) >= 1 {
 			col := parts[0]
@@ -1184,30 +1526,40 @@ func (h *Handler) handleSelectData(w http.ResponseWriter, r *http.Request) {
 			orderClause = fmt.Sprintf(` ORDER BY "%s" %s`, col, dir)
 		}
 	}
+	if orderClause == "" {
+		orderClause = h.primaryKeyOrderClause(tableName)
+	}
+
+	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "%s" %s`, tableName, whereClause)
+	query := fmt.Sprintf(`SELECT %s FROM "%s" %s%s LIMIT %d OFFSET %d`, columnList, tableName, whereClause, orderClause, limit, offset)
+	if wantsSQLExplain(r) {
+		writeSQLExplain(w, query, countQuery, whereValues)
+		return
+	}
+
+	var timing serverTiming
 
 	// Get total count with filters
 	var total int
-	countQuery := fmt.Sprintf(`SELECT COUNT(*) FROM "%s" %s`, tableName, whereClause)
-	err := h.db.QueryRow(countQuery, whereValues...).Scan(&total)
+	done := timing.track("count")
+	err = h.db.QueryRow(countQuery, whereValues...).Scan(&total)
+	done()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table not found"})
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
 		return
 	}
 
 	// Get rows with filters and order
-	query := fmt.Sprintf(`SELECT * FROM "%s" %s%s LIMIT %d OFFSET %d`, tableName, whereClause, orderClause, limit, offset)
+	done = timing.track("query")
 	rows, err := h.db.Query(query, whereValues...)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
 
 	columns, _ := rows.Columns()
+	colTypes := h.columnTypes(tableName)
 	var results []map[string]interface{}
 
 	for rows.Next() {
@@ -1220,7 +1572,7 @@ func (h *Handler) handleSelectData(w http.ResponseWriter, r *http.Request) {
 
 		row := make(map[string]interface{})
 		for i, col := range columns {
-			row[col] = values[i]
+			row[col] = jsonValue(values[i], colTypes[col])
 		}
 		results = append(results, row)
 	}
@@ -1228,27 +1580,56 @@ func (h *Handler) handleSelectData(w http.ResponseWriter, r *http.Request) {
 	if results == nil {
 		results = []map[string]interface{}{}
 	}
+	done()
+
+	// The JSON timing covers count and query; serialization can only be
+	// reported in the header, once the body has been encoded
+	done = timing.track("serialize")
+	body, err := json.Marshal(map[string]interface{}{
+		"rows":      results,
+		"total":     total,
+		"limit":     limit,
+		"max_limit": maxLimit,
+		"offset":    offset,
+		"timing":    timing.millis(),
+	})
+	done()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"rows":   results,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-	})
+	w.Header().Set("Server-Timing", timing.header())
+	w.Write(append(body, '\n'))
 }
 
 func (h *Handler) handleInsertData(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "table")
+	if h.rejectViewWrite(w, tableName) {
+		return
+	}
 
 	var data map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
 		return
 	}
 
+	// Replay the original result when a retried request reuses its key
+	idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+	var requestHash string
+	if idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			writeError(w, http.StatusBadRequest, "invalid_idempotency_key", "Idempotency-Key is too long")
+			return
+		}
+		requestHash = idempotencyRequestHash(data)
+		if h.replayIdempotent(w, idempotencyKey, tableName, requestHash) {
+			return
+		}
+	}
+
 	// Get columns with default values so we can skip empty values for them
 	columnsWithDefaults := make(map[string]bool)
 	rows, err := h.db.Query(`SELECT column_name FROM _columns WHERE table_name = ? AND default_value IS NOT NULL AND default_value != ''`, tableName)
@@ -1262,6 +1643,17 @@ func (h *Handler) handleInsertData(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	columnTypes := h.getColumnTypes(tableName)
+
+	if err := validateColumnSchemas(h.getColumnJSONSchemas(tableName), columnTypes, data); err != nil {
+		writeColumnSchemaError(w, err)
+		return
+	}
+	if err := validateColumnEnums(h.getColumnEnums(tableName), data); err != nil {
+		writeColumnEnumError(w, err)
+		return
+	}
+
 	var columns []string
 	var placeholders []string
 	var values []interface{}
@@ -1270,18 +1662,61 @@ func (h *Handler) handleInsertData(w http.ResponseWriter, r *http.Request) {
 		if columnsWithDefaults[col] && isEmptyValue(val) {
 			continue
 		}
+		coerced, err := coerceColumnValue(columnTypes, col, val)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
 		columns = append(columns, fmt.Sprintf(`"%s"`, col))
 		placeholders = append(placeholders, "?")
-		values = append(values, val)
+		values = append(values, coerced)
 	}
 
 	query := fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s)`,
 		tableName, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
 
-	if _, err := h.db.Exec(query, values...); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	// Requests made with a user token may only insert rows passing the RLS check
+	_, rlsCheck, err := h.dataRLSConditions(r, tableName, "INSERT")
+	if err != nil {
+		if !writeDataRLSError(w, err) {
+			writeError(w, http.StatusInternalServerError, "rls_error", "Failed to apply RLS")
+		}
+		return
+	}
+
+	err = h.runWrite(r, func() error {
+		if idempotencyKey != "" {
+			return h.insertIdempotent(idempotencyKey, tableName, requestHash, query, values, rlsCheck, data)
+		}
+		if rlsCheck == "" {
+			_, err := h.db.Exec(query, values...)
+			return err
+		}
+		tx, err := h.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		result, err := tx.Exec(query, values...)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if err := verifyRLSCheck(tx, tableName, rlsCheck, []int64{id}); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if errors.Is(err, errIdempotencyKeyInUse) && h.replayIdempotent(w, idempotencyKey, tableName, requestHash) {
+		return
+	}
+	if err != nil {
+		if !writeDataRLSError(w, err) {
+			writeWriteError(w, err, http.StatusBadRequest)
+		}
 		return
 	}
 
@@ -1301,14 +1736,57 @@ func isEmptyValue(val interface{}) bool {
 	return false
 }
 
+// getColumnTypes returns the declared pg_type of each column registered in _columns.
+func (h *Handler) getColumnTypes(tableName string) map[string]string {
+	columnTypes := make(map[string]string)
+	rows, err := h.db.Query(`SELECT column_name, pg_type FROM _columns WHERE table_name = ?`, tableName)
+	if err != nil {
+		return columnTypes
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, pgType string
+		if rows.Scan(&name, &pgType) == nil {
+			columnTypes[name] = pgType
+		}
+	}
+	return columnTypes
+}
+
+// coerceColumnValue converts a JSON value to the storage form of the column's
+// declared type. Columns without metadata are passed through unchanged.
+func coerceColumnValue(columnTypes map[string]string, col string, val interface{}) (interface{}, error) {
+	pgType, ok := columnTypes[col]
+	if !ok {
+		return val, nil
+	}
+	coerced, err := types.Coerce(types.PgType(pgType), val)
+	if err != nil {
+		return nil, fmt.Errorf("column %q: %w", col, err)
+	}
+	return coerced, nil
+}
+
 func (h *Handler) handleUpdateData(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "table")
+	if h.rejectViewWrite(w, tableName) {
+		return
+	}
 
 	var data map[string]interface{}
 	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	columnTypes := h.getColumnTypes(tableName)
+
+	if err := validateColumnSchemas(h.getColumnJSONSchemas(tableName), columnTypes, data); err != nil {
+		writeColumnSchemaError(w, err)
+		return
+	}
+	if err := validateColumnEnums(h.getColumnEnums(tableName), data); err != nil {
+		writeColumnEnumError(w, err)
 		return
 	}
 
@@ -1316,46 +1794,196 @@ func (h *Handler) handleUpdateData(w http.ResponseWriter, r *http.Request) {
 	var setClauses []string
 	var values []interface{}
 	for col, val := range data {
+		coerced, err := coerceColumnValue(columnTypes, col, val)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
 		setClauses = append(setClauses, fmt.Sprintf(`"%s" = ?`, col))
-		values = append(values, val)
+		values = append(values, coerced)
 	}
 
 	// Parse filter from query string (simple eq filter)
 	whereClause, whereValues := h.parseSimpleFilter(r.URL.Query())
+
+	// Requests made with a user token may only update rows their RLS policies
+	// allow, and only into rows passing the RLS check
+	rlsUsing, rlsCheck, err := h.dataRLSConditions(r, tableName, "UPDATE")
+	if err != nil {
+		if !writeDataRLSError(w, err) {
+			writeError(w, http.StatusInternalServerError, "rls_error", "Failed to apply RLS")
+		}
+		return
+	}
+	whereClause = appendWhere(whereClause, rlsUsing)
+
+	// Optional optimistic concurrency precondition: the update only applies
+	// if the row's version/updated_at still matches what the client read
+	precondClause, precondValues, err := updatePrecondition(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_precondition", err.Error())
+		return
+	}
+	updateWhere := whereClause
+	if precondClause != "" {
+		if updateWhere == "" {
+			updateWhere = "WHERE " + precondClause
+		} else {
+			updateWhere += " AND " + precondClause
+		}
+		// Bump the version or updated_at so other editors holding the old
+		// value are rejected
+		if r.Header.Get("If-Match") != "" {
+			if _, ok := data["version"]; !ok {
+				setClauses = append(setClauses, `"version" = "version" + 1`)
+			}
+		} else if _, ok := data["updated_at"]; !ok {
+			now, err := coerceColumnValue(columnTypes, "updated_at", time.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00"))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+				return
+			}
+			setClauses = append(setClauses, `"updated_at" = ?`)
+			values = append(values, now)
+		}
+	}
 	values = append(values, whereValues...)
+	values = append(values, precondValues...)
 
-	query := fmt.Sprintf(`UPDATE "%s" SET %s %s`, tableName, strings.Join(setClauses, ", "), whereClause)
+	query := fmt.Sprintf(`UPDATE "%s" SET %s %s`, tableName, strings.Join(setClauses, ", "), updateWhere)
 
-	result, err := h.db.Exec(query, values...)
+	var affected int64
+	err = h.runWrite(r, func() error {
+		if rlsCheck == "" {
+			result, err := h.db.Exec(query, values...)
+			if err != nil {
+				return err
+			}
+			affected, _ = result.RowsAffected()
+			return nil
+		}
+		tx, err := h.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		rowIDs, err := queryRowIDs(tx, query+" RETURNING rowid", values)
+		if err != nil {
+			return err
+		}
+		if err := verifyRLSCheck(tx, tableName, rlsCheck, rowIDs); err != nil {
+			return err
+		}
+		affected = int64(len(rowIDs))
+		return tx.Commit()
+	})
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		if !writeDataRLSError(w, err) {
+			writeWriteError(w, err, http.StatusBadRequest)
+		}
 		return
 	}
 
-	affected, _ := result.RowsAffected()
+	// A failed precondition on an existing row is a conflict: return the current
+	// row so the client can merge and retry
+	if affected == 0 && precondClause != "" {
+		current, err := h.selectRows(tableName, whereClause, whereValues)
+		if err == nil && len(current) > 0 {
+			writeErrorDetails(w, http.StatusConflict, "row_modified", "Row was modified by another request",
+				map[string]interface{}{"current": current})
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{"updated": affected})
 }
 
+// updatedAtExpr normalizes "updated_at" to SQLite's datetime form for
+// comparison. SQLite can't parse the "+00" suffix of stored timestamptz
+// values, so it is dropped; those values are always UTC.
+const updatedAtExpr = `datetime(CASE WHEN "updated_at" LIKE '%+00' THEN substr("updated_at", 1, length("updated_at") - 3) ELSE "updated_at" END)`
+
+// updatePrecondition builds the optimistic concurrency condition for an update.
+// If-Match compares against the "version" column; If-Unmodified-Since requires
+// that "updated_at" is not later than the given HTTP date. HTTP dates have
+// one-second precision, so updated_at is compared to the second.
+func updatePrecondition(r *http.Request) (string, []interface{}, error) {
+	if etag := strings.Trim(r.Header.Get("If-Match"), `"`); etag != "" {
+		return `"version" = ?`, []interface{}{etag}, nil
+	}
+	if header := r.Header.Get("If-Unmodified-Since"); header != "" {
+		since, err := http.ParseTime(header)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid If-Unmodified-Since date: %s", header)
+		}
+		return updatedAtExpr + ` <= ?`, []interface{}{since.UTC().Format("2006-01-02 15:04:05")}, nil
+	}
+	return "", nil, nil
+}
+
+// selectRows returns the rows of a table matching a WHERE clause.
+func (h *Handler) selectRows(tableName, whereClause string, whereValues []interface{}) ([]map[string]interface{}, error) {
+	rows, err := h.db.Query(fmt.Sprintf(`SELECT * FROM "%s" %s`, tableName, whereClause), whereValues...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	colTypes := h.columnTypes(tableName)
+	var results []map[string]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{})
+		for i, col := range columns {
+			row[col] = jsonValue(values[i], colTypes[col])
+		}
+		results = append(results, row)
+	}
+	return results, rows.Err()
+}
+
 func (h *Handler) handleDeleteData(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "table")
+	if h.rejectViewWrite(w, tableName) {
+		return
+	}
 
 	whereClause, whereValues := h.parseSimpleFilter(r.URL.Query())
 	if whereClause == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Filter required for delete"})
+		writeError(w, http.StatusBadRequest, "filter_required", "Filter required for delete")
 		return
 	}
 
+	// Requests made with a user token may only delete rows their RLS policies allow
+	rlsUsing, _, err := h.dataRLSConditions(r, tableName, "DELETE")
+	if err != nil {
+		if !writeDataRLSError(w, err) {
+			writeError(w, http.StatusInternalServerError, "rls_error", "Failed to apply RLS")
+		}
+		return
+	}
+	whereClause = appendWhere(whereClause, rlsUsing)
+
 	query := fmt.Sprintf(`DELETE FROM "%s" %s`, tableName, whereClause)
 
-	if _, err := h.db.Exec(query, whereValues...); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+	err = h.runWrite(r, func() error {
+		_, err := h.db.Exec(query, whereValues...)
+		return err
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusBadRequest)
 		return
 	}
 
@@ -1390,50 +2018,14 @@ func (h *Handler) parseSelectFilter(query url.Values) (string, []interface{}) {
 	var values []interface{}
 
 	for key, vals := range query {
-		if key == "limit" || key == "offset" || key == "order" {
+		if key == "limit" || key == "offset" || key == "order" || key == "explain" {
 			continue
 		}
 		// Process ALL filter values for this key (supports multiple filters on same column)
 		for _, val := range vals {
-			switch {
-			case strings.HasPrefix(val, "eq."):
-				conditions = append(conditions, fmt.Sprintf(`"%s" = ?`, key))
-				values = append(values, strings.TrimPrefix(val, "eq."))
-			case strings.HasPrefix(val, "neq."):
-				conditions = append(conditions, fmt.Sprintf(`"%s" != ?`, key))
-				values = append(values, strings.TrimPrefix(val, "neq."))
-			case strings.HasPrefix(val, "gt."):
-				conditions = append(conditions, fmt.Sprintf(`"%s" > ?`, key))
-				values = append(values, strings.TrimPrefix(val, "gt."))
-			case strings.HasPrefix(val, "gte."):
-				conditions = append(conditions, fmt.Sprintf(`"%s" >= ?`, key))
-				values = append(values, strings.TrimPrefix(val, "gte."))
-			case strings.HasPrefix(val, "lt."):
-				conditions = append(conditions, fmt.Sprintf(`"%s" < ?`, key))
-				values = append(values, strings.TrimPrefix(val, "lt."))
-			case strings.HasPrefix(val, "lte."):
-				conditions = append(conditions, fmt.Sprintf(`"%s" <= ?`, key))
-				values = append(values, strings.TrimPrefix(val, "lte."))
-			case strings.HasPrefix(val, "like."):
-				pattern := strings.TrimPrefix(val, "like.")
-				pattern = strings.ReplaceAll(pattern, "*", "%")
-				conditions = append(conditions, fmt.Sprintf(`"%s" LIKE ?`, key))
-				values = append(values, pattern)
-			case strings.HasPrefix(val, "ilike."):
-				pattern := strings.TrimPrefix(val, "ilike.")
-				pattern = strings.ReplaceAll(pattern, "*", "%")
-				conditions = append(conditions, fmt.Sprintf(`"%s" LIKE ? COLLATE NOCASE`, key))
-				values = append(values, pattern)
-			case strings.HasPrefix(val, "is."):
-				v := strings.TrimPrefix(val, "is.")
-				switch v {
-				case "null":
-					conditions = append(conditions, fmt.Sprintf(`"%s" IS NULL`, key))
-				case "true":
-					conditions = append(conditions, fmt.Sprintf(`"%s" = 1`, key))
-				case "false":
-					conditions = append(conditions, fmt.Sprintf(`"%s" = 0`, key))
-				}
+			if cond, condValues, ok := buildFilterCondition(key, val); ok {
+				conditions = append(conditions, cond)
+				values = append(values, condValues...)
 			}
 		}
 	}
@@ -1444,72 +2036,270 @@ func (h *Handler) parseSelectFilter(query url.Values) (string, []interface{}) {
 	return "WHERE " + strings.Join(conditions, " AND "), values
 }
 
+// buildFilterCondition converts a PostgREST-style filter value (e.g. "eq.active",
+// "not.like.*test*") into a SQL condition for the given column.
+// A "not." prefix negates the condition by wrapping it in NOT (...).
+func buildFilterCondition(key, val string) (string, []interface{}, bool) {
+	if strings.HasPrefix(val, "not.") {
+		cond, values, ok := buildFilterCondition(key, strings.TrimPrefix(val, "not."))
+		if !ok {
+			return "", nil, false
+		}
+		return "NOT (" + cond + ")", values, true
+	}
+
+	switch {
+	case strings.HasPrefix(val, "eq."):
+		return fmt.Sprintf(`"%s" = ?`, key), []interface{}{strings.TrimPrefix(val, "eq.")}, true
+	case strings.HasPrefix(val, "neq."):
+		return fmt.Sprintf(`"%s" != ?`, key), []interface{}{strings.TrimPrefix(val, "neq.")}, true
+	case strings.HasPrefix(val, "gt."):
+		return fmt.Sprintf(`"%s" > ?`, key), []interface{}{strings.TrimPrefix(val, "gt.")}, true
+	case strings.HasPrefix(val, "gte."):
+		return fmt.Sprintf(`"%s" >= ?`, key), []interface{}{strings.TrimPrefix(val, "gte.")}, true
+	case strings.HasPrefix(val, "lt."):
+		return fmt.Sprintf(`"%s" < ?`, key), []interface{}{strings.TrimPrefix(val, "lt.")}, true
+	case strings.HasPrefix(val, "lte."):
+		return fmt.Sprintf(`"%s" <= ?`, key), []interface{}{strings.TrimPrefix(val, "lte.")}, true
+	case strings.HasPrefix(val, "like."):
+		pattern := strings.TrimPrefix(val, "like.")
+		pattern = strings.ReplaceAll(pattern, "*", "%")
+		return fmt.Sprintf(`"%s" LIKE ?`, key), []interface{}{pattern}, true
+	case strings.HasPrefix(val, "ilike."):
+		pattern := strings.TrimPrefix(val, "ilike.")
+		pattern = strings.ReplaceAll(pattern, "*", "%")
+		return fmt.Sprintf(`"%s" LIKE ? COLLATE NOCASE`, key), []interface{}{pattern}, true
+	case strings.HasPrefix(val, "cs."):
+		// Every listed value appears in the column's JSON array. Like
+		// PostgreSQL, a NULL column matches neither cs nor not.cs.
+		items := parseArrayFilterValue(strings.TrimPrefix(val, "cs."))
+		if len(items) == 0 {
+			return "", nil, false
+		}
+		conds := make([]string, len(items))
+		for i := range items {
+			conds[i] = fmt.Sprintf(`EXISTS (SELECT 1 FROM json_each("%s") WHERE value = ?)`, key)
+		}
+		return fmt.Sprintf(`(CASE WHEN "%s" IS NULL THEN NULL ELSE %s END)`, key, strings.Join(conds, " AND ")), items, true
+	case strings.HasPrefix(val, "cd."):
+		// Every element of the column's JSON array is one of the listed values
+		items := parseArrayFilterValue(strings.TrimPrefix(val, "cd."))
+		if len(items) == 0 {
+			return "", nil, false
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(items)), ", ")
+		return fmt.Sprintf(`(CASE WHEN "%s" IS NULL THEN NULL ELSE NOT EXISTS (SELECT 1 FROM json_each("%s") WHERE value NOT IN (%s)) END)`, key, key, placeholders), items, true
+	case strings.HasPrefix(val, "is."):
+		switch strings.TrimPrefix(val, "is.") {
+		case "null":
+			return fmt.Sprintf(`"%s" IS NULL`, key), nil, true
+		case "true":
+			return fmt.Sprintf(`"%s" = 1`, key), nil, true
+		case "false":
+			return fmt.Sprintf(`"%s" = 0`, key), nil, true
+		}
+	}
+	return "", nil, false
+}
+
+// addColumnRequest is a column definition for handleAddColumn and
+// handleAddColumnsBatch.
+type addColumnRequest struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Default  string `json:"default,omitempty"`
+}
+
+// addColumn adds col to tableName and registers it in _columns within tx.
+// It canonicalizes col.Default and returns the SQL to record in the
+// migration. Failures of the ALTER itself are returned as-is so callers can
+// report them as bad requests; a failed registration is wrapped in
+// errRegisterColumn.
+func addColumn(tx *sql.Tx, tableName string, col *addColumnRequest) (string, error) {
+	col.Default = canonicalDefaultValue(col.Default)
+	sqlType := pgTypeToSQLite(col.Type)
+	addSQL := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, tableName, col.Name, sqlType)
+	if col.Default == sequenceDefault {
+		return addSequenceColumn(tx, tableName, col, addSQL)
+	}
+	alterSQL := addSQL
+	if col.Default != "" {
+		alterSQL += " DEFAULT " + mapDefaultValueForSQLite(col.Default, col.Type)
+	}
+
+	if _, err := tx.Exec(alterSQL); err != nil {
+		// SQLite only allows a non-constant default on a column added to an
+		// empty table. A UUID column is added without one instead: existing
+		// rows are filled in here, and inserts through the SQL browser get
+		// UUIDs from rewriteInsertWithUUIDs via the _columns default.
+		if col.Default != "gen_random_uuid()" || !strings.Contains(err.Error(), "non-constant default") {
+			return "", err
+		}
+		backfillSQL := fmt.Sprintf(`UPDATE "%s" SET "%s" = %s`, tableName, col.Name, mapDefaultValueForSQLite(col.Default, col.Type))
+		for _, stmt := range []string{addSQL, backfillSQL} {
+			if _, err := tx.Exec(stmt); err != nil {
+				return "", err
+			}
+		}
+		alterSQL = addSQL + ";\n" + backfillSQL
+	}
+
+	if _, err := tx.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, default_value, is_primary) VALUES (?, ?, ?, ?, ?, ?)`,
+		tableName, col.Name, col.Type, col.Nullable, col.Default, false); err != nil {
+		return "", fmt.Errorf("%w: %v", errRegisterColumn, err)
+	}
+	return alterSQL, nil
+}
+
+// addSequenceColumn adds a nextval() column, numbers the existing rows in
+// rowid order and starts the sequence after them.
+func addSequenceColumn(tx *sql.Tx, tableName string, col *addColumnRequest, addSQL string) (string, error) {
+	if col.Type != string(types.TypeInteger) {
+		return "", fmt.Errorf("default nextval() requires an integer column, not %s", col.Type)
+	}
+	col.Nullable = true
+	backfillSQL := sequenceBackfillSQL(tableName, col.Name)
+	for _, stmt := range []string{addSQL, backfillSQL} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return "", err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, default_value, is_primary) VALUES (?, ?, ?, ?, ?, ?)`,
+		tableName, col.Name, col.Type, true, col.Default, false); err != nil {
+		return "", fmt.Errorf("%w: %v", errRegisterColumn, err)
+	}
+	if _, err := createSequence(tx, tableName, col.Name); err != nil {
+		return "", err
+	}
+	// Replaying the migration numbers the existing rows the same way
+	name := sequenceName(tableName, col.Name)
+	return createSequenceSQL(name, 0) + "\n" + addSQL + " DEFAULT " + nextvalSQL(name), nil
+}
+
+// addedColumnDownSQL returns the down migration for an added column, removing
+// its sequence first since SQLite won't drop a column a trigger uses.
+func addedColumnDownSQL(tableName string, col addColumnRequest) string {
+	dropSQL := fmt.Sprintf(`ALTER TABLE "%s" DROP COLUMN "%s";`, tableName, col.Name)
+	if col.Default == sequenceDefault {
+		dropSQL = dropSequenceDefaultSQL(tableName, col.Name) + "\n" + dropSequenceSQL(sequenceName(tableName, col.Name)) + "\n" + dropSQL
+	}
+	return dropSQL
+}
+
+// errRegisterColumn reports a column that was added but couldn't be
+// recorded in _columns.
+var errRegisterColumn = errors.New("failed to register column")
+
 func (h *Handler) handleAddColumn(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 
-	var col struct {
-		Name     string `json:"name"`
-		Type     string `json:"type"`
-		Nullable bool   `json:"nullable"`
-		Default  string `json:"default,omitempty"`
+	var col addColumnRequest
+	if err := json.NewDecoder(r.Body).Decode(&col); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
+		return
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to start transaction")
+		return
+	}
+	defer tx.Rollback()
+
+	alterSQL, err := addColumn(tx, tableName, &col)
+	if errors.Is(err, errRegisterColumn) {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to register column")
+		return
+	} else if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to commit")
+		return
+	}
+
+	// Write migration file
+	migrationName := fmt.Sprintf("add_%s_column_to_%s", col.Name, tableName)
+	downSQL := addedColumnDownSQL(tableName, col)
+	if err := h.writeReversibleMigration(migrationName, alterSQL+";", downSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Column added but failed to write migration: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(col)
+}
+
+// handleAddColumnsBatch adds several columns in one transaction and records
+// them in a single migration file. If any column fails, none are added.
+// POST /_/api/tables/{name}/columns/batch
+func (h *Handler) handleAddColumnsBatch(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var req struct {
+		Columns []addColumnRequest `json:"columns"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&col); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
-
-	sqlType := pgTypeToSQLite(col.Type)
-	alterSQL := fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, tableName, col.Name, sqlType)
-	if col.Default != "" {
-		alterSQL += " DEFAULT " + mapDefaultValueForSQLite(col.Default, col.Type)
+	if len(req.Columns) == 0 {
+		writeError(w, http.StatusBadRequest, "missing_field", "columns required")
+		return
+	}
+	for _, col := range req.Columns {
+		if col.Name == "" || col.Type == "" {
+			writeError(w, http.StatusBadRequest, "missing_field", "Each column requires a name and type")
+			return
+		}
 	}
 
 	tx, err := h.db.Begin()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to start transaction"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to start transaction")
 		return
 	}
 	defer tx.Rollback()
 
-	if _, err := tx.Exec(alterSQL); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
-		return
-	}
-
-	_, err = tx.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, default_value, is_primary) VALUES (?, ?, ?, ?, ?, ?)`,
-		tableName, col.Name, col.Type, col.Nullable, col.Default, false)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to register column"})
-		return
+	var upSQL, downSQL []string
+	names := make([]string, len(req.Columns))
+	for i := range req.Columns {
+		col := &req.Columns[i]
+		alterSQL, err := addColumn(tx, tableName, col)
+		if errors.Is(err, errRegisterColumn) {
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to register column "+col.Name)
+			return
+		} else if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("column %s: %v", col.Name, err))
+			return
+		}
+		upSQL = append(upSQL, alterSQL+";")
+		// Drop in reverse order so the down migration mirrors the up
+		downSQL = append([]string{addedColumnDownSQL(tableName, *col)}, downSQL...)
+		names[i] = col.Name
 	}
 
 	if err := tx.Commit(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to commit"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to commit")
 		return
 	}
 
-	// Write migration file
-	migrationName := fmt.Sprintf("add_%s_column_to_%s", col.Name, tableName)
-	if err := h.writeMigration(migrationName, alterSQL+";"); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Column added but failed to write migration: " + err.Error()})
+	migrationName := fmt.Sprintf("add_%s_columns_to_%s", strings.Join(names, "_"), tableName)
+	if err := h.writeReversibleMigration(migrationName, strings.Join(upSQL, "\n"), strings.Join(downSQL, "\n")); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Columns added but failed to write migration: "+err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(col)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":   tableName,
+		"columns": req.Columns,
+	})
 }
 
 func (h *Handler) handleRenameColumn(w http.ResponseWriter, r *http.Request) {
@@ -1520,50 +2310,45 @@ func (h *Handler) handleRenameColumn(w http.ResponseWriter, r *http.Request) {
 		NewName string `json:"new_name"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.NewName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "new_name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "new_name required")
 		return
 	}
 
 	tx, err := h.db.Begin()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to start transaction"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to start transaction")
 		return
 	}
 	defer tx.Rollback()
 
 	alterSQL := fmt.Sprintf(`ALTER TABLE "%s" RENAME COLUMN "%s" TO "%s"`, tableName, oldName, req.NewName)
 	if _, err := tx.Exec(alterSQL); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
 	if _, err := tx.Exec(`UPDATE _columns SET column_name = ? WHERE table_name = ? AND column_name = ?`,
 		req.NewName, tableName, oldName); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update metadata"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update metadata")
+		return
+	}
+	// SQLite rewrites the sequence trigger; the sequence keeps its name
+	if _, err := tx.Exec(`UPDATE _sequences SET column_name = ? WHERE table_name = ? AND column_name = ?`,
+		req.NewName, tableName, oldName); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update metadata")
 		return
 	}
 
 	if err := tx.Commit(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to commit"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to commit")
 		return
 	}
 
 	// Write migration file
 	migrationName := fmt.Sprintf("rename_column_%s_to_%s_in_%s", oldName, req.NewName, tableName)
-	if err := h.writeMigration(migrationName, alterSQL+";"); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Column renamed but failed to write migration: " + err.Error()})
+	downSQL := fmt.Sprintf(`ALTER TABLE "%s" RENAME COLUMN "%s" TO "%s";`, tableName, req.NewName, oldName)
+	if err := h.writeReversibleMigration(migrationName, alterSQL+";", downSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Column renamed but failed to write migration: "+err.Error())
 		return
 	}
 
@@ -1575,121 +2360,141 @@ func (h *Handler) handleDropColumn(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	columnName := chi.URLParam(r, "column")
 
-	// Get remaining columns
-	rows, err := h.db.Query(`SELECT column_name, pg_type, is_nullable, default_value, is_primary
-		FROM _columns WHERE table_name = ? AND column_name != ? ORDER BY column_name`, tableName, columnName)
+	// Get remaining columns in table order, with their primary key positions
+	h.ensureTableRegistered(tableName)
+
+	// The down migration re-adds the column (without its data)
+	var downSQL string
+	var droppedType string
+	var droppedDefault sql.NullString
+	var droppedSequence string
+	var droppedSequenceValue int64
+	h.db.QueryRow(`SELECT name, current_value FROM _sequences WHERE table_name = ? AND column_name = ?`,
+		tableName, columnName).Scan(&droppedSequence, &droppedSequenceValue)
+	if h.db.QueryRow(`SELECT pg_type, default_value FROM _columns WHERE table_name = ? AND column_name = ?`,
+		tableName, columnName).Scan(&droppedType, &droppedDefault) == nil {
+		downSQL = fmt.Sprintf(`ALTER TABLE "%s" ADD COLUMN "%s" %s`, tableName, columnName, pgTypeToSQLite(droppedType))
+		if droppedSequence != "" {
+			downSQL = createSequenceSQL(droppedSequence, droppedSequenceValue) + "\n" + downSQL + " DEFAULT " + nextvalSQL(droppedSequence)
+		} else if droppedDefault.Valid && droppedDefault.String != "" {
+			downSQL += " DEFAULT " + mapDefaultValueForSQLite(droppedDefault.String, droppedType)
+		}
+		downSQL += ";"
+	}
+	rows, err := h.db.Query(`SELECT c.column_name, c.pg_type, c.is_nullable, c.default_value, p.pk
+		FROM _columns c JOIN pragma_table_info(?) p ON p.name = c.column_name
+		WHERE c.table_name = ? AND c.column_name != ? ORDER BY p.cid`, tableName, tableName, columnName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to get columns"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get columns")
 		return
 	}
 	defer rows.Close()
 
 	type colInfo struct {
-		name, pgType      string
-		nullable, primary bool
-		defaultVal        sql.NullString
+		name, pgType string
+		nullable     bool
+		pk           int
+		defaultVal   sql.NullString
 	}
 	var remainingCols []colInfo
 	var colNames []string
 
 	for rows.Next() {
 		var c colInfo
-		rows.Scan(&c.name, &c.pgType, &c.nullable, &c.defaultVal, &c.primary)
+		rows.Scan(&c.name, &c.pgType, &c.nullable, &c.defaultVal, &c.pk)
 		remainingCols = append(remainingCols, c)
 		colNames = append(colNames, fmt.Sprintf(`"%s"`, c.name))
 	}
 
 	if len(remainingCols) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Cannot drop last column"})
+		writeError(w, http.StatusBadRequest, "last_column", "Cannot drop last column")
 		return
 	}
 
 	tx, err := h.db.Begin()
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to start transaction"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to start transaction")
 		return
 	}
 	defer tx.Rollback()
 
 	// Create new table without the column
 	var colDefs []string
-	var primaryKeys []string
+	var keyCols []colInfo
 	for _, c := range remainingCols {
 		def := fmt.Sprintf(`"%s" %s`, c.name, pgTypeToSQLite(c.pgType))
 		if !c.nullable {
 			def += " NOT NULL"
 		}
-		if c.defaultVal.Valid {
+		if c.defaultVal.Valid && !isSequenceDefault(c.defaultVal.String) {
 			def += " DEFAULT " + c.defaultVal.String
 		}
 		colDefs = append(colDefs, def)
-		if c.primary {
-			primaryKeys = append(primaryKeys, fmt.Sprintf(`"%s"`, c.name))
+		if c.pk > 0 {
+			keyCols = append(keyCols, c)
 		}
 	}
+	sort.Slice(keyCols, func(i, j int) bool { return keyCols[i].pk < keyCols[j].pk })
+	var primaryKeys []string
+	for _, c := range keyCols {
+		primaryKeys = append(primaryKeys, fmt.Sprintf(`"%s"`, c.name))
+	}
 	if len(primaryKeys) > 0 {
 		colDefs = append(colDefs, "PRIMARY KEY ("+strings.Join(primaryKeys, ", ")+")")
 	}
 
 	newTableSQL := fmt.Sprintf(`CREATE TABLE "%s_new" (%s)`, tableName, strings.Join(colDefs, ", "))
 	if _, err := tx.Exec(newTableSQL); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	// Copy data
 	copySQL := fmt.Sprintf(`INSERT INTO "%s_new" SELECT %s FROM "%s"`, tableName, strings.Join(colNames, ", "), tableName)
 	if _, err := tx.Exec(copySQL); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	// Drop old, rename new
 	if _, err := tx.Exec(fmt.Sprintf(`DROP TABLE "%s"`, tableName)); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE "%s_new" RENAME TO "%s"`, tableName, tableName)); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	// Update metadata
 	if _, err := tx.Exec(`DELETE FROM _columns WHERE table_name = ? AND column_name = ?`, tableName, columnName); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update metadata"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update metadata")
+		return
+	}
+
+	// The rebuild dropped the sequence triggers; the other columns get theirs back
+	if _, err := tx.Exec(`DELETE FROM _sequences WHERE table_name = ? AND column_name = ?`, tableName, columnName); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update metadata")
+		return
+	}
+	if err := restoreSequenceTriggers(tx, tableName); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	if err := tx.Commit(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to commit"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to commit")
 		return
 	}
 
 	// Write migration file (use PostgreSQL-compatible syntax for Supabase migration)
 	dropColumnSQL := fmt.Sprintf(`ALTER TABLE "%s" DROP COLUMN "%s";`, tableName, columnName)
+	if droppedSequence != "" {
+		dropColumnSQL = dropSequenceDefaultSQL(tableName, columnName) + "\n" + dropSequenceSQL(droppedSequence) + "\n" + dropColumnSQL
+	}
 	migrationName := fmt.Sprintf("drop_column_%s_from_%s", columnName, tableName)
-	if err := h.writeMigration(migrationName, dropColumnSQL); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Column dropped but failed to write migration: " + err.Error()})
+	if err := h.writeReversibleMigration(migrationName, dropColumnSQL, downSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Column dropped but failed to write migration: "+err.Error())
 		return
 	}
 
@@ -1728,9 +2533,7 @@ func (h *Handler) handleListUsers(w http.ResponseWriter, r *http.Request) {
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM auth_users %s", whereClause)
 	err := h.db.QueryRow(countQuery).Scan(&total)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to count users"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to count users")
 		return
 	}
 
@@ -1744,9 +2547,7 @@ func (h *Handler) handleListUsers(w http.ResponseWriter, r *http.Request) {
 		LIMIT ? OFFSET ?`, whereClause)
 	rows, err := h.db.Query(usersQuery, limit, offset)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to list users"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list users")
 		return
 	}
 	defer rows.Close()
@@ -1800,26 +2601,20 @@ func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 		AutoConfirm bool   `json:"auto_confirm"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
 	// Validate email
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 	if req.Email == "" || !strings.Contains(req.Email, "@") {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Please enter a valid email address"})
+		writeError(w, http.StatusBadRequest, "invalid_email", "Please enter a valid email address")
 		return
 	}
 
 	// Validate password
 	if len(req.Password) < 6 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Password must be at least 6 characters"})
+		writeError(w, http.StatusBadRequest, "weak_password", "Password must be at least 6 characters")
 		return
 	}
 
@@ -1827,18 +2622,14 @@ func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	var existingID string
 	err := h.db.QueryRow("SELECT id FROM auth_users WHERE email = ?", req.Email).Scan(&existingID)
 	if err == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusConflict)
-		json.NewEncoder(w).Encode(map[string]string{"error": "A user with this email already exists"})
+		writeError(w, http.StatusConflict, "user_exists", "A user with this email already exists")
 		return
 	}
 
 	// Hash password
 	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create user"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create user")
 		return
 	}
 
@@ -1857,9 +2648,7 @@ func (h *Handler) handleCreateUser(w http.ResponseWriter, r *http.Request) {
 	`, id, req.Email, string(hash), emailConfirmedAt, now, now)
 
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create user"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create user")
 		return
 	}
 
@@ -1878,18 +2667,14 @@ func (h *Handler) handleInviteUser(w http.ResponseWriter, r *http.Request) {
 		Email string `json:"email"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
 	// Validate email
 	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
 	if req.Email == "" || !strings.Contains(req.Email, "@") {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Please enter a valid email address"})
+		writeError(w, http.StatusBadRequest, "invalid_email", "Please enter a valid email address")
 		return
 	}
 
@@ -1898,9 +2683,7 @@ func (h *Handler) handleInviteUser(w http.ResponseWriter, r *http.Request) {
 	var emailConfirmedAt sql.NullString
 	err := h.db.QueryRow("SELECT id, email_confirmed_at FROM auth_users WHERE email = ?", req.Email).Scan(&existingID, &emailConfirmedAt)
 	if err == nil && emailConfirmedAt.Valid {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusConflict)
-		json.NewEncoder(w).Encode(map[string]string{"error": "A user with this email already exists"})
+		writeError(w, http.StatusConflict, "user_exists", "A user with this email already exists")
 		return
 	}
 
@@ -1923,9 +2706,7 @@ func (h *Handler) handleInviteUser(w http.ResponseWriter, r *http.Request) {
 			VALUES (?, ?, '', NULL, '{"provider":"email","providers":["email"]}', '{}', ?, ?)
 		`, userID, req.Email, now.Format(time.RFC3339), now.Format(time.RFC3339))
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create user"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create user")
 			return
 		}
 	}
@@ -1937,9 +2718,7 @@ func (h *Handler) handleInviteUser(w http.ResponseWriter, r *http.Request) {
 	`, token, userID, req.Email, expiresAt.Format(time.RFC3339), now.Format(time.RFC3339))
 
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create invitation"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create invitation")
 		return
 	}
 
@@ -1963,9 +2742,7 @@ func (h *Handler) handleInviteUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 	if userID == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User ID required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "User ID required")
 		return
 	}
 
@@ -1977,9 +2754,7 @@ func (h *Handler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 		FROM auth_users WHERE id = ?`, userID).Scan(
 		&id, &email, &emailConfirmedAt, &lastSignInAt, &appMeta, &userMeta, &createdAt, &updatedAt)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User not found"})
+		writeError(w, http.StatusNotFound, "user_not_found", "User not found")
 		return
 	}
 
@@ -1999,9 +2774,7 @@ func (h *Handler) handleGetUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 	if userID == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User ID required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "User ID required")
 		return
 	}
 
@@ -2012,9 +2785,7 @@ func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 		EmailConfirmed *bool   `json:"email_confirmed,omitempty"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
@@ -2043,9 +2814,7 @@ func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(setClauses) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "No fields to update"})
+		writeError(w, http.StatusBadRequest, "no_changes", "No fields to update")
 		return
 	}
 
@@ -2055,17 +2824,13 @@ func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 	query := fmt.Sprintf(`UPDATE auth_users SET %s WHERE id = ?`, strings.Join(setClauses, ", "))
 	result, err := h.db.Exec(query, values...)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	affected, _ := result.RowsAffected()
 	if affected == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User not found"})
+		writeError(w, http.StatusNotFound, "user_not_found", "User not found")
 		return
 	}
 
@@ -2076,25 +2841,19 @@ func (h *Handler) handleUpdateUser(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 	userID := chi.URLParam(r, "id")
 	if userID == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User ID required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "User ID required")
 		return
 	}
 
 	result, err := h.db.Exec(`DELETE FROM auth_users WHERE id = ?`, userID)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	affected, _ := result.RowsAffected()
 	if affected == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "User not found"})
+		writeError(w, http.StatusNotFound, "user_not_found", "User not found")
 		return
 	}
 
@@ -2105,26 +2864,102 @@ func (h *Handler) handleDeleteUser(w http.ResponseWriter, r *http.Request) {
 // RLS Policy Handlers
 // ============================================================================
 
+// policyCommands are the commands an RLS policy can apply to.
+var policyCommands = map[string]bool{"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "ALL": true}
+
+// policySortColumns are the columns policies can be sorted by.
+var policySortColumns = map[string]bool{
+	"table_name":  true,
+	"policy_name": true,
+	"command":     true,
+	"enabled":     true,
+	"created_at":  true,
+}
+
+// handleListPolicies lists RLS policies. Optional query parameters: table,
+// command and enabled filter; sort and order (asc/desc) control ordering;
+// limit and offset paginate. Without limit all matching policies are returned.
 func (h *Handler) handleListPolicies(w http.ResponseWriter, r *http.Request) {
-	tableName := r.URL.Query().Get("table")
+	query := r.URL.Query()
 
-	var rows *sql.Rows
-	var err error
-	if tableName != "" {
-		rows, err = h.db.Query(`
-			SELECT id, table_name, policy_name, command, using_expr, check_expr, enabled, created_at
-			FROM _rls_policies WHERE table_name = ? ORDER BY policy_name
-		`, tableName)
-	} else {
-		rows, err = h.db.Query(`
-			SELECT id, table_name, policy_name, command, using_expr, check_expr, enabled, created_at
-			FROM _rls_policies ORDER BY table_name, policy_name
-		`)
+	var conditions []string
+	var args []interface{}
+	if tableName := query.Get("table"); tableName != "" {
+		conditions = append(conditions, "table_name = ?")
+		args = append(args, tableName)
+	}
+	if command := query.Get("command"); command != "" {
+		command = strings.ToUpper(command)
+		if !policyCommands[command] {
+			writeError(w, http.StatusBadRequest, "invalid_command", "command must be SELECT, INSERT, UPDATE, DELETE, or ALL")
+			return
+		}
+		conditions = append(conditions, "command = ?")
+		args = append(args, command)
+	}
+	if enabled := query.Get("enabled"); enabled != "" {
+		b, err := strconv.ParseBool(enabled)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "enabled must be true or false")
+			return
+		}
+		conditions = append(conditions, "enabled = ?")
+		args = append(args, b)
+	}
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderBy := "table_name, policy_name"
+	if sortBy := query.Get("sort"); sortBy != "" {
+		if !policySortColumns[sortBy] {
+			writeError(w, http.StatusBadRequest, "invalid_sort", "sort must be one of table_name, policy_name, command, enabled, created_at")
+			return
+		}
+		direction := "ASC"
+		switch strings.ToLower(query.Get("order")) {
+		case "", "asc":
+		case "desc":
+			direction = "DESC"
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_sort", "order must be asc or desc")
+			return
+		}
+		orderBy = fmt.Sprintf("%s %s, policy_name", sortBy, direction)
+	}
+
+	limit := -1
+	offset := 0
+	if l := query.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+	if o := query.Get("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "offset must be a non-negative integer")
+			return
+		}
+		offset = parsed
+	}
+
+	var total int
+	if err := h.db.QueryRow("SELECT COUNT(*) FROM _rls_policies "+whereClause, args...).Scan(&total); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
 	}
+
+	rows, err := h.db.Query(fmt.Sprintf(`
+		SELECT id, table_name, policy_name, command, using_expr, check_expr, enabled, created_at
+		FROM _rls_policies %s ORDER BY %s LIMIT ? OFFSET ?
+	`, whereClause, orderBy), append(args, limit, offset)...)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -2154,8 +2989,16 @@ func (h *Handler) handleListPolicies(w http.ResponseWriter, r *http.Request) {
 		policies = append(policies, p)
 	}
 
+	resp := map[string]interface{}{
+		"policies": policies,
+		"total":    total,
+	}
+	if limit > 0 {
+		resp["limit"] = limit
+		resp["offset"] = offset
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{"policies": policies})
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (h *Handler) handleCreatePolicy(w http.ResponseWriter, r *http.Request) {
@@ -2168,26 +3011,19 @@ func (h *Handler) handleCreatePolicy(w http.ResponseWriter, r *http.Request) {
 		Enabled    *bool  `json:"enabled"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
 	// Validate required fields
 	if req.TableName == "" || req.PolicyName == "" || req.Command == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "table_name, policy_name, and command are required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "table_name, policy_name, and command are required")
 		return
 	}
 
 	// Validate command
-	validCommands := map[string]bool{"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "ALL": true}
-	if !validCommands[req.Command] {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "command must be SELECT, INSERT, UPDATE, DELETE, or ALL"})
+	if !policyCommands[req.Command] {
+		writeError(w, http.StatusBadRequest, "invalid_command", "command must be SELECT, INSERT, UPDATE, DELETE, or ALL")
 		return
 	}
 
@@ -2202,14 +3038,10 @@ func (h *Handler) handleCreatePolicy(w http.ResponseWriter, r *http.Request) {
 	`, req.TableName, req.PolicyName, req.Command, req.UsingExpr, req.CheckExpr, enabled)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{"error": "A policy with this name already exists for this table"})
+			writeError(w, http.StatusConflict, "policy_exists", "A policy with this name already exists for this table")
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -2245,9 +3077,7 @@ func (h *Handler) handleGetPolicy(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid policy ID"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid policy ID")
 		return
 	}
 
@@ -2268,15 +3098,11 @@ func (h *Handler) handleGetPolicy(w http.ResponseWriter, r *http.Request) {
 		FROM _rls_policies WHERE id = ?
 	`, id).Scan(&p.ID, &p.TableName, &p.PolicyName, &p.Command, &usingExpr, &checkExpr, &enabled, &p.CreatedAt)
 	if err == sql.ErrNoRows {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Policy not found"})
+		writeError(w, http.StatusNotFound, "policy_not_found", "Policy not found")
 		return
 	}
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	p.UsingExpr = usingExpr.String
@@ -2291,9 +3117,7 @@ func (h *Handler) handleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid policy ID"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid policy ID")
 		return
 	}
 
@@ -2305,9 +3129,7 @@ func (h *Handler) handleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
 		Enabled    *bool   `json:"enabled"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
@@ -2319,11 +3141,8 @@ func (h *Handler) handleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
 		args = append(args, *req.PolicyName)
 	}
 	if req.Command != nil {
-		validCommands := map[string]bool{"SELECT": true, "INSERT": true, "UPDATE": true, "DELETE": true, "ALL": true}
-		if !validCommands[*req.Command] {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(map[string]string{"error": "command must be SELECT, INSERT, UPDATE, DELETE, or ALL"})
+		if !policyCommands[*req.Command] {
+			writeError(w, http.StatusBadRequest, "invalid_command", "command must be SELECT, INSERT, UPDATE, DELETE, or ALL")
 			return
 		}
 		updates = append(updates, "command = ?")
@@ -2347,9 +3166,7 @@ func (h *Handler) handleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if len(updates) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "No fields to update"})
+		writeError(w, http.StatusBadRequest, "no_changes", "No fields to update")
 		return
 	}
 
@@ -2358,22 +3175,16 @@ func (h *Handler) handleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
 	result, err := h.db.Exec(query, args...)
 	if err != nil {
 		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			json.NewEncoder(w).Encode(map[string]string{"error": "A policy with this name already exists for this table"})
+			writeError(w, http.StatusConflict, "policy_exists", "A policy with this name already exists for this table")
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	affected, _ := result.RowsAffected()
 	if affected == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Policy not found"})
+		writeError(w, http.StatusNotFound, "policy_not_found", "Policy not found")
 		return
 	}
 
@@ -2406,25 +3217,19 @@ func (h *Handler) handleDeletePolicy(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid policy ID"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid policy ID")
 		return
 	}
 
 	result, err := h.db.Exec("DELETE FROM _rls_policies WHERE id = ?", id)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	affected, _ := result.RowsAffected()
 	if affected == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Policy not found"})
+		writeError(w, http.StatusNotFound, "policy_not_found", "Policy not found")
 		return
 	}
 
@@ -2438,9 +3243,7 @@ func (h *Handler) handleDeletePolicy(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleGetTableRLS(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
@@ -2450,9 +3253,7 @@ func (h *Handler) handleGetTableRLS(w http.ResponseWriter, r *http.Request) {
 		// Default to disabled if not set
 		enabled = 0
 	} else if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -2471,9 +3272,7 @@ func (h *Handler) handleGetTableRLS(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleSetTableRLS(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
@@ -2481,9 +3280,7 @@ func (h *Handler) handleSetTableRLS(w http.ResponseWriter, r *http.Request) {
 		Enabled bool `json:"enabled"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
@@ -2497,9 +3294,7 @@ func (h *Handler) handleSetTableRLS(w http.ResponseWriter, r *http.Request) {
 		ON CONFLICT(table_name) DO UPDATE SET enabled = excluded.enabled
 	`, tableName, enabled)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -2527,16 +3322,12 @@ func (h *Handler) handleTestPolicy(w http.ResponseWriter, r *http.Request) {
 		UserID    string `json:"user_id"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
 	if req.Table == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "table is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "table is required")
 		return
 	}
 
@@ -2555,9 +3346,7 @@ func (h *Handler) handleTestPolicy(w http.ResponseWriter, r *http.Request) {
 		testExpr = req.CheckExpr
 	}
 	if testExpr == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "using_expr or check_expr is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "using_expr or check_expr is required")
 		return
 	}
 
@@ -2691,41 +3480,50 @@ func (h *Handler) handleRegenerateSecret(w http.ResponseWriter, r *http.Request)
 		Confirmation string `json:"confirmation"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
 	if req.Confirmation != "REGENERATE" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Please type REGENERATE to confirm"})
+		writeError(w, http.StatusBadRequest, "confirmation_required", "Please type REGENERATE to confirm")
 		return
 	}
 
 	// Check if secret is from environment (can't change)
 	if os.Getenv("SBLITE_JWT_SECRET") != "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Cannot regenerate: JWT secret is set via environment variable"})
+		writeError(w, http.StatusBadRequest, "jwt_secret_from_env", "Cannot regenerate: JWT secret is set via environment variable")
 		return
 	}
 
 	// Generate new secret
 	newSecret := uuid.New().String() + "-" + uuid.New().String()
 
-	// Store in _dashboard table
-	_, err := h.db.Exec(`
+	// Store in _dashboard table, re-encrypting credential settings with the
+	// new secret in the same transaction so they stay readable
+	err := h.runWrite(r, func() error {
+		tx, err := h.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(`
 		INSERT INTO _dashboard (key, value, updated_at) VALUES ('jwt_secret', ?, datetime('now'))
 		ON CONFLICT(key) DO UPDATE SET value = ?, updated_at = datetime('now')
-	`, newSecret, newSecret)
+	`, newSecret, newSecret); err != nil {
+			return err
+		}
+		if err := reencryptSecretSettings(tx, h.jwtSecret, newSecret); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to save new secret"})
+		log.Warn("jwt secret rotation failed", "error", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to save new secret: "+err.Error())
 		return
 	}
+	h.jwtSecret = newSecret
 
 	// Invalidate all refresh tokens
 	_, err = h.db.Exec("UPDATE auth_refresh_tokens SET revoked = 1")
@@ -2754,9 +3552,7 @@ func (h *Handler) handleListTemplates(w http.ResponseWriter, r *http.Request) {
 		ORDER BY type
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -2791,9 +3587,7 @@ func (h *Handler) handleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		BodyText string `json:"body_text"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
 		return
 	}
 
@@ -2803,17 +3597,13 @@ func (h *Handler) handleUpdateTemplate(w http.ResponseWriter, r *http.Request) {
 		WHERE type = ?
 	`, req.Subject, req.BodyHTML, req.BodyText, templateType)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	affected, _ := result.RowsAffected()
 	if affected == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Template not found"})
+		writeError(w, http.StatusNotFound, "template_not_found", "Template not found")
 		return
 	}
 
@@ -2862,9 +3652,7 @@ func (h *Handler) handleResetTemplate(w http.ResponseWriter, r *http.Request) {
 
 	def, ok := defaults[templateType]
 	if !ok {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Unknown template type"})
+		writeError(w, http.StatusNotFound, "invalid_template_type", "Unknown template type")
 		return
 	}
 
@@ -2874,9 +3662,7 @@ func (h *Handler) handleResetTemplate(w http.ResponseWriter, r *http.Request) {
 		WHERE type = ?
 	`, def.subject, def.bodyHTML, def.bodyText, templateType)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -2908,9 +3694,7 @@ func (h *Handler) handleExportSchema(w http.ResponseWriter, r *http.Request) {
 		ORDER BY name
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -2942,7 +3726,7 @@ func (h *Handler) generatePostgreSQLDDL(tableName string) string {
 
 	// Get column metadata from _columns table
 	rows, err := h.db.Query(`
-		SELECT column_name, pg_type, is_nullable, default_value, is_primary
+		SELECT column_name, pg_type, is_nullable, default_value, is_primary, COALESCE(description, '')
 		FROM _columns
 		WHERE table_name = ?
 		ORDER BY rowid
@@ -2954,20 +3738,32 @@ func (h *Handler) generatePostgreSQLDDL(tableName string) string {
 	}
 	defer rows.Close()
 
+	// nextval() columns become Postgres sequences owned by the column
+	sequences := h.tableSequences(tableName)
+	sequenceByColumn := make(map[string]Sequence, len(sequences))
+	for _, seq := range sequences {
+		sequenceByColumn[seq.ColumnName] = seq
+		sb.WriteString(fmt.Sprintf("CREATE SEQUENCE IF NOT EXISTS %s;\n", seq.Name))
+	}
+
 	sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", tableName))
 
 	var columns []string
 	var primaryKeys []string
+	var comments []string
 	first := true
 
 	for rows.Next() {
-		var colName, pgType string
+		var colName, pgType, description string
 		var isNullable, isPrimary int
 		var defaultVal sql.NullString
 
-		if err := rows.Scan(&colName, &pgType, &isNullable, &defaultVal, &isPrimary); err != nil {
+		if err := rows.Scan(&colName, &pgType, &isNullable, &defaultVal, &isPrimary, &description); err != nil {
 			continue
 		}
+		if description != "" {
+			comments = append(comments, fmt.Sprintf("COMMENT ON COLUMN %s.%s IS '%s';\n", tableName, colName, escapeSQLString(description)))
+		}
 
 		var colDef strings.Builder
 		if !first {
@@ -2981,7 +3777,9 @@ func (h *Handler) generatePostgreSQLDDL(tableName string) string {
 			colDef.WriteString(" NOT NULL")
 		}
 
-		if defaultVal.Valid && defaultVal.String != "" {
+		if seq, ok := sequenceByColumn[colName]; ok && isSequenceDefault(defaultVal.String) {
+			colDef.WriteString(fmt.Sprintf(" DEFAULT nextval('%s')", escapeSQLString(seq.Name)))
+		} else if defaultVal.Valid && defaultVal.String != "" {
 			colDef.WriteString(fmt.Sprintf(" DEFAULT %s", defaultVal.String))
 		}
 
@@ -2994,15 +3792,128 @@ func (h *Handler) generatePostgreSQLDDL(tableName string) string {
 
 	sb.WriteString(strings.Join(columns, ""))
 
+	// Composite keys keep their declared order
+	if ordered := h.primaryKeyColumns(tableName); len(ordered) > 0 {
+		primaryKeys = ordered
+	}
 	if len(primaryKeys) > 0 {
 		sb.WriteString(fmt.Sprintf(",\n    PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
 	}
 
 	sb.WriteString("\n);\n")
 
+	for _, seq := range sequences {
+		sb.WriteString(fmt.Sprintf("ALTER SEQUENCE %s OWNED BY %s.%s;\n", seq.Name, tableName, seq.ColumnName))
+		if seq.CurrentValue > 0 {
+			sb.WriteString(fmt.Sprintf("SELECT setval('%s', %d);\n", escapeSQLString(seq.Name), seq.CurrentValue))
+		}
+	}
+
+	// Descriptions become comments so documentation survives the move to Postgres
+	var tableDescription string
+	h.db.QueryRow(`SELECT description FROM _table_descriptions WHERE table_name = ?`, tableName).Scan(&tableDescription)
+	if tableDescription != "" {
+		sb.WriteString(fmt.Sprintf("COMMENT ON TABLE %s IS '%s';\n", tableName, escapeSQLString(tableDescription)))
+	}
+	for _, comment := range comments {
+		sb.WriteString(comment)
+	}
+
 	return sb.String()
 }
 
+// exportSlice bounds a data export. The zero value exports every row.
+type exportSlice struct {
+	filters url.Values
+	where   string
+	values  []interface{}
+	limit   int // 0 means no limit
+	offset  int
+}
+
+// parseExportSlice reads limit/offset (or a PostgREST-style "Range: from-to"
+// header) and any column filters from an export request.
+func (h *Handler) parseExportSlice(r *http.Request) (exportSlice, error) {
+	var slice exportSlice
+	query := r.URL.Query()
+
+	if rng := r.Header.Get("Range"); rng != "" && query.Get("limit") == "" && query.Get("offset") == "" {
+		from, to, ok := strings.Cut(rng, "-")
+		start, err1 := strconv.Atoi(strings.TrimSpace(from))
+		end, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if !ok || err1 != nil || err2 != nil || start < 0 || end < start {
+			return slice, fmt.Errorf("invalid Range header %q", rng)
+		}
+		slice.offset = start
+		slice.limit = end - start + 1
+	}
+	if l := query.Get("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed <= 0 {
+			return slice, fmt.Errorf("limit must be a positive integer")
+		}
+		slice.limit = parsed
+	}
+	if o := query.Get("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			return slice, fmt.Errorf("offset must be a non-negative integer")
+		}
+		slice.offset = parsed
+	}
+
+	filters := url.Values{}
+	for key, vals := range query {
+		if key != "tables" && key != "format" && key != "confirm_large" {
+			filters[key] = vals
+		}
+	}
+	slice.filters = filters
+	slice.where, slice.values = h.parseSelectFilter(filters)
+	return slice, nil
+}
+
+// exportRowCount counts the rows an export of the given tables would return.
+func (h *Handler) exportRowCount(tables []string, slice exportSlice) int64 {
+	var total int64
+	for _, table := range tables {
+		table = strings.TrimSpace(table)
+		if !isValidIdentifier(table) {
+			continue
+		}
+		var n int64
+		if err := h.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s" %s`, table, slice.where), slice.values...).Scan(&n); err != nil {
+			continue
+		}
+		n -= int64(slice.offset)
+		if n < 0 {
+			n = 0
+		}
+		if slice.limit > 0 && n > int64(slice.limit) {
+			n = int64(slice.limit)
+		}
+		total += n
+	}
+	return total
+}
+
+// exportQuery builds the SELECT for one table of an export. Bounded exports
+// are ordered by primary key so a slice is reproducible.
+func (h *Handler) exportQuery(table string, slice exportSlice) string {
+	query := fmt.Sprintf(`SELECT * FROM "%s" %s`, table, slice.where)
+	if slice.limit == 0 && slice.offset == 0 {
+		return query
+	}
+	query += h.primaryKeyOrderClause(table)
+	if slice.limit > 0 {
+		return query + fmt.Sprintf(" LIMIT %d OFFSET %d", slice.limit, slice.offset)
+	}
+	return query + fmt.Sprintf(" LIMIT -1 OFFSET %d", slice.offset)
+}
+
+// handleExportData exports table rows as JSON (several tables), NDJSON
+// (several tables, streamed) or CSV (the first table). Without limit, offset, Range or filters every row is exported.
+// GET /_/api/export/data?tables=a,b&format=json&limit=100&offset=0&status=eq.active
 func (h *Handler) handleExportData(w http.ResponseWriter, r *http.Request) {
 	tablesParam := r.URL.Query().Get("tables")
 	format := r.URL.Query().Get("format")
@@ -3011,27 +3922,113 @@ func (h *Handler) handleExportData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if tablesParam == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "tables parameter required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "tables parameter required")
+		return
+	}
+
+	slice, err := h.parseExportSlice(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
-	tables := strings.Split(tablesParam, ",")
+	tables := strings.Split(tablesParam, ",")
+	var csvOpts csvOptions
+	if format == "csv" {
+		tables = tables[:1]
+		if csvOpts, err = parseCSVOptions(r); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+	}
+	for _, table := range tables {
+		if err := h.checkArrayFilters(strings.TrimSpace(table), slice.filters); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return
+		}
+	}
+
+	// Buffered formats can hang the browser on huge tables, so they need an
+	// explicit confirmation above the soft limit. NDJSON streams and is exempt.
+	if limit := h.exportSoftLimit(); limit > 0 && format != "ndjson" && r.URL.Query().Get("confirm_large") != "true" {
+		if count := h.exportRowCount(tables, slice); count > int64(limit) {
+			writeErrorDetails(w, http.StatusConflict, "export_too_large",
+				fmt.Sprintf("Export contains %d rows, above the soft limit of %d. Add confirm_large=true to proceed, or use format=ndjson to stream it.", count, limit),
+				map[string]interface{}{
+					"row_count":  count,
+					"soft_limit": limit,
+					"suggestion": "format=ndjson",
+				})
+			return
+		}
+	}
+
+	switch format {
+	case "json":
+		h.exportDataJSON(w, tables, slice)
+	case "csv":
+		h.exportDataCSV(w, tables, slice, csvOpts)
+	case "ndjson":
+		h.exportDataNDJSON(w, tables, slice)
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_format", "format must be json, csv or ndjson")
+	}
+}
+
+// exportDataNDJSON streams one {"table": ..., "row": {...}} object per line
+// across all requested tables, without buffering the export in memory.
+func (h *Handler) exportDataNDJSON(w http.ResponseWriter, tables []string, slice exportSlice) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=data_%s.ndjson", time.Now().Format("20060102_150405")))
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+
+	for _, table := range tables {
+		table = strings.TrimSpace(table)
+		if !isValidIdentifier(table) {
+			continue
+		}
+
+		rows, err := h.db.Query(h.exportQuery(table, slice), slice.values...)
+		if err != nil {
+			continue
+		}
+
+		columns, _ := rows.Columns()
+		colTypes := h.columnTypes(table)
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+
+		for n := 1; rows.Next(); n++ {
+			if err := rows.Scan(valuePtrs...); err != nil {
+				continue
+			}
+			row := make(map[string]interface{}, len(columns))
+			for i, col := range columns {
+				row[col] = jsonValue(values[i], colTypes[col])
+			}
+			if err := enc.Encode(map[string]interface{}{"table": table, "row": row}); err != nil {
+				// Client went away
+				rows.Close()
+				return
+			}
+			if flusher != nil && n%1000 == 0 {
+				flusher.Flush()
+			}
+		}
+		rows.Close()
 
-	switch format {
-	case "json":
-		h.exportDataJSON(w, tables)
-	case "csv":
-		h.exportDataCSV(w, tables)
-	default:
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "format must be json or csv"})
+		if flusher != nil {
+			flusher.Flush()
+		}
 	}
 }
 
-func (h *Handler) exportDataJSON(w http.ResponseWriter, tables []string) {
+func (h *Handler) exportDataJSON(w http.ResponseWriter, tables []string, slice exportSlice) {
 	result := make(map[string][]map[string]interface{})
 
 	for _, table := range tables {
@@ -3041,12 +4038,13 @@ func (h *Handler) exportDataJSON(w http.ResponseWriter, tables []string) {
 			continue
 		}
 
-		rows, err := h.db.Query(fmt.Sprintf("SELECT * FROM %s", table))
+		rows, err := h.db.Query(h.exportQuery(table, slice), slice.values...)
 		if err != nil {
 			continue
 		}
 
 		columns, _ := rows.Columns()
+		colTypes := h.columnTypes(table)
 		var tableData []map[string]interface{}
 
 		for rows.Next() {
@@ -3062,7 +4060,7 @@ func (h *Handler) exportDataJSON(w http.ResponseWriter, tables []string) {
 
 			row := make(map[string]interface{})
 			for i, col := range columns {
-				row[col] = values[i]
+				row[col] = jsonValue(values[i], colTypes[col])
 			}
 			tableData = append(tableData, row)
 		}
@@ -3076,38 +4074,36 @@ func (h *Handler) exportDataJSON(w http.ResponseWriter, tables []string) {
 	json.NewEncoder(w).Encode(result)
 }
 
-func (h *Handler) exportDataCSV(w http.ResponseWriter, tables []string) {
+func (h *Handler) exportDataCSV(w http.ResponseWriter, tables []string, slice exportSlice, opts csvOptions) {
 	// For CSV, we only export the first table
 	if len(tables) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "No tables specified"})
+		writeError(w, http.StatusBadRequest, "missing_field", "No tables specified")
 		return
 	}
 
 	table := strings.TrimSpace(tables[0])
 	if !isValidIdentifier(table) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid table name"})
+		writeError(w, http.StatusBadRequest, "invalid_identifier", "Invalid table name")
 		return
 	}
 
-	rows, err := h.db.Query(fmt.Sprintf("SELECT * FROM %s", table))
+	rows, err := h.db.Query(h.exportQuery(table, slice), slice.values...)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
 
 	columns, _ := rows.Columns()
+	colTypes := h.columnTypes(table)
 
 	w.Header().Set("Content-Type", "text/csv")
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s.csv", table, time.Now().Format("20060102_150405")))
 
-	csvWriter := csv.NewWriter(w)
+	if opts.bom {
+		w.Write([]byte(utf8BOM))
+	}
+	csvWriter := newCSVExportWriter(w, opts)
 	csvWriter.Write(columns)
 
 	for rows.Next() {
@@ -3123,11 +4119,7 @@ func (h *Handler) exportDataCSV(w http.ResponseWriter, tables []string) {
 
 		record := make([]string, len(columns))
 		for i, v := range values {
-			if v == nil {
-				record[i] = ""
-			} else {
-				record[i] = fmt.Sprintf("%v", v)
-			}
+			record[i] = csvValue(v, colTypes[columns[i]])
 		}
 		csvWriter.Write(record)
 	}
@@ -3143,9 +4135,7 @@ func (h *Handler) handleExportBackup(w http.ResponseWriter, r *http.Request) {
 
 	file, err := os.Open(dbPath)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Cannot open database file"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Cannot open database file")
 		return
 	}
 	defer file.Close()
@@ -3167,9 +4157,7 @@ func (h *Handler) handleExportRLS(w http.ResponseWriter, r *http.Request) {
 		ORDER BY table_name, policy_name
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to query policies"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to query policies")
 		return
 	}
 	defer rows.Close()
@@ -3188,9 +4176,7 @@ func (h *Handler) handleExportRLS(w http.ResponseWriter, r *http.Request) {
 		var enabled int
 
 		if err := rows.Scan(&tableName, &policyName, &command, &usingExpr, &checkExpr, &enabled); err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to scan policy"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan policy")
 			return
 		}
 
@@ -3233,9 +4219,7 @@ func (h *Handler) handleExportRLS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := rows.Err(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to iterate policies"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate policies")
 		return
 	}
 
@@ -3255,9 +4239,7 @@ func (h *Handler) handleExportRLS(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if err := rlsRows.Err(); err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to iterate RLS tables"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate RLS tables")
 			return
 		}
 
@@ -3275,6 +4257,22 @@ func (h *Handler) handleExportRLS(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(sb.String()))
 }
 
+// ExportedAuthUser is one user in the auth users export, and the shape
+// accepted by the users import.
+type ExportedAuthUser struct {
+	ID                string          `json:"id"`
+	Email             string          `json:"email,omitempty"`
+	EncryptedPassword string          `json:"encrypted_password,omitempty"`
+	EmailConfirmedAt  *string         `json:"email_confirmed_at,omitempty"`
+	AppMetadata       json.RawMessage `json:"app_metadata"`
+	UserMetadata      json.RawMessage `json:"user_metadata"`
+	Role              string          `json:"role"`
+	IsAnonymous       bool            `json:"is_anonymous"`
+	CreatedAt         string          `json:"created_at"`
+	UpdatedAt         string          `json:"updated_at"`
+	LastSignInAt      *string         `json:"last_sign_in_at,omitempty"`
+}
+
 // handleExportAuthUsers exports auth users as JSON.
 func (h *Handler) handleExportAuthUsers(w http.ResponseWriter, r *http.Request) {
 	includePasswords := r.URL.Query().Get("include_passwords") == "true"
@@ -3288,30 +4286,14 @@ func (h *Handler) handleExportAuthUsers(w http.ResponseWriter, r *http.Request)
 		ORDER BY created_at
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to query users"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to query users")
 		return
 	}
 	defer rows.Close()
 
-	type ExportUser struct {
-		ID                string          `json:"id"`
-		Email             string          `json:"email,omitempty"`
-		EncryptedPassword string          `json:"encrypted_password,omitempty"`
-		EmailConfirmedAt  *string         `json:"email_confirmed_at,omitempty"`
-		AppMetadata       json.RawMessage `json:"app_metadata"`
-		UserMetadata      json.RawMessage `json:"user_metadata"`
-		Role              string          `json:"role"`
-		IsAnonymous       bool            `json:"is_anonymous"`
-		CreatedAt         string          `json:"created_at"`
-		UpdatedAt         string          `json:"updated_at"`
-		LastSignInAt      *string         `json:"last_sign_in_at,omitempty"`
-	}
-
-	var users []ExportUser
+	var users []ExportedAuthUser
 	for rows.Next() {
-		var u ExportUser
+		var u ExportedAuthUser
 		var encPassword sql.NullString
 		var emailConfirmed, lastSignIn sql.NullString
 		var appMeta, userMeta string
@@ -3320,9 +4302,7 @@ func (h *Handler) handleExportAuthUsers(w http.ResponseWriter, r *http.Request)
 		err := rows.Scan(&u.ID, &u.Email, &encPassword, &emailConfirmed,
 			&appMeta, &userMeta, &u.Role, &isAnon, &u.CreatedAt, &u.UpdatedAt, &lastSignIn)
 		if err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to scan user"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan user")
 			return
 		}
 
@@ -3343,17 +4323,15 @@ func (h *Handler) handleExportAuthUsers(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := rows.Err(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to iterate users"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate users")
 		return
 	}
 
 	export := struct {
-		ExportedAt string       `json:"exported_at"`
-		Count      int          `json:"count"`
-		Users      []ExportUser `json:"users"`
-		Note       string       `json:"note"`
+		ExportedAt string             `json:"exported_at"`
+		Count      int                `json:"count"`
+		Users      []ExportedAuthUser `json:"users"`
+		Note       string             `json:"note"`
 	}{
 		ExportedAt: time.Now().UTC().Format(time.RFC3339),
 		Count:      len(users),
@@ -3374,9 +4352,7 @@ func (h *Handler) handleExportAuthConfig(w http.ResponseWriter, r *http.Request)
 		ORDER BY key
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to query auth config"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to query auth config")
 		return
 	}
 	defer rows.Close()
@@ -3387,9 +4363,7 @@ func (h *Handler) handleExportAuthConfig(w http.ResponseWriter, r *http.Request)
 	for rows.Next() {
 		var key, value string
 		if err := rows.Scan(&key, &value); err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to scan config"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan config")
 			return
 		}
 
@@ -3411,9 +4385,7 @@ func (h *Handler) handleExportAuthConfig(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := rows.Err(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to iterate config"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate config")
 		return
 	}
 
@@ -3440,9 +4412,7 @@ func (h *Handler) handleExportEmailTemplates(w http.ResponseWriter, r *http.Requ
 		ORDER BY type
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to query templates"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to query templates")
 		return
 	}
 	defer rows.Close()
@@ -3462,9 +4432,7 @@ func (h *Handler) handleExportEmailTemplates(w http.ResponseWriter, r *http.Requ
 		var bodyText sql.NullString
 
 		if err := rows.Scan(&t.ID, &t.Type, &t.Subject, &t.BodyHTML, &bodyText, &t.UpdatedAt); err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to scan template"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan template")
 			return
 		}
 
@@ -3475,9 +4443,7 @@ func (h *Handler) handleExportEmailTemplates(w http.ResponseWriter, r *http.Requ
 	}
 
 	if err := rows.Err(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to iterate templates"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate templates")
 		return
 	}
 
@@ -3506,9 +4472,7 @@ func (h *Handler) handleExportStorageBuckets(w http.ResponseWriter, r *http.Requ
 		ORDER BY name
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to query buckets"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to query buckets")
 		return
 	}
 	defer rows.Close()
@@ -3534,9 +4498,7 @@ func (h *Handler) handleExportStorageBuckets(w http.ResponseWriter, r *http.Requ
 		var allowedMimeTypes sql.NullString
 
 		if err := rows.Scan(&b.ID, &b.Name, &owner, &ownerID, &public, &fileSizeLimit, &allowedMimeTypes, &b.CreatedAt, &b.UpdatedAt); err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to scan bucket"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan bucket")
 			return
 		}
 
@@ -3563,9 +4525,7 @@ func (h *Handler) handleExportStorageBuckets(w http.ResponseWriter, r *http.Requ
 	}
 
 	if err := rows.Err(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to iterate buckets"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate buckets")
 		return
 	}
 
@@ -3589,17 +4549,13 @@ func (h *Handler) handleExportStorageBuckets(w http.ResponseWriter, r *http.Requ
 // handleExportFunctions exports edge functions as a ZIP file.
 func (h *Handler) handleExportFunctions(w http.ResponseWriter, r *http.Request) {
 	if h.functionsService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions are not enabled"})
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions are not enabled")
 		return
 	}
 
 	functionsDir := h.functionsService.FunctionsDir()
 	if _, err := os.Stat(functionsDir); os.IsNotExist(err) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Functions directory does not exist"})
+		writeError(w, http.StatusNotFound, "functions_dir_missing", "Functions directory does not exist")
 		return
 	}
 
@@ -3634,15 +4590,11 @@ Exported from sblite on ` + time.Now().UTC().Format(time.RFC3339) + `
 
 	readmeFile, err := zipWriter.Create("README.md")
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create README in ZIP"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create README in ZIP")
 		return
 	}
 	if _, err := readmeFile.Write([]byte(readme)); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to write README"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to write README")
 		return
 	}
 
@@ -3683,16 +4635,12 @@ Exported from sblite on ` + time.Now().UTC().Format(time.RFC3339) + `
 	})
 
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to add files to ZIP: " + err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to add files to ZIP: "+err.Error())
 		return
 	}
 
 	if err := zipWriter.Close(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to finalize ZIP"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to finalize ZIP")
 		return
 	}
 
@@ -3708,9 +4656,7 @@ func (h *Handler) handleExportSecrets(w http.ResponseWriter, r *http.Request) {
 		ORDER BY name
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to query secrets"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to query secrets")
 		return
 	}
 	defer rows.Close()
@@ -3729,9 +4675,7 @@ func (h *Handler) handleExportSecrets(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var name string
 		if err := rows.Scan(&name); err != nil {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]string{"error": "Failed to scan secret"})
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan secret")
 			return
 		}
 
@@ -3740,9 +4684,7 @@ func (h *Handler) handleExportSecrets(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := rows.Err(); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to iterate secrets"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate secrets")
 		return
 	}
 
@@ -3785,9 +4727,33 @@ func (h *Handler) handleGetLogConfig(w http.ResponseWriter, r *http.Request) {
 		"mode":      cfg.LogMode,
 		"file_path": cfg.LogFile,
 		"db_path":   cfg.LogDB,
+		"level":     log.GetLevel(),
 	})
 }
 
+// handleUpdateLogConfig changes the minimum log level. Records below it are
+// dropped before they reach the console, file, database or buffer. The change
+// applies immediately and lasts until the server restarts.
+func (h *Handler) handleUpdateLogConfig(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Level *string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+
+	if req.Level != nil {
+		if err := log.SetLevel(*req.Level); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_setting", err.Error())
+			return
+		}
+		log.Info("log level changed", "level", log.GetLevel())
+	}
+
+	h.handleGetLogConfig(w, r)
+}
+
 func (h *Handler) handleQueryLogs(w http.ResponseWriter, r *http.Request) {
 	cfg := h.serverConfig
 	if cfg == nil || cfg.LogMode != "database" || cfg.LogDB == "" {
@@ -3803,9 +4769,7 @@ func (h *Handler) handleQueryLogs(w http.ResponseWriter, r *http.Request) {
 	// Open log database
 	logDB, err := sql.Open("sqlite", cfg.LogDB+"?mode=ro")
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Cannot open log database"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Cannot open log database")
 		return
 	}
 	defer logDB.Close()
@@ -3879,9 +4843,7 @@ func (h *Handler) handleQueryLogs(w http.ResponseWriter, r *http.Request) {
 	args = append(args, limit, offset)
 	rows, err := logDB.Query(query, args...)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -3943,9 +4905,7 @@ func (h *Handler) handleTailLogs(w http.ResponseWriter, r *http.Request) {
 
 	file, err := os.Open(cfg.LogFile)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Cannot open log file: " + err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Cannot open log file: "+err.Error())
 		return
 	}
 	defer file.Close()
@@ -3963,13 +4923,34 @@ func (h *Handler) handleTailLogs(w http.ResponseWriter, r *http.Request) {
 		start = len(lines) - numLines
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	// Lines written in the json format are also returned parsed; any
+	// unstructured lines (e.g. from before a format change) become entries
+	// carrying just the raw message.
+	format := "text"
+	entries := make([]*log.Entry, 0, len(lines)-start)
+	for _, line := range lines[start:] {
+		entry, ok := log.ParseJSONLine(line)
+		if ok {
+			format = "json"
+		} else {
+			entry = &log.Entry{Message: line}
+		}
+		entries = append(entries, entry)
+	}
+
+	resp := map[string]interface{}{
 		"lines":     lines[start:],
 		"total":     len(lines),
 		"showing":   len(lines) - start,
 		"file_path": cfg.LogFile,
-	})
+		"format":    format,
+	}
+	if format == "json" {
+		resp["entries"] = entries
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (h *Handler) handleBufferLogs(w http.ResponseWriter, r *http.Request) {
@@ -4028,12 +5009,12 @@ type SQLResponse struct {
 func (h *Handler) handleExecuteSQL(w http.ResponseWriter, r *http.Request) {
 	var req SQLRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if strings.TrimSpace(req.Query) == "" {
-		http.Error(w, `{"error": "Query cannot be empty"}`, http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "missing_field", "Query cannot be empty")
 		return
 	}
 
@@ -4198,7 +5179,15 @@ func (h *Handler) handleExecuteSQL(w http.ResponseWriter, r *http.Request) {
 		response.RowCount = len(resultRows)
 	} else {
 		// For non-SELECT queries (INSERT, UPDATE, DELETE, etc.)
-		result, err := h.db.Exec(queryToExecute)
+		var affected int64
+		err := h.runWrite(r, func() error {
+			result, err := h.db.Exec(queryToExecute)
+			if err != nil {
+				return err
+			}
+			affected, _ = result.RowsAffected()
+			return nil
+		})
 		if err != nil {
 			response.Error = err.Error()
 			response.ExecutionTimeMs = time.Since(startTime).Milliseconds()
@@ -4207,7 +5196,6 @@ func (h *Handler) handleExecuteSQL(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		affected, _ := result.RowsAffected()
 		response.AffectedRows = affected
 		response.RowCount = int(affected)
 
@@ -4215,6 +5203,11 @@ func (h *Handler) handleExecuteSQL(w http.ResponseWriter, r *http.Request) {
 		if queryType == "CREATE" && tableName != "" && len(uuidColumns) > 0 {
 			h.storeUUIDColumnDefaults(tableName, uuidColumns)
 		}
+
+		switch queryType {
+		case "CREATE", "DROP", "ALTER":
+			h.recordSchemaSnapshot("sql_editor")
+		}
 	}
 
 	response.ExecutionTimeMs = time.Since(startTime).Milliseconds()
@@ -4250,25 +5243,19 @@ func detectQueryType(query string) string {
 
 func (h *Handler) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
 	if h.jwtSecret == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "JWT secret not configured"})
+		writeError(w, http.StatusInternalServerError, "jwt_secret_not_configured", "JWT secret not configured")
 		return
 	}
 
 	anonKey, err := h.generateAPIKey("anon")
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to generate anon key"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to generate anon key")
 		return
 	}
 
 	serviceKey, err := h.generateAPIKey("service_role")
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to generate service_role key"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to generate service_role key")
 		return
 	}
 
@@ -4297,17 +5284,13 @@ func (h *Handler) generateAPIKey(role string) (string, error) {
 func (h *Handler) handleListFTSIndexes(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
 	indexes, err := h.fts.ListIndexes(tableName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -4318,9 +5301,7 @@ func (h *Handler) handleListFTSIndexes(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) handleCreateFTSIndex(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
@@ -4330,40 +5311,30 @@ func (h *Handler) handleCreateFTSIndex(w http.ResponseWriter, r *http.Request) {
 		Tokenizer string   `json:"tokenizer"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Name == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Index name is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Index name is required")
 		return
 	}
 
 	if len(req.Columns) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "At least one column is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "At least one column is required")
 		return
 	}
 
 	err := h.fts.CreateIndex(tableName, req.Name, req.Columns, req.Tokenizer)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
 	// Fetch the created index to return
 	index, err := h.fts.GetIndex(tableName, req.Name)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Index created but failed to fetch details"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Index created but failed to fetch details")
 		return
 	}
 
@@ -4377,17 +5348,13 @@ func (h *Handler) handleGetFTSIndex(w http.ResponseWriter, r *http.Request) {
 	indexName := chi.URLParam(r, "index")
 
 	if tableName == "" || indexName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name and index name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name and index name required")
 		return
 	}
 
 	index, err := h.fts.GetIndex(tableName, indexName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusNotFound, "index_not_found", err.Error())
 		return
 	}
 
@@ -4400,17 +5367,13 @@ func (h *Handler) handleDeleteFTSIndex(w http.ResponseWriter, r *http.Request) {
 	indexName := chi.URLParam(r, "index")
 
 	if tableName == "" || indexName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name and index name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name and index name required")
 		return
 	}
 
 	err := h.fts.DropIndex(tableName, indexName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
@@ -4422,17 +5385,13 @@ func (h *Handler) handleRebuildFTSIndex(w http.ResponseWriter, r *http.Request)
 	indexName := chi.URLParam(r, "index")
 
 	if tableName == "" || indexName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name and index name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name and index name required")
 		return
 	}
 
 	err := h.fts.RebuildIndex(tableName, indexName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
@@ -4446,9 +5405,7 @@ func (h *Handler) handleRebuildFTSIndex(w http.ResponseWriter, r *http.Request)
 func (h *Handler) handleTestFTSSearch(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
@@ -4459,16 +5416,12 @@ func (h *Handler) handleTestFTSSearch(w http.ResponseWriter, r *http.Request) {
 		Limit     int    `json:"limit"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Query == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Query is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Query is required")
 		return
 	}
 
@@ -4482,9 +5435,7 @@ func (h *Handler) handleTestFTSSearch(w http.ResponseWriter, r *http.Request) {
 	// Get the index to find the FTS table name
 	index, err := h.fts.GetIndex(tableName, req.IndexName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusNotFound, "index_not_found", err.Error())
 		return
 	}
 
@@ -4495,9 +5446,7 @@ func (h *Handler) handleTestFTSSearch(w http.ResponseWriter, r *http.Request) {
 	}
 	ftsQuery, err = fts.ConvertQuery(req.Query, req.QueryType)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid query: " + err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_query", "Invalid query: "+err.Error())
 		return
 	}
 
@@ -4508,9 +5457,7 @@ func (h *Handler) handleTestFTSSearch(w http.ResponseWriter, r *http.Request) {
 	var pkColumn string
 	err = h.db.QueryRow(`SELECT name FROM pragma_table_info(?) WHERE pk = 1`, tableName).Scan(&pkColumn)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to get primary key"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get primary key")
 		return
 	}
 
@@ -4593,8 +5540,7 @@ func (h *Handler) handleListFunctions(w http.ResponseWriter, r *http.Request) {
 
 	funcs, err := h.functionsService.ListFunctions()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -4606,6 +5552,7 @@ func (h *Handler) handleListFunctions(w http.ResponseWriter, r *http.Request) {
 		} else {
 			funcs[i].VerifyJWT = true // Default
 		}
+		funcs[i].MemoryMB, funcs[i].TimeoutMS = meta.EffectiveLimits()
 
 		if h.functionsService.IsRunning() {
 			funcs[i].Status = "ready"
@@ -4635,17 +5582,41 @@ func (h *Handler) handleGetFunctionsStatus(w http.ResponseWriter, r *http.Reques
 	}
 
 	status := "stopped"
+	supervisor := h.functionsService.SupervisorStatus()
 	if h.functionsService.IsRunning() {
 		status = "running"
+	} else if supervisor.GaveUp {
+		// Automatic restarts were exhausted; a manual restart is required
+		status = "unhealthy"
+	}
+
+	// status reflects the process and periodic checks; health is probed now
+	probe := h.functionsService.CheckHealth(r.Context())
+	health := "unreachable"
+	if probe.Healthy {
+		health = "healthy"
+	}
+
+	resp := map[string]interface{}{
+		"enabled":           true,
+		"status":            status,
+		"health":            health,
+		"health_checked_at": probe.CheckedAt,
+		"health_latency_ms": float64(probe.Latency.Microseconds()) / 1000,
+		"restart_count":     supervisor.Restarts,
+		"runtime_port":      h.functionsService.RuntimePort(),
+		"functions_dir":     h.functionsService.FunctionsDir(),
+	}
+	if probe.Error != "" {
+		resp["health_error"] = probe.Error
+	}
+	if !supervisor.LastRestartAt.IsZero() {
+		resp["last_restart_at"] = supervisor.LastRestartAt
+		resp["last_exit_error"] = supervisor.LastExitError
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"enabled":       true,
-		"status":        status,
-		"runtime_port":  h.functionsService.RuntimePort(),
-		"functions_dir": h.functionsService.FunctionsDir(),
-	})
+	json.NewEncoder(w).Encode(resp)
 }
 
 // handleGetRuntimeInfo returns information about the edge runtime for the current platform.
@@ -4757,15 +5728,13 @@ func (h *Handler) handleGetFunction(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions not enabled"})
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
 		return
 	}
 
 	fn, err := h.functionsService.GetFunction(name)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusNotFound, "function_not_found", err.Error())
 		return
 	}
 
@@ -4776,6 +5745,10 @@ func (h *Handler) handleGetFunction(w http.ResponseWriter, r *http.Request) {
 	} else {
 		fn.VerifyJWT = true
 	}
+	fn.MemoryMB, fn.TimeoutMS = meta.EffectiveLimits()
+	limits := h.functionFileLimits()
+	fn.MaxFileSize, fn.MaxFiles = limits.MaxFileSize, limits.MaxFiles
+	fn.FileCount = countFunctionFiles(filepath.Join(h.functionsService.FunctionsDir(), name))
 
 	if h.functionsService.IsRunning() {
 		fn.Status = "ready"
@@ -4792,15 +5765,13 @@ func (h *Handler) handleCreateFunction(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions not enabled"})
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
 		return
 	}
 
 	// Validate function name
 	if err := functions.ValidateFunctionName(name); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
@@ -4817,12 +5788,11 @@ func (h *Handler) handleCreateFunction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.functionsService.CreateFunction(name, req.Template); err != nil {
-		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "already exists") {
-			status = http.StatusConflict
+			writeError(w, http.StatusConflict, "function_exists", err.Error())
+			return
 		}
-		w.WriteHeader(status)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -4837,18 +5807,16 @@ func (h *Handler) handleDeleteFunction(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions not enabled"})
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
 		return
 	}
 
 	if err := h.functionsService.DeleteFunction(name); err != nil {
-		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
-			status = http.StatusNotFound
+			writeError(w, http.StatusNotFound, "function_not_found", err.Error())
+			return
 		}
-		w.WriteHeader(status)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -4860,15 +5828,13 @@ func (h *Handler) handleGetFunctionConfig(w http.ResponseWriter, r *http.Request
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions not enabled"})
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
 		return
 	}
 
 	meta, err := h.functionsService.GetMetadata(name)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -4881,8 +5847,7 @@ func (h *Handler) handleUpdateFunctionConfig(w http.ResponseWriter, r *http.Requ
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions not enabled"})
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
 		return
 	}
 
@@ -4892,19 +5857,41 @@ func (h *Handler) handleUpdateFunctionConfig(w http.ResponseWriter, r *http.Requ
 		TimeoutMS *int              `json:"timeout_ms"`
 		ImportMap *string           `json:"import_map"`
 		EnvVars   map[string]string `json:"env_vars"`
+
+		// GenerateImportMap maps the function's unresolved npm: and jsr:
+		// imports in its import map file
+		GenerateImportMap bool `json:"generate_import_map"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON: " + err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON: "+err.Error())
+		return
+	}
+
+	var memoryMB, timeoutMS int
+	if req.MemoryMB != nil {
+		memoryMB = *req.MemoryMB
+	}
+	if req.TimeoutMS != nil {
+		timeoutMS = *req.TimeoutMS
+	}
+	if err := functions.ValidateLimits(memoryMB, timeoutMS); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_setting", err.Error())
 		return
 	}
+	if req.ImportMap != nil {
+		importMap, err := functions.NormalizeImportMapPath(*req.ImportMap)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_setting", err.Error())
+			return
+		}
+		req.ImportMap = &importMap
+	}
 
 	// Get existing metadata
 	meta, err := h.functionsService.GetMetadata(name)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -4925,9 +5912,20 @@ func (h *Handler) handleUpdateFunctionConfig(w http.ResponseWriter, r *http.Requ
 		meta.EnvVars = req.EnvVars
 	}
 
+	if req.GenerateImportMap {
+		importMapFile := functions.ImportMapFile(meta)
+		added, err := functions.GenerateImportMap(filepath.Join(h.functionsService.FunctionsDir(), name), importMapFile)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_import_map", err.Error())
+			return
+		}
+		if len(added) > 0 {
+			meta.ImportMap = importMapFile
+		}
+	}
+
 	if err := h.functionsService.SetMetadata(meta); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -4952,11 +5950,7 @@ func (h *Handler) handleListFunctionFiles(w http.ResponseWriter, r *http.Request
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Edge functions not enabled. Start the server with --functions flag.",
-		})
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions not enabled. Start the server with --functions flag.")
 		return
 	}
 
@@ -4967,38 +5961,22 @@ func (h *Handler) handleListFunctionFiles(w http.ResponseWriter, r *http.Request
 	info, err := os.Stat(fnDir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": fmt.Sprintf("Function %q not found", name),
-			})
+			writeError(w, http.StatusNotFound, "function_not_found", fmt.Sprintf("Function %q not found", name))
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to access function directory: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to access function directory: %v", err))
 		return
 	}
 
 	if !info.IsDir() {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Function %q is not a directory", name),
-		})
+		writeError(w, http.StatusNotFound, "function_not_found", fmt.Sprintf("Function %q is not a directory", name))
 		return
 	}
 
 	// Build the file tree
 	tree, err := buildFileTree(fnDir, name)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to build file tree: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to build file tree: %v", err))
 		return
 	}
 
@@ -5067,11 +6045,7 @@ func (h *Handler) handleReadFunctionFile(w http.ResponseWriter, r *http.Request)
 	filePath := chi.URLParam(r, "*")
 
 	if h.functionsService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Edge functions not enabled. Start the server with --functions flag.",
-		})
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions not enabled. Start the server with --functions flag.")
 		return
 	}
 
@@ -5079,11 +6053,7 @@ func (h *Handler) handleReadFunctionFile(w http.ResponseWriter, r *http.Request)
 	basePath := filepath.Join(h.functionsService.FunctionsDir(), name)
 	fullPath, err := SanitizePath(basePath, filePath)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Invalid file path: %v", err),
-		})
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid file path: %v", err))
 		return
 	}
 
@@ -5091,71 +6061,51 @@ func (h *Handler) handleReadFunctionFile(w http.ResponseWriter, r *http.Request)
 	info, err := os.Stat(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusNotFound)
-			json.NewEncoder(w).Encode(map[string]string{
-				"error": fmt.Sprintf("File %q not found", filePath),
-			})
+			writeError(w, http.StatusNotFound, "file_not_found", fmt.Sprintf("File %q not found", filePath))
 			return
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to access file: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to access file: %v", err))
 		return
 	}
 
 	// Check if it's a directory
 	if info.IsDir() {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Cannot read directory as file",
-		})
+		writeError(w, http.StatusBadRequest, "not_a_file", "Cannot read directory as file")
 		return
 	}
 
 	// Check file size
 	if info.Size() > MaxFileSize {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("File too large (%d bytes). Maximum allowed size is %d bytes", info.Size(), MaxFileSize),
-		})
+		writeError(w, http.StatusBadRequest, "file_too_large", fmt.Sprintf("File too large (%d bytes). Maximum allowed size is %d bytes", info.Size(), MaxFileSize))
 		return
 	}
 
 	// Read file content
 	content, err := os.ReadFile(fullPath)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to read file: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to read file: %v", err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"path":    filePath,
-		"content": string(content),
-		"size":    info.Size(),
+		"path":        filePath,
+		"content":     string(content),
+		"size":        info.Size(),
+		"hash":        FileContentHash(content),
+		"modified_at": info.ModTime().UTC().Format(time.RFC3339Nano),
 	})
 }
 
 // handleWriteFunctionFile creates or updates a file in a function directory.
+// If base_hash is supplied, the write only succeeds when the file on disk still
+// has that content hash; otherwise it returns 409 with the current hash.
 func (h *Handler) handleWriteFunctionFile(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 	filePath := chi.URLParam(r, "*")
 
 	if h.functionsService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Edge functions not enabled. Start the server with --functions flag.",
-		})
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions not enabled. Start the server with --functions flag.")
 		return
 	}
 
@@ -5163,45 +6113,60 @@ func (h *Handler) handleWriteFunctionFile(w http.ResponseWriter, r *http.Request
 	basePath := filepath.Join(h.functionsService.FunctionsDir(), name)
 	fullPath, err := SanitizePath(basePath, filePath)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Invalid file path: %v", err),
-		})
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid file path: %v", err))
 		return
 	}
 
 	// Parse request body
 	var req struct {
-		Content string `json:"content"`
+		Content  string  `json:"content"`
+		BaseHash *string `json:"base_hash"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Invalid JSON: %v", err),
-		})
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+
+	limits := h.functionFileLimits()
+	if int64(len(req.Content)) > limits.MaxFileSize {
+		writeError(w, http.StatusRequestEntityTooLarge, "file_too_large",
+			fmt.Sprintf("File too large (%d bytes). Maximum allowed size is %d bytes", len(req.Content), limits.MaxFileSize))
+		return
+	}
+	if _, err := os.Stat(fullPath); os.IsNotExist(err) && countFunctionFiles(basePath) >= limits.MaxFiles {
+		writeError(w, http.StatusBadRequest, "too_many_files",
+			fmt.Sprintf("Function %q already has the maximum of %d files", name, limits.MaxFiles))
 		return
 	}
 
+	// Reject the write if the file changed since the client read it. An empty
+	// base hash means the client expects the file not to exist yet.
+	if req.BaseHash != nil {
+		currentHash := ""
+		current, err := os.ReadFile(fullPath)
+		if err == nil {
+			currentHash = FileContentHash(current)
+		} else if !os.IsNotExist(err) {
+			writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to read file: %v", err))
+			return
+		}
+		if currentHash != *req.BaseHash {
+			writeErrorDetails(w, http.StatusConflict, "file_modified", "File was modified since it was read",
+				map[string]interface{}{"current_hash": currentHash})
+			return
+		}
+	}
+
 	// Create parent directories if needed
 	dir := filepath.Dir(fullPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to create directory: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to create directory: %v", err))
 		return
 	}
 
 	// Write file content
 	if err := os.WriteFile(fullPath, []byte(req.Content), 0644); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to write file: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to write file: %v", err))
 		return
 	}
 
@@ -5209,6 +6174,7 @@ func (h *Handler) handleWriteFunctionFile(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(map[string]string{
 		"status": "ok",
 		"path":   filePath,
+		"hash":   FileContentHash([]byte(req.Content)),
 	})
 }
 
@@ -5218,11 +6184,7 @@ func (h *Handler) handleDeleteFunctionFile(w http.ResponseWriter, r *http.Reques
 	filePath := chi.URLParam(r, "*")
 
 	if h.functionsService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Edge functions not enabled. Start the server with --functions flag.",
-		})
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions not enabled. Start the server with --functions flag.")
 		return
 	}
 
@@ -5230,31 +6192,19 @@ func (h *Handler) handleDeleteFunctionFile(w http.ResponseWriter, r *http.Reques
 	basePath := filepath.Join(h.functionsService.FunctionsDir(), name)
 	fullPath, err := SanitizePath(basePath, filePath)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Invalid file path: %v", err),
-		})
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid file path: %v", err))
 		return
 	}
 
 	// Check if file exists
 	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("File %q not found", filePath),
-		})
+		writeError(w, http.StatusNotFound, "file_not_found", fmt.Sprintf("File %q not found", filePath))
 		return
 	}
 
 	// Delete the file or directory
 	if err := os.RemoveAll(fullPath); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to delete: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to delete: %v", err))
 		return
 	}
 
@@ -5269,11 +6219,7 @@ func (h *Handler) handleRenameFunctionFile(w http.ResponseWriter, r *http.Reques
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Edge functions not enabled. Start the server with --functions flag.",
-		})
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions not enabled. Start the server with --functions flag.")
 		return
 	}
 
@@ -5283,20 +6229,12 @@ func (h *Handler) handleRenameFunctionFile(w http.ResponseWriter, r *http.Reques
 		NewPath string `json:"newPath"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Invalid JSON: %v", err),
-		})
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid JSON: %v", err))
 		return
 	}
 
 	if req.OldPath == "" || req.NewPath == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Both oldPath and newPath are required",
-		})
+		writeError(w, http.StatusBadRequest, "missing_field", "Both oldPath and newPath are required")
 		return
 	}
 
@@ -5304,52 +6242,32 @@ func (h *Handler) handleRenameFunctionFile(w http.ResponseWriter, r *http.Reques
 	basePath := filepath.Join(h.functionsService.FunctionsDir(), name)
 	oldFullPath, err := SanitizePath(basePath, req.OldPath)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Invalid old path: %v", err),
-		})
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid old path: %v", err))
 		return
 	}
 
 	newFullPath, err := SanitizePath(basePath, req.NewPath)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Invalid new path: %v", err),
-		})
+		writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Invalid new path: %v", err))
 		return
 	}
 
 	// Check if source exists
 	if _, err := os.Stat(oldFullPath); os.IsNotExist(err) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("File %q not found", req.OldPath),
-		})
+		writeError(w, http.StatusNotFound, "file_not_found", fmt.Sprintf("File %q not found", req.OldPath))
 		return
 	}
 
 	// Create parent directories for new path if needed
 	newDir := filepath.Dir(newFullPath)
 	if err := os.MkdirAll(newDir, 0755); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to create directory: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to create directory: %v", err))
 		return
 	}
 
 	// Rename the file
 	if err := os.Rename(oldFullPath, newFullPath); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to rename: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to rename: %v", err))
 		return
 	}
 
@@ -5362,20 +6280,12 @@ func (h *Handler) handleRenameFunctionFile(w http.ResponseWriter, r *http.Reques
 // handleRestartFunctions restarts the edge runtime.
 func (h *Handler) handleRestartFunctions(w http.ResponseWriter, r *http.Request) {
 	if h.functionsService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Edge functions not enabled. Start the server with --functions flag.",
-		})
+		writeError(w, http.StatusServiceUnavailable, "functions_not_enabled", "Edge functions not enabled. Start the server with --functions flag.")
 		return
 	}
 
 	if err := h.functionsService.Restart(r.Context()); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": fmt.Sprintf("Failed to restart runtime: %v", err),
-		})
+		writeError(w, http.StatusInternalServerError, "internal_error", fmt.Sprintf("Failed to restart runtime: %v", err))
 		return
 	}
 
@@ -5403,8 +6313,7 @@ func (h *Handler) handleListSecrets(w http.ResponseWriter, r *http.Request) {
 
 	secrets, err := h.functionsService.ListSecrets()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -5418,8 +6327,7 @@ func (h *Handler) handleListSecrets(w http.ResponseWriter, r *http.Request) {
 // handleSetSecret creates or updates a secret.
 func (h *Handler) handleSetSecret(w http.ResponseWriter, r *http.Request) {
 	if h.functionsService == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions not enabled"})
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
 		return
 	}
 
@@ -5429,26 +6337,22 @@ func (h *Handler) handleSetSecret(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid JSON: " + err.Error()})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON: "+err.Error())
 		return
 	}
 
 	if req.Name == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Secret name is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Secret name is required")
 		return
 	}
 
 	if req.Value == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Secret value is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Secret value is required")
 		return
 	}
 
 	if err := h.functionsService.SetSecret(req.Name, req.Value); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -5461,23 +6365,71 @@ func (h *Handler) handleSetSecret(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleRevealSecret returns a secret's decrypted value so a forgotten secret
+// can be recovered without re-creating it. The body must carry confirmation
+// "REVEAL" and repeat the dashboard password. Every reveal, and every
+// rejected attempt, is written to the log as an audit entry.
+// POST /_/api/secrets/{name}/reveal
+func (h *Handler) handleRevealSecret(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	if h.functionsService == nil {
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
+		return
+	}
+
+	var req struct {
+		Confirmation string `json:"confirmation"`
+		Password     string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.Confirmation != "REVEAL" {
+		log.Warn("secret reveal rejected", "audit", true, "secret", name, "remote_addr", clientIP(r), "reason", "confirmation")
+		writeError(w, http.StatusBadRequest, "confirmation_required", "Please type REVEAL to confirm")
+		return
+	}
+	if !h.verifyDashboardPassword(w, r, req.Password, "reveal a secret") {
+		return
+	}
+
+	value, err := h.functionsService.GetSecret(name)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "secret_not_found", err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	log.Warn("secret revealed", "audit", true, "secret", name, "remote_addr", clientIP(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":  name,
+		"value": value,
+	})
+}
+
 // handleDeleteSecret deletes a secret.
 func (h *Handler) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
 	name := chi.URLParam(r, "name")
 
 	if h.functionsService == nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Edge functions not enabled"})
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
 		return
 	}
 
 	if err := h.functionsService.DeleteSecret(name); err != nil {
-		status := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "not found") {
-			status = http.StatusNotFound
+			writeError(w, http.StatusNotFound, "secret_not_found", err.Error())
+			return
 		}
-		w.WriteHeader(status)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -5491,15 +6443,12 @@ func (h *Handler) handleDeleteSecret(w http.ResponseWriter, r *http.Request) {
 
 // Storage bucket handlers
 
-// handleListBuckets returns a list of all storage buckets.
+// handleListBuckets returns a page of storage buckets with the total
+// matching ?search=, sorted by ?sort=name|created_at and ?order=asc|desc.
+// GET /_/api/storage/buckets
 func (h *Handler) handleListBuckets(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
@@ -5519,33 +6468,40 @@ func (h *Handler) handleListBuckets(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	buckets, err := h.storageService.ListBuckets(limit, offset, search)
+	req := storage.ListBucketsRequest{Limit: limit, Offset: offset, Search: search}
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		if sort != "name" && sort != "created_at" {
+			writeError(w, http.StatusBadRequest, "invalid_sort", "sort must be name or created_at")
+			return
+		}
+		req.SortBy = &storage.SortByOptions{Column: sort, Order: r.URL.Query().Get("order")}
+	}
+
+	buckets, total, err := h.storageService.ListBuckets(req)
 	if err != nil {
 		h.handleStorageError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(buckets)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"buckets": buckets,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
 }
 
 // handleCreateBucket creates a new storage bucket.
 func (h *Handler) handleCreateBucket(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
 	var req storage.CreateBucketRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request", "message": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
@@ -5563,20 +6519,13 @@ func (h *Handler) handleCreateBucket(w http.ResponseWriter, r *http.Request) {
 // handleGetBucket returns a specific bucket by ID.
 func (h *Handler) handleGetBucket(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_id", "message": "Bucket ID is required"})
+		writeError(w, http.StatusBadRequest, "missing_id", "Bucket ID is required")
 		return
 	}
 
@@ -5593,28 +6542,19 @@ func (h *Handler) handleGetBucket(w http.ResponseWriter, r *http.Request) {
 // handleUpdateBucket updates a bucket's configuration.
 func (h *Handler) handleUpdateBucket(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_id", "message": "Bucket ID is required"})
+		writeError(w, http.StatusBadRequest, "missing_id", "Bucket ID is required")
 		return
 	}
 
 	var req storage.UpdateBucketRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request", "message": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
@@ -5631,20 +6571,13 @@ func (h *Handler) handleUpdateBucket(w http.ResponseWriter, r *http.Request) {
 // handleDeleteBucket deletes a bucket.
 func (h *Handler) handleDeleteBucket(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_id", "message": "Bucket ID is required"})
+		writeError(w, http.StatusBadRequest, "missing_id", "Bucket ID is required")
 		return
 	}
 
@@ -5662,20 +6595,13 @@ func (h *Handler) handleDeleteBucket(w http.ResponseWriter, r *http.Request) {
 // handleEmptyBucket removes all objects from a bucket.
 func (h *Handler) handleEmptyBucket(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_id", "message": "Bucket ID is required"})
+		writeError(w, http.StatusBadRequest, "missing_id", "Bucket ID is required")
 		return
 	}
 
@@ -5690,18 +6616,12 @@ func (h *Handler) handleEmptyBucket(w http.ResponseWriter, r *http.Request) {
 
 // handleStorageError handles storage service errors and returns appropriate HTTP responses.
 func (h *Handler) handleStorageError(w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json")
 	if storageErr, ok := err.(*storage.StorageError); ok {
-		w.WriteHeader(storageErr.StatusCode)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   storageErr.ErrorCode,
-			"message": storageErr.Message,
-		})
+		writeError(w, storageErr.StatusCode, storageErr.ErrorCode, storageErr.Message)
 		return
 	}
 
-	w.WriteHeader(http.StatusInternalServerError)
-	json.NewEncoder(w).Encode(map[string]string{"error": "internal_error", "message": err.Error()})
+	writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 }
 
 // Storage object handlers
@@ -5709,12 +6629,7 @@ func (h *Handler) handleStorageError(w http.ResponseWriter, err error) {
 // handleListObjects lists objects in a bucket with optional prefix filtering.
 func (h *Handler) handleListObjects(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
@@ -5725,16 +6640,12 @@ func (h *Handler) handleListObjects(w http.ResponseWriter, r *http.Request) {
 		Offset int    `json:"offset"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request", "message": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Bucket == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_bucket", "message": "Bucket name is required"})
+		writeError(w, http.StatusBadRequest, "missing_bucket", "Bucket name is required")
 		return
 	}
 
@@ -5763,66 +6674,90 @@ func (h *Handler) handleListObjects(w http.ResponseWriter, r *http.Request) {
 // handleUploadObject uploads a file to a bucket via multipart form.
 func (h *Handler) handleUploadObject(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
-	// Parse multipart form with 32MB max memory
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request", "message": "Failed to parse multipart form: " + err.Error()})
+	// Stream the multipart body instead of buffering the whole form: the file
+	// part is spooled to memory or a temp file according to the upload config.
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Failed to parse multipart form: "+err.Error())
 		return
 	}
 
-	bucket := r.FormValue("bucket")
-	path := r.FormValue("path")
+	var bucket, path, filename, contentType string
+	var upload *spooledUpload
+	defer func() {
+		if upload != nil {
+			upload.Close()
+		}
+	}()
 
-	if bucket == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_bucket", "message": "Bucket name is required"})
-		return
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "Failed to parse multipart form: "+err.Error())
+			return
+		}
+
+		switch part.FormName() {
+		case "bucket", "path":
+			value, err := io.ReadAll(io.LimitReader(part, 4096))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, "invalid_request", "Failed to parse multipart form: "+err.Error())
+				return
+			}
+			if part.FormName() == "bucket" {
+				bucket = string(value)
+			} else {
+				path = string(value)
+			}
+		case "file":
+			if upload != nil || part.FileName() == "" {
+				break
+			}
+			filename = part.FileName()
+			contentType = part.Header.Get("Content-Type")
+			if upload, err = spoolUpload(part, h.uploadConfig); err != nil {
+				writeError(w, http.StatusInternalServerError, "read_error", "Failed to read file content")
+				return
+			}
+		}
+		part.Close()
 	}
 
-	file, header, err := r.FormFile("file")
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_file", "message": "File is required"})
+	if bucket == "" {
+		writeError(w, http.StatusBadRequest, "missing_bucket", "Bucket name is required")
 		return
 	}
-	defer file.Close()
-
-	// Read file content
-	content, err := io.ReadAll(file)
-	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "read_error", "message": "Failed to read file content"})
+	if upload == nil {
+		writeError(w, http.StatusBadRequest, "missing_file", "File is required")
 		return
 	}
 
 	// Construct full path: path + filename
-	fullPath := header.Filename
+	fullPath := filename
 	if path != "" {
 		path = strings.TrimSuffix(path, "/")
-		fullPath = path + "/" + header.Filename
+		fullPath = path + "/" + filename
 	}
 
 	// Detect content type
-	contentType := header.Header.Get("Content-Type")
 	if contentType == "" {
-		contentType = http.DetectContentType(content)
+		head, err := upload.Sniff()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "read_error", "Failed to read file content")
+			return
+		}
+		contentType = http.DetectContentType(head)
 	}
 
 	// Upload the file (upsert = true to allow overwriting)
-	resp, err := h.storageService.UploadObject(bucket, fullPath, io.NopCloser(bytes.NewReader(content)), int64(len(content)), contentType, "", true)
+	resp, err := h.storageService.UploadObject(bucket, fullPath, upload.Reader(), upload.size, contentType, "", true)
 	if err != nil {
 		h.handleStorageError(w, err)
 		return
@@ -5836,12 +6771,7 @@ func (h *Handler) handleUploadObject(w http.ResponseWriter, r *http.Request) {
 // handleDownloadObject downloads a file from a bucket.
 func (h *Handler) handleDownloadObject(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
@@ -5849,15 +6779,11 @@ func (h *Handler) handleDownloadObject(w http.ResponseWriter, r *http.Request) {
 	path := r.URL.Query().Get("path")
 
 	if bucket == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_bucket", "message": "Bucket name is required"})
+		writeError(w, http.StatusBadRequest, "missing_bucket", "Bucket name is required")
 		return
 	}
 	if path == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_path", "message": "Object path is required"})
+		writeError(w, http.StatusBadRequest, "missing_path", "Object path is required")
 		return
 	}
 
@@ -5884,12 +6810,7 @@ func (h *Handler) handleDownloadObject(w http.ResponseWriter, r *http.Request) {
 // handleDeleteObjects deletes multiple files from a bucket.
 func (h *Handler) handleDeleteObjects(w http.ResponseWriter, r *http.Request) {
 	if h.storageService == nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "service_unavailable",
-			"message": "Storage service not configured",
-		})
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
 		return
 	}
 
@@ -5898,23 +6819,17 @@ func (h *Handler) handleDeleteObjects(w http.ResponseWriter, r *http.Request) {
 		Paths  []string `json:"paths"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_request", "message": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Bucket == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_bucket", "message": "Bucket name is required"})
+		writeError(w, http.StatusBadRequest, "missing_bucket", "Bucket name is required")
 		return
 	}
 
 	if len(req.Paths) == 0 {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "missing_paths", "message": "At least one path is required"})
+		writeError(w, http.StatusBadRequest, "missing_paths", "At least one path is required")
 		return
 	}
 
@@ -5962,6 +6877,8 @@ type APIDocsTableInfo struct {
 	Name        string              `json:"name"`
 	Description string              `json:"description"`
 	Columns     []APIDocsColumnInfo `json:"columns"`
+	// IsView marks read-only views, which support select only.
+	IsView bool `json:"is_view,omitempty"`
 }
 
 // APIDocsColumnInfo represents a column for API documentation.
@@ -5969,8 +6886,9 @@ type APIDocsColumnInfo struct {
 	Name        string `json:"name"`
 	Type        string `json:"type"`   // JavaScript type (string, number, boolean, etc.)
 	Format      string `json:"format"` // PostgreSQL type (uuid, text, integer, etc.)
-	Required    bool   `json:"required"`
-	Description string `json:"description"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum,omitempty"` // allowed values, from _columns.enum_values
 }
 
 // APIDocsFunctionInfo represents an RPC function for API documentation.
@@ -5991,12 +6909,12 @@ type APIDocsFunctionArgInfo struct {
 	Position int    `json:"position"`
 }
 
-// handleAPIDocsListTables returns all user tables with their columns for API documentation.
+// handleAPIDocsListTables returns all user tables and views with their columns for API documentation.
 func (h *Handler) handleAPIDocsListTables(w http.ResponseWriter, r *http.Request) {
 	// Get list of user tables (exclude internal tables)
 	rows, err := h.db.Query(`
 		SELECT name FROM sqlite_master
-		WHERE type='table'
+		WHERE type IN ('table', 'view')
 		AND name NOT LIKE 'auth_%'
 		AND name NOT LIKE '_rls%'
 		AND name NOT LIKE '_dashboard%'
@@ -6013,9 +6931,7 @@ func (h *Handler) handleAPIDocsListTables(w http.ResponseWriter, r *http.Request
 		ORDER BY name
 	`)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to list tables"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list tables")
 		return
 	}
 	defer rows.Close()
@@ -6046,17 +6962,13 @@ func (h *Handler) handleAPIDocsListTables(w http.ResponseWriter, r *http.Request
 func (h *Handler) handleAPIDocsGetTable(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
 	tableInfo, err := h.getAPIDocsTableInfo(tableName)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table not found"})
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
 		return
 	}
 
@@ -6075,7 +6987,7 @@ func (h *Handler) getAPIDocsTableInfo(tableName string) (*APIDocsTableInfo, erro
 
 	// Get columns from _columns metadata table with descriptions
 	rows, err := h.db.Query(`
-		SELECT column_name, pg_type, is_nullable, description
+		SELECT column_name, pg_type, is_nullable, description, enum_values
 		FROM _columns
 		WHERE table_name = ?
 		ORDER BY created_at, column_name
@@ -6091,7 +7003,8 @@ func (h *Handler) getAPIDocsTableInfo(tableName string) (*APIDocsTableInfo, erro
 		hasMetadata = true
 		var colName, pgType, colDesc string
 		var isNullable int
-		if err := rows.Scan(&colName, &pgType, &isNullable, &colDesc); err != nil {
+		var enumValues sql.NullString
+		if err := rows.Scan(&colName, &pgType, &isNullable, &colDesc, &enumValues); err != nil {
 			continue
 		}
 
@@ -6101,6 +7014,7 @@ func (h *Handler) getAPIDocsTableInfo(tableName string) (*APIDocsTableInfo, erro
 			Format:      pgType,
 			Required:    isNullable == 0,
 			Description: colDesc,
+			Enum:        parseEnumValues(enumValues),
 		})
 	}
 
@@ -6137,6 +7051,7 @@ func (h *Handler) getAPIDocsTableInfo(tableName string) (*APIDocsTableInfo, erro
 		Name:        tableName,
 		Description: description,
 		Columns:     columns,
+		IsView:      h.isView(tableName),
 	}, nil
 }
 
@@ -6144,8 +7059,7 @@ func (h *Handler) getAPIDocsTableInfo(tableName string) (*APIDocsTableInfo, erro
 func (h *Handler) handleAPIDocsUpdateTableDescription(w http.ResponseWriter, r *http.Request) {
 	tableName := chi.URLParam(r, "name")
 	if tableName == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table name required")
 		return
 	}
 
@@ -6153,8 +7067,7 @@ func (h *Handler) handleAPIDocsUpdateTableDescription(w http.ResponseWriter, r *
 		Description string `json:"description"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
@@ -6165,8 +7078,7 @@ func (h *Handler) handleAPIDocsUpdateTableDescription(w http.ResponseWriter, r *
 		ON CONFLICT(table_name) DO UPDATE SET description = excluded.description, updated_at = datetime('now')
 	`, tableName, req.Description)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update description"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update description")
 		return
 	}
 
@@ -6179,8 +7091,7 @@ func (h *Handler) handleAPIDocsUpdateColumnDescription(w http.ResponseWriter, r
 	tableName := chi.URLParam(r, "name")
 	columnName := chi.URLParam(r, "column")
 	if tableName == "" || columnName == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Table and column names required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Table and column names required")
 		return
 	}
 
@@ -6188,8 +7099,7 @@ func (h *Handler) handleAPIDocsUpdateColumnDescription(w http.ResponseWriter, r
 		Description string `json:"description"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
@@ -6199,15 +7109,13 @@ func (h *Handler) handleAPIDocsUpdateColumnDescription(w http.ResponseWriter, r
 		WHERE table_name = ? AND column_name = ?
 	`, req.Description, tableName, columnName)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update description"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update description")
 		return
 	}
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Column not found in metadata"})
+		writeError(w, http.StatusNotFound, "column_not_found", "Column not found in metadata")
 		return
 	}
 
@@ -6224,8 +7132,7 @@ func (h *Handler) handleAPIDocsListFunctions(w http.ResponseWriter, r *http.Requ
 		ORDER BY f.name
 	`)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to list functions"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list functions")
 		return
 	}
 	defer rows.Close()
@@ -6265,8 +7172,7 @@ func (h *Handler) handleAPIDocsListFunctions(w http.ResponseWriter, r *http.Requ
 func (h *Handler) handleAPIDocsGetFunction(w http.ResponseWriter, r *http.Request) {
 	funcName := chi.URLParam(r, "name")
 	if funcName == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Function name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Function name required")
 		return
 	}
 
@@ -6279,13 +7185,11 @@ func (h *Handler) handleAPIDocsGetFunction(w http.ResponseWriter, r *http.Reques
 		WHERE f.name = ?
 	`, funcName).Scan(&name, &returnType, &returnsSet, &description)
 	if err == sql.ErrNoRows {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Function not found"})
+		writeError(w, http.StatusNotFound, "function_not_found", "Function not found")
 		return
 	}
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to get function"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get function")
 		return
 	}
 
@@ -6346,8 +7250,7 @@ func (h *Handler) getFunctionArguments(funcName string) ([]APIDocsFunctionArgInf
 func (h *Handler) handleAPIDocsUpdateFunctionDescription(w http.ResponseWriter, r *http.Request) {
 	funcName := chi.URLParam(r, "name")
 	if funcName == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Function name required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Function name required")
 		return
 	}
 
@@ -6355,8 +7258,7 @@ func (h *Handler) handleAPIDocsUpdateFunctionDescription(w http.ResponseWriter,
 		Description string `json:"description"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
@@ -6364,8 +7266,7 @@ func (h *Handler) handleAPIDocsUpdateFunctionDescription(w http.ResponseWriter,
 	var exists int
 	err := h.db.QueryRow(`SELECT 1 FROM _rpc_functions WHERE name = ?`, funcName).Scan(&exists)
 	if err == sql.ErrNoRows {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Function not found"})
+		writeError(w, http.StatusNotFound, "function_not_found", "Function not found")
 		return
 	}
 
@@ -6376,8 +7277,7 @@ func (h *Handler) handleAPIDocsUpdateFunctionDescription(w http.ResponseWriter,
 		ON CONFLICT(function_name) DO UPDATE SET description = excluded.description, updated_at = datetime('now')
 	`, funcName, req.Description)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update description"})
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to update description")
 		return
 	}
 
@@ -6424,10 +7324,7 @@ func sqliteTypeToPGType(sqliteType string) string {
 func (h *Handler) handleRealtimeStats(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	if h.realtimeService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "realtime not enabled",
-		})
+		writeError(w, http.StatusServiceUnavailable, "realtime_not_enabled", "realtime not enabled")
 		return
 	}
 
@@ -6720,14 +7617,29 @@ func parseArgValue(s string) interface{} {
 
 // Mail catcher handlers
 
-// handleMailStatus returns whether the mail catcher is enabled.
+// handleMailStatus returns whether the mail catcher is enabled and, when it
+// is, how many emails it holds, when the oldest arrived and its retention.
 func (h *Handler) handleMailStatus(w http.ResponseWriter, r *http.Request) {
-	enabled := h.catchMailer != nil
+	resp := map[string]interface{}{"enabled": h.catchMailer != nil}
+	if h.catchMailer != nil {
+		count, err := h.catchMailer.Count()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		resp["count"] = count
+		resp["oldest_at"] = nil
+		if oldest, err := h.catchMailer.Oldest(); err == nil && !oldest.IsZero() {
+			resp["oldest_at"] = oldest
+		}
+		resp["retention"] = h.mailRetentionConfig()
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]bool{"enabled": enabled})
+	json.NewEncoder(w).Encode(resp)
 }
 
-// handleListEmails returns the list of caught emails.
+// handleListEmails returns the list of caught emails, newest first, or with
+// ?q= the emails matching a full-text search, most relevant first.
 func (h *Handler) handleListEmails(w http.ResponseWriter, r *http.Request) {
 	if h.catchMailer == nil {
 		w.Header().Set("Content-Type", "application/json")
@@ -6749,10 +7661,16 @@ func (h *Handler) handleListEmails(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	emails, err := h.catchMailer.ListEmails(limit, offset)
+	// q searches subjects and bodies, ranking matches by relevance
+	var emails []mail.CaughtEmail
+	var err error
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		emails, err = h.catchMailer.SearchEmails(q, limit, offset)
+	} else {
+		emails, err = h.catchMailer.ListEmails(limit, offset)
+	}
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -6763,8 +7681,7 @@ func (h *Handler) handleListEmails(w http.ResponseWriter, r *http.Request) {
 // handleGetEmail returns a single caught email by ID.
 func (h *Handler) handleGetEmail(w http.ResponseWriter, r *http.Request) {
 	if h.catchMailer == nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Mail catcher not enabled"})
+		writeError(w, http.StatusNotFound, "mail_catcher_not_enabled", "Mail catcher not enabled")
 		return
 	}
 
@@ -6772,8 +7689,7 @@ func (h *Handler) handleGetEmail(w http.ResponseWriter, r *http.Request) {
 
 	email, err := h.catchMailer.GetEmail(id)
 	if err != nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Email not found"})
+		writeError(w, http.StatusNotFound, "email_not_found", "Email not found")
 		return
 	}
 
@@ -6784,16 +7700,14 @@ func (h *Handler) handleGetEmail(w http.ResponseWriter, r *http.Request) {
 // handleDeleteEmail deletes a single caught email by ID.
 func (h *Handler) handleDeleteEmail(w http.ResponseWriter, r *http.Request) {
 	if h.catchMailer == nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Mail catcher not enabled"})
+		writeError(w, http.StatusNotFound, "mail_catcher_not_enabled", "Mail catcher not enabled")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 
 	if err := h.catchMailer.DeleteEmail(id); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -6803,34 +7717,101 @@ func (h *Handler) handleDeleteEmail(w http.ResponseWriter, r *http.Request) {
 // handleClearEmails deletes all caught emails.
 func (h *Handler) handleClearEmails(w http.ResponseWriter, r *http.Request) {
 	if h.catchMailer == nil {
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Mail catcher not enabled"})
+		writeError(w, http.StatusNotFound, "mail_catcher_not_enabled", "Mail catcher not enabled")
 		return
 	}
 
 	if err := h.catchMailer.ClearAll(); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleMailQueue returns outbound queue depth, delivery counters, and queued emails.
+// GET /_/api/mail/queue?status=failed
+func (h *Handler) handleMailQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.mailQueue == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"enabled": false})
+		return
+	}
+
+	limit := 100
+	offset := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	stats, err := h.mailQueue.Stats()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	emails, err := h.mailQueue.ListQueued(r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled": true,
+		"stats":   stats,
+		"emails":  emails,
+	})
+}
+
+// handleRetryMailQueue requeues emails that exhausted their delivery attempts.
+// POST /_/api/mail/queue/retry
+func (h *Handler) handleRetryMailQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.mailQueue == nil {
+		writeError(w, http.StatusNotFound, "mail_queue_not_enabled", "Mail queue not enabled")
+		return
+	}
+
+	n, err := h.mailQueue.RetryFailed()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]int64{"requeued": n})
+}
+
 // Migration API Handlers
 
 // handleMigrationStart creates a new migration session.
 func (h *Handler) handleMigrationStart(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
-	m, err := h.migrationService.StartMigration()
+	var req struct {
+		WebhookURL string `json:"webhook_url"`
+	}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+			return
+		}
+	}
+
+	m, err := h.migrationService.StartMigration(req.WebhookURL)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		if strings.Contains(err.Error(), "invalid webhook URL") {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -6841,40 +7822,35 @@ func (h *Handler) handleMigrationStart(w http.ResponseWriter, r *http.Request) {
 // handleMigrationGet retrieves a migration by ID including items and progress.
 func (h *Handler) handleMigrationGet(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	m, err := h.migrationService.GetMigration(id)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	items, err := h.migrationService.GetItems(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
 	progress, err := h.migrationService.GetProgress(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -6886,18 +7862,87 @@ func (h *Handler) handleMigrationGet(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Page size bounds for GET /_/api/migration/{id}/items.
+const (
+	defaultMigrationItemsLimit = 100
+	maxMigrationItemsLimit     = 1000
+)
+
+// handleMigrationItems returns one page of a migration's items, optionally
+// filtered by status, with the matching total and per-status counts.
+// GET /_/api/migration/{id}/items?limit=100&offset=0&status=failed
+func (h *Handler) handleMigrationItems(w http.ResponseWriter, r *http.Request) {
+	if h.migrationService == nil {
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if _, err := h.migrationService.GetMigration(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		}
+		return
+	}
+
+	limit := defaultMigrationItemsLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+	if limit > maxMigrationItemsLimit {
+		limit = maxMigrationItemsLimit
+	}
+	offset := 0
+	if o := r.URL.Query().Get("offset"); o != "" {
+		if parsed, err := strconv.Atoi(o); err == nil && parsed >= 0 {
+			offset = parsed
+		}
+	}
+
+	status := migration.ItemStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", migration.ItemPending, migration.ItemInProgress, migration.ItemCompleted,
+		migration.ItemFailed, migration.ItemSkipped, migration.ItemRolledBack:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid_request", "Unknown item status: "+string(status))
+		return
+	}
+
+	items, total, err := h.migrationService.ListItems(id, status, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	counts, err := h.migrationService.CountItemsByStatus(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":  items,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+		"counts": counts,
+	})
+}
+
 // handleMigrationConnect stores Supabase credentials and validates the token.
 func (h *Handler) handleMigrationConnect(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
@@ -6905,26 +7950,23 @@ func (h *Handler) handleMigrationConnect(w http.ResponseWriter, r *http.Request)
 		Token string `json:"token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Token == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Token is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Token is required")
 		return
 	}
 
 	if err := h.migrationService.ConnectSupabase(id, req.Token); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else if strings.Contains(err.Error(), "invalid token") {
-			w.WriteHeader(http.StatusUnauthorized)
+			writeError(w, http.StatusUnauthorized, "invalid_token", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -6935,28 +7977,25 @@ func (h *Handler) handleMigrationConnect(w http.ResponseWriter, r *http.Request)
 // handleMigrationProjects lists available Supabase projects for the connected account.
 func (h *Handler) handleMigrationProjects(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	projects, err := h.migrationService.ListSupabaseProjects(id)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else if strings.Contains(err.Error(), "no Supabase credentials") {
-			w.WriteHeader(http.StatusPreconditionFailed)
+			writeError(w, http.StatusPreconditionFailed, "no_supabase_credentials", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -6967,32 +8006,30 @@ func (h *Handler) handleMigrationProjects(w http.ResponseWriter, r *http.Request
 // handleMigrationSelect accepts a selection of items to migrate.
 func (h *Handler) handleMigrationSelect(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	var req migration.SelectItemsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if err := h.migrationService.SelectItems(id, req); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
+		} else if strings.Contains(err.Error(), "invalid mode") {
+			writeError(w, http.StatusBadRequest, "invalid_mode", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7003,29 +8040,51 @@ func (h *Handler) handleMigrationSelect(w http.ResponseWriter, r *http.Request)
 // handleMigrationRun starts the migration execution.
 func (h *Handler) handleMigrationRun(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
-	if err := h.migrationService.RunMigration(id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+	// An optional webhook URL may be supplied (or replaced) when starting the run,
+	// along with the number of tables to copy in parallel
+	var req struct {
+		WebhookURL string `json:"webhook_url"`
+		Workers    int    `json:"workers"`
+	}
+	if r.Body != nil && r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+			return
+		}
+	}
+	if req.WebhookURL != "" {
+		if err := h.migrationService.SetWebhookURL(id, req.WebhookURL); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
+			} else {
+				writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			}
+			return
+		}
+	}
+
+	if err := h.migrationService.RunMigrationWithOptions(id, migration.RunOptions{Workers: req.Workers}); err != nil {
+		if errors.Is(err, migration.ErrShuttingDown) {
+			writeError(w, http.StatusServiceUnavailable, "shutting_down", err.Error())
+		} else if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else if strings.Contains(err.Error(), "no Supabase project") {
-			w.WriteHeader(http.StatusPreconditionFailed)
+			writeError(w, http.StatusPreconditionFailed, "no_supabase_project", err.Error())
 		} else if strings.Contains(err.Error(), "no items selected") {
-			w.WriteHeader(http.StatusPreconditionFailed)
+			writeError(w, http.StatusPreconditionFailed, "no_items_selected", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7036,33 +8095,35 @@ func (h *Handler) handleMigrationRun(w http.ResponseWriter, r *http.Request) {
 // handleMigrationRetry retries failed items by re-running the migration.
 func (h *Handler) handleMigrationRetry(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	// Reset failed items to pending so they can be retried
 	if err := h.migrationService.RetryFailedItems(id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+		if errors.Is(err, migration.ErrShuttingDown) {
+			writeError(w, http.StatusServiceUnavailable, "shutting_down", err.Error())
+		} else if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
 	// Run the migration again (will only process pending items)
 	if err := h.migrationService.RunMigration(id); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		if errors.Is(err, migration.ErrShuttingDown) {
+			writeError(w, http.StatusServiceUnavailable, "shutting_down", err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -7073,27 +8134,26 @@ func (h *Handler) handleMigrationRetry(w http.ResponseWriter, r *http.Request) {
 // handleMigrationRollback undoes a completed or failed migration.
 func (h *Handler) handleMigrationRollback(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	if err := h.migrationService.Rollback(id); err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+		if errors.Is(err, migration.ErrShuttingDown) {
+			writeError(w, http.StatusServiceUnavailable, "shutting_down", err.Error())
+		} else if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else if strings.Contains(err.Error(), "cannot rollback") {
-			w.WriteHeader(http.StatusConflict)
+			writeError(w, http.StatusConflict, "cannot_rollback", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7104,25 +8164,22 @@ func (h *Handler) handleMigrationRollback(w http.ResponseWriter, r *http.Request
 // handleMigrationDelete deletes a migration and all its items.
 func (h *Handler) handleMigrationDelete(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	if err := h.migrationService.DeleteMigration(id); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7132,15 +8189,13 @@ func (h *Handler) handleMigrationDelete(w http.ResponseWriter, r *http.Request)
 // handleMigrationsList returns all migrations for history view.
 func (h *Handler) handleMigrationsList(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	migrations, err := h.migrationService.ListMigrations()
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 
@@ -7151,15 +8206,13 @@ func (h *Handler) handleMigrationsList(w http.ResponseWriter, r *http.Request) {
 // handleSetDatabasePassword stores the Supabase database password for direct PostgreSQL connections.
 func (h *Handler) handleSetDatabasePassword(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
@@ -7167,24 +8220,21 @@ func (h *Handler) handleSetDatabasePassword(w http.ResponseWriter, r *http.Reque
 		Password string `json:"password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if req.Password == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Password is required"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Password is required")
 		return
 	}
 
 	if err := h.migrationService.SetDatabasePassword(id, req.Password); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7195,27 +8245,52 @@ func (h *Handler) handleSetDatabasePassword(w http.ResponseWriter, r *http.Reque
 // handleVerifyBasic runs basic verification checks for a migration.
 func (h *Handler) handleVerifyBasic(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	if err := h.migrationService.RunBasicVerification(id); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
+		} else if strings.Contains(err.Error(), "no Supabase project") {
+			writeError(w, http.StatusPreconditionFailed, "no_supabase_project", err.Error())
+		} else {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// handleVerifyRollback checks that a rollback removed everything from the target project.
+func (h *Handler) handleVerifyRollback(w http.ResponseWriter, r *http.Request) {
+	if h.migrationService == nil {
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
+		return
+	}
+
+	if err := h.migrationService.RunRollbackVerification(id); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else if strings.Contains(err.Error(), "no Supabase project") {
-			w.WriteHeader(http.StatusPreconditionFailed)
+			writeError(w, http.StatusPreconditionFailed, "no_supabase_project", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7226,27 +8301,24 @@ func (h *Handler) handleVerifyBasic(w http.ResponseWriter, r *http.Request) {
 // handleVerifyIntegrity runs data integrity verification checks for a migration.
 func (h *Handler) handleVerifyIntegrity(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	if err := h.migrationService.RunIntegrityVerification(id); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else if strings.Contains(err.Error(), "no Supabase project") {
-			w.WriteHeader(http.StatusPreconditionFailed)
+			writeError(w, http.StatusPreconditionFailed, "no_supabase_project", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7257,34 +8329,30 @@ func (h *Handler) handleVerifyIntegrity(w http.ResponseWriter, r *http.Request)
 // handleVerifyFunctional runs functional verification tests for a migration.
 func (h *Handler) handleVerifyFunctional(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	var opts migration.FunctionalTestOptions
 	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
 	if err := h.migrationService.RunFunctionalVerification(id, opts); err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else if strings.Contains(err.Error(), "no Supabase project") {
-			w.WriteHeader(http.StatusPreconditionFailed)
+			writeError(w, http.StatusPreconditionFailed, "no_supabase_project", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
@@ -7295,33 +8363,35 @@ func (h *Handler) handleVerifyFunctional(w http.ResponseWriter, r *http.Request)
 // handleVerifyResults returns all verification results for a migration.
 func (h *Handler) handleVerifyResults(w http.ResponseWriter, r *http.Request) {
 	if h.migrationService == nil {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Migration service not configured"})
+		writeError(w, http.StatusServiceUnavailable, "migrations_not_configured", "Migration service not configured")
 		return
 	}
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing migration ID"})
+		writeError(w, http.StatusBadRequest, "missing_field", "Missing migration ID")
 		return
 	}
 
 	verifications, err := h.migrationService.GetVerifications(id)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
-			w.WriteHeader(http.StatusNotFound)
+			writeError(w, http.StatusNotFound, "migration_not_found", err.Error())
 		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		}
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"verifications": verifications,
-	})
+	}
+	if discrepancies := migration.RollbackDiscrepancies(verifications); discrepancies != nil {
+		resp["rollback_discrepancies"] = discrepancies
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
 }
 
 // ==================== Observability Handlers ====================
@@ -7342,11 +8412,38 @@ func (h *Handler) handleObservabilityStatus(w http.ResponseWriter, r *http.Reque
 		"endpoint":         cfg.Endpoint,
 		"serviceName":      cfg.ServiceName,
 		"sampleRate":       cfg.SampleRate,
+		"routeSampling":    h.telemetry.RouteSampling(),
 		"metricsEnabled":   cfg.MetricsEnabled,
 		"tracesEnabled":    cfg.TracesEnabled,
 	})
 }
 
+// handleUpdateObservabilityStatus replaces the per-route sampling overrides.
+// They take effect for new requests and last until the server restarts.
+func (h *Handler) handleUpdateObservabilityStatus(w http.ResponseWriter, r *http.Request) {
+	if h.telemetry == nil {
+		writeError(w, http.StatusServiceUnavailable, "observability_not_enabled", "Observability is not enabled. Start the server with --otel-exporter.")
+		return
+	}
+
+	var req struct {
+		RouteSampling *[]observability.RouteSampleRate `json:"routeSampling"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+
+	if req.RouteSampling != nil {
+		if err := h.telemetry.SetRouteSampling(*req.RouteSampling); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_setting", err.Error())
+			return
+		}
+	}
+
+	h.handleObservabilityStatus(w, r)
+}
+
 // handleObservabilityMetrics returns aggregated metrics over time.
 func (h *Handler) handleObservabilityMetrics(w http.ResponseWriter, r *http.Request) {
 	// Flush any buffered metrics to ensure we have the latest data
@@ -7375,7 +8472,7 @@ func (h *Handler) handleObservabilityMetrics(w http.ResponseWriter, r *http.Requ
 
 	rows, err := h.db.Query(query, start)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -7470,7 +8567,7 @@ func (h *Handler) handleObservabilityTraces(w http.ResponseWriter, r *http.Reque
 
 	rows, err := h.db.Query(query, args...)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
 		return
 	}
 	defer rows.Close()
@@ -7504,6 +8601,58 @@ func (h *Handler) handleObservabilityTraces(w http.ResponseWriter, r *http.Reque
 
 		traces = append(traces, traceData)
 	}
+	rows.Close()
+
+	h.attachFunctionSpans(traces)
 
 	json.NewEncoder(w).Encode(traces)
 }
+
+// attachFunctionSpans adds the edge function invocations recorded under each
+// trace as its child "spans", so function-backed requests show the full chain.
+func (h *Handler) attachFunctionSpans(traces []map[string]interface{}) {
+	byTrace := map[string]map[string]interface{}{}
+	for _, t := range traces {
+		if id, ok := t["trace_id"].(string); ok && id != "" {
+			byTrace[id] = t
+		}
+	}
+	if len(byTrace) == 0 {
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT timestamp, value, tags FROM _observability_metrics
+		WHERE metric_name = 'function.invoke_duration_ms' AND timestamp >= ?
+		ORDER BY timestamp ASC
+	`, time.Now().Unix()-int64(15*60))
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ts int64
+		var duration float64
+		var tags string
+		if err := rows.Scan(&ts, &duration, &tags); err != nil {
+			continue
+		}
+		span := map[string]interface{}{
+			"timestamp":   ts,
+			"duration_ms": duration,
+		}
+		for _, pair := range strings.Split(tags, ",") {
+			if key, val, ok := strings.Cut(pair, ":"); ok {
+				span[strings.TrimSpace(key)] = strings.TrimSpace(val)
+			}
+		}
+		id, _ := span["trace_id"].(string)
+		parent, ok := byTrace[id]
+		if !ok {
+			continue
+		}
+		spans, _ := parent["spans"].([]map[string]interface{})
+		parent["spans"] = append(spans, span)
+	}
+}
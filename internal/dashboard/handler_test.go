@@ -2,14 +2,22 @@ package dashboard
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/db"
+	"github.com/markb/sblite/internal/functions"
+	"github.com/markb/sblite/internal/log"
+	"github.com/markb/sblite/internal/observability"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,6 +42,17 @@ func setupTestSession(t *testing.T, h *Handler) string {
 	return token
 }
 
+// testCSRFToken is the double-submit token sent by addTestSession.
+const testCSRFToken = "test-csrf-token"
+
+// addTestSession authenticates a request with the session token, including
+// the CSRF cookie and header required on state-changing requests.
+func addTestSession(req *http.Request, token string) {
+	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	req.AddCookie(&http.Cookie{Name: "_sblite_session_csrf", Value: testCSRFToken})
+	req.Header.Set(CSRFHeader, testCSRFToken)
+}
+
 func TestHandlerServesUI(t *testing.T) {
 	database := setupTestDB(t)
 	defer database.Close()
@@ -304,7 +323,7 @@ func TestHandlerListTables(t *testing.T) {
 	token := setupTestSession(t, h)
 
 	req := httptest.NewRequest("GET", "/api/tables", nil)
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -336,7 +355,7 @@ func TestHandlerGetTableSchema(t *testing.T) {
 	token := setupTestSession(t, h)
 
 	req := httptest.NewRequest("GET", "/api/tables/products", nil)
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -363,7 +382,7 @@ func TestHandlerCreateTable(t *testing.T) {
 	body := `{"name":"orders","columns":[{"name":"id","type":"uuid","primary":true},{"name":"total","type":"integer","nullable":true}]}`
 	req := httptest.NewRequest("POST", "/api/tables", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -379,6 +398,41 @@ func TestHandlerCreateTable(t *testing.T) {
 	require.Equal(t, 2, count)
 }
 
+func TestHandlerCreateTableDryRun(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	token := setupTestSession(t, h)
+
+	body := `{"name":"events","columns":[{"name":"id","type":"uuid","primary":true,"default":"gen_random_uuid()"},{"name":"at","type":"timestamptz","default":"now()"}]}`
+	req := httptest.NewRequest("POST", "/api/tables?dry_run=true", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	addTestSession(req, token)
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var plan map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&plan))
+	require.Equal(t, true, plan["dry_run"])
+	require.Contains(t, plan["sql"], `CREATE TABLE "events"`)
+	require.Contains(t, plan["sql"], "randomblob")
+	require.Contains(t, plan["sql"], "strftime('%Y-%m-%d %H:%M:%f+00', 'now')")
+	require.Regexp(t, `^\d{14}_create_events_table\.sql$`, plan["migration_file"])
+
+	// Nothing was created or written
+	var count int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'events'`).Scan(&count)
+	require.Zero(t, count)
+	h.db.QueryRow(`SELECT COUNT(*) FROM _schema_migrations`).Scan(&count)
+	require.Zero(t, count)
+	entries, _ := os.ReadDir(h.migrationsDir)
+	require.Empty(t, entries)
+}
+
 func TestHandlerDeleteTable(t *testing.T) {
 	h, dbPath := setupTestHandler(t)
 	defer os.Remove(dbPath)
@@ -392,7 +446,7 @@ func TestHandlerDeleteTable(t *testing.T) {
 	token := setupTestSession(t, h)
 
 	req := httptest.NewRequest("DELETE", "/api/tables/to_delete", nil)
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -421,7 +475,7 @@ func TestHandlerSelectData(t *testing.T) {
 	token := setupTestSession(t, h)
 
 	req := httptest.NewRequest("GET", "/api/data/items?limit=2&offset=0", nil)
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -437,6 +491,12 @@ func TestHandlerSelectData(t *testing.T) {
 	rows := result["rows"].([]interface{})
 	require.Len(t, rows, 2)
 	require.Equal(t, float64(3), result["total"])
+
+	// Phase durations are reported in the header and the body
+	require.Regexp(t, `^count;dur=[0-9.]+, query;dur=[0-9.]+, serialize;dur=[0-9.]+$`, w.Header().Get("Server-Timing"))
+	timing := result["timing"].(map[string]interface{})
+	require.Contains(t, timing, "count_ms")
+	require.Contains(t, timing, "query_ms")
 }
 
 func TestHandlerInsertData(t *testing.T) {
@@ -451,7 +511,7 @@ func TestHandlerInsertData(t *testing.T) {
 	body := `{"id":"new-1","name":"New Item"}`
 	req := httptest.NewRequest("POST", "/api/data/items", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -479,7 +539,7 @@ func TestHandlerUpdateData(t *testing.T) {
 	body := `{"name":"New Name"}`
 	req := httptest.NewRequest("PATCH", "/api/data/items?id=eq.1", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -505,7 +565,7 @@ func TestHandlerDeleteData(t *testing.T) {
 	token := setupTestSession(t, h)
 
 	req := httptest.NewRequest("DELETE", "/api/data/items?id=eq.1", nil)
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -533,7 +593,7 @@ func TestHandlerAddColumn(t *testing.T) {
 	body := `{"name":"description","type":"text","nullable":true}`
 	req := httptest.NewRequest("POST", "/api/tables/items/columns", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -548,6 +608,113 @@ func TestHandlerAddColumn(t *testing.T) {
 	require.Equal(t, 1, count)
 }
 
+func TestHandlerAddUUIDDefaultColumn(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	// Existing rows rule out a non-constant DDL default in SQLite
+	_, err := h.db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT); INSERT INTO items (name) VALUES ('old')`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+	post := func(url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/api/tables/items/columns", `{"name":"ref","type":"uuid","nullable":true,"default":"GEN_RANDOM_UUID()"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var defaultValue string
+	h.db.QueryRow(`SELECT default_value FROM _columns WHERE table_name = 'items' AND column_name = 'ref'`).Scan(&defaultValue)
+	require.Equal(t, "gen_random_uuid()", defaultValue)
+
+	// Inserts that leave the column out get a UUID, as do the existing rows
+	w = post("/api/sql", `{"query":"INSERT INTO items (name) VALUES ('new')","postgres_mode":true}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	rows, err := h.db.Query(`SELECT name, ref FROM items ORDER BY id`)
+	require.NoError(t, err)
+	defer rows.Close()
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	var names []string
+	for rows.Next() {
+		var name string
+		var ref sql.NullString
+		require.NoError(t, rows.Scan(&name, &ref))
+		require.True(t, uuidPattern.MatchString(ref.String), "%s has ref %q", name, ref.String)
+		names = append(names, name)
+	}
+	require.Equal(t, []string{"old", "new"}, names)
+}
+
+func TestHandlerAddColumnsBatch(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	_, err := h.db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT)`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/tables/items/columns/batch", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	columnCount := func() int {
+		var n int
+		h.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('items')`).Scan(&n)
+		return n
+	}
+
+	// A failing column rolls back the whole batch
+	w := post(`{"columns":[{"name":"price","type":"numeric"},{"name":"name","type":"text"}]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.Equal(t, 2, columnCount())
+	entries, _ := os.ReadDir(h.migrationsDir)
+	require.Empty(t, entries)
+
+	w = post(`{"columns":[{"name":"price","type":"numeric","nullable":true},{"name":"sku","type":"text","default":"none"}]}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Equal(t, 4, columnCount())
+
+	var registered int
+	h.db.QueryRow(`SELECT COUNT(*) FROM _columns WHERE table_name = 'items' AND column_name IN ('price', 'sku')`).Scan(&registered)
+	require.Equal(t, 2, registered)
+
+	// Both columns are recorded in one up/down migration pair
+	entries, err = os.ReadDir(h.migrationsDir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	var up, down string
+	for _, e := range entries {
+		content, err := os.ReadFile(h.migrationsDir + "/" + e.Name())
+		require.NoError(t, err)
+		if strings.HasSuffix(e.Name(), ".down.sql") {
+			down = string(content)
+		} else {
+			up = string(content)
+		}
+	}
+	require.Contains(t, up, `ADD COLUMN "price"`)
+	require.Contains(t, up, `ADD COLUMN "sku"`)
+	require.Less(t, strings.Index(down, `"sku"`), strings.Index(down, `"price"`))
+
+	w = post(`{"columns":[]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestHandlerRenameColumn(t *testing.T) {
 	h, dbPath := setupTestHandler(t)
 	defer os.Remove(dbPath)
@@ -562,7 +729,7 @@ func TestHandlerRenameColumn(t *testing.T) {
 	body := `{"new_name":"new_name"}`
 	req := httptest.NewRequest("PATCH", "/api/tables/items/columns/old_name", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -590,7 +757,7 @@ func TestHandlerDropColumn(t *testing.T) {
 	token := setupTestSession(t, h)
 
 	req := httptest.NewRequest("DELETE", "/api/tables/items/columns/to_drop", nil)
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: token})
+	addTestSession(req, token)
 	w := httptest.NewRecorder()
 
 	r := chi.NewRouter()
@@ -713,3 +880,688 @@ func TestHandleListFunctionFilesServiceNotEnabled(t *testing.T) {
 	require.NoError(t, err)
 	require.Contains(t, resp["error"], "not enabled")
 }
+
+func TestHandlerInsertDataCoercesTypes(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	_, err := h.db.Exec(`CREATE TABLE items (id TEXT PRIMARY KEY, active INTEGER, qty INTEGER)`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, is_primary) VALUES
+		('items', 'id', 'text', false, true), ('items', 'active', 'boolean', true, false), ('items', 'qty', 'integer', true, false)`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	body := `{"id":"1","active":true,"qty":"12"}`
+	req := httptest.NewRequest("POST", "/api/data/items", strings.NewReader(body))
+	addTestSession(req, token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var active, qty int
+	var qtyType string
+	err = h.db.QueryRow(`SELECT active, qty, typeof(qty) FROM items WHERE id = '1'`).Scan(&active, &qty, &qtyType)
+	require.NoError(t, err)
+	require.Equal(t, 1, active)
+	require.Equal(t, 12, qty)
+	require.Equal(t, "integer", qtyType)
+
+	body = `{"id":"2","qty":"twelve"}`
+	req = httptest.NewRequest("POST", "/api/data/items", strings.NewReader(body))
+	addTestSession(req, token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "qty")
+}
+
+func TestBuildFilterConditionNegation(t *testing.T) {
+	tests := []struct {
+		val        string
+		wantCond   string
+		wantValues []interface{}
+	}{
+		{"eq.active", `"status" = ?`, []interface{}{"active"}},
+		{"not.eq.active", `NOT ("status" = ?)`, []interface{}{"active"}},
+		{"not.like.*test*", `NOT ("status" LIKE ?)`, []interface{}{"%test%"}},
+		{"not.is.null", `NOT ("status" IS NULL)`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.val, func(t *testing.T) {
+			cond, values, ok := buildFilterCondition("status", tt.val)
+			require.True(t, ok)
+			require.Equal(t, tt.wantCond, cond)
+			require.Equal(t, tt.wantValues, values)
+		})
+	}
+
+	_, _, ok := buildFilterCondition("status", "not.bogus.x")
+	require.False(t, ok)
+}
+
+func TestHandlerUpdateDataIfMatch(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	_, err := h.db.Exec(`CREATE TABLE items (id TEXT PRIMARY KEY, name TEXT, version INTEGER DEFAULT 1)`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO items (id, name) VALUES ('1', 'Original')`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	update := func(name, version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/api/data/items?id=eq.1", strings.NewReader(`{"name":"`+name+`"}`))
+		req.Header.Set("If-Match", version)
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// First editor succeeds and bumps the version
+	w := update("First", "1")
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Second editor still holds version 1 and is rejected
+	w = update("Second", "1")
+	require.Equal(t, http.StatusConflict, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, "row_modified", resp["code"])
+	current := resp["details"].(map[string]interface{})["current"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "First", current["name"])
+	require.Equal(t, float64(2), current["version"])
+}
+
+func TestHandlerUpdateDataIfUnmodifiedSince(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	_, err := h.db.Exec(`CREATE TABLE items (id TEXT PRIMARY KEY, name TEXT, updated_at TEXT)`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO _columns (table_name, column_name, pg_type) VALUES ('items', 'updated_at', 'timestamptz')`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO items (id, name, updated_at) VALUES ('1', 'Original', '2020-01-01 10:00:00.250+00')`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	update := func(name, since string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/api/data/items?id=eq.1", strings.NewReader(`{"name":"`+name+`"}`))
+		req.Header.Set("If-Unmodified-Since", since)
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := update("Early", "Wed, 01 Jan 2020 09:59:59 GMT")
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	w = update("Bad", "yesterday")
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// The stored value is compared to the second, not as a string
+	w = update("First", "Wed, 01 Jan 2020 10:00:00 GMT")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var updatedAt string
+	require.NoError(t, h.db.QueryRow(`SELECT updated_at FROM items WHERE id = '1'`).Scan(&updatedAt))
+	require.NotEqual(t, "2020-01-01 10:00:00.250+00", updatedAt)
+	require.True(t, strings.HasSuffix(updatedAt, "+00"), updatedAt)
+
+	// A second editor holding the old date is rejected
+	w = update("Second", "Wed, 01 Jan 2020 10:00:00 GMT")
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+}
+
+func TestHandlerWriteFunctionFileBaseHash(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	dir := t.TempDir()
+	svc, err := functions.NewService(h.db, &functions.Config{FunctionsDir: dir})
+	require.NoError(t, err)
+	h.SetFunctionsService(svc)
+	require.NoError(t, os.MkdirAll(dir+"/hello", 0755))
+	require.NoError(t, os.WriteFile(dir+"/hello/index.ts", []byte("v1"), 0644))
+
+	r := chi.NewRouter()
+	r.Get("/functions/{name}/files/*", h.handleReadFunctionFile)
+	r.Put("/functions/{name}/files/*", h.handleWriteFunctionFile)
+
+	write := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/functions/hello/files/index.ts", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	req := httptest.NewRequest("GET", "/functions/hello/files/index.ts", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var read map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &read))
+	baseHash := read["hash"].(string)
+	require.Equal(t, FileContentHash([]byte("v1")), baseHash)
+	require.NotEmpty(t, read["modified_at"])
+
+	// Another process edits the file after it was read
+	require.NoError(t, os.WriteFile(dir+"/hello/index.ts", []byte("external"), 0644))
+
+	w = write(`{"content":"mine","base_hash":"` + baseHash + `"}`)
+	require.Equal(t, http.StatusConflict, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, "file_modified", resp["code"])
+	currentHash := resp["details"].(map[string]interface{})["current_hash"].(string)
+	require.Equal(t, FileContentHash([]byte("external")), currentHash)
+	content, _ := os.ReadFile(dir + "/hello/index.ts")
+	require.Equal(t, "external", string(content))
+
+	// Writing against the current hash succeeds
+	w = write(`{"content":"mine","base_hash":"` + currentHash + `"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Without a base hash the write is unconditional
+	w = write(`{"content":"forced"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	content, _ = os.ReadFile(dir + "/hello/index.ts")
+	require.Equal(t, "forced", string(content))
+}
+
+func TestHandlerWriteFunctionFileLimits(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	dir := t.TempDir()
+	svc, err := functions.NewService(h.db, &functions.Config{FunctionsDir: dir})
+	require.NoError(t, err)
+	h.SetFunctionsService(svc)
+	require.NoError(t, os.MkdirAll(dir+"/hello", 0755))
+	require.NoError(t, os.WriteFile(dir+"/hello/index.ts", []byte("v1"), 0644))
+
+	r := chi.NewRouter()
+	r.Get("/functions/{name}", h.handleGetFunction)
+	r.Put("/functions/{name}/files/*", h.handleWriteFunctionFile)
+	r.Patch("/settings/functions", h.handleUpdateFunctionSettings)
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("PATCH", "/settings/functions", `{"max_file_size": 10, "max_files": 2}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = send("PATCH", "/settings/functions", `{"max_file_size": 2097152}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = send("PUT", "/functions/hello/files/index.ts", `{"content":"more than ten bytes"}`)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	w = send("PUT", "/functions/hello/files/util.ts", `{"content":"ok"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// A third file is over the count limit, but existing files can be updated
+	w = send("PUT", "/functions/hello/files/extra.ts", `{"content":"ok"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "too_many_files")
+	w = send("PUT", "/functions/hello/files/util.ts", `{"content":"updated"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The function details report the limits
+	w = send("GET", "/functions/hello", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var fn functions.FunctionInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &fn))
+	require.Equal(t, int64(10), fn.MaxFileSize)
+	require.Equal(t, 2, fn.MaxFiles)
+	require.Equal(t, 2, fn.FileCount)
+}
+
+func TestHandlerUpdateLogLevel(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+	defer log.SetLevel(log.GetLevel())
+
+	r := chi.NewRouter()
+	r.Patch("/logs/config", h.handleUpdateLogConfig)
+
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/logs/config", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(`{"level":"error"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, "error", resp["level"])
+	require.Equal(t, "error", log.GetLevel())
+
+	w = patch(`{"level":"loud"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, "error", log.GetLevel())
+}
+
+func TestHandlerTailLogsJSON(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	logFile := t.TempDir() + "/sblite.log"
+	require.NoError(t, os.WriteFile(logFile, []byte(
+		`time=2026-01-01T00:00:00Z level=INFO msg="before switch"`+"\n"+
+			`{"time":"2026-01-01T00:00:01Z","level":"ERROR","msg":"query failed","table":"orders"}`+"\n"), 0644))
+	h.SetServerConfig(&ServerConfig{LogMode: "file", LogFile: logFile})
+
+	req := httptest.NewRequest("GET", "/logs/tail", nil)
+	w := httptest.NewRecorder()
+	h.handleTailLogs(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Lines   []string    `json:"lines"`
+		Format  string      `json:"format"`
+		Entries []log.Entry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp.Lines, 2)
+	require.Equal(t, "json", resp.Format)
+	require.Len(t, resp.Entries, 2)
+	require.Equal(t, resp.Lines[0], resp.Entries[0].Message)
+	require.Equal(t, "ERROR", resp.Entries[1].Level)
+	require.Equal(t, "query failed", resp.Entries[1].Message)
+	require.Equal(t, "orders", resp.Entries[1].Fields["table"])
+}
+
+func TestHandlerTracesIncludeFunctionSpans(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+	require.NoError(t, db.CreateMetricsTables(h.db))
+
+	now := time.Now().Unix()
+	_, err := h.db.Exec(`INSERT INTO _observability_metrics (timestamp, metric_name, value, tags) VALUES
+		(?, 'http.server.request_count', 1, 'http.method:POST,http.status_code:200,trace_id:abc,span_id:01'),
+		(?, 'http.server.request_count', 1, 'http.method:GET,http.status_code:200,trace_id:def,span_id:02'),
+		(?, 'function.invoke_duration_ms', 42, 'function.name:hello,http.status_code:200,trace_id:abc,span_id:03,parent_span_id:01')`,
+		now, now, now)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/observability/traces", nil)
+	w := httptest.NewRecorder()
+	h.handleObservabilityTraces(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var traces []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &traces))
+	require.Len(t, traces, 2)
+	for _, tr := range traces {
+		if tr["trace_id"] == "abc" {
+			spans := tr["spans"].([]interface{})
+			require.Len(t, spans, 1)
+			span := spans[0].(map[string]interface{})
+			require.Equal(t, "hello", span["function.name"])
+			require.Equal(t, "01", span["parent_span_id"])
+			require.Equal(t, float64(42), span["duration_ms"])
+		} else {
+			require.Nil(t, tr["spans"])
+		}
+	}
+}
+
+func TestHandlerUpdateRouteSampling(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	r := chi.NewRouter()
+	r.Patch("/observability/status", h.handleUpdateObservabilityStatus)
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/observability/status", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Observability disabled
+	require.Equal(t, http.StatusServiceUnavailable, patch(`{"routeSampling":[]}`).Code)
+
+	cfg := observability.NewConfig()
+	cfg.Exporter = "stdout"
+	cfg.TracesEnabled = true
+	tel, cleanup, err := observability.Init(context.Background(), cfg)
+	require.NoError(t, err)
+	defer cleanup()
+	h.SetTelemetry(tel)
+
+	w := patch(`{"routeSampling":[{"prefix":"/_/static","rate":0},{"prefix":"/rest/v1","rate":1}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Len(t, resp["routeSampling"], 2)
+	require.Len(t, tel.RouteSampling(), 2)
+
+	w = patch(`{"routeSampling":[{"prefix":"/rest/v1","rate":2}]}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, tel.RouteSampling(), 2)
+}
+
+func TestHandlerTruncateTable(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	_, err := h.db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT)`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO items (name) VALUES ('a'), ('b'), ('c')`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	// Without confirmation nothing is removed
+	req := httptest.NewRequest("POST", "/api/tables/items/truncate", strings.NewReader(`{}`))
+	addTestSession(req, token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest("POST", "/api/tables/items/truncate", strings.NewReader(`{"confirm": true}`))
+	addTestSession(req, token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, float64(3), resp["rows_removed"])
+
+	// Sequence is reset so ids start over
+	_, err = h.db.Exec(`INSERT INTO items (name) VALUES ('d')`)
+	require.NoError(t, err)
+	var id int
+	require.NoError(t, h.db.QueryRow(`SELECT id FROM items`).Scan(&id))
+	require.Equal(t, 1, id)
+
+	// The migration records the truncate without deleting anything on replay
+	matches, err := filepath.Glob(filepath.Join(h.migrationsDir, "*_truncate_items_table.sql"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	content, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		require.True(t, strings.HasPrefix(line, "--"), "expected only comments, got %q", line)
+	}
+
+	// Internal tables can't be truncated
+	req = httptest.NewRequest("POST", "/api/tables/auth_users/truncate", strings.NewReader(`{"confirm": true}`))
+	addTestSession(req, token)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), "reserved_table_name")
+}
+
+func TestHandlerListPoliciesFilterAndPaginate(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	_, err := h.db.Exec(`INSERT INTO _rls_policies (table_name, policy_name, command, using_expr, enabled) VALUES
+		('posts', 'read_all', 'SELECT', 'true', 1),
+		('posts', 'insert_own', 'INSERT', 'true', 1),
+		('posts', 'delete_own', 'DELETE', 'true', 0),
+		('notes', 'read_notes', 'SELECT', 'true', 1)`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	list := func(query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/api/policies"+query, nil)
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp
+	}
+	names := func(resp map[string]interface{}) []string {
+		var out []string
+		for _, p := range resp["policies"].([]interface{}) {
+			out = append(out, p.(map[string]interface{})["policy_name"].(string))
+		}
+		return out
+	}
+
+	// No parameters: everything, ordered by table then name
+	code, resp := list("")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, []string{"read_notes", "delete_own", "insert_own", "read_all"}, names(resp))
+	require.Equal(t, float64(4), resp["total"])
+	require.NotContains(t, resp, "limit")
+
+	_, resp = list("?command=select")
+	require.Equal(t, []string{"read_notes", "read_all"}, names(resp))
+
+	_, resp = list("?table=posts&enabled=true")
+	require.Equal(t, []string{"insert_own", "read_all"}, names(resp))
+
+	_, resp = list("?sort=policy_name&order=desc&limit=2&offset=1")
+	require.Equal(t, []string{"read_all", "insert_own"}, names(resp))
+	require.Equal(t, float64(4), resp["total"])
+	require.Equal(t, float64(2), resp["limit"])
+
+	code, resp = list("?sort=using_expr")
+	require.Equal(t, http.StatusBadRequest, code)
+	require.Equal(t, "invalid_sort", resp["code"])
+
+	code, _ = list("?command=TRUNCATE")
+	require.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestHandlerExportDataSlice(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, status TEXT)`)
+	require.NoError(t, err)
+	for i := 1; i <= 6; i++ {
+		status := "active"
+		if i%2 == 0 {
+			status = "archived"
+		}
+		_, err = database.Exec(`INSERT INTO items (id, status) VALUES (?, ?)`, i, status)
+		require.NoError(t, err)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/export/data", handler.handleExportData)
+
+	ids := func(query string, header http.Header) []float64 {
+		req := httptest.NewRequest("GET", "/export/data?tables=items"+query, nil)
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string][]map[string]interface{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		out := []float64{}
+		for _, row := range resp["items"] {
+			out = append(out, row["id"].(float64))
+		}
+		return out
+	}
+
+	require.Equal(t, []float64{1, 2, 3, 4, 5, 6}, ids("", nil))
+	require.Equal(t, []float64{3, 4}, ids("&limit=2&offset=2", nil))
+	require.Equal(t, []float64{5, 6}, ids("&offset=4", nil))
+	require.Equal(t, []float64{2, 3, 4}, ids("", http.Header{"Range": {"1-3"}}))
+	require.Equal(t, []float64{3, 5}, ids("&status=eq.active&offset=1", nil))
+
+	req := httptest.NewRequest("GET", "/export/data?tables=items&format=csv&limit=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "id,status\n1,active\n", w.Body.String())
+
+	req = httptest.NewRequest("GET", "/export/data?tables=items&limit=-1", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestHandlerExportDataCSVOptions(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE prices (id INTEGER PRIMARY KEY, label TEXT, amount TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO prices (label, amount) VALUES ('Kaffee; gross', '3,50'), ('it''s', '1')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/export/data", handler.handleExportData)
+
+	export := func(query string) (int, string) {
+		req := httptest.NewRequest("GET", "/export/data?tables=prices&format=csv"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code, w.Body.String()
+	}
+
+	code, body := export("")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "id,label,amount\n1,Kaffee; gross,\"3,50\"\n2,it's,1\n", body)
+
+	// Semicolons must be percent-encoded in query strings
+	code, body = export("&delimiter=%3B")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "id;label;amount\n1;\"Kaffee; gross\";3,50\n2;it's;1\n", body)
+
+	code, body = export("&delimiter=%3B&quote='&bom=true")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "\xEF\xBB\xBFid;label;amount\n1;'Kaffee; gross';3,50\n2;'it''s';1\n", body)
+
+	for _, bad := range []string{"&delimiter=%3B%3B", "&delimiter=%0A", "&delimiter=|&quote=|"} {
+		code, _ = export(bad)
+		require.Equal(t, http.StatusBadRequest, code, bad)
+	}
+}
+
+func TestHandlerExportDataNDJSON(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE a (id INTEGER PRIMARY KEY, name TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`CREATE TABLE b (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO a VALUES (1, 'one'), (2, 'two'); INSERT INTO b VALUES (7)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/export/data", handler.handleExportData)
+
+	req := httptest.NewRequest("GET", "/export/data?tables=a,b&format=ndjson", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	require.Len(t, lines, 3)
+	var first map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.Equal(t, "a", first["table"])
+	require.Equal(t, "one", first["row"].(map[string]interface{})["name"])
+	require.JSONEq(t, `{"table":"b","row":{"id":7}}`, lines[2])
+}
+
+func TestHandlerSchemaDescriptions(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)`)
+	require.NoError(t, err)
+	require.NoError(t, handler.ensureTableRegistered("notes"))
+	_, err = database.Exec(`INSERT INTO _table_descriptions (table_name, description) VALUES ('notes', 'User''s notes')`)
+	require.NoError(t, err)
+	_, err = database.Exec(`UPDATE _columns SET description = 'Note text' WHERE table_name = 'notes' AND column_name = 'body'`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}", handler.handleGetTableSchema)
+	r.Get("/export/schema", handler.handleExportSchema)
+
+	req := httptest.NewRequest("GET", "/tables/notes", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var schema struct {
+		Description string                   `json:"description"`
+		Columns     []map[string]interface{} `json:"columns"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&schema))
+	require.Equal(t, "User's notes", schema.Description)
+	require.Equal(t, "", schema.Columns[0]["description"])
+	require.Equal(t, "Note text", schema.Columns[1]["description"])
+
+	req = httptest.NewRequest("GET", "/export/schema", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "COMMENT ON TABLE notes IS 'User''s notes';")
+	require.Contains(t, w.Body.String(), "COMMENT ON COLUMN notes.body IS 'Note text';")
+}
+
+func TestHandlerAuthConfigAllowAnonymous(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	_, err := h.db.Exec(`INSERT INTO auth_users (id, is_anonymous) VALUES ('anon-1', 1), ('anon-2', 1), ('user-1', 0)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/settings/auth-config", h.handleGetAuthConfig)
+	r.Patch("/settings/auth-config", h.handlePatchAuthConfig)
+
+	get := func() AuthConfig {
+		req := httptest.NewRequest("GET", "/settings/auth-config", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		var cfg AuthConfig
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &cfg))
+		return cfg
+	}
+
+	cfg := get()
+	require.True(t, cfg.AllowAnonymous, "anonymous sign-in is enabled by default")
+	require.Equal(t, 2, cfg.AnonymousUserCount)
+
+	req := httptest.NewRequest("PATCH", "/settings/auth-config", strings.NewReader(`{"allow_anonymous": false}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.False(t, get().AllowAnonymous)
+	require.False(t, h.GetAllowAnonymous())
+}
@@ -0,0 +1,114 @@
+package dashboard
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry inserts without creating duplicate rows.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// idempotencyReplayedHeader marks a response replayed from an earlier request.
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+
+	// defaultIdempotencyWindow is how long a key is remembered unless configured.
+	defaultIdempotencyWindow = 24 * time.Hour
+	maxIdempotencyKeyLength  = 255
+	idempotencyTimeFormat    = "2006-01-02T15:04:05.000Z"
+)
+
+// errIdempotencyKeyInUse is returned when a concurrent request recorded the same key first.
+var errIdempotencyKeyInUse = errors.New("idempotency key in use")
+
+// idempotencyWindow returns how long idempotency keys are remembered.
+func (h *Handler) idempotencyWindow() time.Duration {
+	if n := h.getIntSetting("data_idempotency_window_minutes"); n > 0 {
+		return time.Duration(n) * time.Minute
+	}
+	return defaultIdempotencyWindow
+}
+
+// idempotencyRequestHash fingerprints an insert so a key reused with a
+// different body can be detected. json.Marshal sorts map keys, so field order
+// in the request does not matter.
+func idempotencyRequestHash(data map[string]interface{}) string {
+	b, _ := json.Marshal(data)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// replayIdempotent writes the stored response for an unexpired key and reports
+// whether it did. A key reused with a different request body is rejected.
+func (h *Handler) replayIdempotent(w http.ResponseWriter, key, table, requestHash string) bool {
+	var storedHash, response string
+	var status int
+	err := h.db.QueryRow(`SELECT request_hash, status_code, response FROM _idempotency
+		WHERE key = ? AND table_name = ? AND expires_at > ?`,
+		key, table, time.Now().UTC().Format(idempotencyTimeFormat)).Scan(&storedHash, &status, &response)
+	if err != nil {
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if storedHash != requestHash {
+		writeError(w, http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used with a different request body")
+		return true
+	}
+	w.Header().Set(idempotencyReplayedHeader, "true")
+	w.WriteHeader(status)
+	w.Write([]byte(response))
+	return true
+}
+
+// insertIdempotent runs an insert and records its result under key in one
+// transaction, so a row is never created without its key or vice versa. A
+// non-empty check is the RLS check the new row must pass.
+func (h *Handler) insertIdempotent(key, table, requestHash, query string, values []interface{}, check string, response interface{}) error {
+	body, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	// Expired keys may be reused; drop them along with the rest of the backlog
+	if _, err := tx.Exec(`DELETE FROM _idempotency WHERE expires_at <= ?`, now.Format(idempotencyTimeFormat)); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(query, values...)
+	if err != nil {
+		return err
+	}
+	var rowID sql.NullInt64
+	if id, err := result.LastInsertId(); err == nil {
+		rowID = sql.NullInt64{Int64: id, Valid: true}
+		if err := verifyRLSCheck(tx, table, check, []int64{id}); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO _idempotency (key, table_name, request_hash, row_id, status_code, response, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		key, table, requestHash, rowID, http.StatusCreated, string(body)+"\n",
+		now.Format(idempotencyTimeFormat), now.Add(h.idempotencyWindow()).Format(idempotencyTimeFormat))
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return errIdempotencyKeyInUse
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,85 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsertDataIdempotencyKey(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	_, err := h.db.Exec(`CREATE TABLE payments (id INTEGER PRIMARY KEY, amount INTEGER, note TEXT)`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	insert := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/data/payments", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	count := func() int {
+		var n int
+		h.db.QueryRow(`SELECT COUNT(*) FROM payments`).Scan(&n)
+		return n
+	}
+
+	w := insert("pay-1", `{"amount": 100, "note": "first"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	first := w.Body.String()
+
+	// A retry with the same key and body replays the result
+	w = insert("pay-1", `{"note": "first", "amount": 100}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get(idempotencyReplayedHeader))
+	assert.JSONEq(t, first, w.Body.String())
+	assert.Equal(t, 1, count())
+
+	var rowID int64
+	require.NoError(t, h.db.QueryRow(`SELECT row_id FROM _idempotency WHERE key = 'pay-1'`).Scan(&rowID))
+	assert.Equal(t, int64(1), rowID)
+
+	// Reusing the key for a different body is rejected
+	w = insert("pay-1", `{"amount": 200}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 1, count())
+
+	// Requests without a key are unaffected
+	insert("", `{"amount": 100}`)
+	insert("", `{"amount": 100}`)
+	assert.Equal(t, 3, count())
+
+	// Expired keys insert again
+	_, err = h.db.Exec(`UPDATE _idempotency SET expires_at = ?`, time.Now().UTC().Add(-time.Minute).Format(idempotencyTimeFormat))
+	require.NoError(t, err)
+	w = insert("pay-1", `{"amount": 100, "note": "first"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(idempotencyReplayedHeader))
+	assert.Equal(t, 4, count())
+}
+
+func TestIdempotencyWindowSetting(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	assert.Equal(t, defaultIdempotencyWindow, h.idempotencyWindow())
+	require.NoError(t, h.store.Set("data_idempotency_window_minutes", "15"))
+	assert.Equal(t, 15*time.Minute, h.idempotencyWindow())
+}
@@ -0,0 +1,299 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/log"
+)
+
+// IndexStat is an index's sqlite_stat1 entry as written by ANALYZE.
+type IndexStat struct {
+	// Rows is the approximate number of rows in the index.
+	Rows int64 `json:"rows"`
+	// AvgRowsPerKey is, for each prefix of the index columns, the average
+	// number of rows sharing a value; lower means more selective.
+	AvgRowsPerKey []int64 `json:"avg_rows_per_key"`
+}
+
+// IndexProbe is one representative query planned against an index.
+type IndexProbe struct {
+	Query     string `json:"query"`
+	Plan      string `json:"plan"`
+	UsesIndex bool   `json:"uses_index"`
+}
+
+// IndexUsage reports whether the query planner is likely to use an index.
+type IndexUsage struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	// Origin is "c" for CREATE INDEX, "u" for a UNIQUE constraint and "pk"
+	// for a PRIMARY KEY, as in PRAGMA index_list.
+	Origin     string       `json:"origin"`
+	Partial    bool         `json:"partial"`
+	LikelyUsed bool         `json:"likely_used"`
+	Reason     string       `json:"reason"`
+	Stat       *IndexStat   `json:"stat,omitempty"`
+	Probes     []IndexProbe `json:"probes"`
+}
+
+// IndexUsageReport is the response of GET /tables/{name}/indexes/usage.
+type IndexUsageReport struct {
+	Table string `json:"table"`
+	// Analyzed is false until ANALYZE has gathered statistics for the table;
+	// plans and stats are less reliable until then.
+	Analyzed bool         `json:"analyzed"`
+	Indexes  []IndexUsage `json:"indexes"`
+}
+
+// handleAnalyze runs ANALYZE so the query planner has up-to-date statistics,
+// for the whole database or, with ?table=, a single table.
+// POST /_/api/maintenance/analyze
+func (h *Handler) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	stmt := "ANALYZE"
+	table := r.URL.Query().Get("table")
+	if table != "" {
+		var exists int
+		h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&exists)
+		if exists == 0 {
+			writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+			return
+		}
+		stmt = fmt.Sprintf(`ANALYZE "%s"`, table)
+	}
+
+	start := time.Now()
+	if _, err := h.db.Exec(stmt); err != nil {
+		writeError(w, http.StatusInternalServerError, "analyze_failed", err.Error())
+		return
+	}
+	duration := time.Since(start)
+
+	// sqlite_stat1 exists once ANALYZE has run
+	var indexes int
+	query := `SELECT COUNT(*) FROM sqlite_stat1 WHERE idx IS NOT NULL`
+	args := []interface{}{}
+	if table != "" {
+		query += ` AND tbl = ?`
+		args = append(args, table)
+	}
+	h.db.QueryRow(query, args...).Scan(&indexes)
+
+	log.Info("dashboard ran ANALYZE", "table", table, "duration_ms", duration.Milliseconds())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":            table,
+		"analyzed_at":      start.UTC().Format(time.RFC3339),
+		"duration_ms":      duration.Milliseconds(),
+		"indexes_analyzed": indexes,
+	})
+}
+
+// handleIndexUsage plans a few representative queries against each of a
+// table's indexes with EXPLAIN QUERY PLAN and reports which indexes the
+// planner picks, alongside their sqlite_stat1 statistics.
+// GET /_/api/tables/{name}/indexes/usage
+func (h *Handler) handleIndexUsage(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists)
+	if exists == 0 {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+
+	report := IndexUsageReport{Table: tableName, Indexes: []IndexUsage{}}
+	stats := h.indexStats(tableName)
+	report.Analyzed = len(stats) > 0
+
+	rows, err := h.db.Query(`SELECT name, "unique", origin, partial FROM pragma_index_list(?) ORDER BY name`, tableName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	for rows.Next() {
+		var idx IndexUsage
+		var unique, partial int
+		if err := rows.Scan(&idx.Name, &unique, &idx.Origin, &partial); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		idx.Unique = unique == 1
+		idx.Partial = partial == 1
+		idx.Stat = stats[idx.Name]
+		report.Indexes = append(report.Indexes, idx)
+	}
+	rows.Close()
+
+	for i := range report.Indexes {
+		if err := h.probeIndex(tableName, &report.Indexes[i]); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// indexStats returns the sqlite_stat1 entries of a table's indexes, keyed by
+// index name. It is empty when ANALYZE has not run.
+func (h *Handler) indexStats(tableName string) map[string]*IndexStat {
+	stats := map[string]*IndexStat{}
+	var hasStats int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_stat1'`).Scan(&hasStats)
+	if hasStats == 0 {
+		return stats
+	}
+
+	rows, err := h.db.Query(`SELECT idx, stat FROM sqlite_stat1 WHERE tbl = ? AND idx IS NOT NULL`, tableName)
+	if err != nil {
+		return stats
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var idx, stat string
+		if err := rows.Scan(&idx, &stat); err != nil {
+			continue
+		}
+		// "rows avg1 avg2 ..." optionally followed by keywords such as "unordered"
+		fields := strings.Fields(stat)
+		if len(fields) == 0 {
+			continue
+		}
+		s := &IndexStat{AvgRowsPerKey: []int64{}}
+		s.Rows, _ = strconv.ParseInt(fields[0], 10, 64)
+		for _, f := range fields[1:] {
+			n, err := strconv.ParseInt(f, 10, 64)
+			if err != nil {
+				break
+			}
+			s.AvgRowsPerKey = append(s.AvgRowsPerKey, n)
+		}
+		stats[idx] = s
+	}
+	return stats
+}
+
+// probeIndex fills in an index's columns and plans lookups on its leading
+// column, on all of its columns and an ORDER BY, recording whether the
+// planner chose the index for each.
+func (h *Handler) probeIndex(tableName string, idx *IndexUsage) error {
+	cols, err := h.db.Query(`SELECT name FROM pragma_index_info(?) ORDER BY seqno`, idx.Name)
+	if err != nil {
+		return err
+	}
+	idx.Columns = []string{}
+	expression := false
+	for cols.Next() {
+		var name sql.NullString
+		if err := cols.Scan(&name); err != nil {
+			cols.Close()
+			return err
+		}
+		if !name.Valid {
+			expression = true
+		}
+		idx.Columns = append(idx.Columns, name.String)
+	}
+	cols.Close()
+
+	idx.Probes = []IndexProbe{}
+	if expression || len(idx.Columns) == 0 {
+		idx.Reason = "expression index; queries must repeat the indexed expression to use it"
+		return nil
+	}
+
+	// A partial index only serves queries that imply its predicate
+	var where string
+	if idx.Partial {
+		var createSQL string
+		h.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?`, idx.Name).Scan(&createSQL)
+		if m := indexWhereRe.FindStringSubmatch(createSQL); m != nil {
+			where = " AND (" + m[1] + ")"
+		}
+	}
+
+	conds := make([]string, len(idx.Columns))
+	for i, col := range idx.Columns {
+		conds[i] = fmt.Sprintf(`"%s" = ?`, col)
+	}
+	queries := []string{fmt.Sprintf(`SELECT * FROM "%s" WHERE %s%s`, tableName, conds[0], where)}
+	if len(conds) > 1 {
+		queries = append(queries, fmt.Sprintf(`SELECT * FROM "%s" WHERE %s%s`, tableName, strings.Join(conds, " AND "), where))
+	}
+	orderWhere := ""
+	if where != "" {
+		orderWhere = " WHERE " + strings.TrimPrefix(where, " AND ")
+	}
+	queries = append(queries, fmt.Sprintf(`SELECT * FROM "%s"%s ORDER BY "%s"`, tableName, orderWhere, idx.Columns[0]))
+
+	for _, q := range queries {
+		plan, err := h.queryPlan(q, strings.Count(q, "?"))
+		if err != nil {
+			return err
+		}
+		probe := IndexProbe{Query: q, Plan: plan, UsesIndex: planUsesIndex(plan, idx.Name)}
+		if probe.UsesIndex {
+			idx.LikelyUsed = true
+		}
+		idx.Probes = append(idx.Probes, probe)
+	}
+
+	switch {
+	case idx.LikelyUsed:
+		idx.Reason = "the planner uses this index for lookups on its columns"
+	case idx.Stat != nil && len(idx.Stat.AvgRowsPerKey) > 0 && idx.Stat.Rows > 0 && idx.Stat.AvgRowsPerKey[0]*2 > idx.Stat.Rows:
+		idx.Reason = "the leading column has too few distinct values for the index to help"
+	default:
+		idx.Reason = "the planner prefers another index or a table scan for these lookups"
+	}
+	return nil
+}
+
+// queryPlan returns the EXPLAIN QUERY PLAN details of a query, one step per
+// line, binding NULL to its parameters.
+func (h *Handler) queryPlan(query string, params int) (string, error) {
+	rows, err := h.db.Query("EXPLAIN QUERY PLAN "+query, make([]interface{}, params)...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var steps []string
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			return "", err
+		}
+		steps = append(steps, detail)
+	}
+	return strings.Join(steps, "\n"), rows.Err()
+}
+
+// planUsesIndex reports whether a query plan reads through the named index,
+// e.g. "SEARCH t USING INDEX idx (a=?)" or "SCAN t USING COVERING INDEX idx".
+func planUsesIndex(plan, index string) bool {
+	for _, line := range strings.Split(plan, "\n") {
+		for _, marker := range []string{"USING INDEX ", "USING COVERING INDEX "} {
+			if i := strings.Index(line, marker); i >= 0 {
+				rest := line[i+len(marker):]
+				if rest == index || strings.HasPrefix(rest, index+" ") {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerIndexUsage(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`
+		CREATE TABLE events (id INTEGER PRIMARY KEY, kind TEXT, user_id TEXT, created_at TEXT);
+		CREATE INDEX idx_events_user ON events (user_id, created_at);
+		CREATE INDEX idx_events_user_only ON events (user_id);
+		CREATE INDEX idx_events_lower_kind ON events (lower(kind));
+	`)
+	require.NoError(t, err)
+	for i := 0; i < 200; i++ {
+		_, err := database.Exec(`INSERT INTO events (kind, user_id, created_at) VALUES ('click', ?, datetime('now'))`, i)
+		require.NoError(t, err)
+	}
+
+	r := chi.NewRouter()
+	r.Post("/maintenance/analyze", handler.handleAnalyze)
+	r.Get("/tables/{name}/indexes/usage", handler.handleIndexUsage)
+	do := func(method, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	usage := func() IndexUsageReport {
+		w := do("GET", "/tables/events/indexes/usage")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var report IndexUsageReport
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+		return report
+	}
+
+	report := usage()
+	assert.False(t, report.Analyzed)
+	require.Len(t, report.Indexes, 3)
+
+	w := do("POST", "/maintenance/analyze?table=events")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var analyzed map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&analyzed))
+	assert.EqualValues(t, 3, analyzed["indexes_analyzed"])
+	assert.Equal(t, http.StatusNotFound, do("POST", "/maintenance/analyze?table=missing").Code)
+	assert.Equal(t, http.StatusOK, do("POST", "/maintenance/analyze").Code)
+
+	report = usage()
+	assert.True(t, report.Analyzed)
+	byName := map[string]IndexUsage{}
+	for _, idx := range report.Indexes {
+		byName[idx.Name] = idx
+	}
+
+	composite := byName["idx_events_user"]
+	assert.Equal(t, []string{"user_id", "created_at"}, composite.Columns)
+	assert.Equal(t, "c", composite.Origin)
+	assert.True(t, composite.LikelyUsed, composite.Probes)
+	require.NotNil(t, composite.Stat)
+	assert.EqualValues(t, 200, composite.Stat.Rows)
+	assert.Len(t, composite.Probes, 3)
+
+	expr := byName["idx_events_lower_kind"]
+	assert.False(t, expr.LikelyUsed)
+	assert.Contains(t, expr.Reason, "expression")
+
+	assert.Equal(t, http.StatusNotFound, do("GET", "/tables/missing/indexes/usage").Code)
+}
+
+func TestPlanUsesIndex(t *testing.T) {
+	assert.True(t, planUsesIndex("SEARCH t USING INDEX idx_a (a=?)", "idx_a"))
+	assert.True(t, planUsesIndex("SCAN t USING COVERING INDEX idx_a", "idx_a"))
+	assert.False(t, planUsesIndex("SEARCH t USING INDEX idx_ab (a=?)", "idx_a"))
+	assert.False(t, planUsesIndex("SCAN t", "idx_a"))
+}
@@ -0,0 +1,83 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parseArrayFilterValue parses the operand of a cs/cd filter. It accepts a
+// PostgREST array literal ({a,b}), a JSON array (["a",1]) or a single value.
+// Elements that parse as JSON scalars keep their type so numbers match numbers.
+func parseArrayFilterValue(operand string) []interface{} {
+	operand = strings.TrimSpace(operand)
+	if strings.HasPrefix(operand, "[") {
+		var items []interface{}
+		if err := json.Unmarshal([]byte(operand), &items); err != nil {
+			return nil
+		}
+		return items
+	}
+
+	if strings.HasPrefix(operand, "{") && strings.HasSuffix(operand, "}") {
+		operand = operand[1 : len(operand)-1]
+	}
+	if operand == "" {
+		return nil
+	}
+	var items []interface{}
+	for _, raw := range strings.Split(operand, ",") {
+		raw = strings.TrimSpace(raw)
+		var scalar interface{}
+		if err := json.Unmarshal([]byte(raw), &scalar); err == nil {
+			switch scalar.(type) {
+			case float64, bool, string:
+				items = append(items, scalar)
+				continue
+			}
+		}
+		items = append(items, raw)
+	}
+	return items
+}
+
+// isArrayFilter reports whether a filter value uses the cs or cd operator.
+func isArrayFilter(val string) bool {
+	val = strings.TrimPrefix(val, "not.")
+	return strings.HasPrefix(val, "cs.") || strings.HasPrefix(val, "cd.")
+}
+
+// checkArrayFilters verifies that columns filtered with cs/cd hold JSON: either
+// they are registered as json/jsonb, or every stored value is a JSON array.
+func (h *Handler) checkArrayFilters(table string, query url.Values) error {
+	for key, vals := range query {
+		hasArrayFilter := false
+		for _, val := range vals {
+			if isArrayFilter(val) {
+				hasArrayFilter = true
+				break
+			}
+		}
+		if !hasArrayFilter {
+			continue
+		}
+
+		var declaredType string
+		if err := h.db.QueryRow(`SELECT type FROM pragma_table_info(?) WHERE name = ?`, table, key).Scan(&declaredType); err != nil {
+			return fmt.Errorf("column %q does not exist", key)
+		}
+		colType := strings.ToLower(h.columnType(table, key, declaredType))
+		if colType == "json" || colType == "jsonb" {
+			continue
+		}
+
+		var bad int
+		h.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM (SELECT 1 FROM "%s" WHERE "%s" IS NOT NULL
+			AND NOT (json_valid("%s") AND json_type("%s") = 'array') LIMIT 1)`, table, key, key, key)).Scan(&bad)
+		if bad > 0 {
+			return fmt.Errorf("column %q is not a JSON array column; cs and cd filters need JSON arrays", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArrayFilterValue(t *testing.T) {
+	assert.Equal(t, []interface{}{"a", "b"}, parseArrayFilterValue("{a,b}"))
+	assert.Equal(t, []interface{}{"a", float64(1)}, parseArrayFilterValue(`["a",1]`))
+	assert.Equal(t, []interface{}{float64(2), "x y"}, parseArrayFilterValue(`{2, "x y"}`))
+	assert.Equal(t, []interface{}{"go"}, parseArrayFilterValue("go"))
+	assert.Nil(t, parseArrayFilterValue("{}"))
+	assert.Nil(t, parseArrayFilterValue("[oops"))
+}
+
+func TestSelectDataArrayFilters(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, tags TEXT, title TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO posts VALUES
+		(1, '["go","sqlite"]', 'a'),
+		(2, '["go"]', 'b'),
+		(3, '["rust","sqlite"]', 'c'),
+		(4, NULL, 'd')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/data/{table}", handler.handleSelectData)
+
+	ids := func(filter string) []float64 {
+		req := httptest.NewRequest("GET", "/data/posts?tags="+url.QueryEscape(filter), nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		out := []float64{}
+		for _, row := range resp["rows"].([]interface{}) {
+			out = append(out, row.(map[string]interface{})["id"].(float64))
+		}
+		return out
+	}
+
+	assert.Equal(t, []float64{1, 3}, ids("cs.{sqlite}"))
+	assert.Equal(t, []float64{1}, ids(`cs.["go","sqlite"]`))
+	assert.Equal(t, []float64{2, 3}, ids("not.cs.{go,sqlite}"))
+	assert.Equal(t, []float64{1, 2}, ids("cd.{go,sqlite}"))
+
+	// Columns that do not hold JSON arrays are rejected
+	req := httptest.NewRequest("GET", "/data/posts?title=cs.{a}", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_filter")
+}
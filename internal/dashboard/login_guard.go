@@ -0,0 +1,196 @@
+package dashboard
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/markb/sblite/internal/log"
+)
+
+const (
+	// loginMaxFailures is how many failed logins an IP may make before it is
+	// locked out.
+	loginMaxFailures = 5
+	// loginGlobalMaxFailures is how many failed logins from all IPs together
+	// lock out every login, against guessing spread over many addresses.
+	loginGlobalMaxFailures = 100
+	// loginFailureWindow is how long failures are remembered; a source that
+	// stays quiet this long starts over.
+	loginFailureWindow = 15 * time.Minute
+	// loginBaseLockout is the first lockout; each further failure doubles it
+	// up to loginMaxLockout.
+	loginBaseLockout = 30 * time.Second
+	loginMaxLockout  = time.Hour
+)
+
+// loginAttempts tracks failed logins from one source.
+type loginAttempts struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+// loginGuard rate limits dashboard logins per client IP and globally, with
+// exponential lockouts once too many attempts fail.
+type loginGuard struct {
+	mu     sync.Mutex
+	perIP  map[string]*loginAttempts
+	global loginAttempts
+	now    func() time.Time
+}
+
+func newLoginGuard() *loginGuard {
+	return &loginGuard{perIP: make(map[string]*loginAttempts), now: time.Now}
+}
+
+// clientIP returns the request's remote IP. X-Forwarded-For is ignored since
+// clients can set it to dodge the per-IP limit.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// lockedFor returns how long logins from ip remain locked out, 0 if allowed.
+func (g *loginGuard) lockedFor(ip string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	wait := g.global.lockedUntil.Sub(now)
+	if a, ok := g.perIP[ip]; ok {
+		if d := a.lockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// recordFailure counts a failed login and returns the lockout it triggered
+// for ip, 0 if none.
+func (g *loginGuard) recordFailure(ip string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	g.prune(now)
+	a, ok := g.perIP[ip]
+	if !ok {
+		a = &loginAttempts{}
+		g.perIP[ip] = a
+	}
+	g.global.fail(now, loginGlobalMaxFailures)
+	return a.fail(now, loginMaxFailures)
+}
+
+// recordSuccess clears the failures of ip after a correct password.
+func (g *loginGuard) recordSuccess(ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.perIP, ip)
+}
+
+// prune forgets sources whose failures have expired. The caller holds g.mu.
+func (g *loginGuard) prune(now time.Time) {
+	for ip, a := range g.perIP {
+		if a.expired(now) {
+			delete(g.perIP, ip)
+		}
+	}
+	if g.global.expired(now) {
+		g.global = loginAttempts{}
+	}
+}
+
+// fail records a failure and, past max failures, locks the source out for a
+// period that doubles with each further failure.
+func (a *loginAttempts) fail(now time.Time, max int) time.Duration {
+	if a.expired(now) {
+		*a = loginAttempts{}
+	}
+	a.failures++
+	a.lastFailure = now
+	if a.failures < max {
+		return 0
+	}
+
+	lockout := loginMaxLockout
+	if shift := a.failures - max; shift < 32 {
+		if d := loginBaseLockout << shift; d > 0 && d < loginMaxLockout {
+			lockout = d
+		}
+	}
+	a.lockedUntil = now.Add(lockout)
+	return lockout
+}
+
+// expired reports whether the source has been quiet long enough to start
+// over. A running lockout always counts as recent.
+func (a *loginAttempts) expired(now time.Time) bool {
+	return now.After(a.lockedUntil) && now.Sub(a.lastFailure) > loginFailureWindow
+}
+
+// writeLoginLocked responds 429 with Retry-After in whole seconds.
+func writeLoginLocked(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	writeError(w, http.StatusTooManyRequests, "too_many_attempts", "Too many failed login attempts; try again later")
+}
+
+// checkLoginAllowed rejects a login attempt during a lockout. It returns
+// false when the response has been written.
+func (h *Handler) checkLoginAllowed(w http.ResponseWriter, r *http.Request) bool {
+	ip := clientIP(r)
+	if wait := h.loginGuard.lockedFor(ip); wait > 0 {
+		log.Warn("dashboard login rejected during lockout", "audit", true, "remote_addr", ip, "retry_after", wait.String())
+		writeLoginLocked(w, wait)
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure logs a failed login and answers 401, or 429 when the
+// failure triggered a lockout.
+func (h *Handler) recordLoginFailure(w http.ResponseWriter, r *http.Request) {
+	ip := clientIP(r)
+	lockout := h.loginGuard.recordFailure(ip)
+	if lockout > 0 {
+		log.Warn("dashboard login locked out after repeated failures", "audit", true, "remote_addr", ip, "lockout", lockout.String())
+		writeLoginLocked(w, lockout)
+		return
+	}
+	log.Warn("dashboard login failed", "audit", true, "remote_addr", ip)
+	writeError(w, http.StatusUnauthorized, "invalid_password", "Invalid password")
+}
+
+// verifyDashboardPassword checks the dashboard password repeated to confirm a
+// sensitive action such as revealing a secret. A wrong password counts as a
+// failed login, so the check can't be used to guess the password around the
+// login lockout. It returns false when the response has been written.
+func (h *Handler) verifyDashboardPassword(w http.ResponseWriter, r *http.Request, password, action string) bool {
+	ip := clientIP(r)
+	if wait := h.loginGuard.lockedFor(ip); wait > 0 {
+		log.Warn("dashboard password check rejected during lockout", "audit", true, "action", action, "remote_addr", ip, "retry_after", wait.String())
+		writeLoginLocked(w, wait)
+		return false
+	}
+	if h.auth.VerifyPassword(password) {
+		return true
+	}
+
+	lockout := h.loginGuard.recordFailure(ip)
+	log.Warn("dashboard password check failed", "audit", true, "action", action, "remote_addr", ip)
+	if lockout > 0 {
+		writeLoginLocked(w, lockout)
+		return false
+	}
+	writeError(w, http.StatusForbidden, "invalid_password", "The dashboard password is required to "+action)
+	return false
+}
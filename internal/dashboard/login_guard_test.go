@@ -0,0 +1,81 @@
+package dashboard
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginGuardLockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newLoginGuard()
+	g.now = func() time.Time { return now }
+
+	for i := 1; i < loginMaxFailures; i++ {
+		assert.Zero(t, g.recordFailure("10.0.0.1"))
+	}
+	assert.Equal(t, loginBaseLockout, g.recordFailure("10.0.0.1"))
+	assert.Equal(t, loginBaseLockout, g.lockedFor("10.0.0.1"))
+	assert.Zero(t, g.lockedFor("10.0.0.2"), "other IPs are unaffected")
+
+	// Each further failure doubles the lockout
+	now = now.Add(loginBaseLockout)
+	assert.Zero(t, g.lockedFor("10.0.0.1"))
+	assert.Equal(t, 2*loginBaseLockout, g.recordFailure("10.0.0.1"))
+
+	// Failures are forgotten after a quiet window
+	now = now.Add(2*loginBaseLockout + loginFailureWindow + time.Second)
+	assert.Zero(t, g.recordFailure("10.0.0.1"))
+
+	g.recordSuccess("10.0.0.1")
+	assert.Zero(t, g.lockedFor("10.0.0.1"))
+}
+
+func TestLoginGuardGlobalLockout(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := newLoginGuard()
+	g.now = func() time.Time { return now }
+
+	for i := 0; i < loginGlobalMaxFailures; i++ {
+		g.recordFailure(fmt.Sprintf("10.1.%d.%d", i/256, i%256))
+	}
+	assert.Equal(t, loginBaseLockout, g.lockedFor("192.168.1.1"))
+}
+
+func TestHandlerLoginRateLimited(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir()+"/migrations")
+	require.NoError(t, handler.auth.SetupPassword("testpassword123"))
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/auth/login", strings.NewReader(`{"password":"`+password+`"}`))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 1; i < loginMaxFailures; i++ {
+		assert.Equal(t, http.StatusUnauthorized, login("wrong").Code)
+	}
+	w := login("wrong")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// Even the right password is refused during the lockout
+	assert.Equal(t, http.StatusTooManyRequests, login("testpassword123").Code)
+
+	handler.loginGuard.now = func() time.Time { return time.Now().Add(loginBaseLockout) }
+	assert.Equal(t, http.StatusOK, login("testpassword123").Code)
+	assert.Equal(t, http.StatusUnauthorized, login("wrong").Code, "success resets the counter")
+}
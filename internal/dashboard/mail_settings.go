@@ -1,9 +1,12 @@
 package dashboard
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/markb/sblite/internal/mail"
 )
@@ -24,13 +27,23 @@ type MailConfig struct {
 	SMTPPort int
 	SMTPUser string
 	SMTPPass string
+
+	CatchRetention mail.Retention
+}
+
+// MailRetentionConfig limits how many caught emails are kept in catch mode.
+// Zero means no limit.
+type MailRetentionConfig struct {
+	MaxAgeHours int `json:"max_age_hours"`
+	MaxCount    int `json:"max_count"`
 }
 
 // MailSettingsResponse is returned by GET /settings/mail.
 type MailSettingsResponse struct {
-	Mode string         `json:"mode"`
-	From string         `json:"from"`
-	SMTP MailSMTPConfig `json:"smtp"`
+	Mode      string              `json:"mode"`
+	From      string              `json:"from"`
+	SMTP      MailSMTPConfig      `json:"smtp"`
+	Retention MailRetentionConfig `json:"retention"`
 }
 
 // MailSettingsUpdate is the request body for PATCH /settings/mail.
@@ -38,6 +51,55 @@ type MailSettingsUpdate struct {
 	Mode string          `json:"mode,omitempty"`
 	From string          `json:"from,omitempty"`
 	SMTP *MailSMTPConfig `json:"smtp,omitempty"`
+	// Retention replaces the caught email retention when set.
+	Retention *MailRetentionConfig `json:"retention,omitempty"`
+	// Force saves SMTP settings even if the connection test fails.
+	Force bool `json:"force,omitempty"`
+}
+
+// smtpTestTimeout bounds a live SMTP connection test.
+const smtpTestTimeout = 15 * time.Second
+
+// testSMTPConnection performs a live connect/TLS/auth check. It is a variable
+// so tests can replace it.
+var testSMTPConnection = func(ctx context.Context, cfg mail.SMTPConfig) error {
+	return cfg.TestConnection(ctx)
+}
+
+// smtpTestFailure builds the JSON body describing a failed SMTP test.
+func smtpTestFailure(err error) map[string]interface{} {
+	resp := map[string]interface{}{
+		"success": false,
+		"error":   err.Error(),
+	}
+	var testErr *mail.SMTPTestError
+	if errors.As(err, &testErr) {
+		resp["stage"] = testErr.Stage
+	}
+	return resp
+}
+
+// effectiveSMTPConfig merges an SMTP update over the stored settings.
+// A masked or empty password keeps the stored one.
+func (h *Handler) effectiveSMTPConfig(update *MailSMTPConfig) mail.SMTPConfig {
+	host, port, user, pass := h.GetSMTPConfig()
+	cfg := mail.SMTPConfig{Host: host, Port: port, User: user, Pass: pass}
+	if update == nil {
+		return cfg
+	}
+	if update.Host != "" {
+		cfg.Host = update.Host
+	}
+	if update.Port > 0 {
+		cfg.Port = update.Port
+	}
+	if update.User != "" {
+		cfg.User = update.User
+	}
+	if update.Password != "" && update.Password != "********" {
+		cfg.Pass = update.Password
+	}
+	return cfg
 }
 
 // handleGetMailSettings returns mail configuration.
@@ -55,7 +117,7 @@ func (h *Handler) handleGetMailSettings(w http.ResponseWriter, r *http.Request)
 	smtpHost, _ := h.store.Get("mail_smtp_host")
 	smtpPortStr, _ := h.store.Get("mail_smtp_port")
 	smtpUser, _ := h.store.Get("mail_smtp_user")
-	smtpPass, _ := h.store.Get("mail_smtp_password")
+	smtpPass := h.getSecretSetting("mail_smtp_password")
 
 	smtpPort := 587
 	if smtpPortStr != "" {
@@ -73,6 +135,7 @@ func (h *Handler) handleGetMailSettings(w http.ResponseWriter, r *http.Request)
 			User:     smtpUser,
 			Password: maskSecret(smtpPass),
 		},
+		Retention: h.mailRetentionConfig(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -84,16 +147,40 @@ func (h *Handler) handleGetMailSettings(w http.ResponseWriter, r *http.Request)
 func (h *Handler) handleUpdateMailSettings(w http.ResponseWriter, r *http.Request) {
 	var req MailSettingsUpdate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
 		return
 	}
 
-	// Update mode
+	// Validate mode
 	if req.Mode != "" {
 		if req.Mode != mail.ModeLog && req.Mode != mail.ModeCatch && req.Mode != mail.ModeSMTP {
-			http.Error(w, "mode must be 'log', 'catch', or 'smtp'", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, "invalid_setting", "mode must be 'log', 'catch', or 'smtp'")
 			return
 		}
+	}
+	if req.Retention != nil && (req.Retention.MaxAgeHours < 0 || req.Retention.MaxCount < 0) {
+		writeError(w, http.StatusBadRequest, "invalid_setting", "retention max_age_hours and max_count must not be negative")
+		return
+	}
+
+	// Verify the SMTP server accepts the new settings before saving them
+	force := req.Force || r.URL.Query().Get("force") == "true"
+	mode := req.Mode
+	if mode == "" {
+		mode = h.GetMailMode()
+	}
+	if mode == mail.ModeSMTP && (req.Mode == mail.ModeSMTP || req.SMTP != nil) && !force {
+		ctx, cancel := context.WithTimeout(r.Context(), smtpTestTimeout)
+		defer cancel()
+		if err := testSMTPConnection(ctx, h.effectiveSMTPConfig(req.SMTP)); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(smtpTestFailure(err))
+			return
+		}
+	}
+
+	if req.Mode != "" {
 		h.store.Set("mail_mode", req.Mode)
 	}
 
@@ -115,7 +202,19 @@ func (h *Handler) handleUpdateMailSettings(w http.ResponseWriter, r *http.Reques
 		}
 		// Only update password if not masked
 		if req.SMTP.Password != "" && req.SMTP.Password != "********" {
-			h.store.Set("mail_smtp_password", req.SMTP.Password)
+			if err := h.setSecretSetting("mail_smtp_password", req.SMTP.Password); err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+				return
+			}
+		}
+	}
+
+	if req.Retention != nil {
+		h.store.Set("mail_retention_max_age_hours", strconv.Itoa(req.Retention.MaxAgeHours))
+		h.store.Set("mail_retention_max_count", strconv.Itoa(req.Retention.MaxCount))
+		if h.catchMailer != nil {
+			h.catchMailer.SetRetention(h.GetMailRetention())
+			h.catchMailer.Prune()
 		}
 	}
 
@@ -123,7 +222,7 @@ func (h *Handler) handleUpdateMailSettings(w http.ResponseWriter, r *http.Reques
 	if h.onMailReload != nil {
 		cfg := h.buildMailConfig()
 		if err := h.onMailReload(cfg); err != nil {
-			http.Error(w, "failed to reload mail: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "reload_failed", "failed to reload mail: "+err.Error())
 			return
 		}
 	}
@@ -132,6 +231,40 @@ func (h *Handler) handleUpdateMailSettings(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
 }
 
+// handleTestMailConnection tests SMTP settings without saving them.
+// POST /_/api/settings/mail/test
+func (h *Handler) handleTestMailConnection(w http.ResponseWriter, r *http.Request) {
+	var req MailSMTPConfig
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+
+	cfg := h.effectiveSMTPConfig(&req)
+	if cfg.Host == "" {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"error":   "host is required",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), smtpTestTimeout)
+	defer cancel()
+
+	if err := testSMTPConnection(ctx, cfg); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(smtpTestFailure(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
 // buildMailConfig creates a MailConfig from dashboard settings.
 func (h *Handler) buildMailConfig() *MailConfig {
 	mode, _ := h.store.Get("mail_mode")
@@ -146,7 +279,7 @@ func (h *Handler) buildMailConfig() *MailConfig {
 	smtpHost, _ := h.store.Get("mail_smtp_host")
 	smtpPortStr, _ := h.store.Get("mail_smtp_port")
 	smtpUser, _ := h.store.Get("mail_smtp_user")
-	smtpPass, _ := h.store.Get("mail_smtp_password")
+	smtpPass := h.getSecretSetting("mail_smtp_password")
 
 	smtpPort := 587
 	if smtpPortStr != "" {
@@ -162,6 +295,29 @@ func (h *Handler) buildMailConfig() *MailConfig {
 		SMTPPort: smtpPort,
 		SMTPUser: smtpUser,
 		SMTPPass: smtpPass,
+
+		CatchRetention: h.GetMailRetention(),
+	}
+}
+
+// mailRetentionConfig returns the stored caught email retention.
+func (h *Handler) mailRetentionConfig() MailRetentionConfig {
+	var cfg MailRetentionConfig
+	if v, _ := h.store.Get("mail_retention_max_age_hours"); v != "" {
+		cfg.MaxAgeHours, _ = strconv.Atoi(v)
+	}
+	if v, _ := h.store.Get("mail_retention_max_count"); v != "" {
+		cfg.MaxCount, _ = strconv.Atoi(v)
+	}
+	return cfg
+}
+
+// GetMailRetention returns the caught email retention from the store.
+func (h *Handler) GetMailRetention() mail.Retention {
+	cfg := h.mailRetentionConfig()
+	return mail.Retention{
+		MaxAge:   time.Duration(cfg.MaxAgeHours) * time.Hour,
+		MaxCount: cfg.MaxCount,
 	}
 }
 
@@ -188,7 +344,7 @@ func (h *Handler) GetSMTPConfig() (host string, port int, user string, pass stri
 	host, _ = h.store.Get("mail_smtp_host")
 	portStr, _ := h.store.Get("mail_smtp_port")
 	user, _ = h.store.Get("mail_smtp_user")
-	pass, _ = h.store.Get("mail_smtp_password")
+	pass = h.getSecretSetting("mail_smtp_password")
 
 	port = 587
 	if portStr != "" {
@@ -2,10 +2,13 @@ package dashboard
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/markb/sblite/internal/mail"
@@ -88,6 +91,8 @@ func TestUpdateMailSettings_SMTPConfig(t *testing.T) {
 	database := setupTestDB(t)
 	defer database.Close()
 
+	stubSMTPConnectionTest(t, nil)
+
 	handler := NewHandler(database.DB, "")
 	handler.SetMailReloadFunc(func(cfg *MailConfig) error { return nil })
 
@@ -125,3 +130,151 @@ func TestUpdateMailSettings_SMTPConfig(t *testing.T) {
 	assert.Equal(t, "user@example.com", resp.SMTP.User)
 	assert.Equal(t, "********", resp.SMTP.Password) // Should be masked
 }
+
+// stubSMTPConnectionTest replaces the live SMTP test with one returning err.
+func stubSMTPConnectionTest(t *testing.T, err error) *[]mail.SMTPConfig {
+	t.Helper()
+	var tested []mail.SMTPConfig
+	orig := testSMTPConnection
+	testSMTPConnection = func(ctx context.Context, cfg mail.SMTPConfig) error {
+		tested = append(tested, cfg)
+		return err
+	}
+	t.Cleanup(func() { testSMTPConnection = orig })
+	return &tested
+}
+
+func TestUpdateMailSettings_SMTPTestFailureNotPersisted(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	stubSMTPConnectionTest(t, &mail.SMTPTestError{Stage: mail.StageAuth, Err: errors.New("SMTP auth failed: 535")})
+
+	handler := NewHandler(database.DB, "")
+	reloadCalled := false
+	handler.SetMailReloadFunc(func(cfg *MailConfig) error {
+		reloadCalled = true
+		return nil
+	})
+
+	r := chi.NewRouter()
+	r.Patch("/settings/mail", handler.handleUpdateMailSettings)
+
+	body := `{"mode": "smtp", "smtp": {"host": "smtp.example.com", "port": 587, "user": "u", "password": "bad"}}`
+	req := httptest.NewRequest("PATCH", "/settings/mail", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "auth", resp["stage"])
+	assert.Contains(t, resp["error"], "auth failed")
+
+	assert.False(t, reloadCalled)
+	assert.Equal(t, mail.ModeLog, handler.GetMailMode())
+	host, _, _, _ := handler.GetSMTPConfig()
+	assert.Empty(t, host)
+
+	// force=true saves despite the failure
+	req = httptest.NewRequest("PATCH", "/settings/mail?force=true", bytes.NewBufferString(body))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, reloadCalled)
+	assert.Equal(t, mail.ModeSMTP, handler.GetMailMode())
+}
+
+func TestUpdateMailSettings_SMTPTestUsesStoredPassword(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	tested := stubSMTPConnectionTest(t, nil)
+
+	handler := NewHandler(database.DB, "")
+	handler.store.Set("mail_smtp_host", "smtp.example.com")
+	handler.store.Set("mail_smtp_password", "stored-pass")
+
+	r := chi.NewRouter()
+	r.Patch("/settings/mail", handler.handleUpdateMailSettings)
+	r.Post("/settings/mail/test", handler.handleTestMailConnection)
+
+	body := `{"mode": "smtp", "smtp": {"user": "u", "password": "********"}}`
+	req := httptest.NewRequest("PATCH", "/settings/mail", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	require.Len(t, *tested, 1)
+	assert.Equal(t, "smtp.example.com", (*tested)[0].Host)
+	assert.Equal(t, "stored-pass", (*tested)[0].Pass)
+
+	// Switching to catch mode does not test SMTP
+	req = httptest.NewRequest("PATCH", "/settings/mail", bytes.NewBufferString(`{"mode": "catch"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, *tested, 1)
+
+	req = httptest.NewRequest("POST", "/settings/mail/test", bytes.NewBufferString(`{"port": 2525}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, true, resp["success"])
+	assert.Equal(t, 2525, (*tested)[1].Port)
+}
+
+func TestUpdateMailSettings_Retention(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	catcher := mail.NewCatchMailer(database)
+	handler.SetCatchMailer(catcher)
+	for i := 0; i < 3; i++ {
+		msg := &mail.Message{To: "user@example.com", From: "noreply@example.com", Subject: "Hi", BodyText: "Hi", Type: mail.TypeConfirmation}
+		require.NoError(t, catcher.Send(context.Background(), msg))
+	}
+
+	var reloadedConfig *MailConfig
+	handler.SetMailReloadFunc(func(cfg *MailConfig) error {
+		reloadedConfig = cfg
+		return nil
+	})
+
+	r := chi.NewRouter()
+	r.Get("/settings/mail", handler.handleGetMailSettings)
+	r.Patch("/settings/mail", handler.handleUpdateMailSettings)
+	r.Get("/mail/status", handler.handleMailStatus)
+	patch := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/settings/mail", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, patch(`{"retention": {"max_count": -1}}`).Code)
+
+	w := patch(`{"retention": {"max_age_hours": 72, "max_count": 2}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, mail.Retention{MaxAge: 72 * time.Hour, MaxCount: 2}, reloadedConfig.CatchRetention)
+
+	req := httptest.NewRequest("GET", "/settings/mail", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var settings MailSettingsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&settings))
+	assert.Equal(t, MailRetentionConfig{MaxAgeHours: 72, MaxCount: 2}, settings.Retention)
+
+	// The new limit applies right away
+	req = httptest.NewRequest("GET", "/mail/status", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var status map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&status))
+	assert.Equal(t, true, status["enabled"])
+	assert.EqualValues(t, 2, status["count"])
+	assert.NotEmpty(t, status["oldest_at"])
+}
@@ -0,0 +1,345 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxCheckDetailRows caps how many offending rows are reported per check.
+const maxCheckDetailRows = 10
+
+// IntegrityCheck is a user-defined assertion query stored in _integrity_checks.
+// The query must be a SELECT that returns zero rows when the data is healthy.
+type IntegrityCheck struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Query       string `json:"query"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// IntegrityCheckResult is the outcome of a single check within a run.
+type IntegrityCheckResult struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"` // "builtin" or "assertion"
+	Passed  bool     `json:"passed"`
+	Details []string `json:"details,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// IntegrityCheckRun is the response of POST /maintenance/integrity-check.
+type IntegrityCheckRun struct {
+	RunID  string                 `json:"run_id"`
+	RunAt  string                 `json:"run_at"`
+	Passed bool                   `json:"passed"`
+	Checks []IntegrityCheckResult `json:"checks"`
+}
+
+// handleRunIntegrityCheck runs the built-in SQLite integrity checks plus all
+// user-defined assertions, records the results and returns pass/fail per check.
+// POST /_/api/maintenance/integrity-check
+func (h *Handler) handleRunIntegrityCheck(w http.ResponseWriter, r *http.Request) {
+	run := IntegrityCheckRun{
+		RunID:  uuid.New().String(),
+		RunAt:  time.Now().UTC().Format(time.RFC3339),
+		Passed: true,
+	}
+
+	run.Checks = append(run.Checks, h.runPragmaIntegrityCheck(), h.runForeignKeyCheck())
+
+	checks, err := h.listIntegrityChecks()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to load integrity checks")
+		return
+	}
+	for _, check := range checks {
+		run.Checks = append(run.Checks, h.runAssertion(check))
+	}
+
+	for _, result := range run.Checks {
+		if !result.Passed {
+			run.Passed = false
+		}
+		details := strings.Join(result.Details, "\n")
+		if result.Error != "" {
+			details = result.Error
+		}
+		h.db.Exec(`INSERT INTO _integrity_check_results (id, run_id, check_name, passed, details, run_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			uuid.New().String(), run.RunID, result.Name, result.Passed, details, run.RunAt)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// runPragmaIntegrityCheck runs PRAGMA integrity_check, which reports "ok" when healthy.
+func (h *Handler) runPragmaIntegrityCheck() IntegrityCheckResult {
+	result := IntegrityCheckResult{Name: "integrity_check", Type: "builtin"}
+
+	rows, err := h.db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			continue
+		}
+		if msg != "ok" {
+			result.Details = append(result.Details, msg)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Passed = len(result.Details) == 0
+	return result
+}
+
+// runForeignKeyCheck runs PRAGMA foreign_key_check, which returns one row per violation.
+func (h *Handler) runForeignKeyCheck() IntegrityCheckResult {
+	result := IntegrityCheckResult{Name: "foreign_key_check", Type: "builtin"}
+
+	rows, err := h.db.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer rows.Close()
+
+	violations := 0
+	for rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			continue
+		}
+		violations++
+		if len(result.Details) < maxCheckDetailRows {
+			result.Details = append(result.Details, fmt.Sprintf("%s rowid %d references missing row in %s", table, rowid.Int64, parent))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if violations > maxCheckDetailRows {
+		result.Details = append(result.Details, fmt.Sprintf("... and %d more", violations-maxCheckDetailRows))
+	}
+	result.Passed = violations == 0
+	return result
+}
+
+// runAssertion executes a user-defined assertion inside a transaction that is
+// always rolled back, so a check can never modify data.
+func (h *Handler) runAssertion(check IntegrityCheck) IntegrityCheckResult {
+	result := IntegrityCheckResult{Name: check.Name, Type: "assertion"}
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(check.Query)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+	count := 0
+	for rows.Next() {
+		count++
+		if count > maxCheckDetailRows {
+			continue
+		}
+		values := make([]interface{}, len(columns))
+		valuePtrs := make([]interface{}, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			continue
+		}
+		row := make(map[string]interface{})
+		for i, col := range columns {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		encoded, _ := json.Marshal(row)
+		result.Details = append(result.Details, string(encoded))
+	}
+	if err := rows.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if count > maxCheckDetailRows {
+		result.Details = append(result.Details, fmt.Sprintf("... and %d more", count-maxCheckDetailRows))
+	}
+	result.Passed = count == 0
+	return result
+}
+
+// listIntegrityChecks returns all user-defined assertions ordered by name.
+func (h *Handler) listIntegrityChecks() ([]IntegrityCheck, error) {
+	rows, err := h.db.Query(`SELECT id, name, query, COALESCE(description, ''), created_at FROM _integrity_checks ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := []IntegrityCheck{}
+	for rows.Next() {
+		var c IntegrityCheck
+		if err := rows.Scan(&c.ID, &c.Name, &c.Query, &c.Description, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+// handleListIntegrityChecks returns the user-defined assertions.
+// GET /_/api/maintenance/integrity-checks
+func (h *Handler) handleListIntegrityChecks(w http.ResponseWriter, r *http.Request) {
+	checks, err := h.listIntegrityChecks()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list integrity checks")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checks)
+}
+
+// handleCreateIntegrityCheck stores a new assertion query.
+// POST /_/api/maintenance/integrity-checks
+func (h *Handler) handleCreateIntegrityCheck(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string `json:"name"`
+		Query       string `json:"query"`
+		Description string `json:"description"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Name == "" || req.Query == "" {
+		writeError(w, http.StatusBadRequest, "missing_field", "Name and query required")
+		return
+	}
+
+	upper := strings.ToUpper(req.Query)
+	if !strings.HasPrefix(upper, "SELECT") && !strings.HasPrefix(upper, "WITH") {
+		writeError(w, http.StatusBadRequest, "read_only_query", "Query must be a SELECT statement")
+		return
+	}
+
+	check := IntegrityCheck{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Query:       req.Query,
+		Description: req.Description,
+		CreatedAt:   time.Now().UTC().Format("2006-01-02 15:04:05"),
+	}
+	_, err := h.db.Exec(`INSERT INTO _integrity_checks (id, name, query, description, created_at) VALUES (?, ?, ?, ?, ?)`,
+		check.ID, check.Name, check.Query, check.Description, check.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			writeError(w, http.StatusConflict, "integrity_check_exists", "Integrity check already exists")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to create integrity check")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(check)
+}
+
+// handleDeleteIntegrityCheck removes an assertion query by name.
+// DELETE /_/api/maintenance/integrity-checks/{name}
+func (h *Handler) handleDeleteIntegrityCheck(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	result, err := h.db.Exec(`DELETE FROM _integrity_checks WHERE name = ?`, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to delete integrity check")
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		writeError(w, http.StatusNotFound, "integrity_check_not_found", "Integrity check not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListIntegrityCheckResults returns recorded check results, newest first.
+// GET /_/api/maintenance/integrity-check/results
+func (h *Handler) handleListIntegrityCheckResults(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 1000 {
+			limit = parsed
+		}
+	}
+
+	query := `SELECT run_id, check_name, passed, COALESCE(details, ''), run_at FROM _integrity_check_results`
+	var args []interface{}
+	if runID := r.URL.Query().Get("run_id"); runID != "" {
+		query += ` WHERE run_id = ?`
+		args = append(args, runID)
+	}
+	query += ` ORDER BY run_at DESC, check_name LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list results")
+		return
+	}
+	defer rows.Close()
+
+	results := []map[string]interface{}{}
+	for rows.Next() {
+		var runID, checkName, details, runAt string
+		var passed bool
+		if err := rows.Scan(&runID, &checkName, &passed, &details, &runAt); err != nil {
+			continue
+		}
+		results = append(results, map[string]interface{}{
+			"run_id":     runID,
+			"check_name": checkName,
+			"passed":     passed,
+			"details":    details,
+			"run_at":     runAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
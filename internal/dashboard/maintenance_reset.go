@@ -0,0 +1,188 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/markb/sblite/internal/storage"
+)
+
+// resetConfirmation must be typed to confirm a factory reset.
+const resetConfirmation = "RESET"
+
+// resetMetadataTables hold per-table metadata, sequences, policies and RPC
+// functions, all of which a reset clears. Auth, dashboard settings,
+// migration history and integrity checks are left alone.
+var resetMetadataTables = []string{
+	"_columns",
+	"_table_descriptions",
+	"_fts_indexes",
+	"_rls_suspended_policies",
+	"_rls_policies",
+	"_rls_tables",
+	"_rpc_function_args",
+	"_rpc_functions",
+	"_sequences",
+}
+
+// ResetSummary lists what a factory reset removed.
+type ResetSummary struct {
+	Tables       []string `json:"tables"`
+	Views        []string `json:"views"`
+	Policies     int      `json:"policies"`
+	RPCFunctions int      `json:"rpc_functions"`
+	Buckets      []string `json:"buckets"`
+	Objects      int      `json:"objects"`
+	Functions    []string `json:"functions"`
+}
+
+// handleFactoryReset wipes a dev instance back to empty: it drops all user
+// tables and views, clears their metadata, policies and RPC functions,
+// deletes every storage bucket with its objects and, with
+// remove_functions, deletes the edge functions. The dashboard password,
+// auth users and server settings are kept. The body must carry
+// confirmation "RESET".
+// POST /_/api/maintenance/reset
+func (h *Handler) handleFactoryReset(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Confirmation    string `json:"confirmation"`
+		RemoveFunctions bool   `json:"remove_functions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
+		return
+	}
+	if req.Confirmation != resetConfirmation {
+		writeError(w, http.StatusBadRequest, "confirmation_required", "Please type RESET to confirm")
+		return
+	}
+
+	summary := ResetSummary{Tables: []string{}, Views: []string{}, Buckets: []string{}, Functions: []string{}}
+	err := h.runWrite(r, func() error {
+		summary.Tables, summary.Views = []string{}, []string{}
+		tx, err := h.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		// Foreign keys between the dropped tables are only checked at commit
+		if _, err := tx.Exec(`PRAGMA defer_foreign_keys = ON`); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM _rls_policies`).Scan(&summary.Policies); err != nil {
+			return err
+		}
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM _rpc_functions`).Scan(&summary.RPCFunctions); err != nil {
+			return err
+		}
+
+		// Views go first since they depend on tables, then FTS virtual tables,
+		// which take their shadow tables with them
+		if summary.Views, err = dropUserObjects(tx, "view", `type = 'view'`); err != nil {
+			return err
+		}
+		if _, err := dropUserObjects(tx, "table", `type = 'table' AND sql LIKE 'CREATE VIRTUAL TABLE%'`); err != nil {
+			return err
+		}
+		if summary.Tables, err = dropUserObjects(tx, "table", `type = 'table'`); err != nil {
+			return err
+		}
+
+		for _, table := range resetMetadataTables {
+			if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s`, table)); err != nil {
+				return fmt.Errorf("failed to clear %s: %w", table, err)
+			}
+		}
+		if req.RemoveFunctions {
+			for _, table := range []string{"_functions_metadata", "_function_descriptions"} {
+				if _, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s`, table)); err != nil {
+					return fmt.Errorf("failed to clear %s: %w", table, err)
+				}
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if h.storageService != nil {
+		h.db.QueryRow(`SELECT COUNT(*) FROM storage_objects`).Scan(&summary.Objects)
+		for {
+			buckets, _, err := h.storageService.ListBuckets(storage.ListBucketsRequest{})
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list buckets: "+err.Error())
+				return
+			}
+			if len(buckets) == 0 {
+				break
+			}
+			for _, b := range buckets {
+				if err := h.storageService.DeleteBucket(b.ID, true); err != nil {
+					writeError(w, http.StatusInternalServerError, "internal_error", "Failed to delete bucket "+b.Name+": "+err.Error())
+					return
+				}
+				summary.Buckets = append(summary.Buckets, b.Name)
+			}
+		}
+	}
+
+	if req.RemoveFunctions && h.functionsService != nil {
+		funcs, err := h.functionsService.ListFunctions()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return
+		}
+		for _, fn := range funcs {
+			if err := h.functionsService.DeleteFunction(fn.Name); err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+				return
+			}
+			summary.Functions = append(summary.Functions, fn.Name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// dropUserObjects drops the user tables or views matching cond, using the
+// same name filter as userTables, and returns their names.
+func dropUserObjects(tx *sql.Tx, kind, cond string) ([]string, error) {
+	rows, err := tx.Query(`
+		SELECT name FROM sqlite_master
+		WHERE ` + cond + `
+		AND name NOT LIKE '\_%' ESCAPE '\'
+		AND name NOT LIKE 'auth\_%' ESCAPE '\'
+		AND name NOT LIKE 'storage\_%' ESCAPE '\'
+		AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if _, err := tx.Exec(fmt.Sprintf(`DROP %s IF EXISTS "%s"`, kind, name)); err != nil {
+			return nil, fmt.Errorf("failed to drop %s %s: %w", kind, name, err)
+		}
+	}
+	return names, nil
+}
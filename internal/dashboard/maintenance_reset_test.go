@@ -0,0 +1,85 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactoryReset(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	svc, err := storage.NewService(database.DB, storage.Config{LocalPath: t.TempDir()})
+	require.NoError(t, err)
+	handler.SetStorageService(svc)
+	require.NoError(t, handler.auth.SetupPassword("testpassword123"))
+
+	for _, stmt := range []string{
+		`PRAGMA foreign_keys = ON`,
+		`CREATE TABLE authors (id INTEGER PRIMARY KEY, name TEXT)`,
+		`CREATE TABLE books (id INTEGER PRIMARY KEY, author_id INTEGER REFERENCES authors(id))`,
+		`INSERT INTO authors (id, name) VALUES (1, 'Ann')`,
+		`INSERT INTO books (id, author_id) VALUES (1, 1)`,
+		`CREATE VIEW author_books AS SELECT a.name FROM authors a JOIN books b ON b.author_id = a.id`,
+		`CREATE VIRTUAL TABLE authors_fts_name USING fts5(name, content='authors', content_rowid='id')`,
+		`INSERT INTO _fts_indexes (table_name, index_name, columns) VALUES ('authors', 'name', '["name"]')`,
+		`INSERT INTO _columns (table_name, column_name, pg_type) VALUES ('authors', 'name', 'text')`,
+		`INSERT INTO _rls_tables (table_name, enabled) VALUES ('authors', 1)`,
+		`INSERT INTO _rls_policies (table_name, policy_name, command, using_expr) VALUES ('authors', 'read', 'SELECT', 'true')`,
+	} {
+		_, err := database.Exec(stmt)
+		require.NoError(t, err, stmt)
+	}
+	_, err = svc.CreateBucket(storage.CreateBucketRequest{Name: "media"}, "")
+	require.NoError(t, err)
+	_, err = svc.UploadObject("media", "a.txt", strings.NewReader("hi"), 2, "text/plain", "", false)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/maintenance/reset", handler.handleFactoryReset)
+	reset := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/maintenance/reset", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := reset(`{"confirmation": "reset"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	tables, err := handler.userTables()
+	require.NoError(t, err)
+	assert.Contains(t, tables, "authors")
+
+	w = reset(`{"confirmation": "RESET"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var summary ResetSummary
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+	assert.Equal(t, []string{"authors", "books"}, summary.Tables)
+	assert.Equal(t, []string{"author_books"}, summary.Views)
+	assert.Equal(t, 1, summary.Policies)
+	assert.Equal(t, []string{"media"}, summary.Buckets)
+	assert.Equal(t, 1, summary.Objects)
+
+	tables, err = handler.userTables()
+	require.NoError(t, err)
+	assert.Empty(t, tables)
+	for _, table := range []string{"_columns", "_rls_policies", "_rls_tables", "_fts_indexes", "storage_buckets"} {
+		var count int
+		require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM `+table).Scan(&count))
+		assert.Zero(t, count, table)
+	}
+
+	// The dashboard login survives
+	assert.True(t, handler.auth.VerifyPassword("testpassword123"))
+}
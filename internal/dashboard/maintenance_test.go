@@ -0,0 +1,73 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrityCheckRun(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, price INTEGER)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO items (id, price) VALUES (1, 10), (2, -5)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/maintenance/integrity-checks", handler.handleCreateIntegrityCheck)
+	r.Post("/maintenance/integrity-check", handler.handleRunIntegrityCheck)
+
+	body := `{"name": "no_negative_prices", "query": "SELECT id FROM items WHERE price < 0"}`
+	req := httptest.NewRequest("POST", "/maintenance/integrity-checks", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	req = httptest.NewRequest("POST", "/maintenance/integrity-check", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var run IntegrityCheckRun
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&run))
+	assert.False(t, run.Passed)
+	require.Len(t, run.Checks, 3)
+
+	byName := make(map[string]IntegrityCheckResult)
+	for _, c := range run.Checks {
+		byName[c.Name] = c
+	}
+	assert.True(t, byName["integrity_check"].Passed)
+	assert.True(t, byName["foreign_key_check"].Passed)
+	assert.False(t, byName["no_negative_prices"].Passed)
+	assert.Equal(t, []string{`{"id":2}`}, byName["no_negative_prices"].Details)
+
+	var recorded int
+	err = database.QueryRow(`SELECT COUNT(*) FROM _integrity_check_results WHERE run_id = ?`, run.RunID).Scan(&recorded)
+	require.NoError(t, err)
+	assert.Equal(t, 3, recorded)
+}
+
+func TestCreateIntegrityCheckRejectsNonSelect(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	r := chi.NewRouter()
+	r.Post("/maintenance/integrity-checks", handler.handleCreateIntegrityCheck)
+
+	body := `{"name": "bad", "query": "DELETE FROM items"}`
+	req := httptest.NewRequest("POST", "/maintenance/integrity-checks", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
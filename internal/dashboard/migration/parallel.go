@@ -0,0 +1,180 @@
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+const (
+	// DefaultDataWorkers is the number of tables copied concurrently when no worker count is given.
+	DefaultDataWorkers = 1
+	// MaxDataWorkers caps the number of concurrent table copies.
+	MaxDataWorkers = 16
+)
+
+// RunOptions configures a migration run.
+type RunOptions struct {
+	// Workers is the number of table data copies run in parallel.
+	// Tables are still copied after the tables they reference.
+	Workers int `json:"workers"`
+}
+
+// normalizedWorkers returns the worker count clamped to [1, MaxDataWorkers].
+func (o RunOptions) normalizedWorkers() int {
+	switch {
+	case o.Workers < 1:
+		return DefaultDataWorkers
+	case o.Workers > MaxDataWorkers:
+		return MaxDataWorkers
+	}
+	return o.Workers
+}
+
+// dataDependencyLevels groups tables into levels so every table comes after the
+// tables it references through foreign keys. Tables in the same level are
+// independent and may be copied concurrently. References to tables outside the
+// selection are ignored; tables in a reference cycle share the last level.
+func dataDependencyLevels(db *sql.DB, tables []string) ([][]string, error) {
+	selected := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		selected[t] = true
+	}
+
+	deps := make(map[string]map[string]bool, len(tables))
+	for _, t := range tables {
+		rows, err := db.Query(`SELECT "table" FROM pragma_foreign_key_list(?)`, t)
+		if err != nil {
+			return nil, fmt.Errorf("foreign keys of %s: %w", t, err)
+		}
+		deps[t] = make(map[string]bool)
+		for rows.Next() {
+			var ref string
+			if err := rows.Scan(&ref); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if ref != t && selected[ref] {
+				deps[t][ref] = true
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	var levels [][]string
+	done := make(map[string]bool, len(tables))
+	for len(done) < len(tables) {
+		var level []string
+		for _, t := range tables {
+			if done[t] {
+				continue
+			}
+			ready := true
+			for ref := range deps[t] {
+				if !done[ref] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				level = append(level, t)
+			}
+		}
+		if len(level) == 0 {
+			// Cycle: copy the remaining tables together
+			for _, t := range tables {
+				if !done[t] {
+					level = append(level, t)
+				}
+			}
+		}
+		sort.Strings(level)
+		for _, t := range level {
+			done[t] = true
+		}
+		levels = append(levels, level)
+	}
+
+	return levels, nil
+}
+
+// runDataItems runs fn for every data item, level by level, with up to workers
+// items of the same level in flight. It reports whether any item failed.
+func runDataItems(levels [][]*MigrationItem, workers int, fn func(*MigrationItem) error) bool {
+	var mu sync.Mutex
+	hasFailures := false
+
+	for _, level := range levels {
+		sem := make(chan struct{}, workers)
+		var wg sync.WaitGroup
+		for _, item := range level {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(item *MigrationItem) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if err := fn(item); err != nil {
+					mu.Lock()
+					hasFailures = true
+					mu.Unlock()
+				}
+			}(item)
+		}
+		wg.Wait()
+	}
+
+	return hasFailures
+}
+
+// migrateDataItems copies the pending data items, parallelizing independent
+// tables over a shared Postgres connection pool.
+func (s *Service) migrateDataItems(m *Migration, items []*MigrationItem, opts RunOptions) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	workers := opts.normalizedWorkers()
+	byTable := make(map[string]*MigrationItem, len(items))
+	tables := make([]string, 0, len(items))
+	for _, item := range items {
+		byTable[item.ItemName] = item
+		tables = append(tables, item.ItemName)
+	}
+
+	tableLevels, err := dataDependencyLevels(s.db, tables)
+	if err != nil {
+		// Fall back to the selection order, one table at a time
+		tableLevels = make([][]string, len(tables))
+		for i, t := range tables {
+			tableLevels[i] = []string{t}
+		}
+	}
+	levels := make([][]*MigrationItem, len(tableLevels))
+	for i, level := range tableLevels {
+		for _, t := range level {
+			levels[i] = append(levels[i], byTable[t])
+		}
+	}
+
+	pgDB, err := s.getPostgresConnection(m)
+	if err != nil {
+		for _, item := range items {
+			s.markItemFailed(item, fmt.Errorf("connect to postgres: %w", err))
+		}
+		return true
+	}
+	defer pgDB.Close()
+	pgDB.SetMaxOpenConns(workers)
+
+	return runDataItems(levels, workers, func(item *MigrationItem) error {
+		// Tables not started before a shutdown stay pending
+		if s.isDraining() {
+			return nil
+		}
+		return s.migrateDataWith(pgDB, item)
+	})
+}
@@ -0,0 +1,100 @@
+package migration
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunOptionsNormalizedWorkers(t *testing.T) {
+	tests := map[int]int{0: DefaultDataWorkers, -3: DefaultDataWorkers, 4: 4, 1000: MaxDataWorkers}
+	for in, want := range tests {
+		if got := (RunOptions{Workers: in}).normalizedWorkers(); got != want {
+			t.Errorf("normalizedWorkers(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
+
+func TestDataDependencyLevels(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY);
+		CREATE TABLE tags (id INTEGER PRIMARY KEY);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id));
+		CREATE TABLE comments (id INTEGER PRIMARY KEY, post_id INTEGER REFERENCES posts(id), parent_id INTEGER REFERENCES comments(id));
+		CREATE TABLE audit (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users(id));
+	`)
+	if err != nil {
+		t.Fatalf("failed to create tables: %v", err)
+	}
+
+	levels, err := dataDependencyLevels(db, []string{"comments", "posts", "users", "tags", "audit"})
+	if err != nil {
+		t.Fatalf("dataDependencyLevels failed: %v", err)
+	}
+
+	want := [][]string{{"tags", "users"}, {"audit", "posts"}, {"comments"}}
+	if !reflect.DeepEqual(levels, want) {
+		t.Errorf("got levels %v, want %v", levels, want)
+	}
+
+	// References to unselected tables are ignored
+	levels, err = dataDependencyLevels(db, []string{"comments"})
+	if err != nil {
+		t.Fatalf("dataDependencyLevels failed: %v", err)
+	}
+	if !reflect.DeepEqual(levels, [][]string{{"comments"}}) {
+		t.Errorf("got levels %v", levels)
+	}
+}
+
+func TestRunDataItemsRespectsLevelsAndWorkers(t *testing.T) {
+	levels := [][]*MigrationItem{
+		{{ItemName: "a"}, {ItemName: "b"}, {ItemName: "c"}, {ItemName: "d"}},
+		{{ItemName: "e"}},
+	}
+
+	var inFlight, maxInFlight atomic.Int32
+	var mu sync.Mutex
+	finished := map[string]bool{}
+
+	failed := runDataItems(levels, 2, func(item *MigrationItem) error {
+		n := inFlight.Add(1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if item.ItemName == "e" {
+			for _, name := range []string{"a", "b", "c", "d"} {
+				if !finished[name] {
+					t.Errorf("e started before %s finished", name)
+				}
+			}
+			return errors.New("boom")
+		}
+		finished[item.ItemName] = true
+		return nil
+	})
+
+	if !failed {
+		t.Error("expected failure to be reported")
+	}
+	if maxInFlight.Load() > 2 {
+		t.Errorf("expected at most 2 concurrent items, got %d", maxInFlight.Load())
+	}
+	if maxInFlight.Load() < 2 {
+		t.Errorf("expected items to run concurrently, max in flight %d", maxInFlight.Load())
+	}
+}
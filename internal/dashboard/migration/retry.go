@@ -0,0 +1,113 @@
+package migration
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/markb/sblite/internal/log"
+)
+
+// remoteMaxAttempts is how many times a Supabase API or Postgres call is
+// attempted before a transient failure is returned.
+const remoteMaxAttempts = 4
+
+// remoteRetryDelay is the base delay between remote call attempts; it doubles
+// after each failure and is jittered. It is a variable so tests can shorten it.
+var remoteRetryDelay = 500 * time.Millisecond
+
+// retriableStatusError is a Supabase API response worth retrying.
+type retriableStatusError struct {
+	status int
+}
+
+func (e *retriableStatusError) Error() string {
+	return fmt.Sprintf("status %d", e.status)
+}
+
+// isRetriableStatus reports whether an HTTP status is transient: 5xx and 429.
+// Auth failures and other 4xx responses are fatal.
+func isRetriableStatus(status int) bool {
+	return status >= 500 || status == http.StatusTooManyRequests
+}
+
+// isRetriableError reports whether err is a transient network or Postgres
+// failure. Cancellation and authentication errors are never retried.
+func isRetriableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *retriableStatusError
+	if errors.As(err, &statusErr) {
+		return true
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code.Class() == "08": // connection exception
+			return true
+		case pqErr.Code == "40001", pqErr.Code == "40P01": // serialization failure, deadlock
+			return true
+		case pqErr.Code == "53300", pqErr.Code == "57P01", pqErr.Code == "57P03": // too many connections, shutdown
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset") || strings.Contains(msg, "broken pipe")
+}
+
+// retryBackoff returns the delay before the given retry (1 for the first),
+// doubling the base delay each time and jittering it into [d/2, d).
+func retryBackoff(retry int) time.Duration {
+	d := remoteRetryDelay << (retry - 1)
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// withRetry runs fn until it succeeds, fails with a non-retriable error or
+// remoteMaxAttempts is reached. Each retry is logged under the migration so
+// transient failures are visible in the server logs.
+func withRetry(migrationID, op string, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= remoteMaxAttempts; attempt++ {
+		if err = fn(); err == nil || !isRetriableError(err) {
+			return err
+		}
+		if attempt == remoteMaxAttempts {
+			break
+		}
+		delay := retryBackoff(attempt)
+		log.Warn("migration operation failed, retrying", "migration_id", migrationID, "operation", op,
+			"attempt", attempt, "max_attempts", remoteMaxAttempts, "backoff", delay.Round(time.Millisecond).String(), "error", err)
+		time.Sleep(delay)
+	}
+	return fmt.Errorf("after %d attempts: %w", remoteMaxAttempts, err)
+}
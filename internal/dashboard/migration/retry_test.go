@@ -0,0 +1,121 @@
+package migration
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func shortenRemoteRetryDelay(t *testing.T) {
+	oldDelay := remoteRetryDelay
+	remoteRetryDelay = time.Millisecond
+	t.Cleanup(func() { remoteRetryDelay = oldDelay })
+}
+
+func TestIsRetriableError(t *testing.T) {
+	retriable := []error{
+		syscall.ECONNRESET,
+		&retriableStatusError{status: http.StatusServiceUnavailable},
+		&pq.Error{Code: "08006"},
+		&pq.Error{Code: "57P01"},
+	}
+	for _, err := range retriable {
+		if !isRetriableError(err) {
+			t.Errorf("expected %v to be retriable", err)
+		}
+	}
+
+	fatal := []error{
+		errors.New("invalid token"),
+		&pq.Error{Code: "28P01"}, // invalid_password
+		&pq.Error{Code: "42P07"}, // duplicate_table
+	}
+	for _, err := range fatal {
+		if isRetriableError(err) {
+			t.Errorf("expected %v to be fatal", err)
+		}
+	}
+
+	if isRetriableStatus(http.StatusUnauthorized) || isRetriableStatus(http.StatusForbidden) {
+		t.Error("expected auth failures to be fatal")
+	}
+	if !isRetriableStatus(http.StatusBadGateway) || !isRetriableStatus(http.StatusTooManyRequests) {
+		t.Error("expected 502 and 429 to be retriable")
+	}
+}
+
+func TestSupabaseClientRetriesServerErrors(t *testing.T) {
+	shortenRemoteRetryDelay(t)
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"id":"abc","name":"demo"}]`))
+	}))
+	defer srv.Close()
+
+	client := NewSupabaseClient("token")
+	client.baseURL = srv.URL
+	projects, err := client.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects failed: %v", err)
+	}
+	if len(projects) != 1 || projects[0].ID != "abc" {
+		t.Errorf("unexpected projects: %+v", projects)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestSupabaseClientDoesNotRetryAuthErrors(t *testing.T) {
+	shortenRemoteRetryDelay(t)
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := NewSupabaseClient("bad")
+	client.baseURL = srv.URL
+	if _, err := client.ListProjects(); err == nil {
+		t.Fatal("expected ListProjects to fail")
+	}
+	if attempts.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts.Load())
+	}
+}
+
+func TestSupabaseClientGivesUpAfterMaxAttempts(t *testing.T) {
+	shortenRemoteRetryDelay(t)
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("upstream down"))
+	}))
+	defer srv.Close()
+
+	client := NewSupabaseClient("token")
+	client.baseURL = srv.URL
+	_, err := client.ListProjects()
+	if err == nil || !strings.Contains(err.Error(), "status 502: upstream down") {
+		t.Fatalf("expected the final 502 to be reported, got %v", err)
+	}
+	if int(attempts.Load()) != remoteMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", remoteMaxAttempts, attempts.Load())
+	}
+}
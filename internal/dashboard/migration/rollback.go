@@ -12,6 +12,11 @@ import (
 
 // Rollback rolls back a migration by undoing completed items in reverse order.
 func (s *Service) Rollback(migrationID string) error {
+	if err := s.beginRun(); err != nil {
+		return err
+	}
+	defer s.endRun()
+
 	m, err := s.GetMigration(migrationID)
 	if err != nil {
 		return err
@@ -97,6 +102,10 @@ func (s *Service) Rollback(migrationID string) error {
 		return fmt.Errorf("update migration status: %w", err)
 	}
 
+	// Confirm the remote objects are actually gone. Failures are recorded in the
+	// verification itself, so they don't change the rollback outcome.
+	_ = s.RunRollbackVerification(migrationID)
+
 	if len(rollbackErrors) > 0 {
 		return fmt.Errorf("rollback completed with errors: %s", strings.Join(rollbackErrors, "; "))
 	}
@@ -0,0 +1,378 @@
+package migration
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// rollbackQueryChunkSize bounds the number of IN-list parameters per query.
+const rollbackQueryChunkSize = 500
+
+// RunRollbackVerification checks the target project for objects a rollback was
+// supposed to remove (tables, policies, rows, users, buckets, files, functions,
+// secrets) and stores any leftovers as a "rollback" verification.
+func (s *Service) RunRollbackVerification(migrationID string) error {
+	m, err := s.GetMigration(migrationID)
+	if err != nil {
+		return fmt.Errorf("get migration: %w", err)
+	}
+
+	if m.SupabaseProjectRef == "" {
+		return fmt.Errorf("no Supabase project selected for migration")
+	}
+
+	verification, err := s.state.CreateVerification(migrationID, LayerRollback)
+	if err != nil {
+		return fmt.Errorf("create verification: %w", err)
+	}
+
+	now := time.Now().UTC()
+	verification.Status = VerifyRunning
+	verification.StartedAt = &now
+	if err := s.state.UpdateVerification(verification); err != nil {
+		return fmt.Errorf("update verification status: %w", err)
+	}
+
+	items, err := s.state.GetItems(migrationID)
+	if err != nil {
+		s.markVerificationFailed(verification, fmt.Errorf("get items: %w", err))
+		return fmt.Errorf("get items: %w", err)
+	}
+
+	client, err := s.getSupabaseClient(migrationID)
+	if err != nil {
+		s.markVerificationFailed(verification, fmt.Errorf("get supabase client: %w", err))
+		return fmt.Errorf("get supabase client: %w", err)
+	}
+
+	pgDB, err := s.getPostgresConnection(m)
+	if err != nil {
+		s.markVerificationFailed(verification, fmt.Errorf("get postgres connection: %w", err))
+		return fmt.Errorf("get postgres connection: %w", err)
+	}
+	defer pgDB.Close()
+
+	verifier := &rollbackVerifier{
+		queryRemote:    postgresStringQuery(pgDB),
+		supabaseClient: &supabaseClientAdapter{client},
+		projectRef:     m.SupabaseProjectRef,
+		items:          items,
+	}
+	result := verifier.runRollbackChecks()
+
+	resultsJSON, err := json.Marshal(result)
+	if err != nil {
+		s.markVerificationFailed(verification, fmt.Errorf("marshal results: %w", err))
+		return fmt.Errorf("marshal results: %w", err)
+	}
+
+	completedAt := time.Now().UTC()
+	verification.CompletedAt = &completedAt
+	verification.Results = resultsJSON
+	verification.Status = result.Status
+
+	if err := s.state.UpdateVerification(verification); err != nil {
+		return fmt.Errorf("update verification: %w", err)
+	}
+
+	return nil
+}
+
+// postgresStringQuery returns a query function that collects the first column of each row.
+func postgresStringQuery(db *sql.DB) func(query string, args ...interface{}) ([]string, error) {
+	return func(query string, args ...interface{}) ([]string, error) {
+		rows, err := db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		var values []string
+		for rows.Next() {
+			var v string
+			if err := rows.Scan(&v); err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, rows.Err()
+	}
+}
+
+// rollbackVerifier looks for remote objects that survived a rollback.
+type rollbackVerifier struct {
+	// queryRemote runs a query against the target database and returns the first column of each row.
+	queryRemote    func(query string, args ...interface{}) ([]string, error)
+	supabaseClient supabaseClientVerifier
+	projectRef     string
+	items          []*MigrationItem
+}
+
+// runRollbackChecks runs one check per rolled-back item. A check passes when
+// nothing it was supposed to remove is still present on the target.
+func (v *rollbackVerifier) runRollbackChecks() *verificationResult {
+	result := &verificationResult{
+		Layer:  LayerRollback,
+		Checks: []verificationCheckResult{},
+	}
+
+	for _, item := range v.items {
+		if item.Status != ItemRolledBack || item.RollbackInfo == "" {
+			continue
+		}
+
+		name := fmt.Sprintf("rollback_%s", item.ItemType)
+		if item.ItemName != "" && item.ItemName != string(item.ItemType) {
+			name += ":" + item.ItemName
+		}
+
+		leftovers, err := v.findLeftovers(item)
+		check := verificationCheckResult{Name: name, Passed: err == nil && len(leftovers) == 0}
+		switch {
+		case err != nil:
+			check.Message = fmt.Sprintf("could not verify: %v", err)
+		case len(leftovers) > 0:
+			check.Message = fmt.Sprintf("%d object(s) still present after rollback", len(leftovers))
+			check.Details = map[string]interface{}{"leftovers": leftovers}
+		default:
+			check.Message = "all objects removed"
+		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	result.Summary.Total = len(result.Checks)
+	for _, c := range result.Checks {
+		if c.Passed {
+			result.Summary.Passed++
+		} else {
+			result.Summary.Failed++
+		}
+	}
+	if result.Summary.Failed > 0 {
+		result.Status = VerifyFailed
+	} else {
+		result.Status = VerifyPassed
+	}
+
+	return result
+}
+
+// findLeftovers returns the identifiers of objects recorded in the item's
+// rollback info that still exist on the target.
+func (v *rollbackVerifier) findLeftovers(item *MigrationItem) ([]string, error) {
+	switch item.ItemType {
+	case ItemSchema:
+		var info SchemaRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		return v.existingTables(info.Tables)
+
+	case ItemData:
+		var info DataRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		if info.TableName == "" || info.RowCount == 0 {
+			return nil, nil
+		}
+		// A dropped table has no rows left either
+		existing, err := v.existingTables([]string{info.TableName})
+		if err != nil || len(existing) == 0 {
+			return nil, err
+		}
+		quoted, err := quoteIdentifier(info.TableName)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := v.queryRemote(fmt.Sprintf("SELECT count(*)::text FROM %s", quoted))
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 1 && rows[0] != "0" {
+			return []string{fmt.Sprintf("%s: %s row(s)", info.TableName, rows[0])}, nil
+		}
+		return nil, nil
+
+	case ItemUsers:
+		var info UsersRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		return v.queryIn("SELECT id::text FROM auth.users WHERE id::text IN (%s)", nil, info.UserIDs)
+
+	case ItemIdentities:
+		var info IdentitiesRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		return v.queryIn("SELECT id::text FROM auth.identities WHERE id::text IN (%s)", nil, info.IdentityIDs)
+
+	case ItemRLS:
+		var info RLSRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		var leftovers []string
+		for _, p := range info.Policies {
+			rows, err := v.queryRemote(`
+				SELECT policyname FROM pg_policies
+				WHERE schemaname = 'public' AND tablename = $1 AND policyname = $2
+			`, p.TableName, p.PolicyName)
+			if err != nil {
+				return nil, err
+			}
+			if len(rows) > 0 {
+				leftovers = append(leftovers, p.TableName+"."+p.PolicyName)
+			}
+		}
+		return leftovers, nil
+
+	case ItemStorageBuckets:
+		var info BucketsRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		return v.queryIn("SELECT id FROM storage.buckets WHERE id IN (%s)", nil, info.BucketIDs)
+
+	case ItemStorageFiles:
+		var info FilesRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		if info.BucketID == "" {
+			return nil, nil
+		}
+		return v.queryIn("SELECT name FROM storage.objects WHERE bucket_id = $1 AND name IN (%s)",
+			[]interface{}{info.BucketID}, info.Paths)
+
+	case ItemFunctions:
+		var info FunctionsRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		if info.FunctionName == "" || v.supabaseClient == nil {
+			return nil, nil
+		}
+		funcs, err := v.supabaseClient.ListFunctions(v.projectRef)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range funcs {
+			if f.Slug == info.FunctionName || f.Name == info.FunctionName {
+				return []string{info.FunctionName}, nil
+			}
+		}
+		return nil, nil
+
+	case ItemSecrets:
+		var info SecretsRollbackInfo
+		if err := json.Unmarshal([]byte(item.RollbackInfo), &info); err != nil {
+			return nil, fmt.Errorf("parse rollback info: %w", err)
+		}
+		if len(info.SecretNames) == 0 || v.supabaseClient == nil {
+			return nil, nil
+		}
+		secrets, err := v.supabaseClient.ListSecrets(v.projectRef)
+		if err != nil {
+			return nil, err
+		}
+		removed := make(map[string]bool, len(info.SecretNames))
+		for _, name := range info.SecretNames {
+			removed[name] = true
+		}
+		var leftovers []string
+		for _, sec := range secrets {
+			if removed[sec.Name] {
+				leftovers = append(leftovers, sec.Name)
+			}
+		}
+		return leftovers, nil
+	}
+
+	return nil, nil
+}
+
+// existingTables returns which of the given public tables exist on the target.
+func (v *rollbackVerifier) existingTables(tables []string) ([]string, error) {
+	return v.queryIn(`
+		SELECT table_name FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_name IN (%s)
+	`, nil, tables)
+}
+
+// queryIn runs a query whose %s placeholder is replaced with an IN list of
+// PostgreSQL parameters, in chunks, after any fixed leading args.
+func (v *rollbackVerifier) queryIn(queryFmt string, fixed []interface{}, values []string) ([]string, error) {
+	var found []string
+	for start := 0; start < len(values); start += rollbackQueryChunkSize {
+		end := start + rollbackQueryChunkSize
+		if end > len(values) {
+			end = len(values)
+		}
+		chunk := values[start:end]
+
+		placeholders := make([]string, len(chunk))
+		args := append([]interface{}{}, fixed...)
+		for i, val := range chunk {
+			placeholders[i] = fmt.Sprintf("$%d", len(fixed)+i+1)
+			args = append(args, val)
+		}
+
+		rows, err := v.queryRemote(fmt.Sprintf(queryFmt, strings.Join(placeholders, ", ")), args...)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, rows...)
+	}
+	return found, nil
+}
+
+// RollbackDiscrepancy is a failed check from the most recent rollback verification.
+type RollbackDiscrepancy struct {
+	Check   string      `json:"check"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// RollbackDiscrepancies extracts the failed checks of the most recent rollback
+// verification. It returns nil if no rollback verification has run.
+func RollbackDiscrepancies(verifications []*Verification) []RollbackDiscrepancy {
+	var latest *Verification
+	for _, v := range verifications {
+		if v.Layer != LayerRollback || v.StartedAt == nil {
+			continue
+		}
+		if latest == nil || v.StartedAt.After(*latest.StartedAt) {
+			latest = v
+		}
+	}
+	if latest == nil || len(latest.Results) == 0 {
+		return nil
+	}
+
+	var result verificationResult
+	if err := json.Unmarshal(latest.Results, &result); err != nil {
+		return nil
+	}
+
+	discrepancies := []RollbackDiscrepancy{}
+	for _, c := range result.Checks {
+		if !c.Passed {
+			discrepancies = append(discrepancies, RollbackDiscrepancy{Check: c.Name, Message: c.Message, Details: c.Details})
+		}
+	}
+	// A verification that failed before running checks stores only an error
+	if latest.Status == VerifyFailed && len(result.Checks) == 0 {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(latest.Results, &failure) == nil && failure.Error != "" {
+			discrepancies = append(discrepancies, RollbackDiscrepancy{Check: "rollback_verification", Message: failure.Error})
+		}
+	}
+	return discrepancies
+}
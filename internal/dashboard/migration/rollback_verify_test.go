@@ -0,0 +1,140 @@
+package migration
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeRollbackClient struct {
+	functions []functionInfo
+	secrets   []secretInfo
+}
+
+func (f *fakeRollbackClient) ListFunctions(projectRef string) ([]functionInfo, error) {
+	return f.functions, nil
+}
+
+func (f *fakeRollbackClient) ListSecrets(projectRef string) ([]secretInfo, error) {
+	return f.secrets, nil
+}
+
+func (f *fakeRollbackClient) GetAuthConfig(projectRef string) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestRollbackVerifierReportsLeftovers(t *testing.T) {
+	// The remote still has table "posts" and bucket "avatars"; everything else is gone
+	queryRemote := func(query string, args ...interface{}) ([]string, error) {
+		var found []string
+		for _, a := range args {
+			switch {
+			case strings.Contains(query, "information_schema.tables") && a == "posts":
+				found = append(found, "posts")
+			case strings.Contains(query, "storage.buckets") && a == "avatars":
+				found = append(found, "avatars")
+			}
+		}
+		return found, nil
+	}
+
+	items := []*MigrationItem{
+		{ItemType: ItemSchema, ItemName: "schema", Status: ItemRolledBack, RollbackInfo: `{"tables":["posts","comments"]}`},
+		{ItemType: ItemStorageBuckets, ItemName: "storage_buckets", Status: ItemRolledBack, RollbackInfo: `{"bucket_ids":["avatars"]}`},
+		{ItemType: ItemUsers, ItemName: "users", Status: ItemRolledBack, RollbackInfo: `{"user_ids":["u1"]}`},
+		{ItemType: ItemFunctions, ItemName: "hello", Status: ItemRolledBack, RollbackInfo: `{"function_name":"hello"}`},
+		// Items that were not rolled back are not checked
+		{ItemType: ItemSecrets, ItemName: "secrets", Status: ItemCompleted, RollbackInfo: `{"secret_names":["KEY"]}`},
+	}
+
+	v := &rollbackVerifier{
+		queryRemote:    queryRemote,
+		supabaseClient: &fakeRollbackClient{functions: []functionInfo{{Slug: "other"}}},
+		items:          items,
+	}
+	result := v.runRollbackChecks()
+
+	if result.Summary.Total != 4 {
+		t.Fatalf("expected 4 checks, got %d", result.Summary.Total)
+	}
+	if result.Summary.Failed != 2 || result.Status != VerifyFailed {
+		t.Errorf("expected 2 failed checks, got %+v", result.Summary)
+	}
+
+	for _, c := range result.Checks {
+		switch c.Name {
+		case "rollback_schema":
+			details, _ := json.Marshal(c.Details)
+			if c.Passed || !strings.Contains(string(details), "posts") || strings.Contains(string(details), "comments") {
+				t.Errorf("expected posts to be reported as leftover, got %+v", c)
+			}
+		case "rollback_storage_buckets":
+			if c.Passed {
+				t.Errorf("expected avatars bucket to be reported, got %+v", c)
+			}
+		case "rollback_users", "rollback_functions:hello":
+			if !c.Passed {
+				t.Errorf("expected %s to pass, got %+v", c.Name, c)
+			}
+		default:
+			t.Errorf("unexpected check %s", c.Name)
+		}
+	}
+}
+
+func TestRollbackVerifierQueryInChunks(t *testing.T) {
+	calls := 0
+	v := &rollbackVerifier{queryRemote: func(query string, args ...interface{}) ([]string, error) {
+		calls++
+		if len(args) > rollbackQueryChunkSize+1 {
+			t.Errorf("chunk too large: %d args", len(args))
+		}
+		if args[0] != "bucket" {
+			t.Errorf("expected fixed arg first, got %v", args[0])
+		}
+		return nil, nil
+	}}
+
+	paths := make([]string, rollbackQueryChunkSize*2+1)
+	for i := range paths {
+		paths[i] = "file"
+	}
+	if _, err := v.queryIn("SELECT name FROM storage.objects WHERE bucket_id = $1 AND name IN (%s)", []interface{}{"bucket"}, paths); err != nil {
+		t.Fatalf("queryIn failed: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 chunked queries, got %d", calls)
+	}
+}
+
+func TestRollbackDiscrepancies(t *testing.T) {
+	if d := RollbackDiscrepancies(nil); d != nil {
+		t.Errorf("expected nil without rollback verification, got %v", d)
+	}
+
+	older := time.Now().Add(-time.Hour)
+	newer := time.Now()
+	oldResult, _ := json.Marshal(verificationResult{Checks: []verificationCheckResult{{Name: "old", Passed: false}}})
+	newResult, _ := json.Marshal(verificationResult{Checks: []verificationCheckResult{
+		{Name: "rollback_schema", Passed: false, Message: "1 object(s) still present after rollback"},
+		{Name: "rollback_users", Passed: true},
+	}})
+
+	verifications := []*Verification{
+		{Layer: LayerRollback, Status: VerifyFailed, StartedAt: &older, Results: oldResult},
+		{Layer: LayerBasic, Status: VerifyFailed, StartedAt: &newer, Results: oldResult},
+		{Layer: LayerRollback, Status: VerifyFailed, StartedAt: &newer, Results: newResult},
+	}
+
+	d := RollbackDiscrepancies(verifications)
+	if len(d) != 1 || d[0].Check != "rollback_schema" {
+		t.Errorf("expected only the latest rollback discrepancy, got %+v", d)
+	}
+
+	errResult, _ := json.Marshal(map[string]string{"error": "ping postgres: timeout"})
+	d = RollbackDiscrepancies([]*Verification{{Layer: LayerRollback, Status: VerifyFailed, StartedAt: &newer, Results: errResult}})
+	if len(d) != 1 || d[0].Message != "ping postgres: timeout" {
+		t.Errorf("expected verification error to be surfaced, got %+v", d)
+	}
+}
@@ -18,7 +18,9 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/markb/sblite/internal/migrate"
@@ -47,6 +49,11 @@ type Service struct {
 	state        *StateStore
 	supabase     *SupabaseClient
 	serverConfig *ServerConfig
+
+	// runMu guards draining; active counts runs and rollbacks in flight.
+	runMu    sync.Mutex
+	draining bool
+	active   sync.WaitGroup
 }
 
 // ServerConfig holds server configuration needed for migrations.
@@ -65,9 +72,43 @@ func NewService(db *sql.DB, config *ServerConfig) *Service {
 	}
 }
 
-// StartMigration creates a new migration session.
-func (s *Service) StartMigration() (*Migration, error) {
-	return s.state.CreateMigration()
+// StartMigration creates a new migration session. If webhookURL is non-empty,
+// it is POSTed with the final status once the migration completes or fails.
+func (s *Service) StartMigration(webhookURL string) (*Migration, error) {
+	if webhookURL != "" {
+		if err := ValidateWebhookURL(webhookURL); err != nil {
+			return nil, err
+		}
+	}
+
+	m, err := s.state.CreateMigration()
+	if err != nil {
+		return nil, err
+	}
+
+	if webhookURL != "" {
+		m.WebhookURL = webhookURL
+		if err := s.state.UpdateMigration(m); err != nil {
+			return nil, fmt.Errorf("store webhook URL: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// SetWebhookURL sets or replaces the webhook URL notified when the migration finishes.
+func (s *Service) SetWebhookURL(migrationID, webhookURL string) error {
+	if err := ValidateWebhookURL(webhookURL); err != nil {
+		return err
+	}
+
+	m, err := s.GetMigration(migrationID)
+	if err != nil {
+		return err
+	}
+
+	m.WebhookURL = webhookURL
+	return s.state.UpdateMigration(m)
 }
 
 // GetMigration retrieves a migration by ID.
@@ -113,6 +154,7 @@ func (s *Service) ConnectSupabase(migrationID, token string) error {
 
 	// Create client and validate token
 	client := NewSupabaseClient(token)
+	client.migrationID = migrationID
 	if err := client.ValidateToken(); err != nil {
 		return fmt.Errorf("validate token: %w", err)
 	}
@@ -239,10 +281,13 @@ func (s *Service) getPostgresConnection(migration *Migration) (*sql.DB, error) {
 		return nil, fmt.Errorf("open postgres connection: %w", err)
 	}
 
-	// Test the connection
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	if err := db.PingContext(ctx); err != nil {
+	// Test the connection, retrying transient network failures
+	err = withRetry(migration.ID, "postgres ping", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return db.PingContext(ctx)
+	})
+	if err != nil {
 		db.Close()
 		return nil, fmt.Errorf("ping postgres: %w", err)
 	}
@@ -250,6 +295,26 @@ func (s *Service) getPostgresConnection(migration *Migration) (*sql.DB, error) {
 	return db, nil
 }
 
+// TableMode controls whether a table's schema, data or both are migrated.
+type TableMode string
+
+const (
+	TableSchemaOnly TableMode = "schema_only"
+	TableDataOnly   TableMode = "data_only"
+	TableBoth       TableMode = "both"
+)
+
+// TableSelection selects a single table with a migration mode.
+type TableSelection struct {
+	Name string    `json:"name"`
+	Mode TableMode `json:"mode"` // Defaults to both
+}
+
+// SchemaItemMetadata is stored on a schema item that covers only some tables.
+type SchemaItemMetadata struct {
+	Tables []string `json:"tables"`
+}
+
 // SelectItemsRequest specifies which items to include in the migration.
 type SelectItemsRequest struct {
 	// Boolean flags for single-instance items
@@ -267,6 +332,14 @@ type SelectItemsRequest struct {
 	Data         []string `json:"data"`          // Table names for data migration
 	StorageFiles []string `json:"storage_files"` // Bucket IDs for file migration
 	Functions    []string `json:"functions"`     // Function names
+
+	// AllStorageFiles adds a storage_files item for every local bucket
+	AllStorageFiles bool `json:"all_storage_files"`
+
+	// Tables selects tables individually with a mode, so schema and data can be
+	// migrated in separate runs. Schema-selected tables are ignored when Schema is set,
+	// since the schema item then covers every table.
+	Tables []TableSelection `json:"tables"`
 }
 
 // SelectItems creates migration items based on the selection request.
@@ -277,6 +350,23 @@ func (s *Service) SelectItems(migrationID string, req SelectItemsRequest) error
 		return err
 	}
 
+	// Split per-table selections into schema tables and data tables
+	var schemaTables []string
+	dataTables := append([]string{}, req.Data...)
+	for _, t := range req.Tables {
+		switch t.Mode {
+		case TableSchemaOnly:
+			schemaTables = append(schemaTables, t.Name)
+		case TableDataOnly:
+			dataTables = append(dataTables, t.Name)
+		case TableBoth, "":
+			schemaTables = append(schemaTables, t.Name)
+			dataTables = append(dataTables, t.Name)
+		default:
+			return fmt.Errorf("invalid mode %q for table %s (must be schema_only, data_only or both)", t.Mode, t.Name)
+		}
+	}
+
 	// Clear existing items for this migration
 	_, err = s.db.Exec(`DELETE FROM _migration_items WHERE migration_id = ?`, migrationID)
 	if err != nil {
@@ -288,6 +378,15 @@ func (s *Service) SelectItems(migrationID string, req SelectItemsRequest) error
 		if _, err := s.state.CreateItem(migrationID, ItemSchema, "schema"); err != nil {
 			return fmt.Errorf("create schema item: %w", err)
 		}
+	} else if len(schemaTables) > 0 {
+		item, err := s.state.CreateItem(migrationID, ItemSchema, "schema")
+		if err != nil {
+			return fmt.Errorf("create schema item: %w", err)
+		}
+		item.Metadata, _ = json.Marshal(SchemaItemMetadata{Tables: schemaTables})
+		if err := s.state.UpdateItem(item); err != nil {
+			return fmt.Errorf("store schema tables: %w", err)
+		}
 	}
 
 	if req.Users {
@@ -339,12 +438,39 @@ func (s *Service) SelectItems(migrationID string, req SelectItemsRequest) error
 	}
 
 	// Create items for named collections
-	for _, tableName := range req.Data {
+	seenData := make(map[string]bool, len(dataTables))
+	for _, tableName := range dataTables {
+		if seenData[tableName] {
+			continue
+		}
+		seenData[tableName] = true
 		if _, err := s.state.CreateItem(migrationID, ItemData, tableName); err != nil {
 			return fmt.Errorf("create data item for %s: %w", tableName, err)
 		}
 	}
 
+	if req.AllStorageFiles {
+		rows, err := s.db.Query(`SELECT id FROM storage_buckets ORDER BY id`)
+		if err != nil {
+			return fmt.Errorf("list buckets: %w", err)
+		}
+		seen := make(map[string]bool, len(req.StorageFiles))
+		for _, id := range req.StorageFiles {
+			seen[id] = true
+		}
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan bucket: %w", err)
+			}
+			if !seen[id] {
+				req.StorageFiles = append(req.StorageFiles, id)
+			}
+		}
+		rows.Close()
+	}
+
 	for _, bucketID := range req.StorageFiles {
 		if _, err := s.state.CreateItem(migrationID, ItemStorageFiles, bucketID); err != nil {
 			return fmt.Errorf("create storage_files item for %s: %w", bucketID, err)
@@ -365,6 +491,17 @@ func (s *Service) GetItems(migrationID string) ([]*MigrationItem, error) {
 	return s.state.GetItems(migrationID)
 }
 
+// ListItems returns one page of a migration's items, optionally filtered by
+// status, and the total number of matching items.
+func (s *Service) ListItems(migrationID string, status ItemStatus, limit, offset int) ([]*MigrationItem, int, error) {
+	return s.state.ListItems(migrationID, status, limit, offset)
+}
+
+// CountItemsByStatus returns the number of a migration's items in each status.
+func (s *Service) CountItemsByStatus(migrationID string) (map[ItemStatus]int, error) {
+	return s.state.CountItemsByStatus(migrationID)
+}
+
 // MigrationProgress summarizes the progress of a migration.
 type MigrationProgress struct {
 	Total     int `json:"total"`
@@ -404,6 +541,10 @@ func (s *Service) GetProgress(migrationID string) (*MigrationProgress, error) {
 
 // RetryFailedItems resets all failed items to pending so they can be retried.
 func (s *Service) RetryFailedItems(migrationID string) error {
+	if s.isDraining() {
+		return ErrShuttingDown
+	}
+
 	// Verify migration exists
 	m, err := s.GetMigration(migrationID)
 	if err != nil {
@@ -463,11 +604,25 @@ func (s *Service) getSupabaseClient(migrationID string) (*SupabaseClient, error)
 		return nil, fmt.Errorf("decrypt credentials: %w", err)
 	}
 
-	return NewSupabaseClient(token), nil
+	client := NewSupabaseClient(token)
+	client.migrationID = migrationID
+	return client, nil
 }
 
 // RunMigration executes the migration, processing all pending items.
 func (s *Service) RunMigration(migrationID string) error {
+	return s.RunMigrationWithOptions(migrationID, RunOptions{})
+}
+
+// RunMigrationWithOptions executes the migration with the given options.
+// Data items run as one batch, in parallel when opts.Workers > 1, at the
+// position of the first data item so schema items still run before them.
+func (s *Service) RunMigrationWithOptions(migrationID string, opts RunOptions) error {
+	if err := s.beginRun(); err != nil {
+		return err
+	}
+	defer s.endRun()
+
 	m, err := s.GetMigration(migrationID)
 	if err != nil {
 		return err
@@ -487,6 +642,9 @@ func (s *Service) RunMigration(migrationID string) error {
 		return fmt.Errorf("no items selected for migration")
 	}
 
+	// Tables must exist before their data is copied
+	sortItemsForRun(items)
+
 	// Update migration status to in_progress
 	m.Status = StatusInProgress
 	if err := s.state.UpdateMigration(m); err != nil {
@@ -496,11 +654,22 @@ func (s *Service) RunMigration(migrationID string) error {
 	// Track overall success
 	hasFailures := false
 
-	// Process each pending item
+	var dataItems []*MigrationItem
+	for _, item := range items {
+		if item.Status == ItemPending && item.ItemType == ItemData {
+			dataItems = append(dataItems, item)
+		}
+	}
+	dataDone := false
+
+	// Process each pending item, stopping between items on shutdown
 	for _, item := range items {
 		if item.Status != ItemPending {
 			continue
 		}
+		if s.isDraining() {
+			break
+		}
 
 		// Run the appropriate migrator based on item type
 		var migrateErr error
@@ -508,7 +677,12 @@ func (s *Service) RunMigration(migrationID string) error {
 		case ItemSchema:
 			migrateErr = s.migrateSchema(m, item)
 		case ItemData:
-			migrateErr = s.migrateData(m, item)
+			if !dataDone {
+				dataDone = true
+				if s.migrateDataItems(m, dataItems, opts) {
+					migrateErr = fmt.Errorf("one or more tables failed to migrate")
+				}
+			}
 		case ItemUsers:
 			migrateErr = s.migrateUsers(m, item)
 		case ItemIdentities:
@@ -539,6 +713,14 @@ func (s *Service) RunMigration(migrationID string) error {
 		}
 	}
 
+	if s.isDraining() && hasUnfinishedItems(items) {
+		if err := s.markInterrupted(m); err != nil {
+			return fmt.Errorf("update migration status: %w", err)
+		}
+		s.notifyWebhook(m)
+		return nil
+	}
+
 	// Update migration status based on results
 	now := time.Now().UTC()
 	m.CompletedAt = &now
@@ -554,9 +736,45 @@ func (s *Service) RunMigration(migrationID string) error {
 		return fmt.Errorf("update migration status: %w", err)
 	}
 
+	s.notifyWebhook(m)
+
 	return nil
 }
 
+// hasUnfinishedItems reports whether any item is still pending or running.
+func hasUnfinishedItems(items []*MigrationItem) bool {
+	for _, item := range items {
+		if item.Status == ItemPending || item.Status == ItemInProgress {
+			return true
+		}
+	}
+	return false
+}
+
+// itemRunOrder is the order item types run in: schema before the rows that
+// depend on it, and users before data that may reference them.
+var itemRunOrder = map[ItemType]int{
+	ItemSchema:         0,
+	ItemUsers:          1,
+	ItemIdentities:     2,
+	ItemData:           3,
+	ItemRLS:            4,
+	ItemStorageBuckets: 5,
+	ItemStorageFiles:   6,
+	ItemFunctions:      7,
+	ItemSecrets:        8,
+	ItemAuthConfig:     9,
+	ItemOAuthConfig:    10,
+	ItemEmailTemplates: 11,
+}
+
+// sortItemsForRun orders items by type so dependencies run first.
+func sortItemsForRun(items []*MigrationItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return itemRunOrder[items[i].ItemType] < itemRunOrder[items[j].ItemType]
+	})
+}
+
 // markItemStarted marks an item as in_progress with a start time.
 func (s *Service) markItemStarted(item *MigrationItem) error {
 	now := time.Now().UTC()
@@ -606,8 +824,20 @@ func (s *Service) migrateSchema(m *Migration, item *MigrationItem) error {
 	sch := schema.New(s.db)
 	exporter := migrate.New(sch)
 
+	// A schema item may be limited to the tables selected as schema_only/both
+	var meta SchemaItemMetadata
+	if len(item.Metadata) > 0 {
+		json.Unmarshal(item.Metadata, &meta)
+	}
+
 	// Export DDL
-	ddl, err := exporter.ExportDDL()
+	var ddl string
+	var err error
+	if len(meta.Tables) > 0 {
+		ddl, err = exporter.ExportTablesDDL(meta.Tables)
+	} else {
+		ddl, err = exporter.ExportDDL()
+	}
 	if err != nil {
 		s.markItemFailed(item, fmt.Errorf("export DDL: %w", err))
 		return err
@@ -622,16 +852,22 @@ func (s *Service) migrateSchema(m *Migration, item *MigrationItem) error {
 	defer pgDB.Close()
 
 	// Execute DDL
-	_, err = pgDB.Exec(ddl)
+	err = withRetry(m.ID, "execute DDL", func() error {
+		_, err := pgDB.Exec(ddl)
+		return err
+	})
 	if err != nil {
 		s.markItemFailed(item, fmt.Errorf("execute DDL: %w", err))
 		return err
 	}
 
 	// Get list of created tables for rollback
-	tables, err := sch.ListTables()
-	if err != nil {
-		tables = []string{} // Non-fatal
+	tables := meta.Tables
+	if len(tables) == 0 {
+		tables, err = sch.ListTables()
+		if err != nil {
+			tables = []string{} // Non-fatal
+		}
 	}
 
 	rollbackInfo := SchemaRollbackInfo{Tables: tables}
@@ -644,8 +880,9 @@ type DataRollbackInfo struct {
 	RowCount  int    `json:"row_count"`
 }
 
-// migrateData migrates table data from sblite to Supabase.
-func (s *Service) migrateData(m *Migration, item *MigrationItem) error {
+// migrateDataWith migrates one table's data from sblite to Supabase using the
+// given Postgres connection pool.
+func (s *Service) migrateDataWith(pgDB *sql.DB, item *MigrationItem) error {
 	if err := s.markItemStarted(item); err != nil {
 		return err
 	}
@@ -685,14 +922,6 @@ func (s *Service) migrateData(m *Migration, item *MigrationItem) error {
 		quotedColumns[i] = quotedCol
 	}
 
-	// Connect to Supabase PostgreSQL
-	pgDB, err := s.getPostgresConnection(m)
-	if err != nil {
-		s.markItemFailed(item, fmt.Errorf("connect to postgres: %w", err))
-		return err
-	}
-	defer pgDB.Close()
-
 	// Start transaction for batch insert
 	tx, err := pgDB.Begin()
 	if err != nil {
@@ -1033,7 +1262,10 @@ func (s *Service) migrateRLS(m *Migration, item *MigrationItem) error {
 			policySQL += fmt.Sprintf(" WITH CHECK (%s)", checkExpr.String)
 		}
 
-		_, err = pgDB.Exec(policySQL)
+		err = withRetry(m.ID, "create policy", func() error {
+			_, err := pgDB.Exec(policySQL)
+			return err
+		})
 		if err != nil {
 			s.markItemFailed(item, fmt.Errorf("create policy %s.%s: %w", tableName, policyName, err))
 			return err
@@ -1142,7 +1374,81 @@ type FilesRollbackInfo struct {
 	Paths    []string `json:"paths"`
 }
 
-// migrateStorageFiles migrates storage files for a specific bucket from sblite to Supabase.
+// StorageFilesProgress is stored in a storage_files item's metadata and
+// updated after every object so the UI can show per-object progress.
+type StorageFilesProgress struct {
+	TotalObjects   int                    `json:"total_objects"`
+	Uploaded       int                    `json:"uploaded"`
+	BytesUploaded  int64                  `json:"bytes_uploaded"`
+	CurrentObject  string                 `json:"current_object,omitempty"`
+	SkippedObjects []SkippedStorageObject `json:"skipped_objects,omitempty"`
+}
+
+// SkippedStorageObject records an object that was not copied and why.
+type SkippedStorageObject struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// storageBucketRules holds the upload constraints of a bucket.
+type storageBucketRules struct {
+	FileSizeLimit    *int64
+	AllowedMimeTypes []string
+}
+
+// check returns a non-empty reason if an object violates the bucket rules.
+// MIME entries ending in "*" (e.g. "image/*") match any subtype.
+func (r storageBucketRules) check(size int64, mimeType string) string {
+	if r.FileSizeLimit != nil && size > *r.FileSizeLimit {
+		return fmt.Sprintf("size %d exceeds bucket limit of %d bytes", size, *r.FileSizeLimit)
+	}
+	if len(r.AllowedMimeTypes) == 0 {
+		return ""
+	}
+	for _, mt := range r.AllowedMimeTypes {
+		if mt == mimeType || (strings.HasSuffix(mt, "*") && strings.HasPrefix(mimeType, strings.TrimSuffix(mt, "*"))) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("MIME type %s is not allowed by bucket", mimeType)
+}
+
+// getStorageBucketRules loads the size and MIME rules of a local bucket.
+func (s *Service) getStorageBucketRules(bucketID string) (storageBucketRules, error) {
+	var rules storageBucketRules
+	var fileSizeLimit sql.NullInt64
+	var allowedMimeTypes sql.NullString
+	err := s.db.QueryRow(`
+		SELECT file_size_limit, allowed_mime_types FROM storage_buckets WHERE id = ?
+	`, bucketID).Scan(&fileSizeLimit, &allowedMimeTypes)
+	if err == sql.ErrNoRows {
+		return rules, fmt.Errorf("bucket not found: %s", bucketID)
+	}
+	if err != nil {
+		return rules, err
+	}
+	if fileSizeLimit.Valid {
+		rules.FileSizeLimit = &fileSizeLimit.Int64
+	}
+	if allowedMimeTypes.Valid && allowedMimeTypes.String != "" {
+		json.Unmarshal([]byte(allowedMimeTypes.String), &rules.AllowedMimeTypes)
+	}
+	return rules, nil
+}
+
+// updateStorageFilesProgress stores the current progress in the item's metadata.
+func (s *Service) updateStorageFilesProgress(item *MigrationItem, progress *StorageFilesProgress) error {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	item.Metadata = data
+	return s.state.UpdateItem(item)
+}
+
+// migrateStorageFiles uploads the objects of one local bucket to Supabase Storage.
+// Objects that violate the bucket's size or MIME rules are skipped and reported
+// in the item metadata, since Supabase would reject them anyway.
 func (s *Service) migrateStorageFiles(m *Migration, item *MigrationItem) error {
 	if err := s.markItemStarted(item); err != nil {
 		return err
@@ -1150,6 +1456,12 @@ func (s *Service) migrateStorageFiles(m *Migration, item *MigrationItem) error {
 
 	bucketID := item.ItemName
 
+	rules, err := s.getStorageBucketRules(bucketID)
+	if err != nil {
+		s.markItemFailed(item, fmt.Errorf("get bucket rules: %w", err))
+		return err
+	}
+
 	// Get Supabase client and API keys
 	client, err := s.getSupabaseClient(m.ID)
 	if err != nil {
@@ -1176,66 +1488,100 @@ func (s *Service) migrateStorageFiles(m *Migration, item *MigrationItem) error {
 		return fmt.Errorf("service_role key not found")
 	}
 
-	// Query storage objects for this bucket from sblite
+	// Load the object list up front so progress has a known total
+	type storageObject struct {
+		name     string
+		size     int64
+		mimeType string
+	}
 	rows, err := s.db.Query(`
-		SELECT name, content_type
+		SELECT name, COALESCE(size, 0), COALESCE(mime_type, 'application/octet-stream')
 		FROM storage_objects
 		WHERE bucket_id = ?
+		ORDER BY name
 	`, bucketID)
 	if err != nil {
 		s.markItemFailed(item, fmt.Errorf("query objects: %w", err))
 		return err
 	}
-	defer rows.Close()
+	var objects []storageObject
+	for rows.Next() {
+		var obj storageObject
+		if err := rows.Scan(&obj.name, &obj.size, &obj.mimeType); err != nil {
+			rows.Close()
+			s.markItemFailed(item, fmt.Errorf("scan object: %w", err))
+			return err
+		}
+		objects = append(objects, obj)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		s.markItemFailed(item, fmt.Errorf("iterate objects: %w", err))
+		return err
+	}
+
+	progress := &StorageFilesProgress{TotalObjects: len(objects)}
+	if err := s.updateStorageFilesProgress(item, progress); err != nil {
+		return err
+	}
 
 	storageURL := fmt.Sprintf("https://%s.supabase.co/storage/v1/object/%s", m.SupabaseProjectRef, bucketID)
+	httpClient := &http.Client{Timeout: 5 * time.Minute}
 	var uploadedPaths []string
 
-	for rows.Next() {
-		var name, contentType string
-		if err := rows.Scan(&name, &contentType); err != nil {
-			s.markItemFailed(item, fmt.Errorf("scan object: %w", err))
-			return err
+	for _, obj := range objects {
+		if reason := rules.check(obj.size, obj.mimeType); reason != "" {
+			progress.SkippedObjects = append(progress.SkippedObjects, SkippedStorageObject{Name: obj.name, Reason: reason})
+			s.updateStorageFilesProgress(item, progress)
+			continue
 		}
 
-		// Read file from local storage
-		localPath := filepath.Join(s.serverConfig.StorageDir, bucketID, name)
-		fileData, err := os.ReadFile(localPath)
+		progress.CurrentObject = obj.name
+		s.updateStorageFilesProgress(item, progress)
+
+		// Stream file from local storage
+		localPath := filepath.Join(s.serverConfig.StorageDir, bucketID, filepath.FromSlash(obj.name))
+		f, err := os.Open(localPath)
 		if err != nil {
-			s.markItemFailed(item, fmt.Errorf("read file %s: %w", name, err))
+			s.markItemFailed(item, fmt.Errorf("read file %s: %w", obj.name, err))
 			return err
 		}
 
-		// Upload to Supabase Storage API
-		uploadURL := fmt.Sprintf("%s/%s", storageURL, name)
-		req, err := http.NewRequest(http.MethodPost, uploadURL, bytes.NewReader(fileData))
+		// Upload to Supabase Storage API; x-upsert keeps retries idempotent
+		uploadURL := fmt.Sprintf("%s/%s", storageURL, obj.name)
+		req, err := http.NewRequest(http.MethodPost, uploadURL, f)
 		if err != nil {
-			s.markItemFailed(item, fmt.Errorf("create request for %s: %w", name, err))
+			f.Close()
+			s.markItemFailed(item, fmt.Errorf("create request for %s: %w", obj.name, err))
 			return err
 		}
 
+		req.ContentLength = obj.size
 		req.Header.Set("Authorization", "Bearer "+serviceKey)
-		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Content-Type", obj.mimeType)
+		req.Header.Set("x-upsert", "true")
 
-		resp, err := http.DefaultClient.Do(req)
+		resp, err := httpClient.Do(req)
+		f.Close()
 		if err != nil {
-			s.markItemFailed(item, fmt.Errorf("upload %s: %w", name, err))
+			s.markItemFailed(item, fmt.Errorf("upload %s: %w", obj.name, err))
 			return err
 		}
 		resp.Body.Close()
 
 		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-			s.markItemFailed(item, fmt.Errorf("upload %s: status %d", name, resp.StatusCode))
-			return fmt.Errorf("upload %s: status %d", name, resp.StatusCode)
+			s.markItemFailed(item, fmt.Errorf("upload %s: status %d", obj.name, resp.StatusCode))
+			return fmt.Errorf("upload %s: status %d", obj.name, resp.StatusCode)
 		}
 
-		uploadedPaths = append(uploadedPaths, name)
+		uploadedPaths = append(uploadedPaths, obj.name)
+		progress.Uploaded++
+		progress.BytesUploaded += obj.size
 	}
 
-	if err := rows.Err(); err != nil {
-		s.markItemFailed(item, fmt.Errorf("iterate objects: %w", err))
-		return err
-	}
+	progress.CurrentObject = ""
+	data, _ := json.Marshal(progress)
+	item.Metadata = data
 
 	rollbackInfo := FilesRollbackInfo{BucketID: bucketID, Paths: uploadedPaths}
 	return s.markItemCompleted(item, rollbackInfo)
@@ -1559,8 +1905,21 @@ func (s *Service) encryptCredential(plaintext string) ([]byte, error) {
 	if s.serverConfig == nil || s.serverConfig.JWTSecret == "" {
 		return nil, fmt.Errorf("JWT secret not configured")
 	}
+	return EncryptCredential(s.serverConfig.JWTSecret, plaintext)
+}
+
+// decryptCredential decrypts a credential encrypted with encryptCredential.
+func (s *Service) decryptCredential(ciphertext []byte) (string, error) {
+	if s.serverConfig == nil || s.serverConfig.JWTSecret == "" {
+		return "", fmt.Errorf("JWT secret not configured")
+	}
+	return DecryptCredential(s.serverConfig.JWTSecret, ciphertext)
+}
 
-	key := sha256.Sum256([]byte(s.serverConfig.JWTSecret))
+// EncryptCredential encrypts plaintext with AES-GCM using a key derived from
+// secret (SHA-256). The nonce is prepended to the returned ciphertext.
+func EncryptCredential(secret, plaintext string) ([]byte, error) {
+	key := sha256.Sum256([]byte(secret))
 	block, err := aes.NewCipher(key[:])
 	if err != nil {
 		return nil, err
@@ -1579,13 +1938,9 @@ func (s *Service) encryptCredential(plaintext string) ([]byte, error) {
 	return gcm.Seal(nonce, nonce, []byte(plaintext), nil), nil
 }
 
-// decryptCredential decrypts a credential encrypted with encryptCredential.
-func (s *Service) decryptCredential(ciphertext []byte) (string, error) {
-	if s.serverConfig == nil || s.serverConfig.JWTSecret == "" {
-		return "", fmt.Errorf("JWT secret not configured")
-	}
-
-	key := sha256.Sum256([]byte(s.serverConfig.JWTSecret))
+// DecryptCredential decrypts a value produced by EncryptCredential with the same secret.
+func DecryptCredential(secret string, ciphertext []byte) (string, error) {
+	key := sha256.Sum256([]byte(secret))
 	block, err := aes.NewCipher(key[:])
 	if err != nil {
 		return "", err
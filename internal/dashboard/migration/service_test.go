@@ -0,0 +1,161 @@
+package migration
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestStorageBucketRulesCheck(t *testing.T) {
+	limit := int64(100)
+	rules := storageBucketRules{
+		FileSizeLimit:    &limit,
+		AllowedMimeTypes: []string{"image/*", "application/pdf"},
+	}
+
+	tests := []struct {
+		size     int64
+		mimeType string
+		allowed  bool
+	}{
+		{50, "image/png", true},
+		{50, "application/pdf", true},
+		{50, "application/pdfx", false},
+		{50, "text/plain", false},
+		{150, "image/png", false},
+	}
+	for _, tt := range tests {
+		reason := rules.check(tt.size, tt.mimeType)
+		if (reason == "") != tt.allowed {
+			t.Errorf("check(%d, %q) = %q, expected allowed=%v", tt.size, tt.mimeType, reason, tt.allowed)
+		}
+	}
+
+	if reason := (storageBucketRules{}).check(1<<30, "anything/else"); reason != "" {
+		t.Errorf("expected no rules to allow everything, got %q", reason)
+	}
+}
+
+func TestSelectItemsAllStorageFiles(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	_, err := db.Exec(`
+		CREATE TABLE storage_buckets (id TEXT PRIMARY KEY, name TEXT, file_size_limit INTEGER, allowed_mime_types TEXT);
+		INSERT INTO storage_buckets (id, name) VALUES ('avatars', 'avatars'), ('docs', 'docs');
+	`)
+	if err != nil {
+		t.Fatalf("failed to create buckets: %v", err)
+	}
+
+	svc := NewService(db, &ServerConfig{})
+	m, err := svc.StartMigration("")
+	if err != nil {
+		t.Fatalf("StartMigration failed: %v", err)
+	}
+
+	err = svc.SelectItems(m.ID, SelectItemsRequest{StorageFiles: []string{"docs"}, AllStorageFiles: true})
+	if err != nil {
+		t.Fatalf("SelectItems failed: %v", err)
+	}
+
+	items, err := svc.GetItems(m.ID)
+	if err != nil {
+		t.Fatalf("GetItems failed: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected one item per bucket, got %d", len(items))
+	}
+	for _, item := range items {
+		if item.ItemType != ItemStorageFiles {
+			t.Errorf("expected storage_files item, got %s", item.ItemType)
+		}
+	}
+
+	rules, err := svc.getStorageBucketRules("avatars")
+	if err != nil {
+		t.Fatalf("getStorageBucketRules failed: %v", err)
+	}
+	if rules.FileSizeLimit != nil || len(rules.AllowedMimeTypes) != 0 {
+		t.Errorf("expected no rules, got %+v", rules)
+	}
+	if _, err := svc.getStorageBucketRules("missing"); err == nil {
+		t.Error("expected error for missing bucket")
+	}
+}
+
+func TestSelectItemsTableModes(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(db, &ServerConfig{})
+	m, err := svc.StartMigration("")
+	if err != nil {
+		t.Fatalf("StartMigration failed: %v", err)
+	}
+
+	err = svc.SelectItems(m.ID, SelectItemsRequest{Tables: []TableSelection{
+		{Name: "users", Mode: TableSchemaOnly},
+		{Name: "posts", Mode: TableBoth},
+		{Name: "logs", Mode: TableDataOnly},
+		{Name: "tags"},
+	}})
+	if err != nil {
+		t.Fatalf("SelectItems failed: %v", err)
+	}
+
+	items, err := svc.GetItems(m.ID)
+	if err != nil {
+		t.Fatalf("GetItems failed: %v", err)
+	}
+
+	dataTables := map[string]bool{}
+	var schemaItem *MigrationItem
+	for _, item := range items {
+		switch item.ItemType {
+		case ItemSchema:
+			schemaItem = item
+		case ItemData:
+			dataTables[item.ItemName] = true
+		}
+	}
+
+	if schemaItem == nil {
+		t.Fatal("expected a schema item")
+	}
+	var meta SchemaItemMetadata
+	if err := json.Unmarshal(schemaItem.Metadata, &meta); err != nil {
+		t.Fatalf("invalid schema metadata: %v", err)
+	}
+	if !reflect.DeepEqual(meta.Tables, []string{"users", "posts", "tags"}) {
+		t.Errorf("unexpected schema tables: %v", meta.Tables)
+	}
+	if !reflect.DeepEqual(dataTables, map[string]bool{"posts": true, "logs": true, "tags": true}) {
+		t.Errorf("unexpected data tables: %v", dataTables)
+	}
+
+	err = svc.SelectItems(m.ID, SelectItemsRequest{Tables: []TableSelection{{Name: "x", Mode: "everything"}}})
+	if err == nil {
+		t.Error("expected invalid mode to be rejected")
+	}
+}
+
+func TestSortItemsForRun(t *testing.T) {
+	items := []*MigrationItem{
+		{ItemType: ItemData, ItemName: "posts"},
+		{ItemType: ItemRLS},
+		{ItemType: ItemSchema},
+		{ItemType: ItemData, ItemName: "comments"},
+		{ItemType: ItemUsers},
+	}
+	sortItemsForRun(items)
+
+	var got []string
+	for _, item := range items {
+		got = append(got, string(item.ItemType)+":"+item.ItemName)
+	}
+	want := []string{"schema:", "users:", "data:posts", "data:comments", "rls:"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrShuttingDown is returned when a run or rollback is requested after
+// Shutdown was called.
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// interruptedMessage is recorded on migrations and items cut short by a
+// server shutdown. Such migrations are marked failed, so the usual retry
+// and rollback apply.
+const interruptedMessage = "interrupted by server shutdown"
+
+// beginRun registers an active run, refusing once the service is draining.
+func (s *Service) beginRun() error {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if s.draining {
+		return ErrShuttingDown
+	}
+	s.active.Add(1)
+	return nil
+}
+
+// endRun unregisters a run started with beginRun.
+func (s *Service) endRun() {
+	s.active.Done()
+}
+
+// isDraining reports whether Shutdown has been called. Runs check it between
+// items, which are the safe points a migration can stop at.
+func (s *Service) isDraining() bool {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	return s.draining
+}
+
+// Shutdown stops new runs and rollbacks, lets active runs finish the items
+// they are working on, and waits for them until ctx is done. Items not yet
+// started stay pending and the migration is marked failed with
+// interruptedMessage, so it can be retried after a restart. Items still
+// running when ctx expires are reported by RecoverInterrupted on the next
+// start.
+func (s *Service) Shutdown(ctx context.Context) error {
+	s.runMu.Lock()
+	s.draining = true
+	s.runMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("migrations still running: %w", ctx.Err())
+	}
+}
+
+// RecoverInterrupted marks migrations left in_progress by a previous process
+// as failed with interruptedMessage, failing the items that were mid-flight
+// so a retry runs them again. It returns the number of migrations marked.
+func (s *Service) RecoverInterrupted() (int, error) {
+	migrations, err := s.state.ListMigrations()
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for _, m := range migrations {
+		if m.Status != StatusInProgress {
+			continue
+		}
+		items, err := s.state.GetItems(m.ID)
+		if err != nil {
+			return recovered, fmt.Errorf("get items: %w", err)
+		}
+		for _, item := range items {
+			if item.Status == ItemInProgress {
+				if err := s.markItemFailed(item, errors.New(interruptedMessage)); err != nil {
+					return recovered, fmt.Errorf("update item %s: %w", item.ID, err)
+				}
+			}
+		}
+		m.Status = StatusFailed
+		m.ErrorMessage = interruptedMessage
+		if err := s.state.UpdateMigration(m); err != nil {
+			return recovered, fmt.Errorf("update migration %s: %w", m.ID, err)
+		}
+		recovered++
+	}
+	return recovered, nil
+}
+
+// markInterrupted records that a run stopped early because of a shutdown.
+func (s *Service) markInterrupted(m *Migration) error {
+	now := time.Now().UTC()
+	m.Status = StatusFailed
+	m.ErrorMessage = interruptedMessage
+	m.CompletedAt = &now
+	return s.state.UpdateMigration(m)
+}
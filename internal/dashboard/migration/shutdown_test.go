@@ -0,0 +1,128 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdownRefusesNewRuns(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(db, &ServerConfig{})
+	m, err := svc.StartMigration("")
+	if err != nil {
+		t.Fatalf("StartMigration failed: %v", err)
+	}
+
+	if err := svc.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := svc.RunMigration(m.ID); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown from RunMigration, got %v", err)
+	}
+	if err := svc.Rollback(m.ID); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown from Rollback, got %v", err)
+	}
+	if err := svc.RetryFailedItems(m.ID); !errors.Is(err, ErrShuttingDown) {
+		t.Errorf("expected ErrShuttingDown from RetryFailedItems, got %v", err)
+	}
+}
+
+func TestShutdownWaitsForActiveRuns(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(db, &ServerConfig{})
+	if err := svc.beginRun(); err != nil {
+		t.Fatalf("beginRun failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := svc.Shutdown(ctx); err == nil {
+		t.Fatal("expected Shutdown to time out while a run is active")
+	}
+	if !svc.isDraining() {
+		t.Error("expected service to be draining")
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		svc.endRun()
+	}()
+	if err := svc.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected Shutdown to return once the run ended, got %v", err)
+	}
+}
+
+func TestRecoverInterrupted(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(db, &ServerConfig{})
+	m, err := svc.StartMigration("")
+	if err != nil {
+		t.Fatalf("StartMigration failed: %v", err)
+	}
+	done, err := svc.state.CreateItem(m.ID, ItemSchema, "posts")
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+	running, err := svc.state.CreateItem(m.ID, ItemData, "posts")
+	if err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+	if _, err := svc.state.CreateItem(m.ID, ItemRLS, "posts"); err != nil {
+		t.Fatalf("CreateItem failed: %v", err)
+	}
+	if err := svc.markItemCompleted(done, nil); err != nil {
+		t.Fatalf("markItemCompleted failed: %v", err)
+	}
+	if err := svc.markItemStarted(running); err != nil {
+		t.Fatalf("markItemStarted failed: %v", err)
+	}
+	m.Status = StatusInProgress
+	if err := svc.state.UpdateMigration(m); err != nil {
+		t.Fatalf("UpdateMigration failed: %v", err)
+	}
+
+	n, err := svc.RecoverInterrupted()
+	if err != nil {
+		t.Fatalf("RecoverInterrupted failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 migration recovered, got %d", n)
+	}
+
+	m, err = svc.GetMigration(m.ID)
+	if err != nil {
+		t.Fatalf("GetMigration failed: %v", err)
+	}
+	if m.Status != StatusFailed || m.ErrorMessage != interruptedMessage {
+		t.Errorf("expected failed/%q, got %s/%q", interruptedMessage, m.Status, m.ErrorMessage)
+	}
+
+	counts, err := svc.CountItemsByStatus(m.ID)
+	if err != nil {
+		t.Fatalf("CountItemsByStatus failed: %v", err)
+	}
+	if counts[ItemCompleted] != 1 || counts[ItemFailed] != 1 || counts[ItemPending] != 1 {
+		t.Errorf("unexpected item counts: %v", counts)
+	}
+
+	// Interrupted migrations can be retried
+	if err := svc.RetryFailedItems(m.ID); err != nil {
+		t.Fatalf("RetryFailedItems failed: %v", err)
+	}
+	counts, _ = svc.CountItemsByStatus(m.ID)
+	if counts[ItemPending] != 2 {
+		t.Errorf("expected 2 pending items after retry, got %v", counts)
+	}
+
+	if n, _ := svc.RecoverInterrupted(); n != 0 {
+		t.Errorf("expected nothing left to recover, got %d", n)
+	}
+}
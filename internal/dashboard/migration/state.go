@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -58,6 +59,7 @@ const (
 	LayerBasic      VerificationLayer = "basic"
 	LayerIntegrity  VerificationLayer = "integrity"
 	LayerFunctional VerificationLayer = "functional"
+	LayerRollback   VerificationLayer = "rollback"
 )
 
 // VerificationStatus represents the status of a verification.
@@ -80,6 +82,7 @@ type Migration struct {
 	UpdatedAt            time.Time       `json:"updated_at"`
 	CompletedAt          *time.Time      `json:"completed_at,omitempty"`
 	ErrorMessage         string          `json:"error_message,omitempty"`
+	WebhookURL           string          `json:"webhook_url,omitempty"`
 	CredentialsEncrypted []byte          `json:"-"`
 }
 
@@ -111,6 +114,8 @@ type Verification struct {
 // StateStore manages migration state in the database.
 type StateStore struct {
 	db *sql.DB
+	// itemMu serializes item updates, which may come from parallel data workers.
+	itemMu sync.Mutex
 }
 
 // NewStateStore creates a new StateStore.
@@ -143,7 +148,7 @@ func (s *StateStore) CreateMigration() (*Migration, error) {
 func (s *StateStore) GetMigration(id string) (*Migration, error) {
 	row := s.db.QueryRow(`
 		SELECT id, status, supabase_project_ref, supabase_project_name,
-		       created_at, updated_at, completed_at, error_message, credentials_encrypted
+		       created_at, updated_at, completed_at, error_message, credentials_encrypted, webhook_url
 		FROM _migrations
 		WHERE id = ?
 	`, id)
@@ -151,13 +156,13 @@ func (s *StateStore) GetMigration(id string) (*Migration, error) {
 	var m Migration
 	var projectRef, projectName sql.NullString
 	var completedAt sql.NullString
-	var errorMsg sql.NullString
+	var errorMsg, webhookURL sql.NullString
 	var createdAtStr, updatedAtStr string
 	var credentials []byte
 
 	err := row.Scan(
 		&m.ID, &m.Status, &projectRef, &projectName,
-		&createdAtStr, &updatedAtStr, &completedAt, &errorMsg, &credentials,
+		&createdAtStr, &updatedAtStr, &completedAt, &errorMsg, &credentials, &webhookURL,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -169,6 +174,7 @@ func (s *StateStore) GetMigration(id string) (*Migration, error) {
 	m.SupabaseProjectRef = projectRef.String
 	m.SupabaseProjectName = projectName.String
 	m.ErrorMessage = errorMsg.String
+	m.WebhookURL = webhookURL.String
 	m.CredentialsEncrypted = credentials
 
 	m.CreatedAt, err = time.Parse(time.RFC3339, createdAtStr)
@@ -205,11 +211,12 @@ func (s *StateStore) UpdateMigration(m *Migration) error {
 	_, err := s.db.Exec(`
 		UPDATE _migrations
 		SET status = ?, supabase_project_ref = ?, supabase_project_name = ?,
-		    updated_at = ?, completed_at = ?, error_message = ?, credentials_encrypted = ?
+		    updated_at = ?, completed_at = ?, error_message = ?, credentials_encrypted = ?,
+		    webhook_url = ?
 		WHERE id = ?
 	`, m.Status, m.SupabaseProjectRef, m.SupabaseProjectName,
 		m.UpdatedAt.Format(time.RFC3339), completedAt, m.ErrorMessage, m.CredentialsEncrypted,
-		m.ID)
+		m.WebhookURL, m.ID)
 	if err != nil {
 		return fmt.Errorf("update migration: %w", err)
 	}
@@ -221,7 +228,7 @@ func (s *StateStore) UpdateMigration(m *Migration) error {
 func (s *StateStore) ListMigrations() ([]*Migration, error) {
 	rows, err := s.db.Query(`
 		SELECT id, status, supabase_project_ref, supabase_project_name,
-		       created_at, updated_at, completed_at, error_message
+		       created_at, updated_at, completed_at, error_message, webhook_url
 		FROM _migrations
 		ORDER BY created_at DESC
 	`)
@@ -235,12 +242,12 @@ func (s *StateStore) ListMigrations() ([]*Migration, error) {
 		var m Migration
 		var projectRef, projectName sql.NullString
 		var completedAt sql.NullString
-		var errorMsg sql.NullString
+		var errorMsg, webhookURL sql.NullString
 		var createdAtStr, updatedAtStr string
 
 		err := rows.Scan(
 			&m.ID, &m.Status, &projectRef, &projectName,
-			&createdAtStr, &updatedAtStr, &completedAt, &errorMsg,
+			&createdAtStr, &updatedAtStr, &completedAt, &errorMsg, &webhookURL,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("scan migration: %w", err)
@@ -249,6 +256,7 @@ func (s *StateStore) ListMigrations() ([]*Migration, error) {
 		m.SupabaseProjectRef = projectRef.String
 		m.SupabaseProjectName = projectName.String
 		m.ErrorMessage = errorMsg.String
+		m.WebhookURL = webhookURL.String
 
 		m.CreatedAt, err = time.Parse(time.RFC3339, createdAtStr)
 		if err != nil {
@@ -338,8 +346,79 @@ func (s *StateStore) GetItems(migrationID string) ([]*MigrationItem, error) {
 	return items, nil
 }
 
+// ListItems returns one page of a migration's items, optionally restricted
+// to a single status, together with the total number of matching items.
+// An empty status matches all items.
+func (s *StateStore) ListItems(migrationID string, status ItemStatus, limit, offset int) ([]*MigrationItem, int, error) {
+	where := "migration_id = ?"
+	args := []interface{}{migrationID}
+	if status != "" {
+		where += " AND status = ?"
+		args = append(args, status)
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM _migration_items WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count migration items: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, migration_id, item_type, item_name, status,
+		       started_at, completed_at, error_message, rollback_info, metadata
+		FROM _migration_items
+		WHERE `+where+`
+		ORDER BY id
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list migration items: %w", err)
+	}
+	defer rows.Close()
+
+	items := []*MigrationItem{}
+	for rows.Next() {
+		item, err := scanMigrationItem(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("iterate migration items: %w", err)
+	}
+
+	return items, total, nil
+}
+
+// CountItemsByStatus returns the number of a migration's items in each status.
+func (s *StateStore) CountItemsByStatus(migrationID string) (map[ItemStatus]int, error) {
+	rows, err := s.db.Query(`
+		SELECT status, COUNT(*) FROM _migration_items
+		WHERE migration_id = ?
+		GROUP BY status
+	`, migrationID)
+	if err != nil {
+		return nil, fmt.Errorf("count migration items: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[ItemStatus]int)
+	for rows.Next() {
+		var status ItemStatus
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("scan item count: %w", err)
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
+
 // UpdateItem updates all fields of a migration item.
 func (s *StateStore) UpdateItem(item *MigrationItem) error {
+	s.itemMu.Lock()
+	defer s.itemMu.Unlock()
+
 	var startedAt, completedAt *string
 	if item.StartedAt != nil {
 		s := item.StartedAt.Format(time.RFC3339)
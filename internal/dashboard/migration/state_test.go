@@ -3,6 +3,7 @@ package migration
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -20,7 +21,8 @@ CREATE TABLE IF NOT EXISTS _migrations (
     updated_at TEXT NOT NULL DEFAULT (datetime('now')),
     completed_at TEXT,
     error_message TEXT,
-    credentials_encrypted TEXT
+    credentials_encrypted TEXT,
+    webhook_url TEXT
 );
 
 CREATE TABLE IF NOT EXISTS _migration_items (
@@ -39,7 +41,7 @@ CREATE TABLE IF NOT EXISTS _migration_items (
 CREATE TABLE IF NOT EXISTS _migration_verifications (
     id TEXT PRIMARY KEY,
     migration_id TEXT NOT NULL REFERENCES _migrations(id) ON DELETE CASCADE,
-    layer TEXT NOT NULL CHECK (layer IN ('basic', 'integrity', 'functional')),
+    layer TEXT NOT NULL CHECK (layer IN ('basic', 'integrity', 'functional', 'rollback')),
     status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'passed', 'failed')),
     started_at TEXT,
     completed_at TEXT,
@@ -1060,3 +1062,61 @@ func TestEmptyResultsHandling(t *testing.T) {
 		t.Errorf("expected results %q, got %q", `{}`, string(verifications[0].Results))
 	}
 }
+
+func TestListItemsPagination(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	store := NewStateStore(db)
+	m, _ := store.CreateMigration()
+
+	for i := 0; i < 5; i++ {
+		item, err := store.CreateItem(m.ID, ItemSchema, fmt.Sprintf("table_%d", i))
+		if err != nil {
+			t.Fatalf("CreateItem failed: %v", err)
+		}
+		if i < 2 {
+			item.Status = ItemFailed
+			if err := store.UpdateItem(item); err != nil {
+				t.Fatalf("UpdateItem failed: %v", err)
+			}
+		}
+	}
+
+	page, total, err := store.ListItems(m.ID, "", 2, 0)
+	if err != nil {
+		t.Fatalf("ListItems failed: %v", err)
+	}
+	if total != 5 || len(page) != 2 {
+		t.Errorf("expected 2 of 5 items, got %d of %d", len(page), total)
+	}
+
+	last, _, err := store.ListItems(m.ID, "", 2, 4)
+	if err != nil {
+		t.Fatalf("ListItems failed: %v", err)
+	}
+	if len(last) != 1 {
+		t.Errorf("expected 1 item on the last page, got %d", len(last))
+	}
+
+	failed, total, err := store.ListItems(m.ID, ItemFailed, 10, 0)
+	if err != nil {
+		t.Fatalf("ListItems failed: %v", err)
+	}
+	if total != 2 || len(failed) != 2 {
+		t.Errorf("expected 2 failed items, got %d (total %d)", len(failed), total)
+	}
+	for _, item := range failed {
+		if item.Status != ItemFailed {
+			t.Errorf("expected only failed items, got %q", item.Status)
+		}
+	}
+
+	counts, err := store.CountItemsByStatus(m.ID)
+	if err != nil {
+		t.Fatalf("CountItemsByStatus failed: %v", err)
+	}
+	if counts[ItemFailed] != 2 || counts[ItemPending] != 3 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+}
@@ -4,6 +4,7 @@ package migration
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -18,6 +19,8 @@ type SupabaseClient struct {
 	token      string
 	httpClient *http.Client
 	baseURL    string
+	// migrationID labels retry attempts in the logs.
+	migrationID string
 }
 
 // Project represents a Supabase project.
@@ -68,24 +71,58 @@ func NewSupabaseClient(token string) *SupabaseClient {
 
 // doRequest performs an HTTP request to the Supabase Management API.
 func (c *SupabaseClient) doRequest(method, path string, body []byte) (*http.Response, error) {
-	url := c.baseURL + path
-
-	var bodyReader io.Reader
+	contentType := ""
 	if body != nil {
-		bodyReader = bytes.NewReader(body)
+		contentType = "application/json"
 	}
+	return c.send(method, path, contentType, body)
+}
 
-	req, err := http.NewRequest(method, url, bodyReader)
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
+// send performs a request, retrying network errors and 5xx/429 responses
+// with backoff. When retries are exhausted on a bad status, the last response
+// is returned so callers report it as usual.
+func (c *SupabaseClient) send(method, path, contentType string, body []byte) (*http.Response, error) {
+	url := c.baseURL + path
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	if body != nil {
-		req.Header.Set("Content-Type", "application/json")
+	var resp *http.Response
+	err := withRetry(c.migrationID, method+" "+path, func() error {
+		var bodyReader io.Reader
+		if body != nil {
+			bodyReader = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequest(method, url, bodyReader)
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+c.token)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err = c.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		if isRetriableStatus(resp.StatusCode) {
+			// Buffer the body so the final response can still be read
+			respBody, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+			return &retriableStatusError{status: resp.StatusCode}
+		}
+		return nil
+	})
+
+	var statusErr *retriableStatusError
+	if errors.As(err, &statusErr) {
+		return resp, nil
 	}
-
-	return c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // ValidateToken validates the API token by attempting to list projects.
@@ -230,17 +267,7 @@ func (c *SupabaseClient) DeployFunction(projectRef, slug string, metadata Functi
 		return fmt.Errorf("closing multipart writer: %w", err)
 	}
 
-	// Create request
-	url := c.baseURL + "/v1/projects/" + projectRef + "/functions/deploy"
-	req, err := http.NewRequest(http.MethodPost, url, &buf)
-	if err != nil {
-		return fmt.Errorf("creating deploy request: %w", err)
-	}
-
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.send(http.MethodPost, "/v1/projects/"+projectRef+"/functions/deploy", writer.FormDataContentType(), buf.Bytes())
 	if err != nil {
 		return fmt.Errorf("deploying function: %w", err)
 	}
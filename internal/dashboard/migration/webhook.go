@@ -0,0 +1,135 @@
+package migration
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/markb/sblite/internal/log"
+)
+
+const (
+	// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body,
+	// keyed with the server's JWT secret.
+	WebhookSignatureHeader = "X-Sblite-Signature"
+	// webhookMaxAttempts is how many times a webhook is attempted before giving up.
+	webhookMaxAttempts = 4
+)
+
+// webhookRetryDelay is the base delay between delivery attempts; it doubles
+// after each failure. It is a variable so tests can shorten it.
+var webhookRetryDelay = 2 * time.Second
+
+// WebhookPayload is the body POSTed to a migration's webhook URL when the
+// migration reaches a terminal state.
+type WebhookPayload struct {
+	Event        string             `json:"event"`
+	MigrationID  string             `json:"migration_id"`
+	Status       MigrationStatus    `json:"status"`
+	ErrorMessage string             `json:"error_message,omitempty"`
+	CompletedAt  *time.Time         `json:"completed_at,omitempty"`
+	Progress     *MigrationProgress `json:"progress,omitempty"`
+	FailedItems  []*MigrationItem   `json:"failed_items,omitempty"`
+}
+
+// ValidateWebhookURL checks that a webhook URL is an absolute http(s) URL.
+func ValidateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL: must be an absolute http or https URL")
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the hex-encoded HMAC-SHA256 of body keyed with secret.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// notifyWebhook sends the terminal status of a migration to its webhook URL, if any.
+// Delivery runs in the background so it never delays the migration itself.
+func (s *Service) notifyWebhook(m *Migration) {
+	if m.WebhookURL == "" {
+		return
+	}
+
+	payload := WebhookPayload{
+		Event:        "migration." + string(m.Status),
+		MigrationID:  m.ID,
+		Status:       m.Status,
+		ErrorMessage: m.ErrorMessage,
+		CompletedAt:  m.CompletedAt,
+	}
+	if progress, err := s.GetProgress(m.ID); err == nil {
+		payload.Progress = progress
+	}
+	if items, err := s.state.GetItems(m.ID); err == nil {
+		for _, item := range items {
+			if item.Status == ItemFailed {
+				payload.FailedItems = append(payload.FailedItems, item)
+			}
+		}
+	}
+
+	go func() {
+		if err := s.deliverWebhook(m.WebhookURL, payload); err != nil {
+			log.Warn("migration webhook delivery failed", "migration_id", m.ID, "error", err)
+		}
+	}()
+}
+
+// deliverWebhook POSTs the signed payload, retrying with exponential backoff on
+// network errors and non-2xx responses.
+func (s *Service) deliverWebhook(webhookURL string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal webhook payload: %w", err)
+	}
+
+	secret := ""
+	if s.serverConfig != nil {
+		secret = s.serverConfig.JWTSecret
+	}
+	signature := SignWebhookPayload(secret, body)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	delay := webhookRetryDelay
+	var lastErr error
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("create webhook request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(WebhookSignatureHeader, "sha256="+signature)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return fmt.Errorf("after %d attempts: %w", webhookMaxAttempts, lastErr)
+}
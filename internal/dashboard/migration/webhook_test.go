@@ -0,0 +1,112 @@
+package migration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	valid := []string{"https://ci.example.com/hook", "http://localhost:9000/cb"}
+	for _, u := range valid {
+		if err := ValidateWebhookURL(u); err != nil {
+			t.Errorf("expected %q to be valid, got %v", u, err)
+		}
+	}
+
+	invalid := []string{"ftp://example.com", "/relative/path", "not a url", "https://"}
+	for _, u := range invalid {
+		if err := ValidateWebhookURL(u); err == nil {
+			t.Errorf("expected %q to be invalid", u)
+		}
+	}
+}
+
+func TestStartMigrationStoresWebhookURL(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	svc := NewService(db, &ServerConfig{JWTSecret: "secret"})
+	m, err := svc.StartMigration("https://ci.example.com/hook")
+	if err != nil {
+		t.Fatalf("StartMigration failed: %v", err)
+	}
+
+	got, err := svc.GetMigration(m.ID)
+	if err != nil {
+		t.Fatalf("GetMigration failed: %v", err)
+	}
+	if got.WebhookURL != "https://ci.example.com/hook" {
+		t.Errorf("expected webhook URL to be persisted, got %q", got.WebhookURL)
+	}
+
+	if _, err := svc.StartMigration("ftp://example.com"); err == nil {
+		t.Error("expected invalid webhook URL to be rejected")
+	}
+}
+
+func TestDeliverWebhookSignsAndRetries(t *testing.T) {
+	oldDelay := webhookRetryDelay
+	webhookRetryDelay = time.Millisecond
+	defer func() { webhookRetryDelay = oldDelay }()
+
+	var attempts atomic.Int32
+	var gotBody []byte
+	var gotSignature string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		gotBody, _ = io.ReadAll(r.Body)
+		gotSignature = r.Header.Get(WebhookSignatureHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	svc := NewService(nil, &ServerConfig{JWTSecret: "secret"})
+	payload := WebhookPayload{Event: "migration.completed", MigrationID: "m1", Status: StatusCompleted}
+	if err := svc.deliverWebhook(srv.URL, payload); err != nil {
+		t.Fatalf("deliverWebhook failed: %v", err)
+	}
+
+	if attempts.Load() != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts.Load())
+	}
+	if gotSignature != "sha256="+SignWebhookPayload("secret", gotBody) {
+		t.Errorf("signature mismatch: %s", gotSignature)
+	}
+
+	var decoded WebhookPayload
+	if err := json.Unmarshal(gotBody, &decoded); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	if decoded.MigrationID != "m1" || decoded.Status != StatusCompleted {
+		t.Errorf("unexpected payload: %+v", decoded)
+	}
+}
+
+func TestDeliverWebhookGivesUp(t *testing.T) {
+	oldDelay := webhookRetryDelay
+	webhookRetryDelay = time.Millisecond
+	defer func() { webhookRetryDelay = oldDelay }()
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	svc := NewService(nil, &ServerConfig{JWTSecret: "secret"})
+	if err := svc.deliverWebhook(srv.URL, WebhookPayload{MigrationID: "m1"}); err == nil {
+		t.Fatal("expected delivery to fail")
+	}
+	if int(attempts.Load()) != webhookMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", webhookMaxAttempts, attempts.Load())
+	}
+}
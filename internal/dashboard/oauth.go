@@ -1,10 +1,24 @@
 package dashboard
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/oauth"
 )
 
+// oauthTestTimeout bounds a provider configuration test.
+const oauthTestTimeout = 20 * time.Second
+
+// checkOAuthProvider runs the live provider checks. Replaced in tests.
+var checkOAuthProvider = func(ctx context.Context, name string, cfg oauth.Config) ([]oauth.CheckResult, error) {
+	return oauth.CheckProvider(ctx, nil, name, cfg)
+}
+
 // OAuthProviderConfig holds configuration for an OAuth provider.
 type OAuthProviderConfig struct {
 	ClientID     string `json:"client_id"`
@@ -45,7 +59,7 @@ func (h *Handler) handleGetOAuthSettings(w http.ResponseWriter, r *http.Request)
 func (h *Handler) handleUpdateOAuthSettings(w http.ResponseWriter, r *http.Request) {
 	var updates map[string]OAuthProviderConfig
 	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
 		return
 	}
 
@@ -71,14 +85,80 @@ func (h *Handler) handleUpdateOAuthSettings(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(map[string]string{"status": "updated"})
 }
 
+// handleTestOAuthProvider checks a provider's configuration against the live
+// provider before users try to sign in. The body may override the stored
+// client credentials and the callback URL, which defaults to this server's
+// /auth/v1/callback as seen by the request.
+// POST /_/api/settings/oauth/{provider}/test
+func (h *Handler) handleTestOAuthProvider(w http.ResponseWriter, r *http.Request) {
+	provider := chi.URLParam(r, "provider")
+
+	var req struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		RedirectURL  string `json:"redirect_url"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+			return
+		}
+	}
+
+	prefix := "oauth_" + provider + "_"
+	cfg := oauth.Config{
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		RedirectURL:  req.RedirectURL,
+	}
+	if cfg.ClientID == "" {
+		cfg.ClientID, _ = h.store.Get(prefix + "client_id")
+	}
+	if cfg.ClientSecret == "" || cfg.ClientSecret == "********" {
+		cfg.ClientSecret, _ = h.store.Get(prefix + "client_secret")
+	}
+	if cfg.RedirectURL == "" {
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		cfg.RedirectURL = scheme + "://" + r.Host + "/auth/v1/callback"
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), oauthTestTimeout)
+	defer cancel()
+
+	checks, err := checkOAuthProvider(ctx, provider, cfg)
+	if errors.Is(err, oauth.ErrProviderNotFound) {
+		writeError(w, http.StatusNotFound, "provider_not_found", "unknown provider")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	success := true
+	for _, c := range checks {
+		if !c.OK {
+			success = false
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"provider":     provider,
+		"redirect_url": cfg.RedirectURL,
+		"success":      success,
+		"checks":       checks,
+	})
+}
+
 // handleGetRedirectURLs returns allowed OAuth redirect URLs.
 // GET /_/api/settings/oauth/redirect-urls
 func (h *Handler) handleGetRedirectURLs(w http.ResponseWriter, r *http.Request) {
-	urlsJSON, _ := h.store.Get("oauth_redirect_urls")
-	var urls []string
-	if urlsJSON != "" {
-		json.Unmarshal([]byte(urlsJSON), &urls)
-	}
+	urls := h.getRedirectURLs()
 	if urls == nil {
 		urls = []string{}
 	}
@@ -94,26 +174,26 @@ func (h *Handler) handleAddRedirectURL(w http.ResponseWriter, r *http.Request) {
 		URL string `json:"url"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
 		return
 	}
 
 	if req.URL == "" {
-		http.Error(w, "url is required", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "missing_field", "url is required")
+		return
+	}
+	if err := oauth.ValidateRedirectPattern(req.URL); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_redirect_pattern", err.Error())
 		return
 	}
 
 	// Get existing URLs
-	urlsJSON, _ := h.store.Get("oauth_redirect_urls")
-	var urls []string
-	if urlsJSON != "" {
-		json.Unmarshal([]byte(urlsJSON), &urls)
-	}
+	urls := h.getRedirectURLs()
 
 	// Add new URL if not already present
 	for _, u := range urls {
 		if u == req.URL {
-			http.Error(w, "URL already exists", http.StatusConflict)
+			writeError(w, http.StatusConflict, "redirect_url_exists", "URL already exists")
 			return
 		}
 	}
@@ -135,15 +215,11 @@ func (h *Handler) handleDeleteRedirectURL(w http.ResponseWriter, r *http.Request
 		URL string `json:"url"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
 		return
 	}
 
-	urlsJSON, _ := h.store.Get("oauth_redirect_urls")
-	var urls []string
-	if urlsJSON != "" {
-		json.Unmarshal([]byte(urlsJSON), &urls)
-	}
+	urls := h.getRedirectURLs()
 
 	// Remove URL
 	var newURLs []string
@@ -160,6 +236,52 @@ func (h *Handler) handleDeleteRedirectURL(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(map[string]string{"status": "deleted"})
 }
 
+// handleTestRedirectURL reports whether a candidate URL matches an allowed
+// redirect URL pattern.
+// POST /_/api/settings/oauth/redirect-urls/test
+func (h *Handler) handleTestRedirectURL(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+	if req.URL == "" {
+		writeError(w, http.StatusBadRequest, "missing_field", "url is required")
+		return
+	}
+
+	urls := h.getRedirectURLs()
+	matched := make([]string, 0)
+	for _, pattern := range urls {
+		if oauth.MatchRedirectURL(pattern, req.URL) {
+			matched = append(matched, pattern)
+		}
+	}
+
+	// With no patterns configured, every redirect URL is allowed
+	open := len(urls) == 0
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"url":     req.URL,
+		"allowed": open || len(matched) > 0,
+		"matches": matched,
+		"open":    open,
+	})
+}
+
+// getRedirectURLs returns the stored allowed redirect URL patterns.
+func (h *Handler) getRedirectURLs() []string {
+	urlsJSON, _ := h.store.Get("oauth_redirect_urls")
+	var urls []string
+	if urlsJSON != "" {
+		json.Unmarshal([]byte(urlsJSON), &urls)
+	}
+	return urls
+}
+
 // maskSecret returns a masked version of a secret.
 func maskSecret(secret string) string {
 	if secret == "" {
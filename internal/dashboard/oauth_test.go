@@ -1,6 +1,7 @@
 package dashboard
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/oauth"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,7 +27,7 @@ func TestGetOAuthSettings(t *testing.T) {
 	sessionToken := setupTestSession(t, handler)
 
 	req := httptest.NewRequest("GET", "/api/settings/oauth", nil)
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req, sessionToken)
 	w := httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
@@ -77,7 +79,7 @@ func TestUpdateOAuthSettings(t *testing.T) {
 
 	req := httptest.NewRequest("PATCH", "/api/settings/oauth", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req, sessionToken)
 	w := httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
@@ -86,7 +88,7 @@ func TestUpdateOAuthSettings(t *testing.T) {
 
 	// Verify settings were saved
 	req2 := httptest.NewRequest("GET", "/api/settings/oauth", nil)
-	req2.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req2, sessionToken)
 	w2 := httptest.NewRecorder()
 
 	r.ServeHTTP(w2, req2)
@@ -123,7 +125,7 @@ func TestUpdateOAuthSettingsPreserveSecret(t *testing.T) {
 
 	req1 := httptest.NewRequest("PATCH", "/api/settings/oauth", strings.NewReader(body1))
 	req1.Header.Set("Content-Type", "application/json")
-	req1.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req1, sessionToken)
 	w1 := httptest.NewRecorder()
 
 	r.ServeHTTP(w1, req1)
@@ -140,7 +142,7 @@ func TestUpdateOAuthSettingsPreserveSecret(t *testing.T) {
 
 	req2 := httptest.NewRequest("PATCH", "/api/settings/oauth", strings.NewReader(body2))
 	req2.Header.Set("Content-Type", "application/json")
-	req2.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req2, sessionToken)
 	w2 := httptest.NewRecorder()
 
 	r.ServeHTTP(w2, req2)
@@ -148,7 +150,7 @@ func TestUpdateOAuthSettingsPreserveSecret(t *testing.T) {
 
 	// Verify client_id was updated but secret preserved (still shows masked)
 	req3 := httptest.NewRequest("GET", "/api/settings/oauth", nil)
-	req3.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req3, sessionToken)
 	w3 := httptest.NewRecorder()
 
 	r.ServeHTTP(w3, req3)
@@ -183,7 +185,7 @@ func TestRedirectURLManagement(t *testing.T) {
 	body := `{"url": "http://localhost:3000/callback"}`
 	req := httptest.NewRequest("POST", "/api/settings/oauth/redirect-urls", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req, sessionToken)
 	w := httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
@@ -192,7 +194,7 @@ func TestRedirectURLManagement(t *testing.T) {
 
 	// List redirect URLs
 	req2 := httptest.NewRequest("GET", "/api/settings/oauth/redirect-urls", nil)
-	req2.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req2, sessionToken)
 	w2 := httptest.NewRecorder()
 
 	r.ServeHTTP(w2, req2)
@@ -220,7 +222,7 @@ func TestRedirectURLDuplicate(t *testing.T) {
 	body := `{"url": "http://localhost:3000/callback"}`
 	req := httptest.NewRequest("POST", "/api/settings/oauth/redirect-urls", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req, sessionToken)
 	w := httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
@@ -230,7 +232,7 @@ func TestRedirectURLDuplicate(t *testing.T) {
 	// Try to add same URL again
 	req2 := httptest.NewRequest("POST", "/api/settings/oauth/redirect-urls", strings.NewReader(body))
 	req2.Header.Set("Content-Type", "application/json")
-	req2.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req2, sessionToken)
 	w2 := httptest.NewRecorder()
 
 	r.ServeHTTP(w2, req2)
@@ -253,7 +255,7 @@ func TestRedirectURLDelete(t *testing.T) {
 	body1 := `{"url": "http://localhost:3000/callback"}`
 	req1 := httptest.NewRequest("POST", "/api/settings/oauth/redirect-urls", strings.NewReader(body1))
 	req1.Header.Set("Content-Type", "application/json")
-	req1.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req1, sessionToken)
 	w1 := httptest.NewRecorder()
 	r.ServeHTTP(w1, req1)
 	assert.Equal(t, http.StatusCreated, w1.Code)
@@ -261,7 +263,7 @@ func TestRedirectURLDelete(t *testing.T) {
 	body2 := `{"url": "http://localhost:4000/callback"}`
 	req2 := httptest.NewRequest("POST", "/api/settings/oauth/redirect-urls", strings.NewReader(body2))
 	req2.Header.Set("Content-Type", "application/json")
-	req2.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req2, sessionToken)
 	w2 := httptest.NewRecorder()
 	r.ServeHTTP(w2, req2)
 	assert.Equal(t, http.StatusCreated, w2.Code)
@@ -270,14 +272,14 @@ func TestRedirectURLDelete(t *testing.T) {
 	deleteBody := `{"url": "http://localhost:3000/callback"}`
 	req3 := httptest.NewRequest("DELETE", "/api/settings/oauth/redirect-urls", strings.NewReader(deleteBody))
 	req3.Header.Set("Content-Type", "application/json")
-	req3.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req3, sessionToken)
 	w3 := httptest.NewRecorder()
 	r.ServeHTTP(w3, req3)
 	assert.Equal(t, http.StatusOK, w3.Code)
 
 	// Verify only second URL remains
 	req4 := httptest.NewRequest("GET", "/api/settings/oauth/redirect-urls", nil)
-	req4.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req4, sessionToken)
 	w4 := httptest.NewRecorder()
 	r.ServeHTTP(w4, req4)
 
@@ -306,7 +308,7 @@ func TestRedirectURLEmptyURL(t *testing.T) {
 	body := `{"url": ""}`
 	req := httptest.NewRequest("POST", "/api/settings/oauth/redirect-urls", strings.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(&http.Cookie{Name: "_sblite_session", Value: sessionToken})
+	addTestSession(req, sessionToken)
 	w := httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
@@ -334,3 +336,96 @@ func TestBoolToString(t *testing.T) {
 	assert.Equal(t, "true", boolToString(true))
 	assert.Equal(t, "false", boolToString(false))
 }
+
+func TestTestOAuthProvider(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir()+"/migrations")
+	handler.store.Set("oauth_google_client_id", "stored-id")
+	handler.store.Set("oauth_google_client_secret", "stored-secret")
+
+	var got oauth.Config
+	orig := checkOAuthProvider
+	checkOAuthProvider = func(ctx context.Context, name string, cfg oauth.Config) ([]oauth.CheckResult, error) {
+		if name != "google" {
+			return nil, oauth.ErrProviderNotFound
+		}
+		got = cfg
+		return []oauth.CheckResult{
+			{Check: oauth.CheckConfig, OK: true},
+			{Check: oauth.CheckRedirectURL, Error: "redirect URL is not registered"},
+		}, nil
+	}
+	defer func() { checkOAuthProvider = orig }()
+
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+	sessionToken := setupTestSession(t, handler)
+
+	req := httptest.NewRequest("POST", "/api/settings/oauth/google/test", strings.NewReader(`{"client_secret": "********"}`))
+	req.Host = "app.example.com"
+	addTestSession(req, sessionToken)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, false, resp["success"])
+	assert.Equal(t, "http://app.example.com/auth/v1/callback", resp["redirect_url"])
+	assert.Len(t, resp["checks"], 2)
+	assert.Equal(t, "stored-id", got.ClientID)
+	assert.Equal(t, "stored-secret", got.ClientSecret)
+
+	req = httptest.NewRequest("POST", "/api/settings/oauth/myspace/test", nil)
+	addTestSession(req, sessionToken)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestRedirectURLPatterns(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir()+"/migrations")
+
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	sessionToken := setupTestSession(t, handler)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestSession(req, sessionToken)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Overly broad patterns are rejected
+	w := post("/api/settings/oauth/redirect-urls", `{"url": "*"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = post("/api/settings/oauth/redirect-urls", `{"url": "https://*.app"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post("/api/settings/oauth/redirect-urls", `{"url": "https://*.vercel.app/**"}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var resp struct {
+		Allowed bool     `json:"allowed"`
+		Matches []string `json:"matches"`
+	}
+	w = post("/api/settings/oauth/redirect-urls/test", `{"url": "https://my-app-pr-12.vercel.app/auth/callback"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.True(t, resp.Allowed)
+	assert.Equal(t, []string{"https://*.vercel.app/**"}, resp.Matches)
+
+	w = post("/api/settings/oauth/redirect-urls/test", `{"url": "https://evil.com/callback"}`)
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.False(t, resp.Allowed)
+	assert.Empty(t, resp.Matches)
+}
@@ -0,0 +1,235 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxPoliciesImportSize bounds an uploaded policies export.
+const maxPoliciesImportSize = 8 << 20
+
+// ExportedPolicy is one _rls_policies row as written by
+// GET /_/api/export/policies.json. sblite policies are always permissive;
+// Permissive is carried so the file states it explicitly.
+type ExportedPolicy struct {
+	TableName  string `json:"table_name"`
+	PolicyName string `json:"policy_name"`
+	Command    string `json:"command"`
+	UsingExpr  string `json:"using_expr"`
+	CheckExpr  string `json:"check_expr"`
+	Enabled    *bool  `json:"enabled,omitempty"`
+	Permissive *bool  `json:"permissive,omitempty"`
+}
+
+// PolicyImportResult is the outcome of importing one policy.
+type PolicyImportResult struct {
+	Index      int    `json:"index"`
+	TableName  string `json:"table_name,omitempty"`
+	PolicyName string `json:"policy_name,omitempty"`
+	Status     string `json:"status"` // created, updated, skipped or error
+	Error      string `json:"error,omitempty"`
+}
+
+// validateExportedPolicy checks one imported policy the same way
+// handleCreatePolicy checks a new one.
+func validateExportedPolicy(p ExportedPolicy) error {
+	if p.TableName == "" || p.PolicyName == "" || p.Command == "" {
+		return fmt.Errorf("table_name, policy_name, and command are required")
+	}
+	if !policyCommands[p.Command] {
+		return fmt.Errorf("command must be SELECT, INSERT, UPDATE, DELETE, or ALL")
+	}
+	if p.Permissive != nil && !*p.Permissive {
+		return fmt.Errorf("restrictive policies are not supported")
+	}
+	return nil
+}
+
+// handleExportPolicies exports the _rls_policies rows as JSON that
+// handleImportPolicies reads back unchanged, unlike the SQL from
+// handleExportRLS which is meant for Supabase.
+// GET /_/api/export/policies.json
+func (h *Handler) handleExportPolicies(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(`
+		SELECT table_name, policy_name, command, using_expr, check_expr, enabled
+		FROM _rls_policies
+		ORDER BY table_name, policy_name
+	`)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to query policies")
+		return
+	}
+	defer rows.Close()
+
+	policies := []ExportedPolicy{}
+	permissive := true
+	for rows.Next() {
+		var p ExportedPolicy
+		var usingExpr, checkExpr sql.NullString
+		var enabled int
+		if err := rows.Scan(&p.TableName, &p.PolicyName, &p.Command, &usingExpr, &checkExpr, &enabled); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan policy")
+			return
+		}
+		p.UsingExpr = usingExpr.String
+		p.CheckExpr = checkExpr.String
+		isEnabled := enabled == 1
+		p.Enabled = &isEnabled
+		p.Permissive = &permissive
+		policies = append(policies, p)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to iterate policies")
+		return
+	}
+
+	export := struct {
+		ExportedAt string           `json:"exported_at"`
+		Count      int              `json:"count"`
+		Policies   []ExportedPolicy `json:"policies"`
+	}{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Count:      len(policies),
+		Policies:   policies,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", "attachment; filename=rls-policies.json")
+	json.NewEncoder(w).Encode(export)
+}
+
+// handleImportPolicies imports policies from the JSON produced by
+// GET /_/api/export/policies.json. Every policy is validated first and
+// nothing is written if any is invalid. A policy conflicts when its table
+// already has a policy of that name; conflicts are skipped by default,
+// overwritten with ?on_conflict=update, or with ?on_conflict=error reported
+// as a 409 without importing anything. All writes share one transaction.
+// POST /_/api/import/policies
+func (h *Handler) handleImportPolicies(w http.ResponseWriter, r *http.Request) {
+	onConflict := r.URL.Query().Get("on_conflict")
+	if onConflict == "" {
+		onConflict = "skip"
+	}
+	if onConflict != "skip" && onConflict != "update" && onConflict != "error" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "on_conflict must be skip, update or error")
+		return
+	}
+
+	var body struct {
+		Policies []ExportedPolicy `json:"policies"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxPoliciesImportSize)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid policies export: "+err.Error())
+		return
+	}
+	if len(body.Policies) == 0 {
+		writeError(w, http.StatusBadRequest, "missing_field", "policies is required")
+		return
+	}
+
+	invalid := []PolicyImportResult{}
+	seen := make(map[[2]string]bool, len(body.Policies))
+	for i, p := range body.Policies {
+		err := validateExportedPolicy(p)
+		key := [2]string{p.TableName, p.PolicyName}
+		if err == nil && seen[key] {
+			err = fmt.Errorf("duplicate policy %q on table %q", p.PolicyName, p.TableName)
+		}
+		seen[key] = true
+		if err != nil {
+			invalid = append(invalid, PolicyImportResult{Index: i, TableName: p.TableName,
+				PolicyName: p.PolicyName, Status: "error", Error: err.Error()})
+		}
+	}
+	if len(invalid) > 0 {
+		writeErrorDetails(w, http.StatusBadRequest, "invalid_policies",
+			fmt.Sprintf("%d policies are invalid; nothing was imported", len(invalid)),
+			map[string]interface{}{"errors": invalid})
+		return
+	}
+
+	var results []PolicyImportResult
+	var conflicts []PolicyImportResult
+	counts := map[string]int{}
+	err := h.runWrite(r, func() error {
+		results = make([]PolicyImportResult, 0, len(body.Policies))
+		conflicts = []PolicyImportResult{}
+		counts = map[string]int{"created": 0, "updated": 0, "skipped": 0}
+		tx, err := h.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for i, p := range body.Policies {
+			res := PolicyImportResult{Index: i, TableName: p.TableName, PolicyName: p.PolicyName}
+			enabled := 1
+			if p.Enabled != nil && !*p.Enabled {
+				enabled = 0
+			}
+
+			var existingID int64
+			err := tx.QueryRow(`SELECT id FROM _rls_policies WHERE table_name = ? AND policy_name = ?`,
+				p.TableName, p.PolicyName).Scan(&existingID)
+			switch {
+			case err == sql.ErrNoRows:
+				if _, err := tx.Exec(`
+					INSERT INTO _rls_policies (table_name, policy_name, command, using_expr, check_expr, enabled)
+					VALUES (?, ?, ?, ?, ?, ?)
+				`, p.TableName, p.PolicyName, p.Command, p.UsingExpr, p.CheckExpr, enabled); err != nil {
+					return err
+				}
+				res.Status = "created"
+			case err != nil:
+				return err
+			default:
+				conflicts = append(conflicts, res)
+				if onConflict != "update" {
+					res.Status = "skipped"
+					break
+				}
+				if _, err := tx.Exec(`
+					UPDATE _rls_policies SET command = ?, using_expr = ?, check_expr = ?, enabled = ?
+					WHERE id = ?
+				`, p.Command, p.UsingExpr, p.CheckExpr, enabled, existingID); err != nil {
+					return err
+				}
+				res.Status = "updated"
+			}
+			results = append(results, res)
+			counts[res.Status]++
+		}
+
+		if onConflict == "error" && len(conflicts) > 0 {
+			return nil
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	for i := range conflicts {
+		conflicts[i].Status = "conflict"
+	}
+	if onConflict == "error" && len(conflicts) > 0 {
+		writeErrorDetails(w, http.StatusConflict, "policy_conflict",
+			fmt.Sprintf("%d policies already exist; nothing was imported", len(conflicts)),
+			map[string]interface{}{"conflicts": conflicts})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"created":   counts["created"],
+		"updated":   counts["updated"],
+		"skipped":   counts["skipped"],
+		"conflicts": conflicts,
+		"results":   results,
+	})
+}
@@ -0,0 +1,110 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoliciesExportImportRoundTrip(t *testing.T) {
+	src, _ := setupTestHandler(t)
+	_, err := src.db.Exec(`INSERT INTO _rls_policies (table_name, policy_name, command, using_expr, check_expr, enabled) VALUES
+		('posts', 'read_all', 'SELECT', 'true', NULL, 1),
+		('posts', 'insert_own', 'INSERT', NULL, 'auth.uid() = user_id', 0)`)
+	require.NoError(t, err)
+
+	srcToken := setupTestSession(t, src)
+	srcRouter := chi.NewRouter()
+	src.RegisterRoutes(srcRouter)
+
+	req := httptest.NewRequest("GET", "/api/export/policies.json", nil)
+	addTestSession(req, srcToken)
+	w := httptest.NewRecorder()
+	srcRouter.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	exported := w.Body.String()
+	require.Contains(t, exported, `"permissive":true`)
+
+	dst, _ := setupTestHandler(t)
+	_, err = dst.db.Exec(`INSERT INTO _rls_policies (table_name, policy_name, command, using_expr, enabled)
+		VALUES ('posts', 'read_all', 'SELECT', 'false', 1)`)
+	require.NoError(t, err)
+
+	dstToken := setupTestSession(t, dst)
+	dstRouter := chi.NewRouter()
+	dst.RegisterRoutes(dstRouter)
+
+	importPolicies := func(query, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/import/policies"+query, strings.NewReader(body))
+		addTestSession(req, dstToken)
+		w := httptest.NewRecorder()
+		dstRouter.ServeHTTP(w, req)
+		return w
+	}
+	using := func(name string) string {
+		var expr string
+		require.NoError(t, dst.db.QueryRow(`SELECT COALESCE(using_expr, '') FROM _rls_policies WHERE policy_name = ?`, name).Scan(&expr))
+		return expr
+	}
+
+	// on_conflict=error imports nothing and names the conflict
+	w = importPolicies("?on_conflict=error", exported)
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), "read_all")
+	var count int
+	require.NoError(t, dst.db.QueryRow(`SELECT COUNT(*) FROM _rls_policies`).Scan(&count))
+	require.Equal(t, 1, count)
+
+	// The default skips conflicts and reports them
+	w = importPolicies("", exported)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, float64(1), resp["created"])
+	require.Equal(t, float64(1), resp["skipped"])
+	require.Len(t, resp["conflicts"], 1)
+	require.Equal(t, "false", using("read_all"))
+
+	w = importPolicies("?on_conflict=update", exported)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, float64(2), resp["updated"])
+	require.Equal(t, "true", using("read_all"))
+
+	var enabled int
+	var check string
+	require.NoError(t, dst.db.QueryRow(`SELECT enabled, check_expr FROM _rls_policies WHERE policy_name = 'insert_own'`).Scan(&enabled, &check))
+	require.Equal(t, 0, enabled)
+	require.Equal(t, "auth.uid() = user_id", check)
+}
+
+func TestImportPoliciesValidation(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	body := `{"policies": [
+		{"table_name": "posts", "policy_name": "ok", "command": "SELECT", "using_expr": "true"},
+		{"table_name": "posts", "policy_name": "bad_cmd", "command": "TRUNCATE"},
+		{"table_name": "posts", "policy_name": "strict", "command": "SELECT", "permissive": false},
+		{"table_name": "posts", "policy_name": "ok", "command": "SELECT"}
+	]}`
+	req := httptest.NewRequest("POST", "/api/import/policies", strings.NewReader(body))
+	addTestSession(req, token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), "invalid_policies")
+	require.Contains(t, w.Body.String(), "restrictive")
+	require.Contains(t, w.Body.String(), "duplicate")
+
+	var count int
+	require.NoError(t, h.db.QueryRow(`SELECT COUNT(*) FROM _rls_policies`).Scan(&count))
+	require.Equal(t, 0, count)
+}
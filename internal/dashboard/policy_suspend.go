@@ -0,0 +1,110 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handleDisableAllPolicies disables every enabled policy on a table and
+// remembers which ones were on, so handleRestorePolicies can turn exactly
+// those back on. Calling it again keeps the original record.
+// POST /_/api/tables/{name}/policies/disable-all
+func (h *Handler) handleDisableAllPolicies(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var names []string
+	err := h.runWrite(r, func() error {
+		tx, err := h.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		names, err = policyNames(tx, `SELECT policy_name FROM _rls_policies
+			WHERE table_name = ? AND enabled = 1 ORDER BY policy_name`, tableName)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO _rls_suspended_policies (policy_id, table_name)
+			SELECT id, table_name FROM _rls_policies WHERE table_name = ? AND enabled = 1`, tableName); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE _rls_policies SET enabled = 0 WHERE table_name = ? AND enabled = 1`, tableName); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table_name": tableName,
+		"disabled":   names,
+	})
+}
+
+// handleRestorePolicies re-enables the policies switched off by
+// handleDisableAllPolicies. Policies that were already disabled stay off.
+// POST /_/api/tables/{name}/policies/restore
+func (h *Handler) handleRestorePolicies(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var names []string
+	err := h.runWrite(r, func() error {
+		tx, err := h.db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		names, err = policyNames(tx, `SELECT p.policy_name FROM _rls_policies p
+			JOIN _rls_suspended_policies s ON s.policy_id = p.id
+			WHERE s.table_name = ? ORDER BY p.policy_name`, tableName)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE _rls_policies SET enabled = 1
+			WHERE id IN (SELECT policy_id FROM _rls_suspended_policies WHERE table_name = ?)`, tableName); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM _rls_suspended_policies WHERE table_name = ?`, tableName); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table_name": tableName,
+		"restored":   names,
+	})
+}
+
+// policyNames runs a query returning policy names.
+func policyNames(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,60 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisableAllPoliciesAndRestore(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	_, err := h.db.Exec(`INSERT INTO _rls_policies (table_name, policy_name, command, using_expr, enabled) VALUES
+		('posts', 'read_all', 'SELECT', 'true', 1),
+		('posts', 'insert_own', 'INSERT', 'true', 1),
+		('posts', 'delete_own', 'DELETE', 'true', 0),
+		('notes', 'read_notes', 'SELECT', 'true', 1)`)
+	require.NoError(t, err)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	post := func(path string) map[string]interface{} {
+		req := httptest.NewRequest("POST", path, nil)
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+	enabled := func(name string) bool {
+		var e int
+		require.NoError(t, h.db.QueryRow(`SELECT enabled FROM _rls_policies WHERE policy_name = ?`, name).Scan(&e))
+		return e == 1
+	}
+
+	resp := post("/api/tables/posts/policies/disable-all")
+	require.Equal(t, []interface{}{"insert_own", "read_all"}, resp["disabled"])
+	require.False(t, enabled("read_all"))
+	require.False(t, enabled("insert_own"))
+	require.True(t, enabled("read_notes"))
+
+	// A second call does not forget the original state
+	resp = post("/api/tables/posts/policies/disable-all")
+	require.Equal(t, []interface{}{}, resp["disabled"])
+
+	resp = post("/api/tables/posts/policies/restore")
+	require.Equal(t, []interface{}{"insert_own", "read_all"}, resp["restored"])
+	require.True(t, enabled("read_all"))
+	require.True(t, enabled("insert_own"))
+	require.False(t, enabled("delete_own"))
+
+	resp = post("/api/tables/posts/policies/restore")
+	require.Equal(t, []interface{}{}, resp["restored"])
+}
@@ -0,0 +1,190 @@
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRequestTimeout bounds how long a dashboard API request may run
+// before it is answered with 503.
+const DefaultRequestTimeout = 30 * time.Second
+
+// requestTimeoutBody is the response sent when a request runs out of time,
+// in the same shape as writeError.
+const requestTimeoutBody = `{"code":"request_timeout","message":"Request timed out","error":"Request timed out"}`
+
+// timeoutExempt lists the API routes, relative to /api, that stream or
+// legitimately run for minutes: the runtime install progress stream,
+// exports, uploads, downloads and imports, and Supabase migration runs and
+// verifications.
+var timeoutExempt = []string{
+	"/functions/runtime-install",
+	"/functions/import",
+	"/export/*",
+	"/export/*/*",
+	"/storage/objects/upload",
+	"/storage/objects/download",
+	"/migration/*/run",
+	"/migration/*/retry",
+	"/migration/*/rollback",
+	"/migration/*/verify/*",
+}
+
+// SetRequestTimeout sets the deadline for dashboard API requests. Zero
+// selects DefaultRequestTimeout; a negative value disables it.
+func (h *Handler) SetRequestTimeout(d time.Duration) {
+	h.requestTimeout = d
+}
+
+func (h *Handler) apiRequestTimeout() time.Duration {
+	if h.requestTimeout == 0 {
+		return DefaultRequestTimeout
+	}
+	return h.requestTimeout
+}
+
+// isTimeoutExempt reports whether a request path is one of timeoutExempt.
+func isTimeoutExempt(urlPath string) bool {
+	i := strings.Index(urlPath, "/api/")
+	if i < 0 {
+		return false
+	}
+	rel := strings.TrimSuffix(urlPath[i+len("/api"):], "/")
+	for _, pattern := range timeoutExempt {
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// enforceRequestTimeout cancels the request context once the timeout passes,
+// so handlers using QueryContext/ExecContext stop. If the handler hasn't
+// started its response by then it is answered with 503, so a wedged handler
+// can't hold the client forever. Output isn't buffered; a response already
+// under way is left to finish.
+func (h *Handler) enforceRequestTimeout(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := h.apiRequestTimeout()
+		if timeout < 0 || isTimeoutExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// The 503 is claimed before the context is cancelled, so a handler
+		// reacting to the cancellation can't answer first
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		tw := &timeoutWriter{w: w, header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next.ServeHTTP(tw, r.WithContext(ctx))
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+		case <-timer.C:
+			timedOut := tw.timeout()
+			cancel()
+			if !timedOut {
+				select {
+				case p := <-panicked:
+					panic(p)
+				case <-done:
+				}
+			}
+		}
+	})
+}
+
+// timeoutWriter passes a handler's response straight through, until the
+// request times out before the response started; later writes then fail
+// with http.ErrHandlerTimeout. The handler gets its own header map since it
+// may still be running when the timeout response is written.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+// writeHeaderLocked copies the handler's headers and sends the status line.
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	dst := tw.w.Header()
+	for k, v := range tw.header {
+		dst[k] = v
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	if f, ok := tw.w.(http.Flusher); ok {
+		if !tw.wroteHeader {
+			tw.writeHeaderLocked(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+// timeout answers 503 unless the response has already started, and reports
+// whether it did.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.wroteHeader {
+		return false
+	}
+	tw.timedOut = true
+	tw.w.Header().Set("Content-Type", "application/json")
+	tw.w.WriteHeader(http.StatusServiceUnavailable)
+	tw.w.Write([]byte(requestTimeoutBody))
+	return true
+}
@@ -0,0 +1,95 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTimeout(t *testing.T) {
+	database := setupTestDB(t)
+	h := NewHandler(database.DB, "")
+	h.SetRequestTimeout(50 * time.Millisecond)
+
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	r := chi.NewRouter()
+	r.Route("/_/api", func(r chi.Router) {
+		r.Use(h.enforceRequestTimeout)
+		r.Get("/slow", slow)
+		r.Get("/fast", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok":true}`))
+		})
+		r.Get("/export/backup", slow)
+		r.Get("/stream", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("first\n"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			w.Write([]byte("second\n"))
+		})
+	})
+
+	t.Run("slow request gets 503", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/_/api/slow", nil))
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"code":"request_timeout","message":"Request timed out","error":"Request timed out"}`, w.Body.String())
+	})
+
+	t.Run("fast request passes through", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/_/api/fast", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"ok":true}`, w.Body.String())
+	})
+
+	t.Run("started response is not replaced", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/_/api/stream", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "first\nsecond\n", w.Body.String())
+		assert.True(t, w.Flushed)
+	})
+
+	t.Run("exempt route is not cut off", func(t *testing.T) {
+		h.SetRequestTimeout(50 * time.Millisecond)
+		req := httptest.NewRequest("GET", "/_/api/export/backup", nil)
+		w := httptest.NewRecorder()
+		start := time.Now()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("negative timeout disables it", func(t *testing.T) {
+		h.SetRequestTimeout(-1)
+		defer h.SetRequestTimeout(50 * time.Millisecond)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", "/_/api/fast", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestIsTimeoutExempt(t *testing.T) {
+	assert.True(t, isTimeoutExempt("/_/api/functions/runtime-install"))
+	assert.True(t, isTimeoutExempt("/admin/api/export/data"))
+	assert.True(t, isTimeoutExempt("/_/api/migration/abc123/run"))
+	assert.True(t, isTimeoutExempt("/_/api/migration/abc123/verify/integrity"))
+	assert.True(t, isTimeoutExempt("/_/api/storage/objects/download"))
+	assert.True(t, isTimeoutExempt("/_/api/export/auth/users"))
+	assert.False(t, isTimeoutExempt("/_/api/migration/abc123/items"))
+	assert.False(t, isTimeoutExempt("/_/api/tables"))
+	assert.False(t, isTimeoutExempt("/_/static/app.js"))
+}
@@ -0,0 +1,168 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/rls"
+)
+
+// PolicyExplanation is the outcome of one policy evaluated against a row.
+type PolicyExplanation struct {
+	ID              int64  `json:"id"`
+	PolicyName      string `json:"policy_name"`
+	Command         string `json:"command"`
+	UsingExpr       string `json:"using_expr"`
+	SubstitutedExpr string `json:"substituted_expr"`
+	Passed          bool   `json:"passed"`
+	Error           string `json:"error,omitempty"`
+}
+
+// handleExplainRLS evaluates each enabled SELECT policy of a table against a
+// single row, as seen by a given user, and reports which policies pass. As
+// in the REST API, a row is visible only if every applicable policy passes.
+// POST /_/api/tables/{name}/rls/explain
+func (h *Handler) handleExplainRLS(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var req struct {
+		UserID string                 `json:"user_id"`
+		PK     map[string]interface{} `json:"pk"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request")
+		return
+	}
+	if len(req.PK) == 0 {
+		writeError(w, http.StatusBadRequest, "missing_field", "pk is required")
+		return
+	}
+
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists)
+	if exists == 0 {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+
+	// Tables without a primary key are addressed by rowid
+	keyCols := h.primaryKeyColumns(tableName)
+	if len(keyCols) == 0 {
+		keyCols = []string{"rowid"}
+	}
+	if len(req.PK) != len(keyCols) {
+		writeError(w, http.StatusBadRequest, "invalid_pk", "pk must contain exactly the key columns: "+strings.Join(keyCols, ", "))
+		return
+	}
+	var conds []string
+	var args []interface{}
+	for _, col := range keyCols {
+		val, ok := req.PK[col]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid_pk", "pk must contain exactly the key columns: "+strings.Join(keyCols, ", "))
+			return
+		}
+		conds = append(conds, fmt.Sprintf(`"%s" = ?`, col))
+		args = append(args, val)
+	}
+	rowFilter := strings.Join(conds, " AND ")
+
+	var found int
+	h.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM "%s" WHERE %s`, tableName, rowFilter), args...).Scan(&found)
+	if found == 0 {
+		writeError(w, http.StatusNotFound, "row_not_found", "No row matches the given pk")
+		return
+	}
+
+	// Build the auth context the REST API would see for this user
+	authCtx := &rls.AuthContext{Role: "anon", Claims: map[string]any{"role": "anon"}}
+	if req.UserID != "" {
+		var email, role sql.NullString
+		err := h.db.QueryRow(`SELECT email, role FROM auth_users WHERE id = ?`, req.UserID).Scan(&email, &role)
+		if err == sql.ErrNoRows {
+			writeError(w, http.StatusNotFound, "user_not_found", "User not found")
+			return
+		}
+		authCtx.UserID = req.UserID
+		authCtx.Email = email.String
+		authCtx.Role = role.String
+		if authCtx.Role == "" {
+			authCtx.Role = "authenticated"
+		}
+		authCtx.Claims = map[string]any{"sub": authCtx.UserID, "email": authCtx.Email, "role": authCtx.Role}
+	}
+
+	rows, err := h.db.Query(`
+		SELECT id, policy_name, command, COALESCE(using_expr, '') FROM _rls_policies
+		WHERE table_name = ? AND enabled = 1 AND command IN ('SELECT', 'ALL')
+		ORDER BY policy_name
+	`, tableName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	var policies []PolicyExplanation
+	for rows.Next() {
+		var p PolicyExplanation
+		if err := rows.Scan(&p.ID, &p.PolicyName, &p.Command, &p.UsingExpr); err != nil {
+			continue
+		}
+		policies = append(policies, p)
+	}
+	rows.Close()
+
+	visible := true
+	var failed []string
+	for i := range policies {
+		p := &policies[i]
+		if p.UsingExpr == "" {
+			// Policies without a USING clause add no read condition
+			p.Passed = true
+			continue
+		}
+		p.SubstitutedExpr = rls.SubstituteStorageFunctions(rls.SubstituteAuthFunctions(p.UsingExpr, authCtx))
+		var passed int
+		query := fmt.Sprintf(`SELECT CASE WHEN (%s) THEN 1 ELSE 0 END FROM "%s" WHERE %s`, p.SubstitutedExpr, tableName, rowFilter)
+		if err := h.db.QueryRow(query, args...).Scan(&passed); err != nil {
+			p.Error = err.Error()
+		}
+		p.Passed = passed == 1
+		if !p.Passed {
+			visible = false
+			failed = append(failed, p.PolicyName)
+		}
+	}
+	sort.Strings(failed)
+
+	var rlsEnabled bool
+	h.db.QueryRow(`SELECT enabled FROM _rls_tables WHERE table_name = ?`, tableName).Scan(&rlsEnabled)
+
+	decision := "visible"
+	reason := "all applicable policies passed"
+	switch {
+	case len(policies) == 0:
+		reason = "no enabled SELECT policies apply to this table"
+	case !visible:
+		decision = "hidden"
+		reason = "failed policies: " + strings.Join(failed, ", ")
+	}
+
+	if policies == nil {
+		policies = []PolicyExplanation{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":       tableName,
+		"user_id":     req.UserID,
+		"role":        authCtx.Role,
+		"rls_enabled": rlsEnabled,
+		"policies":    policies,
+		"decision":    decision,
+		"reason":      reason,
+	})
+}
@@ -0,0 +1,66 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainRLS(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	_, err := h.db.Exec(`CREATE TABLE notes (id INTEGER PRIMARY KEY, owner_id TEXT, published INTEGER)`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO notes (id, owner_id, published) VALUES (1, 'alice', 0), (2, 'bob', 1)`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO auth_users (id, email) VALUES ('alice', 'alice@example.com')`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO _rls_policies (table_name, policy_name, command, using_expr, enabled) VALUES
+		('notes', 'own_notes', 'SELECT', 'owner_id = auth.uid()', 1),
+		('notes', 'signed_in', 'ALL', 'auth.role() = ''authenticated''', 1),
+		('notes', 'insert_any', 'INSERT', 'true', 1),
+		('notes', 'published_only', 'SELECT', 'published = 1', 0)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/tables/{name}/rls/explain", h.handleExplainRLS)
+	explain := func(body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req := httptest.NewRequest("POST", "/tables/notes/rls/explain", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w, resp
+	}
+
+	w, resp := explain(`{"user_id": "alice", "pk": {"id": 1}}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, "visible", resp["decision"])
+	assert.Len(t, resp["policies"], 2)
+
+	w, resp = explain(`{"user_id": "alice", "pk": {"id": 2}}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hidden", resp["decision"])
+	policies := resp["policies"].([]interface{})
+	own := policies[0].(map[string]interface{})
+	assert.Equal(t, "own_notes", own["policy_name"])
+	assert.Equal(t, false, own["passed"])
+	assert.Equal(t, "owner_id = 'alice'", own["substituted_expr"])
+	assert.Equal(t, true, policies[1].(map[string]interface{})["passed"])
+	assert.Equal(t, "failed policies: own_notes", resp["reason"])
+
+	_, resp = explain(`{"pk": {"id": 1}}`)
+	assert.Equal(t, "hidden", resp["decision"])
+	assert.Equal(t, "anon", resp["role"])
+
+	w, _ = explain(`{"user_id": "alice", "pk": {"id": 99}}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w, _ = explain(`{"user_id": "alice", "pk": {"owner_id": "alice"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
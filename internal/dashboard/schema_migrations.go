@@ -0,0 +1,66 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	schemamigration "github.com/markb/sblite/internal/migration"
+)
+
+// handleRevertMigration undoes an applied schema migration by running its
+// down file and removing its _schema_migrations record. The migration file
+// itself is kept, so the migration is pending again afterwards.
+// POST /_/api/migrations/{version}/revert
+func (h *Handler) handleRevertMigration(w http.ResponseWriter, r *http.Request) {
+	version := chi.URLParam(r, "version")
+
+	var name string
+	err := h.db.QueryRow(`SELECT name FROM _schema_migrations WHERE version = ?`, version).Scan(&name)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "migration_not_found", "Migration is not applied")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	m := schemamigration.Migration{Version: version, Name: name}
+	downSQL, err := schemamigration.ReadDown(h.migrationsDir, m)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeError(w, http.StatusConflict, "migration_not_reversible", "Migration has no down file ("+m.DownFilename()+")")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	err = h.runWrite(r, func() error {
+		return schemamigration.NewRunner(h.db).Revert(m, downSQL)
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusBadRequest)
+		return
+	}
+	h.pruneColumnMetadata()
+	h.recordSchemaSnapshot("revert_" + version + "_" + name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"version":  version,
+		"name":     name,
+		"reverted": true,
+	})
+}
+
+// pruneColumnMetadata removes _columns rows for tables and columns that no
+// longer exist, e.g. after SQL run outside the dashboard's own schema handlers.
+func (h *Handler) pruneColumnMetadata() {
+	h.db.Exec(`DELETE FROM _columns WHERE NOT EXISTS (
+		SELECT 1 FROM pragma_table_info(_columns.table_name) p WHERE p.name = _columns.column_name)`)
+}
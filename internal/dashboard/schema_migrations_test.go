@@ -0,0 +1,108 @@
+package dashboard
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupRevertTest(t *testing.T) (*Handler, chi.Router, string) {
+	t.Helper()
+	database := setupTestDB(t)
+	t.Cleanup(func() { database.Close() })
+
+	dir := t.TempDir()
+	handler := NewHandler(database.DB, dir)
+	r := chi.NewRouter()
+	r.Post("/tables", handler.handleCreateTable)
+	r.Delete("/tables/{name}", handler.handleDeleteTable)
+	r.Post("/tables/{name}/truncate", handler.handleTruncateTable)
+	r.Post("/migrations/{version}/revert", handler.handleRevertMigration)
+	return handler, r, dir
+}
+
+func lastMigrationVersion(t *testing.T, h *Handler) string {
+	t.Helper()
+	var version string
+	require.NoError(t, h.db.QueryRow(`SELECT version FROM _schema_migrations ORDER BY version DESC LIMIT 1`).Scan(&version))
+	return version
+}
+
+func revert(r chi.Router, version string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/migrations/"+version+"/revert", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestRevertCreateTableMigration(t *testing.T) {
+	h, r, dir := setupRevertTest(t)
+
+	body := `{"name": "notes", "columns": [{"name": "id", "type": "integer", "primary": true}, {"name": "body", "type": "text", "nullable": true}]}`
+	req := httptest.NewRequest("POST", "/tables", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	version := lastMigrationVersion(t, h)
+	down, err := os.ReadFile(filepath.Join(dir, version+"_create_notes_table.down.sql"))
+	require.NoError(t, err)
+	assert.Equal(t, `DROP TABLE IF EXISTS "notes";`, string(down))
+
+	w = revert(r, version)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var count int
+	require.NoError(t, h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'notes'`).Scan(&count))
+	assert.Equal(t, 0, count)
+	require.NoError(t, h.db.QueryRow(`SELECT COUNT(*) FROM _columns WHERE table_name = 'notes'`).Scan(&count))
+	assert.Equal(t, 0, count)
+	require.NoError(t, h.db.QueryRow(`SELECT COUNT(*) FROM _schema_migrations`).Scan(&count))
+	assert.Equal(t, 0, count)
+
+	assert.Equal(t, http.StatusNotFound, revert(r, version).Code)
+}
+
+func TestRevertDropTableMigration(t *testing.T) {
+	h, r, _ := setupRevertTest(t)
+
+	_, err := h.db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, sku TEXT)`)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`CREATE INDEX idx_items_sku ON items (sku)`)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("DELETE", "/tables/items", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	w = revert(r, lastMigrationVersion(t, h))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var count int
+	require.NoError(t, h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name IN ('items', 'idx_items_sku')`).Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestRevertIrreversibleMigration(t *testing.T) {
+	h, r, _ := setupRevertTest(t)
+
+	_, err := h.db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("POST", "/tables/items/truncate", bytes.NewBufferString(`{"confirm": true}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = revert(r, lastMigrationVersion(t, h))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "migration_not_reversible")
+}
@@ -0,0 +1,156 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/log"
+	"github.com/markb/sblite/internal/schema"
+)
+
+// defaultSnapshotLimit is how many snapshots GET /schema/snapshots returns
+// when no limit is given.
+const defaultSnapshotLimit = 50
+
+// recordSchemaSnapshot stores a snapshot of the user schema after a
+// schema-changing operation. Failures are logged rather than returned so a
+// snapshot problem never fails the change itself.
+func (h *Handler) recordSchemaSnapshot(reason string) {
+	if _, err := schema.New(h.db).RecordSnapshot(reason); err != nil {
+		log.Warn("failed to record schema snapshot", "reason", reason, "error", err.Error())
+	}
+}
+
+// handleSchemaVersion returns a stable hash of the current user schema, so
+// two environments can be compared for drift.
+// GET /_/api/schema/version
+func (h *Handler) handleSchemaVersion(w http.ResponseWriter, r *http.Request) {
+	snap, err := schema.New(h.db).Snapshot()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	resp := map[string]interface{}{
+		"hash":        snap.Hash,
+		"table_count": len(snap.Tables),
+	}
+	var latestID int64
+	var latestHash string
+	err = h.db.QueryRow(`SELECT id, hash FROM _schema_snapshots ORDER BY id DESC LIMIT 1`).Scan(&latestID, &latestHash)
+	if err == nil {
+		resp["latest_snapshot_id"] = latestID
+		resp["matches_latest_snapshot"] = latestHash == snap.Hash
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleListSchemaSnapshots lists recorded snapshots, newest first.
+// GET /_/api/schema/snapshots?limit=50
+func (h *Handler) handleListSchemaSnapshots(w http.ResponseWriter, r *http.Request) {
+	limit := defaultSnapshotLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	snapshots, err := schema.New(h.db).ListSnapshots(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshots)
+}
+
+// handleGetSchemaSnapshot returns one snapshot with its table definitions.
+// GET /_/api/schema/snapshots/{id}
+func (h *Handler) handleGetSchemaSnapshot(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid snapshot id")
+		return
+	}
+
+	rec, err := schema.New(h.db).GetSnapshot(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "snapshot_not_found", "Snapshot not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rec)
+}
+
+// handleDiffSchemaSnapshots compares two snapshots. Either side may be
+// "current" (the default for "to") to compare against the live schema.
+// GET /_/api/schema/snapshots/diff?from=3&to=current
+func (h *Handler) handleDiffSchemaSnapshots(w http.ResponseWriter, r *http.Request) {
+	s := schema.New(h.db)
+
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		writeError(w, http.StatusBadRequest, "missing_field", "from is required")
+		return
+	}
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = "current"
+	}
+
+	resolve := func(ref string) (*schema.SchemaSnapshot, bool) {
+		if ref == "current" {
+			snap, err := s.Snapshot()
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+				return nil, false
+			}
+			return snap, true
+		}
+		id, err := strconv.ParseInt(ref, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "Invalid snapshot id: "+ref)
+			return nil, false
+		}
+		rec, err := s.GetSnapshot(id)
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "snapshot_not_found", "Snapshot not found: "+ref)
+			return nil, false
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+			return nil, false
+		}
+		return rec.Snapshot, true
+	}
+
+	fromSnap, ok := resolve(from)
+	if !ok {
+		return
+	}
+	toSnap, ok := resolve(to)
+	if !ok {
+		return
+	}
+
+	diff := schema.DiffSnapshots(fromSnap, toSnap)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"from":    from,
+		"to":      to,
+		"changed": diff.Changed(),
+		"diff":    diff,
+	})
+}
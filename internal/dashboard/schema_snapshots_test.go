@@ -0,0 +1,71 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerSchemaSnapshots(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	token := setupTestSession(t, h)
+
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		addTestSession(req, token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("POST", "/api/tables", `{"name":"orders","columns":[{"name":"id","type":"uuid","primary":true}]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	w = do("POST", "/api/sql", `{"query":"ALTER TABLE orders ADD COLUMN total INTEGER"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = do("GET", "/api/schema/snapshots", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var list []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list, 2)
+	assert.Equal(t, "sql_editor", list[0]["reason"])
+	assert.Equal(t, "create_orders_table", list[1]["reason"])
+
+	w = do("GET", "/api/schema/version", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var version map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &version))
+	assert.Equal(t, list[0]["hash"], version["hash"])
+	assert.Equal(t, true, version["matches_latest_snapshot"])
+
+	oldest := int(list[1]["id"].(float64))
+	w = do("GET", "/api/schema/snapshots/diff?from="+strconv.Itoa(oldest), "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var diff struct {
+		Changed bool `json:"changed"`
+		Diff    struct {
+			ChangedTables []struct {
+				Name       string `json:"name"`
+				SQLChanged bool   `json:"sql_changed"`
+			} `json:"changed_tables"`
+		} `json:"diff"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &diff))
+	assert.True(t, diff.Changed)
+	require.Len(t, diff.Diff.ChangedTables, 1)
+	assert.Equal(t, "orders", diff.Diff.ChangedTables[0].Name)
+	assert.True(t, diff.Diff.ChangedTables[0].SQLChanged)
+
+	w = do("GET", "/api/schema/snapshots/999", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,107 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/markb/sblite/internal/dashboard/migration"
+	"github.com/markb/sblite/internal/log"
+)
+
+// encryptedSettingPrefix marks a _dashboard value encrypted with EncryptSetting.
+const encryptedSettingPrefix = "enc:"
+
+// secretSettingKeys are the _dashboard keys holding credentials that are
+// encrypted at rest.
+var secretSettingKeys = []string{"mail_smtp_password", "storage_s3_secret_key"}
+
+// EncryptSetting encrypts a setting value with AES-GCM keyed by the JWT secret.
+func EncryptSetting(secret, plaintext string) (string, error) {
+	ciphertext, err := migration.EncryptCredential(secret, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return encryptedSettingPrefix + base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptSetting returns the plaintext of a stored setting value. Values
+// without the encryption prefix (written before encryption was enabled) are
+// returned unchanged.
+func DecryptSetting(secret, stored string) (string, error) {
+	if !strings.HasPrefix(stored, encryptedSettingPrefix) {
+		return stored, nil
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(stored, encryptedSettingPrefix))
+	if err != nil {
+		return "", fmt.Errorf("decode setting: %w", err)
+	}
+	return migration.DecryptCredential(secret, ciphertext)
+}
+
+// setSecretSetting stores a credential, encrypted when a JWT secret is configured.
+func (h *Handler) setSecretSetting(key, value string) error {
+	if h.jwtSecret != "" && value != "" {
+		encrypted, err := EncryptSetting(h.jwtSecret, value)
+		if err != nil {
+			return fmt.Errorf("encrypt %s: %w", key, err)
+		}
+		value = encrypted
+	}
+	return h.store.Set(key, value)
+}
+
+// getSecretSetting returns the decrypted value of a credential setting, or ""
+// if it is unset or cannot be decrypted (e.g. the JWT secret changed).
+func (h *Handler) getSecretSetting(key string) string {
+	stored, _ := h.store.Get(key)
+	value, err := DecryptSetting(h.jwtSecret, stored)
+	if err != nil {
+		log.Warn("failed to decrypt setting", "key", key, "error", err)
+		return ""
+	}
+	return value
+}
+
+// reencryptSecretSettings re-encrypts the credential settings stored under
+// oldSecret with newSecret within tx. A value that can't be decrypted fails
+// the rotation rather than being left unreadable.
+func reencryptSecretSettings(tx *sql.Tx, oldSecret, newSecret string) error {
+	for _, key := range secretSettingKeys {
+		var stored string
+		err := tx.QueryRow(`SELECT value FROM _dashboard WHERE key = ?`, key).Scan(&stored)
+		if err == sql.ErrNoRows || (err == nil && stored == "") {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		value, err := DecryptSetting(oldSecret, stored)
+		if err != nil {
+			return fmt.Errorf("decrypt %s: %w", key, err)
+		}
+		encrypted, err := EncryptSetting(newSecret, value)
+		if err != nil {
+			return fmt.Errorf("encrypt %s: %w", key, err)
+		}
+		if _, err := tx.Exec(`UPDATE _dashboard SET value = ?, updated_at = datetime('now') WHERE key = ?`, encrypted, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptStoredSecrets encrypts credential settings that were saved in plaintext.
+func (h *Handler) encryptStoredSecrets() {
+	if h.jwtSecret == "" {
+		return
+	}
+	for _, key := range secretSettingKeys {
+		stored, _ := h.store.Get(key)
+		if stored == "" || strings.HasPrefix(stored, encryptedSettingPrefix) {
+			continue
+		}
+		h.setSecretSetting(key, stored)
+	}
+}
@@ -0,0 +1,144 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptSetting(t *testing.T) {
+	encrypted, err := EncryptSetting("jwt-secret", "hunter2")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(encrypted, encryptedSettingPrefix))
+	assert.NotContains(t, encrypted, "hunter2")
+
+	plain, err := DecryptSetting("jwt-secret", encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plain)
+
+	// Legacy plaintext values pass through
+	plain, err = DecryptSetting("jwt-secret", "legacy")
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", plain)
+
+	_, err = DecryptSetting("other-secret", encrypted)
+	assert.Error(t, err)
+}
+
+func TestMailPasswordEncryptedAtRest(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	handler.SetJWTSecret("jwt-secret")
+
+	var reloaded *MailConfig
+	handler.SetMailReloadFunc(func(cfg *MailConfig) error {
+		reloaded = cfg
+		return nil
+	})
+
+	r := chi.NewRouter()
+	r.Patch("/settings/mail", handler.handleUpdateMailSettings)
+	r.Get("/settings/mail", handler.handleGetMailSettings)
+
+	body := `{"smtp": {"host": "smtp.example.com", "password": "hunter2"}}`
+	req := httptest.NewRequest("PATCH", "/settings/mail", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	stored, _ := handler.store.Get("mail_smtp_password")
+	assert.True(t, strings.HasPrefix(stored, encryptedSettingPrefix))
+	assert.NotContains(t, stored, "hunter2")
+
+	// The reload callback and SMTP config receive the plaintext
+	require.NotNil(t, reloaded)
+	assert.Equal(t, "hunter2", reloaded.SMTPPass)
+	_, _, _, pass := handler.GetSMTPConfig()
+	assert.Equal(t, "hunter2", pass)
+
+	// Reads for display stay masked
+	req = httptest.NewRequest("GET", "/settings/mail", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var resp MailSettingsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "********", resp.SMTP.Password)
+}
+
+func TestEncryptStoredSecretsMigratesPlaintext(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	require.NoError(t, handler.store.Set("storage_s3_secret_key", "plain-s3-secret"))
+
+	handler.SetJWTSecret("jwt-secret")
+
+	stored, _ := handler.store.Get("storage_s3_secret_key")
+	assert.True(t, strings.HasPrefix(stored, encryptedSettingPrefix))
+	assert.Equal(t, "plain-s3-secret", handler.buildStorageConfig().S3SecretKey)
+}
+
+func TestRegenerateSecretReencryptsSettings(t *testing.T) {
+	t.Setenv("SBLITE_JWT_SECRET", "")
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	handler.SetJWTSecret("old-secret")
+	require.NoError(t, handler.setSecretSetting("mail_smtp_password", "hunter2"))
+	require.NoError(t, handler.setSecretSetting("storage_s3_secret_key", "s3-secret"))
+	before, _ := handler.store.Get("mail_smtp_password")
+
+	r := chi.NewRouter()
+	r.Post("/settings/auth/regenerate-secret", handler.handleRegenerateSecret)
+	req := httptest.NewRequest("POST", "/settings/auth/regenerate-secret", bytes.NewBufferString(`{"confirmation": "REGENERATE"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var newSecret string
+	require.NoError(t, database.QueryRow(`SELECT value FROM _dashboard WHERE key = 'jwt_secret'`).Scan(&newSecret))
+	require.NotEqual(t, "old-secret", newSecret)
+
+	after, _ := handler.store.Get("mail_smtp_password")
+	assert.NotEqual(t, before, after)
+	plain, err := DecryptSetting(newSecret, after)
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", plain)
+
+	assert.Equal(t, "hunter2", handler.getSecretSetting("mail_smtp_password"))
+	assert.Equal(t, "s3-secret", handler.getSecretSetting("storage_s3_secret_key"))
+}
+
+func TestRegenerateSecretFailsOnUndecryptableSetting(t *testing.T) {
+	t.Setenv("SBLITE_JWT_SECRET", "")
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	handler.SetJWTSecret("old-secret")
+	stale, err := EncryptSetting("some-other-secret", "hunter2")
+	require.NoError(t, err)
+	require.NoError(t, handler.store.Set("mail_smtp_password", stale))
+
+	r := chi.NewRouter()
+	r.Post("/settings/auth/regenerate-secret", handler.handleRegenerateSecret)
+	req := httptest.NewRequest("POST", "/settings/auth/regenerate-secret", bytes.NewBufferString(`{"confirmation": "REGENERATE"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	var count int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM _dashboard WHERE key = 'jwt_secret'`).Scan(&count))
+	assert.Zero(t, count, "a failed rotation leaves the secret unchanged")
+}
@@ -0,0 +1,164 @@
+package dashboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxEnvImportSize bounds an uploaded .env file.
+const maxEnvImportSize = 1 << 20
+
+// envEntry is a KEY=value pair parsed from a .env file.
+type envEntry struct {
+	Line  int
+	Key   string
+	Value string
+}
+
+// envParseError describes a .env line that could not be parsed.
+type envParseError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// parseEnvFile parses .env content: KEY=value lines with an optional "export "
+// prefix. Blank lines and # comments are ignored. Double-quoted values support
+// \n, \" and \\ escapes, single-quoted values are literal, and unquoted values
+// end at an inline " #" comment.
+func parseEnvFile(r io.Reader) ([]envEntry, []envParseError) {
+	var entries []envEntry
+	var errs []envParseError
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxEnvImportSize)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			errs = append(errs, envParseError{Line: n, Message: "expected KEY=value"})
+			continue
+		}
+		if !isValidIdentifier(key) {
+			errs = append(errs, envParseError{Line: n, Message: fmt.Sprintf("invalid key %q", key)})
+			continue
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			errs = append(errs, envParseError{Line: n, Message: err.Error()})
+			continue
+		}
+		entries = append(entries, envEntry{Line: n, Key: key, Value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, envParseError{Message: err.Error()})
+	}
+	return entries, errs
+}
+
+// parseEnvValue unquotes a .env value.
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return value[1 : end+1], nil
+	case '"':
+		var sb strings.Builder
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+			if c == '"' {
+				return sb.String(), nil
+			}
+			if c == '\\' && i+1 < len(value) {
+				i++
+				switch value[i] {
+				case 'n':
+					sb.WriteByte('\n')
+				case 't':
+					sb.WriteByte('\t')
+				default:
+					sb.WriteByte(value[i])
+				}
+				continue
+			}
+			sb.WriteByte(c)
+		}
+		return "", fmt.Errorf("unterminated double quote")
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// handleImportSecrets upserts secrets from a .env file, sent either as the
+// raw request body or as the "file" field of a multipart form. Keys with empty
+// values, such as those in an unfilled export template, are skipped.
+// POST /_/api/secrets/import
+func (h *Handler) handleImportSecrets(w http.ResponseWriter, r *http.Request) {
+	if h.functionsService == nil {
+		writeError(w, http.StatusBadRequest, "functions_not_enabled", "Edge functions not enabled")
+		return
+	}
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "missing_field", "file field is required")
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	entries, parseErrors := parseEnvFile(io.LimitReader(body, maxEnvImportSize))
+	if parseErrors == nil {
+		parseErrors = []envParseError{}
+	}
+
+	imported := []string{}
+	skipped := []string{}
+	for _, entry := range entries {
+		if entry.Value == "" {
+			skipped = append(skipped, entry.Key)
+			continue
+		}
+		if err := h.functionsService.SetSecret(entry.Key, entry.Value); err != nil {
+			parseErrors = append(parseErrors, envParseError{Line: entry.Line, Message: err.Error()})
+			continue
+		}
+		imported = append(imported, entry.Key)
+	}
+
+	resp := map[string]interface{}{
+		"imported":       len(imported),
+		"skipped":        len(skipped),
+		"names":          imported,
+		"skipped_names":  skipped,
+		"errors":         parseErrors,
+		"restart_needed": len(imported) > 0,
+	}
+	if len(imported) > 0 {
+		resp["message"] = "Secrets imported. Restart edge runtime for changes to take effect."
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,119 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/functions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	content := `# comment
+
+API_KEY=abc123
+export TOKEN="multi\nline \"quoted\""
+LITERAL='a "b" \n'
+INLINE=value # trailing comment
+EMPTY=
+not valid
+1BAD=x
+OPEN="unterminated
+`
+	entries, errs := parseEnvFile(strings.NewReader(content))
+
+	got := map[string]string{}
+	for _, e := range entries {
+		got[e.Key] = e.Value
+	}
+	assert.Equal(t, map[string]string{
+		"API_KEY": "abc123",
+		"TOKEN":   "multi\nline \"quoted\"",
+		"LITERAL": `a "b" \n`,
+		"INLINE":  "value",
+		"EMPTY":   "",
+	}, got)
+
+	require.Len(t, errs, 3)
+	assert.Equal(t, 8, errs[0].Line)
+	assert.Equal(t, 9, errs[1].Line)
+	assert.Equal(t, 10, errs[2].Line)
+}
+
+func TestImportSecrets(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	svc, err := functions.NewService(database.DB, &functions.Config{FunctionsDir: t.TempDir(), JWTSecret: "test-secret"})
+	require.NoError(t, err)
+	handler.SetFunctionsService(svc)
+
+	r := chi.NewRouter()
+	r.Post("/secrets/import", handler.handleImportSecrets)
+
+	req := httptest.NewRequest("POST", "/secrets/import", strings.NewReader("API_KEY=abc\nUNFILLED=\nbroken line\n"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp struct {
+		Imported      int             `json:"imported"`
+		Skipped       int             `json:"skipped"`
+		Errors        []envParseError `json:"errors"`
+		RestartNeeded bool            `json:"restart_needed"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 1, resp.Imported)
+	assert.Equal(t, 1, resp.Skipped)
+	assert.Len(t, resp.Errors, 1)
+	assert.True(t, resp.RestartNeeded)
+
+	names, err := svc.ListSecrets()
+	require.NoError(t, err)
+	require.Len(t, names, 1)
+}
+
+func TestRevealSecret(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	svc, err := functions.NewService(database.DB, &functions.Config{FunctionsDir: t.TempDir(), JWTSecret: "test-secret"})
+	require.NoError(t, err)
+	handler.SetFunctionsService(svc)
+	require.NoError(t, svc.SetSecret("API_KEY", "sk-123"))
+
+	require.NoError(t, handler.auth.SetupPassword("testpassword123"))
+
+	r := chi.NewRouter()
+	r.Post("/secrets/{name}/reveal", handler.handleRevealSecret)
+
+	reveal := func(name, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/secrets/"+name+"/reveal", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, reveal("API_KEY", `{"password": "testpassword123"}`).Code)
+	assert.Equal(t, http.StatusForbidden, reveal("API_KEY", `{"confirmation": "REVEAL", "password": "wrong"}`).Code)
+	assert.Equal(t, http.StatusNotFound, reveal("MISSING", `{"confirmation": "REVEAL", "password": "testpassword123"}`).Code)
+
+	w := reveal("API_KEY", `{"confirmation": "REVEAL", "password": "testpassword123"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"name":"API_KEY","value":"sk-123"}`, w.Body.String())
+
+	// Wrong passwords count towards the login lockout
+	for i := 0; i < loginMaxFailures; i++ {
+		w = reveal("API_KEY", `{"confirmation": "REVEAL", "password": "wrong"}`)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, http.StatusTooManyRequests, reveal("API_KEY", `{"confirmation": "REVEAL", "password": "testpassword123"}`).Code)
+}
@@ -0,0 +1,258 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	schemamigration "github.com/markb/sblite/internal/migration"
+)
+
+// sequenceDefault is the column default that numbers rows from a sequence.
+// The sequence's current value is kept in _sequences and an AFTER INSERT
+// trigger assigns the next one to rows inserted without a value. Since the
+// value is filled in after the insert, sequence columns are never NOT NULL.
+const sequenceDefault = "nextval()"
+
+// Sequence is a row of _sequences.
+type Sequence struct {
+	Name         string `json:"name"`
+	TableName    string `json:"table_name"`
+	ColumnName   string `json:"column_name"`
+	CurrentValue int64  `json:"current_value"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// isSequenceDefault reports whether a column default is sequenceDefault.
+func isSequenceDefault(defaultVal string) bool {
+	return strings.EqualFold(strings.TrimSpace(defaultVal), sequenceDefault)
+}
+
+// sequenceName names the sequence, and its trigger, for a column.
+func sequenceName(tableName, columnName string) string {
+	return tableName + "_" + columnName + "_seq"
+}
+
+// sequenceBackfillSQL numbers the existing rows of a table in rowid order.
+func sequenceBackfillSQL(tableName, columnName string) string {
+	return fmt.Sprintf(`UPDATE "%s" SET "%s" = n.rn FROM (SELECT rowid AS id, row_number() OVER (ORDER BY rowid) AS rn FROM "%s") AS n WHERE "%s".rowid = n.id`,
+		tableName, columnName, tableName, tableName)
+}
+
+// createSequence registers a sequence for a column within tx, starting
+// after the largest integer already in the column, and creates its trigger.
+// It returns the sequence's current value.
+func createSequence(tx *sql.Tx, tableName, columnName string) (int64, error) {
+	name := sequenceName(tableName, columnName)
+
+	var current int64
+	if err := tx.QueryRow(fmt.Sprintf(`SELECT COALESCE(MAX("%s"), 0) FROM "%s" WHERE typeof("%s") = 'integer'`,
+		columnName, tableName, columnName)).Scan(&current); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`INSERT INTO _sequences (name, table_name, column_name, current_value) VALUES (?, ?, ?, ?)`,
+		name, tableName, columnName, current); err != nil {
+		return 0, fmt.Errorf("failed to register sequence %s: %w", name, err)
+	}
+	if _, err := tx.Exec(schemamigration.SequenceTriggerSQL(name, tableName, columnName)); err != nil {
+		return 0, err
+	}
+	return current, nil
+}
+
+// createSequenceSQL returns the statement creating a sequence whose next
+// value is current+1. Migration files declare sequences as PostgreSQL does,
+// like the DDL export; the migration runner maps them onto _sequences and a
+// trigger.
+func createSequenceSQL(name string, current int64) string {
+	if current > 0 {
+		return fmt.Sprintf("CREATE SEQUENCE %s START WITH %d;", name, current+1)
+	}
+	return fmt.Sprintf("CREATE SEQUENCE %s;", name)
+}
+
+// dropSequenceSQL returns the statement dropping a sequence.
+func dropSequenceSQL(name string) string {
+	return fmt.Sprintf("DROP SEQUENCE IF EXISTS %s;", name)
+}
+
+// nextvalSQL returns the column default drawing from a sequence.
+func nextvalSQL(name string) string {
+	return fmt.Sprintf("nextval('%s')", name)
+}
+
+// setSequenceDefaultSQL returns the statement making a column draw from a
+// sequence.
+func setSequenceDefaultSQL(tableName, columnName, name string) string {
+	return fmt.Sprintf(`ALTER TABLE "%s" ALTER COLUMN "%s" SET DEFAULT %s;`, tableName, columnName, nextvalSQL(name))
+}
+
+// dropSequenceDefaultSQL returns the statement detaching a column from its
+// sequence.
+func dropSequenceDefaultSQL(tableName, columnName string) string {
+	return fmt.Sprintf(`ALTER TABLE "%s" ALTER COLUMN "%s" DROP DEFAULT;`, tableName, columnName)
+}
+
+// restoreSequenceTriggers recreates the triggers of a table's sequences,
+// which are lost when the table is rebuilt.
+func restoreSequenceTriggers(tx *sql.Tx, tableName string) error {
+	rows, err := tx.Query(`SELECT name, column_name FROM _sequences WHERE table_name = ?`, tableName)
+	if err != nil {
+		return err
+	}
+	var triggers []string
+	for rows.Next() {
+		var name, column string
+		if err := rows.Scan(&name, &column); err != nil {
+			rows.Close()
+			return err
+		}
+		triggers = append(triggers, schemamigration.SequenceTriggerSQL(name, tableName, column))
+	}
+	rows.Close()
+
+	for _, trigger := range triggers {
+		if _, err := tx.Exec(trigger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSequence loads a sequence by name.
+func (h *Handler) getSequence(name string) (*Sequence, error) {
+	var s Sequence
+	err := h.db.QueryRow(`SELECT name, table_name, column_name, current_value, created_at FROM _sequences WHERE name = ?`, name).
+		Scan(&s.Name, &s.TableName, &s.ColumnName, &s.CurrentValue, &s.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// tableSequences returns a table's sequences.
+func (h *Handler) tableSequences(tableName string) []Sequence {
+	var sequences []Sequence
+	rows, err := h.db.Query(`SELECT name, table_name, column_name, current_value, created_at FROM _sequences
+		WHERE table_name = ? ORDER BY name`, tableName)
+	if err != nil {
+		return sequences
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var s Sequence
+		if rows.Scan(&s.Name, &s.TableName, &s.ColumnName, &s.CurrentValue, &s.CreatedAt) == nil {
+			sequences = append(sequences, s)
+		}
+	}
+	return sequences
+}
+
+// handleListSequences lists sequences, optionally only those of ?table.
+// GET /_/api/sequences
+func (h *Handler) handleListSequences(w http.ResponseWriter, r *http.Request) {
+	query := `SELECT name, table_name, column_name, current_value, created_at FROM _sequences`
+	var args []interface{}
+	if table := r.URL.Query().Get("table"); table != "" {
+		query += ` WHERE table_name = ?`
+		args = append(args, table)
+	}
+	rows, err := h.db.Query(query+` ORDER BY name`, args...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list sequences")
+		return
+	}
+	defer rows.Close()
+
+	sequences := []Sequence{}
+	for rows.Next() {
+		var s Sequence
+		if err := rows.Scan(&s.Name, &s.TableName, &s.ColumnName, &s.CurrentValue, &s.CreatedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to scan sequence")
+			return
+		}
+		sequences = append(sequences, s)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sequences)
+}
+
+// handleGetSequence returns a sequence and its current value.
+// GET /_/api/sequences/{name}
+func (h *Handler) handleGetSequence(w http.ResponseWriter, r *http.Request) {
+	s, err := h.getSequence(chi.URLParam(r, "name"))
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "sequence_not_found", "Sequence not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get sequence")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// handleResetSequence sets a sequence's current value, 0 by default, so the
+// next row gets value+1. Setting it below the column's largest value would
+// hand out numbers already in use, so that answers 409 unless force is set.
+// POST /_/api/sequences/{name}/reset
+func (h *Handler) handleResetSequence(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Value int64 `json:"value"`
+		Force bool  `json:"force"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+			return
+		}
+	}
+	if req.Value < 0 {
+		writeError(w, http.StatusBadRequest, "invalid_value", "value must not be negative")
+		return
+	}
+
+	s, err := h.getSequence(chi.URLParam(r, "name"))
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "sequence_not_found", "Sequence not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get sequence")
+		return
+	}
+
+	if !req.Force {
+		var maxValue int64
+		if err := h.db.QueryRow(fmt.Sprintf(`SELECT COALESCE(MAX("%s"), 0) FROM "%s" WHERE typeof("%s") = 'integer'`,
+			s.ColumnName, s.TableName, s.ColumnName)).Scan(&maxValue); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to check existing values")
+			return
+		}
+		if req.Value < maxValue {
+			writeErrorDetails(w, http.StatusConflict, "sequence_below_max",
+				fmt.Sprintf("%s already holds values up to %d; set force to reset anyway", s.ColumnName, maxValue),
+				map[string]interface{}{"max_value": maxValue})
+			return
+		}
+	}
+
+	err = h.runWrite(r, func() error {
+		_, err := h.db.Exec(`UPDATE _sequences SET current_value = ? WHERE name = ?`, req.Value, s.Name)
+		return err
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusInternalServerError)
+		return
+	}
+	s.CurrentValue = req.Value
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
@@ -0,0 +1,230 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	schemamigration "github.com/markb/sblite/internal/migration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequenceColumnOnCreateTable(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir())
+	r := chi.NewRouter()
+	r.Post("/tables", handler.handleCreateTable)
+	r.Get("/sequences/{name}", handler.handleGetSequence)
+	r.Post("/sequences/{name}/reset", handler.handleResetSequence)
+
+	body := `{"name": "orders", "columns": [
+		{"name": "id", "type": "uuid", "primary": true, "default": "gen_random_uuid()"},
+		{"name": "order_number", "type": "integer", "default": "nextval()"},
+		{"name": "item", "type": "text", "nullable": true}
+	]}`
+	req := httptest.NewRequest("POST", "/tables", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	for _, item := range []string{"a", "b"} {
+		_, err := database.Exec(`INSERT INTO orders (item) VALUES (?)`, item)
+		require.NoError(t, err)
+	}
+	// Explicit values are kept and don't advance the sequence
+	_, err := database.Exec(`INSERT INTO orders (item, order_number) VALUES ('c', 100)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO orders (item) VALUES ('d')`)
+	require.NoError(t, err)
+
+	numbers := func() map[string]int64 {
+		rows, err := database.Query(`SELECT item, order_number FROM orders`)
+		require.NoError(t, err)
+		defer rows.Close()
+		out := map[string]int64{}
+		for rows.Next() {
+			var item string
+			var n int64
+			require.NoError(t, rows.Scan(&item, &n))
+			out[item] = n
+		}
+		return out
+	}
+	assert.Equal(t, map[string]int64{"a": 1, "b": 2, "c": 100, "d": 3}, numbers())
+
+	req = httptest.NewRequest("GET", "/sequences/orders_order_number_seq", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var seq Sequence
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &seq))
+	assert.Equal(t, int64(3), seq.CurrentValue)
+	assert.Equal(t, "order_number", seq.ColumnName)
+
+	// Resetting below values in use needs force
+	req = httptest.NewRequest("POST", "/sequences/orders_order_number_seq/reset", bytes.NewBufferString(`{"value": 10}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+
+	req = httptest.NewRequest("POST", "/sequences/orders_order_number_seq/reset", bytes.NewBufferString(`{"value": 500}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	_, err = database.Exec(`INSERT INTO orders (item) VALUES ('e')`)
+	require.NoError(t, err)
+	assert.Equal(t, int64(501), numbers()["e"])
+
+	req = httptest.NewRequest("GET", "/sequences/missing_seq", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSequenceColumnAddAndDrop(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir())
+	_, err := database.Exec(`CREATE TABLE tickets (id INTEGER PRIMARY KEY, title TEXT, extra TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO tickets (title) VALUES ('x'), ('y')`)
+	require.NoError(t, err)
+	require.NoError(t, handler.ensureTableRegistered("tickets"))
+
+	r := chi.NewRouter()
+	r.Post("/tables/{name}/columns", handler.handleAddColumn)
+	r.Delete("/tables/{name}/columns/{column}", handler.handleDropColumn)
+
+	req := httptest.NewRequest("POST", "/tables/tickets/columns",
+		bytes.NewBufferString(`{"name": "ticket_no", "type": "text", "default": "nextval()"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code, "nextval() needs an integer column")
+
+	req = httptest.NewRequest("POST", "/tables/tickets/columns",
+		bytes.NewBufferString(`{"name": "ticket_no", "type": "integer", "default": "nextval()"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// Migration versions are per second
+	_, err = database.Exec(`DELETE FROM _schema_migrations`)
+	require.NoError(t, err)
+
+	// Existing rows are numbered and new ones continue after them
+	_, err = database.Exec(`INSERT INTO tickets (title) VALUES ('z')`)
+	require.NoError(t, err)
+	var got []int64
+	rows, err := database.Query(`SELECT ticket_no FROM tickets ORDER BY id`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var n int64
+		require.NoError(t, rows.Scan(&n))
+		got = append(got, n)
+	}
+	rows.Close()
+	assert.Equal(t, []int64{1, 2, 3}, got)
+
+	// Dropping another column rebuilds the table; the trigger must survive
+	req = httptest.NewRequest("DELETE", "/tables/tickets/columns/extra", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	_, err = database.Exec(`INSERT INTO tickets (title) VALUES ('w')`)
+	require.NoError(t, err)
+	var n int64
+	require.NoError(t, database.QueryRow(`SELECT ticket_no FROM tickets WHERE title = 'w'`).Scan(&n))
+	assert.Equal(t, int64(4), n)
+
+	_, err = database.Exec(`DELETE FROM _schema_migrations`)
+	require.NoError(t, err)
+	req = httptest.NewRequest("DELETE", "/tables/tickets/columns/ticket_no", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	var count int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM _sequences`).Scan(&count))
+	assert.Zero(t, count)
+	_, err = database.Exec(`INSERT INTO tickets (title) VALUES ('v')`)
+	require.NoError(t, err)
+}
+
+func TestSequenceMigrationReplay(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	migrationsDir := t.TempDir()
+	handler := NewHandler(database.DB, migrationsDir)
+	r := chi.NewRouter()
+	r.Post("/tables", handler.handleCreateTable)
+	r.Delete("/tables/{name}", handler.handleDeleteTable)
+
+	body := `{"name": "orders", "columns": [
+		{"name": "id", "type": "uuid", "primary": true, "default": "gen_random_uuid()"},
+		{"name": "order_number", "type": "integer", "default": "nextval()"},
+		{"name": "item", "type": "text", "nullable": true}
+	]}`
+	req := httptest.NewRequest("POST", "/tables", bytes.NewBufferString(body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	_, err := database.Exec(`INSERT INTO orders (item) VALUES ('a'), ('b')`)
+	require.NoError(t, err)
+
+	// Migration versions are per second
+	_, err = database.Exec(`DELETE FROM _schema_migrations`)
+	require.NoError(t, err)
+	req = httptest.NewRequest("DELETE", "/tables/orders", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	readMigration := func(pattern string) string {
+		matches, err := filepath.Glob(filepath.Join(migrationsDir, pattern))
+		require.NoError(t, err)
+		require.Len(t, matches, 1, pattern)
+		content, err := os.ReadFile(matches[0])
+		require.NoError(t, err)
+		// Migrations use PostgreSQL statements, not sblite's bookkeeping
+		assert.NotContains(t, string(content), "TRIGGER")
+		assert.NotContains(t, string(content), "_sequences")
+		return string(content)
+	}
+	createUp := readMigration("*_create_orders_table.sql")
+	assert.Contains(t, createUp, "CREATE SEQUENCE orders_order_number_seq;")
+	assert.Contains(t, createUp, "DEFAULT nextval('orders_order_number_seq')")
+	assert.Contains(t, readMigration("*_create_orders_table.down.sql"), "DROP SEQUENCE IF EXISTS orders_order_number_seq;")
+	dropUp := readMigration("*_drop_orders_table.sql")
+	assert.Contains(t, dropUp, "DROP SEQUENCE IF EXISTS orders_order_number_seq;")
+	dropDown := readMigration("*_drop_orders_table.down.sql")
+	assert.Contains(t, dropDown, "CREATE SEQUENCE orders_order_number_seq START WITH 3;")
+
+	// Replaying the files elsewhere gives the same numbering
+	replica := setupTestDB(t)
+	defer replica.Close()
+	runner := schemamigration.NewRunner(replica.DB)
+	require.NoError(t, runner.Apply(schemamigration.Migration{Version: "20260101000000", Name: "create_orders_table", SQL: createUp}))
+	_, err = replica.Exec(`INSERT INTO orders (item) VALUES ('a'), ('b')`)
+	require.NoError(t, err)
+	dropMigration := schemamigration.Migration{Version: "20260101000001", Name: "drop_orders_table", SQL: dropUp}
+	require.NoError(t, runner.Apply(dropMigration))
+	var count int
+	require.NoError(t, replica.QueryRow(`SELECT COUNT(*) FROM _sequences`).Scan(&count))
+	assert.Zero(t, count)
+
+	require.NoError(t, runner.Revert(dropMigration, dropDown))
+	_, err = replica.Exec(`INSERT INTO orders (item) VALUES ('c')`)
+	require.NoError(t, err)
+	var n int64
+	require.NoError(t, replica.QueryRow(`SELECT order_number FROM orders WHERE item = 'c'`).Scan(&n))
+	assert.Equal(t, int64(3), n)
+}
@@ -0,0 +1,154 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// sessionMaxAge is how long a dashboard session cookie lasts, in seconds.
+const sessionMaxAge = 86400
+
+// SessionCookieSettings controls the attributes of the dashboard session
+// cookie, for deployments behind a reverse proxy on another path or site.
+type SessionCookieSettings struct {
+	// SameSite is "strict", "lax" or "none".
+	SameSite string `json:"same_site"`
+	// Secure is "auto" (set when the request arrived over HTTPS, directly or
+	// per X-Forwarded-Proto), "always" or "never".
+	Secure string `json:"secure"`
+	Path   string `json:"path"`
+	Domain string `json:"domain"`
+}
+
+// sessionCookieSettings returns the configured cookie attributes, with
+// defaults for unset values.
+func (h *Handler) sessionCookieSettings() SessionCookieSettings {
+	settings := SessionCookieSettings{SameSite: "strict", Secure: "auto", Path: h.BasePath() + "/"}
+	if v, _ := h.store.Get("session_cookie_same_site"); v != "" {
+		settings.SameSite = v
+	}
+	if v, _ := h.store.Get("session_cookie_secure"); v != "" {
+		settings.Secure = v
+	}
+	if v, _ := h.store.Get("session_cookie_path"); v != "" {
+		settings.Path = v
+	}
+	settings.Domain, _ = h.store.Get("session_cookie_domain")
+	return settings
+}
+
+// setSessionCookie writes the session cookie with the configured attributes.
+// A negative maxAge deletes it.
+func (h *Handler) setSessionCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, h.dashboardCookie(r, h.sessionCookieName(), value, maxAge))
+}
+
+// dashboardCookie builds an HttpOnly cookie with the configured session
+// cookie attributes.
+func (h *Handler) dashboardCookie(r *http.Request, name, value string, maxAge int) *http.Cookie {
+	settings := h.sessionCookieSettings()
+
+	sameSite := http.SameSiteStrictMode
+	switch settings.SameSite {
+	case "lax":
+		sameSite = http.SameSiteLaxMode
+	case "none":
+		sameSite = http.SameSiteNoneMode
+	}
+
+	secure := r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+	switch settings.Secure {
+	case "always":
+		secure = true
+	case "never":
+		secure = false
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     settings.Path,
+		Domain:   settings.Domain,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: sameSite,
+		MaxAge:   maxAge,
+	}
+}
+
+// handleGetSessionCookieSettings returns the session cookie attributes.
+// GET /_/api/settings/session-cookie
+func (h *Handler) handleGetSessionCookieSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.sessionCookieSettings())
+}
+
+// handleUpdateSessionCookieSettings changes the session cookie attributes.
+// They apply to cookies issued from the next login on.
+// PATCH /_/api/settings/session-cookie
+func (h *Handler) handleUpdateSessionCookieSettings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SameSite *string `json:"same_site"`
+		Secure   *string `json:"secure"`
+		Path     *string `json:"path"`
+		Domain   *string `json:"domain"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
+		return
+	}
+
+	current := h.sessionCookieSettings()
+	if req.SameSite != nil {
+		switch *req.SameSite {
+		case "strict", "lax", "none":
+			current.SameSite = *req.SameSite
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_setting", "same_site must be strict, lax or none")
+			return
+		}
+	}
+	if req.Secure != nil {
+		switch *req.Secure {
+		case "auto", "always", "never":
+			current.Secure = *req.Secure
+		default:
+			writeError(w, http.StatusBadRequest, "invalid_setting", "secure must be auto, always or never")
+			return
+		}
+	}
+	if req.Path != nil {
+		if !strings.HasPrefix(*req.Path, "/") {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "path must start with /")
+			return
+		}
+		current.Path = *req.Path
+	}
+	if req.Domain != nil {
+		if strings.ContainsAny(*req.Domain, " ;/") {
+			writeError(w, http.StatusBadRequest, "invalid_setting", "domain is not a valid host name")
+			return
+		}
+		current.Domain = *req.Domain
+	}
+	// Browsers drop SameSite=None cookies that are not Secure
+	if current.SameSite == "none" && current.Secure == "never" {
+		writeError(w, http.StatusBadRequest, "invalid_setting", "same_site none requires secure auto or always")
+		return
+	}
+
+	for key, val := range map[string]string{
+		"session_cookie_same_site": current.SameSite,
+		"session_cookie_secure":    current.Secure,
+		"session_cookie_path":      current.Path,
+		"session_cookie_domain":    current.Domain,
+	} {
+		if err := h.store.Set(key, val); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "failed to save settings")
+			return
+		}
+	}
+
+	h.handleGetSessionCookieSettings(w, r)
+}
@@ -0,0 +1,58 @@
+package dashboard
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionCookieAttributes(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	cookieFor := func(r *http.Request) *http.Cookie {
+		w := httptest.NewRecorder()
+		handler.setSessionCookie(w, r, "token", sessionMaxAge)
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		return cookies[0]
+	}
+
+	// Defaults: strict, dashboard path, Secure only over HTTPS
+	c := cookieFor(httptest.NewRequest("POST", "/_/api/auth/login", nil))
+	assert.Equal(t, http.SameSiteStrictMode, c.SameSite)
+	assert.Equal(t, "/_/", c.Path)
+	assert.False(t, c.Secure)
+
+	proxied := httptest.NewRequest("POST", "/_/api/auth/login", nil)
+	proxied.Header.Set("X-Forwarded-Proto", "https")
+	assert.True(t, cookieFor(proxied).Secure)
+
+	r := chi.NewRouter()
+	r.Patch("/settings/session-cookie", handler.handleUpdateSessionCookieSettings)
+	patch := func(body string) int {
+		req := httptest.NewRequest("PATCH", "/settings/session-cookie", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, patch(`{"same_site": "none", "secure": "always", "path": "/admin/_/", "domain": "example.com"}`))
+	c = cookieFor(httptest.NewRequest("POST", "/_/api/auth/login", nil))
+	assert.Equal(t, http.SameSiteNoneMode, c.SameSite)
+	assert.True(t, c.Secure)
+	assert.Equal(t, "/admin/_/", c.Path)
+	assert.Equal(t, "example.com", c.Domain)
+
+	assert.Equal(t, http.StatusBadRequest, patch(`{"secure": "never"}`))
+	assert.Equal(t, http.StatusBadRequest, patch(`{"same_site": "sometimes"}`))
+	assert.Equal(t, http.StatusBadRequest, patch(`{"path": "admin"}`))
+	assert.Equal(t, http.StatusOK, patch(`{"same_site": "lax", "secure": "never"}`))
+	assert.False(t, cookieFor(proxied).Secure)
+}
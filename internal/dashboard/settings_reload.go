@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/markb/sblite/internal/log"
+)
+
+// SettingsReloadResult reports the outcome of a configuration reload.
+// Subsystems without a registered reload hook are listed as skipped.
+type SettingsReloadResult struct {
+	Reloaded []string          `json:"reloaded"`
+	Skipped  []string          `json:"skipped"`
+	Errors   map[string]string `json:"errors"`
+}
+
+// reloadSettings re-applies the stored configuration to every subsystem
+// that registered a reload hook. A failing subsystem does not stop the
+// others from being refreshed.
+func (h *Handler) reloadSettings() *SettingsReloadResult {
+	result := &SettingsReloadResult{
+		Reloaded: []string{},
+		Skipped:  []string{},
+		Errors:   map[string]string{},
+	}
+
+	// An unset site URL means the server default is in use, so there is
+	// nothing to push.
+	if siteURL := h.GetSiteURL(); h.onSiteURLChange != nil && siteURL != "" {
+		h.onSiteURLChange(siteURL)
+		result.Reloaded = append(result.Reloaded, "site_url")
+	} else {
+		result.Skipped = append(result.Skipped, "site_url")
+	}
+
+	if h.onStorageReload != nil {
+		if err := h.onStorageReload(h.buildStorageConfig()); err != nil {
+			result.Errors["storage"] = err.Error()
+		} else {
+			result.Reloaded = append(result.Reloaded, "storage")
+		}
+	} else {
+		result.Skipped = append(result.Skipped, "storage")
+	}
+
+	if h.onMailReload != nil {
+		if err := h.onMailReload(h.buildMailConfig()); err != nil {
+			result.Errors["mail"] = err.Error()
+		} else {
+			result.Reloaded = append(result.Reloaded, "mail")
+		}
+	} else {
+		result.Skipped = append(result.Skipped, "mail")
+	}
+
+	if h.oauthReloadFunc != nil {
+		h.oauthReloadFunc()
+		result.Reloaded = append(result.Reloaded, "oauth")
+	} else {
+		result.Skipped = append(result.Skipped, "oauth")
+	}
+
+	return result
+}
+
+// handleReloadSettings re-reads the stored configuration and hot-reloads
+// each subsystem, e.g. after settings were changed outside the dashboard.
+// POST /_/api/settings/reload
+func (h *Handler) handleReloadSettings(w http.ResponseWriter, r *http.Request) {
+	result := h.reloadSettings()
+
+	if len(result.Errors) > 0 {
+		log.Warn("settings reload completed with errors",
+			"reloaded", result.Reloaded, "errors", result.Errors, "remote_addr", r.RemoteAddr)
+	} else {
+		log.Info("settings reloaded", "reloaded", result.Reloaded, "remote_addr", r.RemoteAddr)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
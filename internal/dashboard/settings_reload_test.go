@@ -0,0 +1,45 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerReloadSettings(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	require.NoError(t, handler.store.Set("site_url", "https://example.com"))
+	require.NoError(t, handler.store.Set("storage_backend", "local"))
+
+	var siteURL string
+	var storageBackend string
+	handler.SetOnSiteURLChange(func(u string) { siteURL = u })
+	handler.SetStorageReloadFunc(func(cfg *StorageConfig) error {
+		storageBackend = cfg.Backend
+		return nil
+	})
+	handler.SetMailReloadFunc(func(cfg *MailConfig) error {
+		return errors.New("smtp unreachable")
+	})
+
+	req := httptest.NewRequest("POST", "/settings/reload", nil)
+	w := httptest.NewRecorder()
+	handler.handleReloadSettings(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp SettingsReloadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.ElementsMatch(t, []string{"site_url", "storage"}, resp.Reloaded)
+	assert.Equal(t, []string{"oauth"}, resp.Skipped)
+	assert.Equal(t, "smtp unreachable", resp.Errors["mail"])
+	assert.Equal(t, "https://example.com", siteURL)
+	assert.Equal(t, "local", storageBackend)
+}
@@ -185,6 +185,7 @@ const App = {
                 currentFile: null,      // Currently open file path
                 content: '',            // File content in editor
                 originalContent: '',    // For dirty detection
+                baseHash: null,         // Hash of the file as last read/saved
                 isDirty: false,         // Has unsaved changes
                 tree: null,             // File tree structure
                 expandedFolders: {},    // Which folders are expanded
@@ -5074,6 +5075,9 @@ const App = {
                                 <button class="btn btn-secondary" onclick="App.exportData('csv')">
                                     Export Data (CSV)
                                 </button>
+                                <button class="btn btn-secondary" onclick="App.exportData('ndjson')">
+                                    Export Data (NDJSON)
+                                </button>
                                 <small>Export all table data</small>
                             </div>
                             <div class="export-item">
@@ -5268,7 +5272,11 @@ const App = {
             const res = await fetch('/_/api/logs/tail?lines=100');
             if (res.ok) {
                 const data = await res.json();
-                this.state.logs.tailLines = data.lines || [];
+                // JSON-format logs come back parsed; show them as readable lines
+                this.state.logs.tailLines = data.entries
+                    ? data.entries.map(e => [e.timestamp, e.level, e.message, e.fields ? JSON.stringify(e.fields) : '']
+                        .filter(Boolean).join(' '))
+                    : (data.lines || []);
             }
         } catch (e) {
             this.state.logs.tailLines = [];
@@ -6704,6 +6712,7 @@ const App = {
             currentFile: null,
             content: '',
             originalContent: '',
+            baseHash: null,
             isDirty: false,
             tree: null,
             expandedFolders: {},
@@ -6739,6 +6748,7 @@ const App = {
             currentFile: null,
             content: '',
             originalContent: '',
+            baseHash: null,
             isDirty: false,
             tree: null,
             expandedFolders: {},
@@ -7049,6 +7059,7 @@ const App = {
                 this.state.functions.editor.currentFile = path;
                 this.state.functions.editor.content = data.content;
                 this.state.functions.editor.originalContent = data.content;
+                this.state.functions.editor.baseHash = data.hash;
                 this.state.functions.editor.isDirty = false;
 
                 // Update Monaco editor if it exists
@@ -7064,21 +7075,32 @@ const App = {
         }
     },
 
-    async saveFunctionFile() {
+    async saveFunctionFile(force = false) {
         const { selected } = this.state.functions;
-        const { currentFile, monacoEditor } = this.state.functions.editor;
+        const { currentFile, monacoEditor, baseHash } = this.state.functions.editor;
         if (!selected || !currentFile) return;
 
         const content = monacoEditor ? monacoEditor.getValue() : this.state.functions.editor.content;
+        const body = { content };
+        if (!force && baseHash) body.base_hash = baseHash;
 
         try {
             const res = await fetch(`/_/api/functions/${selected}/files/${currentFile}`, {
                 method: 'PUT',
                 headers: { 'Content-Type': 'application/json' },
-                body: JSON.stringify({ content })
+                body: JSON.stringify(body)
             });
 
+            if (res.status === 409) {
+                if (confirm('This file was changed outside the editor since you opened it. Overwrite those changes?')) {
+                    await this.saveFunctionFile(true);
+                }
+                return;
+            }
+
             if (res.ok) {
+                const data = await res.json();
+                this.state.functions.editor.baseHash = data.hash;
                 this.state.functions.editor.originalContent = content;
                 this.state.functions.editor.content = content;
                 this.state.functions.editor.isDirty = false;
@@ -8601,6 +8623,7 @@ const App = {
                     this.state.functions.editor.currentFile = null;
                     this.state.functions.editor.content = '';
                     this.state.functions.editor.originalContent = '';
+                    this.state.functions.editor.baseHash = null;
                     this.state.functions.editor.isDirty = false;
                     if (this.state.functions.editor.monacoEditor) {
                         this.state.functions.editor.monacoEditor.setValue('// Select a file to edit');
@@ -9989,6 +10012,11 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
                     <div class="stat-card">
                         <div class="stat-label">Sample Rate</div>
                         <div class="stat-value">${(config?.sampleRate * 100).toFixed(0)}%</div>
+                        ${(config?.routeSampling || []).length ? `
+                            <div style="font-size: 0.8rem; color: var(--text-muted);">
+                                ${config.routeSampling.map(r => `${this.escapeHtml(r.prefix)}: ${(r.rate * 100).toFixed(0)}%`).join('<br>')}
+                            </div>
+                        ` : ''}
                     </div>
                     <div class="stat-card">
                         <div class="stat-label">Metrics</div>
@@ -10168,6 +10196,14 @@ curl -X POST '${baseUrl}/auth/v1/invite' \\
                                             <td style="${isSlow ? 'color: var(--warning); font-weight: bold;' : ''}">${duration.toFixed(1)}ms</td>
                                             <td style="font-size: 0.8rem; color: var(--text-muted); max-width: 300px; overflow: hidden; text-overflow: ellipsis;" title="${t.tags}">${t.tags}</td>
                                         </tr>
+                                        ${(t.spans || []).map(s => `
+                                            <tr class="trace-span-row" style="font-size: 0.85rem; color: var(--text-muted);">
+                                                <td></td>
+                                                <td colspan="2">&#8627; <code>function.invoke</code></td>
+                                                <td>${(s.duration_ms || 0).toFixed(1)}ms</td>
+                                                <td>${this.escapeHtml(s['function.name'] || '')} (${s['http.status_code'] || 'N/A'})</td>
+                                            </tr>
+                                        `).join('')}
                                     `;
                                 }).join('')}
                             </tbody>
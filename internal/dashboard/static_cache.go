@@ -0,0 +1,120 @@
+package dashboard
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache policies for embedded dashboard files. Vite puts a content hash in
+// every assets/* file name, so those can be cached indefinitely; index.html
+// and the legacy static files keep their names and must be revalidated.
+const (
+	cacheImmutable  = "public, max-age=31536000, immutable"
+	cacheRevalidate = "no-cache"
+)
+
+// embeddedModTime stands in for the modification time of embedded files,
+// which only change when the binary does.
+var embeddedModTime = time.Now().UTC().Truncate(time.Second)
+
+// embeddedETags caches content hashes of embedded files by path.
+var embeddedETags sync.Map
+
+// embeddedETag returns a strong ETag for an embedded file, hashing its
+// content on first use.
+func embeddedETag(path string, content []byte) string {
+	if etag, ok := embeddedETags.Load(path); ok {
+		return etag.(string)
+	}
+	sum := sha256.Sum256(content)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	embeddedETags.Store(path, etag)
+	return etag
+}
+
+// serveEmbedded writes an embedded file with caching headers. It answers
+// HEAD requests and conditional requests (If-None-Match, If-Modified-Since)
+// with 304 when the client's copy is current.
+func serveEmbedded(w http.ResponseWriter, r *http.Request, path string, content []byte, contentType, cacheControl string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", embeddedETag(path, content))
+	http.ServeContent(w, r, path, embeddedModTime, bytes.NewReader(content))
+}
+
+// gzipMinSize is the smallest file worth compressing on the fly.
+const gzipMinSize = 1024
+
+// embeddedGzips caches on-the-fly gzip encodings of embedded files by path.
+var embeddedGzips sync.Map
+
+// serveEmbeddedCompressed serves an embedded file in the best encoding the
+// client accepts: a pre-compressed .br or .gz sibling from fsys if the build
+// produced one, otherwise gzip computed once per file for compressible types,
+// otherwise the file as is.
+func serveEmbeddedCompressed(w http.ResponseWriter, r *http.Request, fsys fs.FS, path string, content []byte, contentType, cacheControl string) {
+	w.Header().Add("Vary", "Accept-Encoding")
+
+	for _, enc := range []struct{ name, ext string }{{"br", ".br"}, {"gzip", ".gz"}} {
+		if !acceptsEncoding(r, enc.name) {
+			continue
+		}
+		if compressed, err := fs.ReadFile(fsys, path+enc.ext); err == nil {
+			w.Header().Set("Content-Encoding", enc.name)
+			serveEmbedded(w, r, path+enc.ext, compressed, contentType, cacheControl)
+			return
+		}
+	}
+
+	if acceptsEncoding(r, "gzip") && len(content) >= gzipMinSize && isCompressible(contentType) {
+		w.Header().Set("Content-Encoding", "gzip")
+		serveEmbedded(w, r, path+".gz", embeddedGzip(path, content), contentType, cacheControl)
+		return
+	}
+
+	serveEmbedded(w, r, path, content, contentType, cacheControl)
+}
+
+// embeddedGzip returns the gzip encoding of an embedded file, compressing it
+// on first use.
+func embeddedGzip(path string, content []byte) []byte {
+	if gz, ok := embeddedGzips.Load(path); ok {
+		return gz.([]byte)
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(content)
+	zw.Close()
+	embeddedGzips.Store(path, buf.Bytes())
+	return buf.Bytes()
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header lists enc
+// without a zero quality value.
+func acceptsEncoding(r *http.Request, enc string) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), enc) {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// isCompressible reports whether a content type benefits from gzip; images
+// other than SVG and fonts like woff2 are already compressed.
+func isCompressible(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.HasPrefix(contentType, "application/javascript") ||
+		strings.HasPrefix(contentType, "application/json") ||
+		strings.HasPrefix(contentType, "image/svg+xml")
+}
@@ -0,0 +1,124 @@
+package dashboard
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticCachingHeaders(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	r := chi.NewRouter()
+	r.Get("/static/*", handler.handleStatic)
+	r.Head("/static/*", handler.handleStatic)
+	r.Get("/", handler.handleIndex)
+
+	req := httptest.NewRequest("GET", "/static/app.js", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.NotEmpty(t, w.Header().Get("Last-Modified"))
+	assert.Equal(t, cacheRevalidate, w.Header().Get("Cache-Control"))
+	assert.Equal(t, "application/javascript; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotZero(t, w.Body.Len())
+
+	// A matching ETag is answered without a body
+	req = httptest.NewRequest("GET", "/static/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Zero(t, w.Body.Len())
+
+	req = httptest.NewRequest("HEAD", "/static/app.js", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Zero(t, w.Body.Len())
+
+	req = httptest.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, cacheRevalidate, w.Header().Get("Cache-Control"))
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
+
+func TestAssetsCachedImmutably(t *testing.T) {
+	w := httptest.NewRecorder()
+	serveEmbedded(w, httptest.NewRequest("GET", "/assets/index-abc123.js", nil),
+		"assets/index-abc123.js", []byte("console.log(1)"), "application/javascript; charset=utf-8", cacheImmutable)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, cacheImmutable, w.Header().Get("Cache-Control"))
+	assert.Equal(t, "console.log(1)", w.Body.String())
+}
+
+func TestAssetsServedCompressed(t *testing.T) {
+	js := []byte(strings.Repeat("console.log('dashboard');\n", 100))
+	fsys := fstest.MapFS{
+		"assets/app.js":       {Data: js},
+		"assets/app.js.br":    {Data: []byte("brotli-bytes")},
+		"assets/small.css":    {Data: []byte("body{}")},
+		"assets/logo.png":     {Data: bytes.Repeat([]byte{0x89}, 4096)},
+		"assets/vendor.js":    {Data: js},
+		"assets/vendor.js.gz": {Data: []byte("pre-gzipped")},
+	}
+	serve := func(path, acceptEncoding, contentType string) *httptest.ResponseRecorder {
+		content, err := fsys.ReadFile(path)
+		require.NoError(t, err)
+		req := httptest.NewRequest("GET", "/"+path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		serveEmbeddedCompressed(w, req, fsys, path, content, contentType, cacheImmutable)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+		return w
+	}
+	const jsType = "application/javascript; charset=utf-8"
+
+	// Pre-compressed siblings win, brotli first
+	w := serve("assets/app.js", "gzip, deflate, br", jsType)
+	assert.Equal(t, "br", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "brotli-bytes", w.Body.String())
+	assert.Equal(t, jsType, w.Header().Get("Content-Type"))
+
+	w = serve("assets/vendor.js", "gzip, br", jsType)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "pre-gzipped", w.Body.String())
+
+	// Without a gzip sibling the file is compressed on the fly
+	w = serve("assets/app.js", "gzip, br;q=0", jsType)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	plain, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, js, plain)
+
+	// Plain when the client accepts nothing, the file is tiny, or already compressed
+	for _, tc := range []struct{ path, accept, contentType string }{
+		{"assets/app.js", "", jsType},
+		{"assets/small.css", "gzip", "text/css; charset=utf-8"},
+		{"assets/logo.png", "gzip", "image/png"},
+	} {
+		w = serve(tc.path, tc.accept, tc.contentType)
+		assert.Empty(t, w.Header().Get("Content-Encoding"), tc.path)
+	}
+}
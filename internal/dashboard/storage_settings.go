@@ -62,7 +62,7 @@ func (h *Handler) handleGetStorageSettings(w http.ResponseWriter, r *http.Reques
 	s3Region, _ := h.store.Get("storage_s3_region")
 	s3Bucket, _ := h.store.Get("storage_s3_bucket")
 	s3AccessKey, _ := h.store.Get("storage_s3_access_key")
-	s3SecretKey, _ := h.store.Get("storage_s3_secret_key")
+	s3SecretKey := h.getSecretSetting("storage_s3_secret_key")
 	s3PathStyle, _ := h.store.Get("storage_s3_path_style")
 
 	resp := StorageSettingsResponse{
@@ -88,14 +88,14 @@ func (h *Handler) handleGetStorageSettings(w http.ResponseWriter, r *http.Reques
 func (h *Handler) handleUpdateStorageSettings(w http.ResponseWriter, r *http.Request) {
 	var req StorageSettingsUpdate
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
 		return
 	}
 
 	// Update backend type
 	if req.Backend != "" {
 		if req.Backend != "local" && req.Backend != "s3" {
-			http.Error(w, "backend must be 'local' or 's3'", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, "invalid_setting", "backend must be 'local' or 's3'")
 			return
 		}
 		h.store.Set("storage_backend", req.Backend)
@@ -122,7 +122,10 @@ func (h *Handler) handleUpdateStorageSettings(w http.ResponseWriter, r *http.Req
 		}
 		// Only update secret if not masked
 		if req.S3.SecretKey != "" && req.S3.SecretKey != "********" {
-			h.store.Set("storage_s3_secret_key", req.S3.SecretKey)
+			if err := h.setSecretSetting("storage_s3_secret_key", req.S3.SecretKey); err != nil {
+				writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+				return
+			}
 		}
 		h.store.Set("storage_s3_path_style", boolToString(req.S3.PathStyle))
 	}
@@ -131,7 +134,7 @@ func (h *Handler) handleUpdateStorageSettings(w http.ResponseWriter, r *http.Req
 	if h.onStorageReload != nil {
 		cfg := h.buildStorageConfig()
 		if err := h.onStorageReload(cfg); err != nil {
-			http.Error(w, "failed to reload storage: "+err.Error(), http.StatusInternalServerError)
+			writeError(w, http.StatusInternalServerError, "reload_failed", "failed to reload storage: "+err.Error())
 			return
 		}
 	}
@@ -145,7 +148,7 @@ func (h *Handler) handleUpdateStorageSettings(w http.ResponseWriter, r *http.Req
 func (h *Handler) handleTestStorageConnection(w http.ResponseWriter, r *http.Request) {
 	var req StorageS3Config
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid JSON", http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, "invalid_request", "invalid JSON")
 		return
 	}
 
@@ -162,7 +165,7 @@ func (h *Handler) handleTestStorageConnection(w http.ResponseWriter, r *http.Req
 	// If secret is masked, use stored secret
 	secretKey := req.SecretKey
 	if secretKey == "********" || secretKey == "" {
-		secretKey, _ = h.store.Get("storage_s3_secret_key")
+		secretKey = h.getSecretSetting("storage_s3_secret_key")
 	}
 
 	// Create S3 backend to test connection
@@ -209,7 +212,7 @@ func (h *Handler) buildStorageConfig() *StorageConfig {
 	s3Region, _ := h.store.Get("storage_s3_region")
 	s3Bucket, _ := h.store.Get("storage_s3_bucket")
 	s3AccessKey, _ := h.store.Get("storage_s3_access_key")
-	s3SecretKey, _ := h.store.Get("storage_s3_secret_key")
+	s3SecretKey := h.getSecretSetting("storage_s3_secret_key")
 	s3PathStyle, _ := h.store.Get("storage_s3_path_style")
 
 	return &StorageConfig{
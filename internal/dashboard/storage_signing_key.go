@@ -0,0 +1,73 @@
+package dashboard
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/markb/sblite/internal/log"
+)
+
+// storageSigningKeySetting is the _dashboard key holding the key that signs
+// storage URLs. It is separate from the JWT secret so that either can be
+// rotated without invalidating what the other protects.
+const storageSigningKeySetting = "storage_signing_key"
+
+func newStorageSigningKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// StorageSigningKey returns the storage URL signing key, generating and
+// saving one on first use.
+func (h *Handler) StorageSigningKey() (string, error) {
+	if key, _ := h.store.Get(storageSigningKeySetting); key != "" {
+		return key, nil
+	}
+	key, err := newStorageSigningKey()
+	if err != nil {
+		return "", err
+	}
+	if err := h.store.Set(storageSigningKeySetting, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// SetStorageSigningKeyFunc sets the callback applying a rotated storage
+// signing key to the storage API.
+func (h *Handler) SetStorageSigningKeyFunc(f func(string)) {
+	h.onSigningKeyRotate = f
+}
+
+// handleRotateStorageSigningKey replaces the storage URL signing key. Signed
+// download and upload URLs issued before are rejected from then on; auth
+// sessions and API keys are unaffected.
+// POST /_/api/storage/rotate-signing-key
+func (h *Handler) handleRotateStorageSigningKey(w http.ResponseWriter, r *http.Request) {
+	key, err := newStorageSigningKey()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to generate signing key")
+		return
+	}
+	if err := h.store.Set(storageSigningKeySetting, key); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to save signing key")
+		return
+	}
+	if h.onSigningKeyRotate != nil {
+		h.onSigningKeyRotate(key)
+	}
+	log.Info("storage signing key rotated", "audit", true, "remote_addr", clientIP(r))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":    true,
+		"message":    "Storage signing key rotated. Outstanding signed URLs have been invalidated.",
+		"rotated_at": time.Now().UTC().Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,49 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateStorageSigningKey(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	handler.jwtSecret = "jwt-secret"
+
+	key, err := handler.StorageSigningKey()
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+	again, err := handler.StorageSigningKey()
+	require.NoError(t, err)
+	assert.Equal(t, key, again, "key is generated once and persisted")
+
+	var applied string
+	handler.SetStorageSigningKeyFunc(func(k string) { applied = k })
+
+	token, err := storage.GenerateDownloadToken("avatars", "a.png", 60, key)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/storage/rotate-signing-key", handler.handleRotateStorageSigningKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("POST", "/storage/rotate-signing-key", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	rotated, err := handler.StorageSigningKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, rotated)
+	assert.Equal(t, rotated, applied)
+
+	// URLs signed with the old key are rejected; the JWT secret is untouched
+	_, err = storage.ValidateDownloadToken(token, rotated)
+	assert.Error(t, err)
+	assert.Equal(t, "jwt-secret", handler.jwtSecret)
+}
@@ -0,0 +1,70 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+// BucketUsage summarizes the objects stored in one bucket.
+type BucketUsage struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	ObjectCount   int64  `json:"object_count"`
+	TotalBytes    int64  `json:"total_bytes"`
+	FileSizeLimit *int64 `json:"file_size_limit"`
+	// OverLimitCount counts objects larger than the bucket's current size
+	// limit, e.g. uploaded before the limit was lowered.
+	OverLimitCount int64 `json:"over_limit_count"`
+}
+
+// StorageUsage is the per-bucket and overall storage usage summary.
+type StorageUsage struct {
+	Buckets     []BucketUsage `json:"buckets"`
+	ObjectCount int64         `json:"object_count"`
+	TotalBytes  int64         `json:"total_bytes"`
+}
+
+// handleStorageUsage returns object counts and total bytes per bucket,
+// computed from storage_objects.
+// GET /_/api/storage/usage
+func (h *Handler) handleStorageUsage(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(`
+		SELECT b.id, b.name, b.file_size_limit,
+			COUNT(o.id),
+			COALESCE(SUM(o.size), 0),
+			COALESCE(SUM(CASE WHEN b.file_size_limit > 0 AND o.size > b.file_size_limit THEN 1 ELSE 0 END), 0)
+		FROM storage_buckets b
+		LEFT JOIN storage_objects o ON o.bucket_id = b.id
+		GROUP BY b.id
+		ORDER BY b.name
+	`)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to compute storage usage")
+		return
+	}
+	defer rows.Close()
+
+	usage := StorageUsage{Buckets: []BucketUsage{}}
+	for rows.Next() {
+		var b BucketUsage
+		var limit sql.NullInt64
+		if err := rows.Scan(&b.ID, &b.Name, &limit, &b.ObjectCount, &b.TotalBytes, &b.OverLimitCount); err != nil {
+			writeError(w, http.StatusInternalServerError, "internal_error", "Failed to compute storage usage")
+			return
+		}
+		if limit.Valid && limit.Int64 > 0 {
+			b.FileSizeLimit = &limit.Int64
+		}
+		usage.Buckets = append(usage.Buckets, b)
+		usage.ObjectCount += b.ObjectCount
+		usage.TotalBytes += b.TotalBytes
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to compute storage usage")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usage)
+}
@@ -0,0 +1,102 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageUsage(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`INSERT INTO storage_buckets (id, name, file_size_limit) VALUES
+		('avatars', 'avatars', 100), ('docs', 'docs', NULL), ('empty', 'empty', NULL)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO storage_objects (id, bucket_id, name, size) VALUES
+		('1', 'avatars', 'a.png', 50), ('2', 'avatars', 'b.png', 150),
+		('3', 'docs', 'report.pdf', 1000)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/storage/usage", handler.handleStorageUsage)
+
+	req := httptest.NewRequest("GET", "/storage/usage", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var usage StorageUsage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &usage))
+	assert.Equal(t, int64(3), usage.ObjectCount)
+	assert.Equal(t, int64(1200), usage.TotalBytes)
+	require.Len(t, usage.Buckets, 3)
+
+	avatars := usage.Buckets[0]
+	assert.Equal(t, "avatars", avatars.Name)
+	assert.Equal(t, int64(2), avatars.ObjectCount)
+	assert.Equal(t, int64(200), avatars.TotalBytes)
+	require.NotNil(t, avatars.FileSizeLimit)
+	assert.Equal(t, int64(1), avatars.OverLimitCount)
+
+	docs := usage.Buckets[1]
+	assert.Equal(t, int64(1000), docs.TotalBytes)
+	assert.Nil(t, docs.FileSizeLimit)
+	assert.Equal(t, int64(0), docs.OverLimitCount)
+
+	assert.Equal(t, int64(0), usage.Buckets[2].ObjectCount)
+}
+
+func TestListBucketsPagination(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	svc, err := storage.NewService(database.DB, storage.Config{LocalPath: t.TempDir()})
+	require.NoError(t, err)
+	handler.SetStorageService(svc)
+	for _, name := range []string{"beta", "alpha", "gamma", "alpine"} {
+		_, err = svc.CreateBucket(storage.CreateBucketRequest{Name: name}, "")
+		require.NoError(t, err)
+	}
+
+	r := chi.NewRouter()
+	r.Get("/storage/buckets", handler.handleListBuckets)
+
+	list := func(query string) (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/storage/buckets"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp
+	}
+	names := func(resp map[string]interface{}) []string {
+		var out []string
+		for _, b := range resp["buckets"].([]interface{}) {
+			out = append(out, b.(map[string]interface{})["name"].(string))
+		}
+		return out
+	}
+
+	code, resp := list("?limit=2&sort=name&order=desc")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(4), resp["total"])
+	assert.Equal(t, float64(2), resp["limit"])
+	assert.Equal(t, []string{"gamma", "beta"}, names(resp))
+
+	code, resp = list("?search=alp&sort=name")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(2), resp["total"])
+	assert.Equal(t, []string{"alpha", "alpine"}, names(resp))
+
+	code, _ = list("?sort=size")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
@@ -0,0 +1,144 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/storage"
+)
+
+// handleListStorageWebhooks lists webhooks notified of storage events.
+// GET /_/api/storage/webhooks
+func (h *Handler) handleListStorageWebhooks(w http.ResponseWriter, r *http.Request) {
+	if h.storageService == nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
+		return
+	}
+
+	hooks, err := h.storageService.ListWebhooks()
+	if err != nil {
+		h.handleStorageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hooks)
+}
+
+// handleCreateStorageWebhook registers a webhook for object uploads and
+// deletes, optionally limited to one bucket and a subset of events. The
+// response carries the signing secret, which is not shown again.
+// POST /_/api/storage/webhooks
+func (h *Handler) handleCreateStorageWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.storageService == nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
+		return
+	}
+
+	var req storage.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if req.URL == nil || *req.URL == "" {
+		writeError(w, http.StatusBadRequest, "missing_field", "url is required")
+		return
+	}
+
+	hook, err := h.storageService.CreateWebhook(req)
+	if err != nil {
+		h.handleStorageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(hook)
+}
+
+// handleGetStorageWebhook returns one storage webhook.
+// GET /_/api/storage/webhooks/{id}
+func (h *Handler) handleGetStorageWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.storageService == nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
+		return
+	}
+
+	hook, err := h.storageService.GetWebhook(chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleStorageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hook)
+}
+
+// handleUpdateStorageWebhook changes a webhook's url, bucket, events or
+// enabled flag.
+// PUT /_/api/storage/webhooks/{id}
+func (h *Handler) handleUpdateStorageWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.storageService == nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
+		return
+	}
+
+	var req storage.WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	hook, err := h.storageService.UpdateWebhook(chi.URLParam(r, "id"), req)
+	if err != nil {
+		h.handleStorageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hook)
+}
+
+// handleDeleteStorageWebhook removes a webhook and its pending deliveries.
+// DELETE /_/api/storage/webhooks/{id}
+func (h *Handler) handleDeleteStorageWebhook(w http.ResponseWriter, r *http.Request) {
+	if h.storageService == nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
+		return
+	}
+
+	if err := h.storageService.DeleteWebhook(chi.URLParam(r, "id")); err != nil {
+		h.handleStorageError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListStorageWebhookDeliveries returns a webhook's recent outbox
+// entries with their delivery status and last error.
+// GET /_/api/storage/webhooks/{id}/deliveries?limit=50
+func (h *Handler) handleListStorageWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if h.storageService == nil {
+		writeError(w, http.StatusServiceUnavailable, "service_unavailable", "Storage service not configured")
+		return
+	}
+
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	deliveries, err := h.storageService.ListWebhookDeliveries(chi.URLParam(r, "id"), limit)
+	if err != nil {
+		h.handleStorageError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
@@ -0,0 +1,166 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// handleTableDDL returns the DDL that recreates a table and its indexes, in
+// SQLite (as stored in sqlite_master) or PostgreSQL (as in the schema
+// export, plus foreign keys, unique constraints and indexes).
+// GET /_/api/tables/{name}/ddl?dialect=sqlite|postgres
+func (h *Handler) handleTableDDL(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	dialect := r.URL.Query().Get("dialect")
+	if dialect == "" {
+		dialect = "sqlite"
+	}
+	if dialect != "sqlite" && dialect != "postgres" {
+		writeError(w, http.StatusBadRequest, "invalid_dialect", "dialect must be sqlite or postgres")
+		return
+	}
+
+	var createSQL string
+	if err := h.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&createSQL); err != nil {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+
+	indexes, err := h.listTableIndexes(tableName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	var sb strings.Builder
+	if dialect == "sqlite" {
+		sb.WriteString(createSQL + ";\n")
+		for _, idx := range indexes {
+			sb.WriteString(idx.SQL + ";\n")
+		}
+	} else {
+		// Tables created outside the dashboard need _columns metadata
+		h.ensureTableRegistered(tableName)
+		sb.WriteString(h.generatePostgreSQLDDL(tableName))
+		sb.WriteString(h.postgresConstraintsDDL(tableName, indexes))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":   tableName,
+		"dialect": dialect,
+		"sql":     sb.String(),
+	})
+}
+
+// postgresConstraintsDDL returns the statements adding a table's foreign
+// keys, UNIQUE constraints and indexes in PostgreSQL, which
+// generatePostgreSQLDDL leaves out.
+func (h *Handler) postgresConstraintsDDL(tableName string, indexes []TableIndex) string {
+	var sb strings.Builder
+
+	// pragma_foreign_key_list has a row per column; id groups composite keys
+	type foreignKey struct {
+		table, onUpdate, onDelete string
+		from, to                  []string
+	}
+	var keys []*foreignKey
+	byID := make(map[int]*foreignKey)
+	rows, err := h.db.Query(`SELECT id, "table", "from", COALESCE("to", ''), on_update, on_delete
+		FROM pragma_foreign_key_list(?) ORDER BY id, seq`, tableName)
+	if err == nil {
+		for rows.Next() {
+			var id int
+			var table, from, to, onUpdate, onDelete string
+			if err := rows.Scan(&id, &table, &from, &to, &onUpdate, &onDelete); err != nil {
+				continue
+			}
+			fk, ok := byID[id]
+			if !ok {
+				fk = &foreignKey{table: table, onUpdate: onUpdate, onDelete: onDelete}
+				byID[id] = fk
+				keys = append(keys, fk)
+			}
+			fk.from = append(fk.from, from)
+			if to != "" {
+				fk.to = append(fk.to, to)
+			}
+		}
+		rows.Close()
+	}
+	for _, fk := range keys {
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s", tableName, strings.Join(fk.from, ", "), fk.table)
+		if len(fk.to) > 0 {
+			stmt += fmt.Sprintf(" (%s)", strings.Join(fk.to, ", "))
+		}
+		if fk.onUpdate != "NO ACTION" {
+			stmt += " ON UPDATE " + fk.onUpdate
+		}
+		if fk.onDelete != "NO ACTION" {
+			stmt += " ON DELETE " + fk.onDelete
+		}
+		sb.WriteString(stmt + ";\n")
+	}
+
+	// UNIQUE column constraints are backed by automatic indexes
+	rows, err = h.db.Query(`SELECT name FROM pragma_index_list(?) WHERE origin = 'u' ORDER BY name`, tableName)
+	if err == nil {
+		var names []string
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err == nil {
+				names = append(names, name)
+			}
+		}
+		rows.Close()
+		for _, name := range names {
+			var cols []string
+			colRows, err := h.db.Query(`SELECT name FROM pragma_index_info(?) ORDER BY seqno`, name)
+			if err != nil {
+				continue
+			}
+			for colRows.Next() {
+				var col string
+				if err := colRows.Scan(&col); err == nil {
+					cols = append(cols, col)
+				}
+			}
+			colRows.Close()
+			sb.WriteString(fmt.Sprintf("ALTER TABLE %s ADD UNIQUE (%s);\n", tableName, strings.Join(cols, ", ")))
+		}
+	}
+
+	// Declared enum values become CHECK constraints
+	enums := h.getColumnEnums(tableName)
+	if len(enums) > 0 {
+		order, _ := h.columnOrder(tableName)
+		for _, col := range order {
+			values, ok := enums[col]
+			if !ok {
+				continue
+			}
+			quoted := make([]string, len(values))
+			for i, v := range values {
+				quoted[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+			}
+			sb.WriteString(fmt.Sprintf("ALTER TABLE %s ADD CHECK (%s IN (%s));\n", tableName, col, strings.Join(quoted, ", ")))
+		}
+	}
+
+	for _, idx := range indexes {
+		stmt := "CREATE INDEX"
+		if idx.Unique {
+			stmt = "CREATE UNIQUE INDEX"
+		}
+		stmt += fmt.Sprintf(" %s ON %s (%s)", idx.Name, tableName, strings.Join(idx.Columns, ", "))
+		if idx.Where != "" {
+			stmt += " WHERE " + idx.Where
+		}
+		sb.WriteString(stmt + ";\n")
+	}
+	return sb.String()
+}
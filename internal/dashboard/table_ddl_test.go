@@ -0,0 +1,60 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableDDL(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`
+		CREATE TABLE teams (id INTEGER PRIMARY KEY, slug TEXT UNIQUE);
+		CREATE TABLE members (id INTEGER PRIMARY KEY, team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE, email TEXT, deleted_at TEXT);
+		CREATE UNIQUE INDEX idx_members_email ON members (email) WHERE deleted_at IS NULL;
+	`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}/ddl", handler.handleTableDDL)
+
+	ddl := func(url string) (int, string) {
+		req := httptest.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var resp struct {
+			SQL string `json:"sql"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.SQL
+	}
+
+	code, sqliteDDL := ddl("/tables/members/ddl")
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, sqliteDDL, "CREATE TABLE members (id INTEGER PRIMARY KEY, team_id INTEGER NOT NULL REFERENCES teams(id) ON DELETE CASCADE")
+	assert.Contains(t, sqliteDDL, "CREATE UNIQUE INDEX idx_members_email ON members (email) WHERE deleted_at IS NULL;")
+
+	code, pgDDL := ddl("/tables/members/ddl?dialect=postgres")
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, pgDDL, "CREATE TABLE members (")
+	assert.Contains(t, pgDDL, "team_id integer NOT NULL")
+	assert.Contains(t, pgDDL, "ALTER TABLE members ADD FOREIGN KEY (team_id) REFERENCES teams (id) ON DELETE CASCADE;")
+	assert.Contains(t, pgDDL, "CREATE UNIQUE INDEX idx_members_email ON members (email) WHERE deleted_at IS NULL;")
+
+	code, pgDDL = ddl("/tables/teams/ddl?dialect=postgres")
+	require.Equal(t, http.StatusOK, code)
+	assert.Contains(t, pgDDL, "ALTER TABLE teams ADD UNIQUE (slug);")
+
+	code, _ = ddl("/tables/members/ddl?dialect=mysql")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = ddl("/tables/missing/ddl")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -0,0 +1,257 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TableIndex describes an explicitly created index on a table.
+type TableIndex struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	// Where is the predicate of a partial index, empty for a full index.
+	Where string `json:"where,omitempty"`
+	SQL   string `json:"sql"`
+}
+
+// indexWhereRe captures the predicate of a partial CREATE INDEX statement,
+// which follows the closing parenthesis of the column list.
+var indexWhereRe = regexp.MustCompile(`(?is)\)\s*WHERE\s+(.+?)\s*;?\s*$`)
+
+// listTableIndexes returns the indexes created on a table with CREATE INDEX.
+// Automatic indexes backing PRIMARY KEY and UNIQUE constraints are omitted.
+func (h *Handler) listTableIndexes(tableName string) ([]TableIndex, error) {
+	rows, err := h.db.Query(`
+		SELECT name, sql FROM sqlite_master
+		WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL
+		ORDER BY name
+	`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	var indexes []TableIndex
+	for rows.Next() {
+		var idx TableIndex
+		if err := rows.Scan(&idx.Name, &idx.SQL); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if m := indexWhereRe.FindStringSubmatch(idx.SQL); m != nil {
+			idx.Where = m[1]
+		}
+		indexes = append(indexes, idx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range indexes {
+		var unique int
+		h.db.QueryRow(`SELECT "unique" FROM pragma_index_list(?) WHERE name = ?`, tableName, indexes[i].Name).Scan(&unique)
+		indexes[i].Unique = unique == 1
+
+		cols, err := h.db.Query(`SELECT COALESCE(name, '') FROM pragma_index_info(?) ORDER BY seqno`, indexes[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		indexes[i].Columns = []string{}
+		for cols.Next() {
+			var name string
+			if err := cols.Scan(&name); err == nil {
+				indexes[i].Columns = append(indexes[i].Columns, name)
+			}
+		}
+		cols.Close()
+	}
+	return indexes, nil
+}
+
+// validateIndexPredicate checks that a partial-index predicate is a single
+// expression over the table's own columns by preparing it as the WHERE
+// clause of a query against the table. It returns the error code and
+// message to report, or empty strings when the predicate is valid.
+func (h *Handler) validateIndexPredicate(tableName, where string) (string, string) {
+	if strings.Contains(where, ";") {
+		return "invalid_predicate", "where must be a single expression"
+	}
+	_, err := h.db.Exec(fmt.Sprintf(`EXPLAIN SELECT 1 FROM "%s" WHERE %s`, tableName, where))
+	if err == nil {
+		return "", ""
+	}
+	msg := err.Error()
+	if i := strings.Index(msg, "no such column"); i >= 0 {
+		return "unknown_column", "where references a column that does not exist: " + strings.TrimPrefix(msg[i:], "no such column: ")
+	}
+	return "invalid_predicate", "where is not a valid expression: " + msg
+}
+
+// handleListIndexes lists a table's indexes, including partial-index
+// predicates.
+// GET /_/api/tables/{name}/indexes
+func (h *Handler) handleListIndexes(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists)
+	if exists == 0 {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+
+	indexes, err := h.listTableIndexes(tableName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if indexes == nil {
+		indexes = []TableIndex{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(indexes)
+}
+
+// handleCreateIndex creates an index on one or more columns. An optional
+// where predicate makes it a partial index, which only covers matching rows,
+// e.g. "deleted_at IS NULL" for soft-deleted tables.
+// POST /_/api/tables/{name}/indexes
+func (h *Handler) handleCreateIndex(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var req struct {
+		Name    string   `json:"name"`
+		Columns []string `json:"columns"`
+		Unique  bool     `json:"unique"`
+		Where   string   `json:"where"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if len(req.Columns) == 0 {
+		writeError(w, http.StatusBadRequest, "missing_field", "columns required")
+		return
+	}
+
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists)
+	if exists == 0 {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+
+	quoted := make([]string, len(req.Columns))
+	for i, col := range req.Columns {
+		var found int
+		h.db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, tableName, col).Scan(&found)
+		if found == 0 {
+			writeError(w, http.StatusBadRequest, "unknown_column", "Column "+col+" does not exist")
+			return
+		}
+		quoted[i] = fmt.Sprintf(`"%s"`, col)
+	}
+
+	if req.Name == "" {
+		req.Name = fmt.Sprintf("idx_%s_%s", tableName, strings.Join(req.Columns, "_"))
+	}
+	if !isValidIdentifier(req.Name) {
+		writeError(w, http.StatusBadRequest, "invalid_name", "Index name must contain only letters, digits and underscores")
+		return
+	}
+	var collision int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, req.Name).Scan(&collision)
+	if collision > 0 {
+		writeError(w, http.StatusConflict, "index_exists", "An object named "+req.Name+" already exists")
+		return
+	}
+
+	req.Where = strings.TrimSpace(req.Where)
+	if req.Where != "" {
+		if code, msg := h.validateIndexPredicate(tableName, req.Where); code != "" {
+			writeError(w, http.StatusBadRequest, code, msg)
+			return
+		}
+	}
+
+	createSQL := fmt.Sprintf(`CREATE INDEX "%s" ON "%s" (%s)`, req.Name, tableName, strings.Join(quoted, ", "))
+	if req.Unique {
+		createSQL = "CREATE UNIQUE" + strings.TrimPrefix(createSQL, "CREATE")
+	}
+	if req.Where != "" {
+		createSQL += " WHERE " + req.Where
+	}
+
+	err := h.runWrite(r, func() error {
+		_, err := h.db.Exec(createSQL)
+		return err
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Write migration file
+	migrationName := fmt.Sprintf("create_%s_index", req.Name)
+	downSQL := fmt.Sprintf(`DROP INDEX IF EXISTS "%s";`, req.Name)
+	if err := h.writeReversibleMigration(migrationName, createSQL+";", downSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Index created but failed to write migration: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(TableIndex{
+		Name:    req.Name,
+		Columns: req.Columns,
+		Unique:  req.Unique,
+		Where:   req.Where,
+		SQL:     createSQL,
+	})
+}
+
+// handleDropIndex drops an index created with CREATE INDEX. The migration's
+// down file recreates it from its stored definition.
+// DELETE /_/api/tables/{name}/indexes/{index}
+func (h *Handler) handleDropIndex(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+	indexName := chi.URLParam(r, "index")
+
+	var createSQL string
+	err := h.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name = ? AND sql IS NOT NULL`,
+		tableName, indexName).Scan(&createSQL)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "index_not_found", "Index not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	dropSQL := fmt.Sprintf(`DROP INDEX "%s"`, indexName)
+	err = h.runWrite(r, func() error {
+		_, err := h.db.Exec(dropSQL)
+		return err
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Write migration file
+	migrationName := fmt.Sprintf("drop_%s_index", indexName)
+	if err := h.writeReversibleMigration(migrationName, dropSQL+";", createSQL+";"); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Index dropped but failed to write migration: "+err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,82 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePartialIndex(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	migrationsDir := t.TempDir()
+	handler := NewHandler(database.DB, migrationsDir)
+	_, err := database.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT, deleted_at TEXT)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/{name}/indexes", handler.handleListIndexes)
+	r.Post("/tables/{name}/indexes", handler.handleCreateIndex)
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/tables/posts/indexes", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create(`{"columns": ["title"], "where": "deleted_at IS NULL"}`)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	// The query planner uses the partial index for matching queries
+	var plan string
+	rows, err := database.Query(`EXPLAIN QUERY PLAN SELECT id FROM posts WHERE title = 'a' AND deleted_at IS NULL`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		require.NoError(t, rows.Scan(&id, &parent, &notused, &detail))
+		plan += detail
+	}
+	rows.Close()
+	assert.Contains(t, plan, "idx_posts_title")
+
+	req := httptest.NewRequest("GET", "/tables/posts/indexes", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var indexes []TableIndex
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &indexes))
+	require.Len(t, indexes, 1)
+	assert.Equal(t, "idx_posts_title", indexes[0].Name)
+	assert.Equal(t, []string{"title"}, indexes[0].Columns)
+	assert.Equal(t, "deleted_at IS NULL", indexes[0].Where)
+
+	// The predicate is reproduced in the migration
+	files, err := filepath.Glob(filepath.Join(migrationsDir, "*_create_idx_posts_title_index.sql"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(content), `WHERE deleted_at IS NULL;`), string(content))
+
+	w = create(`{"name": "idx_bad", "columns": ["title"], "where": "archived = 1"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown_column")
+
+	w = create(`{"name": "idx_bad", "columns": ["title"], "where": "deleted_at IS"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_predicate")
+
+	w = create(`{"name": "idx_bad", "columns": ["title"], "where": "1; DROP TABLE posts"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
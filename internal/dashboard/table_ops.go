@@ -0,0 +1,594 @@
+package dashboard
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/fts"
+)
+
+// handleTruncateTable removes all rows from a table while keeping its schema.
+// SQLite has no TRUNCATE, so this runs DELETE FROM and resets the table's
+// AUTOINCREMENT counter. With cascade, the table's FTS indexes are rebuilt
+// afterwards so they are guaranteed to be empty and consistent.
+// POST /_/api/tables/{name}/truncate
+func (h *Handler) handleTruncateTable(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var req struct {
+		Confirm bool `json:"confirm"`
+		Cascade bool `json:"cascade"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if !req.Confirm {
+		writeError(w, http.StatusBadRequest, "confirmation_required", "Truncate requires \"confirm\": true")
+		return
+	}
+	if isReservedTableName(tableName) {
+		writeError(w, http.StatusBadRequest, "reserved_table_name", reservedTableNameMessage(tableName))
+		return
+	}
+
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&exists)
+	if exists == 0 {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+
+	var indexes []*fts.Index
+	if req.Cascade {
+		indexes, _ = h.fts.ListIndexes(tableName)
+	}
+
+	var removed int64
+	err := h.runWrite(r, func() error {
+		tx, err := h.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		result, err := tx.Exec(fmt.Sprintf(`DELETE FROM "%s"`, tableName))
+		if err != nil {
+			return err
+		}
+		removed, _ = result.RowsAffected()
+
+		for _, idx := range indexes {
+			ftsTable := fts.GetFTSTableName(tableName, idx.IndexName)
+			if _, err := tx.Exec(fmt.Sprintf(`INSERT INTO %q(%q) VALUES('rebuild')`, ftsTable, ftsTable)); err != nil {
+				return fmt.Errorf("failed to rebuild FTS index %s: %w", idx.IndexName, err)
+			}
+		}
+
+		// sqlite_sequence only exists once an AUTOINCREMENT table has been created
+		var hasSequence int
+		tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence'`).Scan(&hasSequence)
+		if hasSequence > 0 {
+			if _, err := tx.Exec(`DELETE FROM sqlite_sequence WHERE name = ?`, tableName); err != nil {
+				return fmt.Errorf("failed to reset sequence: %w", err)
+			}
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Write migration file. Migrations carry schema changes, not data, so
+	// the truncate is only recorded as a comment.
+	truncateSQL := fmt.Sprintf("-- Truncated table %s (schema preserved)", tableName)
+	migrationName := fmt.Sprintf("truncate_%s_table", tableName)
+	if err := h.writeMigration(migrationName, truncateSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Table truncated but failed to write migration: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"table":        tableName,
+		"rows_removed": removed,
+	})
+}
+
+// createTableNameRe matches the table name in a CREATE TABLE statement,
+// quoted or unquoted, with an optional IF NOT EXISTS.
+var createTableNameRe = regexp.MustCompile(`(?is)^\s*CREATE\s+TABLE\s+(IF\s+NOT\s+EXISTS\s+)?("(?:[^"]|"")+"|\[[^\]]+\]|` + "`[^`]+`" + `|[^\s(]+)`)
+
+// renameCreateTableSQL rewrites a CREATE TABLE statement to create newName instead.
+func renameCreateTableSQL(createSQL, newName string) (string, error) {
+	loc := createTableNameRe.FindStringSubmatchIndex(createSQL)
+	if loc == nil {
+		return "", fmt.Errorf("unrecognized CREATE TABLE statement")
+	}
+	nameStart, nameEnd := loc[4], loc[5]
+	return createSQL[:nameStart] + fmt.Sprintf(`"%s"`, newName) + createSQL[nameEnd:], nil
+}
+
+// handleCloneTable creates a copy of a table's structure and metadata,
+// optionally including its rows.
+// POST /_/api/tables/{name}/clone
+func (h *Handler) handleCloneTable(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var req struct {
+		NewName  string `json:"new_name"`
+		WithData bool   `json:"with_data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.NewName) == "" {
+		writeError(w, http.StatusBadRequest, "missing_field", "new_name required")
+		return
+	}
+	if !isValidIdentifier(req.NewName) {
+		writeError(w, http.StatusBadRequest, "invalid_identifier", "Invalid table name")
+		return
+	}
+	if isReservedTableName(req.NewName) {
+		writeError(w, http.StatusBadRequest, "reserved_table_name", reservedTableNameMessage(req.NewName))
+		return
+	}
+
+	var createSQL string
+	err := h.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = ?`, tableName).Scan(&createSQL)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to read table definition")
+		return
+	}
+
+	var collision int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = ?`, req.NewName).Scan(&collision)
+	if collision > 0 {
+		writeError(w, http.StatusConflict, "table_exists", "A table named "+req.NewName+" already exists")
+		return
+	}
+
+	cloneSQL, err := renameCreateTableSQL(createSQL, req.NewName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	copySQL := fmt.Sprintf(`INSERT INTO "%s" SELECT * FROM "%s"`, req.NewName, tableName)
+
+	// Make sure the source has metadata to copy
+	h.ensureTableRegistered(tableName)
+
+	var copied int64
+	var sequenceNames, createSequences, setSequenceDefaults []string
+	err = h.runWrite(r, func() error {
+		tx, err := h.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(cloneSQL); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`INSERT INTO _columns (table_name, column_name, pg_type, is_nullable, default_value, is_primary, description, json_schema, is_hidden, ordinal, enum_values)
+			SELECT ?, column_name, pg_type, is_nullable, default_value, is_primary, description, json_schema, is_hidden, ordinal, enum_values
+			FROM _columns WHERE table_name = ?`, req.NewName, tableName); err != nil {
+			return fmt.Errorf("failed to copy column metadata: %w", err)
+		}
+
+		if _, err := tx.Exec(`INSERT INTO _table_descriptions (table_name, description)
+			SELECT ?, description FROM _table_descriptions WHERE table_name = ?`, req.NewName, tableName); err != nil {
+			return fmt.Errorf("failed to copy table description: %w", err)
+		}
+
+		if req.WithData {
+			result, err := tx.Exec(copySQL)
+			if err != nil {
+				return fmt.Errorf("failed to copy data: %w", err)
+			}
+			copied, _ = result.RowsAffected()
+		}
+
+		// The clone numbers its rows from its own sequences
+		sequenceNames, createSequences, setSequenceDefaults = nil, nil, nil
+		seqRows, err := tx.Query(`SELECT column_name FROM _sequences WHERE table_name = ?`, tableName)
+		if err != nil {
+			return err
+		}
+		var seqCols []string
+		for seqRows.Next() {
+			var col string
+			if err := seqRows.Scan(&col); err != nil {
+				seqRows.Close()
+				return err
+			}
+			seqCols = append(seqCols, col)
+		}
+		seqRows.Close()
+		for _, col := range seqCols {
+			current, err := createSequence(tx, req.NewName, col)
+			if err != nil {
+				return err
+			}
+			name := sequenceName(req.NewName, col)
+			sequenceNames = append(sequenceNames, name)
+			createSequences = append(createSequences, createSequenceSQL(name, current))
+			setSequenceDefaults = append(setSequenceDefaults, setSequenceDefaultSQL(req.NewName, col, name))
+		}
+
+		return tx.Commit()
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Write migration file
+	upStatements := append(createSequences, cloneSQL+";")
+	if req.WithData {
+		upStatements = append(upStatements, copySQL+";")
+	}
+	upStatements = append(upStatements, setSequenceDefaults...)
+	migrationSQL := strings.Join(upStatements, "\n")
+	migrationName := fmt.Sprintf("clone_%s_to_%s", tableName, req.NewName)
+	downSQL := fmt.Sprintf(`DROP TABLE IF EXISTS "%s";`, req.NewName)
+	for _, name := range sequenceNames {
+		downSQL += "\n" + dropSequenceSQL(name)
+	}
+	if err := h.writeReversibleMigration(migrationName, migrationSQL, downSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Table cloned but failed to write migration: "+err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":        req.NewName,
+		"source":      tableName,
+		"rows_copied": copied,
+	})
+}
+
+// primaryKeyColumns returns a table's primary key columns in key order.
+func (h *Handler) primaryKeyColumns(table string) []string {
+	rows, err := h.db.Query(`SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, table)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			cols = append(cols, name)
+		}
+	}
+	return cols
+}
+
+// errPrimaryKeyConflict is returned when existing rows violate a new primary key.
+var errPrimaryKeyConflict = errors.New("existing rows have duplicate values for the new primary key")
+
+// errForeignKeyViolation is returned when a rebuilt table breaks a foreign key.
+var errForeignKeyViolation = errors.New("the new primary key breaks a foreign key")
+
+// columnPrimaryKeyRe matches a column-level PRIMARY KEY constraint.
+var columnPrimaryKeyRe = regexp.MustCompile(`(?i)\s+(CONSTRAINT\s+("(?:[^"]|"")+"|\w+)\s+)?PRIMARY\s+KEY(\s+(ASC|DESC))?(\s+ON\s+CONFLICT\s+\w+)?(\s+AUTOINCREMENT)?\b`)
+
+// tablePrimaryKeyRe matches a table-level PRIMARY KEY constraint.
+var tablePrimaryKeyRe = regexp.MustCompile(`(?i)^(CONSTRAINT\s+("(?:[^"]|"")+"|\w+)\s+)?PRIMARY\s+KEY\b`)
+
+// splitTableDefinition splits a CREATE TABLE statement into the part up to
+// its opening parenthesis, its column definitions and table constraints, and
+// the table options after the closing parenthesis (WITHOUT ROWID, STRICT).
+func splitTableDefinition(createSQL string) (string, []string, string, error) {
+	open := -1
+	depth := 0
+	var quote byte
+	var items []string
+	itemStart := 0
+	for i := 0; i < len(createSQL); i++ {
+		c := createSQL[i]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '[':
+			quote = ']'
+		case '(':
+			depth++
+			if open < 0 {
+				open = i
+				itemStart = i + 1
+			}
+		case ')':
+			depth--
+			if depth == 0 && open >= 0 {
+				items = append(items, strings.TrimSpace(createSQL[itemStart:i]))
+				return createSQL[:open], items, createSQL[i+1:], nil
+			}
+		case ',':
+			if depth == 1 {
+				items = append(items, strings.TrimSpace(createSQL[itemStart:i]))
+				itemStart = i + 1
+			}
+		}
+	}
+	return "", nil, "", fmt.Errorf("unrecognized CREATE TABLE statement")
+}
+
+// primaryKeyTableSQL rewrites a CREATE TABLE statement to create newName
+// with keyCols as its primary key. Everything else in the definition,
+// including CHECK, UNIQUE and foreign key constraints, is kept.
+func primaryKeyTableSQL(createSQL, newName string, keyCols []string) (string, error) {
+	renamed, err := renameCreateTableSQL(createSQL, newName)
+	if err != nil {
+		return "", err
+	}
+	prefix, items, suffix, err := splitTableDefinition(renamed)
+	if err != nil {
+		return "", err
+	}
+	var defs []string
+	for _, item := range items {
+		if tablePrimaryKeyRe.MatchString(item) {
+			continue
+		}
+		if !isTableConstraint(item) {
+			item = columnPrimaryKeyRe.ReplaceAllString(item, "")
+		}
+		defs = append(defs, item)
+	}
+	if len(keyCols) > 0 {
+		defs = append(defs, "PRIMARY KEY ("+strings.Join(keyCols, ", ")+")")
+	}
+	return prefix + "(" + strings.Join(defs, ", ") + ")" + suffix, nil
+}
+
+// isTableConstraint reports whether an item of a CREATE TABLE body is a
+// table constraint rather than a column definition.
+func isTableConstraint(item string) bool {
+	upper := strings.ToUpper(item)
+	for _, kw := range []string{"CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN"} {
+		if strings.HasPrefix(upper, kw) && (len(upper) == len(kw) || !isIdentChar(upper[len(kw)])) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// handleSetPrimaryKey changes a table's primary key to the given columns, in
+// order. SQLite cannot alter a key in place, so the table is rebuilt from its
+// original definition following SQLite's documented procedure for other
+// kinds of table schema changes: with foreign keys off, the rows, indexes
+// and triggers are copied to the new table, and foreign_key_check must pass
+// before the change commits. An empty list removes the key.
+// PUT /_/api/tables/{name}/primary-key
+func (h *Handler) handleSetPrimaryKey(w http.ResponseWriter, r *http.Request) {
+	tableName := chi.URLParam(r, "name")
+
+	var req struct {
+		Columns []string `json:"columns"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	var objType, createSQL string
+	if err := h.db.QueryRow(`SELECT type, sql FROM sqlite_master WHERE name = ?`, tableName).Scan(&objType, &createSQL); err != nil || objType != "table" {
+		writeError(w, http.StatusNotFound, "table_not_found", "Table not found")
+		return
+	}
+	h.ensureTableRegistered(tableName)
+
+	rows, err := h.db.Query(`SELECT c.column_name, c.pg_type, c.is_nullable, c.default_value
+		FROM _columns c JOIN pragma_table_info(?) p ON p.name = c.column_name
+		WHERE c.table_name = ? ORDER BY p.cid`, tableName, tableName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to get columns")
+		return
+	}
+	var colDefs, colNames []string
+	known := make(map[string]bool)
+	for rows.Next() {
+		var name, pgType string
+		var nullable bool
+		var defaultVal sql.NullString
+		if err := rows.Scan(&name, &pgType, &nullable, &defaultVal); err != nil {
+			continue
+		}
+		def := fmt.Sprintf(`"%s" %s`, name, pgTypeToSQLite(pgType))
+		if !nullable {
+			def += " NOT NULL"
+		}
+		if defaultVal.Valid && defaultVal.String != "" && !isSequenceDefault(defaultVal.String) {
+			def += " DEFAULT " + mapDefaultValueForSQLite(defaultVal.String, pgType)
+		}
+		colDefs = append(colDefs, def)
+		colNames = append(colNames, fmt.Sprintf(`"%s"`, name))
+		known[name] = true
+	}
+	rows.Close()
+
+	var keyCols []string
+	inKey := make(map[string]bool, len(req.Columns))
+	for _, col := range req.Columns {
+		if !known[col] {
+			writeError(w, http.StatusBadRequest, "column_not_found", fmt.Sprintf("Column %q does not exist", col))
+			return
+		}
+		if inKey[col] {
+			writeError(w, http.StatusBadRequest, "invalid_request", fmt.Sprintf("Column %q is listed more than once", col))
+			return
+		}
+		inKey[col] = true
+		keyCols = append(keyCols, fmt.Sprintf(`"%s"`, col))
+	}
+
+	// The new table keeps the original definition so CHECK, UNIQUE and
+	// foreign key constraints survive. Only if that can't be parsed is it
+	// built from the column metadata instead.
+	newTableSQL, err := primaryKeyTableSQL(createSQL, tableName+"_new", keyCols)
+	if err != nil {
+		if len(keyCols) > 0 {
+			colDefs = append(colDefs, "PRIMARY KEY ("+strings.Join(keyCols, ", ")+")")
+		}
+		newTableSQL = fmt.Sprintf(`CREATE TABLE "%s_new" (%s)`, tableName, strings.Join(colDefs, ", "))
+	}
+
+	// Indexes and triggers are dropped with the old table and recreated after
+	var dependents []string
+	depRows, err := h.db.Query(`SELECT sql FROM sqlite_master
+		WHERE tbl_name = ? AND type IN ('index', 'trigger') AND sql IS NOT NULL`, tableName)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to read indexes")
+		return
+	}
+	for depRows.Next() {
+		var stmt string
+		if depRows.Scan(&stmt) == nil {
+			dependents = append(dependents, stmt)
+		}
+	}
+	depRows.Close()
+
+	err = h.runWrite(r, func() error {
+		// foreign_keys can't change inside a transaction, and applies per
+		// connection, so the rebuild runs on a connection of its own
+		ctx := r.Context()
+		conn, err := h.db.Conn(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get connection: %w", err)
+		}
+		defer conn.Close()
+		var foreignKeys bool
+		if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&foreignKeys); err != nil {
+			return err
+		}
+		if foreignKeys {
+			if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+				return err
+			}
+			defer conn.ExecContext(context.Background(), `PRAGMA foreign_keys = ON`)
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(newTableSQL); err != nil {
+			return err
+		}
+		cols := strings.Join(colNames, ", ")
+		if _, err := tx.Exec(fmt.Sprintf(`INSERT INTO "%s_new" (%s) SELECT %s FROM "%s"`, tableName, cols, cols, tableName)); err != nil {
+			if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return errPrimaryKeyConflict
+			}
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`DROP TABLE "%s"`, tableName)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE "%s_new" RENAME TO "%s"`, tableName, tableName)); err != nil {
+			return err
+		}
+		for _, stmt := range dependents {
+			if _, err := tx.Exec(stmt); err != nil {
+				return fmt.Errorf("failed to recreate %q: %w", stmt, err)
+			}
+		}
+		for name := range known {
+			if _, err := tx.Exec(`UPDATE _columns SET is_primary = ? WHERE table_name = ? AND column_name = ?`,
+				inKey[name], tableName, name); err != nil {
+				return fmt.Errorf("failed to update metadata: %w", err)
+			}
+		}
+
+		// Only violations involving this table count; others predate the change
+		fkRows, err := tx.Query(`PRAGMA foreign_key_check`)
+		if err != nil {
+			// A reference to columns that are no longer unique
+			if strings.Contains(err.Error(), "foreign key mismatch") {
+				return fmt.Errorf("%w: %v", errForeignKeyViolation, err)
+			}
+			return err
+		}
+		violated := false
+		for fkRows.Next() {
+			var child, parent string
+			var rowid sql.NullInt64
+			var fkid int
+			if err := fkRows.Scan(&child, &rowid, &parent, &fkid); err != nil {
+				fkRows.Close()
+				return err
+			}
+			if child == tableName || parent == tableName {
+				violated = true
+			}
+		}
+		fkRows.Close()
+		if err := fkRows.Err(); err != nil {
+			return err
+		}
+		if violated {
+			return errForeignKeyViolation
+		}
+		return tx.Commit()
+	})
+	if errors.Is(err, errPrimaryKeyConflict) {
+		writeError(w, http.StatusConflict, "primary_key_conflict", err.Error())
+		return
+	}
+	if errors.Is(err, errForeignKeyViolation) {
+		writeError(w, http.StatusConflict, "foreign_key_violation", err.Error())
+		return
+	}
+	if err != nil {
+		writeWriteError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	// Write migration file (PostgreSQL syntax for Supabase migration)
+	migrationSQL := fmt.Sprintf(`ALTER TABLE "%s" DROP CONSTRAINT IF EXISTS "%s_pkey";`, tableName, tableName)
+	if len(keyCols) > 0 {
+		migrationSQL += fmt.Sprintf("\nALTER TABLE \"%s\" ADD PRIMARY KEY (%s);", tableName, strings.Join(keyCols, ", "))
+	}
+	if err := h.writeMigration(fmt.Sprintf("set_primary_key_%s", tableName), migrationSQL); err != nil {
+		writeError(w, http.StatusInternalServerError, "migration_write_failed", "Primary key changed but failed to write migration: "+err.Error())
+		return
+	}
+
+	primaryKey := req.Columns
+	if primaryKey == nil {
+		primaryKey = []string{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":        tableName,
+		"primary_key": primaryKey,
+	})
+}
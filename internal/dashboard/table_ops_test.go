@@ -0,0 +1,193 @@
+package dashboard
+
+import (
+	"bytes"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenameCreateTableSQL(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`CREATE TABLE items (id INTEGER)`, `CREATE TABLE "copy" (id INTEGER)`},
+		{`CREATE TABLE "items" (id INTEGER)`, `CREATE TABLE "copy" (id INTEGER)`},
+		{`CREATE TABLE IF NOT EXISTS items(id INTEGER)`, `CREATE TABLE IF NOT EXISTS "copy"(id INTEGER)`},
+	}
+	for _, tt := range tests {
+		got, err := renameCreateTableSQL(tt.in, "copy")
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestCloneTable(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir())
+	_, err := database.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO items (id, name) VALUES (1, 'a'), (2, 'b')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/tables/{name}/clone", handler.handleCloneTable)
+
+	req := httptest.NewRequest("POST", "/tables/items/clone", bytes.NewBufferString(`{"new_name": "items_copy", "with_data": true}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	var count int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM items_copy`).Scan(&count))
+	assert.Equal(t, 2, count)
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM _columns WHERE table_name = 'items_copy'`).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// Cloning onto an existing name is a conflict
+	req = httptest.NewRequest("POST", "/tables/items/clone", bytes.NewBufferString(`{"new_name": "items_copy"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	// Internal table names are reserved
+	req = httptest.NewRequest("POST", "/tables/items/clone", bytes.NewBufferString(`{"new_name": "auth_items"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "reserved_table_name")
+
+	// Names that would need quoting are rejected before any SQL runs
+	req = httptest.NewRequest("POST", "/tables/items/clone", bytes.NewBufferString(`{"new_name": "x\"; DROP TABLE items; --"}`))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid_identifier")
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM items`).Scan(&count))
+	assert.Equal(t, 2, count)
+}
+
+func TestSetPrimaryKey(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir())
+	_, err := database.Exec(`CREATE TABLE memberships (org TEXT NOT NULL, member TEXT NOT NULL, role TEXT)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`CREATE INDEX idx_memberships_role ON memberships (role)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO memberships VALUES ('acme', 'ann', 'admin'), ('acme', 'bob', 'member'), ('beta', 'ann', 'member')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Put("/tables/{name}/primary-key", handler.handleSetPrimaryKey)
+	r.Get("/tables/{name}", handler.handleGetTableSchema)
+	r.Delete("/tables/{name}/columns/{column}", handler.handleDropColumn)
+
+	setKey := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/tables/memberships/primary-key", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// Existing rows conflict with a key on org alone
+	assert.Equal(t, http.StatusConflict, setKey(`{"columns": ["org"]}`).Code)
+	assert.Equal(t, http.StatusBadRequest, setKey(`{"columns": ["missing"]}`).Code)
+
+	w := setKey(`{"columns": ["member", "org"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"member", "org"}, handler.primaryKeyColumns("memberships"))
+
+	var count int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM memberships`).Scan(&count))
+	assert.Equal(t, 3, count)
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'idx_memberships_role'`).Scan(&count))
+	assert.Equal(t, 1, count)
+
+	req := httptest.NewRequest("GET", "/tables/memberships", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"primary_key":["member","org"]`)
+
+	// Rebuilding for a dropped column keeps the key order
+	// Migration versions are per second; clear the one just recorded
+	_, err = database.Exec(`DELETE FROM _schema_migrations`)
+	require.NoError(t, err)
+	req = httptest.NewRequest("DELETE", "/tables/memberships/columns/role", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	assert.Equal(t, []string{"member", "org"}, handler.primaryKeyColumns("memberships"))
+
+	_, err = database.Exec(`DELETE FROM _schema_migrations`)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, setKey(`{"columns": []}`).Code)
+	assert.Empty(t, handler.primaryKeyColumns("memberships"))
+}
+
+func TestSetPrimaryKeyKeepsConstraints(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, t.TempDir())
+	_, err := database.Exec(`CREATE TABLE teams (id INTEGER PRIMARY KEY, slug TEXT NOT NULL UNIQUE)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`CREATE TABLE players (
+		id INTEGER PRIMARY KEY,
+		email TEXT NOT NULL UNIQUE,
+		age INTEGER CHECK (age >= 0),
+		team_id INTEGER REFERENCES teams (id),
+		joined_at TEXT DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00', 'now'))
+	)`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO teams (id, slug) VALUES (1, 'red'), (2, 'blue')`)
+	require.NoError(t, err)
+	_, err = database.Exec(`INSERT INTO players (id, email, age, team_id) VALUES (1, 'a@example.com', 20, 1), (2, 'b@example.com', 30, 2)`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Put("/tables/{name}/primary-key", handler.handleSetPrimaryKey)
+	setKey := func(table, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/tables/"+table+"/primary-key", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := setKey("players", `{"columns": ["id", "age"]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, []string{"id", "age"}, handler.primaryKeyColumns("players"))
+
+	// CHECK, UNIQUE, foreign key and default survive the rebuild
+	_, err = database.Exec(`INSERT INTO players (id, email, age, team_id) VALUES (3, 'c@example.com', -1, 1)`)
+	assert.Error(t, err, "CHECK constraint should still apply")
+	_, err = database.Exec(`INSERT INTO players (id, email, age, team_id) VALUES (3, 'a@example.com', 1, 1)`)
+	assert.Error(t, err, "UNIQUE constraint on email should still apply")
+	var fks int
+	require.NoError(t, database.QueryRow(`SELECT COUNT(*) FROM pragma_foreign_key_list('players') WHERE "table" = 'teams'`).Scan(&fks))
+	assert.Equal(t, 1, fks)
+	_, err = database.Exec(`INSERT INTO players (id, email, age, team_id) VALUES (4, 'e@example.com', 1, 2)`)
+	require.NoError(t, err)
+	var joinedAt sql.NullString
+	require.NoError(t, database.QueryRow(`SELECT joined_at FROM players WHERE id = 4`).Scan(&joinedAt))
+	assert.True(t, joinedAt.Valid)
+
+	// players references teams.id, which a key on slug would leave without
+	// a unique constraint; foreign_key_check catches that before commit
+	_, err = database.Exec(`DELETE FROM _schema_migrations`)
+	require.NoError(t, err)
+	w = setKey("teams", `{"columns": ["slug"]}`)
+	assert.Equal(t, http.StatusConflict, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "foreign_key_violation")
+	assert.Equal(t, []string{"id"}, handler.primaryKeyColumns("teams"))
+}
@@ -0,0 +1,92 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// PrimaryKeyAuditEntry reports whether a table has a primary key. Tables
+// without one fall back to rowid for get-by-id, pagination and ordering,
+// which changes when rows are vacuumed or re-inserted.
+type PrimaryKeyAuditEntry struct {
+	Table      string   `json:"table"`
+	HasKey     bool     `json:"has_primary_key"`
+	PrimaryKey []string `json:"primary_key"`
+	// Candidates are NOT NULL columns with unique values that could become
+	// the key as they are.
+	Candidates []string `json:"candidates,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// primaryKeyCandidates returns the table's NOT NULL columns whose values
+// are all distinct, "id" first.
+func (h *Handler) primaryKeyCandidates(tableName string) []string {
+	rows, err := h.db.Query(`SELECT name FROM pragma_table_info(?) WHERE "notnull" = 1 OR lower(name) = 'id' ORDER BY cid`, tableName)
+	if err != nil {
+		return nil
+	}
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err == nil {
+			cols = append(cols, name)
+		}
+	}
+	rows.Close()
+
+	var candidates []string
+	for _, col := range cols {
+		var nulls, duplicates int
+		h.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) - COUNT("%s"), COUNT("%s") - COUNT(DISTINCT "%s") FROM "%s"`,
+			col, col, col, tableName)).Scan(&nulls, &duplicates)
+		if nulls > 0 || duplicates > 0 {
+			continue
+		}
+		if strings.EqualFold(col, "id") {
+			candidates = append([]string{col}, candidates...)
+		} else {
+			candidates = append(candidates, col)
+		}
+	}
+	return candidates
+}
+
+// handlePrimaryKeyAudit lists user tables, flagging those without a primary
+// key along with a suggested fix.
+// GET /_/api/tables/pk-audit
+func (h *Handler) handlePrimaryKeyAudit(w http.ResponseWriter, r *http.Request) {
+	tables, err := h.userTables()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list tables")
+		return
+	}
+
+	entries := make([]PrimaryKeyAuditEntry, 0, len(tables))
+	missing := 0
+	for _, table := range tables {
+		entry := PrimaryKeyAuditEntry{Table: table, PrimaryKey: h.primaryKeyColumns(table)}
+		if entry.PrimaryKey == nil {
+			entry.PrimaryKey = []string{}
+		}
+		entry.HasKey = len(entry.PrimaryKey) > 0
+		if !entry.HasKey {
+			missing++
+			entry.Candidates = h.primaryKeyCandidates(table)
+			if len(entry.Candidates) > 0 {
+				entry.Suggestion = fmt.Sprintf(`Make %q the primary key with PUT /_/api/tables/%s/primary-key {"columns": [%q]}`,
+					entry.Candidates[0], table, entry.Candidates[0])
+			} else {
+				entry.Suggestion = fmt.Sprintf("No column is unique and NOT NULL; add an id column to %s and make it the primary key", table)
+			}
+		}
+		entries = append(entries, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tables":  entries,
+		"missing": missing,
+	})
+}
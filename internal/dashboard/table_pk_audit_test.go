@@ -0,0 +1,62 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrimaryKeyAudit(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`
+		CREATE TABLE keyed (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE legacy (code TEXT NOT NULL, id INTEGER, note TEXT);
+		INSERT INTO legacy VALUES ('a', 1, 'x'), ('b', 2, 'x');
+		CREATE TABLE messy (name TEXT, tag TEXT NOT NULL);
+		INSERT INTO messy VALUES ('a', 't'), ('a', 't');
+	`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/tables/pk-audit", handler.handlePrimaryKeyAudit)
+
+	req := httptest.NewRequest("GET", "/tables/pk-audit", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Tables  []PrimaryKeyAuditEntry `json:"tables"`
+		Missing int                    `json:"missing"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, 2, resp.Missing)
+
+	byName := make(map[string]PrimaryKeyAuditEntry)
+	for _, entry := range resp.Tables {
+		byName[entry.Table] = entry
+	}
+	require.Len(t, byName, 3)
+
+	assert.True(t, byName["keyed"].HasKey)
+	assert.Equal(t, []string{"id"}, byName["keyed"].PrimaryKey)
+	assert.Empty(t, byName["keyed"].Suggestion)
+
+	legacy := byName["legacy"]
+	assert.False(t, legacy.HasKey)
+	assert.Equal(t, []string{"id", "code"}, legacy.Candidates)
+	assert.Contains(t, legacy.Suggestion, "/_/api/tables/legacy/primary-key")
+
+	messy := byName["messy"]
+	assert.False(t, messy.HasKey)
+	assert.Empty(t, messy.Candidates)
+	assert.Contains(t, messy.Suggestion, "add an id column")
+}
@@ -0,0 +1,248 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/markb/sblite/internal/types"
+)
+
+// TableValidationIssue is a single problem found in a CreateTableRequest.
+type TableValidationIssue struct {
+	Column  string `json:"column,omitempty"` // empty for table-level issues
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// TableValidationResult lists the errors that would make table creation fail
+// or produce a broken table, and warnings about likely mistakes.
+type TableValidationResult struct {
+	Valid    bool                   `json:"valid"`
+	Errors   []TableValidationIssue `json:"errors"`
+	Warnings []TableValidationIssue `json:"warnings"`
+}
+
+// sqliteKeywords are SQLite's reserved words (https://sqlite.org/lang_keywords.html).
+// They work as quoted identifiers but must be quoted in every hand-written query.
+var sqliteKeywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`ABORT ACTION ADD AFTER ALL ALTER ALWAYS ANALYZE AND AS ASC
+		ATTACH AUTOINCREMENT BEFORE BEGIN BETWEEN BY CASCADE CASE CAST CHECK COLLATE COLUMN
+		COMMIT CONFLICT CONSTRAINT CREATE CROSS CURRENT CURRENT_DATE CURRENT_TIME
+		CURRENT_TIMESTAMP DATABASE DEFAULT DEFERRABLE DEFERRED DELETE DESC DETACH DISTINCT DO
+		DROP EACH ELSE END ESCAPE EXCEPT EXCLUDE EXCLUSIVE EXISTS EXPLAIN FAIL FILTER FIRST
+		FOLLOWING FOR FOREIGN FROM FULL GENERATED GLOB GROUP GROUPS HAVING IF IGNORE IMMEDIATE
+		IN INDEX INDEXED INITIALLY INNER INSERT INSTEAD INTERSECT INTO IS ISNULL JOIN KEY LAST
+		LEFT LIKE LIMIT MATCH MATERIALIZED NATURAL NO NOT NOTHING NOTNULL NULL NULLS OF OFFSET
+		ON OR ORDER OTHERS OUTER OVER PARTITION PLAN PRAGMA PRECEDING PRIMARY QUERY RAISE RANGE
+		RECURSIVE REFERENCES REGEXP REINDEX RELEASE RENAME REPLACE RESTRICT RETURNING RIGHT
+		ROLLBACK ROW ROWS SAVEPOINT SELECT SET TABLE TEMP TEMPORARY THEN TIES TO TRANSACTION
+		TRIGGER UNBOUNDED UNION UNIQUE UPDATE USING VACUUM VALUES VIEW VIRTUAL WHEN WHERE WINDOW
+		WITH WITHOUT`) {
+		sqliteKeywords[kw] = true
+	}
+}
+
+// reservedTablePrefixes are the name prefixes of sblite's internal tables
+// (auth_users, storage_objects, _columns, ...) and SQLite's own. The table
+// list and schema export hide tables with these prefixes.
+var reservedTablePrefixes = []string{"auth_", "storage_", "_", "sqlite_"}
+
+// isReservedTableName reports whether name could collide with an internal
+// table. SQLite table names are case-insensitive, so the check is too.
+func isReservedTableName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, prefix := range reservedTablePrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// reservedTableNameMessage explains why a reserved name was rejected.
+func reservedTableNameMessage(name string) string {
+	return fmt.Sprintf("Table name %q is reserved: names starting with %s are used by internal tables",
+		name, strings.Join(reservedTablePrefixes, ", "))
+}
+
+// handleValidateTable checks a CreateTableRequest without creating anything.
+// POST /_/api/tables/validate
+func (h *Handler) handleValidateTable(w http.ResponseWriter, r *http.Request) {
+	var req CreateTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.validateCreateTable(req))
+}
+
+// validateCreateTable reports problems in a table definition.
+func (h *Handler) validateCreateTable(req CreateTableRequest) TableValidationResult {
+	result := TableValidationResult{
+		Errors:   []TableValidationIssue{},
+		Warnings: []TableValidationIssue{},
+	}
+	addError := func(column, code, format string, args ...interface{}) {
+		result.Errors = append(result.Errors, TableValidationIssue{Column: column, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+	addWarning := func(column, code, format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, TableValidationIssue{Column: column, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case req.Name == "":
+		addError("", "name_required", "Table name is required")
+	case !isValidIdentifier(req.Name):
+		addError("", "invalid_identifier", "Table name %q may only contain letters, digits and underscores, and cannot start with a digit", req.Name)
+	case isReservedTableName(req.Name):
+		addError("", "reserved_table_name", "%s", reservedTableNameMessage(req.Name))
+	default:
+		if sqliteKeywords[strings.ToUpper(req.Name)] {
+			addWarning("", "reserved_word", "Table name %q is a reserved SQL keyword and must be quoted in queries", req.Name)
+		}
+		var exists int
+		h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type IN ('table', 'view') AND name = ? COLLATE NOCASE`, req.Name).Scan(&exists)
+		if exists > 0 {
+			addError("", "table_exists", "A table or view named %q already exists", req.Name)
+		}
+	}
+
+	if len(req.Columns) == 0 {
+		addError("", "columns_required", "At least one column is required")
+	}
+
+	seen := make(map[string]bool, len(req.Columns))
+	hasPrimary := false
+	inKey := make(map[string]bool, len(req.PrimaryKey))
+	for _, name := range req.PrimaryKey {
+		inKey[strings.ToLower(name)] = true
+	}
+	for i, col := range req.Columns {
+		label := col.Name
+		if label == "" {
+			label = fmt.Sprintf("#%d", i+1)
+		}
+
+		switch {
+		case col.Name == "":
+			addError(label, "name_required", "Column %d has no name", i+1)
+		case !isValidIdentifier(col.Name):
+			addError(label, "invalid_identifier", "Column name %q may only contain letters, digits and underscores, and cannot start with a digit", col.Name)
+		default:
+			// SQLite column names are case-insensitive
+			key := strings.ToLower(col.Name)
+			if seen[key] {
+				addError(label, "duplicate_column", "Column %q is defined more than once", col.Name)
+			}
+			seen[key] = true
+			if sqliteKeywords[strings.ToUpper(col.Name)] {
+				addWarning(label, "reserved_word", "Column name %q is a reserved SQL keyword and must be quoted in queries", col.Name)
+			}
+		}
+
+		validType := types.IsValidType(col.Type)
+		if !validType {
+			addError(label, "invalid_type", "Unsupported type %q", col.Type)
+		}
+
+		if col.Primary || inKey[strings.ToLower(col.Name)] {
+			hasPrimary = true
+			if col.Nullable {
+				addWarning(label, "nullable_primary_key", "Primary key column %q allows NULL; SQLite permits NULL keys, so mark it not nullable", col.Name)
+			}
+		}
+
+		if col.Default != "" && validType {
+			if msg := defaultTypeMismatch(col.Default, col.Type); msg != "" {
+				addError(label, "default_type_mismatch", "%s", msg)
+			}
+		}
+	}
+
+	keySeen := make(map[string]bool, len(req.PrimaryKey))
+	for _, name := range req.PrimaryKey {
+		key := strings.ToLower(name)
+		if !seen[key] {
+			addError(name, "unknown_primary_key_column", "Primary key column %q is not one of the table's columns", name)
+		} else if keySeen[key] {
+			addError(name, "duplicate_primary_key_column", "Primary key column %q is listed more than once", name)
+		}
+		keySeen[key] = true
+	}
+
+	if len(req.Columns) > 0 && !hasPrimary {
+		addWarning("", "missing_primary_key", "Table has no primary key; rows cannot be updated or deleted from the data API reliably")
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
+
+// defaultTypeMismatch returns a message if a column default does not fit the
+// column's type, or "" if it does.
+func defaultTypeMismatch(defaultVal, pgType string) string {
+	lower := strings.ToLower(strings.TrimSpace(defaultVal))
+	switch lower {
+	case "null":
+		return ""
+	case "gen_random_uuid()":
+		if pgType != string(types.TypeUUID) && pgType != string(types.TypeText) {
+			return fmt.Sprintf("Default gen_random_uuid() produces a uuid, not %s", pgType)
+		}
+		return ""
+	case sequenceDefault:
+		if pgType != string(types.TypeInteger) {
+			return fmt.Sprintf("Default nextval() produces an integer, not %s", pgType)
+		}
+		return ""
+	case "now()", "current_timestamp":
+		if pgType != string(types.TypeTimestamptz) && pgType != string(types.TypeText) {
+			return fmt.Sprintf("Default %s produces a timestamp, not %s", defaultVal, pgType)
+		}
+		return ""
+	}
+	if strings.HasSuffix(lower, ")") {
+		// Other expressions can't be checked without evaluating them
+		return ""
+	}
+
+	// String literals may be quoted
+	literal := strings.TrimSpace(defaultVal)
+	if len(literal) >= 2 && literal[0] == '\'' && literal[len(literal)-1] == '\'' {
+		literal = strings.ReplaceAll(literal[1:len(literal)-1], "''", "'")
+	}
+
+	var err error
+	switch types.PgType(pgType) {
+	case types.TypeInteger:
+		_, err = strconv.ParseInt(literal, 10, 64)
+	case types.TypeNumeric:
+		_, err = strconv.ParseFloat(literal, 64)
+	case types.TypeBoolean:
+		switch strings.ToLower(literal) {
+		case "true", "false", "1", "0":
+		default:
+			err = fmt.Errorf("not a boolean")
+		}
+	case types.TypeUUID:
+		_, err = uuid.Parse(literal)
+	case types.TypeJSONB:
+		if !json.Valid([]byte(literal)) {
+			err = fmt.Errorf("not valid JSON")
+		}
+	case types.TypeTimestamptz:
+		err = types.Validate(types.TypeTimestamptz, literal)
+	}
+	if err != nil {
+		return fmt.Sprintf("Default %s is not a valid %s value", defaultVal, pgType)
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func issueCodes(issues []TableValidationIssue) []string {
+	codes := make([]string, 0, len(issues))
+	for _, i := range issues {
+		codes = append(codes, i.Column+":"+i.Code)
+	}
+	return codes
+}
+
+func TestValidateCreateTable(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	_, err := h.db.Exec(`CREATE TABLE existing (id TEXT)`)
+	require.NoError(t, err)
+
+	decode := func(body string) CreateTableRequest {
+		var req CreateTableRequest
+		require.NoError(t, json.Unmarshal([]byte(body), &req))
+		return req
+	}
+
+	result := h.validateCreateTable(decode(`{"name":"orders","columns":[
+		{"name":"id","type":"uuid","primary":true,"default":"gen_random_uuid()"},
+		{"name":"total","type":"numeric","nullable":true,"default":"0.5"},
+		{"name":"paid","type":"boolean","default":"false"},
+		{"name":"created_at","type":"timestamptz","default":"now()"}]}`))
+	assert.True(t, result.Valid, "%v", result.Errors)
+	assert.Empty(t, result.Warnings)
+
+	result = h.validateCreateTable(decode(`{"name":"existing","columns":[
+		{"name":"id","type":"integer","nullable":true},
+		{"name":"order","type":"text"},
+		{"name":"ID","type":"text"},
+		{"name":"bad name","type":"text"},
+		{"name":"count","type":"integer","default":"'abc'"},
+		{"name":"meta","type":"jsonb","default":"{bad"},
+		{"name":"n","type":"varchar"}]}`))
+	assert.False(t, result.Valid)
+	assert.ElementsMatch(t, []string{
+		":table_exists",
+		"ID:duplicate_column",
+		"bad name:invalid_identifier",
+		"count:default_type_mismatch",
+		"meta:default_type_mismatch",
+		"n:invalid_type",
+	}, issueCodes(result.Errors))
+	assert.ElementsMatch(t, []string{"order:reserved_word", ":missing_primary_key"}, issueCodes(result.Warnings))
+
+	result = h.validateCreateTable(decode(`{"name":"1st","columns":[]}`))
+	assert.ElementsMatch(t, []string{":invalid_identifier", ":columns_required"}, issueCodes(result.Errors))
+
+	for _, name := range []string{"auth_users", "Storage_things", "_columns", "sqlite_stat9"} {
+		result = h.validateCreateTable(decode(`{"name":"` + name + `","columns":[{"name":"id","type":"integer","primary":true}]}`))
+		assert.Equal(t, []string{":reserved_table_name"}, issueCodes(result.Errors), name)
+	}
+	assert.False(t, isReservedTableName("authors"))
+	assert.False(t, isReservedTableName("my_storage"))
+}
+
+func TestHandlerValidateTable(t *testing.T) {
+	h, dbPath := setupTestHandler(t)
+	defer os.Remove(dbPath)
+
+	token := setupTestSession(t, h)
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+
+	body := `{"name":"items","columns":[{"name":"id","type":"integer","primary":true,"default":"abc"}]}`
+	req := httptest.NewRequest("POST", "/api/tables/validate", strings.NewReader(body))
+	addTestSession(req, token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var result TableValidationResult
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&result))
+	assert.False(t, result.Valid)
+	assert.Equal(t, []string{"id:default_type_mismatch"}, issueCodes(result.Errors))
+
+	// Nothing was created
+	var count int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'items'`).Scan(&count)
+	assert.Equal(t, 0, count)
+}
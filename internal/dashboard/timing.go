@@ -0,0 +1,47 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// serverTiming collects named phase durations for a response, reported in the
+// Server-Timing header (https://www.w3.org/TR/server-timing/) so browser dev
+// tools can show where the time went.
+type serverTiming struct {
+	names []string
+	durs  []time.Duration
+}
+
+// track starts timing a phase; call the returned func when it ends.
+func (t *serverTiming) track(name string) func() {
+	start := time.Now()
+	return func() {
+		t.names = append(t.names, name)
+		t.durs = append(t.durs, time.Since(start))
+	}
+}
+
+// header formats the phases as a Server-Timing header value.
+func (t *serverTiming) header() string {
+	parts := make([]string, len(t.names))
+	for i, name := range t.names {
+		parts[i] = fmt.Sprintf("%s;dur=%.3f", name, durationMs(t.durs[i]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// millis returns the phases recorded so far as "<name>_ms" keys.
+func (t *serverTiming) millis() map[string]float64 {
+	m := make(map[string]float64, len(t.names))
+	for i, name := range t.names {
+		m[name+"_ms"] = durationMs(t.durs[i])
+	}
+	return m
+}
+
+// durationMs converts d to milliseconds with microsecond precision.
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,110 @@
+package dashboard
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// DefaultUploadMemoryThreshold is how much of an uploaded file is kept in
+// memory before it is spooled to a temp file (8 MB).
+const DefaultUploadMemoryThreshold int64 = 8 << 20
+
+// UploadConfig controls how dashboard uploads are buffered before they are
+// handed to the storage backend.
+type UploadConfig struct {
+	// TempDir is where files above MemoryThreshold are spooled. Empty uses
+	// the system temp directory.
+	TempDir string
+	// MemoryThreshold is the largest file kept entirely in memory. Zero uses
+	// DefaultUploadMemoryThreshold; negative always spools to disk.
+	MemoryThreshold int64
+}
+
+// SetUploadConfig sets how dashboard uploads are buffered.
+func (h *Handler) SetUploadConfig(cfg UploadConfig) {
+	h.uploadConfig = cfg
+}
+
+func (c UploadConfig) memoryThreshold() int64 {
+	if c.MemoryThreshold == 0 {
+		return DefaultUploadMemoryThreshold
+	}
+	if c.MemoryThreshold < 0 {
+		return 0
+	}
+	return c.MemoryThreshold
+}
+
+// spooledUpload holds an uploaded file either in memory or in a temp file.
+// Close must always be called; it removes the temp file.
+type spooledUpload struct {
+	mem  []byte
+	file *os.File
+	size int64
+}
+
+// spoolUpload reads src into memory until it exceeds the configured threshold,
+// then continues into a temp file. On error, nothing is left on disk.
+func spoolUpload(src io.Reader, cfg UploadConfig) (*spooledUpload, error) {
+	threshold := cfg.memoryThreshold()
+
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(src, threshold+1))
+	if err != nil {
+		return nil, err
+	}
+	if n <= threshold {
+		return &spooledUpload{mem: buf.Bytes(), size: n}, nil
+	}
+
+	f, err := os.CreateTemp(cfg.TempDir, "sblite-upload-*")
+	if err != nil {
+		return nil, err
+	}
+	s := &spooledUpload{file: f}
+	if s.size, err = io.Copy(f, io.MultiReader(&buf, src)); err != nil {
+		s.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reader returns the spooled content from the start.
+func (s *spooledUpload) Reader() io.Reader {
+	if s.file != nil {
+		return s.file
+	}
+	return bytes.NewReader(s.mem)
+}
+
+// Sniff returns up to the first 512 bytes, for content type detection.
+func (s *spooledUpload) Sniff() ([]byte, error) {
+	if s.file == nil {
+		if len(s.mem) > 512 {
+			return s.mem[:512], nil
+		}
+		return s.mem, nil
+	}
+	head := make([]byte, 512)
+	n, err := s.file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// Close releases the content and removes any temp file.
+func (s *spooledUpload) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	name := s.file.Name()
+	s.file.Close()
+	s.file = nil
+	return os.Remove(name)
+}
@@ -0,0 +1,109 @@
+package dashboard
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/markb/sblite/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingReader struct{ r io.Reader }
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func tempDirEntries(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	return len(entries)
+}
+
+func TestSpoolUpload(t *testing.T) {
+	dir := t.TempDir()
+	cfg := UploadConfig{TempDir: dir, MemoryThreshold: 10}
+
+	// Small uploads stay in memory
+	s, err := spoolUpload(strings.NewReader("small"), cfg)
+	require.NoError(t, err)
+	assert.Nil(t, s.file)
+	assert.Equal(t, int64(5), s.size)
+	content, _ := io.ReadAll(s.Reader())
+	assert.Equal(t, "small", string(content))
+	require.NoError(t, s.Close())
+
+	// Larger uploads are spooled to the temp dir and removed on close
+	s, err = spoolUpload(strings.NewReader("a much larger upload"), cfg)
+	require.NoError(t, err)
+	require.NotNil(t, s.file)
+	assert.Equal(t, 1, tempDirEntries(t, dir))
+	assert.Equal(t, int64(20), s.size)
+	head, err := s.Sniff()
+	require.NoError(t, err)
+	assert.Equal(t, "a much larger upload", string(head))
+	content, _ = io.ReadAll(s.Reader())
+	assert.Equal(t, "a much larger upload", string(content))
+	require.NoError(t, s.Close())
+	assert.Equal(t, 0, tempDirEntries(t, dir))
+
+	// A failed read leaves nothing behind
+	_, err = spoolUpload(&failingReader{strings.NewReader("a much larger upload")}, cfg)
+	require.Error(t, err)
+	assert.Equal(t, 0, tempDirEntries(t, dir))
+
+	// Negative threshold always spools
+	s, err = spoolUpload(strings.NewReader("x"), UploadConfig{TempDir: dir, MemoryThreshold: -1})
+	require.NoError(t, err)
+	assert.NotNil(t, s.file)
+	require.NoError(t, s.Close())
+}
+
+func TestHandleUploadObjectSpooled(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	svc, err := storage.NewService(database.DB, storage.Config{LocalPath: t.TempDir()})
+	require.NoError(t, err)
+	handler.SetStorageService(svc)
+	_, err = svc.CreateBucket(storage.CreateBucketRequest{Name: "docs"}, "")
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+	handler.SetUploadConfig(UploadConfig{TempDir: tempDir, MemoryThreshold: 16})
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", "notes.txt")
+	require.NoError(t, err)
+	fw.Write([]byte(strings.Repeat("spooled content ", 10)))
+	mw.WriteField("bucket", "docs")
+	mw.WriteField("path", "team/")
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest("POST", "/storage/objects/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	handler.handleUploadObject(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	assert.Equal(t, 0, tempDirEntries(t, tempDir))
+
+	reader, _, size, err := svc.GetObject("docs", "team/notes.txt")
+	require.NoError(t, err)
+	defer reader.Close()
+	assert.Equal(t, int64(160), size)
+}
@@ -0,0 +1,122 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/markb/sblite/internal/log"
+)
+
+const (
+	// defaultImpersonationTTL is how long an impersonation token lasts unless
+	// the request asks for less.
+	defaultImpersonationTTL = 15 * time.Minute
+	// maxImpersonationTTL caps the requested lifetime at that of a regular
+	// access token.
+	maxImpersonationTTL = time.Hour
+)
+
+// SetImpersonationEnabled turns POST /api/users/{id}/impersonate on or off.
+// It is on by default.
+func (h *Handler) SetImpersonationEnabled(enabled bool) {
+	h.impersonationDisabled = !enabled
+}
+
+// handleImpersonateUser mints a short-lived access token for a user so the
+// owner can see the data as that user, e.g. to debug RLS policies. The
+// dashboard has a single owner account, so the request must repeat the
+// dashboard password; wrong passwords count towards the login lockout. The
+// token carries the user's claims plus "impersonated": true, has no session
+// and cannot be refreshed.
+// POST /_/api/users/{id}/impersonate
+func (h *Handler) handleImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	if h.impersonationDisabled {
+		writeError(w, http.StatusForbidden, "impersonation_disabled", "User impersonation is disabled")
+		return
+	}
+	if h.jwtSecret == "" {
+		writeError(w, http.StatusInternalServerError, "jwt_secret_not_configured", "JWT secret not configured")
+		return
+	}
+
+	var req struct {
+		Password  string `json:"password"`
+		ExpiresIn int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+	if !h.verifyDashboardPassword(w, r, req.Password, "impersonate a user") {
+		log.Warn("dashboard user impersonation rejected", "audit", true, "remote_addr", clientIP(r), "user_id", chi.URLParam(r, "id"))
+		return
+	}
+	ttl := defaultImpersonationTTL
+	if req.ExpiresIn < 0 || time.Duration(req.ExpiresIn)*time.Second > maxImpersonationTTL {
+		writeError(w, http.StatusBadRequest, "invalid_expires_in", "expires_in must be between 1 and 3600 seconds")
+		return
+	}
+	if req.ExpiresIn > 0 {
+		ttl = time.Duration(req.ExpiresIn) * time.Second
+	}
+
+	userID := chi.URLParam(r, "id")
+	var email, appMeta, userMeta, role sql.NullString
+	var isAnonymous int
+	err := h.db.QueryRow(`
+		SELECT email, raw_app_meta_data, raw_user_meta_data, role, COALESCE(is_anonymous, 0)
+		FROM auth_users WHERE id = ? AND deleted_at IS NULL`, userID).Scan(&email, &appMeta, &userMeta, &role, &isAnonymous)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "user_not_found", "User not found")
+		return
+	}
+
+	metadata := func(ns sql.NullString) map[string]interface{} {
+		m := map[string]interface{}{}
+		if ns.Valid {
+			json.Unmarshal([]byte(ns.String), &m)
+		}
+		return m
+	}
+	if !role.Valid || role.String == "" {
+		role.String = "authenticated"
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := jwt.MapClaims{
+		"aud":           "authenticated",
+		"exp":           expiresAt.Unix(),
+		"iat":           now.Unix(),
+		"iss":           "sblite",
+		"sub":           userID,
+		"email":         email.String,
+		"phone":         "",
+		"role":          role.String,
+		"aal":           "aal1",
+		"is_anonymous":  isAnonymous != 0,
+		"app_metadata":  metadata(appMeta),
+		"user_metadata": metadata(userMeta),
+		"impersonated":  true,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(h.jwtSecret))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to sign token")
+		return
+	}
+
+	log.Warn("dashboard issued user impersonation token", "audit", true, "remote_addr", clientIP(r),
+		"user_id", userID, "email", email.String, "expires_at", expiresAt.UTC().Format(time.RFC3339))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": token,
+		"token_type":   "bearer",
+		"expires_in":   int(ttl.Seconds()),
+		"expires_at":   expiresAt.Unix(),
+	})
+}
@@ -0,0 +1,71 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerImpersonateUser(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	handler.SetJWTSecret("test-secret-that-is-long-enough-32")
+	require.NoError(t, handler.auth.SetupPassword("testpassword123"))
+	_, err := database.Exec(`INSERT INTO auth_users (id, email, raw_app_meta_data) VALUES ('u1', 'a@example.com', '{"plan":"pro"}')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/users/{id}/impersonate", handler.handleImpersonateUser)
+	impersonate := func(id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/users/"+id+"/impersonate", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, impersonate("u1", `{"password": "wrong"}`).Code)
+	assert.Equal(t, http.StatusNotFound, impersonate("missing", `{"password": "testpassword123"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, impersonate("u1", `{"password": "testpassword123", "expires_in": 7200}`).Code)
+
+	w := impersonate("u1", `{"password": "testpassword123", "expires_in": 300}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+		ExpiresAt   int64  `json:"expires_at"`
+	}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 300, resp.ExpiresIn)
+	assert.InDelta(t, time.Now().Add(5*time.Minute).Unix(), resp.ExpiresAt, 5)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(resp.AccessToken, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte("test-secret-that-is-long-enough-32"), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "u1", claims["sub"])
+	assert.Equal(t, "authenticated", claims["role"])
+	assert.Equal(t, "a@example.com", claims["email"])
+	assert.Equal(t, true, claims["impersonated"])
+	assert.Equal(t, "pro", claims["app_metadata"].(map[string]interface{})["plan"])
+
+	// Wrong passwords count towards the login lockout
+	for i := 1; i < loginMaxFailures; i++ {
+		w = impersonate("u1", `{"password": "wrong"}`)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, http.StatusTooManyRequests, impersonate("u1", `{"password": "testpassword123"}`).Code)
+
+	handler.SetImpersonationEnabled(false)
+	assert.Equal(t, http.StatusForbidden, impersonate("u1", `{"password": "testpassword123"}`).Code)
+}
@@ -0,0 +1,145 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markb/sblite/internal/log"
+)
+
+// UserSession is one of a user's auth sessions as shown to admins. Refresh
+// token values are never included.
+type UserSession struct {
+	ID        string      `json:"id"`
+	CreatedAt interface{} `json:"created_at"`
+	// LastUsedAt is when the session last issued a refresh token, on sign-in
+	// or refresh.
+	LastUsedAt interface{} `json:"last_used_at"`
+	AAL        interface{} `json:"aal"`
+	NotAfter   interface{} `json:"not_after"`
+	// Revoked is set once the session has ended or none of its refresh
+	// tokens can be used.
+	Revoked bool `json:"revoked"`
+	// RefreshCount is how many refresh tokens the session has issued.
+	RefreshCount int `json:"refresh_count"`
+}
+
+// userExists reports whether an auth user with the id exists.
+func (h *Handler) userExists(userID string) bool {
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM auth_users WHERE id = ?`, userID).Scan(&exists)
+	return exists > 0
+}
+
+// handleListUserSessions returns a user's sessions, most recently used first.
+// The device a session was created from is not recorded.
+// GET /_/api/users/{id}/sessions
+func (h *Handler) handleListUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	if !h.userExists(userID) {
+		writeError(w, http.StatusNotFound, "user_not_found", "User not found")
+		return
+	}
+
+	rows, err := h.db.Query(`
+		SELECT s.id, s.created_at, MAX(t.created_at), s.aal, s.not_after,
+		       COUNT(t.id), COALESCE(MIN(t.revoked), 1)
+		FROM auth_sessions s
+		LEFT JOIN auth_refresh_tokens t ON t.session_id = s.id
+		WHERE s.user_id = ?
+		GROUP BY s.id
+		ORDER BY COALESCE(MAX(t.created_at), s.created_at) DESC`, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "query_error", err.Error())
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	sessions := []UserSession{}
+	for rows.Next() {
+		var s UserSession
+		var createdAt, lastUsedAt, aal, notAfter sql.NullString
+		var revoked int
+		if err := rows.Scan(&s.ID, &createdAt, &lastUsedAt, &aal, &notAfter, &s.RefreshCount, &revoked); err != nil {
+			writeError(w, http.StatusInternalServerError, "query_error", err.Error())
+			return
+		}
+		s.CreatedAt = nullStringToInterface(createdAt)
+		s.LastUsedAt = nullStringToInterface(lastUsedAt)
+		s.AAL = nullStringToInterface(aal)
+		s.NotAfter = nullStringToInterface(notAfter)
+		s.Revoked = revoked != 0 || (notAfter.Valid && notAfter.String <= now)
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "query_error", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// handleRevokeUserSessions revokes one of a user's sessions, or all of them
+// without a session id. Their refresh tokens stop working at once and the
+// session is closed; access tokens already issued remain valid until they
+// expire. Revoked sessions stay listed so admins can see what was ended.
+// DELETE /_/api/users/{id}/sessions
+// DELETE /_/api/users/{id}/sessions/{sessionId}
+func (h *Handler) handleRevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+	sessionID := chi.URLParam(r, "sessionId")
+	if !h.userExists(userID) {
+		writeError(w, http.StatusNotFound, "user_not_found", "User not found")
+		return
+	}
+
+	tokenCond, sessionCond, args := "user_id = ?", "user_id = ?", []interface{}{userID}
+	if sessionID != "" {
+		var exists int
+		h.db.QueryRow(`SELECT COUNT(*) FROM auth_sessions WHERE id = ? AND user_id = ?`, sessionID, userID).Scan(&exists)
+		if exists == 0 {
+			writeError(w, http.StatusNotFound, "session_not_found", "Session not found")
+			return
+		}
+		tokenCond, sessionCond = "user_id = ? AND session_id = ?", "user_id = ? AND id = ?"
+		args = append(args, sessionID)
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := h.db.Begin()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE auth_refresh_tokens SET revoked = 1, updated_at = ? WHERE revoked = 0 AND `+tokenCond,
+		append([]interface{}{now}, args...)...); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	// Close the sessions that were still open
+	res, err := tx.Exec(`UPDATE auth_sessions SET not_after = ?, updated_at = ?
+		WHERE (not_after IS NULL OR not_after > ?) AND `+sessionCond,
+		append([]interface{}{now, now, now}, args...)...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	revoked, _ := res.RowsAffected()
+
+	log.Info("dashboard revoked user sessions", "audit", true, "remote_addr", clientIP(r),
+		"user_id", userID, "session_id", sessionID, "revoked", revoked)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"revoked": revoked})
+}
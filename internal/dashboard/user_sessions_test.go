@@ -0,0 +1,79 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerUserSessions(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`
+		INSERT INTO auth_users (id, email) VALUES ('u1', 'a@example.com'), ('u2', 'b@example.com');
+		INSERT INTO auth_sessions (id, user_id, created_at) VALUES
+			('s1', 'u1', '2026-01-01T00:00:00Z'), ('s2', 'u1', '2026-01-02T00:00:00Z'), ('s3', 'u2', '2026-01-01T00:00:00Z');
+		INSERT INTO auth_refresh_tokens (token, user_id, session_id, revoked, created_at) VALUES
+			('secret-1a', 'u1', 's1', 1, '2026-01-01T00:00:00Z'),
+			('secret-1b', 'u1', 's1', 0, '2026-01-05T00:00:00Z'),
+			('secret-2', 'u1', 's2', 0, '2026-01-02T00:00:00Z'),
+			('secret-3', 'u2', 's3', 0, '2026-01-01T00:00:00Z');
+	`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/users/{id}/sessions", handler.handleListUserSessions)
+	r.Delete("/users/{id}/sessions", handler.handleRevokeUserSessions)
+	r.Delete("/users/{id}/sessions/{sessionId}", handler.handleRevokeUserSessions)
+
+	do := func(method, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	list := func(userID string) []UserSession {
+		w := do("GET", "/users/"+userID+"/sessions")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "secret")
+		var sessions []UserSession
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&sessions))
+		return sessions
+	}
+
+	sessions := list("u1")
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "s1", sessions[0].ID, "most recently used first")
+	assert.Equal(t, "2026-01-05T00:00:00Z", sessions[0].LastUsedAt)
+	assert.Equal(t, 2, sessions[0].RefreshCount)
+	assert.False(t, sessions[0].Revoked)
+
+	assert.Equal(t, http.StatusNotFound, do("GET", "/users/missing/sessions").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/users/u1/sessions/s3").Code, "another user's session")
+
+	w := do("DELETE", "/users/u1/sessions/s1")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var revoked int
+	database.QueryRow(`SELECT COUNT(*) FROM auth_refresh_tokens WHERE session_id = 's1' AND revoked = 0`).Scan(&revoked)
+	assert.Zero(t, revoked)
+	for _, s := range list("u1") {
+		assert.Equal(t, s.ID == "s1", s.Revoked, s.ID)
+	}
+
+	w = do("DELETE", "/users/u1/sessions")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp map[string]int
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, 1, resp["revoked"])
+	for _, s := range list("u1") {
+		assert.True(t, s.Revoked, s.ID)
+	}
+	assert.False(t, list("u2")[0].Revoked)
+}
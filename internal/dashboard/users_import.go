@@ -0,0 +1,188 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxUsersImportSize bounds an uploaded users export.
+const maxUsersImportSize = 32 << 20
+
+// UserImportResult is the outcome of importing one user.
+type UserImportResult struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Status string `json:"status"` // created, updated, skipped or error
+	Error  string `json:"error,omitempty"`
+}
+
+// importAuthUser inserts or, on conflict with onConflict "update", updates
+// one exported user inside tx. Users conflict when their id or email is
+// already taken; an update keeps the existing row's id.
+func importAuthUser(tx *sql.Tx, u ExportedAuthUser, onConflict string) UserImportResult {
+	res := UserImportResult{ID: u.ID, Email: u.Email}
+	fail := func(msg string) UserImportResult {
+		res.Status = "error"
+		res.Error = msg
+		return res
+	}
+
+	if !u.IsAnonymous && !strings.Contains(u.Email, "@") {
+		return fail("a valid email is required for non-anonymous users")
+	}
+	if u.EncryptedPassword != "" {
+		if _, err := bcrypt.Cost([]byte(u.EncryptedPassword)); err != nil {
+			return fail("encrypted_password is not a bcrypt hash")
+		}
+	}
+	appMeta, err := normalizeUserMetadata(u.AppMetadata)
+	if err != nil {
+		return fail("app_metadata: " + err.Error())
+	}
+	userMeta, err := normalizeUserMetadata(u.UserMetadata)
+	if err != nil {
+		return fail("user_metadata: " + err.Error())
+	}
+	if u.Role == "" {
+		u.Role = "authenticated"
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if u.CreatedAt == "" {
+		u.CreatedAt = now
+	}
+	if u.UpdatedAt == "" {
+		u.UpdatedAt = now
+	}
+
+	var email, password interface{}
+	if u.Email != "" {
+		email = u.Email
+	}
+	if u.EncryptedPassword != "" {
+		password = u.EncryptedPassword
+	}
+
+	var existingID string
+	err = tx.QueryRow(`SELECT id FROM auth_users WHERE id = ? OR (email IS NOT NULL AND email = ?) LIMIT 1`,
+		u.ID, email).Scan(&existingID)
+	switch {
+	case err == sql.ErrNoRows:
+		if res.ID == "" {
+			res.ID = uuid.New().String()
+		}
+		_, err = tx.Exec(`
+			INSERT INTO auth_users (id, email, encrypted_password, email_confirmed_at, raw_app_meta_data,
+				raw_user_meta_data, role, is_anonymous, created_at, updated_at, last_sign_in_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, res.ID, email, password, u.EmailConfirmedAt, appMeta, userMeta, u.Role, u.IsAnonymous,
+			u.CreatedAt, u.UpdatedAt, u.LastSignInAt)
+		if err != nil {
+			return fail(err.Error())
+		}
+		res.Status = "created"
+	case err != nil:
+		return fail(err.Error())
+	case onConflict != "update":
+		res.ID = existingID
+		res.Status = "skipped"
+	default:
+		// Keep the stored password when the export omitted hashes
+		_, err = tx.Exec(`
+			UPDATE auth_users SET email = ?, encrypted_password = COALESCE(?, encrypted_password),
+				email_confirmed_at = ?, raw_app_meta_data = ?, raw_user_meta_data = ?, role = ?,
+				is_anonymous = ?, updated_at = ?, last_sign_in_at = ?
+			WHERE id = ?
+		`, email, password, u.EmailConfirmedAt, appMeta, userMeta, u.Role, u.IsAnonymous,
+			u.UpdatedAt, u.LastSignInAt, existingID)
+		if err != nil {
+			return fail(err.Error())
+		}
+		res.ID = existingID
+		res.Status = "updated"
+	}
+	return res
+}
+
+// normalizeUserMetadata returns metadata as a JSON object string, treating
+// a missing value as an empty object.
+func normalizeUserMetadata(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "{}", nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return "", fmt.Errorf("must be a JSON object")
+	}
+	return string(raw), nil
+}
+
+// handleImportUsers imports users from the JSON produced by
+// GET /_/api/export/auth/users, preserving ids, metadata and bcrypt password
+// hashes. All users are written in one transaction. A user whose id or email
+// already exists is skipped, or updated with ?on_conflict=update.
+// POST /_/api/users/import
+func (h *Handler) handleImportUsers(w http.ResponseWriter, r *http.Request) {
+	onConflict := r.URL.Query().Get("on_conflict")
+	if onConflict == "" {
+		onConflict = "skip"
+	}
+	if onConflict != "skip" && onConflict != "update" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "on_conflict must be skip or update")
+		return
+	}
+
+	var body struct {
+		Users []ExportedAuthUser `json:"users"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxUsersImportSize)).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "Invalid users export: "+err.Error())
+		return
+	}
+	if len(body.Users) == 0 {
+		writeError(w, http.StatusBadRequest, "missing_field", "users is required")
+		return
+	}
+
+	var results []UserImportResult
+	var counts map[string]int
+	err := h.runWrite(r, func() error {
+		results = make([]UserImportResult, 0, len(body.Users))
+		counts = map[string]int{"created": 0, "updated": 0, "skipped": 0, "error": 0}
+		tx, err := h.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for i, u := range body.Users {
+			u.Email = strings.ToLower(strings.TrimSpace(u.Email))
+			res := importAuthUser(tx, u, onConflict)
+			res.Index = i
+			results = append(results, res)
+			counts[res.Status]++
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		writeWriteError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"created": counts["created"],
+		"updated": counts["updated"],
+		"skipped": counts["skipped"],
+		"failed":  counts["error"],
+		"results": results,
+	})
+}
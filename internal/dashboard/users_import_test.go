@@ -0,0 +1,66 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestImportUsers(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	_, err = h.db.Exec(`INSERT INTO auth_users (id, email, raw_user_meta_data) VALUES ('existing-id', 'taken@example.com', '{"plan":"free"}')`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Post("/users/import", h.handleImportUsers)
+	importUsers := func(query string, users []map[string]interface{}) map[string]interface{} {
+		body, _ := json.Marshal(map[string]interface{}{"users": users})
+		req := httptest.NewRequest("POST", "/users/import"+query, bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		var resp map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return resp
+	}
+
+	users := []map[string]interface{}{
+		{"id": "user-1", "email": "new@example.com", "encrypted_password": string(hash),
+			"user_metadata": map[string]string{"name": "New"}, "app_metadata": map[string]string{"provider": "email"},
+			"role": "authenticated", "created_at": "2025-01-01T00:00:00Z", "updated_at": "2025-01-02T00:00:00Z"},
+		{"id": "other-id", "email": "Taken@example.com", "user_metadata": map[string]string{"plan": "pro"}},
+		{"id": "bad", "email": "bad@example.com", "encrypted_password": "plaintext"},
+		{"id": "anon-1", "is_anonymous": true},
+	}
+
+	resp := importUsers("", users)
+	assert.Equal(t, float64(2), resp["created"])
+	assert.Equal(t, float64(1), resp["skipped"])
+	assert.Equal(t, float64(1), resp["failed"])
+	results := resp["results"].([]interface{})
+	assert.Equal(t, "skipped", results[1].(map[string]interface{})["status"])
+	assert.Equal(t, "existing-id", results[1].(map[string]interface{})["id"])
+	assert.Equal(t, "error", results[2].(map[string]interface{})["status"])
+
+	// The id, metadata and password hash are preserved
+	var storedHash, userMeta, createdAt string
+	require.NoError(t, h.db.QueryRow(`SELECT encrypted_password, raw_user_meta_data, created_at FROM auth_users WHERE id = 'user-1'`).
+		Scan(&storedHash, &userMeta, &createdAt))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(storedHash), []byte("secret123")))
+	assert.JSONEq(t, `{"name":"New"}`, userMeta)
+	assert.Equal(t, "2025-01-01T00:00:00Z", createdAt)
+
+	resp = importUsers("?on_conflict=update", users[1:2])
+	assert.Equal(t, float64(1), resp["updated"])
+	require.NoError(t, h.db.QueryRow(`SELECT raw_user_meta_data FROM auth_users WHERE id = 'existing-id'`).Scan(&userMeta))
+	assert.JSONEq(t, `{"plan":"pro"}`, userMeta)
+}
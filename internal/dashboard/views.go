@@ -0,0 +1,132 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ViewInfo describes a SQLite view. Views are created and dropped through
+// the SQL browser; the dashboard lists them and reads from them.
+type ViewInfo struct {
+	Name string `json:"name"`
+	// Type is always "view", to tell views apart from tables in combined
+	// listings such as the API docs.
+	Type    string           `json:"type"`
+	SQL     string           `json:"sql"`
+	Columns []ViewColumnInfo `json:"columns,omitempty"`
+}
+
+// ViewColumnInfo is a column of a view as SQLite reports it.
+type ViewColumnInfo struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// isView reports whether name is a view rather than a table.
+func (h *Handler) isView(name string) bool {
+	var exists int
+	h.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'view' AND name = ?`, name).Scan(&exists)
+	return exists > 0
+}
+
+// rejectViewWrite answers 405 for a write to a view, which the data API only
+// reads from. It returns true when the response has been written.
+func (h *Handler) rejectViewWrite(w http.ResponseWriter, name string) bool {
+	if !h.isView(name) {
+		return false
+	}
+	writeError(w, http.StatusMethodNotAllowed, "read_only_view", fmt.Sprintf("%s is a view and cannot be modified", name))
+	return true
+}
+
+// listViewNames returns the user views, skipping internal ones the same way
+// the table list does.
+func (h *Handler) listViewNames() ([]string, error) {
+	rows, err := h.db.Query(`
+		SELECT name FROM sqlite_master
+		WHERE type='view'
+		AND name NOT LIKE '\_%' ESCAPE '\'
+		AND name NOT LIKE 'auth\_%' ESCAPE '\'
+		AND name NOT LIKE 'storage\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// viewInfo returns a view's definition and, with columns set, its columns.
+func (h *Handler) viewInfo(name string, columns bool) (*ViewInfo, error) {
+	info := &ViewInfo{Name: name, Type: "view"}
+	if err := h.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'view' AND name = ?`, name).Scan(&info.SQL); err != nil {
+		return nil, err
+	}
+	if !columns {
+		return info, nil
+	}
+
+	rows, err := h.db.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, name))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notnull, pk int
+		var col ViewColumnInfo
+		var dflt interface{}
+		if err := rows.Scan(&cid, &col.Name, &col.Type, &notnull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		info.Columns = append(info.Columns, col)
+	}
+	return info, rows.Err()
+}
+
+// handleListViews returns the user views with their definitions.
+// GET /_/api/views
+func (h *Handler) handleListViews(w http.ResponseWriter, r *http.Request) {
+	names, err := h.listViewNames()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", "Failed to list views")
+		return
+	}
+
+	views := []ViewInfo{}
+	for _, name := range names {
+		info, err := h.viewInfo(name, false)
+		if err != nil {
+			continue
+		}
+		views = append(views, *info)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// handleGetView returns a view's definition and columns.
+// GET /_/api/views/{name}
+func (h *Handler) handleGetView(w http.ResponseWriter, r *http.Request) {
+	info, err := h.viewInfo(chi.URLParam(r, "name"), true)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "view_not_found", "View not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
@@ -0,0 +1,83 @@
+package dashboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerViews(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	handler := NewHandler(database.DB, "")
+	_, err := database.Exec(`
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, total INTEGER);
+		INSERT INTO orders VALUES (1, 10), (2, 250);
+		CREATE VIEW big_orders AS SELECT id, total FROM orders WHERE total > 100;
+	`)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/views", handler.handleListViews)
+	r.Get("/views/{name}", handler.handleGetView)
+	r.Get("/data/{table}", handler.handleSelectData)
+	r.Post("/data/{table}", handler.handleInsertData)
+	r.Patch("/data/{table}", handler.handleUpdateData)
+	r.Delete("/data/{table}", handler.handleDeleteData)
+	r.Get("/apidocs/tables", handler.handleAPIDocsListTables)
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("GET", "/views", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var views []ViewInfo
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&views))
+	require.Len(t, views, 1)
+	assert.Equal(t, "big_orders", views[0].Name)
+	assert.Equal(t, "view", views[0].Type)
+	assert.Contains(t, views[0].SQL, "WHERE total > 100")
+
+	w = do("GET", "/views/big_orders", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var view ViewInfo
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&view))
+	require.Len(t, view.Columns, 2)
+	assert.Equal(t, "total", view.Columns[1].Name)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/views/orders", "").Code)
+
+	// Views can be read through the data API but not written
+	w = do("GET", "/data/big_orders", "")
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Contains(t, w.Body.String(), "250")
+	assert.NotContains(t, w.Body.String(), `"total":10`)
+	assert.Equal(t, http.StatusMethodNotAllowed, do("POST", "/data/big_orders", `{"id": 3, "total": 500}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do("PATCH", "/data/big_orders?id=eq.2", `{"total": 1}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do("DELETE", "/data/big_orders?id=eq.2", "").Code)
+
+	var registered int
+	database.QueryRow(`SELECT COUNT(*) FROM _columns WHERE table_name = 'big_orders'`).Scan(&registered)
+	assert.Zero(t, registered, "views are not registered in _columns")
+
+	w = do("GET", "/apidocs/tables", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	var docs []APIDocsTableInfo
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&docs))
+	flags := map[string]bool{}
+	for _, d := range docs {
+		flags[d.Name] = d.IsView
+	}
+	assert.Contains(t, flags, "orders")
+	assert.False(t, flags["orders"])
+	assert.True(t, flags["big_orders"])
+}
@@ -0,0 +1,102 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/markb/sblite/internal/db"
+)
+
+const (
+	// defaultBusyRetries is how many times a write failing with SQLITE_BUSY or
+	// SQLITE_LOCKED is retried unless configured.
+	defaultBusyRetries = 3
+	// busyRetryBackoff is the wait before the first retry; it doubles each time.
+	busyRetryBackoff = 50 * time.Millisecond
+	// busyRetryAfterSeconds is sent in Retry-After when a write gives up.
+	busyRetryAfterSeconds = 1
+)
+
+// newDefaultWriteQueue creates the write queue used until the server installs
+// the shared one via SetWriteQueue.
+func newDefaultWriteQueue() *db.WriteQueue {
+	return db.NewWriteQueue(db.DefaultWriteQueueSize, db.DefaultWriteTimeout)
+}
+
+// runWrite executes fn through the write queue so concurrent mutations are
+// serialized rather than failing with SQLITE_BUSY. Writers outside the queue
+// (the REST API, other processes) can still hold the lock, so a busy error is
+// retried with backoff, releasing the queue between attempts.
+func (h *Handler) runWrite(r *http.Request, fn func() error) error {
+	retries := h.busyRetries()
+	backoff := busyRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := h.writes.Do(r.Context(), fn)
+		if !db.IsBusy(err) || attempt >= retries {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-r.Context().Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// busyRetries returns how many times a busy write is retried. Unlike other
+// settings, 0 is a valid value and disables retries.
+func (h *Handler) busyRetries() int {
+	val, _ := h.store.Get("data_busy_retries")
+	if n, err := strconv.Atoi(val); err == nil && n >= 0 {
+		return n
+	}
+	return defaultBusyRetries
+}
+
+// writeWriteError writes the response for a failed write. Queue saturation and
+// a database that stayed locked are reported as 503 with Retry-After so clients
+// can retry; other errors use fallback.
+func writeWriteError(w http.ResponseWriter, err error, fallback int) {
+	if errors.Is(err, db.ErrWriteQueueFull) || errors.Is(err, db.ErrWriteQueueTimeout) {
+		w.Header().Set("Retry-After", strconv.Itoa(busyRetryAfterSeconds))
+		writeError(w, http.StatusServiceUnavailable, "write_queue_busy", err.Error())
+		return
+	}
+	if db.IsBusy(err) {
+		w.Header().Set("Retry-After", strconv.Itoa(busyRetryAfterSeconds))
+		writeError(w, http.StatusServiceUnavailable, "database_busy", "The database is busy; retry the request")
+		return
+	}
+	writeError(w, fallback, "query_failed", err.Error())
+}
+
+// handleDBStats returns connection pool, write queue and file size statistics.
+// GET /_/api/maintenance/db-stats
+func (h *Handler) handleDBStats(w http.ResponseWriter, r *http.Request) {
+	pool := h.db.Stats()
+
+	var pageCount, pageSize, freelistCount int64
+	h.db.QueryRow(`PRAGMA page_count`).Scan(&pageCount)
+	h.db.QueryRow(`PRAGMA page_size`).Scan(&pageSize)
+	h.db.QueryRow(`PRAGMA freelist_count`).Scan(&freelistCount)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"connections": map[string]interface{}{
+			"open":          pool.OpenConnections,
+			"in_use":        pool.InUse,
+			"idle":          pool.Idle,
+			"wait_count":    pool.WaitCount,
+			"wait_duration": pool.WaitDuration.Milliseconds(),
+		},
+		"write_queue":    h.writes.Stats(),
+		"page_count":     pageCount,
+		"page_size":      pageSize,
+		"freelist_count": freelistCount,
+		"size_bytes":     pageCount * pageSize,
+	})
+}
@@ -0,0 +1,71 @@
+package dashboard
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// busyError produces a real SQLITE_BUSY error from a second connection.
+func busyError(t *testing.T) error {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	holder, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer holder.Close()
+	_, err = holder.Exec(`CREATE TABLE t (id INTEGER)`)
+	require.NoError(t, err)
+
+	tx, err := holder.Begin()
+	require.NoError(t, err)
+	defer tx.Rollback()
+	_, err = tx.Exec(`INSERT INTO t VALUES (1)`)
+	require.NoError(t, err)
+
+	other, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer other.Close()
+	_, err = other.Exec(`INSERT INTO t VALUES (2)`)
+	require.Error(t, err)
+	return err
+}
+
+func TestRunWriteRetriesBusy(t *testing.T) {
+	h, _ := setupTestHandler(t)
+	busy := busyError(t)
+	req := httptest.NewRequest("POST", "/", nil)
+
+	calls := 0
+	err := h.runWrite(req, func() error {
+		calls++
+		if calls < 3 {
+			return busy
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// Retries are configurable and give up with the busy error
+	require.NoError(t, h.store.Set("data_busy_retries", "1"))
+	calls = 0
+	err = h.runWrite(req, func() error {
+		calls++
+		return busy
+	})
+	assert.Equal(t, busy, err)
+	assert.Equal(t, 2, calls)
+
+	w := httptest.NewRecorder()
+	writeWriteError(w, err, http.StatusBadRequest)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+	var resp APIError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "database_busy", resp.Code)
+}
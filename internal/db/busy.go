@@ -0,0 +1,20 @@
+package db
+
+import (
+	"errors"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// IsBusy reports whether err is SQLITE_BUSY or SQLITE_LOCKED (including their
+// extended codes), meaning another connection held a lock the operation needed.
+// Nothing was written, so the operation can safely be retried.
+func IsBusy(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
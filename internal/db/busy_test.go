@@ -0,0 +1,50 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsBusy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "busy.db")
+	holder, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer holder.Close()
+	if _, err := holder.Exec(`CREATE TABLE t (id INTEGER)`); err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := holder.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`INSERT INTO t VALUES (1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second connection without busy_timeout fails immediately
+	other, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	_, err = other.Exec(`INSERT INTO t VALUES (2)`)
+	if err == nil {
+		t.Fatal("expected write to fail while another connection holds the lock")
+	}
+	if !IsBusy(err) {
+		t.Errorf("expected IsBusy for %v", err)
+	}
+
+	if IsBusy(errors.New("database is locked")) {
+		t.Error("plain errors are not busy errors")
+	}
+	if IsBusy(nil) {
+		t.Error("nil is not a busy error")
+	}
+}
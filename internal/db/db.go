@@ -6,6 +6,7 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"modernc.org/sqlite"
 	_ "modernc.org/sqlite"
@@ -61,10 +62,21 @@ func regexpFunc(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value,
 
 type DB struct {
 	*sql.DB
+	// Writes serializes data mutations to avoid SQLITE_BUSY under concurrent load.
+	Writes *WriteQueue
 }
 
 func New(path string) (*DB, error) {
-	db, err := sql.Open("sqlite", path)
+	// Apply busy_timeout on every pooled connection so a writer waits for the
+	// lock instead of failing immediately with SQLITE_BUSY
+	dsn := path
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	dsn += fmt.Sprintf("%s_pragma=busy_timeout(%d)", sep, DefaultBusyTimeoutMs)
+
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -81,5 +93,5 @@ func New(path string) (*DB, error) {
 		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
 	}
 
-	return &DB{db}, nil
+	return &DB{DB: db, Writes: NewWriteQueue(DefaultWriteQueueSize, DefaultWriteTimeout)}, nil
 }
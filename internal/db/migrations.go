@@ -96,6 +96,25 @@ CREATE TABLE IF NOT EXISTS auth_emails (
 CREATE INDEX IF NOT EXISTS idx_auth_emails_created_at ON auth_emails(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_auth_emails_type ON auth_emails(email_type);
 
+CREATE TABLE IF NOT EXISTS auth_email_queue (
+    id TEXT PRIMARY KEY,
+    to_email TEXT NOT NULL,
+    from_email TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    body_html TEXT,
+    body_text TEXT,
+    email_type TEXT NOT NULL,
+    user_id TEXT,
+    metadata TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_email_queue_due ON auth_email_queue(status, next_attempt_at);
+
 CREATE TABLE IF NOT EXISTS auth_email_templates (
     id TEXT PRIMARY KEY,
     type TEXT UNIQUE NOT NULL,
@@ -132,6 +151,10 @@ CREATE TABLE IF NOT EXISTS _columns (
     is_primary    INTEGER DEFAULT 0,
     description   TEXT DEFAULT '',
     created_at    TEXT DEFAULT (datetime('now')),
+    json_schema   TEXT,
+    is_hidden     INTEGER DEFAULT 0,
+    ordinal       INTEGER,
+    enum_values   TEXT,
     PRIMARY KEY (table_name, column_name)
 );
 
@@ -286,6 +309,7 @@ CREATE TABLE IF NOT EXISTS storage_buckets (
     public        INTEGER DEFAULT 0,
     file_size_limit   INTEGER,
     allowed_mime_types TEXT,
+    cache_max_age INTEGER,
     created_at    TEXT DEFAULT (datetime('now')),
     updated_at    TEXT DEFAULT (datetime('now'))
 );
@@ -355,7 +379,8 @@ CREATE TABLE IF NOT EXISTS _migrations (
     updated_at TEXT NOT NULL DEFAULT (datetime('now')),
     completed_at TEXT,
     error_message TEXT,
-    credentials_encrypted TEXT
+    credentials_encrypted TEXT,
+    webhook_url TEXT
 );
 
 CREATE INDEX IF NOT EXISTS idx_migrations_status ON _migrations(status);
@@ -382,7 +407,7 @@ CREATE INDEX IF NOT EXISTS idx_migration_items_status ON _migration_items(status
 CREATE TABLE IF NOT EXISTS _migration_verifications (
     id TEXT PRIMARY KEY,
     migration_id TEXT NOT NULL REFERENCES _migrations(id) ON DELETE CASCADE,
-    layer TEXT NOT NULL CHECK (layer IN ('basic', 'integrity', 'functional')),
+    layer TEXT NOT NULL CHECK (layer IN ('basic', 'integrity', 'functional', 'rollback')),
     status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'passed', 'failed')),
     started_at TEXT,
     completed_at TEXT,
@@ -392,6 +417,113 @@ CREATE TABLE IF NOT EXISTS _migration_verifications (
 CREATE INDEX IF NOT EXISTS idx_migration_verifications_migration_id ON _migration_verifications(migration_id);
 `
 
+// Integrity checks schema: user-defined assertion queries and recorded results
+const integrityChecksSchema = `
+-- Assertion queries: each is a SELECT that should return zero rows
+CREATE TABLE IF NOT EXISTS _integrity_checks (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL UNIQUE,
+    query       TEXT NOT NULL,
+    description TEXT DEFAULT '',
+    created_at  TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+-- Results of each integrity check run
+CREATE TABLE IF NOT EXISTS _integrity_check_results (
+    id         TEXT PRIMARY KEY,
+    run_id     TEXT NOT NULL,
+    check_name TEXT NOT NULL,
+    passed     INTEGER NOT NULL,
+    details    TEXT,
+    run_at     TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_check_results_run_id ON _integrity_check_results(run_id);
+CREATE INDEX IF NOT EXISTS idx_integrity_check_results_run_at ON _integrity_check_results(run_at DESC);
+`
+
+const schemaSnapshotsSchema = `
+-- Point-in-time copies of the user schema, recorded after schema changes
+CREATE TABLE IF NOT EXISTS _schema_snapshots (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    hash       TEXT NOT NULL,
+    reason     TEXT NOT NULL DEFAULT '',
+    tables     TEXT NOT NULL CHECK (json_valid(tables)),
+    created_at TEXT NOT NULL
+);
+`
+
+const storageWebhooksSchema = `
+-- Endpoints notified of storage object uploads and deletes
+CREATE TABLE IF NOT EXISTS _storage_webhooks (
+    id         TEXT PRIMARY KEY,
+    url        TEXT NOT NULL,
+    bucket     TEXT,
+    events     TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(events)),
+    secret     TEXT NOT NULL,
+    enabled    INTEGER NOT NULL DEFAULT 1,
+    created_at TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+
+-- Outbox of storage events awaiting delivery, one row per webhook
+CREATE TABLE IF NOT EXISTS _storage_webhook_outbox (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id      TEXT NOT NULL,
+    operation       TEXT NOT NULL,
+    payload         TEXT NOT NULL CHECK (json_valid(payload)),
+    status          TEXT NOT NULL DEFAULT 'pending',
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT,
+    next_attempt_at TEXT NOT NULL,
+    created_at      TEXT NOT NULL,
+    delivered_at    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_storage_webhook_outbox_pending ON _storage_webhook_outbox(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_storage_webhook_outbox_webhook ON _storage_webhook_outbox(webhook_id, id DESC);
+`
+
+const idempotencySchema = `
+-- Results of inserts made with an Idempotency-Key header, replayed on retry
+CREATE TABLE IF NOT EXISTS _idempotency (
+    key          TEXT NOT NULL,
+    table_name   TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    row_id       INTEGER,
+    status_code  INTEGER NOT NULL,
+    response     TEXT NOT NULL,
+    created_at   TEXT NOT NULL,
+    expires_at   TEXT NOT NULL,
+    PRIMARY KEY (key, table_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_expires_at ON _idempotency(expires_at);
+`
+
+const suspendedPoliciesSchema = `
+-- Policies switched off by "disable all policies for table", re-enabled on restore
+CREATE TABLE IF NOT EXISTS _rls_suspended_policies (
+    policy_id    INTEGER PRIMARY KEY,
+    table_name   TEXT NOT NULL,
+    suspended_at TEXT NOT NULL DEFAULT (datetime('now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_rls_suspended_policies_table ON _rls_suspended_policies(table_name);
+`
+
+const sequencesSchema = `
+-- Sequences behind nextval() column defaults; each has an AFTER INSERT trigger of the same name
+CREATE TABLE IF NOT EXISTS _sequences (
+    name          TEXT PRIMARY KEY,
+    table_name    TEXT NOT NULL,
+    column_name   TEXT NOT NULL,
+    current_value INTEGER NOT NULL DEFAULT 0,
+    created_at    TEXT NOT NULL DEFAULT (datetime('now')),
+    UNIQUE(table_name, column_name)
+);
+`
+
 const defaultTemplates = `
 INSERT OR IGNORE INTO auth_email_templates (id, type, subject, body_html, body_text, updated_at) VALUES
 ('tpl-confirmation', 'confirmation', 'Confirm your email',
@@ -572,6 +704,28 @@ func (db *DB) RunMigrations() error {
 		return fmt.Errorf("failed to run migration state schema migration: %w", err)
 	}
 
+	// Recreate _migration_verifications if its layer CHECK predates the rollback layer
+	var hasOldLayerCheck int
+	row = db.QueryRow(`
+		SELECT COUNT(*) FROM sqlite_master
+		WHERE type='table' AND name='_migration_verifications' AND sql NOT LIKE '%''rollback''%'
+	`)
+	if err := row.Scan(&hasOldLayerCheck); err == nil && hasOldLayerCheck > 0 {
+		if err := db.rebuildMigrationVerifications(); err != nil {
+			return fmt.Errorf("failed to migrate _migration_verifications: %w", err)
+		}
+	}
+
+	// Add webhook_url column to _migrations if it doesn't exist (for existing databases)
+	var hasWebhookURL int
+	row = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('_migrations')
+		WHERE name = 'webhook_url'
+	`)
+	if err := row.Scan(&hasWebhookURL); err == nil && hasWebhookURL == 0 {
+		_, _ = db.Exec(`ALTER TABLE _migrations ADD COLUMN webhook_url TEXT`)
+	}
+
 	// Add description column to _columns if it doesn't exist (for existing databases)
 	var hasDescription int
 	row = db.QueryRow(`
@@ -587,10 +741,122 @@ func (db *DB) RunMigrations() error {
 		}
 	}
 
+	// Add json_schema column to _columns if it doesn't exist (for existing databases)
+	var hasJSONSchema int
+	row = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('_columns')
+		WHERE name = 'json_schema'
+	`)
+	if err := row.Scan(&hasJSONSchema); err == nil && hasJSONSchema == 0 {
+		_, _ = db.Exec(`ALTER TABLE _columns ADD COLUMN json_schema TEXT`)
+	}
+
+	// Add is_hidden column to _columns if it doesn't exist (for existing databases)
+	var hasIsHidden int
+	row = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('_columns')
+		WHERE name = 'is_hidden'
+	`)
+	if err := row.Scan(&hasIsHidden); err == nil && hasIsHidden == 0 {
+		_, _ = db.Exec(`ALTER TABLE _columns ADD COLUMN is_hidden INTEGER DEFAULT 0`)
+	}
+
+	// Add ordinal column to _columns if it doesn't exist (for existing databases)
+	var hasOrdinal int
+	row = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('_columns')
+		WHERE name = 'ordinal'
+	`)
+	if err := row.Scan(&hasOrdinal); err == nil && hasOrdinal == 0 {
+		_, _ = db.Exec(`ALTER TABLE _columns ADD COLUMN ordinal INTEGER`)
+	}
+
+	// Add enum_values column to _columns if it doesn't exist (for existing databases)
+	var hasEnumValues int
+	row = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('_columns')
+		WHERE name = 'enum_values'
+	`)
+	if err := row.Scan(&hasEnumValues); err == nil && hasEnumValues == 0 {
+		_, _ = db.Exec(`ALTER TABLE _columns ADD COLUMN enum_values TEXT`)
+	}
+
+	// Add cache_max_age column to storage_buckets if it doesn't exist (for existing databases)
+	var hasCacheMaxAge int
+	row = db.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('storage_buckets')
+		WHERE name = 'cache_max_age'
+	`)
+	if err := row.Scan(&hasCacheMaxAge); err == nil && hasCacheMaxAge == 0 {
+		_, _ = db.Exec(`ALTER TABLE storage_buckets ADD COLUMN cache_max_age INTEGER`)
+	}
+
 	_, err = db.Exec(apiDocsSchema)
 	if err != nil {
 		return fmt.Errorf("failed to run API docs schema migration: %w", err)
 	}
 
+	_, err = db.Exec(integrityChecksSchema)
+	if err != nil {
+		return fmt.Errorf("failed to run integrity checks schema migration: %w", err)
+	}
+
+	_, err = db.Exec(idempotencySchema)
+	if err != nil {
+		return fmt.Errorf("failed to run idempotency schema migration: %w", err)
+	}
+
+	_, err = db.Exec(suspendedPoliciesSchema)
+	if err != nil {
+		return fmt.Errorf("failed to run suspended policies schema migration: %w", err)
+	}
+
+	_, err = db.Exec(sequencesSchema)
+	if err != nil {
+		return fmt.Errorf("failed to run sequences schema migration: %w", err)
+	}
+
+	_, err = db.Exec(schemaSnapshotsSchema)
+	if err != nil {
+		return fmt.Errorf("failed to run schema snapshots migration: %w", err)
+	}
+
+	_, err = db.Exec(storageWebhooksSchema)
+	if err != nil {
+		return fmt.Errorf("failed to run storage webhooks migration: %w", err)
+	}
+
 	return nil
 }
+
+// rebuildMigrationVerifications recreates _migration_verifications with the
+// current layer CHECK. It runs in a transaction so an interrupted rebuild
+// leaves the old table in place, and clears a leftover copy from one first.
+func (db *DB) rebuildMigrationVerifications() error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		DROP TABLE IF EXISTS _migration_verifications_new;
+		CREATE TABLE _migration_verifications_new (
+			id TEXT PRIMARY KEY,
+			migration_id TEXT NOT NULL REFERENCES _migrations(id) ON DELETE CASCADE,
+			layer TEXT NOT NULL CHECK (layer IN ('basic', 'integrity', 'functional', 'rollback')),
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'passed', 'failed')),
+			started_at TEXT,
+			completed_at TEXT,
+			results TEXT
+		);
+		INSERT INTO _migration_verifications_new SELECT * FROM _migration_verifications;
+		DROP TABLE _migration_verifications;
+		ALTER TABLE _migration_verifications_new RENAME TO _migration_verifications;
+		CREATE INDEX IF NOT EXISTS idx_migration_verifications_migration_id ON _migration_verifications(migration_id);
+	`)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -191,3 +191,54 @@ func TestDashboardTableCreated(t *testing.T) {
 		t.Errorf("expected _dashboard table to exist")
 	}
 }
+
+func TestMigrationVerificationsLayerUpgrade(t *testing.T) {
+	path := t.TempDir() + "/test.db"
+	database, err := New(path)
+	if err != nil {
+		t.Fatalf("failed to create db: %v", err)
+	}
+	defer database.Close()
+
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	// Put back the table as it was before the rollback layer, plus a copy
+	// left over from an interrupted rebuild
+	_, err = database.Exec(`
+		DROP TABLE _migration_verifications;
+		CREATE TABLE _migration_verifications (
+			id TEXT PRIMARY KEY,
+			migration_id TEXT NOT NULL REFERENCES _migrations(id) ON DELETE CASCADE,
+			layer TEXT NOT NULL CHECK (layer IN ('basic', 'integrity', 'functional')),
+			status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'passed', 'failed')),
+			started_at TEXT,
+			completed_at TEXT,
+			results TEXT
+		);
+		CREATE TABLE _migration_verifications_new (id TEXT);
+		INSERT INTO _migrations (id) VALUES ('m1');
+		INSERT INTO _migration_verifications (id, migration_id, layer) VALUES ('v1', 'm1', 'basic');
+	`)
+	if err != nil {
+		t.Fatalf("failed to set up old table: %v", err)
+	}
+
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("RunMigrations() error: %v", err)
+	}
+
+	var count int
+	database.QueryRow(`SELECT COUNT(*) FROM _migration_verifications WHERE id = 'v1'`).Scan(&count)
+	if count != 1 {
+		t.Error("expected existing verification to be kept")
+	}
+	if _, err := database.Exec(`INSERT INTO _migration_verifications (id, migration_id, layer) VALUES ('v2', 'm1', 'rollback')`); err != nil {
+		t.Errorf("expected rollback layer to be allowed: %v", err)
+	}
+	database.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = '_migration_verifications_new'`).Scan(&count)
+	if count != 0 {
+		t.Error("expected no leftover _migration_verifications_new")
+	}
+}
@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultWriteQueueSize is the maximum number of writes waiting for the writer lock.
+	DefaultWriteQueueSize = 256
+	// DefaultWriteTimeout is how long a write waits in the queue before giving up.
+	DefaultWriteTimeout = 30 * time.Second
+	// DefaultBusyTimeoutMs is the SQLite busy_timeout applied to every connection.
+	DefaultBusyTimeoutMs = 5000
+)
+
+var (
+	// ErrWriteQueueFull is returned when the write queue has no room for another write.
+	ErrWriteQueueFull = errors.New("write queue is full")
+	// ErrWriteQueueTimeout is returned when a write waited too long for its turn.
+	ErrWriteQueueTimeout = errors.New("timed out waiting for write queue")
+)
+
+// WriteQueue serializes writes to the SQLite database so concurrent mutations
+// wait their turn instead of failing with SQLITE_BUSY. Reads do not go through
+// the queue and remain concurrent (WAL mode).
+type WriteQueue struct {
+	lock      chan struct{}
+	maxSize   int
+	timeout   time.Duration
+	pending   atomic.Int64
+	processed atomic.Uint64
+	rejected  atomic.Uint64
+}
+
+// WriteQueueStats is a snapshot of the write queue state.
+type WriteQueueStats struct {
+	Depth     int    `json:"depth"`
+	MaxSize   int    `json:"max_size"`
+	TimeoutMs int64  `json:"timeout_ms"`
+	Processed uint64 `json:"processed"`
+	Rejected  uint64 `json:"rejected"`
+}
+
+// NewWriteQueue creates a write queue that admits at most maxSize waiting writes,
+// each waiting up to timeout for the writer lock.
+func NewWriteQueue(maxSize int, timeout time.Duration) *WriteQueue {
+	if maxSize <= 0 {
+		maxSize = DefaultWriteQueueSize
+	}
+	if timeout <= 0 {
+		timeout = DefaultWriteTimeout
+	}
+	return &WriteQueue{
+		lock:    make(chan struct{}, 1),
+		maxSize: maxSize,
+		timeout: timeout,
+	}
+}
+
+// Do runs fn while holding the writer lock. Writes are admitted in arrival order
+// as far as the Go scheduler allows. Returns ErrWriteQueueFull if the queue is at
+// capacity, ErrWriteQueueTimeout if the lock was not acquired in time, or the
+// context error if ctx is cancelled while waiting.
+func (q *WriteQueue) Do(ctx context.Context, fn func() error) error {
+	if q.pending.Add(1) > int64(q.maxSize) {
+		q.pending.Add(-1)
+		q.rejected.Add(1)
+		return ErrWriteQueueFull
+	}
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+
+	select {
+	case q.lock <- struct{}{}:
+	case <-ctx.Done():
+		q.pending.Add(-1)
+		q.rejected.Add(1)
+		return ctx.Err()
+	case <-timer.C:
+		q.pending.Add(-1)
+		q.rejected.Add(1)
+		return ErrWriteQueueTimeout
+	}
+
+	defer func() {
+		<-q.lock
+		q.pending.Add(-1)
+		q.processed.Add(1)
+	}()
+	return fn()
+}
+
+// Stats returns a snapshot of the queue state. Depth includes the write in progress.
+func (q *WriteQueue) Stats() WriteQueueStats {
+	return WriteQueueStats{
+		Depth:     int(q.pending.Load()),
+		MaxSize:   q.maxSize,
+		TimeoutMs: q.timeout.Milliseconds(),
+		Processed: q.processed.Load(),
+		Rejected:  q.rejected.Load(),
+	}
+}
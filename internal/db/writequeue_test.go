@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWriteQueueSerializesWrites(t *testing.T) {
+	q := NewWriteQueue(10, time.Second)
+
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := q.Do(context.Background(), func() error {
+				mu.Lock()
+				active++
+				if active > maxActive {
+					maxActive = active
+				}
+				mu.Unlock()
+				time.Sleep(5 * time.Millisecond)
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return nil
+			})
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Errorf("expected at most 1 concurrent write, got %d", maxActive)
+	}
+	stats := q.Stats()
+	if stats.Processed != 5 || stats.Depth != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestWriteQueueFull(t *testing.T) {
+	q := NewWriteQueue(1, time.Second)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go q.Do(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+
+	if err := q.Do(context.Background(), func() error { return nil }); err != ErrWriteQueueFull {
+		t.Errorf("expected ErrWriteQueueFull, got %v", err)
+	}
+	close(release)
+
+	if q.Stats().Rejected != 1 {
+		t.Errorf("expected 1 rejected write, got %d", q.Stats().Rejected)
+	}
+}
+
+func TestWriteQueueTimeout(t *testing.T) {
+	q := NewWriteQueue(10, 20*time.Millisecond)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go q.Do(context.Background(), func() error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	defer close(release)
+
+	if err := q.Do(context.Background(), func() error { return nil }); err != ErrWriteQueueTimeout {
+		t.Errorf("expected ErrWriteQueueTimeout, got %v", err)
+	}
+}
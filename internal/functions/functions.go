@@ -23,8 +23,10 @@ type Service struct {
 	baseURL      string
 	sblitePort   int
 
-	mu      sync.RWMutex
-	started bool
+	mu             sync.RWMutex
+	started        bool
+	supervisor     SupervisorStatus
+	supervisorStop chan struct{}
 }
 
 // Config holds configuration for the functions service.
@@ -123,6 +125,8 @@ func (s *Service) Start(ctx context.Context) error {
 	}
 
 	s.started = true
+	s.supervisor = SupervisorStatus{}
+	s.startSupervisorLocked()
 	return nil
 }
 
@@ -136,6 +140,7 @@ func (s *Service) Stop() error {
 	}
 
 	log.Info("stopping edge functions service")
+	s.stopSupervisorLocked()
 
 	if err := s.runtime.Stop(); err != nil {
 		return fmt.Errorf("failed to stop edge runtime: %w", err)
@@ -152,6 +157,12 @@ func (s *Service) IsRunning() bool {
 	return s.started && s.runtime.IsHealthy()
 }
 
+// CheckHealth actively probes the edge runtime's health endpoint. It catches
+// a runtime whose process is alive but no longer answering requests.
+func (s *Service) CheckHealth(ctx context.Context) HealthProbe {
+	return s.runtime.Probe(ctx)
+}
+
 // RuntimePort returns the port the edge runtime is listening on.
 func (s *Service) RuntimePort() int {
 	return s.runtime.Port()
@@ -324,6 +335,15 @@ func (s *Service) Store() *Store {
 	return s.store
 }
 
+// GetSecret returns a secret's decrypted value. It backs the dashboard's
+// explicit reveal action; ListSecrets never exposes values.
+func (s *Service) GetSecret(name string) (string, error) {
+	if s.store == nil {
+		return "", fmt.Errorf("store not initialized")
+	}
+	return s.store.GetSecret(name)
+}
+
 // SetSecret sets a secret value (requires restart to take effect).
 func (s *Service) SetSecret(name, value string) error {
 	if s.store == nil {
@@ -1,9 +1,16 @@
 package functions
 
 import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestValidateFunctionName(t *testing.T) {
@@ -306,3 +313,107 @@ func TestPlatformString(t *testing.T) {
 		t.Error("PlatformString returned invalid format")
 	}
 }
+
+func TestRuntimeProbe(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+	rm := NewRuntimeManager(RuntimeConfig{Port: port})
+
+	probe := rm.Probe(context.Background())
+	if !probe.Healthy || probe.Error != "" {
+		t.Fatalf("expected healthy probe, got %+v", probe)
+	}
+	if probe.CheckedAt.IsZero() || probe.Latency <= 0 {
+		t.Errorf("expected check time and latency, got %+v", probe)
+	}
+	if rm.LastProbe() != probe {
+		t.Errorf("expected LastProbe to return the latest probe")
+	}
+
+	healthy.Store(false)
+	if probe := rm.Probe(context.Background()); probe.Healthy || probe.Error == "" {
+		t.Errorf("expected non-200 response to be unhealthy, got %+v", probe)
+	}
+}
+
+func TestRuntimeProbeUnreachable(t *testing.T) {
+	// A listener that accepts connections but never responds, like a hung runtime
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	rm := NewRuntimeManager(RuntimeConfig{Port: ln.Addr().(*net.TCPAddr).Port})
+	start := time.Now()
+	probe := rm.Probe(context.Background())
+	if probe.Healthy {
+		t.Fatal("expected hung runtime to be unreachable")
+	}
+	if elapsed := time.Since(start); elapsed > healthProbeTimeout+time.Second {
+		t.Errorf("probe took %v, expected it to time out after %v", elapsed, healthProbeTimeout)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	tests := []struct {
+		memoryMB, timeoutMS int
+		wantErr             bool
+	}{
+		{0, 0, false},
+		{MinMemoryMB, MinTimeoutMS, false},
+		{MaxMemoryMB, MaxTimeoutMS, false},
+		{MinMemoryMB - 1, 0, true},
+		{MaxMemoryMB + 1, 0, true},
+		{0, 999, true},
+		{0, MaxTimeoutMS + 1, true},
+		{-1, 0, true},
+	}
+	for _, tt := range tests {
+		err := ValidateLimits(tt.memoryMB, tt.timeoutMS)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateLimits(%d, %d) error = %v, wantErr %v", tt.memoryMB, tt.timeoutMS, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSetLimitHeaders(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/functions/v1/hello", nil)
+	// Callers cannot raise their own limits
+	req.Header.Set(memoryLimitHeader, "4096")
+
+	timeout := setLimitHeaders(req, &FunctionMetadata{MemoryMB: 64})
+	if got := req.Header.Get(memoryLimitHeader); got != "64" {
+		t.Errorf("expected memory limit 64, got %q", got)
+	}
+	if got := req.Header.Get(timeoutLimitHeader); got != strconv.Itoa(DefaultTimeoutMS) {
+		t.Errorf("expected default timeout, got %q", got)
+	}
+	if timeout != time.Duration(DefaultTimeoutMS)*time.Millisecond {
+		t.Errorf("unexpected timeout %v", timeout)
+	}
+
+	var meta *FunctionMetadata
+	if mem, to := meta.EffectiveLimits(); mem != DefaultMemoryMB || to != DefaultTimeoutMS {
+		t.Errorf("expected defaults for missing metadata, got %d, %d", mem, to)
+	}
+}
@@ -1,12 +1,19 @@
 package functions
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/markb/sblite/internal/observability"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Handler handles HTTP requests for the functions API.
@@ -14,6 +21,7 @@ type Handler struct {
 	service   *Service
 	proxy     *FunctionsProxy
 	jwtSecret []byte
+	telemetry *observability.Telemetry
 }
 
 // NewHandler creates a new functions handler.
@@ -25,6 +33,11 @@ func NewHandler(service *Service, jwtSecret string) *Handler {
 	}
 }
 
+// SetTelemetry enables recording function invocation spans for the traces view.
+func (h *Handler) SetTelemetry(tel *observability.Telemetry) {
+	h.telemetry = tel
+}
+
 // RegisterRoutes registers the functions API routes.
 func (h *Handler) RegisterRoutes(r chi.Router) {
 	// Invocation routes - all methods supported
@@ -71,8 +84,54 @@ func (h *Handler) handleInvoke(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Proxy to edge runtime
-	h.proxy.ServeHTTP(w, r)
+	// The runtime applies the function's memory limit and timeout to its
+	// worker; the deadline also stops waiting on a worker that overruns
+	timeout := setLimitHeaders(r, meta)
+	setImportMapHeader(r, filepath.Join(h.service.FunctionsDir(), name), meta)
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// Proxy to edge runtime in a child span of the HTTP request span; the
+	// proxy forwards its context to the runtime as a traceparent header
+	parentSpanID := trace.SpanContextFromContext(r.Context()).SpanID()
+	ctx, span := otel.Tracer("sblite").Start(ctx, "function.invoke "+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(observability.AttrFunctionName.String(name)),
+	)
+	defer span.End()
+
+	start := time.Now()
+	rw := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	h.proxy.ServeHTTP(rw, r.WithContext(ctx))
+
+	span.SetAttributes(observability.AttrHTTPStatusCode.Int(rw.status))
+	if rw.status >= 500 {
+		span.SetStatus(codes.Error, http.StatusText(rw.status))
+	}
+	h.telemetry.RecordFunctionInvocation(ctx, name, rw.status, time.Since(start), parentSpanID)
+}
+
+// statusRecorder captures the status code written by the proxy.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *statusRecorder) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *statusRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Flush supports streaming function responses.
+func (rw *statusRecorder) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
 }
 
 // handleOptions handles CORS preflight requests.
@@ -0,0 +1,253 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultImportMapFile is the import map used, relative to the function's
+// directory, when its config doesn't name one.
+const DefaultImportMapFile = "import_map.json"
+
+// importMapHeader carries the function's import map file from the proxy to
+// the main service, which passes it to the function's worker.
+const importMapHeader = "X-Sblite-Import-Map"
+
+// ImportMap is a Deno import map.
+type ImportMap struct {
+	Imports map[string]string            `json:"imports"`
+	Scopes  map[string]map[string]string `json:"scopes,omitempty"`
+}
+
+// ImportSpecifier is a module specifier found in a function's source.
+type ImportSpecifier struct {
+	Specifier string   `json:"specifier"`
+	Kind      string   `json:"kind"` // npm, jsr, node, url, relative or bare
+	Files     []string `json:"files"`
+	Resolved  bool     `json:"resolved"`
+	MappedTo  string   `json:"mapped_to,omitempty"`
+}
+
+// importPattern matches the specifier of static imports and exports
+// (`import x from "a"`, `export * from "a"`, `import "a"`) and dynamic
+// imports with a literal argument.
+var importPattern = regexp.MustCompile(`(?m)(?:\bfrom\s*|\bimport\s*\(\s*|^\s*import\s*)["']([^"'\n]+)["']`)
+
+// sourceExtensions are the files scanned for imports.
+var sourceExtensions = map[string]bool{
+	".ts": true, ".tsx": true, ".mts": true,
+	".js": true, ".jsx": true, ".mjs": true,
+}
+
+// ImportMapFile returns the function's import map path relative to its
+// directory.
+func ImportMapFile(meta *FunctionMetadata) string {
+	if meta != nil && meta.ImportMap != "" {
+		return meta.ImportMap
+	}
+	return DefaultImportMapFile
+}
+
+// NormalizeImportMapPath cleans an import map path from a function config.
+// The path must be a .json file inside the function's directory. An empty
+// path is returned as-is and selects the default.
+func NormalizeImportMapPath(path string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", nil
+	}
+	clean := filepath.ToSlash(filepath.Clean(filepath.FromSlash(path)))
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("import_map must be a path inside the function directory")
+	}
+	if !strings.HasSuffix(clean, ".json") {
+		return "", fmt.Errorf("import_map must be a .json file")
+	}
+	return clean, nil
+}
+
+// LoadImportMap reads an import map from the function directory. A missing
+// file yields an empty map.
+func LoadImportMap(fnDir, file string) (*ImportMap, error) {
+	m := &ImportMap{Imports: map[string]string{}}
+	data, err := os.ReadFile(filepath.Join(fnDir, filepath.FromSlash(file)))
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid import map %s: %w", file, err)
+	}
+	if m.Imports == nil {
+		m.Imports = map[string]string{}
+	}
+	return m, nil
+}
+
+// Resolve returns the import map target for a specifier, matching an exact
+// key or the longest key ending in "/" that prefixes it.
+func (m *ImportMap) Resolve(specifier string) (string, bool) {
+	if target, ok := m.Imports[specifier]; ok {
+		return target, true
+	}
+	best := ""
+	for key := range m.Imports {
+		if strings.HasSuffix(key, "/") && strings.HasPrefix(specifier, key) && len(key) > len(best) {
+			best = key
+		}
+	}
+	if best == "" {
+		return "", false
+	}
+	return m.Imports[best] + strings.TrimPrefix(specifier, best), true
+}
+
+// specifierKind classifies a module specifier.
+func specifierKind(specifier string) string {
+	switch {
+	case strings.HasPrefix(specifier, "npm:"):
+		return "npm"
+	case strings.HasPrefix(specifier, "jsr:"):
+		return "jsr"
+	case strings.HasPrefix(specifier, "node:"):
+		return "node"
+	case strings.HasPrefix(specifier, "http://"), strings.HasPrefix(specifier, "https://"), strings.HasPrefix(specifier, "data:"):
+		return "url"
+	case strings.HasPrefix(specifier, "./"), strings.HasPrefix(specifier, "../"), strings.HasPrefix(specifier, "/"):
+		return "relative"
+	default:
+		return "bare"
+	}
+}
+
+// ScanImports lists the module specifiers imported by the function's source
+// files and whether each resolves without runtime flags: URLs, relative
+// paths and node: built-ins always do, npm:, jsr: and bare specifiers only
+// through the import map.
+func ScanImports(fnDir string, importMap *ImportMap) ([]ImportSpecifier, error) {
+	found := make(map[string]map[string]bool)
+	err := filepath.WalkDir(fnDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != fnDir && (d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !sourceExtensions[filepath.Ext(path)] {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(fnDir, path)
+		for _, match := range importPattern.FindAllStringSubmatch(string(data), -1) {
+			if found[match[1]] == nil {
+				found[match[1]] = make(map[string]bool)
+			}
+			found[match[1]][filepath.ToSlash(rel)] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]ImportSpecifier, 0, len(found))
+	for specifier, files := range found {
+		spec := ImportSpecifier{Specifier: specifier, Kind: specifierKind(specifier)}
+		for file := range files {
+			spec.Files = append(spec.Files, file)
+		}
+		sort.Strings(spec.Files)
+		if target, ok := importMap.Resolve(specifier); ok {
+			spec.Resolved, spec.MappedTo = true, target
+		} else {
+			spec.Resolved = spec.Kind == "url" || spec.Kind == "relative" || spec.Kind == "node"
+		}
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Specifier < specs[j].Specifier })
+	return specs, nil
+}
+
+// esmURL returns the esm.sh URL serving an npm: or jsr: specifier, which
+// lets the runtime load it without npm or jsr support.
+func esmURL(specifier string) (string, bool) {
+	switch {
+	case strings.HasPrefix(specifier, "npm:"):
+		pkg := strings.TrimLeft(strings.TrimPrefix(specifier, "npm:"), "/")
+		return "https://esm.sh/" + pkg, pkg != ""
+	case strings.HasPrefix(specifier, "jsr:"):
+		pkg := strings.TrimLeft(strings.TrimPrefix(specifier, "jsr:"), "/")
+		return "https://esm.sh/jsr/" + pkg, pkg != ""
+	}
+	return "", false
+}
+
+// GenerateImportMap adds an entry to the function's import map for every
+// unresolved npm: and jsr: specifier in its source, writing the file only
+// if something was added. Existing entries are kept. It returns the
+// specifiers that were added.
+func GenerateImportMap(fnDir, file string) ([]string, error) {
+	importMap, err := LoadImportMap(fnDir, file)
+	if err != nil {
+		return nil, err
+	}
+	specs, err := ScanImports(fnDir, importMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var added []string
+	for _, spec := range specs {
+		if spec.Resolved {
+			continue
+		}
+		if target, ok := esmURL(spec.Specifier); ok {
+			importMap.Imports[spec.Specifier] = target
+			added = append(added, spec.Specifier)
+		}
+	}
+	if len(added) == 0 {
+		return added, nil
+	}
+
+	data, err := json.MarshalIndent(importMap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(fnDir, filepath.FromSlash(file))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// setImportMapHeader tells the runtime which import map to load for the
+// function, if its file exists, overwriting any value from the caller.
+func setImportMapHeader(r *http.Request, fnDir string, meta *FunctionMetadata) {
+	r.Header.Del(importMapHeader)
+	file := ImportMapFile(meta)
+	if _, err := NormalizeImportMapPath(file); err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(fnDir, filepath.FromSlash(file))); err == nil {
+		r.Header.Set(importMapHeader, file)
+	}
+}
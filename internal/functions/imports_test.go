@@ -0,0 +1,132 @@
+package functions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScanImports(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "lib"), 0755)
+	os.WriteFile(filepath.Join(dir, "index.ts"), []byte(`import { z } from "npm:zod@3.22.4"
+import * as path from 'jsr:@std/path@1'
+import { serve } from "https://deno.land/std@0.168.0/http/server.ts"
+import { helper } from "./lib/helper.ts"
+import "npm:dotenv/config"
+const mod = await import("lodash")
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "lib", "helper.ts"), []byte(`export { z } from "npm:zod@3.22.4"
+import fs from "node:fs"
+`), 0644)
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte(`import x from "npm:ignored"`), 0644)
+
+	importMap := &ImportMap{Imports: map[string]string{"lodash": "https://esm.sh/lodash@4"}}
+	specs, err := ScanImports(dir, importMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]ImportSpecifier)
+	for _, spec := range specs {
+		got[spec.Specifier] = spec
+	}
+	if len(got) != 7 {
+		t.Fatalf("expected 7 specifiers, got %v", specs)
+	}
+	zod := got["npm:zod@3.22.4"]
+	if zod.Kind != "npm" || zod.Resolved || !reflect.DeepEqual(zod.Files, []string{"index.ts", "lib/helper.ts"}) {
+		t.Errorf("unexpected zod specifier %+v", zod)
+	}
+	if spec := got["jsr:@std/path@1"]; spec.Kind != "jsr" || spec.Resolved {
+		t.Errorf("unexpected jsr specifier %+v", spec)
+	}
+	if spec := got["lodash"]; spec.Kind != "bare" || !spec.Resolved || spec.MappedTo != "https://esm.sh/lodash@4" {
+		t.Errorf("expected lodash to resolve through the import map, got %+v", spec)
+	}
+	for _, resolved := range []string{"https://deno.land/std@0.168.0/http/server.ts", "./lib/helper.ts", "node:fs"} {
+		if !got[resolved].Resolved {
+			t.Errorf("expected %s to resolve", resolved)
+		}
+	}
+}
+
+func TestGenerateImportMap(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.ts"), []byte(`import { z } from "npm:zod@3"
+import * as path from "jsr:@std/path@1"
+import { x } from "npm:/already@1"
+import y from "bare-pkg"
+`), 0644)
+	os.WriteFile(filepath.Join(dir, DefaultImportMapFile),
+		[]byte(`{"imports": {"npm:/already@1": "https://example.com/already.js"}}`), 0644)
+
+	added, err := GenerateImportMap(dir, DefaultImportMapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(added, []string{"jsr:@std/path@1", "npm:zod@3"}) {
+		t.Errorf("unexpected added specifiers %v", added)
+	}
+
+	importMap, err := LoadImportMap(dir, DefaultImportMapFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"npm:zod@3":       "https://esm.sh/zod@3",
+		"jsr:@std/path@1": "https://esm.sh/jsr/@std/path@1",
+		"npm:/already@1":  "https://example.com/already.js",
+	}
+	if !reflect.DeepEqual(importMap.Imports, want) {
+		t.Errorf("unexpected import map %v", importMap.Imports)
+	}
+
+	// Running again has nothing to add; the bare specifier stays unresolved
+	added, err = GenerateImportMap(dir, DefaultImportMapFile)
+	if err != nil || len(added) != 0 {
+		t.Errorf("expected nothing added, got %v, %v", added, err)
+	}
+}
+
+func TestNormalizeImportMapPath(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{" import_map.json ", "import_map.json", false},
+		{"./config/../deno_map.json", "deno_map.json", false},
+		{"../other/import_map.json", "", true},
+		{"/etc/import_map.json", "", true},
+		{"import_map.txt", "", true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeImportMapPath(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeImportMapPath(%q) = %q, %v", tt.input, got, err)
+		}
+	}
+}
+
+func TestSetImportMapHeader(t *testing.T) {
+	dir := t.TempDir()
+	req := httptest.NewRequest(http.MethodPost, "/functions/v1/hello", nil)
+	req.Header.Set(importMapHeader, "../../secrets.json")
+
+	// Without the file, the caller's value is dropped
+	setImportMapHeader(req, dir, &FunctionMetadata{})
+	if got := req.Header.Get(importMapHeader); got != "" {
+		t.Errorf("expected no import map header, got %q", got)
+	}
+
+	os.WriteFile(filepath.Join(dir, DefaultImportMapFile), []byte(`{"imports": {}}`), 0644)
+	setImportMapHeader(req, dir, &FunctionMetadata{})
+	if got := req.Header.Get(importMapHeader); got != DefaultImportMapFile {
+		t.Errorf("expected %s, got %q", DefaultImportMapFile, got)
+	}
+}
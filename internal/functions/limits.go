@@ -0,0 +1,64 @@
+package functions
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Per-function resource limits. A zero MemoryMB or TimeoutMS in
+// FunctionMetadata means the default applies.
+const (
+	MinMemoryMB     = 32
+	MaxMemoryMB     = 512
+	DefaultMemoryMB = 150
+
+	MinTimeoutMS     = 1000
+	MaxTimeoutMS     = 300000
+	DefaultTimeoutMS = 300000
+)
+
+// Headers carrying a function's limits from the proxy to the main service,
+// which applies them when creating the function's worker.
+const (
+	memoryLimitHeader  = "X-Sblite-Memory-Limit-Mb"
+	timeoutLimitHeader = "X-Sblite-Timeout-Ms"
+)
+
+// ValidateLimits checks configured limits against the allowed ranges.
+// Zero values are accepted and reset the limit to its default.
+func ValidateLimits(memoryMB, timeoutMS int) error {
+	if memoryMB != 0 && (memoryMB < MinMemoryMB || memoryMB > MaxMemoryMB) {
+		return fmt.Errorf("memory_mb must be between %d and %d", MinMemoryMB, MaxMemoryMB)
+	}
+	if timeoutMS != 0 && (timeoutMS < MinTimeoutMS || timeoutMS > MaxTimeoutMS) {
+		return fmt.Errorf("timeout_ms must be between %d and %d", MinTimeoutMS, MaxTimeoutMS)
+	}
+	return nil
+}
+
+// EffectiveLimits returns the memory limit in MB and timeout in milliseconds
+// applied to the function, substituting defaults for unset values.
+func (m *FunctionMetadata) EffectiveLimits() (memoryMB, timeoutMS int) {
+	memoryMB, timeoutMS = DefaultMemoryMB, DefaultTimeoutMS
+	if m == nil {
+		return memoryMB, timeoutMS
+	}
+	if m.MemoryMB > 0 {
+		memoryMB = m.MemoryMB
+	}
+	if m.TimeoutMS > 0 {
+		timeoutMS = m.TimeoutMS
+	}
+	return memoryMB, timeoutMS
+}
+
+// setLimitHeaders passes the function's effective limits to the runtime,
+// overwriting any values supplied by the caller.
+func setLimitHeaders(r *http.Request, meta *FunctionMetadata) time.Duration {
+	memoryMB, timeoutMS := meta.EffectiveLimits()
+	r.Header.Set(memoryLimitHeader, strconv.Itoa(memoryMB))
+	r.Header.Set(timeoutLimitHeader, strconv.Itoa(timeoutMS))
+	return time.Duration(timeoutMS) * time.Millisecond
+}
@@ -12,6 +12,11 @@ const mainServiceTemplate = `// sblite edge functions main router
 
 const FUNCTIONS_PATH = Deno.env.get("SBLITE_FUNCTIONS_PATH") || "/functions";
 
+function parseLimit(value: string | null, fallback: number): number {
+  const n = Number(value);
+  return Number.isInteger(n) && n > 0 ? n : fallback;
+}
+
 Deno.serve(async (req: Request) => {
   const url = new URL(req.url);
   const pathname = url.pathname;
@@ -64,13 +69,25 @@ Deno.serve(async (req: Request) => {
       }
     }
 
+    // Per-function limits are set by sblite on every proxied request
+    const memoryLimitMb = parseLimit(req.headers.get("` + memoryLimitHeader + `"), 150);
+    const workerTimeoutMs = parseLimit(req.headers.get("` + timeoutLimitHeader + `"), 5 * 60 * 1000);
+    const headers = new Headers(req.headers);
+    headers.delete("` + memoryLimitHeader + `");
+    headers.delete("` + timeoutLimitHeader + `");
+
+    // The function's import map, relative to its directory, if it has one
+    const importMap = req.headers.get("` + importMapHeader + `");
+    headers.delete("` + importMapHeader + `");
+    const importMapPath = importMap ? ` + "`${servicePath}/${importMap}`" + ` : null;
+
     // Create or reuse worker for this function
     const worker = await EdgeRuntime.userWorkers.create({
       servicePath,
-      memoryLimitMb: 150,
-      workerTimeoutMs: 5 * 60 * 1000, // 5 minutes
+      memoryLimitMb,
+      workerTimeoutMs,
       noModuleCache: false,
-      importMapPath: null,
+      importMapPath,
       envVars: Object.entries(Deno.env.toObject()),
       forceCreate: false,
       netAccessDisabled: false,
@@ -80,7 +97,7 @@ Deno.serve(async (req: Request) => {
 
     // Forward to worker with the original request
     // The worker will handle the request based on pathname
-    const response = await worker.fetch(req);
+    const response = await worker.fetch(new Request(req, { headers }));
     return response;
   } catch (error) {
     console.error(` + "`Error invoking function '${functionName}':`, error);" + `
@@ -1,7 +1,9 @@
 package functions
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httputil"
@@ -9,6 +11,8 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // FunctionsProxy proxies requests to the edge runtime.
@@ -38,6 +42,9 @@ func NewFunctionsProxy(runtimePort int) *FunctionsProxy {
 			req.URL.Path = "/"
 			req.URL.RawPath = ""
 		}
+		// Forward the trace context (W3C traceparent) so the function's work
+		// joins the caller's trace
+		otel.GetTextMapPropagator().Inject(req.Context(), propagation.HeaderCarrier(req.Header))
 	}
 
 	proxy.ModifyResponse = modifyProxyResponse
@@ -92,7 +99,7 @@ func handleProxyError(w http.ResponseWriter, r *http.Request, err error) {
 
 	if strings.Contains(err.Error(), "connection refused") {
 		message = "Edge runtime is not running"
-	} else if strings.Contains(err.Error(), "timeout") {
+	} else if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "timeout") {
 		statusCode = http.StatusGatewayTimeout
 		message = "Function execution timed out"
 	}
@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestFunctionsProxyPropagatesTraceContext(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(prev)
+
+	var gotPath, gotTraceparent string
+	runtime := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotTraceparent = r.Header.Get("traceparent")
+	}))
+	defer runtime.Close()
+
+	u, _ := url.Parse(runtime.URL)
+	port, _ := strconv.Atoi(u.Port())
+	proxy := NewFunctionsProxy(port)
+
+	tp := sdktrace.NewTracerProvider()
+	ctx, span := tp.Tracer("test").Start(t.Context(), "request")
+	defer span.End()
+
+	req := httptest.NewRequest("POST", "/functions/v1/hello", nil).WithContext(ctx)
+	proxy.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotPath != "/hello" {
+		t.Errorf("expected path /hello, got %q", gotPath)
+	}
+	traceID := span.SpanContext().TraceID().String()
+	if !strings.Contains(gotTraceparent, traceID) {
+		t.Errorf("expected traceparent with trace ID %s, got %q", traceID, gotTraceparent)
+	}
+}
@@ -36,6 +36,18 @@ type RuntimeConfig struct {
 	Secrets map[string]string
 }
 
+// healthProbeTimeout bounds a single request to the runtime's health
+// endpoint, so a hung runtime is reported as unreachable.
+const healthProbeTimeout = 2 * time.Second
+
+// HealthProbe is the result of one request to the runtime's health endpoint.
+type HealthProbe struct {
+	Healthy   bool
+	CheckedAt time.Time
+	Latency   time.Duration
+	Error     string
+}
+
 // RuntimeManager manages the edge runtime subprocess.
 type RuntimeManager struct {
 	config       RuntimeConfig
@@ -43,8 +55,12 @@ type RuntimeManager struct {
 	process      *exec.Cmd
 	processLock  sync.Mutex
 	healthy      bool
+	lastProbe    HealthProbe
 	healthTicker *time.Ticker
 	stopCh       chan struct{}
+	// exited is closed when the current process exits, after exitErr is set.
+	exited  chan struct{}
+	exitErr error
 }
 
 // NewRuntimeManager creates a new runtime manager.
@@ -120,14 +136,23 @@ func (rm *RuntimeManager) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to start edge runtime: %w", err)
 	}
 
+	// Reap the process once; Stop and the service supervisor wait on exited
+	exited := make(chan struct{})
+	rm.exited = exited
+	rm.exitErr = nil
+	go func(cmd *exec.Cmd) {
+		rm.exitErr = cmd.Wait()
+		close(exited)
+	}(rm.process)
+
 	// Start health check goroutine
 	rm.stopCh = make(chan struct{})
 	rm.healthTicker = time.NewTicker(5 * time.Second)
-	go rm.healthCheckLoop()
+	go rm.healthCheckLoop(rm.healthTicker, rm.stopCh)
 
 	// Wait for runtime to be ready
 	if err := rm.waitForReady(ctx, 30*time.Second); err != nil {
-		rm.Stop()
+		rm.stopLocked()
 		return fmt.Errorf("edge runtime failed to start: %w", err)
 	}
 
@@ -140,7 +165,12 @@ func (rm *RuntimeManager) Start(ctx context.Context) error {
 func (rm *RuntimeManager) Stop() error {
 	rm.processLock.Lock()
 	defer rm.processLock.Unlock()
+	return rm.stopLocked()
+}
 
+// stopLocked stops the process; the caller holds processLock. It also cleans
+// up after a process that has already exited.
+func (rm *RuntimeManager) stopLocked() error {
 	if rm.process == nil {
 		return nil
 	}
@@ -163,13 +193,8 @@ func (rm *RuntimeManager) Stop() error {
 	}
 
 	// Wait with timeout
-	done := make(chan error, 1)
-	go func() {
-		done <- rm.process.Wait()
-	}()
-
 	select {
-	case <-done:
+	case <-rm.exited:
 		// Process exited cleanly
 	case <-time.After(5 * time.Second):
 		// Force kill
@@ -177,7 +202,7 @@ func (rm *RuntimeManager) Stop() error {
 		if rm.process.Process != nil {
 			rm.process.Process.Kill()
 		}
-		<-done
+		<-rm.exited
 	}
 
 	rm.process = nil
@@ -201,6 +226,62 @@ func (rm *RuntimeManager) IsHealthy() bool {
 	return rm.healthy
 }
 
+// Exited returns a channel that is closed when the current runtime process
+// exits, or nil if no process has been started.
+func (rm *RuntimeManager) Exited() <-chan struct{} {
+	rm.processLock.Lock()
+	defer rm.processLock.Unlock()
+	return rm.exited
+}
+
+// ExitErr returns the error the last process exited with. It is only
+// meaningful once the channel returned by Exited is closed.
+func (rm *RuntimeManager) ExitErr() error {
+	rm.processLock.Lock()
+	defer rm.processLock.Unlock()
+	return rm.exitErr
+}
+
+// Probe sends a request to the runtime's health endpoint and reports whether
+// it answered with 200 OK within healthProbeTimeout. Unlike IsHealthy, which
+// reflects the last periodic check, Probe checks the runtime now.
+func (rm *RuntimeManager) Probe(ctx context.Context) HealthProbe {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	probe := HealthProbe{CheckedAt: time.Now().UTC()}
+	healthURL := fmt.Sprintf("http://127.0.0.1:%d/health", rm.config.Port)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, healthURL, nil)
+	if err == nil {
+		var resp *http.Response
+		resp, err = http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = fmt.Errorf("health endpoint returned status %d", resp.StatusCode)
+			}
+		}
+	}
+	probe.Latency = time.Since(probe.CheckedAt)
+	probe.Healthy = err == nil
+	if err != nil {
+		probe.Error = err.Error()
+	}
+
+	rm.processLock.Lock()
+	rm.lastProbe = probe
+	rm.processLock.Unlock()
+	return probe
+}
+
+// LastProbe returns the most recent health probe, or a zero HealthProbe if
+// the runtime has not been probed yet.
+func (rm *RuntimeManager) LastProbe() HealthProbe {
+	rm.processLock.Lock()
+	defer rm.processLock.Unlock()
+	return rm.lastProbe
+}
+
 // Port returns the port the runtime is listening on.
 func (rm *RuntimeManager) Port() int {
 	return rm.config.Port
@@ -335,20 +416,15 @@ func (rm *RuntimeManager) waitForReady(ctx context.Context, timeout time.Duratio
 	return fmt.Errorf("timeout waiting for edge runtime to be ready")
 }
 
-// healthCheckLoop runs periodic health checks.
-func (rm *RuntimeManager) healthCheckLoop() {
-	healthURL := fmt.Sprintf("http://127.0.0.1:%d/health", rm.config.Port)
-
+// healthCheckLoop runs periodic health checks until stopCh is closed. The
+// ticker and channel are passed in because Stop clears the fields.
+func (rm *RuntimeManager) healthCheckLoop(ticker *time.Ticker, stopCh <-chan struct{}) {
 	for {
 		select {
-		case <-rm.stopCh:
+		case <-stopCh:
 			return
-		case <-rm.healthTicker.C:
-			resp, err := http.Get(healthURL)
-			healthy := err == nil && resp != nil && resp.StatusCode == http.StatusOK
-			if resp != nil {
-				resp.Body.Close()
-			}
+		case <-ticker.C:
+			healthy := rm.Probe(context.Background()).Healthy
 
 			rm.processLock.Lock()
 			wasHealthy := rm.healthy
@@ -0,0 +1,143 @@
+package functions
+
+import (
+	"context"
+	"time"
+
+	"github.com/markb/sblite/internal/log"
+)
+
+// supervisorMaxRestarts is how many consecutive restarts are attempted after
+// the runtime crashes before the supervisor gives up.
+const supervisorMaxRestarts = 5
+
+// The supervisor waits supervisorBaseDelay before the first restart, doubling
+// up to supervisorMaxDelay. A runtime that stays up for supervisorStablePeriod
+// resets the consecutive restart count. They are variables so tests can
+// shorten them.
+var (
+	supervisorBaseDelay    = time.Second
+	supervisorMaxDelay     = 30 * time.Second
+	supervisorStablePeriod = time.Minute
+)
+
+// SupervisorStatus reports automatic restarts of the edge runtime.
+type SupervisorStatus struct {
+	// Restarts counts restarts after crashes since the service was started.
+	Restarts int
+	// LastRestartAt is when the last restart was attempted, zero if none.
+	LastRestartAt time.Time
+	// LastExitError describes why the runtime last exited unexpectedly.
+	LastExitError string
+	// GaveUp is set once supervisorMaxRestarts consecutive restarts failed to
+	// keep the runtime up; the service then stays down until restarted.
+	GaveUp bool
+}
+
+// SupervisorStatus returns the current restart statistics.
+func (s *Service) SupervisorStatus() SupervisorStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.supervisor
+}
+
+// startSupervisorLocked watches the running runtime and restarts it if it
+// exits unexpectedly. The caller holds s.mu.
+func (s *Service) startSupervisorLocked() {
+	stop := make(chan struct{})
+	s.supervisorStop = stop
+	go s.supervise(stop, s.runtime.Exited(), time.Now())
+}
+
+// stopSupervisorLocked stops the supervisor before an intentional shutdown.
+// The caller holds s.mu.
+func (s *Service) stopSupervisorLocked() {
+	if s.supervisorStop != nil {
+		close(s.supervisorStop)
+		s.supervisorStop = nil
+	}
+}
+
+// supervise restarts the runtime with exponential backoff each time it exits,
+// until stop is closed or supervisorMaxRestarts consecutive restarts fail.
+func (s *Service) supervise(stop <-chan struct{}, exited <-chan struct{}, startedAt time.Time) {
+	consecutive := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-exited:
+		}
+
+		exitMsg := "exited"
+		if err := s.runtime.ExitErr(); err != nil {
+			exitMsg = err.Error()
+		}
+		if time.Since(startedAt) >= supervisorStablePeriod {
+			consecutive = 0
+		}
+
+		for {
+			consecutive++
+			if consecutive > supervisorMaxRestarts {
+				s.giveUp(stop, exitMsg)
+				return
+			}
+
+			delay := supervisorBaseDelay << (consecutive - 1)
+			if delay > supervisorMaxDelay || delay <= 0 {
+				delay = supervisorMaxDelay
+			}
+			log.Warn("edge runtime exited unexpectedly, restarting",
+				"error", exitMsg, "attempt", consecutive, "max_attempts", supervisorMaxRestarts, "delay", delay.String())
+
+			select {
+			case <-stop:
+				return
+			case <-time.After(delay):
+			}
+
+			s.mu.Lock()
+			select {
+			case <-stop:
+				s.mu.Unlock()
+				return
+			default:
+			}
+			s.runtime.Stop()
+			err := s.runtime.Start(context.Background())
+			s.supervisor.Restarts++
+			s.supervisor.LastRestartAt = time.Now().UTC()
+			s.supervisor.LastExitError = exitMsg
+			s.mu.Unlock()
+
+			if err == nil {
+				log.Info("edge runtime restarted", "restarts", s.SupervisorStatus().Restarts)
+				exited = s.runtime.Exited()
+				startedAt = time.Now()
+				break
+			}
+			exitMsg = err.Error()
+			log.Warn("edge runtime restart failed", "error", exitMsg, "attempt", consecutive)
+		}
+	}
+}
+
+// giveUp marks the service down after repeated crashes.
+func (s *Service) giveUp(stop <-chan struct{}, exitMsg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	select {
+	case <-stop:
+		return
+	default:
+	}
+
+	log.Error("edge runtime keeps crashing, giving up on automatic restarts",
+		"restarts", s.supervisor.Restarts, "error", exitMsg)
+	s.runtime.Stop()
+	s.supervisor.GaveUp = true
+	s.supervisor.LastExitError = exitMsg
+	s.supervisorStop = nil
+	s.started = false
+}
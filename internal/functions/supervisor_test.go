@@ -0,0 +1,137 @@
+package functions
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary stand in for the edge runtime: with
+// SBLITE_FAKE_EDGE_RUNTIME=1 it serves /health on --port and, if
+// SBLITE_FAKE_EDGE_RUNTIME_LIFETIME is set, crashes after that long.
+func TestMain(m *testing.M) {
+	if os.Getenv("SBLITE_FAKE_EDGE_RUNTIME") == "1" {
+		runFakeRuntime()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func runFakeRuntime() {
+	var port string
+	for i, arg := range os.Args {
+		if arg == "--port" && i+1 < len(os.Args) {
+			port = os.Args[i+1]
+		}
+	}
+	go http.ListenAndServe("127.0.0.1:"+port, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	if lifetime, err := time.ParseDuration(os.Getenv("SBLITE_FAKE_EDGE_RUNTIME_LIFETIME")); err == nil && lifetime > 0 {
+		time.Sleep(lifetime)
+		os.Exit(1)
+	}
+	select {}
+}
+
+func newFakeRuntimeService(t *testing.T, lifetime time.Duration) *Service {
+	t.Helper()
+	t.Setenv("SBLITE_FAKE_EDGE_RUNTIME", "1")
+	t.Setenv("SBLITE_FAKE_EDGE_RUNTIME_LIFETIME", lifetime.String())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	svc, err := NewService(nil, &Config{FunctionsDir: t.TempDir(), RuntimePort: port})
+	if err != nil {
+		t.Fatalf("NewService failed: %v", err)
+	}
+	svc.runtime.config.BinaryPath = os.Args[0]
+	return svc
+}
+
+func shortenSupervisorDelays(t *testing.T, stable time.Duration) {
+	oldBase, oldMax, oldStable := supervisorBaseDelay, supervisorMaxDelay, supervisorStablePeriod
+	supervisorBaseDelay, supervisorMaxDelay, supervisorStablePeriod = 10*time.Millisecond, 50*time.Millisecond, stable
+	t.Cleanup(func() {
+		supervisorBaseDelay, supervisorMaxDelay, supervisorStablePeriod = oldBase, oldMax, oldStable
+	})
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("condition not met before timeout")
+}
+
+func TestSupervisorRestartsCrashedRuntime(t *testing.T) {
+	// Runs that last longer than the stable period reset the backoff, so a
+	// runtime that crashes occasionally is restarted indefinitely
+	shortenSupervisorDelays(t, 100*time.Millisecond)
+	svc := newFakeRuntimeService(t, 400*time.Millisecond)
+
+	if err := svc.Start(t.Context()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer svc.Stop()
+
+	waitFor(t, 10*time.Second, func() bool { return svc.SupervisorStatus().Restarts >= 2 })
+	waitFor(t, 5*time.Second, svc.IsRunning)
+
+	status := svc.SupervisorStatus()
+	if status.GaveUp {
+		t.Error("expected supervisor to keep restarting a runtime that stays up between crashes")
+	}
+	if status.LastRestartAt.IsZero() || status.LastExitError == "" {
+		t.Errorf("expected restart details, got %+v", status)
+	}
+}
+
+func TestSupervisorGivesUpAfterRepeatedCrashes(t *testing.T) {
+	shortenSupervisorDelays(t, time.Hour)
+	svc := newFakeRuntimeService(t, 150*time.Millisecond)
+
+	if err := svc.Start(t.Context()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer svc.Stop()
+
+	waitFor(t, 20*time.Second, func() bool { return svc.SupervisorStatus().GaveUp })
+
+	if got := svc.SupervisorStatus().Restarts; got != supervisorMaxRestarts {
+		t.Errorf("expected %d restarts before giving up, got %d", supervisorMaxRestarts, got)
+	}
+	if svc.IsRunning() {
+		t.Error("expected service to be down after giving up")
+	}
+}
+
+func TestSupervisorStopsOnIntentionalStop(t *testing.T) {
+	shortenSupervisorDelays(t, time.Hour)
+	svc := newFakeRuntimeService(t, 0)
+
+	if err := svc.Start(t.Context()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := svc.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if status := svc.SupervisorStatus(); status.Restarts != 0 {
+		t.Errorf("expected no restarts after an intentional stop, got %+v", status)
+	}
+}
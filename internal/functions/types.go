@@ -10,6 +10,14 @@ type FunctionInfo struct {
 	Status     string    `json:"status,omitempty"`
 	VerifyJWT  bool      `json:"verify_jwt"`
 	ModTime    time.Time `json:"mod_time"`
+	// MemoryMB and TimeoutMS are the effective limits, defaults included
+	MemoryMB  int `json:"memory_mb,omitempty"`
+	TimeoutMS int `json:"timeout_ms,omitempty"`
+	// MaxFileSize and MaxFiles are the dashboard editor's write limits;
+	// FileCount is the number of files the function holds
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+	MaxFiles    int   `json:"max_files,omitempty"`
+	FileCount   int   `json:"file_count,omitempty"`
 }
 
 // FunctionInvokeRequest represents a function invocation request.
@@ -0,0 +1,44 @@
+package log
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+)
+
+// Entry is a log record parsed from a line written in the json format.
+type Entry struct {
+	Timestamp string         `json:"timestamp"`
+	Level     string         `json:"level"`
+	Message   string         `json:"message"`
+	Fields    map[string]any `json:"fields,omitempty"`
+}
+
+// ParseJSONLine parses a line written by the json format. It returns false
+// for text-format or otherwise unstructured lines.
+func ParseJSONLine(line string) (*Entry, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal([]byte(line), &record); err != nil {
+		return nil, false
+	}
+	msg, ok := record[slog.MessageKey].(string)
+	if !ok {
+		return nil, false
+	}
+
+	entry := &Entry{Message: msg}
+	entry.Timestamp, _ = record[slog.TimeKey].(string)
+	entry.Level, _ = record[slog.LevelKey].(string)
+	delete(record, slog.TimeKey)
+	delete(record, slog.LevelKey)
+	delete(record, slog.MessageKey)
+	if len(record) > 0 {
+		entry.Fields = record
+	}
+	return entry, true
+}
@@ -0,0 +1,55 @@
+// internal/log/entry_test.go
+package log
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseJSONLine_FileHandlerOutput(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "test.log")
+	cfg := &Config{FilePath: logPath, Format: "json", MaxSizeMB: 1}
+
+	h, err := NewFileHandler(cfg, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("NewFileHandler: %v", err)
+	}
+	slog.New(h).Warn("disk almost full", "free_mb", 12, slog.Group("req", "id", "abc"))
+	h.Close()
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	line := strings.TrimSpace(string(data))
+
+	entry, ok := ParseJSONLine(line)
+	if !ok {
+		t.Fatalf("expected %q to parse as a JSON log line", line)
+	}
+	if entry.Message != "disk almost full" || entry.Level != "WARN" || entry.Timestamp == "" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.Fields["free_mb"] != float64(12) {
+		t.Errorf("expected free_mb field, got %v", entry.Fields)
+	}
+	if group, _ := entry.Fields["req"].(map[string]any); group["id"] != "abc" {
+		t.Errorf("expected nested req.id field, got %v", entry.Fields)
+	}
+}
+
+func TestParseJSONLine_Unstructured(t *testing.T) {
+	for _, line := range []string{
+		`time=2026-01-01T00:00:00Z level=INFO msg="text format"`,
+		`{"not":"a log record"}`,
+		`{broken`,
+		``,
+	} {
+		if _, ok := ParseJSONLine(line); ok {
+			t.Errorf("ParseJSONLine(%q) = true, want false", line)
+		}
+	}
+}
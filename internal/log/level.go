@@ -0,0 +1,70 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// minLevel is the minimum level written to any backend. It is applied before
+// records reach the console, file, database or buffer, and can be changed at
+// runtime with SetLevel.
+var minLevel = new(slog.LevelVar)
+
+// SetLevel changes the minimum log level. It takes effect immediately for all
+// loggers, including ones created earlier with With.
+func SetLevel(level string) error {
+	switch strings.ToLower(level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+	minLevel.Set(ParseLevel(level))
+	return nil
+}
+
+// GetLevel returns the current minimum log level name.
+func GetLevel() string {
+	return LevelName(minLevel.Level())
+}
+
+// LevelName returns the config name for a level: "debug", "info", "warn" or "error".
+func LevelName(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	default:
+		return "error"
+	}
+}
+
+// levelHandler drops records below a dynamic minimum level before they reach
+// the wrapped handler.
+type levelHandler struct {
+	level   slog.Leveler
+	wrapped slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.wrapped.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < h.level.Level() {
+		return nil
+	}
+	return h.wrapped.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, wrapped: h.wrapped.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, wrapped: h.wrapped.WithGroup(name)}
+}
@@ -28,22 +28,28 @@ type Config struct {
 
 	// Buffer-specific
 	BufferLines int // In-memory buffer size (0 to disable)
+
+	// Access log
+	AccessLogLevel      string   // Level for successful requests, or "off" (default: info)
+	AccessLogSkipStatic bool     // Don't log successful static asset requests
+	AccessLogExclude    []string // Path prefixes whose successful requests aren't logged
 }
 
 // DefaultConfig returns the default logging configuration.
 func DefaultConfig() *Config {
 	return &Config{
-		Mode:          "console",
-		Level:         "info",
-		Format:        "text",
-		FilePath:      "sblite.log",
-		MaxSizeMB:     100,
-		MaxAgeDays:    7,
-		MaxBackups:    3,
-		DBPath:        "log.db",
-		RetentionDays: 7,
-		Fields:        []string{},
-		BufferLines:   500,
+		Mode:           "console",
+		Level:          "info",
+		Format:         "text",
+		FilePath:       "sblite.log",
+		MaxSizeMB:      100,
+		MaxAgeDays:     7,
+		MaxBackups:     3,
+		DBPath:         "log.db",
+		RetentionDays:  7,
+		Fields:         []string{},
+		BufferLines:    500,
+		AccessLogLevel: "info",
 	}
 }
 
@@ -74,8 +80,11 @@ func Init(cfg *Config) error {
 	mu.Lock()
 	defer mu.Unlock()
 
+	// Backends accept every level; the minimum level is applied once, in
+	// front of them, so that SetLevel can change it at runtime.
 	var handler slog.Handler
-	level := ParseLevel(cfg.Level)
+	minLevel.Set(ParseLevel(cfg.Level))
+	level := slog.LevelDebug
 
 	switch cfg.Mode {
 	case "file":
@@ -102,8 +111,15 @@ func Init(cfg *Config) error {
 		logBuffer = nil
 	}
 
-	defaultLogger = slog.New(handler)
+	defaultLogger = slog.New(&levelHandler{level: minLevel, wrapped: handler})
 	slog.SetDefault(defaultLogger)
+
+	accessLog = AccessLogConfig{
+		Level:        ParseLevel(cfg.AccessLogLevel),
+		Disabled:     strings.EqualFold(cfg.AccessLogLevel, "off"),
+		SkipStatic:   cfg.AccessLogSkipStatic,
+		ExcludePaths: cfg.AccessLogExclude,
+	}
 	return nil
 }
 
@@ -75,3 +75,41 @@ func TestInit_BufferDisabled(t *testing.T) {
 		t.Error("expected nil when buffer disabled")
 	}
 }
+
+func TestSetLevel_FiltersBeforeBuffer(t *testing.T) {
+	cfg := &Config{
+		Mode:        "console",
+		Level:       "warn",
+		BufferLines: 100,
+	}
+	if err := Init(cfg); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	defer SetLevel("info")
+
+	if got := GetLevel(); got != "warn" {
+		t.Errorf("GetLevel() = %q, want warn", got)
+	}
+
+	// Loggers created before the change follow it too
+	logger := With("component", "test")
+	logger.Info("dropped info message")
+	if total, _, _ := GetBufferStats(); total != 0 {
+		t.Errorf("expected info to be dropped at warn level, buffer has %d lines", total)
+	}
+
+	if err := SetLevel("debug"); err != nil {
+		t.Fatalf("SetLevel failed: %v", err)
+	}
+	logger.Debug("kept debug message")
+	if total, _, _ := GetBufferStats(); total != 1 {
+		t.Errorf("expected debug to be buffered after SetLevel, buffer has %d lines", total)
+	}
+
+	if err := SetLevel("verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+	if got := GetLevel(); got != "debug" {
+		t.Errorf("invalid level changed GetLevel() to %q", got)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -50,7 +52,77 @@ func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	return nil, nil, http.ErrNotSupported
 }
 
-// RequestLogger returns middleware that logs HTTP requests.
+// AccessLogConfig controls the access log written by RequestLogger.
+// The zero value logs every request at info level.
+type AccessLogConfig struct {
+	// Level is used for successful requests; 4xx and 5xx responses are
+	// always logged at warn and error.
+	Level slog.Level
+	// Disabled stops logging successful requests.
+	Disabled bool
+	// SkipStatic stops logging successful requests for static assets.
+	SkipStatic bool
+	// ExcludePaths are path prefixes whose successful requests are not logged.
+	ExcludePaths []string
+}
+
+var accessLog AccessLogConfig
+
+// SetAccessLogConfig replaces the access log configuration.
+func SetAccessLogConfig(cfg AccessLogConfig) {
+	mu.Lock()
+	defer mu.Unlock()
+	accessLog = cfg
+}
+
+func getAccessLogConfig() AccessLogConfig {
+	mu.RLock()
+	defer mu.RUnlock()
+	return accessLog
+}
+
+// staticAssetPrefixes are routes that only serve static files.
+var staticAssetPrefixes = []string{"/_/static/", "/_/assets/"}
+
+// staticAssetExtensions are file extensions treated as static assets.
+var staticAssetExtensions = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true, ".ico": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".webp": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".otf": true,
+}
+
+// isStaticAsset reports whether r requests a static file.
+func isStaticAsset(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	for _, prefix := range staticAssetPrefixes {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return staticAssetExtensions[strings.ToLower(path.Ext(r.URL.Path))]
+}
+
+// skip reports whether a request that completed with status should be left
+// out of the access log. Failed requests are always logged.
+func (c AccessLogConfig) skip(r *http.Request, status int) bool {
+	if status >= 400 {
+		return false
+	}
+	if c.Disabled || (c.SkipStatic && isStaticAsset(r)) {
+		return true
+	}
+	for _, prefix := range c.ExcludePaths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequestLogger returns middleware that writes an access log line for each
+// HTTP request through the configured log sink, as set by SetAccessLogConfig.
 func RequestLogger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -70,8 +142,13 @@ func RequestLogger(next http.Handler) http.Handler {
 		// Calculate duration
 		duration := time.Since(start)
 
+		cfg := getAccessLogConfig()
+		if cfg.skip(r, ww.status) {
+			return
+		}
+
 		// Determine log level based on status
-		level := slog.LevelInfo
+		level := cfg.Level
 		if ww.status >= 500 {
 			level = slog.LevelError
 		} else if ww.status >= 400 {
@@ -95,3 +95,49 @@ func TestGetRequestID(t *testing.T) {
 
 	wrapped.ServeHTTP(rec, req)
 }
+
+func TestRequestLogger_AccessLogConfig(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := &Config{Format: "text"}
+	slog.SetDefault(slog.New(NewConsoleHandler(&buf, cfg, slog.LevelInfo)))
+	defer SetAccessLogConfig(AccessLogConfig{})
+
+	wrapped := RequestLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail.js" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	serve := func(path string) string {
+		buf.Reset()
+		wrapped.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		return buf.String()
+	}
+
+	SetAccessLogConfig(AccessLogConfig{SkipStatic: true, ExcludePaths: []string{"/health"}})
+	if out := serve("/_/static/app.js"); out != "" {
+		t.Errorf("expected static asset to be skipped, got %q", out)
+	}
+	if out := serve("/public/logo.png"); out != "" {
+		t.Errorf("expected static file to be skipped, got %q", out)
+	}
+	if out := serve("/health"); out != "" {
+		t.Errorf("expected excluded path to be skipped, got %q", out)
+	}
+	if out := serve("/fail.js"); !strings.Contains(out, "status=404") {
+		t.Errorf("expected failed request to be logged, got %q", out)
+	}
+	if out := serve("/rest/v1/items"); !strings.Contains(out, "level=INFO") {
+		t.Errorf("expected request to be logged at INFO, got %q", out)
+	}
+
+	// Below the handler's level, successful requests disappear
+	SetAccessLogConfig(AccessLogConfig{Level: slog.LevelDebug})
+	if out := serve("/rest/v1/items"); out != "" {
+		t.Errorf("expected debug access log to be filtered, got %q", out)
+	}
+
+	SetAccessLogConfig(AccessLogConfig{Disabled: true})
+	if out := serve("/rest/v1/items"); out != "" {
+		t.Errorf("expected access log to be disabled, got %q", out)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,11 +24,20 @@ type CaughtEmail struct {
 	UserID    string         `json:"user_id,omitempty"`
 	CreatedAt time.Time      `json:"created_at"`
 	Metadata  map[string]any `json:"metadata,omitempty"`
+	// Snippet is set on search results: the best matching passage with
+	// matches wrapped in <mark></mark>.
+	Snippet string `json:"snippet,omitempty"`
 }
 
 // CatchMailer stores emails in the database for local development.
 type CatchMailer struct {
 	db *db.DB
+
+	indexOnce sync.Once
+	indexErr  error
+
+	retentionMu sync.Mutex
+	retention   Retention
 }
 
 // NewCatchMailer creates a new CatchMailer.
@@ -54,6 +64,9 @@ func (m *CatchMailer) Send(ctx context.Context, msg *Message) error {
 		metadataJSON = &s
 	}
 
+	// Create the index first so that filling it does not pick up this email
+	indexed := m.ensureSearchIndex() == nil
+
 	_, err := m.db.Exec(`
 		INSERT INTO auth_emails (id, to_email, from_email, subject, body_html, body_text, email_type, user_id, created_at, metadata)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
@@ -63,6 +76,13 @@ func (m *CatchMailer) Send(ctx context.Context, msg *Message) error {
 		return fmt.Errorf("failed to store email: %w", err)
 	}
 
+	// The email is caught even if it cannot be indexed for search
+	if indexed {
+		m.indexEmail(id, msg.Subject, msg.BodyHTML, msg.BodyText)
+	}
+
+	// Pruning failures must not fail delivery; the next send retries
+	m.Prune()
 	return nil
 }
 
@@ -145,12 +165,18 @@ func (m *CatchMailer) GetEmail(id string) (*CaughtEmail, error) {
 // DeleteEmail removes a single email.
 func (m *CatchMailer) DeleteEmail(id string) error {
 	_, err := m.db.Exec("DELETE FROM auth_emails WHERE id = ?", id)
+	if err == nil && m.ensureSearchIndex() == nil {
+		m.db.Exec("DELETE FROM "+searchIndexTable+" WHERE id = ?", id)
+	}
 	return err
 }
 
 // ClearAll removes all caught emails.
 func (m *CatchMailer) ClearAll() error {
 	_, err := m.db.Exec("DELETE FROM auth_emails")
+	if err == nil && m.ensureSearchIndex() == nil {
+		m.db.Exec("DELETE FROM " + searchIndexTable)
+	}
 	return err
 }
 
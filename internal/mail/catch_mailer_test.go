@@ -4,7 +4,9 @@ package mail
 import (
 	"context"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/markb/sblite/internal/db"
 )
@@ -138,3 +140,99 @@ func TestCatchMailer_ClearAll(t *testing.T) {
 		t.Errorf("expected 0 emails after clear, got %d", len(emails))
 	}
 }
+
+func TestCatchMailer_SearchEmails(t *testing.T) {
+	database := setupTestDB(t)
+	mailer := NewCatchMailer(database)
+	ctx := context.Background()
+
+	// Emails caught before the index exists are indexed on first search
+	_, err := database.Exec(`INSERT INTO auth_emails (id, to_email, from_email, subject, body_html, email_type, created_at)
+		VALUES ('old', 'old@example.com', 'noreply@example.com', 'Welcome', '<p>Open <a href="x">the portal</a> &amp; enjoy</p>', 'invite', '2024-01-01T00:00:00Z')`)
+	if err != nil {
+		t.Fatalf("insert error = %v", err)
+	}
+
+	for _, msg := range []*Message{
+		{To: "a@example.com", From: "noreply@example.com", Subject: "Confirm your signup", BodyText: "Follow https://app.example.com/confirm?token=abc123 to confirm", Type: TypeConfirmation},
+		{To: "b@example.com", From: "noreply@example.com", Subject: "Reset your password", BodyText: "Use https://app.example.com/recover?token=xyz789", Type: TypeRecovery},
+	} {
+		if err := mailer.Send(ctx, msg); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	results, err := mailer.SearchEmails("token=abc", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchEmails() error = %v", err)
+	}
+	if len(results) != 1 || results[0].To != "a@example.com" {
+		t.Fatalf("expected the confirmation email, got %+v", results)
+	}
+	if !strings.Contains(results[0].Snippet, "<mark>") {
+		t.Errorf("expected highlighted snippet, got %q", results[0].Snippet)
+	}
+
+	results, err = mailer.SearchEmails("portal", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchEmails() error = %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "old" {
+		t.Fatalf("expected the previously caught email, got %+v", results)
+	}
+	if strings.Contains(results[0].Snippet, "href") {
+		t.Errorf("HTML markup should not be indexed, got %q", results[0].Snippet)
+	}
+
+	if err := mailer.ClearAll(); err != nil {
+		t.Fatalf("ClearAll() error = %v", err)
+	}
+	results, _ = mailer.SearchEmails("password", 10, 0)
+	if len(results) != 0 {
+		t.Errorf("expected no results after ClearAll, got %d", len(results))
+	}
+}
+
+func TestCatchMailer_Prune(t *testing.T) {
+	database := setupTestDB(t)
+	mailer := NewCatchMailer(database)
+
+	// An email caught long ago
+	old := time.Now().UTC().Add(-48 * time.Hour).Format(time.RFC3339)
+	if _, err := database.Exec(`INSERT INTO auth_emails (id, to_email, from_email, subject, email_type, created_at)
+		VALUES ('old', 'a@example.com', 'noreply@example.com', 'Old', 'confirmation', ?)`, old); err != nil {
+		t.Fatalf("failed to insert email: %v", err)
+	}
+	oldest, err := mailer.Oldest()
+	if err != nil || oldest.Format(time.RFC3339) != old {
+		t.Fatalf("Oldest() = %v, %v, want %s", oldest, err, old)
+	}
+
+	mailer.SetRetention(Retention{MaxAge: 24 * time.Hour, MaxCount: 2})
+	for i := 0; i < 3; i++ {
+		msg := &Message{To: "user@example.com", From: "noreply@example.com", Subject: "New", BodyText: "Hello", Type: TypeConfirmation}
+		if err := mailer.Send(context.Background(), msg); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	count, _ := mailer.Count()
+	if count != 2 {
+		t.Fatalf("expected 2 emails after pruning, got %d", count)
+	}
+	if _, err := mailer.GetEmail("old"); err == nil {
+		t.Error("expected the expired email to be pruned")
+	}
+	results, err := mailer.SearchEmails("hello", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchEmails() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected pruned emails to leave the search index, got %d results", len(results))
+	}
+
+	mailer.ClearAll()
+	if oldest, err := mailer.Oldest(); err != nil || !oldest.IsZero() {
+		t.Errorf("Oldest() on empty catcher = %v, %v", oldest, err)
+	}
+}
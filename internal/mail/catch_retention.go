@@ -0,0 +1,87 @@
+// internal/mail/catch_retention.go
+package mail
+
+import (
+	"fmt"
+	"time"
+)
+
+// Retention limits how many caught emails are kept. A zero field is no limit.
+type Retention struct {
+	MaxAge   time.Duration
+	MaxCount int
+}
+
+// SetRetention sets the retention enforced after each caught email.
+func (m *CatchMailer) SetRetention(r Retention) {
+	m.retentionMu.Lock()
+	m.retention = r
+	m.retentionMu.Unlock()
+}
+
+// Retention returns the retention in effect.
+func (m *CatchMailer) Retention() Retention {
+	m.retentionMu.Lock()
+	defer m.retentionMu.Unlock()
+	return m.retention
+}
+
+// Prune deletes the emails outside the retention: those older than MaxAge,
+// then all but the newest MaxCount. It returns how many were deleted.
+func (m *CatchMailer) Prune() (int64, error) {
+	r := m.Retention()
+	var deleted int64
+
+	if r.MaxAge > 0 {
+		cutoff := time.Now().UTC().Add(-r.MaxAge).Format(time.RFC3339)
+		n, err := m.pruneWhere(`created_at < ?`, cutoff)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	if r.MaxCount > 0 {
+		n, err := m.pruneWhere(`id NOT IN (SELECT id FROM auth_emails ORDER BY created_at DESC, rowid DESC LIMIT ?)`, r.MaxCount)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+	return deleted, nil
+}
+
+// pruneWhere deletes the emails matching cond along with their search index
+// entries.
+func (m *CatchMailer) pruneWhere(cond string, arg any) (int64, error) {
+	indexed := m.ensureSearchIndex() == nil
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if indexed {
+		if _, err := tx.Exec(`DELETE FROM `+searchIndexTable+` WHERE id IN (SELECT id FROM auth_emails WHERE `+cond+`)`, arg); err != nil {
+			return 0, fmt.Errorf("failed to prune email search index: %w", err)
+		}
+	}
+	res, err := tx.Exec(`DELETE FROM auth_emails WHERE `+cond, arg)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune emails: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// Oldest returns when the oldest caught email was received, or the zero time
+// when there are none.
+func (m *CatchMailer) Oldest() (time.Time, error) {
+	var createdAt *string
+	if err := m.db.QueryRow(`SELECT MIN(created_at) FROM auth_emails`).Scan(&createdAt); err != nil || createdAt == nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, *createdAt)
+}
@@ -0,0 +1,159 @@
+// internal/mail/catch_search.go
+package mail
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// searchIndexTable is the FTS5 index over caught emails. It is kept separate
+// from auth_emails and maintained by CatchMailer, which creates it on first
+// use and fills it with the emails caught so far.
+const searchIndexTable = "auth_emails_fts"
+
+var (
+	htmlBlockPattern = regexp.MustCompile(`(?is)<(style|script)[^>]*>.*?</(style|script)>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// searchableBody returns the text indexed for an email: the text body, or
+// the HTML body with markup removed when there is no text body.
+func searchableBody(bodyHTML, bodyText string) string {
+	if strings.TrimSpace(bodyText) != "" {
+		return bodyText
+	}
+	text := htmlBlockPattern.ReplaceAllString(bodyHTML, " ")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// ensureSearchIndex creates and fills the search index once per mailer.
+func (m *CatchMailer) ensureSearchIndex() error {
+	m.indexOnce.Do(func() {
+		m.indexErr = m.createSearchIndex()
+	})
+	return m.indexErr
+}
+
+func (m *CatchMailer) createSearchIndex() error {
+	var exists int
+	m.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, searchIndexTable).Scan(&exists)
+	if exists > 0 {
+		return nil
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`CREATE VIRTUAL TABLE ` + searchIndexTable + ` USING fts5(id UNINDEXED, subject, body)`); err != nil {
+		return fmt.Errorf("failed to create email search index: %w", err)
+	}
+
+	// Index the emails caught before the index existed
+	type caught struct{ id, subject, body string }
+	var existing []caught
+	rows, err := tx.Query(`SELECT id, subject, COALESCE(body_html, ''), COALESCE(body_text, '') FROM auth_emails`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var c caught
+		var bodyHTML, bodyText string
+		if err := rows.Scan(&c.id, &c.subject, &bodyHTML, &bodyText); err != nil {
+			rows.Close()
+			return err
+		}
+		c.body = searchableBody(bodyHTML, bodyText)
+		existing = append(existing, c)
+	}
+	rows.Close()
+	for _, c := range existing {
+		if _, err := tx.Exec(`INSERT INTO `+searchIndexTable+` (id, subject, body) VALUES (?, ?, ?)`, c.id, c.subject, c.body); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// indexEmail adds a caught email to the search index, which must exist.
+func (m *CatchMailer) indexEmail(id, subject, bodyHTML, bodyText string) {
+	m.db.Exec(`INSERT INTO `+searchIndexTable+` (id, subject, body) VALUES (?, ?, ?)`, id, subject, searchableBody(bodyHTML, bodyText))
+}
+
+// searchQuery turns free text into an FTS5 query matching emails containing
+// every word, the last one as a prefix. Words are quoted so that characters
+// in URLs or addresses are not read as query syntax.
+func searchQuery(q string) string {
+	words := strings.Fields(q)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	if len(words) > 0 {
+		words[len(words)-1] += "*"
+	}
+	return strings.Join(words, " ")
+}
+
+// SearchEmails returns caught emails whose subject or body match q, most
+// relevant first, each with a highlighted snippet.
+func (m *CatchMailer) SearchEmails(q string, limit, offset int) ([]CaughtEmail, error) {
+	if err := m.ensureSearchIndex(); err != nil {
+		return nil, err
+	}
+	match := searchQuery(q)
+	if match == "" {
+		return []CaughtEmail{}, nil
+	}
+
+	rows, err := m.db.Query(`
+		SELECT e.id, e.to_email, e.from_email, e.subject, e.body_html, e.body_text, e.email_type, e.user_id, e.created_at, e.metadata,
+			snippet(`+searchIndexTable+`, -1, '<mark>', '</mark>', '…', 16)
+		FROM `+searchIndexTable+` f
+		JOIN auth_emails e ON e.id = f.id
+		WHERE `+searchIndexTable+` MATCH ?
+		ORDER BY bm25(`+searchIndexTable+`)
+		LIMIT ? OFFSET ?
+	`, match, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search emails: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []CaughtEmail{}
+	for rows.Next() {
+		var e CaughtEmail
+		var bodyHTML, bodyText, userID, metadataJSON *string
+		var createdAt string
+
+		err := rows.Scan(&e.ID, &e.To, &e.From, &e.Subject, &bodyHTML, &bodyText, &e.Type, &userID, &createdAt, &metadataJSON, &e.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email: %w", err)
+		}
+
+		if bodyHTML != nil {
+			e.BodyHTML = *bodyHTML
+		}
+		if bodyText != nil {
+			e.BodyText = *bodyText
+		}
+		if userID != nil {
+			e.UserID = *userID
+		}
+		e.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+		if metadataJSON != nil {
+			json.Unmarshal([]byte(*metadataJSON), &e.Metadata)
+		}
+
+		emails = append(emails, e)
+	}
+
+	return emails, rows.Err()
+}
@@ -62,6 +62,10 @@ type Config struct {
 	SMTPPort int
 	SMTPUser string
 	SMTPPass string
+
+	RatePerMinute int // max SMTP deliveries per minute from the outbound queue
+
+	CatchRetention Retention // how many caught emails to keep in catch mode
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -0,0 +1,347 @@
+// internal/mail/queue_mailer.go
+package mail
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markb/sblite/internal/db"
+)
+
+// Queue item statuses
+const (
+	QueueStatusPending = "pending"
+	QueueStatusFailed  = "failed"
+)
+
+// queueTimeFormat is fixed-width so stored timestamps compare correctly as text.
+const queueTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// QueueConfig controls how queued emails are delivered.
+type QueueConfig struct {
+	RatePerMinute int           // maximum deliveries per minute
+	MaxAttempts   int           // attempts before an email is marked failed
+	BaseBackoff   time.Duration // delay after the first failure, doubled per attempt
+	MaxBackoff    time.Duration // upper bound on the retry delay
+	PollInterval  time.Duration // how often to check for due emails when idle
+	SendTimeout   time.Duration // timeout for a single delivery
+}
+
+// DefaultQueueConfig returns a QueueConfig with sensible defaults.
+func DefaultQueueConfig() QueueConfig {
+	return QueueConfig{
+		RatePerMinute: 60,
+		MaxAttempts:   5,
+		BaseBackoff:   30 * time.Second,
+		MaxBackoff:    time.Hour,
+		PollInterval:  5 * time.Second,
+		SendTimeout:   30 * time.Second,
+	}
+}
+
+// QueuedEmail is an email waiting in (or rejected from) the outbound queue.
+type QueuedEmail struct {
+	ID            string    `json:"id"`
+	To            string    `json:"to"`
+	Subject       string    `json:"subject"`
+	Type          string    `json:"type"`
+	Status        string    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error,omitempty"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// QueueStats summarizes the outbound queue.
+type QueueStats struct {
+	Pending   int    `json:"pending"`
+	Failed    int    `json:"failed"`
+	Sent      int64  `json:"sent"`
+	Retries   int64  `json:"retries"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// QueueMailer persists outgoing emails and delivers them in the background
+// through another Mailer, limiting the send rate and retrying failures with
+// exponential backoff. Pending emails survive restarts.
+type QueueMailer struct {
+	db       *db.DB
+	delivery Mailer
+	config   QueueConfig
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+
+	mu        sync.Mutex
+	sent      int64
+	retries   int64
+	lastError string
+}
+
+// NewQueueMailer creates a QueueMailer that delivers through delivery.
+// Call Start to begin processing the queue.
+func NewQueueMailer(database *db.DB, delivery Mailer, config QueueConfig) *QueueMailer {
+	defaults := DefaultQueueConfig()
+	if config.RatePerMinute <= 0 {
+		config.RatePerMinute = defaults.RatePerMinute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaults.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.SendTimeout <= 0 {
+		config.SendTimeout = defaults.SendTimeout
+	}
+	return &QueueMailer{
+		db:       database,
+		delivery: delivery,
+		config:   config,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Send adds the email to the queue and returns without waiting for delivery.
+func (m *QueueMailer) Send(ctx context.Context, msg *Message) error {
+	if err := msg.Validate(); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+
+	var metadataJSON *string
+	if msg.Metadata != nil {
+		b, err := json.Marshal(msg.Metadata)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metadata: %w", err)
+		}
+		s := string(b)
+		metadataJSON = &s
+	}
+
+	now := time.Now().UTC().Format(queueTimeFormat)
+	_, err := m.db.ExecContext(ctx, `
+		INSERT INTO auth_email_queue (id, to_email, from_email, subject, body_html, body_text, email_type, user_id, metadata, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, uuid.New().String(), msg.To, msg.From, msg.Subject, msg.BodyHTML, msg.BodyText, msg.Type, msg.UserID, metadataJSON, QueueStatusPending, now, now)
+	if err != nil {
+		return fmt.Errorf("failed to queue email: %w", err)
+	}
+
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start launches the background worker.
+func (m *QueueMailer) Start() {
+	go m.run()
+}
+
+// Stop stops the background worker and waits for an in-flight delivery to finish.
+// Undelivered emails stay queued for the next start.
+func (m *QueueMailer) Stop() {
+	m.once.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+func (m *QueueMailer) run() {
+	defer close(m.done)
+
+	interval := time.Minute / time.Duration(m.config.RatePerMinute)
+	for {
+		delivered, err := m.processNext()
+		wait := interval
+		if err != nil || !delivered {
+			wait = m.config.PollInterval
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-m.stop:
+			timer.Stop()
+			return
+		case <-m.wake:
+			// Wake early for new mail only when idle; keep the rate limit otherwise
+			if delivered {
+				select {
+				case <-timer.C:
+				case <-m.stop:
+					timer.Stop()
+					return
+				}
+			}
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// processNext attempts the next due email. It reports whether an email was attempted.
+func (m *QueueMailer) processNext() (bool, error) {
+	now := time.Now().UTC()
+
+	var (
+		id, to, from, subject, emailType string
+		bodyHTML, bodyText, userID, meta sql.NullString
+		attempts                         int
+	)
+	err := m.db.QueryRow(`
+		SELECT id, to_email, from_email, subject, body_html, body_text, email_type, user_id, metadata, attempts
+		FROM auth_email_queue
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, created_at
+		LIMIT 1
+	`, QueueStatusPending, now.Format(queueTimeFormat)).Scan(&id, &to, &from, &subject, &bodyHTML, &bodyText, &emailType, &userID, &meta, &attempts)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read email queue: %w", err)
+	}
+
+	msg := &Message{
+		To:       to,
+		From:     from,
+		Subject:  subject,
+		BodyHTML: bodyHTML.String,
+		BodyText: bodyText.String,
+		Type:     emailType,
+		UserID:   userID.String,
+	}
+	if meta.Valid && meta.String != "" {
+		json.Unmarshal([]byte(meta.String), &msg.Metadata)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.SendTimeout)
+	sendErr := m.delivery.Send(ctx, msg)
+	cancel()
+
+	if sendErr == nil {
+		m.mu.Lock()
+		m.sent++
+		m.mu.Unlock()
+		_, err = m.db.Exec("DELETE FROM auth_email_queue WHERE id = ?", id)
+		return true, err
+	}
+
+	attempts++
+	m.mu.Lock()
+	m.lastError = sendErr.Error()
+	if attempts < m.config.MaxAttempts {
+		m.retries++
+	}
+	m.mu.Unlock()
+
+	status := QueueStatusPending
+	if attempts >= m.config.MaxAttempts {
+		status = QueueStatusFailed
+	}
+	next := now.Add(m.backoff(attempts)).Format(queueTimeFormat)
+	_, err = m.db.Exec(`
+		UPDATE auth_email_queue SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?
+	`, status, attempts, sendErr.Error(), next, id)
+	return true, err
+}
+
+// backoff returns the delay before the retry following the given attempt count.
+func (m *QueueMailer) backoff(attempts int) time.Duration {
+	delay := m.config.BaseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= m.config.MaxBackoff {
+			return m.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+// Stats returns the current queue depth and delivery counters.
+func (m *QueueMailer) Stats() (QueueStats, error) {
+	var stats QueueStats
+	err := m.db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN status = 'pending' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END), 0)
+		FROM auth_email_queue
+	`).Scan(&stats.Pending, &stats.Failed)
+	if err != nil {
+		return stats, fmt.Errorf("failed to count email queue: %w", err)
+	}
+
+	m.mu.Lock()
+	stats.Sent = m.sent
+	stats.Retries = m.retries
+	stats.LastError = m.lastError
+	m.mu.Unlock()
+	return stats, nil
+}
+
+// ListQueued returns queued emails with the given status (all if empty), oldest first.
+func (m *QueueMailer) ListQueued(status string, limit, offset int) ([]QueuedEmail, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	rows, err := m.db.Query(`
+		SELECT id, to_email, subject, email_type, status, attempts, last_error, next_attempt_at, created_at
+		FROM auth_email_queue
+		WHERE ? = '' OR status = ?
+		ORDER BY created_at
+		LIMIT ? OFFSET ?
+	`, status, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list email queue: %w", err)
+	}
+	defer rows.Close()
+
+	emails := []QueuedEmail{}
+	for rows.Next() {
+		var e QueuedEmail
+		var lastError sql.NullString
+		var nextAttemptAt, createdAt string
+		if err := rows.Scan(&e.ID, &e.To, &e.Subject, &e.Type, &e.Status, &e.Attempts, &lastError, &nextAttemptAt, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan queued email: %w", err)
+		}
+		e.LastError = lastError.String
+		e.NextAttemptAt, _ = time.Parse(queueTimeFormat, nextAttemptAt)
+		e.CreatedAt, _ = time.Parse(queueTimeFormat, createdAt)
+		emails = append(emails, e)
+	}
+	return emails, rows.Err()
+}
+
+// RetryFailed moves failed emails back to pending for immediate delivery.
+func (m *QueueMailer) RetryFailed() (int64, error) {
+	now := time.Now().UTC().Format(queueTimeFormat)
+	result, err := m.db.Exec(`
+		UPDATE auth_email_queue SET status = ?, attempts = 0, next_attempt_at = ? WHERE status = ?
+	`, QueueStatusPending, now, QueueStatusFailed)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retry emails: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	if n > 0 {
+		select {
+		case m.wake <- struct{}{}:
+		default:
+		}
+	}
+	return n, nil
+}
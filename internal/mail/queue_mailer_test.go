@@ -0,0 +1,158 @@
+// internal/mail/queue_mailer_test.go
+package mail
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMailer records delivered messages and fails while failures > 0.
+type recordingMailer struct {
+	mu       sync.Mutex
+	sent     []*Message
+	failures int
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.failures > 0 {
+		m.failures--
+		return errors.New("smtp unavailable")
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *recordingMailer) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.sent)
+}
+
+func testQueueConfig() QueueConfig {
+	return QueueConfig{
+		RatePerMinute: 6000,
+		MaxAttempts:   3,
+		BaseBackoff:   time.Millisecond,
+		MaxBackoff:    5 * time.Millisecond,
+		PollInterval:  5 * time.Millisecond,
+	}
+}
+
+func testMessage(to string) *Message {
+	return &Message{
+		To:       to,
+		From:     "noreply@example.com",
+		Subject:  "Reset your password",
+		BodyText: "Click the link",
+		Type:     TypeRecovery,
+		Metadata: map[string]any{"token": "abc"},
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestQueueMailer_SendPersistsAndDelivers(t *testing.T) {
+	database := setupTestDB(t)
+	delivery := &recordingMailer{}
+	queue := NewQueueMailer(database, delivery, testQueueConfig())
+
+	// Queued before the worker starts, as after a restart
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := queue.Send(context.Background(), testMessage(to)); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+	}
+	stats, err := queue.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Pending != 2 {
+		t.Errorf("expected 2 pending, got %d", stats.Pending)
+	}
+
+	queue.Start()
+	defer queue.Stop()
+	waitFor(t, func() bool { return delivery.count() == 2 })
+
+	stats, _ = queue.Stats()
+	if stats.Pending != 0 || stats.Sent != 2 {
+		t.Errorf("unexpected stats after delivery: %+v", stats)
+	}
+	if delivery.sent[0].Metadata["token"] != "abc" {
+		t.Errorf("expected metadata to round-trip, got %v", delivery.sent[0].Metadata)
+	}
+}
+
+func TestQueueMailer_RetriesThenFails(t *testing.T) {
+	database := setupTestDB(t)
+	delivery := &recordingMailer{failures: 100}
+	queue := NewQueueMailer(database, delivery, testQueueConfig())
+	queue.Start()
+	defer queue.Stop()
+
+	if err := queue.Send(context.Background(), testMessage("a@example.com")); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		stats, _ := queue.Stats()
+		return stats.Failed == 1
+	})
+
+	emails, err := queue.ListQueued(QueueStatusFailed, 10, 0)
+	if err != nil {
+		t.Fatalf("ListQueued failed: %v", err)
+	}
+	if len(emails) != 1 || emails[0].Attempts != 3 || emails[0].LastError != "smtp unavailable" {
+		t.Fatalf("unexpected failed emails: %+v", emails)
+	}
+	stats, _ := queue.Stats()
+	if stats.Retries != 2 || stats.LastError != "smtp unavailable" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Requeue once the server recovers
+	delivery.mu.Lock()
+	delivery.failures = 0
+	delivery.mu.Unlock()
+
+	n, err := queue.RetryFailed()
+	if err != nil || n != 1 {
+		t.Fatalf("RetryFailed = %d, %v", n, err)
+	}
+	waitFor(t, func() bool { return delivery.count() == 1 })
+}
+
+func TestQueueMailer_Backoff(t *testing.T) {
+	queue := NewQueueMailer(nil, nil, QueueConfig{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second})
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := queue.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestQueueMailer_InvalidMessage(t *testing.T) {
+	database := setupTestDB(t)
+	queue := NewQueueMailer(database, &recordingMailer{}, testQueueConfig())
+
+	if err := queue.Send(context.Background(), &Message{}); err == nil {
+		t.Error("expected invalid message to be rejected")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"mime/multipart"
 	"net"
@@ -57,46 +58,15 @@ func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
 		return fmt.Errorf("invalid message: %w", err)
 	}
 
-	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
-	auth := smtp.PlainAuth("", m.config.User, m.config.Pass, m.config.Host)
-
 	// Build the email message
 	body := m.buildMessage(msg)
 
-	// Connect with timeout
-	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	client, err := m.config.connect(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to connect to SMTP server: %w", err)
-	}
-	defer conn.Close()
-
-	// Set deadline based on context or default 30 seconds
-	deadline := time.Now().Add(30 * time.Second)
-	if d, ok := ctx.Deadline(); ok {
-		deadline = d
-	}
-	conn.SetDeadline(deadline)
-
-	// Create SMTP client
-	client, err := smtp.NewClient(conn, m.config.Host)
-	if err != nil {
-		return fmt.Errorf("failed to create SMTP client: %w", err)
+		return err
 	}
 	defer client.Close()
 
-	// STARTTLS for port 587
-	if m.config.Port == 587 {
-		tlsConfig := &tls.Config{ServerName: m.config.Host}
-		if err := client.StartTLS(tlsConfig); err != nil {
-			return fmt.Errorf("failed to start TLS: %w", err)
-		}
-	}
-
-	// Authenticate
-	if err := client.Auth(auth); err != nil {
-		return fmt.Errorf("SMTP auth failed: %w", err)
-	}
-
 	// Set sender and recipient
 	if err := client.Mail(msg.From); err != nil {
 		return fmt.Errorf("failed to set sender: %w", err)
@@ -120,6 +90,96 @@ func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
 	return client.Quit()
 }
 
+// SMTP connection test stages reported in SMTPTestError.
+const (
+	StageDNS     = "dns"
+	StageConnect = "connect"
+	StageTLS     = "tls"
+	StageAuth    = "auth"
+)
+
+// SMTPTestError describes which step of an SMTP connection failed.
+type SMTPTestError struct {
+	Stage string
+	Err   error
+}
+
+func (e *SMTPTestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SMTPTestError) Unwrap() error {
+	return e.Err
+}
+
+// TestConnection resolves, connects, negotiates TLS and authenticates against
+// the SMTP server without sending a message. Failures are *SMTPTestError.
+func (c SMTPConfig) TestConnection(ctx context.Context) error {
+	if net.ParseIP(c.Host) == nil {
+		if _, err := net.DefaultResolver.LookupHost(ctx, c.Host); err != nil {
+			return &SMTPTestError{Stage: StageDNS, Err: fmt.Errorf("failed to resolve SMTP host: %w", err)}
+		}
+	}
+
+	client, err := c.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Quit()
+}
+
+// connect dials the server, starts TLS on port 587 and authenticates.
+// Errors are *SMTPTestError so callers can tell which step failed.
+func (c SMTPConfig) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+
+	// Connect with timeout
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		stage := StageConnect
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) {
+			stage = StageDNS
+		}
+		return nil, &SMTPTestError{Stage: stage, Err: fmt.Errorf("failed to connect to SMTP server: %w", err)}
+	}
+
+	// Set deadline based on context or default 30 seconds
+	deadline := time.Now().Add(30 * time.Second)
+	if d, ok := ctx.Deadline(); ok {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	// Create SMTP client
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return nil, &SMTPTestError{Stage: StageConnect, Err: fmt.Errorf("failed to create SMTP client: %w", err)}
+	}
+
+	// STARTTLS for port 587
+	if c.Port == 587 {
+		tlsConfig := &tls.Config{ServerName: c.Host}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, &SMTPTestError{Stage: StageTLS, Err: fmt.Errorf("failed to start TLS: %w", err)}
+		}
+	}
+
+	// Authenticate
+	if c.User != "" {
+		auth := smtp.PlainAuth("", c.User, c.Pass, c.Host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, &SMTPTestError{Stage: StageAuth, Err: fmt.Errorf("SMTP auth failed: %w", err)}
+		}
+	}
+
+	return client, nil
+}
+
 // buildMessage creates a MIME multipart email message.
 func (m *SMTPMailer) buildMessage(msg *Message) []byte {
 	var buf bytes.Buffer
@@ -2,7 +2,13 @@
 package mail
 
 import (
+	"bufio"
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
 	"testing"
 )
 
@@ -90,3 +96,86 @@ func TestSMTPMailer_SendValidationError(t *testing.T) {
 		t.Error("Send() should return error for invalid message")
 	}
 }
+
+// startFakeSMTP runs a minimal SMTP server that accepts AUTH PLAIN only for the given password.
+func startFakeSMTP(t *testing.T, password string) (string, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				fmt.Fprintf(conn, "220 fake ESMTP\r\n")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					cmd := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(cmd, "EHLO"):
+						fmt.Fprintf(conn, "250-fake\r\n250 AUTH PLAIN\r\n")
+					case strings.HasPrefix(cmd, "AUTH PLAIN"):
+						fields := strings.Fields(strings.TrimSpace(line))
+						decoded, _ := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+						if strings.HasSuffix(string(decoded), "\x00"+password) {
+							fmt.Fprintf(conn, "235 ok\r\n")
+						} else {
+							fmt.Fprintf(conn, "535 authentication failed\r\n")
+						}
+					case strings.HasPrefix(cmd, "QUIT"):
+						fmt.Fprintf(conn, "221 bye\r\n")
+						return
+					default:
+						fmt.Fprintf(conn, "250 ok\r\n")
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return "127.0.0.1", addr.Port
+}
+
+func TestSMTPConfig_TestConnection(t *testing.T) {
+	host, port := startFakeSMTP(t, "secret")
+
+	cfg := SMTPConfig{Host: host, Port: port, User: "user", Pass: "secret"}
+	if err := cfg.TestConnection(context.Background()); err != nil {
+		t.Fatalf("expected connection test to pass, got %v", err)
+	}
+
+	cfg.Pass = "wrong"
+	err := cfg.TestConnection(context.Background())
+	var testErr *SMTPTestError
+	if !errors.As(err, &testErr) || testErr.Stage != StageAuth {
+		t.Errorf("expected auth stage error, got %v", err)
+	}
+}
+
+func TestSMTPConfig_TestConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	cfg := SMTPConfig{Host: "127.0.0.1", Port: port, User: "user", Pass: "secret"}
+	err = cfg.TestConnection(context.Background())
+	var testErr *SMTPTestError
+	if !errors.As(err, &testErr) || testErr.Stage != StageConnect {
+		t.Errorf("expected connect stage error, got %v", err)
+	}
+}
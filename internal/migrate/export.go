@@ -42,6 +42,12 @@ func (e *Exporter) ExportDDL() (string, error) {
 		return "", fmt.Errorf("failed to list tables: %w", err)
 	}
 
+	return e.ExportTablesDDL(tables)
+}
+
+// ExportTablesDDL generates PostgreSQL DDL for the given tables only, in the
+// order given. Returns formatted DDL with a header comment.
+func (e *Exporter) ExportTablesDDL(tables []string) (string, error) {
 	var sb strings.Builder
 
 	// Write header comment
@@ -161,6 +161,34 @@ func TestExportDDL_MultipleTables(t *testing.T) {
 	}
 }
 
+func TestExportTablesDDL(t *testing.T) {
+	_, sch := setupTestDB(t)
+
+	cols := []schema.Column{
+		{TableName: "users", ColumnName: "id", PgType: "uuid", IsNullable: false, IsPrimary: true},
+		{TableName: "posts", ColumnName: "id", PgType: "uuid", IsNullable: false, IsPrimary: true},
+	}
+
+	for _, col := range cols {
+		if err := sch.RegisterColumn(col); err != nil {
+			t.Fatalf("RegisterColumn failed: %v", err)
+		}
+	}
+
+	exporter := New(sch)
+	ddl, err := exporter.ExportTablesDDL([]string{"posts"})
+	if err != nil {
+		t.Fatalf("ExportTablesDDL failed: %v", err)
+	}
+
+	if !strings.Contains(ddl, "CREATE TABLE posts") {
+		t.Error("expected DDL to contain 'CREATE TABLE posts'")
+	}
+	if strings.Contains(ddl, "CREATE TABLE users") {
+		t.Error("expected DDL to only contain the selected table")
+	}
+}
+
 func TestExportDDL_EmptySchema(t *testing.T) {
 	_, sch := setupTestDB(t)
 
@@ -6,6 +6,7 @@ package migration
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -27,14 +28,26 @@ func (m Migration) Filename() string {
 	return fmt.Sprintf("%s_%s.sql", m.Version, m.Name)
 }
 
+// DownFilename returns the filename of the paired down migration that reverts
+// this one: version_name.down.sql
+func (m Migration) DownFilename() string {
+	return fmt.Sprintf("%s_%s.down.sql", m.Version, m.Name)
+}
+
+// IsDownFilename reports whether a filename is a down migration.
+func IsDownFilename(filename string) bool {
+	return strings.HasSuffix(filename, ".down.sql")
+}
+
 // filenameRegex matches migration filenames: YYYYMMDDHHmmss_name.sql
 var filenameRegex = regexp.MustCompile(`^(\d{14})_(.+)\.sql$`)
 
 // ParseFilename parses a migration filename into a Migration struct.
-// Returns an error if the filename doesn't match the expected format.
+// Returns an error if the filename doesn't match the expected format or is a
+// down migration.
 func ParseFilename(filename string) (Migration, error) {
 	matches := filenameRegex.FindStringSubmatch(filename)
-	if matches == nil {
+	if matches == nil || IsDownFilename(filename) {
 		return Migration{}, fmt.Errorf("invalid migration filename: %s", filename)
 	}
 
@@ -46,6 +46,7 @@ func TestParseMigrationFilename(t *testing.T) {
 		{"invalid.sql", "", "", false},
 		{"20260117143022.sql", "", "", false},
 		{"not_a_migration.txt", "", "", false},
+		{"20260117143022_create_posts.down.sql", "", "", false},
 	}
 
 	for _, tt := range tests {
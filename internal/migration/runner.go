@@ -9,8 +9,6 @@ import (
 	"sort"
 	"strings"
 	"time"
-
-	"github.com/markb/sblite/internal/pgtranslate"
 )
 
 // Runner handles migration execution against a database
@@ -59,18 +57,16 @@ func (r *Runner) Apply(m Migration) error {
 
 	// Execute the migration SQL
 	// Split by semicolons and execute each statement
+	// Statements are translated from PostgreSQL syntax to SQLite, so
+	// migrations written for Supabase also work here
 	statements := splitStatements(m.SQL)
+	replay := newSequenceReplay()
 	for _, stmt := range statements {
 		stmt = strings.TrimSpace(stmt)
 		if stmt == "" {
 			continue
 		}
-
-		// Translate PostgreSQL syntax to SQLite
-		// This allows migrations written in PostgreSQL syntax to work with SQLite
-		translated := pgtranslate.TranslateToSQLite(stmt)
-
-		if _, err := tx.Exec(translated); err != nil {
+		if err := replay.exec(tx, stmt); err != nil {
 			return fmt.Errorf("migration %s failed: %w", m.Version, err)
 		}
 	}
@@ -91,6 +87,51 @@ func (r *Runner) Apply(m Migration) error {
 	return nil
 }
 
+// Revert executes a migration's down SQL and removes its record within a
+// transaction, so the migration becomes pending again.
+func (r *Runner) Revert(m Migration, downSQL string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	replay := newSequenceReplay()
+	for _, stmt := range splitStatements(downSQL) {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		if err := replay.exec(tx, stmt); err != nil {
+			return fmt.Errorf("revert of migration %s failed: %w", m.Version, err)
+		}
+	}
+
+	result, err := tx.Exec(`DELETE FROM _schema_migrations WHERE version = ?`, m.Version)
+	if err != nil {
+		return fmt.Errorf("failed to remove migration record: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("migration %s is not applied", m.Version)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit revert: %w", err)
+	}
+
+	return nil
+}
+
+// ReadDown reads a migration's down SQL from dir. It returns an error wrapping
+// os.ErrNotExist if the migration has no down file.
+func ReadDown(dir string, m Migration) (string, error) {
+	content, err := os.ReadFile(filepath.Join(dir, m.DownFilename()))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
 // splitStatements splits SQL by semicolons, respecting quotes
 func splitStatements(sql string) []string {
 	var statements []string
@@ -153,9 +153,10 @@ func TestRunnerReadFromDir(t *testing.T) {
 
 	// Create test migration files
 	files := map[string]string{
-		"20260117100000_create_posts.sql": "CREATE TABLE posts (id TEXT);",
-		"20260117110000_create_users.sql": "CREATE TABLE users (id TEXT);",
-		"not_a_migration.txt":             "should be ignored",
+		"20260117100000_create_posts.sql":      "CREATE TABLE posts (id TEXT);",
+		"20260117110000_create_users.sql":      "CREATE TABLE users (id TEXT);",
+		"20260117110000_create_users.down.sql": "DROP TABLE users;",
+		"not_a_migration.txt":                  "should be ignored",
 	}
 	for name, content := range files {
 		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
@@ -256,3 +257,163 @@ func TestRunnerGetPending(t *testing.T) {
 		t.Errorf("expected second pending version 20260117120000, got %s", pending[1].Version)
 	}
 }
+
+func TestRunnerRevert(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	dir := t.TempDir()
+	m := Migration{Version: "20260117100000", Name: "create_posts", SQL: "CREATE TABLE posts (id TEXT);"}
+	if err := os.WriteFile(filepath.Join(dir, m.DownFilename()), []byte("DROP TABLE posts;"), 0644); err != nil {
+		t.Fatalf("failed to write down file: %v", err)
+	}
+
+	runner := NewRunner(database.DB)
+	if err := runner.Apply(m); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+
+	down, err := ReadDown(dir, m)
+	if err != nil {
+		t.Fatalf("ReadDown() error: %v", err)
+	}
+	if err := runner.Revert(m, down); err != nil {
+		t.Fatalf("Revert() error: %v", err)
+	}
+
+	var count int
+	database.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'posts'").Scan(&count)
+	if count != 0 {
+		t.Error("expected posts table to be dropped")
+	}
+	applied, _ := runner.GetApplied()
+	if len(applied) != 0 {
+		t.Errorf("expected migration record to be removed, got %d", len(applied))
+	}
+
+	// Reverting again fails: the migration is no longer applied
+	if err := runner.Revert(m, "SELECT 1;"); err == nil {
+		t.Error("expected error reverting a migration that is not applied")
+	}
+
+	if _, err := ReadDown(dir, Migration{Version: "20260117110000", Name: "missing"}); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error for missing down file, got %v", err)
+	}
+}
+
+func TestRunnerApply_Sequences(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	runner := NewRunner(database.DB)
+
+	m := Migration{
+		Version: "20260117130000",
+		Name:    "create_tickets",
+		SQL: `
+			CREATE SEQUENCE tickets_number_seq START WITH 100;
+			CREATE TABLE tickets (
+				id TEXT PRIMARY KEY,
+				number INTEGER DEFAULT nextval('tickets_number_seq')
+			);
+			INSERT INTO tickets (id) VALUES ('a');
+			INSERT INTO tickets (id, number) VALUES ('b', 500);
+			INSERT INTO tickets (id) VALUES ('c');
+		`,
+	}
+	if err := runner.Apply(m); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+
+	numbers := map[string]int64{}
+	rows, err := database.Query("SELECT id, number FROM tickets")
+	if err != nil {
+		t.Fatalf("query tickets: %v", err)
+	}
+	for rows.Next() {
+		var id string
+		var number int64
+		rows.Scan(&id, &number)
+		numbers[id] = number
+	}
+	rows.Close()
+	if numbers["a"] != 100 || numbers["b"] != 500 || numbers["c"] != 101 {
+		t.Errorf("unexpected ticket numbers: %v", numbers)
+	}
+
+	var current int64
+	if err := database.QueryRow("SELECT current_value FROM _sequences WHERE name = 'tickets_number_seq' AND table_name = 'tickets' AND column_name = 'number'").Scan(&current); err != nil {
+		t.Fatalf("sequence not registered: %v", err)
+	}
+	if current != 101 {
+		t.Errorf("expected current value 101, got %d", current)
+	}
+
+	// Adding a sequence column numbers existing rows in rowid order
+	m = Migration{
+		Version: "20260117140000",
+		Name:    "add_position",
+		SQL: `
+			CREATE SEQUENCE tickets_position_seq;
+			ALTER TABLE tickets ADD COLUMN "position" INTEGER DEFAULT nextval('tickets_position_seq');
+		`,
+	}
+	if err := runner.Apply(m); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	var position int64
+	database.QueryRow("SELECT position FROM tickets WHERE id = 'c'").Scan(&position)
+	if position != 3 {
+		t.Errorf("expected position 3 for the third row, got %d", position)
+	}
+
+	// Dropping the default and the sequence removes the trigger
+	m = Migration{
+		Version: "20260117150000",
+		Name:    "drop_position_seq",
+		SQL: `
+			ALTER TABLE tickets ALTER COLUMN position DROP DEFAULT;
+			DROP SEQUENCE IF EXISTS tickets_position_seq;
+		`,
+	}
+	if err := runner.Apply(m); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+	var count int
+	database.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name = 'tickets_position_seq'").Scan(&count)
+	if count != 0 {
+		t.Error("expected tickets_position_seq trigger to be dropped")
+	}
+	database.QueryRow("SELECT COUNT(*) FROM _sequences WHERE name = 'tickets_position_seq'").Scan(&count)
+	if count != 0 {
+		t.Error("expected tickets_position_seq to be removed from _sequences")
+	}
+}
+
+func TestRunnerRevert_SetDefaultNextval(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	runner := NewRunner(database.DB)
+
+	m := Migration{Version: "20260117160000", Name: "create_items", SQL: "CREATE TABLE items (id INTEGER, name TEXT);"}
+	if err := runner.Apply(m); err != nil {
+		t.Fatalf("Apply() error: %v", err)
+	}
+
+	down := `
+		CREATE SEQUENCE items_id_seq START WITH 10;
+		INSERT INTO items (id, name) VALUES (3, 'kept');
+		ALTER TABLE items ALTER COLUMN id SET DEFAULT nextval('items_id_seq');
+		INSERT INTO items (name) VALUES ('next');
+	`
+	if err := runner.Revert(m, down); err != nil {
+		t.Fatalf("Revert() error: %v", err)
+	}
+
+	var id int64
+	database.QueryRow("SELECT id FROM items WHERE name = 'next'").Scan(&id)
+	if id != 10 {
+		t.Errorf("expected id 10 from the sequence, got %d", id)
+	}
+}
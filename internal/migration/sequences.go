@@ -0,0 +1,164 @@
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/markb/sblite/internal/pgtranslate"
+)
+
+// Migrations declare sequences the PostgreSQL way, which SQLite has no
+// syntax for. sblite keeps a sequence's current value in _sequences and
+// numbers rows inserted without a value with an AFTER INSERT trigger named
+// after the sequence, so the runner maps the statements onto those:
+//
+//	CREATE SEQUENCE s [START [WITH] n]                     start kept until a column uses s
+//	... DEFAULT nextval('s') ...                           default removed, column bound to s
+//	ALTER TABLE t ALTER COLUMN c SET DEFAULT nextval('s')  column bound to s
+//	ALTER TABLE t ALTER COLUMN c DROP DEFAULT              sequence of t.c unbound
+//	DROP SEQUENCE [IF EXISTS] s                            sequence and trigger removed
+
+var (
+	ident = `("(?:[^"]|"")+"|\w+)`
+
+	createSequenceRe = regexp.MustCompile(`(?is)^CREATE\s+SEQUENCE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + ident + `(?:\s+START\s+(?:WITH\s+)?(-?\d+))?\s*$`)
+	dropSequenceRe   = regexp.MustCompile(`(?is)^DROP\s+SEQUENCE\s+(?:IF\s+EXISTS\s+)?` + ident + `\s*$`)
+	setNextvalRe     = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+` + ident + `\s+ALTER\s+(?:COLUMN\s+)?` + ident + `\s+SET\s+DEFAULT\s+nextval\(\s*'([^']+)'(?:::regclass)?\s*\)\s*$`)
+	dropDefaultRe    = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+` + ident + `\s+ALTER\s+(?:COLUMN\s+)?` + ident + `\s+DROP\s+DEFAULT\s*$`)
+
+	// nextvalColumnRe finds the columns of a CREATE TABLE or ADD COLUMN
+	// statement whose default is nextval().
+	nextvalColumnRe  = regexp.MustCompile(`(?is)(?:\(|,|\bCOLUMN)\s*` + ident + `\s+[^,()]*?(\s+DEFAULT\s+nextval\(\s*'([^']+)'(?:::regclass)?\s*\))`)
+	createTableRe    = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?` + ident)
+	alterAddColumnRe = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+` + ident + `\s+ADD\s+`)
+)
+
+// SequenceTriggerSQL returns the trigger that gives rows inserted without a
+// value the sequence's next value. Explicit values are kept and don't
+// advance the sequence, as in PostgreSQL.
+func SequenceTriggerSQL(name, tableName, columnName string) string {
+	quotedName := strings.ReplaceAll(name, "'", "''")
+	return fmt.Sprintf(`CREATE TRIGGER "%s" AFTER INSERT ON "%s" WHEN NEW."%s" IS NULL BEGIN
+	UPDATE _sequences SET current_value = current_value + 1 WHERE name = '%s';
+	UPDATE "%s" SET "%s" = (SELECT current_value FROM _sequences WHERE name = '%s') WHERE rowid = NEW.rowid;
+END`, name, tableName, columnName, quotedName, tableName, columnName, quotedName)
+}
+
+// unquoteIdent strips the double quotes of a quoted identifier.
+func unquoteIdent(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+	}
+	return s
+}
+
+// sequenceReplay executes the statements of one migration, translating
+// sequence statements as described above.
+type sequenceReplay struct {
+	// starts holds the START value of sequences created but not yet bound
+	starts map[string]int64
+}
+
+func newSequenceReplay() *sequenceReplay {
+	return &sequenceReplay{starts: make(map[string]int64)}
+}
+
+// exec runs one statement within tx.
+func (s *sequenceReplay) exec(tx *sql.Tx, stmt string) error {
+	if m := createSequenceRe.FindStringSubmatch(stmt); m != nil {
+		start := int64(1)
+		if m[2] != "" {
+			start, _ = strconv.ParseInt(m[2], 10, 64)
+		}
+		s.starts[unquoteIdent(m[1])] = start
+		return nil
+	}
+	if m := dropSequenceRe.FindStringSubmatch(stmt); m != nil {
+		return dropSequence(tx, unquoteIdent(m[1]))
+	}
+	if m := setNextvalRe.FindStringSubmatch(stmt); m != nil {
+		return s.bind(tx, unquoteIdent(m[1]), unquoteIdent(m[2]), m[3])
+	}
+	if m := dropDefaultRe.FindStringSubmatch(stmt); m != nil {
+		var name string
+		err := tx.QueryRow(`SELECT name FROM _sequences WHERE table_name = ? AND column_name = ?`,
+			unquoteIdent(m[1]), unquoteIdent(m[2])).Scan(&name)
+		if err == nil {
+			return dropSequence(tx, name)
+		}
+	}
+
+	var table string
+	if m := createTableRe.FindStringSubmatch(stmt); m != nil {
+		table = unquoteIdent(m[1])
+	} else if m := alterAddColumnRe.FindStringSubmatch(stmt); m != nil {
+		table = unquoteIdent(m[1])
+	}
+	var bindings [][2]string
+	if table != "" {
+		for _, m := range nextvalColumnRe.FindAllStringSubmatch(stmt, -1) {
+			bindings = append(bindings, [2]string{unquoteIdent(m[1]), m[3]})
+			stmt = strings.Replace(stmt, m[2], "", 1)
+		}
+	}
+
+	if _, err := tx.Exec(pgtranslate.TranslateToSQLite(stmt)); err != nil {
+		return err
+	}
+	for _, b := range bindings {
+		if err := s.bind(tx, table, b[0], b[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bind makes name number tableName.columnName: rows without a value are
+// numbered in rowid order, and the sequence continues after the largest
+// value in the column or its START value, whichever is higher.
+func (s *sequenceReplay) bind(tx *sql.Tx, tableName, columnName, name string) error {
+	current := int64(0)
+	if start, ok := s.starts[name]; ok {
+		current = start - 1
+	}
+	var maxValue int64
+	if err := tx.QueryRow(fmt.Sprintf(`SELECT COALESCE(MAX("%s"), 0) FROM "%s" WHERE typeof("%s") = 'integer'`,
+		columnName, tableName, columnName)).Scan(&maxValue); err != nil {
+		return err
+	}
+	current = max(current, maxValue)
+
+	result, err := tx.Exec(fmt.Sprintf(`UPDATE "%s" SET "%s" = n.rn + ? FROM (SELECT rowid AS id, row_number() OVER (ORDER BY rowid) AS rn FROM "%s" WHERE "%s" IS NULL) AS n WHERE "%s".rowid = n.id`,
+		tableName, columnName, tableName, columnName, tableName), current)
+	if err != nil {
+		return err
+	}
+	numbered, _ := result.RowsAffected()
+	current += numbered
+
+	if _, err := tx.Exec(`INSERT INTO _sequences (name, table_name, column_name, current_value) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET table_name = excluded.table_name, column_name = excluded.column_name, current_value = excluded.current_value`,
+		name, tableName, columnName, current); err != nil {
+		return fmt.Errorf("failed to register sequence %s: %w", name, err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS "%s"`, name)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(SequenceTriggerSQL(name, tableName, columnName)); err != nil {
+		return err
+	}
+	delete(s.starts, name)
+	return nil
+}
+
+// dropSequence removes a sequence and its trigger.
+func dropSequence(tx *sql.Tx, name string) error {
+	if _, err := tx.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS "%s"`, name)); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`DELETE FROM _sequences WHERE name = ?`, name)
+	return err
+}
@@ -0,0 +1,273 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Provider endpoints probed by CheckProvider. Variables so tests can point them
+// at a local server.
+var (
+	googleDiscoveryURL = "https://accounts.google.com/.well-known/openid-configuration"
+	googleAuthorizeURL = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL     = "https://oauth2.googleapis.com/token"
+
+	githubCheckAuthorizeURL = githubAuthorizeURL
+	githubCheckTokenURL     = githubTokenURL
+)
+
+// Check names reported by CheckProvider.
+const (
+	CheckConfig      = "config"
+	CheckDiscovery   = "discovery"
+	CheckRedirectURL = "redirect_url"
+	CheckCredentials = "credentials"
+)
+
+// CheckResult is the outcome of one provider configuration check.
+type CheckResult struct {
+	Check string `json:"check"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	Hint  string `json:"hint,omitempty"`
+}
+
+// CheckProvider verifies a provider configuration against the live provider:
+// the provider's endpoints are reachable, the client ID accepts cfg.RedirectURL
+// as a registered callback, and the client secret is valid. Checks after a
+// failed configuration or discovery check are skipped.
+func CheckProvider(ctx context.Context, client *http.Client, name string, cfg Config) ([]CheckResult, error) {
+	if name != "google" && name != "github" {
+		return nil, ErrProviderNotFound
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	// Inspect redirects instead of following them
+	noRedirect := *client
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	config := checkConfig(cfg)
+	results := []CheckResult{config}
+	if !config.OK {
+		return results, nil
+	}
+
+	var discovery CheckResult
+	if name == "google" {
+		discovery = checkGoogleDiscovery(ctx, client)
+	} else {
+		discovery = checkReachable(ctx, client, githubCheckAuthorizeURL)
+	}
+	results = append(results, discovery)
+	if !discovery.OK {
+		return results, nil
+	}
+
+	if name == "google" {
+		results = append(results,
+			checkGoogleRedirect(ctx, &noRedirect, cfg),
+			checkCredentials(ctx, client, googleTokenURL, cfg, "invalid_grant"),
+		)
+	} else {
+		results = append(results,
+			checkGitHubRedirect(ctx, &noRedirect, cfg),
+			checkCredentials(ctx, client, githubCheckTokenURL, cfg, "bad_verification_code"),
+		)
+	}
+	return results, nil
+}
+
+func checkConfig(cfg Config) CheckResult {
+	result := CheckResult{Check: CheckConfig}
+	switch {
+	case cfg.ClientID == "":
+		result.Error = "client ID is not set"
+	case cfg.ClientSecret == "":
+		result.Error = "client secret is not set"
+	default:
+		u, err := url.Parse(cfg.RedirectURL)
+		if err != nil || !u.IsAbs() || u.Host == "" {
+			result.Error = fmt.Sprintf("redirect URL %q is not an absolute URL", cfg.RedirectURL)
+			result.Hint = "Provide the public URL of /auth/v1/callback, e.g. https://example.com/auth/v1/callback"
+			return result
+		}
+		result.OK = true
+		return result
+	}
+	result.Hint = "Set the client ID and secret from the provider's developer console"
+	return result
+}
+
+// checkGoogleDiscovery fetches Google's OpenID discovery document.
+func checkGoogleDiscovery(ctx context.Context, client *http.Client) CheckResult {
+	result := CheckResult{Check: CheckDiscovery}
+	resp, err := get(ctx, client, googleDiscoveryURL)
+	if err != nil {
+		result.Error = fmt.Sprintf("discovery endpoint unreachable: %v", err)
+		result.Hint = "Check that this server can reach accounts.google.com over HTTPS"
+		return result
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		result.Error = fmt.Sprintf("discovery endpoint returned %d", resp.StatusCode)
+		return result
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil || doc.AuthorizationEndpoint == "" {
+		result.Error = "discovery document is invalid"
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+// checkReachable checks that an endpoint answers at all.
+func checkReachable(ctx context.Context, client *http.Client, endpoint string) CheckResult {
+	result := CheckResult{Check: CheckDiscovery}
+	resp, err := get(ctx, client, endpoint)
+	if err != nil {
+		result.Error = fmt.Sprintf("%s unreachable: %v", endpoint, err)
+		result.Hint = "Check that this server can reach the provider over HTTPS"
+		return result
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		result.Error = fmt.Sprintf("%s returned %d", endpoint, resp.StatusCode)
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+// checkGoogleRedirect starts an authorization request. Google redirects to its
+// sign-in page when the client and redirect URI are valid and renders an error
+// page naming the problem otherwise.
+func checkGoogleRedirect(ctx context.Context, client *http.Client, cfg Config) CheckResult {
+	result := CheckResult{Check: CheckRedirectURL}
+	params := url.Values{
+		"client_id":     {cfg.ClientID},
+		"redirect_uri":  {cfg.RedirectURL},
+		"response_type": {"code"},
+		"scope":         {"openid email"},
+	}
+	resp, err := get(ctx, client, googleAuthorizeURL+"?"+params.Encode())
+	if err != nil {
+		result.Error = fmt.Sprintf("authorization endpoint unreachable: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	text := location + string(body)
+	switch {
+	case strings.Contains(text, "redirect_uri_mismatch"):
+		result.Error = fmt.Sprintf("redirect URL %s is not registered for this client", cfg.RedirectURL)
+		result.Hint = "Add it under Authorized redirect URIs in the Google Cloud console"
+	case strings.Contains(text, "invalid_client") || strings.Contains(text, "deleted_client"):
+		result.Error = "Google does not recognize this client ID"
+		result.Hint = "Copy the client ID of a Web application OAuth client from the Google Cloud console"
+	case resp.StatusCode >= 300 && resp.StatusCode < 400 && location != "":
+		result.OK = true
+	default:
+		result.Error = fmt.Sprintf("unexpected authorization response (%d)", resp.StatusCode)
+	}
+	return result
+}
+
+// checkGitHubRedirect starts an authorization request. GitHub answers an
+// unregistered redirect URI by redirecting to the registered callback with
+// error=redirect_uri_mismatch, and an unknown client ID with 404.
+func checkGitHubRedirect(ctx context.Context, client *http.Client, cfg Config) CheckResult {
+	result := CheckResult{Check: CheckRedirectURL}
+	params := url.Values{
+		"client_id":    {cfg.ClientID},
+		"redirect_uri": {cfg.RedirectURL},
+		"scope":        {"read:user user:email"},
+	}
+	resp, err := get(ctx, client, githubCheckAuthorizeURL+"?"+params.Encode())
+	if err != nil {
+		result.Error = fmt.Sprintf("authorization endpoint unreachable: %v", err)
+		return result
+	}
+	resp.Body.Close()
+
+	location := resp.Header.Get("Location")
+	switch {
+	case strings.Contains(location, "redirect_uri_mismatch"):
+		result.Error = fmt.Sprintf("redirect URL %s does not match the app's callback URL", cfg.RedirectURL)
+		result.Hint = "Set the Authorization callback URL of the GitHub OAuth app to this URL"
+	case resp.StatusCode == http.StatusNotFound:
+		result.Error = "GitHub does not recognize this client ID"
+		result.Hint = "Copy the Client ID from the GitHub OAuth app settings"
+	case resp.StatusCode < 400:
+		result.OK = true
+	default:
+		result.Error = fmt.Sprintf("unexpected authorization response (%d)", resp.StatusCode)
+	}
+	return result
+}
+
+// checkCredentials exchanges a bogus authorization code. With valid client
+// credentials the provider rejects only the code (validCodeError); otherwise it
+// reports a client authentication error.
+func checkCredentials(ctx context.Context, client *http.Client, tokenURL string, cfg Config, validCodeError string) CheckResult {
+	result := CheckResult{Check: CheckCredentials}
+	form := url.Values{
+		"client_id":     {cfg.ClientID},
+		"client_secret": {cfg.ClientSecret},
+		"code":          {"sblite-config-check"},
+		"grant_type":    {"authorization_code"},
+		"redirect_uri":  {cfg.RedirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Error = fmt.Sprintf("token endpoint unreachable: %v", err)
+		return result
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body)
+	switch body.Error {
+	case validCodeError:
+		result.OK = true
+	case "invalid_client", "unauthorized_client", "incorrect_client_credentials":
+		result.Error = "client secret was rejected by the provider"
+		result.Hint = "Generate a new client secret and save it in the OAuth settings"
+	default:
+		result.Error = fmt.Sprintf("unexpected token response (%d %s)", resp.StatusCode, body.Error)
+	}
+	return result
+}
+
+func get(ctx context.Context, client *http.Client, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
@@ -0,0 +1,147 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const checkRedirectURL = "https://app.example.com/auth/v1/callback"
+
+// fakeGoogle serves discovery, authorize and token endpoints that accept only
+// client "good-id" with secret "good-secret" and checkRedirectURL.
+func fakeGoogle(t *testing.T) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/discovery", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"authorization_endpoint": "x", "token_endpoint": "y"})
+	})
+	mux.HandleFunc("/auth", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("client_id") != "good-id":
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, "Error 401: invalid_client")
+		case r.URL.Query().Get("redirect_uri") != checkRedirectURL:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Error 400: redirect_uri_mismatch")
+		default:
+			http.Redirect(w, r, "/signin", http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.WriteHeader(http.StatusBadRequest)
+		if r.PostForm.Get("client_secret") != "good-secret" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	origDiscovery, origAuth, origToken := googleDiscoveryURL, googleAuthorizeURL, googleTokenURL
+	googleDiscoveryURL, googleAuthorizeURL, googleTokenURL = srv.URL+"/discovery", srv.URL+"/auth", srv.URL+"/token"
+	t.Cleanup(func() {
+		googleDiscoveryURL, googleAuthorizeURL, googleTokenURL = origDiscovery, origAuth, origToken
+	})
+}
+
+func checkByName(results []CheckResult) map[string]CheckResult {
+	m := make(map[string]CheckResult, len(results))
+	for _, r := range results {
+		m[r.Check] = r
+	}
+	return m
+}
+
+func TestCheckProviderGoogle(t *testing.T) {
+	fakeGoogle(t)
+	ctx := context.Background()
+
+	results, err := CheckProvider(ctx, nil, "google", Config{ClientID: "good-id", ClientSecret: "good-secret", RedirectURL: checkRedirectURL})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	for _, r := range results {
+		assert.True(t, r.OK, "%s: %s", r.Check, r.Error)
+	}
+
+	results, err = CheckProvider(ctx, nil, "google", Config{ClientID: "good-id", ClientSecret: "good-secret", RedirectURL: "https://other.example.com/cb"})
+	require.NoError(t, err)
+	redirect := checkByName(results)[CheckRedirectURL]
+	assert.False(t, redirect.OK)
+	assert.Contains(t, redirect.Error, "not registered")
+	assert.NotEmpty(t, redirect.Hint)
+
+	results, err = CheckProvider(ctx, nil, "google", Config{ClientID: "bad-id", ClientSecret: "bad-secret", RedirectURL: checkRedirectURL})
+	require.NoError(t, err)
+	checks := checkByName(results)
+	assert.Contains(t, checks[CheckRedirectURL].Error, "client ID")
+	assert.Contains(t, checks[CheckCredentials].Error, "client secret")
+}
+
+func TestCheckProviderGitHub(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Get("client_id") == "":
+			w.WriteHeader(http.StatusOK) // plain reachability probe
+		case r.URL.Query().Get("client_id") != "good-id":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Query().Get("redirect_uri") != checkRedirectURL:
+			http.Redirect(w, r, checkRedirectURL+"?error=redirect_uri_mismatch", http.StatusFound)
+		default:
+			http.Redirect(w, r, "/login", http.StatusFound)
+		}
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("client_secret") != "good-secret" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "incorrect_client_credentials"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"error": "bad_verification_code"})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	origAuth, origToken := githubCheckAuthorizeURL, githubCheckTokenURL
+	githubCheckAuthorizeURL, githubCheckTokenURL = srv.URL+"/authorize", srv.URL+"/token"
+	defer func() { githubCheckAuthorizeURL, githubCheckTokenURL = origAuth, origToken }()
+
+	ctx := context.Background()
+	results, err := CheckProvider(ctx, nil, "github", Config{ClientID: "good-id", ClientSecret: "good-secret", RedirectURL: checkRedirectURL})
+	require.NoError(t, err)
+	for _, r := range results {
+		assert.True(t, r.OK, "%s: %s", r.Check, r.Error)
+	}
+
+	results, err = CheckProvider(ctx, nil, "github", Config{ClientID: "good-id", ClientSecret: "wrong", RedirectURL: "https://other.example.com/cb"})
+	require.NoError(t, err)
+	checks := checkByName(results)
+	assert.Contains(t, checks[CheckRedirectURL].Error, "callback URL")
+	assert.False(t, checks[CheckCredentials].OK)
+}
+
+func TestCheckProviderConfig(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := CheckProvider(ctx, nil, "myspace", Config{})
+	assert.ErrorIs(t, err, ErrProviderNotFound)
+
+	results, err := CheckProvider(ctx, nil, "github", Config{ClientSecret: "s", RedirectURL: checkRedirectURL})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "client ID is not set", results[0].Error)
+
+	results, err = CheckProvider(ctx, nil, "github", Config{ClientID: "id", ClientSecret: "s", RedirectURL: "/auth/v1/callback"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Error, "not an absolute URL")
+}
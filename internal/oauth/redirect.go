@@ -0,0 +1,124 @@
+package oauth
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidRedirectPattern is returned for redirect URL patterns that are
+// malformed or too broad to be safe.
+var ErrInvalidRedirectPattern = errors.New("invalid redirect URL pattern")
+
+// ValidateRedirectPattern checks an allowed redirect URL pattern.
+//
+// Patterns are URLs whose host and path may contain glob wildcards: in the host
+// "*" matches one DNS label and may only replace whole leftmost labels; in the
+// path "*" matches within one segment and "**" matches any number of segments.
+// A pattern must name a scheme and keep at least two literal host labels after
+// its wildcards, so "*", "https://*" and "https://*.com" are rejected.
+func ValidateRedirectPattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("%w: pattern is empty", ErrInvalidRedirectPattern)
+	}
+	u, err := url.Parse(pattern)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%w: %q must be an absolute URL such as https://app.example.com/**", ErrInvalidRedirectPattern, pattern)
+	}
+	if strings.Contains(u.Scheme, "*") {
+		return fmt.Errorf("%w: scheme cannot contain wildcards", ErrInvalidRedirectPattern)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: pattern cannot contain user info", ErrInvalidRedirectPattern)
+	}
+	if strings.Contains(u.Port(), "*") {
+		return fmt.Errorf("%w: port cannot contain wildcards", ErrInvalidRedirectPattern)
+	}
+
+	labels := strings.Split(u.Hostname(), ".")
+	literal := 0
+	for i, label := range labels {
+		switch {
+		case label == "*":
+			if literal > 0 {
+				return fmt.Errorf("%w: host wildcards must be leftmost labels", ErrInvalidRedirectPattern)
+			}
+		case strings.Contains(label, "*"):
+			if i != 0 {
+				return fmt.Errorf("%w: host wildcards must be leftmost labels", ErrInvalidRedirectPattern)
+			}
+		default:
+			literal++
+		}
+	}
+	if literal < len(labels) && literal < 2 {
+		// e.g. "*.com" or "app-*.io" would match hosts anyone can register
+		return fmt.Errorf("%w: %q matches too many hosts; keep at least two literal host labels (e.g. *.example.com)", ErrInvalidRedirectPattern, u.Host)
+	}
+	return nil
+}
+
+// MatchRedirectURL reports whether candidate is allowed by pattern. Scheme and
+// port must match exactly. A pattern without path wildcards allows any path
+// under its own path, so "http://localhost:3000" allows every local path.
+// Query strings and fragments are ignored.
+func MatchRedirectURL(pattern, candidate string) bool {
+	p, err := url.Parse(pattern)
+	if err != nil || p.Host == "" {
+		return false
+	}
+	c, err := url.Parse(candidate)
+	if err != nil || c.Host == "" || c.User != nil {
+		return false
+	}
+
+	if !strings.EqualFold(p.Scheme, c.Scheme) || p.Port() != c.Port() {
+		return false
+	}
+	if !hostGlob(strings.ToLower(p.Hostname())).MatchString(strings.ToLower(c.Hostname())) {
+		return false
+	}
+
+	if !strings.Contains(p.Path, "*") {
+		if p.Path == "" || p.Path == "/" {
+			return true
+		}
+		return strings.HasPrefix(c.Path, p.Path)
+	}
+	return pathGlob(p.Path).MatchString(c.Path)
+}
+
+// hostGlob compiles a host pattern where "*" matches within one label.
+func hostGlob(host string) *regexp.Regexp {
+	parts := strings.Split(host, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, "[^.]+") + "$")
+}
+
+// pathGlob compiles a path pattern where "**" matches across segments and "*"
+// matches within one segment.
+func pathGlob(path string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(path); i++ {
+		switch {
+		case strings.HasPrefix(path[i:], "/**"):
+			// "/**" also matches the bare parent path
+			b.WriteString("(/.*)?")
+			i += 2
+		case strings.HasPrefix(path[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case path[i] == '*':
+			b.WriteString("[^/]*")
+		default:
+			b.WriteString(regexp.QuoteMeta(path[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
@@ -0,0 +1,73 @@
+package oauth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRedirectPattern(t *testing.T) {
+	valid := []string{
+		"http://localhost:3000",
+		"https://app.example.com/auth/callback",
+		"https://*.vercel.app/**",
+		"https://preview-*.myapp.vercel.app/auth/*",
+		"myapp://callback",
+	}
+	for _, p := range valid {
+		assert.NoError(t, ValidateRedirectPattern(p), p)
+	}
+
+	invalid := []string{
+		"",
+		"*",
+		"**",
+		"/auth/callback",
+		"https://*",
+		"https://*.com",
+		"https://app-*.io",
+		"https://*.*.com",
+		"https://app.*.example.com",
+		"http*://app.example.com",
+		"https://app.example.com:*",
+		"https://user@app.example.com",
+	}
+	for _, p := range invalid {
+		assert.ErrorIs(t, ValidateRedirectPattern(p), ErrInvalidRedirectPattern, p)
+	}
+}
+
+func TestMatchRedirectURL(t *testing.T) {
+	tests := []struct {
+		pattern   string
+		candidate string
+		match     bool
+	}{
+		// Exact and prefix patterns keep their existing meaning
+		{"http://localhost:3000", "http://localhost:3000/any/path", true},
+		{"http://localhost:3000/auth", "http://localhost:3000/auth/callback", true},
+		{"http://localhost:3000/auth", "http://localhost:3000/other", false},
+		{"http://localhost:3000", "http://localhost:4000/", false},
+		{"http://localhost:3000", "https://localhost:3000/", false},
+
+		// Host wildcards match exactly one label
+		{"https://*.vercel.app/**", "https://my-app-git-main.vercel.app/auth/callback", true},
+		{"https://*.vercel.app/**", "https://my-app.vercel.app", true},
+		{"https://*.vercel.app/**", "https://vercel.app/", false},
+		{"https://*.vercel.app/**", "https://a.b.vercel.app/", false},
+		{"https://*.vercel.app/**", "https://evil.com/?x=.vercel.app", false},
+		{"https://*.vercel.app/**", "https://evil.com#.vercel.app", false},
+		{"https://*.vercel.app/**", "https://x.vercel.app@evil.com/", false},
+		{"https://preview-*.example.com", "https://preview-42.example.com/", true},
+		{"https://preview-*.example.com", "https://prod.example.com/", false},
+
+		// Path wildcards
+		{"https://app.example.com/auth/*", "https://app.example.com/auth/callback", true},
+		{"https://app.example.com/auth/*", "https://app.example.com/auth/a/b", false},
+		{"https://app.example.com/auth/**", "https://app.example.com/auth/a/b", true},
+		{"https://app.example.com/auth/**", "https://app.example.com/authx", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.match, MatchRedirectURL(tt.pattern, tt.candidate), "%s vs %s", tt.pattern, tt.candidate)
+	}
+}
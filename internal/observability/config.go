@@ -14,6 +14,9 @@ type Config struct {
 	// Trace sampling rate (0.0 to 1.0)
 	SampleRate float64
 
+	// Per-route-prefix sampling rates that override SampleRate
+	RouteSampling []RouteSampleRate
+
 	// Enable metrics collection
 	MetricsEnabled bool
 
@@ -0,0 +1,28 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RecordFunctionInvocation stores an edge function invocation for the traces
+// view. ctx must carry the function's span; its parent (the HTTP request span)
+// is recorded so the dashboard can nest the invocation under the request.
+// Safe to call on a nil Telemetry.
+func (t *Telemetry) RecordFunctionInvocation(ctx context.Context, name string, status int, duration time.Duration, parentSpanID trace.SpanID) {
+	if t == nil {
+		return
+	}
+
+	tags := fmt.Sprintf("function.name:%s,http.status_code:%d", name, status)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		tags += fmt.Sprintf(",trace_id:%s,span_id:%s", sc.TraceID(), sc.SpanID())
+		if parentSpanID.IsValid() {
+			tags += fmt.Sprintf(",parent_span_id:%s", parentSpanID)
+		}
+	}
+	go t.StoreMetric(time.Now().Unix(), "function.invoke_duration_ms", float64(duration.Milliseconds()), tags)
+}
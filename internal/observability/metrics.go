@@ -22,6 +22,12 @@ type Metrics struct {
 	HTTPRequestCount    metric.Int64Counter
 	HTTPRequestDuration metric.Float64Histogram
 	HTTPResponseSize    metric.Int64Histogram
+
+	// Storage metrics
+	StorageOperationCount  metric.Int64Counter
+	StorageErrorCount      metric.Int64Counter
+	StorageBytesUploaded   metric.Int64Counter
+	StorageBytesDownloaded metric.Int64Counter
 }
 
 // InitMetrics initializes and returns metric instruments.
@@ -58,6 +64,42 @@ func InitMetrics(mp metric.MeterProvider) (*Metrics, error) {
 		return nil, fmt.Errorf("failed to create response size histogram: %w", err)
 	}
 
+	m.StorageOperationCount, err = meter.Int64Counter(
+		"storage.operation_count",
+		metric.WithDescription("Number of storage operations"),
+		metric.WithUnit("{operation}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage operation counter: %w", err)
+	}
+
+	m.StorageErrorCount, err = meter.Int64Counter(
+		"storage.error_count",
+		metric.WithDescription("Number of failed storage operations"),
+		metric.WithUnit("{operation}"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage error counter: %w", err)
+	}
+
+	m.StorageBytesUploaded, err = meter.Int64Counter(
+		"storage.bytes_uploaded",
+		metric.WithDescription("Bytes written to storage"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage upload bytes counter: %w", err)
+	}
+
+	m.StorageBytesDownloaded, err = meter.Int64Counter(
+		"storage.bytes_downloaded",
+		metric.WithDescription("Bytes read from storage"),
+		metric.WithUnit("By"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create storage download bytes counter: %w", err)
+	}
+
 	return m, nil
 }
 
@@ -5,9 +5,11 @@ import (
 	"net/http"
 	"time"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -35,9 +37,12 @@ func HTTPMiddleware(tel *Telemetry, serviceName string) func(http.Handler) http.
 			// Create span name from method and route
 			spanName := r.Method + " " + r.URL.Path
 
-			// Start span
+			// Continue the caller's trace if it sent a traceparent header
+			parentCtx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			// Start span; per-route sampling overrides match its http.target attribute
 			ctx, span := tracer.Start(
-				r.Context(),
+				parentCtx,
 				spanName,
 				trace.WithAttributes(attrs...),
 			)
@@ -75,6 +80,10 @@ func HTTPMiddleware(tel *Telemetry, serviceName string) func(http.Handler) http.
 			// Store metrics to database for dashboard visualization
 			timestamp := start.Unix()
 			tags := fmt.Sprintf("http.method:%s,http.status_code:%d", r.Method, rw.status)
+			if sc := span.SpanContext(); sc.IsValid() {
+				// Lets the traces view attach child spans, such as edge function invocations
+				tags += fmt.Sprintf(",trace_id:%s,span_id:%s", sc.TraceID(), sc.SpanID())
+			}
 			go tel.StoreMetric(timestamp, "http.server.request_count", 1, tags)
 			go tel.StoreMetric(timestamp, "http.server.request_duration_ms", float64(duration.Milliseconds()), tags)
 
@@ -0,0 +1,144 @@
+package observability
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// RouteSampleRate overrides the trace sampling rate for requests whose path
+// starts with Prefix. The longest matching prefix wins.
+type RouteSampleRate struct {
+	Prefix string  `json:"prefix"`
+	Rate   float64 `json:"rate"`
+}
+
+// ParseRouteSampling parses a comma-separated list of prefix=rate pairs,
+// e.g. "/_/static=0,/health=0,/rest/v1=1".
+func ParseRouteSampling(s string) ([]RouteSampleRate, error) {
+	var routes []RouteSampleRate
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		prefix, rateStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid route sampling %q: expected prefix=rate", pair)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateStr), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route sampling rate %q", rateStr)
+		}
+		routes = append(routes, RouteSampleRate{Prefix: strings.TrimSpace(prefix), Rate: rate})
+	}
+	if err := validateRouteSampling(routes); err != nil {
+		return nil, err
+	}
+	return routes, nil
+}
+
+func validateRouteSampling(routes []RouteSampleRate) error {
+	seen := make(map[string]bool)
+	for _, r := range routes {
+		if !strings.HasPrefix(r.Prefix, "/") {
+			return fmt.Errorf("route prefix %q must start with /", r.Prefix)
+		}
+		if r.Rate < 0 || r.Rate > 1 {
+			return fmt.Errorf("sample rate for %s must be between 0 and 1", r.Prefix)
+		}
+		if seen[r.Prefix] {
+			return fmt.Errorf("duplicate route prefix %q", r.Prefix)
+		}
+		seen[r.Prefix] = true
+	}
+	return nil
+}
+
+// routeSampler applies per-route sampling overrides to HTTP request spans,
+// identified by their http.target start attribute. Other spans, and requests
+// without an override, use the base sampler.
+type routeSampler struct {
+	base sdktrace.Sampler
+
+	mu     sync.RWMutex
+	routes []RouteSampleRate // longest prefix first
+}
+
+func newRouteSampler(base sdktrace.Sampler, routes []RouteSampleRate) *routeSampler {
+	s := &routeSampler{base: base}
+	s.set(routes)
+	return s
+}
+
+func (s *routeSampler) set(routes []RouteSampleRate) {
+	sorted := append([]RouteSampleRate(nil), routes...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i].Prefix) > len(sorted[j].Prefix)
+	})
+	s.mu.Lock()
+	s.routes = sorted
+	s.mu.Unlock()
+}
+
+func (s *routeSampler) get() []RouteSampleRate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	routes := append([]RouteSampleRate(nil), s.routes...)
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Prefix < routes[j].Prefix })
+	return routes
+}
+
+// rateFor returns the override for path, if any.
+func (s *routeSampler) rateFor(path string) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.routes {
+		if strings.HasPrefix(path, r.Prefix) {
+			return r.Rate, true
+		}
+	}
+	return 0, false
+}
+
+func (s *routeSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	for _, attr := range p.Attributes {
+		if attr.Key != AttrHTTPTarget {
+			continue
+		}
+		if rate, ok := s.rateFor(attr.Value.AsString()); ok {
+			return sdktrace.TraceIDRatioBased(rate).ShouldSample(p)
+		}
+		break
+	}
+	return s.base.ShouldSample(p)
+}
+
+func (s *routeSampler) Description() string {
+	return "RouteSampler{" + s.base.Description() + "}"
+}
+
+// RouteSampling returns the current per-route sampling overrides.
+func (t *Telemetry) RouteSampling() []RouteSampleRate {
+	if t.sampler == nil {
+		return []RouteSampleRate{}
+	}
+	return t.sampler.get()
+}
+
+// SetRouteSampling replaces the per-route sampling overrides. The change
+// applies to requests started after the call.
+func (t *Telemetry) SetRouteSampling(routes []RouteSampleRate) error {
+	if err := validateRouteSampling(routes); err != nil {
+		return err
+	}
+	if t.sampler == nil {
+		return fmt.Errorf("tracing is not enabled")
+	}
+	t.sampler.set(routes)
+	return nil
+}
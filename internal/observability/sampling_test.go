@@ -0,0 +1,73 @@
+package observability
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestParseRouteSampling(t *testing.T) {
+	routes, err := ParseRouteSampling("/_/static=0, /rest/v1=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(routes) != 2 || routes[0] != (RouteSampleRate{"/_/static", 0}) || routes[1] != (RouteSampleRate{"/rest/v1", 1}) {
+		t.Errorf("unexpected routes: %+v", routes)
+	}
+
+	for _, bad := range []string{"/health", "health=0", "/health=2", "/a=0,/a=1", "/a=x"} {
+		if _, err := ParseRouteSampling(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestRouteSampler(t *testing.T) {
+	sampler := newRouteSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(0)), []RouteSampleRate{
+		{Prefix: "/rest/v1", Rate: 1},
+		{Prefix: "/rest/v1/health", Rate: 0},
+	})
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSampler(sampler)).Tracer("test")
+
+	sampled := func(path string) bool {
+		_, span := tracer.Start(context.Background(), "req", trace.WithAttributes(AttrHTTPTarget.String(path)))
+		defer span.End()
+		return span.SpanContext().IsSampled()
+	}
+
+	if !sampled("/rest/v1/todos") {
+		t.Error("expected /rest/v1 override to sample")
+	}
+	if sampled("/rest/v1/health") {
+		t.Error("expected longest prefix /rest/v1/health to win")
+	}
+	if sampled("/auth/v1/token") {
+		t.Error("expected base rate of 0 without an override")
+	}
+
+	// Overrides can be changed at runtime
+	sampler.set([]RouteSampleRate{{Prefix: "/auth", Rate: 1}})
+	if !sampled("/auth/v1/token") || sampled("/rest/v1/todos") {
+		t.Error("expected updated overrides to apply")
+	}
+}
+
+func TestSetRouteSamplingValidates(t *testing.T) {
+	tel := &Telemetry{sampler: newRouteSampler(sdktrace.AlwaysSample(), nil)}
+	if err := tel.SetRouteSampling([]RouteSampleRate{{Prefix: "/x", Rate: 1.5}}); err == nil {
+		t.Error("expected error for rate above 1")
+	}
+	if err := tel.SetRouteSampling([]RouteSampleRate{{Prefix: "/b", Rate: 0}, {Prefix: "/a", Rate: 1}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	routes := tel.RouteSampling()
+	if len(routes) != 2 || routes[0].Prefix != "/a" {
+		t.Errorf("expected routes sorted by prefix, got %+v", routes)
+	}
+
+	if err := (&Telemetry{}).SetRouteSampling(nil); err == nil {
+		t.Error("expected error when tracing is disabled")
+	}
+}
@@ -0,0 +1,71 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Storage operation names used as the storage.operation tag.
+const (
+	StorageOpUpload   = "upload"
+	StorageOpDownload = "download"
+	StorageOpDelete   = "delete"
+	StorageOpCopy     = "copy"
+	StorageOpMove     = "move"
+	StorageOpList     = "list"
+)
+
+// RecordStorageOperation records a storage operation on a bucket: its count,
+// whether it failed, and the bytes transferred for uploads and downloads.
+// Like HTTP metrics, it is exported via OTel and stored for the dashboard.
+// Safe to call on a nil Telemetry.
+func (t *Telemetry) RecordStorageOperation(op, bucket string, bytes int64, err error) {
+	if t == nil {
+		return
+	}
+
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+
+	if metrics := t.Metrics(); metrics != nil {
+		ctx := context.Background()
+		attrs := metric.WithAttributes(
+			AttrStorageOperation.String(op),
+			AttrStorageBucket.String(bucket),
+			attribute.String("storage.status", status),
+		)
+		metrics.StorageOperationCount.Add(ctx, 1, attrs)
+		if err != nil {
+			metrics.StorageErrorCount.Add(ctx, 1, attrs)
+		} else if bytes > 0 {
+			switch op {
+			case StorageOpUpload:
+				metrics.StorageBytesUploaded.Add(ctx, bytes, attrs)
+			case StorageOpDownload:
+				metrics.StorageBytesDownloaded.Add(ctx, bytes, attrs)
+			}
+		}
+	}
+
+	timestamp := time.Now().Unix()
+	tags := fmt.Sprintf("storage.operation:%s,storage.bucket:%s,storage.status:%s", op, bucket, status)
+	go t.StoreMetric(timestamp, "storage.operation_count", 1, tags)
+	if err != nil {
+		go t.StoreMetric(timestamp, "storage.error_count", 1, tags)
+		return
+	}
+	if bytes > 0 {
+		switch op {
+		case StorageOpUpload:
+			go t.StoreMetric(timestamp, "storage.bytes_uploaded", float64(bytes), tags)
+		case StorageOpDownload:
+			go t.StoreMetric(timestamp, "storage.bytes_downloaded", float64(bytes), tags)
+		}
+	}
+}
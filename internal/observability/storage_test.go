@@ -0,0 +1,79 @@
+package observability
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markb/sblite/internal/db"
+	_ "modernc.org/sqlite"
+)
+
+func TestRecordStorageOperation(t *testing.T) {
+	conn, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open db: %v", err)
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	if err := db.CreateMetricsTables(conn); err != nil {
+		t.Fatalf("failed to create metrics tables: %v", err)
+	}
+
+	tel, cleanup, err := Init(context.Background(), NewConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer cleanup()
+	tel.SetDB(conn)
+
+	tel.RecordStorageOperation(StorageOpUpload, "avatars", 1024, nil)
+	tel.RecordStorageOperation(StorageOpDownload, "docs", 0, errors.New("not found"))
+
+	// Metrics are buffered asynchronously
+	want := map[string]string{
+		"storage.operation_count": "",
+		"storage.bytes_uploaded":  "storage.operation:upload,storage.bucket:avatars,storage.status:ok",
+		"storage.error_count":     "storage.operation:download,storage.bucket:docs,storage.status:error",
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if err := tel.FlushMetrics(); err != nil {
+			t.Fatalf("flush failed: %v", err)
+		}
+		var count int
+		conn.QueryRow(`SELECT COUNT(*) FROM _observability_metrics WHERE metric_name LIKE 'storage.%'`).Scan(&count)
+		if count >= 4 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for name, tags := range want {
+		query := `SELECT COUNT(*) FROM _observability_metrics WHERE metric_name = ?`
+		args := []interface{}{name}
+		if tags != "" {
+			query += ` AND tags = ?`
+			args = append(args, tags)
+		}
+		var count int
+		if err := conn.QueryRow(query, args...).Scan(&count); err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if count == 0 {
+			t.Errorf("expected metric %s with tags %q", name, tags)
+		}
+	}
+
+	var uploaded float64
+	conn.QueryRow(`SELECT value FROM _observability_metrics WHERE metric_name = 'storage.bytes_uploaded'`).Scan(&uploaded)
+	if uploaded != 1024 {
+		t.Errorf("expected 1024 bytes uploaded, got %f", uploaded)
+	}
+
+	// A nil telemetry is a no-op
+	var nilTel *Telemetry
+	nilTel.RecordStorageOperation(StorageOpDelete, "docs", 0, nil)
+}
@@ -10,6 +10,8 @@ import (
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -23,6 +25,7 @@ type Telemetry struct {
 	db             *sql.DB
 	shutdownFunc   func(context.Context) error
 	_shutdownOnce  sync.Once
+	sampler        *routeSampler
 	metricsMu      sync.RWMutex
 	metricsBuffer  []metricData
 	stopFlusher    chan struct{}
@@ -48,12 +51,18 @@ func Init(ctx context.Context, cfg *Config) (*Telemetry, func(), error) {
 
 	// Initialize tracer provider if enabled
 	if cfg.TracesEnabled {
-		tp, err := initTracerProvider(ctx, cfg)
+		tel.sampler = newRouteSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRate)), cfg.RouteSampling)
+		tp, err := initTracerProvider(ctx, cfg, tel.sampler)
 		if err != nil {
 			return nil, nil, err
 		}
 		tel.tracerProvider = tp
 		otel.SetTracerProvider(tp)
+		// W3C trace context, so traces continue from callers and into edge functions
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		))
 	}
 
 	// Initialize meter provider if enabled
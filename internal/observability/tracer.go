@@ -18,7 +18,7 @@ import (
 )
 
 // initTracerProvider initializes the trace provider based on config.
-func initTracerProvider(ctx context.Context, cfg *Config) (trace.TracerProvider, error) {
+func initTracerProvider(ctx context.Context, cfg *Config, sampler sdktrace.Sampler) (trace.TracerProvider, error) {
 	var exporter sdktrace.SpanExporter
 	var err error
 
@@ -67,11 +67,6 @@ func initTracerProvider(ctx context.Context, cfg *Config) (trace.TracerProvider,
 		return nil, fmt.Errorf("failed to create resource: %w", err)
 	}
 
-	// Create sampler based on config
-	sampler := sdktrace.ParentBased(
-		sdktrace.TraceIDRatioBased(cfg.SampleRate),
-	)
-
 	// Create tracer provider
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithBatcher(exporter),
@@ -84,16 +79,19 @@ func initTracerProvider(ctx context.Context, cfg *Config) (trace.TracerProvider,
 
 // Common span attributes
 var (
-	AttrHTTPMethod     = attribute.Key("http.method")
-	AttrHTTPRoute      = attribute.Key("http.route")
-	AttrHTTPStatusCode = attribute.Key("http.status_code")
-	AttrHTTPTarget     = attribute.Key("http.target")
-	AttrHTTPScheme     = attribute.Key("http.scheme")
-	AttrHTTPHost       = attribute.Key("http.host")
-	AttrHTTPRemoteAddr = attribute.Key("http.remote_addr")
-	AttrUserID         = attribute.Key("user.id")
-	AttrUserRole       = attribute.Key("user.role")
-	AttrDBName         = attribute.Key("db.name")
-	AttrDBTable        = attribute.Key("db.table")
-	AttrDBOperation    = attribute.Key("db.operation")
+	AttrHTTPMethod       = attribute.Key("http.method")
+	AttrHTTPRoute        = attribute.Key("http.route")
+	AttrHTTPStatusCode   = attribute.Key("http.status_code")
+	AttrHTTPTarget       = attribute.Key("http.target")
+	AttrHTTPScheme       = attribute.Key("http.scheme")
+	AttrHTTPHost         = attribute.Key("http.host")
+	AttrHTTPRemoteAddr   = attribute.Key("http.remote_addr")
+	AttrUserID           = attribute.Key("user.id")
+	AttrUserRole         = attribute.Key("user.role")
+	AttrDBName           = attribute.Key("db.name")
+	AttrDBTable          = attribute.Key("db.table")
+	AttrDBOperation      = attribute.Key("db.operation")
+	AttrStorageOperation = attribute.Key("storage.operation")
+	AttrStorageBucket    = attribute.Key("storage.bucket")
+	AttrFunctionName     = attribute.Key("function.name")
 )
@@ -872,7 +872,8 @@ func (h *Handler) HandleDelete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// validateRow validates a row's data against the schema metadata for a table.
+// validateRow validates a row's data against the schema metadata for a table,
+// including the allowed values of enum columns.
 // If no schema metadata exists for the table (raw SQL table), validation is skipped.
 func (h *Handler) validateRow(table string, data map[string]any) error {
 	if h.schema == nil {
@@ -889,6 +890,11 @@ func (h *Handler) validateRow(table string, data map[string]any) error {
 		return nil
 	}
 
+	enums, err := h.schema.GetEnumValues(table)
+	if err != nil {
+		return fmt.Errorf("failed to load schema for validation: %w", err)
+	}
+
 	for colName, value := range data {
 		col, ok := cols[colName]
 		if !ok {
@@ -898,6 +904,11 @@ func (h *Handler) validateRow(table string, data map[string]any) error {
 		if err := types.Validate(types.PgType(col.PgType), value); err != nil {
 			return fmt.Errorf("column %q: %w", colName, err)
 		}
+		if allowed, ok := enums[colName]; ok {
+			if err := types.ValidateEnum(allowed, value); err != nil {
+				return fmt.Errorf("column %q: %w", colName, err)
+			}
+		}
 
 		// Check nullable
 		if !col.IsNullable && value == nil {
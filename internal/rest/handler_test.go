@@ -1681,6 +1681,26 @@ func TestValidateRow(t *testing.T) {
 			t.Errorf("expected error to mention column 'age', got: %v", err)
 		}
 	})
+
+	t.Run("enum column - only allowed values pass", func(t *testing.T) {
+		handler, database := setupTestHandlerWithSchema(t)
+		defer database.Close()
+
+		if _, err := database.Exec(`UPDATE _columns SET enum_values = '["Ann","Bob"]' WHERE table_name = 'profiles' AND column_name = 'name'`); err != nil {
+			t.Fatalf("failed to set enum values: %v", err)
+		}
+
+		if err := handler.validateRow("profiles", map[string]any{"name": "Ann"}); err != nil {
+			t.Errorf("expected allowed value to pass validation, got error: %v", err)
+		}
+		err := handler.validateRow("profiles", map[string]any{"name": "Carol"})
+		if err == nil {
+			t.Fatal("expected value outside the enum to fail validation")
+		}
+		if !strings.Contains(err.Error(), "name") || !strings.Contains(err.Error(), "Ann, Bob") {
+			t.Errorf("expected error to name the column and allowed values, got: %v", err)
+		}
+	})
 }
 
 func TestIsInternalTable(t *testing.T) {
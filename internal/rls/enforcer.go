@@ -111,6 +111,36 @@ func (e *Enforcer) GetUpdateConditions(tableName string, ctx *AuthContext) (stri
 	return strings.Join(conditions, " AND "), nil
 }
 
+// GetUpdateCheckConditions returns CHECK conditions the updated rows of
+// UPDATE queries must pass
+func (e *Enforcer) GetUpdateCheckConditions(tableName string, ctx *AuthContext) (string, error) {
+	// service_role bypasses RLS
+	if ctx != nil && ctx.BypassRLS {
+		return "", nil
+	}
+
+	policies, err := e.policyService.GetPoliciesForTable(tableName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get policies for %s: %w", tableName, err)
+	}
+
+	var conditions []string
+	for _, p := range policies {
+		if p.Command == "UPDATE" || p.Command == "ALL" {
+			if p.CheckExpr != "" {
+				substituted := substituteAllFunctions(p.CheckExpr, ctx)
+				conditions = append(conditions, "("+substituted+")")
+			}
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	return strings.Join(conditions, " AND "), nil
+}
+
 // GetDeleteConditions returns WHERE conditions for DELETE queries
 func (e *Enforcer) GetDeleteConditions(tableName string, ctx *AuthContext) (string, error) {
 	// service_role bypasses RLS
@@ -148,6 +148,40 @@ func TestEnforcerUpdateConditions(t *testing.T) {
 	}
 }
 
+func TestEnforcerUpdateCheckConditions(t *testing.T) {
+	database := setupTestDB(t)
+	defer database.Close()
+
+	policyService := NewService(database)
+	enforcer := NewEnforcer(policyService)
+
+	// Only the CHECK expression applies to the updated rows
+	_, err := policyService.CreatePolicy("todos", "user_update", "UPDATE", "user_id = auth.uid()", "owner = auth.uid()")
+	if err != nil {
+		t.Fatalf("failed to create policy: %v", err)
+	}
+
+	ctx := &AuthContext{
+		UserID: "user-789",
+		Role:   "authenticated",
+	}
+
+	conditions, err := enforcer.GetUpdateCheckConditions("todos", ctx)
+	if err != nil {
+		t.Fatalf("failed to get conditions: %v", err)
+	}
+
+	expected := "(owner = 'user-789')"
+	if conditions != expected {
+		t.Errorf("expected %q, got %q", expected, conditions)
+	}
+
+	conditions, _ = enforcer.GetUpdateCheckConditions("todos", &AuthContext{BypassRLS: true})
+	if conditions != "" {
+		t.Errorf("expected no conditions for service_role, got %q", conditions)
+	}
+}
+
 func TestEnforcerDeleteConditions(t *testing.T) {
 	database := setupTestDB(t)
 	defer database.Close()
@@ -5,6 +5,7 @@ package schema
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 
 	"github.com/markb/sblite/internal/types"
@@ -129,6 +130,41 @@ func (s *Schema) GetColumns(tableName string) (map[string]Column, error) {
 	return columns, nil
 }
 
+// GetEnumValues returns the allowed values declared for a table's columns,
+// keyed by column name. Columns without enum values are left out.
+func (s *Schema) GetEnumValues(tableName string) (map[string][]string, error) {
+	rows, err := s.db.Query(`
+		SELECT column_name, enum_values
+		FROM _columns
+		WHERE table_name = ? AND enum_values IS NOT NULL AND enum_values != ''
+	`, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enum values for table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	enums := make(map[string][]string)
+	for rows.Next() {
+		var name, raw string
+		if err := rows.Scan(&name, &raw); err != nil {
+			return nil, fmt.Errorf("failed to scan enum values: %w", err)
+		}
+		var values []string
+		if err := json.Unmarshal([]byte(raw), &values); err != nil {
+			return nil, fmt.Errorf("invalid enum values for %s.%s: %w", tableName, name, err)
+		}
+		if len(values) > 0 {
+			enums[name] = values
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating enum rows: %w", err)
+	}
+
+	return enums, nil
+}
+
 // DeleteTableColumns removes all column metadata for a given table.
 func (s *Schema) DeleteTableColumns(tableName string) error {
 	query := `DELETE FROM _columns WHERE table_name = ?`
@@ -0,0 +1,351 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SnapshotColumn is the _columns metadata of one column as captured in a
+// schema snapshot.
+type SnapshotColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Default  string `json:"default,omitempty"`
+	Primary  bool   `json:"primary"`
+}
+
+// TableSnapshot is the definition of one user table: its DDL as stored by
+// SQLite, the DDL of its explicit indexes, and its column metadata.
+type TableSnapshot struct {
+	Name    string           `json:"name"`
+	SQL     string           `json:"sql"`
+	Indexes []string         `json:"indexes"`
+	Columns []SnapshotColumn `json:"columns"`
+}
+
+// SchemaSnapshot is the user-visible schema at a point in time. Tables,
+// indexes and columns are sorted so that equal schemas hash identically.
+type SchemaSnapshot struct {
+	Hash   string          `json:"hash"`
+	Tables []TableSnapshot `json:"tables"`
+}
+
+// SnapshotRecord is a snapshot stored in the _schema_snapshots table.
+type SnapshotRecord struct {
+	ID        int64           `json:"id"`
+	Hash      string          `json:"hash"`
+	Reason    string          `json:"reason"`
+	CreatedAt string          `json:"created_at"`
+	Snapshot  *SchemaSnapshot `json:"snapshot,omitempty"`
+}
+
+// Snapshot captures the current schema of all user tables, skipping
+// internal tables (_*, auth_*, storage_*, sqlite_*) and FTS5 shadow tables.
+func (s *Schema) Snapshot() (*SchemaSnapshot, error) {
+	rows, err := s.db.Query(`
+		SELECT name, COALESCE(sql, '') FROM sqlite_master
+		WHERE type = 'table'
+		AND name NOT LIKE '\_%' ESCAPE '\'
+		AND name NOT LIKE 'auth\_%' ESCAPE '\'
+		AND name NOT LIKE 'storage\_%' ESCAPE '\'
+		AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	var tables []TableSnapshot
+	var virtual []string
+	for rows.Next() {
+		var t TableSnapshot
+		if err := rows.Scan(&t.Name, &t.SQL); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		if strings.HasPrefix(strings.ToUpper(t.SQL), "CREATE VIRTUAL TABLE") {
+			virtual = append(virtual, t.Name)
+		}
+		tables = append(tables, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating table rows: %w", err)
+	}
+
+	snap := &SchemaSnapshot{Tables: []TableSnapshot{}}
+	for _, t := range tables {
+		if isShadowTable(t.Name, virtual) {
+			continue
+		}
+		if t.Indexes, err = s.tableIndexes(t.Name); err != nil {
+			return nil, err
+		}
+		if t.Columns, err = s.snapshotColumns(t.Name); err != nil {
+			return nil, err
+		}
+		snap.Tables = append(snap.Tables, t)
+	}
+
+	data, err := json.Marshal(snap.Tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	snap.Hash = hex.EncodeToString(sum[:])
+	return snap, nil
+}
+
+// isShadowTable reports whether name is one of the tables FTS5 creates to
+// back a virtual table (e.g. posts_fts_body_data for posts_fts_body).
+func isShadowTable(name string, virtual []string) bool {
+	for _, v := range virtual {
+		if strings.HasPrefix(name, v+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// tableIndexes returns the DDL of the explicitly created indexes on a table.
+// Automatic indexes for PRIMARY KEY and UNIQUE constraints have no SQL and
+// are already covered by the table DDL.
+func (s *Schema) tableIndexes(tableName string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT sql FROM sqlite_master
+		WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL
+		ORDER BY name
+	`, tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes for table %s: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	indexes := []string{}
+	for rows.Next() {
+		var ddl string
+		if err := rows.Scan(&ddl); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		indexes = append(indexes, ddl)
+	}
+	return indexes, rows.Err()
+}
+
+// snapshotColumns returns a table's _columns metadata sorted by column name.
+func (s *Schema) snapshotColumns(tableName string) ([]SnapshotColumn, error) {
+	cols, err := s.GetColumns(tableName)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]SnapshotColumn, 0, len(cols))
+	for _, c := range cols {
+		out = append(out, SnapshotColumn{
+			Name:     c.ColumnName,
+			Type:     c.PgType,
+			Nullable: c.IsNullable,
+			Default:  c.DefaultValue,
+			Primary:  c.IsPrimary,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// RecordSnapshot stores the current schema in _schema_snapshots unless it
+// matches the most recent snapshot. It returns the stored record, or nil
+// when the schema is unchanged.
+func (s *Schema) RecordSnapshot(reason string) (*SnapshotRecord, error) {
+	snap, err := s.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	var lastHash string
+	err = s.db.QueryRow(`SELECT hash FROM _schema_snapshots ORDER BY id DESC LIMIT 1`).Scan(&lastHash)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to read latest snapshot: %w", err)
+	}
+	if lastHash == snap.Hash {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(snap.Tables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	createdAt := time.Now().UTC().Format(time.RFC3339)
+	result, err := s.db.Exec(`INSERT INTO _schema_snapshots (hash, reason, tables, created_at) VALUES (?, ?, ?, ?)`,
+		snap.Hash, reason, string(data), createdAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to store snapshot: %w", err)
+	}
+	return &SnapshotRecord{ID: id, Hash: snap.Hash, Reason: reason, CreatedAt: createdAt, Snapshot: snap}, nil
+}
+
+// ListSnapshots returns stored snapshots, newest first, without their
+// table definitions.
+func (s *Schema) ListSnapshots(limit int) ([]SnapshotRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT id, hash, reason, created_at FROM _schema_snapshots
+		ORDER BY id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	records := []SnapshotRecord{}
+	for rows.Next() {
+		var rec SnapshotRecord
+		if err := rows.Scan(&rec.ID, &rec.Hash, &rec.Reason, &rec.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating snapshot rows: %w", err)
+	}
+	return records, nil
+}
+
+// GetSnapshot returns a stored snapshot including its table definitions.
+// Returns sql.ErrNoRows if no snapshot has the given ID.
+func (s *Schema) GetSnapshot(id int64) (*SnapshotRecord, error) {
+	var rec SnapshotRecord
+	var tables string
+	err := s.db.QueryRow(`SELECT id, hash, reason, tables, created_at FROM _schema_snapshots WHERE id = ?`, id).
+		Scan(&rec.ID, &rec.Hash, &rec.Reason, &tables, &rec.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	rec.Snapshot = &SchemaSnapshot{Hash: rec.Hash}
+	if err := json.Unmarshal([]byte(tables), &rec.Snapshot.Tables); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot %d: %w", id, err)
+	}
+	return &rec, nil
+}
+
+// SchemaDiff describes how the schema changed between two snapshots.
+type SchemaDiff struct {
+	FromHash      string      `json:"from_hash"`
+	ToHash        string      `json:"to_hash"`
+	AddedTables   []string    `json:"added_tables"`
+	RemovedTables []string    `json:"removed_tables"`
+	ChangedTables []TableDiff `json:"changed_tables"`
+}
+
+// TableDiff describes the changes to a table present in both snapshots.
+type TableDiff struct {
+	Name           string   `json:"name"`
+	SQLChanged     bool     `json:"sql_changed"`
+	FromSQL        string   `json:"from_sql,omitempty"`
+	ToSQL          string   `json:"to_sql,omitempty"`
+	AddedColumns   []string `json:"added_columns,omitempty"`
+	RemovedColumns []string `json:"removed_columns,omitempty"`
+	ChangedColumns []string `json:"changed_columns,omitempty"`
+	AddedIndexes   []string `json:"added_indexes,omitempty"`
+	RemovedIndexes []string `json:"removed_indexes,omitempty"`
+}
+
+// Changed reports whether the diff contains any change.
+func (d *SchemaDiff) Changed() bool {
+	return len(d.AddedTables) > 0 || len(d.RemovedTables) > 0 || len(d.ChangedTables) > 0
+}
+
+// DiffSnapshots compares two snapshots. Tables and columns are matched by
+// name, so a rename shows up as a removal plus an addition.
+func DiffSnapshots(from, to *SchemaSnapshot) *SchemaDiff {
+	diff := &SchemaDiff{
+		FromHash:      from.Hash,
+		ToHash:        to.Hash,
+		AddedTables:   []string{},
+		RemovedTables: []string{},
+		ChangedTables: []TableDiff{},
+	}
+
+	fromTables := make(map[string]TableSnapshot, len(from.Tables))
+	for _, t := range from.Tables {
+		fromTables[t.Name] = t
+	}
+	toTables := make(map[string]bool, len(to.Tables))
+	for _, t := range to.Tables {
+		toTables[t.Name] = true
+		old, ok := fromTables[t.Name]
+		if !ok {
+			diff.AddedTables = append(diff.AddedTables, t.Name)
+			continue
+		}
+		if td, changed := diffTable(old, t); changed {
+			diff.ChangedTables = append(diff.ChangedTables, td)
+		}
+	}
+	for _, t := range from.Tables {
+		if !toTables[t.Name] {
+			diff.RemovedTables = append(diff.RemovedTables, t.Name)
+		}
+	}
+	return diff
+}
+
+// diffTable compares two versions of the same table.
+func diffTable(from, to TableSnapshot) (TableDiff, bool) {
+	td := TableDiff{Name: from.Name}
+	if from.SQL != to.SQL {
+		td.SQLChanged = true
+		td.FromSQL = from.SQL
+		td.ToSQL = to.SQL
+	}
+
+	fromCols := make(map[string]SnapshotColumn, len(from.Columns))
+	for _, c := range from.Columns {
+		fromCols[c.Name] = c
+	}
+	toCols := make(map[string]bool, len(to.Columns))
+	for _, c := range to.Columns {
+		toCols[c.Name] = true
+		old, ok := fromCols[c.Name]
+		if !ok {
+			td.AddedColumns = append(td.AddedColumns, c.Name)
+		} else if old != c {
+			td.ChangedColumns = append(td.ChangedColumns, c.Name)
+		}
+	}
+	for _, c := range from.Columns {
+		if !toCols[c.Name] {
+			td.RemovedColumns = append(td.RemovedColumns, c.Name)
+		}
+	}
+
+	td.AddedIndexes = missingFrom(to.Indexes, from.Indexes)
+	td.RemovedIndexes = missingFrom(from.Indexes, to.Indexes)
+
+	changed := td.SQLChanged || len(td.AddedColumns) > 0 || len(td.RemovedColumns) > 0 ||
+		len(td.ChangedColumns) > 0 || len(td.AddedIndexes) > 0 || len(td.RemovedIndexes) > 0
+	return td, changed
+}
+
+// missingFrom returns the entries of a that are not in b.
+func missingFrom(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, s := range b {
+		seen[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !seen[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"testing"
+)
+
+func TestSnapshotHashStable(t *testing.T) {
+	database := setupTestDB(t)
+	s := New(database.DB)
+
+	if _, err := database.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := s.RegisterColumn(Column{TableName: "posts", ColumnName: "id", PgType: "integer", IsPrimary: true}); err != nil {
+		t.Fatalf("failed to register column: %v", err)
+	}
+
+	first, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	second, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if first.Hash != second.Hash {
+		t.Errorf("hash changed without a schema change: %s != %s", first.Hash, second.Hash)
+	}
+	for _, table := range first.Tables {
+		if table.Name != "posts" {
+			t.Errorf("unexpected table in snapshot: %s", table.Name)
+		}
+	}
+
+	if _, err := database.Exec(`CREATE INDEX idx_posts_title ON posts(title)`); err != nil {
+		t.Fatalf("failed to create index: %v", err)
+	}
+	third, err := s.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	if third.Hash == first.Hash {
+		t.Error("expected hash to change after adding an index")
+	}
+}
+
+func TestRecordSnapshotAndDiff(t *testing.T) {
+	database := setupTestDB(t)
+	s := New(database.DB)
+
+	if _, err := database.Exec(`CREATE TABLE posts (id INTEGER PRIMARY KEY, title TEXT)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	first, err := s.RecordSnapshot("create_posts_table")
+	if err != nil || first == nil {
+		t.Fatalf("RecordSnapshot failed: %v", err)
+	}
+
+	// An unchanged schema does not produce a new snapshot
+	again, err := s.RecordSnapshot("noop")
+	if err != nil {
+		t.Fatalf("RecordSnapshot failed: %v", err)
+	}
+	if again != nil {
+		t.Errorf("expected no snapshot for unchanged schema, got id %d", again.ID)
+	}
+
+	if _, err := database.Exec(`ALTER TABLE posts ADD COLUMN body TEXT`); err != nil {
+		t.Fatalf("failed to alter table: %v", err)
+	}
+	if err := s.RegisterColumn(Column{TableName: "posts", ColumnName: "body", PgType: "text", IsNullable: true}); err != nil {
+		t.Fatalf("failed to register column: %v", err)
+	}
+	if _, err := database.Exec(`CREATE TABLE tags (name TEXT)`); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	second, err := s.RecordSnapshot("add_body")
+	if err != nil || second == nil {
+		t.Fatalf("RecordSnapshot failed: %v", err)
+	}
+
+	list, err := s.ListSnapshots(10)
+	if err != nil {
+		t.Fatalf("ListSnapshots failed: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != second.ID {
+		t.Fatalf("expected 2 snapshots newest first, got %+v", list)
+	}
+
+	from, err := s.GetSnapshot(first.ID)
+	if err != nil {
+		t.Fatalf("GetSnapshot failed: %v", err)
+	}
+	to, err := s.GetSnapshot(second.ID)
+	if err != nil {
+		t.Fatalf("GetSnapshot failed: %v", err)
+	}
+
+	diff := DiffSnapshots(from.Snapshot, to.Snapshot)
+	if !diff.Changed() {
+		t.Fatal("expected diff to report changes")
+	}
+	if len(diff.AddedTables) != 1 || diff.AddedTables[0] != "tags" {
+		t.Errorf("AddedTables = %v, want [tags]", diff.AddedTables)
+	}
+	if len(diff.ChangedTables) != 1 {
+		t.Fatalf("ChangedTables = %+v, want posts", diff.ChangedTables)
+	}
+	posts := diff.ChangedTables[0]
+	if !posts.SQLChanged || len(posts.AddedColumns) != 1 || posts.AddedColumns[0] != "body" {
+		t.Errorf("unexpected posts diff: %+v", posts)
+	}
+
+	if DiffSnapshots(to.Snapshot, to.Snapshot).Changed() {
+		t.Error("expected no changes when diffing a snapshot with itself")
+	}
+}
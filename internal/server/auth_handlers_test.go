@@ -598,6 +598,33 @@ func TestHandleSignupAnonymous(t *testing.T) {
 	}
 }
 
+func TestHandleSignupAnonymousDisabled(t *testing.T) {
+	srv := setupTestServer(t)
+	if err := srv.dashboardStore.Set("auth_allow_anonymous", "false"); err != nil {
+		t.Fatalf("failed to disable anonymous sign-in: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/auth/v1/signup", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected status 403, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]any
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp["error"] != "anonymous_disabled" {
+		t.Errorf("expected error anonymous_disabled, got %v", resp["error"])
+	}
+
+	var count int
+	srv.db.QueryRow("SELECT COUNT(*) FROM auth_users WHERE is_anonymous = 1").Scan(&count)
+	if count != 0 {
+		t.Errorf("expected no anonymous users to be created, got %d", count)
+	}
+}
+
 func TestHandleSignupAnonymousWithMetadata(t *testing.T) {
 	srv := setupTestServer(t)
 
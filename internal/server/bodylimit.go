@@ -0,0 +1,181 @@
+// internal/server/bodylimit.go
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DefaultMaxBodySize caps request bodies outside upload routes (10 MB).
+	DefaultMaxBodySize int64 = 10 << 20
+	// DefaultMaxUploadSize caps request bodies on upload routes (50 MB).
+	DefaultMaxUploadSize int64 = 50 << 20
+)
+
+// uploadRoutes are path prefixes whose POST, PUT and PATCH bodies use the
+// upload limit instead of the default one.
+var uploadRoutes = []string{
+	"/storage/v1/object/",
+	"/storage/v1/upload/",
+	"/functions/v1/",
+}
+
+// dashboardUploadRoutes are upload routes relative to the dashboard path.
+var dashboardUploadRoutes = []string{
+	"/api/storage/objects/upload",
+	"/api/functions/import",
+}
+
+// BodyLimits configures the maximum request body size. A negative limit
+// disables the check.
+type BodyLimits struct {
+	Default int64
+	Upload  int64
+	// DashboardPath is where the dashboard is mounted (empty = DefaultDashboardPath).
+	DashboardPath string
+}
+
+// limitFor returns the body limit that applies to r.
+func (l BodyLimits) limitFor(r *http.Request) int64 {
+	switch r.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		for _, prefix := range uploadRoutes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return l.Upload
+			}
+		}
+		dashboardPath := l.DashboardPath
+		if dashboardPath == "" {
+			dashboardPath = DefaultDashboardPath
+		}
+		for _, route := range dashboardUploadRoutes {
+			if strings.HasPrefix(r.URL.Path, dashboardPath+route) {
+				return l.Upload
+			}
+		}
+	}
+	return l.Default
+}
+
+// bodyLimitMiddleware rejects request bodies larger than the configured limit
+// with a 413 JSON error. Bodies with a declared Content-Length are rejected
+// up front; others are cut off while the handler reads them, and whatever the
+// handler then responds is replaced by the 413.
+func bodyLimitMiddleware(limits BodyLimits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := limits.limitFor(r)
+			if limit < 0 || r.Body == nil || r.Body == http.NoBody || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > limit {
+				writePayloadTooLarge(w, limit)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+			r.Body = body
+			lw := &bodyLimitWriter{ResponseWriter: w, body: body, limit: limit}
+			next.ServeHTTP(lw, r)
+			if !lw.wroteHeader && body.exceeded {
+				writePayloadTooLarge(w, limit)
+			}
+		})
+	}
+}
+
+// writePayloadTooLarge writes the 413 response for a body over limit bytes.
+func writePayloadTooLarge(w http.ResponseWriter, limit int64) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Del("Content-Encoding")
+	h.Set("Content-Type", "application/json")
+	h.Set("Connection", "close")
+	w.WriteHeader(http.StatusRequestEntityTooLarge)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   "payload_too_large",
+		Message: fmt.Sprintf("Request body exceeds the maximum size of %s", formatByteSize(limit)),
+	})
+}
+
+// formatByteSize renders a byte count in the largest whole unit.
+func formatByteSize(n int64) string {
+	switch {
+	case n >= 1<<30 && n%(1<<30) == 0:
+		return fmt.Sprintf("%d GB", n>>30)
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// limitedBody records whether the wrapped http.MaxBytesReader hit its limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// bodyLimitWriter replaces the handler's response with a 413 once the request
+// body has exceeded its limit, whatever error the handler reported.
+type bodyLimitWriter struct {
+	http.ResponseWriter
+	body        *limitedBody
+	limit       int64
+	wroteHeader bool
+	rejected    bool
+}
+
+func (w *bodyLimitWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.body.exceeded {
+		w.rejected = true
+		writePayloadTooLarge(w.ResponseWriter, w.limit)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *bodyLimitWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.rejected {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher for streaming handlers.
+func (w *bodyLimitWriter) Flush() {
+	if w.rejected {
+		return
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *bodyLimitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,95 @@
+// internal/server/bodylimit_test.go
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bodyLimitTestHandler(limits BodyLimits) http.Handler {
+	return bodyLimitMiddleware(limits)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestBodyLimitDeclaredLength(t *testing.T) {
+	handler := bodyLimitTestHandler(BodyLimits{Default: 16, Upload: 64})
+
+	req := httptest.NewRequest("POST", "/rest/v1/items", strings.NewReader(strings.Repeat("x", 17)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var body ErrorResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "payload_too_large", body.Error)
+	assert.Contains(t, body.Message, "16 bytes")
+}
+
+func TestBodyLimitStreamedBody(t *testing.T) {
+	handler := bodyLimitTestHandler(BodyLimits{Default: 16, Upload: 64})
+
+	req := httptest.NewRequest("POST", "/rest/v1/items", strings.NewReader(strings.Repeat("x", 32)))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	var body ErrorResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "payload_too_large", body.Error)
+}
+
+func TestBodyLimitWithinLimit(t *testing.T) {
+	handler := bodyLimitTestHandler(BodyLimits{Default: 16, Upload: 64})
+
+	req := httptest.NewRequest("POST", "/rest/v1/items", strings.NewReader(strings.Repeat("x", 16)))
+	req.ContentLength = -1
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+}
+
+func TestBodyLimitUploadRoute(t *testing.T) {
+	handler := bodyLimitTestHandler(BodyLimits{Default: 16, Upload: 64})
+
+	req := httptest.NewRequest("POST", "/storage/v1/object/avatars/a.png", strings.NewReader(strings.Repeat("x", 32)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	req = httptest.NewRequest("POST", "/storage/v1/object/avatars/a.png", strings.NewReader(strings.Repeat("x", 65)))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestBodyLimitUnlimited(t *testing.T) {
+	handler := bodyLimitTestHandler(BodyLimits{Default: -1, Upload: -1})
+
+	req := httptest.NewRequest("POST", "/rest/v1/items", strings.NewReader(strings.Repeat("x", 1024)))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestFormatByteSize(t *testing.T) {
+	assert.Equal(t, "10 MB", formatByteSize(10<<20))
+	assert.Equal(t, "2 GB", formatByteSize(2<<30))
+	assert.Equal(t, "4 KB", formatByteSize(4096))
+	assert.Equal(t, "1500 bytes", formatByteSize(1500))
+}
@@ -0,0 +1,247 @@
+// internal/server/compress.go
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// compressMinSize is the smallest response body worth compressing.
+const compressMinSize = 1024
+
+// incompressibleTypes are content type prefixes that are already compressed
+// or streamed, and are sent as-is.
+var incompressibleTypes = []string{
+	"image/",
+	"video/",
+	"audio/",
+	"font/woff",
+	"text/event-stream",
+	"application/zip",
+	"application/gzip",
+	"application/x-gzip",
+	"application/x-bzip2",
+	"application/x-xz",
+	"application/x-7z-compressed",
+	"application/x-rar-compressed",
+	"application/zstd",
+	"application/pdf",
+	"application/wasm",
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		gz, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression)
+		return gz
+	},
+}
+
+// compressMiddleware gzip- or deflate-encodes responses larger than minSize
+// when the client accepts it. Smaller bodies, already-compressed content,
+// partial content, Server-Sent Events and WebSocket upgrades pass through.
+func compressMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" ||
+				strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			defer cw.close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header,
+// preferring gzip and honoring q=0. It returns "" if neither is acceptable.
+func negotiateEncoding(header string) string {
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		qualities[name] = q
+	}
+
+	quality := func(name string) float64 {
+		if q, ok := qualities[name]; ok {
+			return q
+		}
+		return qualities["*"]
+	}
+	gzipQ, deflateQ := quality("gzip"), quality("deflate")
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return "gzip"
+	case deflateQ > 0:
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter buffers the start of a response until it knows whether the
+// body is large enough to compress, then either compresses or passes through.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status   int
+	buf      bytes.Buffer
+	decided  bool
+	compress io.WriteCloser // nil when passing through
+	hijacked bool
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.status == 0 {
+		cw.status = code
+	}
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.decided {
+		if cw.compress != nil {
+			return cw.compress.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf.Write(b)
+	if cw.buf.Len() >= cw.minSize {
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// decide commits to compressing (if large and eligible) or passing through,
+// writes the header and flushes the buffered body.
+func (cw *compressWriter) decide(large bool) error {
+	cw.decided = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	h := cw.Header()
+	if large && cw.compressible() {
+		if h.Get("Content-Type") == "" {
+			// net/http would otherwise sniff the compressed bytes
+			h.Set("Content-Type", http.DetectContentType(cw.buf.Bytes()))
+		}
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", cw.encoding)
+		if cw.encoding == "gzip" {
+			gz := gzipWriterPool.Get().(*gzip.Writer)
+			gz.Reset(cw.ResponseWriter)
+			cw.compress = gz
+		} else {
+			fw, _ := flate.NewWriter(cw.ResponseWriter, flate.DefaultCompression)
+			cw.compress = fw
+		}
+		cw.ResponseWriter.WriteHeader(cw.status)
+		_, err := cw.compress.Write(cw.buf.Bytes())
+		cw.buf.Reset()
+		return err
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	_, err := cw.ResponseWriter.Write(cw.buf.Bytes())
+	cw.buf.Reset()
+	return err
+}
+
+func (cw *compressWriter) compressible() bool {
+	h := cw.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Range") != "" {
+		return false
+	}
+	if cw.status < 200 || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified ||
+		cw.status == http.StatusPartialContent {
+		return false
+	}
+	contentType := strings.ToLower(h.Get("Content-Type"))
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Flush sends buffered data immediately. A response flushed before reaching
+// the size threshold is treated as a stream and is not compressed.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide(false)
+	}
+	if f, ok := cw.compress.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface to support WebSocket upgrades.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := cw.ResponseWriter.(http.Hijacker); ok {
+		cw.hijacked = true
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+func (cw *compressWriter) close() {
+	if cw.hijacked {
+		return
+	}
+	if !cw.decided {
+		if cw.status == 0 && cw.buf.Len() == 0 {
+			// Handler wrote nothing; let net/http send its default response
+			return
+		}
+		cw.decide(false)
+	}
+	if cw.compress != nil {
+		cw.compress.Close()
+		if gz, ok := cw.compress.(*gzip.Writer); ok {
+			gz.Reset(io.Discard)
+			gzipWriterPool.Put(gz)
+		}
+	}
+}
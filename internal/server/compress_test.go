@@ -0,0 +1,123 @@
+// internal/server/compress_test.go
+package server
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"":                        "",
+		"gzip":                    "gzip",
+		"deflate":                 "deflate",
+		"gzip, deflate, br":       "gzip",
+		"deflate;q=1, gzip;q=0.5": "deflate",
+		"gzip;q=0, deflate":       "deflate",
+		"gzip;q=0":                "",
+		"identity":                "",
+		"*":                       "gzip",
+		"*, gzip;q=0":             "deflate",
+	}
+	for header, want := range tests {
+		assert.Equal(t, want, negotiateEncoding(header), "Accept-Encoding: %q", header)
+	}
+}
+
+func serveCompressed(t *testing.T, acceptEncoding string, handler http.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	if acceptEncoding != "" {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+	w := httptest.NewRecorder()
+	compressMiddleware(compressMinSize)(handler).ServeHTTP(w, req)
+	return w
+}
+
+func TestCompressMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"name":"row"},`, 200)
+	jsonHandler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Length", "999")
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, body)
+		}
+	}
+
+	// Large JSON is gzipped
+	w := serveCompressed(t, "gzip, deflate", jsonHandler(large))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	assert.Empty(t, w.Header().Get("Content-Length"))
+	gz, err := gzip.NewReader(w.Body)
+	require.NoError(t, err)
+	body, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, large, string(body))
+
+	// deflate when gzip is not accepted
+	w = serveCompressed(t, "deflate", jsonHandler(large))
+	assert.Equal(t, "deflate", w.Header().Get("Content-Encoding"))
+	body, err = io.ReadAll(flate.NewReader(w.Body))
+	require.NoError(t, err)
+	assert.Equal(t, large, string(body))
+
+	// Small bodies and clients without Accept-Encoding are untouched
+	w = serveCompressed(t, "gzip", jsonHandler(`{"ok":true}`))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, `{"ok":true}`, w.Body.String())
+
+	w = serveCompressed(t, "", jsonHandler(large))
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, w.Body.String())
+
+	// Already-compressed content types pass through
+	w = serveCompressed(t, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/zip")
+		io.WriteString(w, large)
+	})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.Equal(t, large, w.Body.String())
+
+	// Streams flushed before the threshold are not compressed
+	w = serveCompressed(t, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, "data: 1\n\n")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, large)
+	})
+	assert.Empty(t, w.Header().Get("Content-Encoding"))
+	assert.True(t, w.Flushed)
+	assert.Equal(t, "data: 1\n\n"+large, w.Body.String())
+
+	// Empty responses keep their status
+	w = serveCompressed(t, "gzip", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestCompressMiddlewareSkipsEventStreams(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	req.Header.Set("Accept", "text/event-stream")
+	w := httptest.NewRecorder()
+
+	var wrapped bool
+	compressMiddleware(compressMinSize)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, wrapped = w.(*compressWriter)
+	})).ServeHTTP(w, req)
+	assert.False(t, wrapped)
+}
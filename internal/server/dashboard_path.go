@@ -0,0 +1,40 @@
+// internal/server/dashboard_path.go
+package server
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultDashboardPath is where the dashboard is mounted unless configured otherwise.
+const DefaultDashboardPath = "/_"
+
+// reservedPaths are the API route prefixes the dashboard cannot be mounted
+// at or under.
+var reservedPaths = []string{"/health", "/auth/v1", "/rest/v1", "/admin/v1", "/storage/v1", "/functions/v1", "/realtime/v1"}
+
+// NormalizeDashboardPath validates a configured dashboard mount path and
+// returns it with a leading slash and without a trailing one, e.g. "admin/"
+// becomes "/admin". An empty path selects DefaultDashboardPath.
+func NormalizeDashboardPath(p string) (string, error) {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return DefaultDashboardPath, nil
+	}
+
+	normalized := "/" + strings.Trim(p, "/")
+	if normalized == "/" {
+		return "", fmt.Errorf("dashboard path cannot be the root path")
+	}
+	if path.Clean(normalized) != normalized || strings.ContainsAny(normalized, "?#%\"'<> \\") {
+		return "", fmt.Errorf("invalid dashboard path: %s", p)
+	}
+
+	for _, reserved := range reservedPaths {
+		if normalized == reserved || strings.HasPrefix(normalized, reserved+"/") {
+			return "", fmt.Errorf("dashboard path %s conflicts with the %s API routes", normalized, reserved)
+		}
+	}
+	return normalized, nil
+}
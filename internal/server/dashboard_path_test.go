@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeDashboardPath(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "/_"},
+		{"/_", "/_"},
+		{"admin", "/admin"},
+		{"/admin/", "/admin"},
+		{"/tools/sblite", "/tools/sblite"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeDashboardPath(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, in := range []string{"/", "//", "/a/../b", "/a b", "/rest/v1", "/auth/v1/dashboard", "/health"} {
+		_, err := NormalizeDashboardPath(in)
+		assert.Error(t, err, in)
+	}
+}
+
+func TestBodyLimitDashboardPath(t *testing.T) {
+	limits := BodyLimits{Default: 16, Upload: 64, DashboardPath: "/admin"}
+
+	req := httptest.NewRequest("POST", "/admin/api/storage/objects/upload", nil)
+	assert.Equal(t, int64(64), limits.limitFor(req))
+
+	req = httptest.NewRequest("POST", "/_/api/storage/objects/upload", nil)
+	assert.Equal(t, int64(16), limits.limitFor(req))
+}
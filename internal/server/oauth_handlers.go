@@ -114,34 +114,27 @@ func (s *Server) extractAnonymousUserID(r *http.Request) string {
 	return ""
 }
 
-// isRedirectURLAllowed checks if the redirect URL is in the allowed list.
+// isRedirectURLAllowed checks if the redirect URL matches an allowed URL
+// pattern, from the server config or the dashboard settings.
 func (s *Server) isRedirectURLAllowed(redirectURL string) bool {
-	// If no allowed URLs configured, allow all (development mode)
-	if len(s.allowedRedirectURLs) == 0 {
-		return true
+	allowed := s.allowedRedirectURLs
+	if s.dashboardStore != nil {
+		if urlsJSON, _ := s.dashboardStore.Get("oauth_redirect_urls"); urlsJSON != "" {
+			var stored []string
+			if json.Unmarshal([]byte(urlsJSON), &stored) == nil {
+				allowed = append(append([]string{}, allowed...), stored...)
+			}
+		}
 	}
 
-	parsed, err := url.Parse(redirectURL)
-	if err != nil {
-		return false
+	// If no allowed URLs configured, allow all (development mode)
+	if len(allowed) == 0 {
+		return true
 	}
 
-	for _, allowed := range s.allowedRedirectURLs {
-		allowedParsed, err := url.Parse(allowed)
-		if err != nil {
-			continue
-		}
-
-		// Match scheme and host
-		if parsed.Scheme == allowedParsed.Scheme && parsed.Host == allowedParsed.Host {
-			// If allowed URL has a path, redirect must start with it
-			if allowedParsed.Path != "" && allowedParsed.Path != "/" {
-				if strings.HasPrefix(parsed.Path, allowedParsed.Path) {
-					return true
-				}
-			} else {
-				return true
-			}
+	for _, pattern := range allowed {
+		if oauth.MatchRedirectURL(pattern, redirectURL) {
+			return true
 		}
 	}
 
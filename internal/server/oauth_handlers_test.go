@@ -619,3 +619,21 @@ func TestUnlinkIdentityUnauthorized(t *testing.T) {
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
+
+func TestAuthorizeEndpointDashboardRedirectPatterns(t *testing.T) {
+	srv := setupTestServer(t)
+
+	srv.configureOAuthProvider("google", "test-client-id", "test-secret", true)
+	err := srv.dashboardStore.Set("oauth_redirect_urls", `["https://*.vercel.app/**"]`)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/auth/v1/authorize?provider=google&redirect_to=https://pr-7.vercel.app/auth/callback", nil)
+	w := httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+
+	req = httptest.NewRequest("GET", "/auth/v1/authorize?provider=google&redirect_to=https://evil.com/callback", nil)
+	w = httptest.NewRecorder()
+	srv.Router().ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
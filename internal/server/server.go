@@ -44,6 +44,7 @@ type Server struct {
 	mailConfig       *mail.Config
 	mailer           mail.Mailer
 	catchMailer      *mail.CatchMailer
+	mailQueue        *mail.QueueMailer
 	emailService     *mail.EmailService
 	adminHandler     *admin.Handler
 	schema           *schema.Schema
@@ -91,6 +92,12 @@ type Server struct {
 	// Static file serving
 	staticDir string
 
+	// Request body size limits
+	bodyLimits BodyLimits
+
+	// Path the dashboard is mounted under
+	dashboardPath string
+
 	// Observability
 	telemetry *observability.Telemetry
 }
@@ -103,6 +110,14 @@ type ServerConfig struct {
 	StoragePath   string          // Path for local file storage (deprecated, use StorageConfig)
 	StorageConfig *storage.Config // Full storage configuration
 	StaticDir     string          // Directory for static file hosting
+	MaxBodySize   int64           // Max request body in bytes (0 = DefaultMaxBodySize, <0 = unlimited)
+	MaxUploadSize int64           // Max body for upload routes (0 = DefaultMaxUploadSize, <0 = unlimited)
+	UploadTempDir string          // Where dashboard uploads are spooled (empty = system temp dir)
+	UploadMemory  int64           // Upload bytes kept in memory before spooling (0 = default, <0 = always spool)
+	DashboardPath string          // Path the dashboard is mounted under (empty = DefaultDashboardPath)
+
+	DisableImpersonation bool          // Turn off minting user access tokens from the dashboard
+	DashboardTimeout     time.Duration // Dashboard API request deadline (0 = dashboard.DefaultRequestTimeout, <0 = none)
 }
 
 func New(database *db.DB, jwtSecret string, mailConfig *mail.Config, migrationsDir string, storagePath string) *Server {
@@ -142,6 +157,18 @@ func NewWithConfig(database *db.DB, cfg ServerConfig) *Server {
 		oauthRegistry:   oauth.NewRegistry(),
 		oauthStateStore: oauth.NewStateStore(database.DB),
 		staticDir:       cfg.StaticDir,
+		bodyLimits:      BodyLimits{Default: cfg.MaxBodySize, Upload: cfg.MaxUploadSize},
+		dashboardPath:   cfg.DashboardPath,
+	}
+	if s.dashboardPath == "" {
+		s.dashboardPath = DefaultDashboardPath
+	}
+	s.bodyLimits.DashboardPath = s.dashboardPath
+	if s.bodyLimits.Default == 0 {
+		s.bodyLimits.Default = DefaultMaxBodySize
+	}
+	if s.bodyLimits.Upload == 0 {
+		s.bodyLimits.Upload = DefaultMaxUploadSize
 	}
 
 	// Initialize admin handler (uses schema)
@@ -160,6 +187,11 @@ func NewWithConfig(database *db.DB, cfg ServerConfig) *Server {
 	// Initialize dashboard handler
 	s.dashboardHandler = dashboard.NewHandler(database.DB, cfg.MigrationsDir)
 	s.dashboardHandler.SetJWTSecret(cfg.JWTSecret)
+	s.dashboardHandler.SetWriteQueue(database.Writes)
+	s.dashboardHandler.SetUploadConfig(dashboard.UploadConfig{TempDir: cfg.UploadTempDir, MemoryThreshold: cfg.UploadMemory})
+	s.dashboardHandler.SetBasePath(s.dashboardPath)
+	s.dashboardHandler.SetImpersonationEnabled(!cfg.DisableImpersonation)
+	s.dashboardHandler.SetRequestTimeout(cfg.DashboardTimeout)
 	s.dashboardStore = s.dashboardHandler.GetStore()
 	// Set RPC interceptor and executor on dashboard handler
 	s.dashboardHandler.SetRPCInterceptor(s.rpcInterceptor)
@@ -174,6 +206,7 @@ func NewWithConfig(database *db.DB, cfg ServerConfig) *Server {
 
 	// Set catch mailer on dashboard handler for mail viewer (if in catch mode)
 	s.dashboardHandler.SetCatchMailer(s.catchMailer)
+	s.dashboardHandler.SetMailQueue(s.mailQueue)
 
 	// Set up callback to update mail config when SiteURL changes via dashboard
 	s.dashboardHandler.SetOnSiteURLChange(func(siteURL string) {
@@ -203,6 +236,13 @@ func NewWithConfig(database *db.DB, cfg ServerConfig) *Server {
 		s.storageHandler.SetRLSEnforcer(rlsService, rlsEnforcer)
 		// Pass JWT secret for signed URL generation
 		s.storageHandler.SetJWTSecret(cfg.JWTSecret)
+		// Signed URLs use their own key so it can be rotated apart from the JWT secret
+		if key, err := s.dashboardHandler.StorageSigningKey(); err == nil {
+			s.storageHandler.SetSigningKey(key)
+		} else {
+			log.Warn("failed to load storage signing key, signing URLs with the JWT secret", "error", err.Error())
+		}
+		s.dashboardHandler.SetStorageSigningKeyFunc(s.storageHandler.SetSigningKey)
 		// Enable TUS resumable uploads
 		uploadsDir := storageCfg.LocalPath
 		if uploadsDir == "" {
@@ -258,6 +298,12 @@ func (s *Server) SetMigrationService(svc *migration.Service) {
 func (s *Server) SetTelemetry(tel *observability.Telemetry) {
 	s.telemetry = tel
 	s.dashboardHandler.SetTelemetry(tel)
+	if s.storageService != nil {
+		s.storageService.SetTelemetry(tel)
+	}
+	if s.functionsHandler != nil {
+		s.functionsHandler.SetTelemetry(tel)
+	}
 }
 
 // applyPersistedSettings applies settings persisted in the dashboard to the server config.
@@ -288,13 +334,24 @@ func (s *Server) applyPersistedSettings() {
 	if pass != "" {
 		s.mailConfig.SMTPPass = pass
 	}
+	s.mailConfig.CatchRetention = s.dashboardHandler.GetMailRetention()
 }
 
 // initMail initializes the mail services based on configuration.
 func (s *Server) initMail() {
+	// Stop the previous queue worker; pending emails stay in the queue table
+	if s.mailQueue != nil {
+		s.mailQueue.Stop()
+		s.mailQueue = nil
+	}
+
 	switch s.mailConfig.Mode {
 	case mail.ModeCatch:
 		s.catchMailer = mail.NewCatchMailer(s.db)
+		s.catchMailer.SetRetention(s.mailConfig.CatchRetention)
+		if _, err := s.catchMailer.Prune(); err != nil {
+			log.Warn("failed to prune caught emails", "error", err.Error())
+		}
 		s.mailer = s.catchMailer
 	case mail.ModeSMTP:
 		s.catchMailer = nil // Clear catch mailer when not in catch mode
@@ -304,7 +361,14 @@ func (s *Server) initMail() {
 			User: s.mailConfig.SMTPUser,
 			Pass: s.mailConfig.SMTPPass,
 		}
-		s.mailer = mail.NewSMTPMailer(smtpConfig)
+		// Queue SMTP deliveries so bursts don't block request handlers
+		queueConfig := mail.DefaultQueueConfig()
+		if s.mailConfig.RatePerMinute > 0 {
+			queueConfig.RatePerMinute = s.mailConfig.RatePerMinute
+		}
+		s.mailQueue = mail.NewQueueMailer(s.db, mail.NewSMTPMailer(smtpConfig), queueConfig)
+		s.mailQueue.Start()
+		s.mailer = s.mailQueue
 	default:
 		// Default to log mode
 		s.catchMailer = nil // Clear catch mailer when not in catch mode
@@ -326,12 +390,14 @@ func (s *Server) ReloadMail(cfg *dashboard.MailConfig) error {
 	s.mailConfig.SMTPPort = cfg.SMTPPort
 	s.mailConfig.SMTPUser = cfg.SMTPUser
 	s.mailConfig.SMTPPass = cfg.SMTPPass
+	s.mailConfig.CatchRetention = cfg.CatchRetention
 
 	// Reinitialize mailer
 	s.initMail()
 
 	// Update dashboard handler with catch mailer state (may be nil if not in catch mode)
 	s.dashboardHandler.SetCatchMailer(s.catchMailer)
+	s.dashboardHandler.SetMailQueue(s.mailQueue)
 
 	log.Info("mail configuration reloaded",
 		"mode", cfg.Mode,
@@ -358,6 +424,8 @@ func (s *Server) setupRoutes() {
 
 	s.router.Use(log.RequestLogger)
 	s.router.Use(middleware.Recoverer)
+	s.router.Use(bodyLimitMiddleware(s.bodyLimits))
+	s.router.Use(compressMiddleware(compressMinSize))
 	s.router.Use(middleware.SetHeader("Content-Type", "application/json"))
 
 	s.router.Get("/health", s.handleHealth)
@@ -430,7 +498,7 @@ func (s *Server) setupRoutes() {
 	})
 
 	// Dashboard routes
-	s.router.Route("/_", func(r chi.Router) {
+	s.router.Route(s.dashboardPath, func(r chi.Router) {
 		s.dashboardHandler.RegisterRoutes(r)
 	})
 
@@ -438,6 +506,7 @@ func (s *Server) setupRoutes() {
 	if s.storageHandler != nil {
 		s.router.Route("/storage/v1", func(r chi.Router) {
 			// Public routes - no API key required
+			r.Get("/object/public/{bucketName}", s.storageHandler.ListPublicObjects) // Rate limited per IP
 			r.Get("/object/public/{bucketName}/*", s.storageHandler.GetPublicObject)
 			r.Get("/object/sign/{bucketName}/*", s.storageHandler.GetSignedObject)           // Download via signed URL
 			r.Put("/object/upload/sign/{bucketName}/*", s.storageHandler.UploadToSignedURL) // Upload via signed URL
@@ -548,6 +617,11 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	// Stop the outbound mail queue; undelivered emails are sent after restart
+	if s.mailQueue != nil {
+		s.mailQueue.Stop()
+	}
+
 	if len(errs) > 0 {
 		return fmt.Errorf("shutdown errors: %v", errs)
 	}
@@ -621,6 +695,7 @@ func (s *Server) EnableFunctions(cfg *functions.Config) error {
 
 	s.functionsService = svc
 	s.functionsHandler = functions.NewHandler(svc, s.jwtSecret)
+	s.functionsHandler.SetTelemetry(s.telemetry)
 	s.functionsEnabled = true
 
 	// Set functions service on dashboard handler for management UI
@@ -750,3 +825,11 @@ func (s *Server) StartTUSCleanup(ctx context.Context) {
 		log.Info("TUS upload cleanup routine started")
 	}
 }
+
+// StartStorageWebhooks starts delivering queued storage events to webhooks.
+func (s *Server) StartStorageWebhooks(ctx context.Context) {
+	if s.storageService == nil {
+		return
+	}
+	s.storageService.StartWebhookDispatcher(ctx, 2*time.Second)
+}
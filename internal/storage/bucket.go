@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -21,6 +22,9 @@ func (s *Service) CreateBucket(req CreateBucketRequest, ownerID string) (*Bucket
 	if req.Name == "" {
 		return nil, &StorageError{StatusCode: 400, ErrorCode: "invalid_name", Message: "Bucket name is required"}
 	}
+	if err := validateCacheMaxAge(req.CacheMaxAge); err != nil {
+		return nil, err
+	}
 
 	// Check if bucket already exists
 	var exists int
@@ -43,9 +47,9 @@ func (s *Service) CreateBucket(req CreateBucketRequest, ownerID string) (*Bucket
 
 	// Insert bucket
 	_, err = s.db.Exec(`
-		INSERT INTO storage_buckets (id, name, owner_id, public, file_size_limit, allowed_mime_types, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-	`, id, req.Name, nilIfEmpty(ownerID), boolToInt(req.Public), req.FileSizeLimit, mimeTypesJSON, now, now)
+		INSERT INTO storage_buckets (id, name, owner_id, public, file_size_limit, allowed_mime_types, cache_max_age, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, id, req.Name, nilIfEmpty(ownerID), boolToInt(req.Public), req.FileSizeLimit, mimeTypesJSON, req.CacheMaxAge, now, now)
 	if err != nil {
 		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to create bucket: %v", err)}
 	}
@@ -57,6 +61,7 @@ func (s *Service) CreateBucket(req CreateBucketRequest, ownerID string) (*Bucket
 		Public:           req.Public,
 		FileSizeLimit:    req.FileSizeLimit,
 		AllowedMimeTypes: req.AllowedMimeTypes,
+		CacheMaxAge:      req.CacheMaxAge,
 		CreatedAt:        now,
 		UpdatedAt:        now,
 	}, nil
@@ -66,14 +71,14 @@ func (s *Service) CreateBucket(req CreateBucketRequest, ownerID string) (*Bucket
 func (s *Service) GetBucket(id string) (*Bucket, error) {
 	var bucket Bucket
 	var public int
-	var fileSizeLimit sql.NullInt64
+	var fileSizeLimit, cacheMaxAge sql.NullInt64
 	var mimeTypesJSON sql.NullString
 	var owner, ownerID sql.NullString
 
 	err := s.db.QueryRow(`
-		SELECT id, name, owner, owner_id, public, file_size_limit, allowed_mime_types, created_at, updated_at
+		SELECT id, name, owner, owner_id, public, file_size_limit, allowed_mime_types, cache_max_age, created_at, updated_at
 		FROM storage_buckets WHERE id = ?
-	`, id).Scan(&bucket.ID, &bucket.Name, &owner, &ownerID, &public, &fileSizeLimit, &mimeTypesJSON, &bucket.CreatedAt, &bucket.UpdatedAt)
+	`, id).Scan(&bucket.ID, &bucket.Name, &owner, &ownerID, &public, &fileSizeLimit, &mimeTypesJSON, &cacheMaxAge, &bucket.CreatedAt, &bucket.UpdatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, &StorageError{StatusCode: 404, ErrorCode: "not_found", Message: "Bucket not found"}
 	} else if err != nil {
@@ -87,6 +92,9 @@ func (s *Service) GetBucket(id string) (*Bucket, error) {
 	if fileSizeLimit.Valid {
 		bucket.FileSizeLimit = &fileSizeLimit.Int64
 	}
+	if cacheMaxAge.Valid {
+		bucket.CacheMaxAge = &cacheMaxAge.Int64
+	}
 
 	if mimeTypesJSON.Valid {
 		json.Unmarshal([]byte(mimeTypesJSON.String), &bucket.AllowedMimeTypes)
@@ -99,14 +107,14 @@ func (s *Service) GetBucket(id string) (*Bucket, error) {
 func (s *Service) GetBucketByName(name string) (*Bucket, error) {
 	var bucket Bucket
 	var public int
-	var fileSizeLimit sql.NullInt64
+	var fileSizeLimit, cacheMaxAge sql.NullInt64
 	var mimeTypesJSON sql.NullString
 	var owner, ownerID sql.NullString
 
 	err := s.db.QueryRow(`
-		SELECT id, name, owner, owner_id, public, file_size_limit, allowed_mime_types, created_at, updated_at
+		SELECT id, name, owner, owner_id, public, file_size_limit, allowed_mime_types, cache_max_age, created_at, updated_at
 		FROM storage_buckets WHERE name = ?
-	`, name).Scan(&bucket.ID, &bucket.Name, &owner, &ownerID, &public, &fileSizeLimit, &mimeTypesJSON, &bucket.CreatedAt, &bucket.UpdatedAt)
+	`, name).Scan(&bucket.ID, &bucket.Name, &owner, &ownerID, &public, &fileSizeLimit, &mimeTypesJSON, &cacheMaxAge, &bucket.CreatedAt, &bucket.UpdatedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, &StorageError{StatusCode: 404, ErrorCode: "not_found", Message: "Bucket not found"}
 	} else if err != nil {
@@ -120,6 +128,9 @@ func (s *Service) GetBucketByName(name string) (*Bucket, error) {
 	if fileSizeLimit.Valid {
 		bucket.FileSizeLimit = &fileSizeLimit.Int64
 	}
+	if cacheMaxAge.Valid {
+		bucket.CacheMaxAge = &cacheMaxAge.Int64
+	}
 
 	if mimeTypesJSON.Valid {
 		json.Unmarshal([]byte(mimeTypesJSON.String), &bucket.AllowedMimeTypes)
@@ -128,26 +139,46 @@ func (s *Service) GetBucketByName(name string) (*Bucket, error) {
 	return &bucket, nil
 }
 
-// ListBuckets returns all buckets.
-func (s *Service) ListBuckets(limit, offset int, search string) ([]Bucket, error) {
+// ListBuckets returns a page of buckets and the number of buckets matching
+// the search.
+func (s *Service) ListBuckets(req ListBucketsRequest) ([]Bucket, int, error) {
+	limit := req.Limit
 	if limit <= 0 {
 		limit = 100
 	}
 
-	query := `SELECT id, name, owner, owner_id, public, file_size_limit, allowed_mime_types, created_at, updated_at FROM storage_buckets`
+	where := ""
 	args := []any{}
+	if req.Search != "" {
+		where = " WHERE name LIKE ?"
+		args = append(args, "%"+req.Search+"%")
+	}
+
+	var total int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM storage_buckets"+where, args...).Scan(&total); err != nil {
+		return nil, 0, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to count buckets: %v", err)}
+	}
 
-	if search != "" {
-		query += " WHERE name LIKE ?"
-		args = append(args, "%"+search+"%")
+	sortColumn := "name"
+	sortOrder := "ASC"
+	if req.SortBy != nil {
+		switch req.SortBy.Column {
+		case "name", "created_at":
+			sortColumn = req.SortBy.Column
+		}
+		if strings.ToUpper(req.SortBy.Order) == "DESC" {
+			sortOrder = "DESC"
+		}
 	}
 
-	query += " ORDER BY name ASC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
+	query := `SELECT id, name, owner, owner_id, public, file_size_limit, allowed_mime_types, cache_max_age, created_at, updated_at FROM storage_buckets` + where
+	// Ties on created_at are broken by name so pages are stable
+	query += fmt.Sprintf(" ORDER BY %s %s, name ASC LIMIT ? OFFSET ?", sortColumn, sortOrder)
+	args = append(args, limit, req.Offset)
 
 	rows, err := s.db.Query(query, args...)
 	if err != nil {
-		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to list buckets: %v", err)}
+		return nil, 0, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to list buckets: %v", err)}
 	}
 	defer rows.Close()
 
@@ -155,13 +186,13 @@ func (s *Service) ListBuckets(limit, offset int, search string) ([]Bucket, error
 	for rows.Next() {
 		var bucket Bucket
 		var public int
-		var fileSizeLimit sql.NullInt64
+		var fileSizeLimit, cacheMaxAge sql.NullInt64
 		var mimeTypesJSON sql.NullString
 		var owner, ownerID sql.NullString
 
-		err := rows.Scan(&bucket.ID, &bucket.Name, &owner, &ownerID, &public, &fileSizeLimit, &mimeTypesJSON, &bucket.CreatedAt, &bucket.UpdatedAt)
+		err := rows.Scan(&bucket.ID, &bucket.Name, &owner, &ownerID, &public, &fileSizeLimit, &mimeTypesJSON, &cacheMaxAge, &bucket.CreatedAt, &bucket.UpdatedAt)
 		if err != nil {
-			return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to scan bucket: %v", err)}
+			return nil, 0, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to scan bucket: %v", err)}
 		}
 
 		bucket.Public = public == 1
@@ -171,6 +202,9 @@ func (s *Service) ListBuckets(limit, offset int, search string) ([]Bucket, error
 		if fileSizeLimit.Valid {
 			bucket.FileSizeLimit = &fileSizeLimit.Int64
 		}
+		if cacheMaxAge.Valid {
+			bucket.CacheMaxAge = &cacheMaxAge.Int64
+		}
 
 		if mimeTypesJSON.Valid {
 			json.Unmarshal([]byte(mimeTypesJSON.String), &bucket.AllowedMimeTypes)
@@ -183,7 +217,7 @@ func (s *Service) ListBuckets(limit, offset int, search string) ([]Bucket, error
 		buckets = []Bucket{}
 	}
 
-	return buckets, nil
+	return buckets, total, nil
 }
 
 // UpdateBucket updates a bucket's configuration.
@@ -193,6 +227,9 @@ func (s *Service) UpdateBucket(id string, req UpdateBucketRequest) (*Bucket, err
 	if err != nil {
 		return nil, err
 	}
+	if err := validateCacheMaxAge(req.CacheMaxAge); err != nil {
+		return nil, err
+	}
 
 	now := Now()
 
@@ -224,6 +261,12 @@ func (s *Service) UpdateBucket(id string, req UpdateBucketRequest) (*Bucket, err
 		bucket.AllowedMimeTypes = req.AllowedMimeTypes
 	}
 
+	if req.CacheMaxAge != nil {
+		updates = append(updates, "cache_max_age = ?")
+		args = append(args, *req.CacheMaxAge)
+		bucket.CacheMaxAge = req.CacheMaxAge
+	}
+
 	args = append(args, id)
 
 	query := fmt.Sprintf("UPDATE storage_buckets SET %s WHERE id = ?", joinStrings(updates, ", "))
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
@@ -20,18 +22,43 @@ type Handler struct {
 	rlsEnforcer *rls.Enforcer
 	jwtSecret   string
 	tusHandler  *TUSHandler
+
+	keyMu      sync.RWMutex
+	signingKey string
+
+	publicLimiter *rateLimiter
 }
 
 // NewHandler creates a new storage handler.
 func NewHandler(service *Service) *Handler {
-	return &Handler{service: service}
+	return &Handler{service: service, publicLimiter: newRateLimiter(publicListLimit, publicListWindow)}
 }
 
-// SetJWTSecret sets the JWT secret for signed URL generation.
+// SetJWTSecret sets the JWT secret, used to sign URLs until SetSigningKey is called.
 func (h *Handler) SetJWTSecret(secret string) {
 	h.jwtSecret = secret
 }
 
+// SetSigningKey sets the key signing storage URLs, separate from the JWT
+// secret so it can be rotated without invalidating user sessions. Signed URLs
+// issued under the previous key stop working.
+func (h *Handler) SetSigningKey(key string) {
+	h.keyMu.Lock()
+	defer h.keyMu.Unlock()
+	h.signingKey = key
+}
+
+// urlSigningKey returns the signing key for storage URLs, falling back to the
+// JWT secret when none is set.
+func (h *Handler) urlSigningKey() string {
+	h.keyMu.RLock()
+	defer h.keyMu.RUnlock()
+	if h.signingKey != "" {
+		return h.signingKey
+	}
+	return h.jwtSecret
+}
+
 // EnableTUS initializes and enables TUS resumable uploads.
 // uploadsDir is the directory for temporary upload files.
 func (h *Handler) EnableTUS(uploadsDir string) {
@@ -88,6 +115,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		r.Put("/upload/sign/{bucketName}/*", h.UploadToSignedURL)      // Upload via signed URL
 
 		// Public objects (no auth required for public buckets)
+		r.Get("/public/{bucketName}", h.ListPublicObjects)
 		r.Get("/public/{bucketName}/*", h.GetPublicObject)
 
 		// Authenticated object operations
@@ -332,9 +360,12 @@ func (h *Handler) checkStorageInsertRLS(r *http.Request, bucketName, objectPath,
 func (h *Handler) ListBuckets(w http.ResponseWriter, r *http.Request) {
 	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
 	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
-	search := r.URL.Query().Get("search")
+	req := ListBucketsRequest{Limit: limit, Offset: offset, Search: r.URL.Query().Get("search")}
+	if column := r.URL.Query().Get("sortColumn"); column != "" {
+		req.SortBy = &SortByOptions{Column: column, Order: r.URL.Query().Get("sortOrder")}
+	}
 
-	buckets, err := h.service.ListBuckets(limit, offset, search)
+	buckets, _, err := h.service.ListBuckets(req)
 	if err != nil {
 		h.jsonError(w, err)
 		return
@@ -575,25 +606,48 @@ func (h *Handler) GetObject(w http.ResponseWriter, r *http.Request) {
 	io.Copy(w, reader)
 }
 
-// GetPublicObject downloads a file from a public bucket.
+// GetPublicObject downloads a file from a public bucket. Responses carry the
+// bucket's Cache-Control and the object's ETag so browsers and CDNs can cache
+// and revalidate them; displayable types are served inline.
 // GET /storage/v1/object/public/{bucketName}/*
 func (h *Handler) GetPublicObject(w http.ResponseWriter, r *http.Request) {
 	bucketName := chi.URLParam(r, "bucketName")
 	objectPath := chi.URLParam(r, "*")
 
 	// Check bucket is public
-	isPublic, err := h.service.IsBucketPublic(bucketName)
+	bucket, err := h.service.GetBucketByName(bucketName)
 	if err != nil {
 		h.jsonError(w, err)
 		return
 	}
-	if !isPublic {
+	if !bucket.Public {
 		h.jsonError(w, &StorageError{StatusCode: 400, ErrorCode: "not_public", Message: "Bucket is not public"})
 		return
 	}
 
+	info, err := h.service.GetObjectInfo(bucketName, objectPath)
+	if err != nil {
+		h.jsonError(w, err)
+		return
+	}
+	etag := quoteETag(info.ETag)
+	w.Header().Set("Cache-Control", bucket.publicCacheControl())
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if t, err := time.Parse(time.RFC3339, info.UpdatedAt); err == nil {
+		w.Header().Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+	}
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	reader, contentType, size, err := h.service.GetObject(bucketName, objectPath)
 	if err != nil {
+		w.Header().Del("Cache-Control")
+		w.Header().Del("ETag")
+		w.Header().Del("Last-Modified")
 		h.jsonError(w, err)
 		return
 	}
@@ -604,11 +658,18 @@ func (h *Handler) GetPublicObject(w http.ResponseWriter, r *http.Request) {
 	if size > 0 {
 		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
 	}
-	w.Header().Set("Cache-Control", "public, max-age=3600")
 
-	// Check for download query param
-	if download := r.URL.Query().Get("download"); download != "" {
-		w.Header().Set("Content-Disposition", "attachment; filename=\""+download+"\"")
+	// The download query param forces an attachment, optionally renamed
+	if download, ok := r.URL.Query()["download"]; ok {
+		filename := objectFilename(objectPath)
+		if len(download) > 0 && download[0] != "" {
+			filename = download[0]
+		}
+		w.Header().Set("Content-Disposition", contentDisposition("attachment", filename))
+	} else if isDisplayable(contentType) {
+		w.Header().Set("Content-Disposition", contentDisposition("inline", objectFilename(objectPath)))
+	} else {
+		w.Header().Set("Content-Disposition", contentDisposition("attachment", objectFilename(objectPath)))
 	}
 
 	io.Copy(w, reader)
@@ -742,7 +803,7 @@ func (h *Handler) CreateSignedURL(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate the signed token
-	token, err := GenerateDownloadToken(bucketName, objectPath, req.ExpiresIn, h.jwtSecret)
+	token, err := GenerateDownloadToken(bucketName, objectPath, req.ExpiresIn, h.urlSigningKey())
 	if err != nil {
 		h.jsonError(w, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: "Failed to generate signed URL"})
 		return
@@ -790,7 +851,7 @@ func (h *Handler) CreateSignedURLs(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Generate token for this file
-		token, err := GenerateDownloadToken(bucketName, path, req.ExpiresIn, h.jwtSecret)
+		token, err := GenerateDownloadToken(bucketName, path, req.ExpiresIn, h.urlSigningKey())
 		if err != nil {
 			errMsg := "Failed to generate signed URL"
 			item.Error = &errMsg
@@ -818,7 +879,7 @@ func (h *Handler) GetSignedObject(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate the token
-	claims, err := ValidateDownloadToken(token, h.jwtSecret)
+	claims, err := ValidateDownloadToken(token, h.urlSigningKey())
 	if err != nil {
 		h.jsonError(w, &StorageError{StatusCode: 401, ErrorCode: "invalid_token", Message: "Invalid or expired token"})
 		return
@@ -870,7 +931,7 @@ func (h *Handler) CreateSignedUploadURL(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Generate the upload token (fixed 2-hour expiry)
-	token, err := GenerateUploadToken(bucketName, objectPath, ownerID, upsert, h.jwtSecret)
+	token, err := GenerateUploadToken(bucketName, objectPath, ownerID, upsert, h.urlSigningKey())
 	if err != nil {
 		h.jsonError(w, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: "Failed to generate signed upload URL"})
 		return
@@ -897,7 +958,7 @@ func (h *Handler) UploadToSignedURL(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Validate the token
-	claims, err := ValidateUploadToken(token, h.jwtSecret)
+	claims, err := ValidateUploadToken(token, h.urlSigningKey())
 	if err != nil {
 		h.jsonError(w, &StorageError{StatusCode: 401, ErrorCode: "invalid_token", Message: "Invalid or expired token"})
 		return
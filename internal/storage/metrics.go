@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"io"
+
+	"github.com/markb/sblite/internal/observability"
+)
+
+// SetTelemetry enables storage operation metrics. A nil telemetry disables them.
+func (s *Service) SetTelemetry(tel *observability.Telemetry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.telemetry = tel
+}
+
+func (s *Service) record(op, bucket string, bytes int64, err error) {
+	s.mu.RLock()
+	tel := s.telemetry
+	s.mu.RUnlock()
+	tel.RecordStorageOperation(op, bucket, bytes, err)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// UploadObject uploads a file to a bucket.
+func (s *Service) UploadObject(bucketName, objectPath string, content io.Reader, size int64, contentType string, ownerID string, upsert bool) (*UploadResponse, error) {
+	counter := &countingReader{r: content}
+	resp, err := s.uploadObject(bucketName, objectPath, counter, size, contentType, ownerID, upsert)
+	s.record(observability.StorageOpUpload, bucketName, counter.n, err)
+	return resp, err
+}
+
+// GetObject retrieves a file from a bucket.
+// Returns the reader, content type, and size.
+func (s *Service) GetObject(bucketName, objectPath string) (io.ReadCloser, string, int64, error) {
+	reader, contentType, size, err := s.getObject(bucketName, objectPath)
+	s.record(observability.StorageOpDownload, bucketName, size, err)
+	return reader, contentType, size, err
+}
+
+// DeleteObject deletes a file from a bucket.
+func (s *Service) DeleteObject(bucketName, objectPath string) error {
+	err := s.deleteObject(bucketName, objectPath)
+	s.record(observability.StorageOpDelete, bucketName, 0, err)
+	return err
+}
+
+// ListObjects lists objects in a bucket with a prefix.
+func (s *Service) ListObjects(bucketName string, req ListObjectsRequest) ([]Object, error) {
+	objects, err := s.listObjects(bucketName, req)
+	s.record(observability.StorageOpList, bucketName, 0, err)
+	return objects, err
+}
+
+// CopyObject copies an object within or between buckets.
+func (s *Service) CopyObject(req CopyObjectRequest, ownerID string) (*UploadResponse, error) {
+	resp, err := s.copyObject(req, ownerID)
+	s.record(observability.StorageOpCopy, req.BucketID, 0, err)
+	return resp, err
+}
+
+// MoveObject moves an object within or between buckets.
+func (s *Service) MoveObject(req MoveObjectRequest, ownerID string) error {
+	err := s.moveObject(req, ownerID)
+	s.record(observability.StorageOpMove, req.BucketID, 0, err)
+	return err
+}
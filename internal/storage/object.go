@@ -12,8 +12,8 @@ import (
 	"strings"
 )
 
-// UploadObject uploads a file to a bucket.
-func (s *Service) UploadObject(bucketName, objectPath string, content io.Reader, size int64, contentType string, ownerID string, upsert bool) (*UploadResponse, error) {
+// uploadObject uploads a file to a bucket.
+func (s *Service) uploadObject(bucketName, objectPath string, content io.Reader, size int64, contentType string, ownerID string, upsert bool) (*UploadResponse, error) {
 	// Get bucket
 	bucket, err := s.GetBucketByName(bucketName)
 	if err != nil {
@@ -86,6 +86,7 @@ func (s *Service) UploadObject(bucketName, objectPath string, content io.Reader,
 			return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to update object: %v", err)}
 		}
 
+		s.enqueueWebhookEvent(WebhookEventUpload, bucketName, objectPath, fileInfo.Size, contentType)
 		return &UploadResponse{
 			ID:  existingID,
 			Key: bucketName + "/" + objectPath,
@@ -104,15 +105,15 @@ func (s *Service) UploadObject(bucketName, objectPath string, content io.Reader,
 		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to create object: %v", err)}
 	}
 
+	s.enqueueWebhookEvent(WebhookEventUpload, bucketName, objectPath, fileInfo.Size, contentType)
 	return &UploadResponse{
 		ID:  id,
 		Key: bucketName + "/" + objectPath,
 	}, nil
 }
 
-// GetObject retrieves a file from a bucket.
-// Returns the reader, content type, and size.
-func (s *Service) GetObject(bucketName, objectPath string) (io.ReadCloser, string, int64, error) {
+// getObject retrieves a file from a bucket.
+func (s *Service) getObject(bucketName, objectPath string) (io.ReadCloser, string, int64, error) {
 	// Get bucket
 	bucket, err := s.GetBucketByName(bucketName)
 	if err != nil {
@@ -205,8 +206,8 @@ func (s *Service) GetObjectInfo(bucketName, objectPath string) (*Object, error)
 	return &obj, nil
 }
 
-// DeleteObject deletes a file from a bucket.
-func (s *Service) DeleteObject(bucketName, objectPath string) error {
+// deleteObject deletes a file from a bucket.
+func (s *Service) deleteObject(bucketName, objectPath string) error {
 	// Get bucket
 	bucket, err := s.GetBucketByName(bucketName)
 	if err != nil {
@@ -217,7 +218,10 @@ func (s *Service) DeleteObject(bucketName, objectPath string) error {
 
 	// Check object exists
 	var id string
-	err = s.db.QueryRow("SELECT id FROM storage_objects WHERE bucket_id = ? AND name = ?", bucket.ID, objectPath).Scan(&id)
+	var size int64
+	var mimeType sql.NullString
+	err = s.db.QueryRow("SELECT id, COALESCE(size, 0), mime_type FROM storage_objects WHERE bucket_id = ? AND name = ?",
+		bucket.ID, objectPath).Scan(&id, &size, &mimeType)
 	if errors.Is(err, sql.ErrNoRows) {
 		return &StorageError{StatusCode: 404, ErrorCode: "not_found", Message: "Object not found"}
 	} else if err != nil {
@@ -236,6 +240,7 @@ func (s *Service) DeleteObject(bucketName, objectPath string) error {
 		return &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to delete object: %v", err)}
 	}
 
+	s.enqueueWebhookEvent(WebhookEventDelete, bucketName, objectPath, size, mimeType.String)
 	return nil
 }
 
@@ -250,8 +255,8 @@ func (s *Service) DeleteObjects(bucketName string, paths []string) []error {
 	return errors
 }
 
-// ListObjects lists objects in a bucket with a prefix.
-func (s *Service) ListObjects(bucketName string, req ListObjectsRequest) ([]Object, error) {
+// listObjects lists objects in a bucket with a prefix.
+func (s *Service) listObjects(bucketName string, req ListObjectsRequest) ([]Object, error) {
 	// Get bucket
 	bucket, err := s.GetBucketByName(bucketName)
 	if err != nil {
@@ -356,8 +361,8 @@ func (s *Service) ListObjects(bucketName string, req ListObjectsRequest) ([]Obje
 	return objects, nil
 }
 
-// CopyObject copies an object within or between buckets.
-func (s *Service) CopyObject(req CopyObjectRequest, ownerID string) (*UploadResponse, error) {
+// copyObject copies an object within or between buckets.
+func (s *Service) copyObject(req CopyObjectRequest, ownerID string) (*UploadResponse, error) {
 	// Get source bucket
 	srcBucket, err := s.GetBucket(req.BucketID)
 	if err != nil {
@@ -421,10 +426,10 @@ func (s *Service) CopyObject(req CopyObjectRequest, ownerID string) (*UploadResp
 	}, nil
 }
 
-// MoveObject moves an object within or between buckets.
-func (s *Service) MoveObject(req MoveObjectRequest, ownerID string) error {
+// moveObject moves an object within or between buckets.
+func (s *Service) moveObject(req MoveObjectRequest, ownerID string) error {
 	// Copy first
-	_, err := s.CopyObject(CopyObjectRequest{
+	_, err := s.copyObject(CopyObjectRequest{
 		BucketID:          req.BucketID,
 		SourceKey:         req.SourceKey,
 		DestinationBucket: req.DestinationBucket,
@@ -442,7 +447,7 @@ func (s *Service) MoveObject(req MoveObjectRequest, ownerID string) error {
 	}
 
 	// Delete source
-	return s.DeleteObject(srcBucket.Name, req.SourceKey)
+	return s.deleteObject(srcBucket.Name, req.SourceKey)
 }
 
 // DetectContentType detects the content type of a file.
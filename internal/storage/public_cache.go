@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"mime"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// DefaultCacheMaxAge is how long, in seconds, browsers and CDNs may cache a
+// public download when the bucket doesn't set cache_max_age.
+const DefaultCacheMaxAge = 3600
+
+// maxCacheMaxAge caps cache_max_age at a year, the longest lifetime caches
+// honour.
+const maxCacheMaxAge = 365 * 24 * 3600
+
+func validateCacheMaxAge(v *int64) error {
+	if v != nil && (*v < 0 || *v > maxCacheMaxAge) {
+		return &StorageError{StatusCode: 400, ErrorCode: "invalid_cache_max_age",
+			Message: "cache_max_age must be between 0 and " + strconv.Itoa(maxCacheMaxAge) + " seconds"}
+	}
+	return nil
+}
+
+// publicCacheControl returns the Cache-Control header for public downloads
+// from the bucket. A max age of 0 makes caches revalidate with the ETag on
+// every use.
+func (b *Bucket) publicCacheControl() string {
+	maxAge := int64(DefaultCacheMaxAge)
+	if b.CacheMaxAge != nil {
+		maxAge = *b.CacheMaxAge
+	}
+	if maxAge == 0 {
+		return "public, no-cache"
+	}
+	return "public, max-age=" + strconv.FormatInt(maxAge, 10)
+}
+
+// isDisplayable reports whether browsers render the content type themselves,
+// so it can be served inline rather than as a download. HTML and SVG are
+// left out since inline they would run scripts on this origin.
+func isDisplayable(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "image/svg+xml":
+		return false
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"):
+		return true
+	}
+	switch mediaType {
+	case "text/plain", "text/css", "text/csv", "application/json", "application/pdf":
+		return true
+	}
+	return false
+}
+
+// contentDisposition builds a Content-Disposition header value with a
+// properly escaped filename.
+func contentDisposition(disposition, filename string) string {
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": filename}); v != "" {
+		return v
+	}
+	return disposition
+}
+
+// quoteETag returns the object's etag as a strong HTTP entity tag.
+func quoteETag(etag string) string {
+	if etag == "" || strings.HasPrefix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// objectFilename is the last path segment of an object name.
+func objectFilename(objectPath string) string {
+	return path.Base(objectPath)
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestGetPublicObjectCacheHeaders(t *testing.T) {
+	svc := setupWebhookService(t)
+	if _, err := svc.CreateBucket(CreateBucketRequest{Name: "site", Public: true}, ""); err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	if _, err := svc.UploadObject("site", "img/logo.png", strings.NewReader("png"), 3, "image/png", "", false); err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+	if _, err := svc.UploadObject("site", "page.html", strings.NewReader("<p>"), 3, "text/html", "", false); err != nil {
+		t.Fatalf("failed to upload: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Route("/storage/v1", NewHandler(svc).RegisterRoutes)
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/storage/v1/object/public/site/img/logo.png", "")
+	if w.Code != http.StatusOK || w.Body.String() != "png" {
+		t.Fatalf("expected 200 with body, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=3600" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+	if got := w.Header().Get("Content-Disposition"); got != `inline; filename=logo.png` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	etag := w.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) || w.Header().Get("Last-Modified") == "" {
+		t.Fatalf("expected ETag and Last-Modified, got %q/%q", etag, w.Header().Get("Last-Modified"))
+	}
+
+	// Revalidation
+	if w := get("/storage/v1/object/public/site/img/logo.png", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("expected 304 with empty body, got %d", w.Code)
+	}
+	if w := get("/storage/v1/object/public/site/img/logo.png", `"other"`); w.Code != http.StatusOK {
+		t.Errorf("expected 200 for a stale ETag, got %d", w.Code)
+	}
+
+	// HTML is never inline; download forces an attachment
+	if got := get("/storage/v1/object/public/site/page.html", "").Header().Get("Content-Disposition"); got != `attachment; filename=page.html` {
+		t.Errorf("unexpected Content-Disposition for HTML %q", got)
+	}
+	if got := get("/storage/v1/object/public/site/img/logo.png?download=brand.png", "").Header().Get("Content-Disposition"); got != `attachment; filename=brand.png` {
+		t.Errorf("unexpected Content-Disposition for download %q", got)
+	}
+
+	// Per-bucket max age
+	day := int64(86400)
+	if _, err := svc.UpdateBucket("site", UpdateBucketRequest{CacheMaxAge: &day}); err != nil {
+		t.Fatalf("UpdateBucket failed: %v", err)
+	}
+	if got := get("/storage/v1/object/public/site/img/logo.png", "").Header().Get("Cache-Control"); got != "public, max-age=86400" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+	zero := int64(0)
+	svc.UpdateBucket("site", UpdateBucketRequest{CacheMaxAge: &zero})
+	if got := get("/storage/v1/object/public/site/img/logo.png", "").Header().Get("Cache-Control"); got != "public, no-cache" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+
+	negative := int64(-1)
+	if _, err := svc.UpdateBucket("site", UpdateBucketRequest{CacheMaxAge: &negative}); err == nil {
+		t.Error("expected a negative cache_max_age to be rejected")
+	}
+	if b, _ := svc.GetBucket("site"); b.CacheMaxAge == nil || *b.CacheMaxAge != 0 {
+		t.Errorf("expected cache_max_age 0 to be stored, got %v", b.CacheMaxAge)
+	}
+}
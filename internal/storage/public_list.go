@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	// publicListLimit is how many public listings one client IP may request
+	// per publicListWindow.
+	publicListLimit  = 60
+	publicListWindow = time.Minute
+	// publicListMaxObjects caps the limit parameter of a public listing.
+	publicListMaxObjects = 1000
+)
+
+// rateLimiter counts requests per client IP in fixed windows.
+type rateLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	counts map[string]*rateWindow
+	now    func() time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{limit: limit, window: window, counts: make(map[string]*rateWindow), now: time.Now}
+}
+
+// allow counts a request from ip and returns how long it must wait, 0 if it
+// is within the limit.
+func (l *rateLimiter) allow(ip string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, w := range l.counts {
+		if now.Sub(w.start) >= l.window {
+			delete(l.counts, key)
+		}
+	}
+	w, ok := l.counts[ip]
+	if !ok {
+		w = &rateWindow{start: now}
+		l.counts[ip] = w
+	}
+	if w.count >= l.limit {
+		return w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return 0
+}
+
+// clientIP returns the request's remote IP. X-Forwarded-For is ignored since
+// clients can set it to dodge the limit.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// ListPublicObjects lists the objects of a public bucket without
+// authentication. The prefix, search, limit, offset, sortBy and order query
+// parameters mean the same as the fields of the authenticated list body;
+// limit is capped at publicListMaxObjects.
+// Private and missing buckets both return 404, and owners are left out.
+// GET /storage/v1/object/public/{bucketName}
+func (h *Handler) ListPublicObjects(w http.ResponseWriter, r *http.Request) {
+	if wait := h.publicLimiter.allow(clientIP(r)); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds()+0.999)))
+		h.jsonError(w, &StorageError{StatusCode: http.StatusTooManyRequests, ErrorCode: "too_many_requests", Message: "Too many requests"})
+		return
+	}
+
+	bucketName := chi.URLParam(r, "bucketName")
+	isPublic, err := h.service.IsBucketPublic(bucketName)
+	if err != nil || !isPublic {
+		h.jsonError(w, &StorageError{StatusCode: http.StatusNotFound, ErrorCode: "not_found", Message: "Bucket not found"})
+		return
+	}
+
+	q := r.URL.Query()
+	req := ListObjectsRequest{
+		Prefix: q.Get("prefix"),
+		Search: q.Get("search"),
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			h.jsonError(w, &StorageError{StatusCode: http.StatusBadRequest, ErrorCode: "invalid_request", Message: "limit must be a non-negative integer"})
+			return
+		}
+		req.Limit = min(limit, publicListMaxObjects)
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			h.jsonError(w, &StorageError{StatusCode: http.StatusBadRequest, ErrorCode: "invalid_request", Message: "offset must be a non-negative integer"})
+			return
+		}
+		req.Offset = offset
+	}
+	if v := q.Get("sortBy"); v != "" {
+		req.SortBy = &SortByOptions{Column: v, Order: q.Get("order")}
+	}
+
+	objects, err := h.service.ListObjects(bucketName, req)
+	if err != nil {
+		h.jsonError(w, err)
+		return
+	}
+	for i := range objects {
+		objects[i].Owner = ""
+		objects[i].OwnerID = ""
+	}
+	if objects == nil {
+		objects = []Object{}
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	h.jsonResponse(w, http.StatusOK, objects)
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestListPublicObjects(t *testing.T) {
+	svc := setupWebhookService(t)
+	if _, err := svc.CreateBucket(CreateBucketRequest{Name: "site", Public: true}, ""); err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	for _, name := range []string{"index.html", "assets/app.js", "assets/app.css"} {
+		if _, err := svc.UploadObject("site", name, strings.NewReader("x"), 1, "text/plain", "user-1", false); err != nil {
+			t.Fatalf("failed to upload %s: %v", name, err)
+		}
+	}
+
+	h := NewHandler(svc)
+	r := chi.NewRouter()
+	r.Route("/storage/v1", h.RegisterRoutes)
+
+	list := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := list("/storage/v1/object/public/site?prefix=assets/")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	// As in the authenticated list, names are relative to the prefix
+	var objects []Object
+	if err := json.Unmarshal(w.Body.Bytes(), &objects); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(objects) != 2 || objects[0].Name != "app.css" || objects[1].Name != "app.js" {
+		t.Fatalf("unexpected objects: %+v", objects)
+	}
+	if objects[0].OwnerID != "" || objects[0].Owner != "" {
+		t.Errorf("expected owner to be omitted, got %q/%q", objects[0].Owner, objects[0].OwnerID)
+	}
+
+	if w := list("/storage/v1/object/public/site?limit=1&sortBy=name&order=desc"); !strings.Contains(w.Body.String(), "index.html") || strings.Contains(w.Body.String(), "assets/") {
+		t.Errorf("expected only index.html, got %s", w.Body.String())
+	}
+
+	for _, query := range []string{"limit=-1", "limit=abc", "offset=-5", "offset=1x"} {
+		if w := list("/storage/v1/object/public/site?" + query); w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, w.Code)
+		}
+	}
+	if w := list("/storage/v1/object/public/site?limit=1000000"); w.Code != http.StatusOK {
+		t.Errorf("expected an oversized limit to be capped, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Private and missing buckets look the same
+	if w := list("/storage/v1/object/public/media"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for private bucket, got %d", w.Code)
+	}
+	if w := list("/storage/v1/object/public/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing bucket, got %d", w.Code)
+	}
+
+	// Downloads still work next to the listing route
+	if w := list("/storage/v1/object/public/site/index.html"); w.Code != http.StatusOK || w.Body.String() != "x" {
+		t.Errorf("expected object download, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	if l.allow("a") != 0 || l.allow("a") != 0 {
+		t.Fatal("expected the first two requests to be allowed")
+	}
+	if wait := l.allow("a"); wait != time.Minute {
+		t.Errorf("expected a one minute wait, got %v", wait)
+	}
+	if l.allow("b") != 0 {
+		t.Error("expected other IPs to have their own limit")
+	}
+
+	now = now.Add(time.Minute)
+	if l.allow("a") != 0 {
+		t.Error("expected the limit to reset after the window")
+	}
+}
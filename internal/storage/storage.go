@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"sync"
 
+	"github.com/markb/sblite/internal/observability"
 	"github.com/markb/sblite/internal/storage/backend"
 )
 
@@ -15,6 +16,7 @@ type Service struct {
 	ctx        context.Context
 	mu         sync.RWMutex
 	tusService *TUSService
+	telemetry  *observability.Telemetry
 }
 
 // Config holds configuration for the storage service.
@@ -87,9 +89,10 @@ func (s *Service) Close() error {
 // WithContext returns a copy of the service with the given context.
 func (s *Service) WithContext(ctx context.Context) *Service {
 	return &Service{
-		db:      s.db,
-		backend: s.backend,
-		ctx:     ctx,
+		db:        s.db,
+		backend:   s.backend,
+		ctx:       ctx,
+		telemetry: s.telemetry,
 	}
 }
 
@@ -17,6 +17,7 @@ type Bucket struct {
 	Public           bool     `json:"public"`
 	FileSizeLimit    *int64   `json:"file_size_limit,omitempty"`
 	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"`
+	CacheMaxAge      *int64   `json:"cache_max_age,omitempty"` // seconds public downloads may be cached (nil = DefaultCacheMaxAge)
 	CreatedAt        string   `json:"created_at"`
 	UpdatedAt        string   `json:"updated_at"`
 }
@@ -28,6 +29,7 @@ type CreateBucketRequest struct {
 	Public           bool     `json:"public,omitempty"`
 	FileSizeLimit    *int64   `json:"file_size_limit,omitempty"`
 	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"`
+	CacheMaxAge      *int64   `json:"cache_max_age,omitempty"`
 }
 
 // UpdateBucketRequest is the request body for updating a bucket.
@@ -35,6 +37,7 @@ type UpdateBucketRequest struct {
 	Public           *bool    `json:"public,omitempty"`
 	FileSizeLimit    *int64   `json:"file_size_limit,omitempty"`
 	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"`
+	CacheMaxAge      *int64   `json:"cache_max_age,omitempty"`
 }
 
 // Object represents a stored file's metadata.
@@ -57,6 +60,14 @@ type Object struct {
 	UpdatedAt      string            `json:"updated_at"`
 }
 
+// ListBucketsRequest selects a page of buckets.
+type ListBucketsRequest struct {
+	Limit  int
+	Offset int
+	Search string         // substring of the bucket name
+	SortBy *SortByOptions // name (default) or created_at
+}
+
 // ListObjectsRequest is the request body for listing objects.
 type ListObjectsRequest struct {
 	Prefix string         `json:"prefix"`
@@ -0,0 +1,405 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/markb/sblite/internal/log"
+)
+
+// Storage events delivered to webhooks.
+const (
+	WebhookEventUpload = "upload"
+	WebhookEventDelete = "delete"
+)
+
+const (
+	// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body,
+	// keyed with the webhook's secret.
+	WebhookSignatureHeader = "X-Sblite-Signature"
+	// WebhookDeliveryHeader carries the outbox id, which stays the same across
+	// retries so receivers can deduplicate.
+	WebhookDeliveryHeader = "X-Sblite-Delivery"
+	// webhookMaxAttempts is how many times an event is attempted before it is
+	// marked failed.
+	webhookMaxAttempts = 5
+	// webhookBatchSize bounds how many outbox entries one dispatch pass sends.
+	webhookBatchSize = 50
+)
+
+// webhookRetryDelay is the delay before the first retry; it doubles after
+// each failure. It is a variable so tests can shorten it.
+var webhookRetryDelay = 10 * time.Second
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// Webhook is an endpoint notified of storage events.
+type Webhook struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Bucket string `json:"bucket,omitempty"` // empty matches every bucket
+	// Events lists the operations delivered; empty means all of them.
+	Events  []string `json:"events"`
+	Secret  string   `json:"secret,omitempty"` // only returned on creation
+	Enabled bool     `json:"enabled"`
+	// CreatedAt and UpdatedAt are RFC 3339 timestamps.
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// WebhookRequest is the request body for creating or updating a webhook.
+// Omitted fields keep their current value on update.
+type WebhookRequest struct {
+	URL     *string  `json:"url,omitempty"`
+	Bucket  *string  `json:"bucket,omitempty"`
+	Events  []string `json:"events,omitempty"`
+	Enabled *bool    `json:"enabled,omitempty"`
+}
+
+// WebhookEvent is the JSON body POSTed to a webhook.
+type WebhookEvent struct {
+	Operation   string `json:"operation"`
+	Bucket      string `json:"bucket"`
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type,omitempty"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// WebhookDelivery is an outbox entry for one event and webhook.
+type WebhookDelivery struct {
+	ID          int64           `json:"id"`
+	WebhookID   string          `json:"webhook_id"`
+	Operation   string          `json:"operation"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"` // pending, delivered or failed
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	NextAttempt string          `json:"next_attempt_at"`
+	CreatedAt   string          `json:"created_at"`
+	DeliveredAt string          `json:"delivered_at,omitempty"`
+}
+
+// SignWebhookPayload returns the hex-encoded HMAC-SHA256 of body keyed with secret.
+func SignWebhookPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhook checks a webhook's URL, bucket and events.
+func (s *Service) validateWebhook(hook *Webhook) error {
+	u, err := url.Parse(hook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &StorageError{StatusCode: 400, ErrorCode: "invalid_url", Message: "Webhook URL must be an absolute http or https URL"}
+	}
+	for _, ev := range hook.Events {
+		if ev != WebhookEventUpload && ev != WebhookEventDelete {
+			return &StorageError{StatusCode: 400, ErrorCode: "invalid_event", Message: fmt.Sprintf("Unknown event %q, expected upload or delete", ev)}
+		}
+	}
+	if hook.Bucket != "" {
+		if _, err := s.GetBucketByName(hook.Bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListWebhooks returns all storage webhooks without their secrets.
+func (s *Service) ListWebhooks() ([]Webhook, error) {
+	rows, err := s.db.Query(`
+		SELECT id, url, COALESCE(bucket, ''), events, enabled, created_at, updated_at
+		FROM _storage_webhooks ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to list webhooks: %v", err)}
+	}
+	defer rows.Close()
+
+	hooks := []Webhook{}
+	for rows.Next() {
+		hook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to scan webhook: %v", err)}
+		}
+		hooks = append(hooks, *hook)
+	}
+	return hooks, rows.Err()
+}
+
+// GetWebhook returns a storage webhook without its secret.
+func (s *Service) GetWebhook(id string) (*Webhook, error) {
+	row := s.db.QueryRow(`
+		SELECT id, url, COALESCE(bucket, ''), events, enabled, created_at, updated_at
+		FROM _storage_webhooks WHERE id = ?
+	`, id)
+	hook, err := scanWebhook(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &StorageError{StatusCode: 404, ErrorCode: "not_found", Message: "Webhook not found"}
+	} else if err != nil {
+		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to get webhook: %v", err)}
+	}
+	return hook, nil
+}
+
+func scanWebhook(row interface{ Scan(...any) error }) (*Webhook, error) {
+	var hook Webhook
+	var events string
+	if err := row.Scan(&hook.ID, &hook.URL, &hook.Bucket, &events, &hook.Enabled, &hook.CreatedAt, &hook.UpdatedAt); err != nil {
+		return nil, err
+	}
+	hook.Events = []string{}
+	json.Unmarshal([]byte(events), &hook.Events)
+	return &hook, nil
+}
+
+// CreateWebhook registers a webhook and generates its signing secret, which
+// is only returned here.
+func (s *Service) CreateWebhook(req WebhookRequest) (*Webhook, error) {
+	hook := &Webhook{Events: req.Events, Enabled: true}
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Bucket != nil {
+		hook.Bucket = *req.Bucket
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+	if err := s.validateWebhook(hook); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to generate secret: %v", err)}
+	}
+	hook.ID = generateUUID()
+	hook.Secret = hex.EncodeToString(secret)
+	hook.CreatedAt = Now()
+	hook.UpdatedAt = hook.CreatedAt
+
+	_, err := s.db.Exec(`
+		INSERT INTO _storage_webhooks (id, url, bucket, events, secret, enabled, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, hook.ID, hook.URL, nilIfEmpty(hook.Bucket), MarshalJSONString(hook.Events), hook.Secret, hook.Enabled, hook.CreatedAt, hook.UpdatedAt)
+	if err != nil {
+		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to create webhook: %v", err)}
+	}
+	return hook, nil
+}
+
+// UpdateWebhook changes the fields set in req.
+func (s *Service) UpdateWebhook(id string, req WebhookRequest) (*Webhook, error) {
+	hook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		hook.URL = *req.URL
+	}
+	if req.Bucket != nil {
+		hook.Bucket = *req.Bucket
+	}
+	if req.Events != nil {
+		hook.Events = req.Events
+	}
+	if req.Enabled != nil {
+		hook.Enabled = *req.Enabled
+	}
+	if err := s.validateWebhook(hook); err != nil {
+		return nil, err
+	}
+	hook.UpdatedAt = Now()
+
+	_, err = s.db.Exec(`
+		UPDATE _storage_webhooks SET url = ?, bucket = ?, events = ?, enabled = ?, updated_at = ?
+		WHERE id = ?
+	`, hook.URL, nilIfEmpty(hook.Bucket), MarshalJSONString(hook.Events), hook.Enabled, hook.UpdatedAt, id)
+	if err != nil {
+		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to update webhook: %v", err)}
+	}
+	return hook, nil
+}
+
+// DeleteWebhook removes a webhook and its queued deliveries.
+func (s *Service) DeleteWebhook(id string) error {
+	result, err := s.db.Exec(`DELETE FROM _storage_webhooks WHERE id = ?`, id)
+	if err != nil {
+		return &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to delete webhook: %v", err)}
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return &StorageError{StatusCode: 404, ErrorCode: "not_found", Message: "Webhook not found"}
+	}
+	s.db.Exec(`DELETE FROM _storage_webhook_outbox WHERE webhook_id = ?`, id)
+	return nil
+}
+
+// ListWebhookDeliveries returns a webhook's most recent outbox entries.
+func (s *Service) ListWebhookDeliveries(id string, limit int) ([]WebhookDelivery, error) {
+	if _, err := s.GetWebhook(id); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.Query(`
+		SELECT id, webhook_id, operation, payload, status, attempts, COALESCE(last_error, ''),
+			next_attempt_at, created_at, COALESCE(delivered_at, '')
+		FROM _storage_webhook_outbox WHERE webhook_id = ?
+		ORDER BY id DESC LIMIT ?
+	`, id, limit)
+	if err != nil {
+		return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to list deliveries: %v", err)}
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		var payload string
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Operation, &payload, &d.Status, &d.Attempts, &d.LastError,
+			&d.NextAttempt, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, &StorageError{StatusCode: 500, ErrorCode: "internal", Message: fmt.Sprintf("Failed to scan delivery: %v", err)}
+		}
+		d.Payload = json.RawMessage(payload)
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// enqueueWebhookEvent adds an event to the outbox of every enabled webhook
+// subscribed to it. Failures are logged rather than returned so a webhook
+// problem never fails the storage operation itself.
+func (s *Service) enqueueWebhookEvent(operation, bucketName, objectPath string, size int64, contentType string) {
+	now := Now()
+	payload := MarshalJSONString(WebhookEvent{
+		Operation:   operation,
+		Bucket:      bucketName,
+		Path:        objectPath,
+		Size:        size,
+		ContentType: contentType,
+		Timestamp:   now,
+	})
+	_, err := s.db.Exec(`
+		INSERT INTO _storage_webhook_outbox (webhook_id, operation, payload, next_attempt_at, created_at)
+		SELECT id, ?, ?, ?, ? FROM _storage_webhooks
+		WHERE enabled = 1
+			AND (bucket IS NULL OR bucket = ?)
+			AND (json_array_length(events) = 0 OR EXISTS (SELECT 1 FROM json_each(events) WHERE value = ?))
+	`, operation, payload, now, now, bucketName, operation)
+	if err != nil {
+		log.Warn("failed to enqueue storage webhook event", "operation", operation, "bucket", bucketName, "path", objectPath, "error", err.Error())
+	}
+}
+
+// DeliverWebhooks sends due outbox entries and returns how many were
+// delivered. Failed sends are retried with a doubling delay until
+// webhookMaxAttempts is reached.
+func (s *Service) DeliverWebhooks(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT o.id, o.operation, o.payload, o.attempts, w.url, w.secret
+		FROM _storage_webhook_outbox o JOIN _storage_webhooks w ON w.id = o.webhook_id
+		WHERE o.status = 'pending' AND o.next_attempt_at <= ?
+		ORDER BY o.id LIMIT ?
+	`, Now(), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	type pending struct {
+		id                 int64
+		operation, payload string
+		attempts           int
+		url, secret        string
+	}
+	var due []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.operation, &p.payload, &p.attempts, &p.url, &p.secret); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, p)
+	}
+	rows.Close()
+
+	delivered := 0
+	for _, p := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		sendErr := sendWebhook(ctx, p.url, p.secret, p.id, p.operation, []byte(p.payload))
+		attempts := p.attempts + 1
+		if sendErr == nil {
+			s.db.Exec(`UPDATE _storage_webhook_outbox SET status = 'delivered', attempts = ?, last_error = NULL, delivered_at = ? WHERE id = ?`,
+				attempts, Now(), p.id)
+			delivered++
+			continue
+		}
+
+		status := "pending"
+		if attempts >= webhookMaxAttempts {
+			status = "failed"
+			log.Warn("storage webhook delivery failed", "delivery", p.id, "url", p.url, "attempts", attempts, "error", sendErr.Error())
+		}
+		next := time.Now().UTC().Add(webhookRetryDelay << (attempts - 1)).Format(time.RFC3339)
+		s.db.Exec(`UPDATE _storage_webhook_outbox SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+			status, attempts, sendErr.Error(), next, p.id)
+	}
+	return delivered, nil
+}
+
+// sendWebhook POSTs one signed event.
+func sendWebhook(ctx context.Context, webhookURL, secret string, deliveryID int64, operation string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(secret, body))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(deliveryID, 10))
+	req.Header.Set("X-Sblite-Event", "storage."+operation)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// StartWebhookDispatcher starts a background routine that delivers queued
+// storage events every interval.
+func (s *Service) StartWebhookDispatcher(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.DeliverWebhooks(ctx); err != nil && ctx.Err() == nil {
+					log.Warn("storage webhook dispatch failed", "error", err.Error())
+				}
+			}
+		}
+	}()
+}
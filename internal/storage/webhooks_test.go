@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/markb/sblite/internal/db"
+)
+
+func setupWebhookService(t *testing.T) *Service {
+	t.Helper()
+	dir := t.TempDir()
+	database, err := db.New(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := database.RunMigrations(); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	svc, err := NewService(database.DB, Config{Backend: "local", LocalPath: filepath.Join(dir, "storage")})
+	if err != nil {
+		t.Fatalf("failed to create storage service: %v", err)
+	}
+	if _, err := svc.CreateBucket(CreateBucketRequest{Name: "media"}, ""); err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	if _, err := svc.CreateBucket(CreateBucketRequest{Name: "other"}, ""); err != nil {
+		t.Fatalf("failed to create bucket: %v", err)
+	}
+	return svc
+}
+
+func TestStorageWebhookDeliversSignedEvents(t *testing.T) {
+	svc := setupWebhookService(t)
+
+	var events []WebhookEvent
+	var signatures []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var ev WebhookEvent
+		json.Unmarshal(body, &ev)
+		events = append(events, ev)
+		signatures = append(signatures, r.Header.Get(WebhookSignatureHeader))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	bucket := "media"
+	hook, err := svc.CreateWebhook(WebhookRequest{URL: &srv.URL, Bucket: &bucket})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if hook.Secret == "" {
+		t.Fatal("expected a signing secret on creation")
+	}
+
+	if _, err := svc.UploadObject("media", "photos/cat.png", strings.NewReader("png-bytes"), 9, "image/png", "", false); err != nil {
+		t.Fatalf("UploadObject failed: %v", err)
+	}
+	if _, err := svc.UploadObject("other", "ignored.txt", strings.NewReader("x"), 1, "text/plain", "", false); err != nil {
+		t.Fatalf("UploadObject failed: %v", err)
+	}
+	if err := svc.DeleteObject("media", "photos/cat.png"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	delivered, err := svc.DeliverWebhooks(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverWebhooks failed: %v", err)
+	}
+	if delivered != 2 || len(events) != 2 {
+		t.Fatalf("expected 2 events for the media bucket, got %d delivered: %+v", delivered, events)
+	}
+
+	upload := events[0]
+	if upload.Operation != WebhookEventUpload || upload.Bucket != "media" || upload.Path != "photos/cat.png" ||
+		upload.Size != 9 || upload.ContentType != "image/png" {
+		t.Errorf("unexpected upload event: %+v", upload)
+	}
+	if events[1].Operation != WebhookEventDelete || events[1].Size != 9 {
+		t.Errorf("unexpected delete event: %+v", events[1])
+	}
+
+	body, _ := json.Marshal(upload)
+	if signatures[0] != "sha256="+SignWebhookPayload(hook.Secret, body) {
+		t.Errorf("signature does not match payload")
+	}
+
+	deliveries, err := svc.ListWebhookDeliveries(hook.ID, 10)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	for _, d := range deliveries {
+		if d.Status != "delivered" || d.Attempts != 1 {
+			t.Errorf("expected delivered after one attempt, got %+v", d)
+		}
+	}
+}
+
+func TestStorageWebhookRetriesThenFails(t *testing.T) {
+	svc := setupWebhookService(t)
+	oldDelay := webhookRetryDelay
+	webhookRetryDelay = 0
+	defer func() { webhookRetryDelay = oldDelay }()
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	hook, err := svc.CreateWebhook(WebhookRequest{URL: &srv.URL, Events: []string{WebhookEventUpload}})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	if _, err := svc.UploadObject("media", "a.txt", strings.NewReader("a"), 1, "text/plain", "", false); err != nil {
+		t.Fatalf("UploadObject failed: %v", err)
+	}
+	// Deletes are not subscribed
+	if err := svc.DeleteObject("media", "a.txt"); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	for i := 0; i < webhookMaxAttempts+2; i++ {
+		if _, err := svc.DeliverWebhooks(context.Background()); err != nil {
+			t.Fatalf("DeliverWebhooks failed: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if int(attempts.Load()) != webhookMaxAttempts {
+		t.Errorf("expected %d attempts, got %d", webhookMaxAttempts, attempts.Load())
+	}
+
+	deliveries, err := svc.ListWebhookDeliveries(hook.ID, 10)
+	if err != nil {
+		t.Fatalf("ListWebhookDeliveries failed: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != "failed" || deliveries[0].LastError == "" {
+		t.Errorf("expected one failed delivery, got %+v", deliveries)
+	}
+}
+
+func TestStorageWebhookValidation(t *testing.T) {
+	svc := setupWebhookService(t)
+
+	bad := "ftp://example.com"
+	if _, err := svc.CreateWebhook(WebhookRequest{URL: &bad}); err == nil {
+		t.Error("expected non-http URL to be rejected")
+	}
+	good := "https://example.com/hook"
+	if _, err := svc.CreateWebhook(WebhookRequest{URL: &good, Events: []string{"rename"}}); err == nil {
+		t.Error("expected unknown event to be rejected")
+	}
+	missing := "nope"
+	if _, err := svc.CreateWebhook(WebhookRequest{URL: &good, Bucket: &missing}); err == nil {
+		t.Error("expected unknown bucket to be rejected")
+	}
+
+	hook, err := svc.CreateWebhook(WebhookRequest{URL: &good})
+	if err != nil {
+		t.Fatalf("CreateWebhook failed: %v", err)
+	}
+	disabled := false
+	updated, err := svc.UpdateWebhook(hook.ID, WebhookRequest{Enabled: &disabled})
+	if err != nil || updated.Enabled || updated.URL != good {
+		t.Fatalf("unexpected update result: %+v, %v", updated, err)
+	}
+	if err := svc.DeleteWebhook(hook.ID); err != nil {
+		t.Fatalf("DeleteWebhook failed: %v", err)
+	}
+	if _, err := svc.GetWebhook(hook.ID); err == nil {
+		t.Error("expected deleted webhook to be gone")
+	}
+}
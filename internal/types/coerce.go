@@ -0,0 +1,168 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimestamptzFormat is the storage format for timestamptz values.
+// It matches the output of the now() default: strftime('%Y-%m-%d %H:%M:%f+00', 'now').
+const TimestamptzFormat = "2006-01-02 15:04:05.000+00"
+
+// Coerce converts a decoded JSON value into the representation stored in SQLite
+// for the given PostgreSQL type. Booleans become 0/1, numeric strings become
+// numbers, timestamps are normalized to UTC and JSON objects are serialized.
+// Nil values are returned unchanged (nullability is checked separately).
+// Types without a storage conversion are validated and passed through.
+func Coerce(pgType PgType, value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	switch pgType {
+	case TypeBoolean:
+		return coerceBoolean(value)
+	case TypeInteger:
+		return coerceInteger(value)
+	case TypeNumeric:
+		return coerceNumeric(value)
+	case TypeTimestamptz:
+		return coerceTimestamptz(value)
+	case TypeJSONB:
+		return coerceJSONB(value)
+	case TypeText:
+		// Text columns accept scalars in their textual form
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+		return nil, fmt.Errorf("text must be a string, got %T", value)
+	}
+
+	if err := Validate(pgType, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// coerceBoolean converts bool, 0/1 and common textual forms to 0 or 1.
+func coerceBoolean(value any) (any, error) {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case float64:
+		if v == 0 || v == 1 {
+			return int(v), nil
+		}
+		return nil, fmt.Errorf("boolean number must be 0 or 1, got %v", v)
+	case int:
+		if v == 0 || v == 1 {
+			return v, nil
+		}
+		return nil, fmt.Errorf("boolean integer must be 0 or 1, got %d", v)
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "t", "1", "yes", "on":
+			return 1, nil
+		case "false", "f", "0", "no", "off":
+			return 0, nil
+		}
+		return nil, fmt.Errorf("invalid boolean value: %s", v)
+	}
+	return nil, fmt.Errorf("boolean must be bool or 0/1, got %T", value)
+}
+
+// coerceInteger converts whole numbers and integer strings to int64.
+func coerceInteger(value any) (any, error) {
+	var n int64
+	switch v := value.(type) {
+	case float64:
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("integer cannot have decimal part: %v", v)
+		}
+		n = int64(v)
+	case int:
+		n = int64(v)
+	case int64:
+		n = v
+	case bool:
+		// SQLite stores booleans as integers, so untyped tables may receive them
+		if v {
+			n = 1
+		}
+	case string:
+		parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer format: %s", v)
+		}
+		n = parsed
+	default:
+		return nil, fmt.Errorf("integer must be a number or integer string, got %T", value)
+	}
+
+	if n > math.MaxInt32 || n < math.MinInt32 {
+		return nil, fmt.Errorf("integer out of int32 range: %d", n)
+	}
+	return n, nil
+}
+
+// coerceNumeric converts numeric strings to float64.
+func coerceNumeric(value any) (any, error) {
+	switch v := value.(type) {
+	case float64, int, int64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("invalid numeric format: %s", v)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("numeric must be a number or numeric string, got %T", value)
+}
+
+// coerceTimestamptz parses ISO 8601 timestamps and normalizes them to UTC
+// in TimestamptzFormat. Timestamps without an offset are treated as UTC.
+func coerceTimestamptz(value any) (any, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("timestamptz must be a string, got %T", value)
+	}
+
+	formats := append([]string{TimestamptzFormat, "2006-01-02 15:04:05Z07:00", "2006-01-02 15:04:05", "2006-01-02"}, timestampFormats...)
+	for _, format := range formats {
+		if t, err := time.Parse(format, strings.TrimSpace(s)); err == nil {
+			return t.UTC().Format(TimestamptzFormat), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid timestamptz format: %s", s)
+}
+
+// coerceJSONB serializes objects and arrays to a JSON string.
+func coerceJSONB(value any) (any, error) {
+	switch v := value.(type) {
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		return string(b), nil
+	case string:
+		if err := validateJSONB(v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("jsonb must be a JSON object or array, got %T", value)
+}
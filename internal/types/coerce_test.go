@@ -0,0 +1,47 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestCoerce(t *testing.T) {
+	tests := []struct {
+		name    string
+		pgType  PgType
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{"nil passes through", TypeBoolean, nil, nil, false},
+		{"boolean true", TypeBoolean, true, 1, false},
+		{"boolean false", TypeBoolean, false, 0, false},
+		{"boolean string", TypeBoolean, "true", 1, false},
+		{"boolean number", TypeBoolean, float64(0), 0, false},
+		{"boolean invalid", TypeBoolean, "maybe", nil, true},
+		{"integer from float", TypeInteger, float64(42), int64(42), false},
+		{"integer from string", TypeInteger, "42", int64(42), false},
+		{"integer with decimal", TypeInteger, 4.5, nil, true},
+		{"integer invalid string", TypeInteger, "abc", nil, true},
+		{"numeric from string", TypeNumeric, "3.14", 3.14, false},
+		{"numeric invalid", TypeNumeric, "pi", nil, true},
+		{"timestamptz with offset", TypeTimestamptz, "2024-01-15T12:30:00+02:00", "2024-01-15 10:30:00.000+00", false},
+		{"timestamptz stored form", TypeTimestamptz, "2024-01-15 10:30:00.123+00", "2024-01-15 10:30:00.123+00", false},
+		{"timestamptz invalid", TypeTimestamptz, "yesterday", nil, true},
+		{"jsonb object", TypeJSONB, map[string]any{"a": float64(1)}, `{"a":1}`, false},
+		{"jsonb invalid string", TypeJSONB, "{", nil, true},
+		{"text from number", TypeText, float64(7), "7", false},
+		{"uuid invalid", TypeUUID, "nope", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Coerce(tt.pgType, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Coerce(%s, %v) error = %v, wantErr %v", tt.pgType, tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Coerce(%s, %v) = %v (%T), want %v (%T)", tt.pgType, tt.value, got, got, tt.want, tt.want)
+			}
+		})
+	}
+}
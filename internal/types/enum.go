@@ -0,0 +1,37 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EnumAllows reports whether v is one of the allowed values of an enum
+// column. Non-string scalars are compared by their text form, as SQLite
+// stores them.
+func EnumAllows(allowed []string, v any) bool {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = v
+	case float64, int, int64, bool, json.Number:
+		s = fmt.Sprint(v)
+	default:
+		return false
+	}
+	for _, a := range allowed {
+		if a == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateEnum returns an error if a non-null value is not one of the
+// allowed values.
+func ValidateEnum(allowed []string, v any) error {
+	if v == nil || EnumAllows(allowed, v) {
+		return nil
+	}
+	return fmt.Errorf("must be one of %s, got %v", strings.Join(allowed, ", "), v)
+}
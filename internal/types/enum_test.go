@@ -0,0 +1,32 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestValidateEnum(t *testing.T) {
+	allowed := []string{"active", "inactive", "1", "true"}
+	tests := []struct {
+		name    string
+		value   any
+		wantErr bool
+	}{
+		{"allowed string", "active", false},
+		{"null is not checked", nil, false},
+		{"other string", "deleted", true},
+		{"case matters", "Active", true},
+		{"number by text form", float64(1), false},
+		{"int by text form", 1, false},
+		{"bool by text form", true, false},
+		{"unlisted number", float64(2), true},
+		{"object", map[string]any{"a": "active"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEnum(allowed, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEnum(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ParseJSONSchema decodes and sanity-checks a JSON Schema document.
+// Only the subset understood by ValidateJSONSchema is meaningful.
+func ParseJSONSchema(raw string) (map[string]any, error) {
+	var schema map[string]any
+	if err := json.Unmarshal([]byte(raw), &schema); err != nil {
+		return nil, fmt.Errorf("schema must be a JSON object: %w", err)
+	}
+	if p, ok := schema["pattern"].(string); ok {
+		if _, err := regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	return schema, nil
+}
+
+// ValidateJSONSchema validates a decoded JSON value against a JSON Schema and
+// returns one message per violation (empty when valid). Supported keywords:
+// type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum.
+// For jsonb columns, string values are decoded as JSON first, since jsonb
+// values may be sent as text; strings for other column types are validated
+// as they are.
+func ValidateJSONSchema(schema map[string]any, pgType PgType, value any) []string {
+	if s, ok := value.(string); ok && pgType == TypeJSONB {
+		var decoded any
+		if err := json.Unmarshal([]byte(s), &decoded); err == nil {
+			value = decoded
+		}
+	}
+	var errs []string
+	validateSchemaNode(schema, value, "$", &errs)
+	return errs
+}
+
+func validateSchemaNode(schema map[string]any, value any, path string, errs *[]string) {
+	addErr := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := schema["type"]; ok && !matchesSchemaType(t, value) {
+		addErr("expected type %v, got %s", t, jsonTypeName(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			addErr("value is not one of the allowed values")
+		}
+	}
+	if c, ok := schema["const"]; ok && !jsonEqual(c, value) {
+		addErr("value does not match const")
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		props, _ := schema["properties"].(map[string]any)
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := v[name]; !present {
+						addErr("missing required property %q", name)
+					}
+				}
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if sub, ok := props[k].(map[string]any); ok {
+				validateSchemaNode(sub, v[k], path+"."+k, errs)
+			} else if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+				addErr("unexpected property %q", k)
+			}
+		}
+	case []any:
+		if n, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < n {
+			addErr("expected at least %v items, got %d", n, len(v))
+		}
+		if n, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > n {
+			addErr("expected at most %v items, got %d", n, len(v))
+		}
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateSchemaNode(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema, "minLength"); ok && length < n {
+			addErr("expected length >= %v", n)
+		}
+		if n, ok := schemaNumber(schema, "maxLength"); ok && length > n {
+			addErr("expected length <= %v", n)
+		}
+		if p, ok := schema["pattern"].(string); ok {
+			if re, err := regexp.Compile(p); err == nil && !re.MatchString(v) {
+				addErr("does not match pattern %s", p)
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(schema, "minimum"); ok && v < n {
+			addErr("expected >= %v", n)
+		}
+		if n, ok := schemaNumber(schema, "maximum"); ok && v > n {
+			addErr("expected <= %v", n)
+		}
+	}
+}
+
+// matchesSchemaType checks a value against a "type" keyword (string or list of strings).
+func matchesSchemaType(t any, value any) bool {
+	switch tt := t.(type) {
+	case string:
+		return matchesSingleType(tt, value)
+	case []any:
+		for _, item := range tt {
+			if s, ok := item.(string); ok && matchesSingleType(s, value) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func matchesSingleType(t string, value any) bool {
+	switch t {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	}
+	return jsonTypeName(value) == t
+}
+
+// jsonTypeName returns the JSON Schema type name of a decoded JSON value.
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return strings.ToLower(fmt.Sprintf("%T", value))
+}
+
+func schemaNumber(schema map[string]any, key string) (float64, bool) {
+	n, ok := schema[key].(float64)
+	return n, ok
+}
+
+func jsonEqual(a, b any) bool {
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(ab) == string(bb)
+}
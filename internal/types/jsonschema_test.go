@@ -0,0 +1,74 @@
+package types
+
+import (
+	"testing"
+)
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema, err := ParseJSONSchema(`{
+		"type": "object",
+		"required": ["name"],
+		"additionalProperties": false,
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"role": {"enum": ["admin", "user"]}
+		}
+	}`)
+	if err != nil {
+		t.Fatalf("ParseJSONSchema failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		value     any
+		wantValid bool
+	}{
+		{"valid object", map[string]any{"name": "Ann", "age": float64(3)}, true},
+		{"valid json string", `{"name": "Ann", "tags": ["a"]}`, true},
+		{"missing required", map[string]any{"age": float64(3)}, false},
+		{"wrong type", map[string]any{"name": float64(1)}, false},
+		{"non-integer", map[string]any{"name": "Ann", "age": 1.5}, false},
+		{"below minimum", map[string]any{"name": "Ann", "age": float64(-1)}, false},
+		{"too many items", map[string]any{"name": "Ann", "tags": []any{"a", "b", "c"}}, false},
+		{"bad item type", map[string]any{"name": "Ann", "tags": []any{float64(1)}}, false},
+		{"not in enum", map[string]any{"name": "Ann", "role": "root"}, false},
+		{"additional property", map[string]any{"name": "Ann", "extra": true}, false},
+		{"not an object", []any{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateJSONSchema(schema, TypeJSONB, tt.value)
+			if (len(errs) == 0) != tt.wantValid {
+				t.Errorf("ValidateJSONSchema(%v) errors = %v, wantValid %v", tt.value, errs, tt.wantValid)
+			}
+		})
+	}
+}
+
+func TestParseJSONSchemaInvalid(t *testing.T) {
+	if _, err := ParseJSONSchema(`[1, 2]`); err == nil {
+		t.Error("expected error for non-object schema")
+	}
+	if _, err := ParseJSONSchema(`{"pattern": "("}`); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestValidateJSONSchemaTextColumn(t *testing.T) {
+	schema, err := ParseJSONSchema(`{"type": "string", "pattern": "^[0-9]+$"}`)
+	if err != nil {
+		t.Fatalf("ParseJSONSchema failed: %v", err)
+	}
+
+	// A numeric-looking string in a text column is still a string
+	if errs := ValidateJSONSchema(schema, TypeText, "12345"); len(errs) != 0 {
+		t.Errorf("expected numeric-looking text to be a valid string, got %v", errs)
+	}
+	// In a jsonb column the same text is the JSON number 12345
+	if errs := ValidateJSONSchema(schema, TypeJSONB, "12345"); len(errs) == 0 {
+		t.Error("expected jsonb text 12345 to be decoded as a number")
+	}
+}